@@ -340,7 +340,9 @@ type CallExpression struct {
 	Method         string
 	Arguments      []Expression
 	Block          *BlockStatement
-	BlockArguments []*Identifier
+	BlockArguments []Expression
+	// BlockLocals are the block-local variables declared after the parameters, like `tmp` in `|x; tmp|`
+	BlockLocals []*Identifier
 }
 
 func (tce *CallExpression) expressionNode() {}
@@ -369,12 +371,24 @@ func (tce *CallExpression) String() string {
 		var blockArgs []string
 		out.WriteString(" do")
 
-		if len(tce.BlockArguments) > 0 {
+		if len(tce.BlockArguments) > 0 || len(tce.BlockLocals) > 0 {
 			for _, arg := range tce.BlockArguments {
 				blockArgs = append(blockArgs, arg.String())
 			}
 			out.WriteString(" |")
 			out.WriteString(strings.Join(blockArgs, ", "))
+
+			if len(tce.BlockLocals) > 0 {
+				var locals []string
+
+				for _, local := range tce.BlockLocals {
+					locals = append(locals, local.Value)
+				}
+
+				out.WriteString("; ")
+				out.WriteString(strings.Join(locals, ", "))
+			}
+
 			out.WriteString("|")
 		}
 
@@ -386,9 +400,35 @@ func (tce *CallExpression) String() string {
 	return out.String()
 }
 
+// DestructuringParameter represents a parenthesized block parameter like `(a, b)` in `|(a, b), c|`,
+// which unpacks an array argument into its Parameters.
+// Each of the Parameters is either an Identifier or a nested DestructuringParameter.
+type DestructuringParameter struct {
+	*BaseNode
+	Parameters []Expression
+}
+
+func (dp *DestructuringParameter) expressionNode() {}
+
+// TokenLiteral returns the token literal of the destructuring parameter's opening paren
+func (dp *DestructuringParameter) TokenLiteral() string {
+	return dp.Token.Literal
+}
+func (dp *DestructuringParameter) String() string {
+	var params []string
+
+	for _, param := range dp.Parameters {
+		params = append(params, param.String())
+	}
+
+	return "(" + strings.Join(params, ", ") + ")"
+}
+
 // SelfExpression represents a "self" expression
 type SelfExpression struct {
 	*BaseNode
+	// Implicit is true when it's the omitted receiver of a method call, like `foo(1)`
+	Implicit bool
 }
 
 func (se *SelfExpression) expressionNode() {}
@@ -451,6 +491,8 @@ type RangeExpression struct {
 	*BaseNode
 	Start Expression
 	End   Expression
+	// Exclusive is true for a `...` range, which doesn't include its end
+	Exclusive bool
 }
 
 func (re *RangeExpression) expressionNode() {}
@@ -466,7 +508,7 @@ func (re *RangeExpression) String() string {
 
 	out.WriteString("(")
 	out.WriteString(re.Start.String())
-	out.WriteString("..")
+	out.WriteString(re.Token.Literal)
 	out.WriteString(re.End.String())
 	out.WriteString(")")
 
@@ -43,6 +43,13 @@ func (b *BaseNode) IsCallExpression(t *testing.T) (ce *testableCallExpression) {
 	return
 }
 
+// IsClassVariable fails the test and returns nil by default
+func (b *BaseNode) IsClassVariable(t *testing.T) (cv *testableClassVariable) {
+	t.Helper()
+	t.Fatalf(nodeFailureMsgFormat, "class variable", b)
+	return
+}
+
 // IsConditionalExpression fails the test and returns nil by default
 func (b *BaseNode) IsConditionalExpression(t *testing.T) *testableConditionalExpression {
 	t.Helper()
@@ -151,6 +158,11 @@ func (ce *CallExpression) IsCallExpression(t *testing.T) *testableCallExpression
 	return &testableCallExpression{CallExpression: ce, t: t}
 }
 
+// IsClassVariable returns pointer of the receiver class variable
+func (cv *ClassVariable) IsClassVariable(t *testing.T) *testableClassVariable {
+	return &testableClassVariable{ClassVariable: cv, t: t}
+}
+
 // IsConditionalExpression returns pointer of the receiver conditional expression
 func (ce *ConditionalExpression) IsConditionalExpression(t *testing.T) *testableConditionalExpression {
 	return &testableConditionalExpression{ConditionalExpression: ce, t: t}
@@ -155,9 +155,10 @@ func (ns *NextStatement) String() string {
 	return "next"
 }
 
-// BreakStatement represents "break" keyword
+// BreakStatement represents "break" keyword, with an optional value like `break 10`
 type BreakStatement struct {
 	*BaseNode
+	Value Expression
 }
 
 func (bs *BreakStatement) statementNode() {}
@@ -167,6 +168,10 @@ func (bs *BreakStatement) TokenLiteral() string {
 	return bs.Token.Literal
 }
 func (bs *BreakStatement) String() string {
+	if bs.Value != nil {
+		return bs.TokenLiteral() + " " + bs.Value.String()
+	}
+
 	return bs.TokenLiteral()
 }
 
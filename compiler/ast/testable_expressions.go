@@ -12,6 +12,7 @@ type testableExpression interface {
 	IsAssignExpression(t *testing.T) *testableAssignExpression
 	IsBooleanExpression(t *testing.T) *testableBooleanExpression
 	IsCallExpression(t *testing.T) *testableCallExpression
+	IsClassVariable(t *testing.T) *testableClassVariable
 	IsConditionalExpression(t *testing.T) *testableConditionalExpression
 	IsConstant(t *testing.T) *testableConstant
 	IsHashExpression(t *testing.T) *testableHashExpression
@@ -77,6 +78,11 @@ func (tce *testableCallExpression) NthArgument(n int) testableExpression {
 	return tce.Arguments[n-1].(testableExpression)
 }
 
+// NthBlockArgument returns n-th block parameter of the call expression as TestingExpression
+func (tce *testableCallExpression) NthBlockArgument(n int) testableExpression {
+	return tce.BlockArguments[n-1].(testableExpression)
+}
+
 // ReceiverExpression returns call expression's receiver as TestingExpression
 func (tce *testableCallExpression) TestableReceiver() testableExpression {
 	return tce.Receiver.(testableExpression)
@@ -116,6 +122,19 @@ func (tce *testableConditionalExpression) TestableConsequence() CodeBlock {
 	return tss
 }
 
+type testableClassVariable struct {
+	*ClassVariable
+	t *testing.T
+}
+
+// ShouldHaveName checks if the class variable's name is same as we expected
+func (tcv *testableClassVariable) ShouldHaveName(expectedName string) {
+	if tcv.Value != expectedName {
+		tcv.t.Helper()
+		tcv.t.Fatalf("expect current class variable to be '%s', got '%s'", expectedName, tcv.Value)
+	}
+}
+
 type testableConstant struct {
 	*Constant
 	t *testing.T
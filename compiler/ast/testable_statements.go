@@ -169,7 +169,7 @@ func (tds *testableDefStatement) ShouldHaveSplatParam(expectedName string) {
 	for _, param := range tds.Parameters {
 		p, ok := param.(*PrefixExpression)
 
-		if ok {
+		if ok && p.Operator == "*" {
 			paramName := p.Right.(*Identifier).Value
 			if expectedName == paramName {
 				return
@@ -181,6 +181,23 @@ func (tds *testableDefStatement) ShouldHaveSplatParam(expectedName string) {
 	tds.t.Fatalf("Can't find splat param '%s' in method '%s'", expectedName, tds.Name.Value)
 }
 
+// ShouldHaveDoubleSplatParam checks if the method has expected double splat argument
+func (tds *testableDefStatement) ShouldHaveDoubleSplatParam(expectedName string) {
+	for _, param := range tds.Parameters {
+		p, ok := param.(*PrefixExpression)
+
+		if ok && p.Operator == "**" {
+			paramName := p.Right.(*Identifier).Value
+			if expectedName == paramName {
+				return
+			}
+		}
+	}
+
+	tds.t.Helper()
+	tds.t.Fatalf("Can't find double splat param '%s' in method '%s'", expectedName, tds.Name.Value)
+}
+
 type testableModuleStatement struct {
 	*ModuleStatement
 	t *testing.T
@@ -5,7 +5,7 @@ import (
 	"strings"
 )
 
-// Variable interface represents assignable nodes in Goby, currently are Identifier, InstanceVariable and Constant
+// Variable interface represents assignable nodes in Goby, currently are Identifier, InstanceVariable, ClassVariable and Constant
 type Variable interface {
 	variableNode()
 	ReturnValue() string
@@ -81,6 +81,45 @@ func (iv *InstanceVariable) String() string {
 	return iv.Value
 }
 
+// ClassVariable represents a class variable
+type ClassVariable struct {
+	*BaseNode
+	Value string
+}
+
+func (cv *ClassVariable) variableNode() {}
+
+// ReturnValue is a polymorphic method for returning a value
+func (cv *ClassVariable) ReturnValue() string {
+	return cv.Value
+}
+func (cv *ClassVariable) expressionNode() {}
+
+// TokenLiteral returns the class variable's token literal
+func (cv *ClassVariable) TokenLiteral() string {
+	return cv.Token.Literal
+}
+func (cv *ClassVariable) String() string {
+	return cv.Value
+}
+
+// GlobalVariable represents a global variable. Only the last match `$~` and its groups like `$1` are supported,
+// and they can't be assigned.
+type GlobalVariable struct {
+	*BaseNode
+	Value string
+}
+
+func (gv *GlobalVariable) expressionNode() {}
+
+// TokenLiteral returns the global variable's token literal
+func (gv *GlobalVariable) TokenLiteral() string {
+	return gv.Token.Literal
+}
+func (gv *GlobalVariable) String() string {
+	return gv.Value
+}
+
 // Constant represents a constant that may include namespace
 type Constant struct {
 	*BaseNode
@@ -0,0 +1,90 @@
+package bytecode
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PrettyName returns the instruction set's readable name, like `<Def:foo>`, `<Block:0>` or `<ProgramStart>`
+func (is *InstructionSet) PrettyName() string {
+	if is.isType == Program {
+		return fmt.Sprintf("<%s>", Program)
+	}
+
+	return fmt.Sprintf("<%s:%s>", is.isType, is.name)
+}
+
+// JumpLabels returns the labels of the instructions that jumps and branches target, by their index.
+// The labels are numbered in the order of the targets, like `L0`, `L1` and so on.
+// A target can be the index right after the last instruction, when a jump leaves the instruction set.
+func (is *InstructionSet) JumpLabels() map[int]string {
+	var targets []int
+	seen := make(map[int]bool)
+
+	for _, i := range is.Instructions {
+		if i.anchor == nil || seen[i.anchor.line] {
+			continue
+		}
+
+		seen[i.anchor.line] = true
+		targets = append(targets, i.anchor.line)
+	}
+
+	sort.Ints(targets)
+	labels := make(map[int]string, len(targets))
+
+	for n, target := range targets {
+		labels[target] = fmt.Sprintf("L%d", n)
+	}
+
+	return labels
+}
+
+// Disassemble returns the instructions in a readable form, one per line with its index and source line.
+// Jump targets are rendered as labels, which are placed before the instructions they point to.
+//
+// ```
+// <ProgramStart>
+// 0000 putboolean true (line 1)
+// 0001 branchunless L0 (line 1)
+// ```
+func (is *InstructionSet) Disassemble() string {
+	var out bytes.Buffer
+	labels := is.JumpLabels()
+
+	out.WriteString(is.PrettyName())
+	out.WriteString("\n")
+
+	for n, i := range is.Instructions {
+		if label, ok := labels[n]; ok {
+			fmt.Fprintf(&out, "%s:\n", label)
+		}
+
+		params := make([]string, len(i.Params))
+
+		for j, param := range i.Params {
+			params[j] = fmt.Sprint(param)
+		}
+
+		// The first parameter of a jump is its target
+		if i.anchor != nil {
+			params[0] = labels[i.anchor.line]
+		}
+
+		fmt.Fprintf(&out, "%04d %s", n, i.ActionName())
+
+		if len(params) > 0 {
+			fmt.Fprintf(&out, " %s", strings.Join(params, ", "))
+		}
+
+		fmt.Fprintf(&out, " (line %d)\n", i.sourceLine)
+	}
+
+	if label, ok := labels[len(is.Instructions)]; ok {
+		fmt.Fprintf(&out, "%s:\n", label)
+	}
+
+	return out.String()
+}
@@ -16,6 +16,10 @@ func (g *Generator) compileExpression(is *InstructionSet, exp ast.Expression, sc
 		is.define(GetConstant, sourceLine, exp.Value, exp.IsNamespace)
 	case *ast.InstanceVariable:
 		is.define(GetInstanceVariable, sourceLine, exp.Value)
+	case *ast.ClassVariable:
+		is.define(GetClassVariable, sourceLine, exp.Value)
+	case *ast.GlobalVariable:
+		is.define(GetGlobalVariable, sourceLine, exp.Value)
 	case *ast.IntegerLiteral:
 		is.define(PutObject, sourceLine, exp.Value)
 	case *ast.FloatLiteral:
@@ -29,7 +33,12 @@ func (g *Generator) compileExpression(is *InstructionSet, exp ast.Expression, sc
 	case *ast.RangeExpression:
 		g.compileExpression(is, exp.Start, scope, table)
 		g.compileExpression(is, exp.End, scope, table)
-		is.define(NewRange, sourceLine, 0)
+
+		if exp.Exclusive {
+			is.define(NewRange, sourceLine, 1)
+		} else {
+			is.define(NewRange, sourceLine, 0)
+		}
 	case *ast.ArrayExpression:
 		for _, elem := range exp.Elements {
 			g.compileExpression(is, elem, scope, table)
@@ -74,7 +83,8 @@ func (g *Generator) compileIdentifier(is *InstructionSet, exp *ast.Identifier, s
 
 	// otherwise it's a method call
 	is.define(PutSelf, exp.Line())
-	is.define(Send, exp.Line(), exp.Value, 0, "", initArgSet(0))
+	send := is.define(Send, exp.Line(), exp.Value, 0, "", initArgSet(0))
+	send.receiverKind = ImplicitReceiver
 }
 
 func (g *Generator) compileYieldExpression(is *InstructionSet, exp *ast.YieldExpression, scope *scope, table *localTable) {
@@ -137,7 +147,15 @@ func (g *Generator) compileCallExpression(is *InstructionSet, exp *ast.CallExpre
 		g.compileBlockArgExpression(blockIndex, exp, scope, newTable)
 	}
 
-	is.define(Send, exp.Line(), exp.Method, len(exp.Arguments), blockInfo, argSet)
+	send := is.define(Send, exp.Line(), exp.Method, len(exp.Arguments), blockInfo, argSet)
+
+	if self, ok := exp.Receiver.(*ast.SelfExpression); ok {
+		if self.Implicit {
+			send.receiverKind = ImplicitReceiver
+		} else {
+			send.receiverKind = SelfReceiver
+		}
+	}
 }
 
 func (g *Generator) compileAssignExpression(is *InstructionSet, exp *ast.AssignExpression, scope *scope, table *localTable) {
@@ -162,6 +180,8 @@ func (g *Generator) compileAssignExpression(is *InstructionSet, exp *ast.AssignE
 				is.define(SetLocal, exp.Line(), depth, index)
 			case *ast.InstanceVariable:
 				is.define(SetInstanceVariable, exp.Line(), name.Value)
+			case *ast.ClassVariable:
+				is.define(SetClassVariable, exp.Line(), name.Value)
 			case *ast.Constant:
 				is.define(SetConstant, exp.Line(), name.Value)
 			}
@@ -188,17 +208,50 @@ func (g *Generator) compileBlockArgExpression(index int, exp *ast.CallExpression
 
 	argSet := initArgSet(len(exp.BlockArguments))
 
+	// A destructuring parameter receives the argument in a local named after its pattern, like `(a, b)`,
+	// which can't clash with the names in the block
 	for i, arg := range exp.BlockArguments {
-		argSet.setArg(i, arg.Value, NormalArg)
-		table.set(arg.Value)
+		argSet.setArg(i, arg.String(), NormalArg)
+		table.set(arg.String())
+	}
+
+	// Block-local variables are defined in the block's table, so they shadow the outer locals with the same names
+	for _, local := range exp.BlockLocals {
+		table.set(local.Value)
 	}
 
 	is.argTypes = argSet
+
+	for _, arg := range exp.BlockArguments {
+		if param, ok := arg.(*ast.DestructuringParameter); ok {
+			g.compileDestructuringParameter(is, param, table)
+		}
+	}
 	g.compileCodeBlock(is, exp.Block, scope, table)
 	g.endInstructions(is, exp.Line())
 	g.instructionSets = append(g.instructionSets, is)
 }
 
+// compileDestructuringParameter expands the array in the parameter's local into the locals of its inner parameters.
+// Like a multiple assignment, missing elements are nil and extra ones are ignored, and a non-array value is assigned to the first parameter.
+func (g *Generator) compileDestructuringParameter(is *InstructionSet, param *ast.DestructuringParameter, table *localTable) {
+	index := table.set(param.String())
+	is.define(GetLocal, param.Line(), 0, index)
+	is.define(ExpandArray, param.Line(), len(param.Parameters), true)
+
+	for _, p := range param.Parameters {
+		index := table.set(p.String())
+		is.define(SetLocal, param.Line(), 0, index)
+		is.define(Pop, param.Line())
+	}
+
+	for _, p := range param.Parameters {
+		if nested, ok := p.(*ast.DestructuringParameter); ok {
+			g.compileDestructuringParameter(is, nested, table)
+		}
+	}
+}
+
 func (g *Generator) compileIfExpression(is *InstructionSet, exp *ast.IfExpression, scope *scope, table *localTable) {
 	anchorLast := &anchor{}
 
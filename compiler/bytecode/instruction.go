@@ -45,6 +45,9 @@ const (
 	Pop
 	Dup
 	Leave
+	GetClassVariable
+	SetClassVariable
+	GetGlobalVariable
 	InstructionCount
 )
 
@@ -80,8 +83,21 @@ var InstructionNameTable = []string{
 	Pop:                 "pop",
 	Dup:                 "dup",
 	Leave:               "leave",
+	GetClassVariable:    "getclassvariable",
+	SetClassVariable:    "setclassvariable",
+	GetGlobalVariable:   "getglobalvariable",
 }
 
+// Receiver kinds of a method call, which decide whether private and protected methods can be called
+const (
+	// ExplicitReceiver is a receiver written in the call, like `foo.bar`
+	ExplicitReceiver uint8 = iota
+	// SelfReceiver is `self` written as the receiver, like `self.bar`
+	SelfReceiver
+	// ImplicitReceiver is the omitted receiver, like `bar`
+	ImplicitReceiver
+)
+
 // Instruction represents compiled bytecode instruction
 type Instruction struct {
 	Opcode     uint8
@@ -89,6 +105,8 @@ type Instruction struct {
 	line       int
 	anchor     *anchor
 	sourceLine int
+	// receiverKind is the kind of a `send` instruction's receiver
+	receiverKind uint8
 }
 
 // Inspect is for inspecting the instruction's content
@@ -115,6 +133,11 @@ func (i *Instruction) AnchorLine() int {
 	panic("you are calling AnchorLine on an instruction without anchors")
 }
 
+// ReceiverKind returns how the receiver of a `send` instruction is written: explicitly, as `self`, or omitted
+func (i *Instruction) ReceiverKind() uint8 {
+	return i.receiverKind
+}
+
 // Line returns instruction's line number
 func (i *Instruction) Line() int {
 	return i.line
@@ -137,6 +160,8 @@ type InstructionSet struct {
 	Instructions []*Instruction
 	count        int
 	argTypes     *ArgSet
+	// argDefaults holds the source of optional parameters' default values by their index, for inspecting the method
+	argDefaults map[int]string
 }
 
 // ArgSet stores the metadata of a method definition's parameters.
@@ -152,6 +177,11 @@ func initArgSet(argCount int) *ArgSet {
 	}
 }
 
+// NewArgSet creates an ArgSet with the given names and types, which should have the same length
+func NewArgSet(names []string, types []uint8) *ArgSet {
+	return &ArgSet{names: names, types: types}
+}
+
 // Types are the getter method of *ArgSet's types attribute
 // TODO: needs to change the func to simple public variable
 func (as *ArgSet) Types() []uint8 {
@@ -175,6 +205,11 @@ func (is *InstructionSet) ArgTypes() *ArgSet {
 	return is.argTypes
 }
 
+// ArgDefaults returns the source of optional parameters' default values by their index
+func (is *InstructionSet) ArgDefaults() map[int]string {
+	return is.argDefaults
+}
+
 // Name returns instruction set's name
 // TODO: needs to change the func to simple public variable
 func (is *InstructionSet) Name() string {
@@ -13,6 +13,7 @@ const (
 	SplatArg
 	RequiredKeywordArg
 	OptionalKeywordArg
+	DoubleSplatArg
 )
 
 func (g *Generator) compileStatements(stmts []ast.Statement, scope *scope, table *localTable) {
@@ -68,7 +69,7 @@ func (g *Generator) compileStatement(is *InstructionSet, statement ast.Statement
 	case *ast.NextStatement:
 		g.compileNextStatement(is, stmt, scope)
 	case *ast.BreakStatement:
-		g.compileBreakStatement(is, stmt, scope)
+		g.compileBreakStatement(is, stmt, scope, table)
 	}
 }
 
@@ -109,7 +110,16 @@ func (g *Generator) compileNextStatement(is *InstructionSet, stmt ast.Statement,
 	g.instructionsWithAnchor = append(g.instructionsWithAnchor, jp)
 }
 
-func (g *Generator) compileBreakStatement(is *InstructionSet, stmt ast.Statement, scope *scope) {
+func (g *Generator) compileBreakStatement(is *InstructionSet, stmt *ast.BreakStatement, scope *scope, table *localTable) {
+	// `break` inside a block can carry a value, which becomes the return value of the method call that received the block
+	if stmt.Value != nil {
+		g.compileExpression(is, stmt.Value, scope, table)
+
+		if is.isType != Block {
+			is.define(Pop, stmt.Line())
+		}
+	}
+
 	if scope.anchors["break"] != nil {
 		/*
 			# We also need to leave current frame if it's inside block like:
@@ -129,13 +139,22 @@ func (g *Generator) compileBreakStatement(is *InstructionSet, stmt ast.Statement
 			y # 12
 		*/
 		if is.isType == Block {
-			is.define(Break, stmt.Line())
+			g.defineBreak(is, stmt)
 		}
 		jp := is.define(Jump, stmt.Line(), scope.anchors["break"])
 		g.instructionsWithAnchor = append(g.instructionsWithAnchor, jp)
 	} else {
-		is.define(Break, stmt.Line())
+		g.defineBreak(is, stmt)
+	}
+}
+
+func (g *Generator) defineBreak(is *InstructionSet, stmt *ast.BreakStatement) {
+	if stmt.Value != nil && is.isType == Block {
+		is.define(Break, stmt.Line(), 1)
+		return
 	}
+
+	is.define(Break, stmt.Line())
 }
 
 func (g *Generator) compileClassStmt(is *InstructionSet, stmt *ast.ClassStatement, scope *scope, table *localTable) {
@@ -196,6 +215,7 @@ func (g *Generator) compileDefStmt(is *InstructionSet, stmt *ast.DefStatement, s
 		name:   stmt.Name.Value,
 		isType: MethodDef,
 		argTypes: initArgSet(len(stmt.Parameters)),
+		argDefaults: make(map[int]string),
 	}
 
 	for i := 0; i < len(stmt.Parameters); i++ {
@@ -212,15 +232,25 @@ func (g *Generator) compileDefStmt(is *InstructionSet, stmt *ast.DefStatement, s
 			g.compileAssignExpression(newIS, exp, scope, scope.localTable)
 
 			newIS.argTypes.setArg(i, varName.Value, OptionedArg)
+			newIS.argDefaults[i] = exp.Value.String()
 		case *ast.PrefixExpression:
-			if exp.Operator != "*" {
+			if exp.Operator != "*" && exp.Operator != "**" {
 				continue
 			}
 
 			ident := exp.Right.(*ast.Identifier)
+			index, depth := scope.localTable.setLCL(ident.Value, scope.localTable.depth)
+
+			if exp.Operator == "**" {
+				// Set default value to an empty hash
+				newIS.define(NewHash, exp.Line(), 0)
+				newIS.define(SetLocal, exp.Line(), depth, index, 1)
+
+				newIS.argTypes.setArg(i, ident.Value, DoubleSplatArg)
+				continue
+			}
 
 			// Set default value to an empty array
-			index, depth := scope.localTable.setLCL(ident.Value, scope.localTable.depth)
 			newIS.define(NewArray, exp.Line(), 0)
 			newIS.define(SetLocal, exp.Line(), depth, index, 1)
 
@@ -233,6 +263,7 @@ func (g *Generator) compileDefStmt(is *InstructionSet, stmt *ast.DefStatement, s
 				g.compileExpression(newIS, exp.Value, scope, scope.localTable)
 				newIS.define(SetLocal, exp.Line(), depth, index, 1)
 				newIS.argTypes.setArg(i, key.Value, OptionalKeywordArg)
+				newIS.argDefaults[i] = exp.Value.String()
 			} else {
 				newIS.argTypes.setArg(i, key.Value, RequiredKeywordArg)
 			}
@@ -8,6 +8,30 @@ import (
 	"github.com/goby-lang/goby/compiler/parser"
 )
 
+// Diagnostic is an error or a warning found while compiling, with the line it's on.
+// Lines are counted from 0, like in the messages.
+type Diagnostic struct {
+	Message string
+	Line    int
+}
+
+// Result is what CompileWithDiagnostics returns: every error and warning found in the source code,
+// and the instruction sets of the statements that could be compiled.
+type Result struct {
+	InstructionSets []*bytecode.InstructionSet
+	Errors          []*Diagnostic
+	Warnings        []*Diagnostic
+}
+
+// Err returns the first error as an error, or nil if there's none
+func (r *Result) Err() error {
+	if len(r.Errors) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf(r.Errors[0].Message)
+}
+
 // CompileToInstructions compiles input source code into instruction set data structures
 func CompileToInstructions(input string, pm parser.Mode) ([]*bytecode.InstructionSet, error) {
 	l := lexer.New(input)
@@ -21,3 +45,33 @@ func CompileToInstructions(input string, pm parser.Mode) ([]*bytecode.Instructio
 	g.InitTopLevelScope(program)
 	return g.GenerateInstructions(program.Statements), nil
 }
+
+// CompileWithDiagnostics compiles input source code like CompileToInstructions, but doesn't stop at the first error.
+// Parsing resumes at the next top level statement after an error, so all the errors are reported along with the warnings,
+// and the statements without errors are still compiled into the instruction sets.
+// Running them runs the valid part of the program, which is up to the caller to decide.
+func CompileWithDiagnostics(input string, pm parser.Mode) *Result {
+	l := lexer.New(input)
+	p := parser.New(l)
+	p.Mode = pm
+	program, errs := p.ParseProgramWithRecovery()
+
+	result := &Result{}
+
+	for _, err := range errs {
+		result.Errors = append(result.Errors, &Diagnostic{Message: err.Message, Line: err.Line})
+	}
+
+	for _, w := range p.Warnings() {
+		result.Warnings = append(result.Warnings, &Diagnostic{Message: w.Message, Line: w.Line})
+	}
+
+	if program == nil {
+		return result
+	}
+
+	g := bytecode.NewGenerator()
+	g.InitTopLevelScope(program)
+	result.InstructionSets = g.GenerateInstructions(program.Statements)
+	return result
+}
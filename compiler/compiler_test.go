@@ -1,6 +1,7 @@
 package compiler
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/goby-lang/goby/compiler/bytecode"
@@ -150,3 +151,191 @@ end
 		}
 	}
 }
+
+func TestDisassemble(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`a = 1
+if a > 1
+  10
+else
+  20
+end`, `<ProgramStart>
+0000 putobject 1 (line 1)
+0001 setlocal 0, 0 (line 1)
+0002 pop (line 1)
+0003 getlocal 0, 0 (line 2)
+0004 putobject 1 (line 2)
+0005 send >, 1, , &{[] []} (line 2)
+0006 branchunless L0 (line 2)
+0007 putobject 10 (line 3)
+0008 jump L1 (line 2)
+L0:
+0009 putobject 20 (line 5)
+L1:
+0010 pop (line 2)
+0011 leave (line 2)
+`},
+		{`i = 0
+while i < 3 do
+  i += 1
+end`, `<ProgramStart>
+0000 putobject 0 (line 1)
+0001 setlocal 0, 0 (line 1)
+0002 pop (line 1)
+0003 jump L1 (line 2)
+L0:
+0004 getlocal 0, 0 (line 3)
+0005 putobject 1 (line 3)
+0006 send +, 1, , &{[] []} (line 3)
+0007 setlocal 0, 0 (line 3)
+0008 pop (line 3)
+L1:
+0009 getlocal 0, 0 (line 2)
+0010 putobject 3 (line 2)
+0011 send <, 1, , &{[] []} (line 2)
+0012 branchif L0 (line 2)
+0013 leave (line 2)
+`},
+	}
+
+	for i, tt := range tests {
+		is, err := CompileToInstructions(tt.input, parser.NormalMode)
+
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if got := is[len(is)-1].Disassemble(); got != tt.expected {
+			t.Errorf("At test case %d: expect disassembled instructions to be:\n%s\ngot:\n%s", i, tt.expected, got)
+		}
+	}
+}
+
+func TestInstructionSetPrettyName(t *testing.T) {
+	is, err := CompileToInstructions(`
+class Foo
+  def bar
+    [1].each do |i|
+      i
+    end
+  end
+end
+`, parser.NormalMode)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	var names []string
+
+	for _, set := range is {
+		names = append(names, set.PrettyName())
+	}
+
+	expected := "<Block:0> <Def:bar> <DefClass:Foo> <ProgramStart>"
+
+	if got := strings.Join(names, " "); got != expected {
+		t.Errorf("Expect the instruction sets' names to be %s. got: %s", expected, got)
+	}
+}
+
+func TestCompileWithDiagnostics(t *testing.T) {
+	result := CompileWithDiagnostics(`h = { a: 1, b: 2, a: 3 }
+def foo(a
+  1
+end
+def bar
+  h.class
+end
+class Baz
+  def qux
+    [1].each do |i|
+      i +
+    end
+  end
+end
+x = )
+y = { c: 1, c: 2 }
+`, parser.NormalMode)
+
+	expectedErrors := []Diagnostic{
+		{Message: "expected next token to be ), got INT(1) instead. Line: 2", Line: 2},
+		{Message: "unexpected end Line: 11", Line: 11},
+		{Message: "unexpected ) Line: 14", Line: 14},
+	}
+	expectedWarnings := []Diagnostic{
+		{Message: "duplicate key a in hash literal, the last value is used. Line: 0", Line: 0},
+		{Message: "duplicate key c in hash literal, the last value is used. Line: 15", Line: 15},
+	}
+
+	verifyDiagnostics(t, "errors", result.Errors, expectedErrors)
+	verifyDiagnostics(t, "warnings", result.Warnings, expectedWarnings)
+
+	if err := result.Err(); err == nil || err.Error() != expectedErrors[0].Message {
+		t.Fatalf("Expect the first error to be `%s`. got: %v", expectedErrors[0].Message, err)
+	}
+
+	// The statements with errors are left out, including the class whose method has one
+	var names []string
+
+	for _, set := range result.InstructionSets {
+		names = append(names, set.PrettyName())
+	}
+
+	expected := "<Def:bar> <ProgramStart>"
+
+	if got := strings.Join(names, " "); got != expected {
+		t.Errorf("Expect the instruction sets' names to be %s. got: %s", expected, got)
+	}
+
+	if got := result.InstructionSets[len(result.InstructionSets)-1].Disassemble(); !strings.Contains(got, "putstring c") || strings.Contains(got, "Baz") {
+		t.Errorf("Expect the program to have the valid statements only. got:\n%s", got)
+	}
+}
+
+func TestCompileWithDiagnosticsValidProgram(t *testing.T) {
+	input := `a = { b: 1 }
+def foo(x)
+  x.class
+end
+foo(a)`
+
+	result := CompileWithDiagnostics(input, parser.NormalMode)
+
+	if len(result.Errors) != 0 || len(result.Warnings) != 0 || result.Err() != nil {
+		t.Fatalf("Expect no diagnostics. got: %d errors and %d warnings", len(result.Errors), len(result.Warnings))
+	}
+
+	is, err := CompileToInstructions(input, parser.NormalMode)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(is) != len(result.InstructionSets) {
+		t.Fatalf("Expect %d instruction sets. got: %d", len(is), len(result.InstructionSets))
+	}
+
+	for i, set := range is {
+		if got := result.InstructionSets[i].Disassemble(); got != set.Disassemble() {
+			t.Errorf("Expect the instruction set %d to be:\n%s\ngot:\n%s", i, set.Disassemble(), got)
+		}
+	}
+}
+
+func verifyDiagnostics(t *testing.T, kind string, got []*Diagnostic, expected []Diagnostic) {
+	t.Helper()
+
+	if len(got) != len(expected) {
+		t.Fatalf("Expect %d %s. got: %d", len(expected), kind, len(got))
+	}
+
+	for i, d := range got {
+		if *d != expected[i] {
+			t.Errorf("Expect %s #%d to be %+v. got: %+v", kind, i, expected[i], *d)
+		}
+	}
+}
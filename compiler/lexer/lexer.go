@@ -54,6 +54,9 @@ func (l *Lexer) NextToken() token.Token {
 		if l.peekChar() == '=' {
 			l.readChar()
 			tok = token.CreateOperator("==", l.line)
+		} else if l.peekChar() == '~' {
+			l.readChar()
+			tok = token.CreateOperator("=~", l.line)
 		} else {
 			tok = token.CreateOperator("=", l.line)
 		}
@@ -90,6 +93,9 @@ func (l *Lexer) NextToken() token.Token {
 			} else {
 				tok = token.CreateOperator("<=", l.line)
 			}
+		} else if l.peekChar() == '<' {
+			l.readChar()
+			tok = token.CreateOperator("<<", l.line)
 		} else {
 			tok = token.CreateOperator("<", l.line)
 		}
@@ -111,6 +117,13 @@ func (l *Lexer) NextToken() token.Token {
 		}
 		tok = token.CreateOperator("+", l.line)
 	case '.':
+		if l.peekChar() == '.' && l.peekCharAt(1) == '.' {
+			tok = token.CreateOperator("...", l.line)
+			l.readChar()
+			l.readChar()
+			l.readChar()
+			return tok
+		}
 		if l.peekChar() == '.' {
 			tok = token.CreateOperator("..", l.line)
 			l.readChar()
@@ -156,9 +169,23 @@ func (l *Lexer) NextToken() token.Token {
 		if l.peekChar() == '&' {
 			l.readChar()
 			tok = token.CreateOperator("&&", l.line)
+		} else {
+			tok = token.CreateOperator("&", l.line)
 		}
+	case '^':
+		tok = token.CreateOperator("^", l.line)
 	case '%':
 		tok = token.CreateOperator("%", l.line)
+	case '$':
+		// Only the last match `$~` and its groups like `$1` are supported
+		if l.peekChar() == '~' || isDigit(l.peekChar()) {
+			tok.Literal = string(l.readGlobalVariable())
+			tok.Type = token.GlobalVariable
+			tok.Line = l.line
+			return tok
+		}
+
+		tok = token.Token{Type: token.Illegal, Literal: string(l.ch), Line: l.line}
 	case '#':
 		tok.Literal = string(l.absorbComment())
 		tok.Type = token.Comment
@@ -200,6 +227,13 @@ func (l *Lexer) NextToken() token.Token {
 			}
 			return tok
 		} else if isInstanceVariable(l.ch) {
+			if isInstanceVariable(l.peekChar()) && isLetter(l.peekCharAt(1)) {
+				tok.Literal = string(l.readInstanceVariable())
+				tok.Type = token.ClassVariable
+				tok.Line = l.line
+				return tok
+			}
+
 			if isLetter(l.peekChar()) {
 				tok.Literal = string(l.readInstanceVariable())
 				tok.Type = token.InstanceVariable
@@ -212,6 +246,8 @@ func (l *Lexer) NextToken() token.Token {
 			tok.Literal = string(l.readNumber())
 			tok.Type = token.Int
 			tok.Line = l.line
+			// digits after a dot are a float's fraction, not a method name
+			l.FSM.Event("initial")
 			return tok
 		}
 
@@ -254,7 +290,8 @@ func (l *Lexer) readIdentifier() []rune {
 		l.readChar()
 	}
 
-	if l.ch == '?' {
+	// Predicate methods end with `?`, and destructive methods with `!` (but `!=` is an operator)
+	if l.ch == '?' || l.ch == '!' && l.peekChar() != '=' {
 		l.readChar()
 	}
 
@@ -277,6 +314,22 @@ func (l *Lexer) readInstanceVariable() []rune {
 	return l.input[position:l.position]
 }
 
+func (l *Lexer) readGlobalVariable() []rune {
+	position := l.position
+	l.readChar()
+
+	if l.ch == '~' {
+		l.readChar()
+		return l.input[position:l.position]
+	}
+
+	for isDigit(l.ch) {
+		l.readChar()
+	}
+
+	return l.input[position:l.position]
+}
+
 func (l *Lexer) readString(ch rune) string {
 	l.readChar()
 
@@ -351,6 +404,15 @@ func (l *Lexer) peekChar() rune {
 	// Peek shouldn't increment positions.
 }
 
+// peekCharAt looks ahead the given number of runes after peekChar without moving the positions
+func (l *Lexer) peekCharAt(offset int) rune {
+	if l.readPosition+offset >= len(l.input) {
+		return 0
+	}
+
+	return l.input[l.readPosition+offset]
+}
+
 func isDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
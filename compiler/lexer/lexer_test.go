@@ -39,6 +39,46 @@ func TestNextToken(t *testing.T) {
 				{token.Int, "15", 3},
 			},
 		},
+		{
+			`
+				@@count ||= 0
+				@@a1 = @a
+				@@
+			`,
+			[]struct {
+				expectedType    token.Type
+				expectedLiteral string
+				expectedLine    int
+			}{
+				{token.ClassVariable, "@@count", 1},
+				{token.OrEq, "||=", 1},
+				{token.Int, "0", 1},
+				{token.ClassVariable, "@@a1", 2},
+				{token.Assign, "=", 2},
+				{token.InstanceVariable, "@a", 2},
+				{token.Illegal, "@", 3},
+			},
+		},
+		{
+			`
+				s =~ r
+				$~
+				$12
+				$a
+			`,
+			[]struct {
+				expectedType    token.Type
+				expectedLiteral string
+				expectedLine    int
+			}{
+				{token.Ident, "s", 1},
+				{token.Match, "=~", 1},
+				{token.Ident, "r", 1},
+				{token.GlobalVariable, "$~", 2},
+				{token.GlobalVariable, "$12", 3},
+				{token.Illegal, "$", 4},
+			},
+		},
 		{
 			`
 	class Person
@@ -224,6 +264,27 @@ func TestNextToken(t *testing.T) {
 			},
 		}, {
 			`
+	a.strip!;
+	a!=b;
+			`,
+			[]struct {
+				expectedType    token.Type
+				expectedLiteral string
+				expectedLine    int
+			}{
+
+				{token.Ident, "a", 1},
+				{token.Dot, ".", 1},
+				{token.Ident, "strip!", 1},
+				{token.Semicolon, ";", 1},
+
+				{token.Ident, "a", 2},
+				{token.NotEq, "!=", 2},
+				{token.Ident, "b", 2},
+				{token.Semicolon, ";", 2},
+			},
+		}, {
+			`
 	# This is comment.
 	# And I should be ignored.
 			`,
@@ -287,6 +348,7 @@ func TestNextToken(t *testing.T) {
 	10 <= 10;
 	10 >= 10;
 	a = 1 <=> 2;
+	s << "a";
 			`,
 			[]struct {
 				expectedType    token.Type
@@ -309,6 +371,11 @@ func TestNextToken(t *testing.T) {
 				{token.COMP, "<=>", 3},
 				{token.Int, "2", 3},
 				{token.Semicolon, ";", 3},
+
+				{token.Ident, "s", 4},
+				{token.Append, "<<", 4},
+				{token.String, "a", 4},
+				{token.Semicolon, ";", 4},
 			},
 		}, {
 			`
@@ -346,6 +413,23 @@ func TestNextToken(t *testing.T) {
 			},
 		}, {
 			`
+	true & false | true ^ false
+			`,
+			[]struct {
+				expectedType    token.Type
+				expectedLiteral string
+				expectedLine    int
+			}{
+				{token.True, "true", 1},
+				{token.BitAnd, "&", 1},
+				{token.False, "false", 1},
+				{token.Bar, "|", 1},
+				{token.True, "true", 1},
+				{token.Caret, "^", 1},
+				{token.False, "false", 1},
+			},
+		}, {
+			`
 	nil
 			`,
 			[]struct {
@@ -532,6 +616,22 @@ func TestNextToken(t *testing.T) {
 			},
 		}, {
 			`
+	(1...n)
+			`,
+			[]struct {
+				expectedType    token.Type
+				expectedLiteral string
+				expectedLine    int
+			}{
+
+				{token.LParen, "(", 1},
+				{token.Int, "1", 1},
+				{token.ExclusiveRange, "...", 1},
+				{token.Ident, "n", 1},
+				{token.RParen, ")", 1},
+			},
+		}, {
+			`
 	while i < 10 do
 	 break
 	end
@@ -551,6 +651,25 @@ func TestNextToken(t *testing.T) {
 			},
 		}, {
 			`
+	foo(0.5) do
+	end
+			`,
+			[]struct {
+				expectedType    token.Type
+				expectedLiteral string
+				expectedLine    int
+			}{
+				{token.Ident, "foo", 1},
+				{token.LParen, "(", 1},
+				{token.Int, "0", 1},
+				{token.Dot, ".", 1},
+				{token.Int, "5", 1},
+				{token.RParen, ")", 1},
+				{token.Do, "do", 1},
+				{token.End, "end", 2},
+			},
+		}, {
+			`
 	a += 1
 	b -= 2
 	c ||= true
@@ -9,6 +9,7 @@ const (
 	SplatArg
 	RequiredKeywordArg
 	OptionalKeywordArg
+	DoubleSplatArg
 )
 
 // Types is a table maps argument types enum to the their real name
@@ -18,6 +19,7 @@ var Types = map[int]string{
 	RequiredKeywordArg: "Keyword argument",
 	OptionalKeywordArg: "Optioned keyword argument",
 	SplatArg:           "Splat argument",
+	DoubleSplatArg:     "Double splat argument",
 }
 
 // Tokens marks token types that can be used as method call arguments
@@ -28,6 +30,7 @@ var Tokens = map[token.Type]bool{
 	token.False:            true,
 	token.Null:             true,
 	token.InstanceVariable: true,
+	token.ClassVariable:    true,
 	token.Ident:            true,
 	token.Constant:         true,
 }
@@ -101,6 +101,7 @@ func (p *Parser) parseHashPair(pairs map[string]ast.Expression) {
 	var value ast.Expression
 
 	p.nextToken()
+	line := p.curToken.Line
 
 	switch p.curToken.Type {
 	case token.Constant, token.Ident:
@@ -116,6 +117,11 @@ func (p *Parser) parseHashPair(pairs map[string]ast.Expression) {
 
 	p.nextToken()
 	value = p.parseExpression(precedence.Normal)
+
+	if _, ok := pairs[key]; ok {
+		p.warn(line, "duplicate key %s in hash literal, the last value is used. Line: %d", key, line)
+	}
+
 	pairs[key] = value
 }
 
@@ -151,8 +157,9 @@ func (p *Parser) parseArrayElements() []ast.Expression {
 
 func (p *Parser) parseRangeExpression(left ast.Expression) ast.Expression {
 	exp := &ast.RangeExpression{
-		BaseNode: &ast.BaseNode{Token: p.curToken},
-		Start:    left,
+		BaseNode:  &ast.BaseNode{Token: p.curToken},
+		Start:     left,
+		Exclusive: p.curTokenIs(token.ExclusiveRange),
 	}
 
 	precedence := p.curPrecedence()
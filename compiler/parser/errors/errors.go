@@ -30,6 +30,8 @@ type Error struct {
 	// Message contains the readable message of error
 	Message string
 	ErrType int
+	// Line is where the error is, counted from 0 like in the message, or -1 if it's not known
+	Line int
 }
 
 // IsEOF checks if error is end of file error
@@ -64,7 +66,7 @@ func (e *Error) IsUnexpectedEmptyLine(len int) bool {
 
 // InitError is a helper function for easily initializing error object
 func InitError(msg string, errType int) *Error {
-	return &Error{Message: msg, ErrType: errType}
+	return &Error{Message: msg, ErrType: errType, Line: -1}
 }
 
 // NewArgumentError is a helper function the helps initializing argument errors
@@ -72,11 +74,15 @@ func NewArgumentError(formerArgType, laterArgType int, argLiteral string, line i
 	formerArg := arguments.Types[formerArgType]
 	laterArg := arguments.Types[laterArgType]
 	msg := fmt.Sprintf("%s \"%s\" should be defined before %s. Line: %d", formerArg, argLiteral, laterArg, line)
-	return InitError(msg, ArgumentError)
+	err := InitError(msg, ArgumentError)
+	err.Line = line
+	return err
 }
 
 // NewTypeParsingError is a helper function the helps initializing type parsing errors
 func NewTypeParsingError(tokenLiteral, targetType string, line int) *Error {
 	msg := fmt.Sprintf("could not parse %q as %s. Line: %d", tokenLiteral, targetType, line)
-	return InitError(msg, SyntaxError)
+	err := InitError(msg, SyntaxError)
+	err.Line = line
+	return err
 }
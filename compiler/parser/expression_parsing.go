@@ -82,7 +82,19 @@ func (p *Parser) parseAssignExpression(v ast.Expression) ast.Expression {
 	return exp
 }
 
+// conversionFunctions are the builtin methods with capitalized names, which can be called with parens like `Rational(1, 3)`.
+// Other capitalized methods can't be called.
+var conversionFunctions = map[string]bool{
+	"Rational": true,
+}
+
 func (p *Parser) parseConstant() ast.Expression {
+	if conversionFunctions[p.curToken.Literal] && p.peekTokenIs(token.LParen) {
+		method := p.parseIdentifier()
+		p.nextToken()
+		return p.parseCallExpressionWithoutReceiver(method)
+	}
+
 	c := &ast.Constant{BaseNode: &ast.BaseNode{Token: p.curToken}, Value: p.curToken.Literal}
 
 	if p.peekTokenIs(token.ResolutionOperator) {
@@ -115,7 +127,7 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 
 	// Prohibit calling a capitalized method on toplevel:
 	if p.curTokenIs(token.Constant) && (p.fsm.Is(states.Normal) || p.fsm.Is(states.ParsingAssignment)) {
-		if p.peekTokenIs(token.LParen) {
+		if p.peekTokenIs(token.LParen) && !conversionFunctions[p.curToken.Literal] {
 			p.callConstantError(p.curToken.Type)
 			return nil
 		}
@@ -285,6 +297,14 @@ func (p *Parser) parseInstanceVariable() ast.Expression {
 	return &ast.InstanceVariable{BaseNode: &ast.BaseNode{Token: p.curToken}, Value: p.curToken.Literal}
 }
 
+func (p *Parser) parseClassVariable() ast.Expression {
+	return &ast.ClassVariable{BaseNode: &ast.BaseNode{Token: p.curToken}, Value: p.curToken.Literal}
+}
+
+func (p *Parser) parseGlobalVariable() ast.Expression {
+	return &ast.GlobalVariable{BaseNode: &ast.BaseNode{Token: p.curToken}, Value: p.curToken.Literal}
+}
+
 func (p *Parser) parseMultiVariables(left ast.Expression) ast.Expression {
 	var1, ok := left.(ast.Variable)
 
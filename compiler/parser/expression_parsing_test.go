@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/goby-lang/goby/compiler/ast"
 	"github.com/goby-lang/goby/compiler/lexer"
+	"strings"
 	"testing"
 )
 
@@ -289,13 +290,123 @@ func TestCallExpressionWithBlock(t *testing.T) {
 	callExpression := program.FirstStmt().IsExpression(t).IsCallExpression(t)
 	callExpression.TestableReceiver().IsArrayExpression(t)
 	callExpression.ShouldHaveMethodName("each")
-	callExpression.BlockArguments[0].IsIdentifier(t).ShouldHaveName("i")
+	callExpression.NthBlockArgument(1).IsIdentifier(t).ShouldHaveName("i")
 
 	block := callExpression.Block
 	exp := block.Statements[0].(ast.TestableStatement).IsExpression(t)
 	exp.IsCallExpression(t).ShouldHaveMethodName("puts")
 }
 
+func TestCallExpressionWithDestructuringBlockParameters(t *testing.T) {
+	input := `
+	[[1, [2, 3]]].each do |a, (b, (c, d))|
+	  puts(a)
+	end
+	`
+	l := lexer.New(input)
+	p := New(l)
+	program, err := p.ParseProgram()
+
+	if err != nil {
+		t.Fatal(err.Message)
+	}
+
+	callExpression := program.FirstStmt().IsExpression(t).IsCallExpression(t)
+	callExpression.NthBlockArgument(1).IsIdentifier(t).ShouldHaveName("a")
+
+	param, ok := callExpression.BlockArguments[1].(*ast.DestructuringParameter)
+
+	if !ok {
+		t.Fatalf("expect second block parameter to be a destructuring parameter. got: %T", callExpression.BlockArguments[1])
+	}
+
+	if param.String() != "(b, (c, d))" {
+		t.Fatalf("expect destructuring parameter to be '(b, (c, d))'. got: %s", param.String())
+	}
+
+	if _, ok := param.Parameters[1].(*ast.DestructuringParameter); !ok {
+		t.Fatalf("expect nested destructuring parameter. got: %T", param.Parameters[1])
+	}
+}
+
+func TestCallExpressionWithDestructuringBlockParametersFail(t *testing.T) {
+	inputs := []string{
+		`[1].each do |(a, b| end`,
+		`[1].each do |a, (b, c|
+		end`,
+	}
+
+	for i, input := range inputs {
+		l := lexer.New(input)
+		p := New(l)
+		_, err := p.ParseProgram()
+
+		if err == nil {
+			t.Fatalf("At case %d: expect a parsing error", i)
+		}
+	}
+}
+
+func TestCallExpressionWithBlockLocals(t *testing.T) {
+	tests := []struct {
+		input  string
+		params []string
+		locals []string
+	}{
+		{`[1].each do |a; b, c| end`, []string{"a"}, []string{"b", "c"}},
+		{`[1].each do |a, (b, c); d| end`, []string{"a", "(b, c)"}, []string{"d"}},
+		{`[1].each do |; a| end`, nil, []string{"a"}},
+	}
+
+	for i, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program, err := p.ParseProgram()
+
+		if err != nil {
+			t.Fatalf("At case %d: %s", i, err.Message)
+		}
+
+		callExpression := program.FirstStmt().IsExpression(t).IsCallExpression(t)
+		var params, locals []string
+
+		for _, param := range callExpression.BlockArguments {
+			params = append(params, param.String())
+		}
+
+		for _, local := range callExpression.BlockLocals {
+			locals = append(locals, local.Value)
+		}
+
+		if strings.Join(params, " ") != strings.Join(tt.params, " ") {
+			t.Fatalf("At case %d: expect block parameters to be %v. got: %v", i, tt.params, params)
+		}
+
+		if strings.Join(locals, " ") != strings.Join(tt.locals, " ") {
+			t.Fatalf("At case %d: expect block locals to be %v. got: %v", i, tt.locals, locals)
+		}
+	}
+}
+
+func TestCallExpressionWithBlockLocalsFail(t *testing.T) {
+	inputs := []string{
+		`[1].each do |a;| end`,
+		`[1].each do |a; 1| end`,
+		`[1].each do |a; b,| end`,
+		`[1].each do |a; b end`,
+	}
+
+	for i, input := range inputs {
+		l := lexer.New(input)
+		p := New(l)
+		_, err := p.ParseProgram()
+
+		if err == nil {
+			t.Fatalf("At case %d: expect a parsing error", i)
+		}
+	}
+}
+
 func TestCaseExpression(t *testing.T) {
 	input := `
 	case 2
@@ -351,6 +462,25 @@ func TestCaseExpression(t *testing.T) {
 	alternativeInfix.TestableRightExpression().IsIntegerLiteral(t).ShouldEqualTo(2)
 }
 
+func TestClassVariableExpression(t *testing.T) {
+	input := `@@count = @@count + 1`
+
+	l := lexer.New(input)
+	p := New(l)
+	program, err := p.ParseProgram()
+
+	if err != nil {
+		t.Fatal(err.Message)
+	}
+
+	assignExp := program.FirstStmt().IsExpression(t).IsAssignExpression(t)
+	assignExp.NthVariable(1).IsClassVariable(t).ShouldHaveName("@@count")
+
+	infix := assignExp.TestableValue().IsInfixExpression(t)
+	infix.TestableLeftExpression().IsClassVariable(t).ShouldHaveName("@@count")
+	infix.TestableRightExpression().IsIntegerLiteral(t).ShouldEqualTo(1)
+}
+
 func TestConstantExpression(t *testing.T) {
 	input := `Person;`
 
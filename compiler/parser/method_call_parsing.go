@@ -17,7 +17,7 @@ func (p *Parser) parseCallExpressionWithoutReceiver(receiver ast.Expression) ast
 	p.fsm.Event(events.ParseFuncCall)
 	// real receiver is self
 	selfTok := token.Token{Type: token.Self, Literal: "self", Line: p.curToken.Line}
-	self := &ast.SelfExpression{BaseNode: &ast.BaseNode{Token: selfTok}}
+	self := &ast.SelfExpression{BaseNode: &ast.BaseNode{Token: selfTok}, Implicit: true}
 
 	// current token might be the first argument
 	//     method name      |       argument
@@ -129,19 +129,25 @@ func (p *Parser) parseBlockArgument(exp *ast.CallExpression) {
 
 	// Parse block arguments
 	if p.peekTokenIs(token.Bar) {
-		var params []*ast.Identifier
-
-		p.nextToken()
 		p.nextToken()
+		var params []ast.Expression
 
-		param := &ast.Identifier{BaseNode: &ast.BaseNode{Token: p.curToken}, Value: p.curToken.Literal}
-		params = append(params, param)
+		// The block can declare block-local variables without parameters, like `|; tmp|`
+		if !p.peekTokenIs(token.Semicolon) {
+			params = p.parseBlockParameters()
 
-		for p.peekTokenIs(token.Comma) {
-			p.nextToken()
+			if params == nil {
+				return
+			}
+		}
+
+		if p.peekTokenIs(token.Semicolon) {
 			p.nextToken()
-			param := &ast.Identifier{BaseNode: &ast.BaseNode{Token: p.curToken}, Value: p.curToken.Literal}
-			params = append(params, param)
+			exp.BlockLocals = p.parseBlockLocals()
+
+			if exp.BlockLocals == nil {
+				return
+			}
 		}
 
 		if !p.expectPeek(token.Bar) {
@@ -154,3 +160,66 @@ func (p *Parser) parseBlockArgument(exp *ast.CallExpression) {
 	exp.Block = p.parseBlockStatement(token.End)
 	exp.Block.KeepLastValue()
 }
+
+// parseBlockParameters parses the comma separated parameters following the current token, which is `|` or `(`
+func (p *Parser) parseBlockParameters() []ast.Expression {
+	var params []ast.Expression
+
+	p.nextToken()
+	param := p.parseBlockParameter()
+
+	if param == nil {
+		return nil
+	}
+
+	params = append(params, param)
+
+	for p.peekTokenIs(token.Comma) {
+		p.nextToken()
+		p.nextToken()
+		param := p.parseBlockParameter()
+
+		if param == nil {
+			return nil
+		}
+
+		params = append(params, param)
+	}
+
+	return params
+}
+
+// parseBlockLocals parses the comma separated block-local variables following the current token, which is `;`
+func (p *Parser) parseBlockLocals() []*ast.Identifier {
+	var locals []*ast.Identifier
+
+	for {
+		if !p.expectPeek(token.Ident) {
+			return nil
+		}
+
+		locals = append(locals, &ast.Identifier{BaseNode: &ast.BaseNode{Token: p.curToken}, Value: p.curToken.Literal})
+
+		if !p.peekTokenIs(token.Comma) {
+			return locals
+		}
+
+		p.nextToken()
+	}
+}
+
+// parseBlockParameter parses a block parameter, which is an identifier or a parenthesized group of parameters to destructure
+func (p *Parser) parseBlockParameter() ast.Expression {
+	if !p.curTokenIs(token.LParen) {
+		return &ast.Identifier{BaseNode: &ast.BaseNode{Token: p.curToken}, Value: p.curToken.Literal}
+	}
+
+	param := &ast.DestructuringParameter{BaseNode: &ast.BaseNode{Token: p.curToken}}
+	param.Parameters = p.parseBlockParameters()
+
+	if param.Parameters == nil || !p.expectPeek(token.RParen) {
+		return nil
+	}
+
+	return param
+}
@@ -18,6 +18,8 @@ func TestDefStatement(t *testing.T) {
 	def bar(x = 10, y: ); end
 
 	def baz(z: 100, *s); end
+
+	def qux(a, k:, *s, **opts); end
 	`
 
 	l := lexer.New(input)
@@ -55,6 +57,79 @@ func TestDefStatement(t *testing.T) {
 	fourthStmt.ShouldHaveName("baz")
 	fourthStmt.ShouldHaveOptionalKeywordParam("z")
 	fourthStmt.ShouldHaveSplatParam("s")
+
+	fifthStmt := program.NthStmt(5).IsDefStmt(t)
+	fifthStmt.ShouldHaveName("qux")
+	fifthStmt.ShouldHaveNormalParam("a")
+	fifthStmt.ShouldHaveRequiredKeywordParam("k")
+	fifthStmt.ShouldHaveSplatParam("s")
+	fifthStmt.ShouldHaveDoubleSplatParam("opts")
+}
+
+func TestDefStatementWithOperatorName(t *testing.T) {
+	input := `
+	def <=>(other)
+	  0
+	end
+
+	def ==(other); end
+
+	def +(other); end
+
+	def <<(other); end
+	`
+
+	l := lexer.New(input)
+	p := New(l)
+	program, err := p.ParseProgram()
+
+	if err != nil {
+		t.Fatal(err.Message)
+	}
+
+	firstStmt := program.FirstStmt().IsDefStmt(t)
+	firstStmt.ShouldHaveName("<=>")
+	firstStmt.ShouldHaveNormalParam("other")
+
+	secondStmt := program.NthStmt(2).IsDefStmt(t)
+	secondStmt.ShouldHaveName("==")
+	secondStmt.ShouldHaveNormalParam("other")
+
+	thirdStmt := program.NthStmt(3).IsDefStmt(t)
+	thirdStmt.ShouldHaveName("+")
+	thirdStmt.ShouldHaveNormalParam("other")
+
+	fourthStmt := program.NthStmt(4).IsDefStmt(t)
+	fourthStmt.ShouldHaveName("<<")
+	fourthStmt.ShouldHaveNormalParam("other")
+}
+
+func TestDefStatementWithDoubleSplatParameterFail(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`def foo(**opts, a); end`, "Double splat argument should be the last parameter. got: a. Line: 0"},
+		{`def foo(**opts, k: 1); end`, "Double splat argument should be the last parameter. got: k: 1. Line: 0"},
+		{`def foo(**a, **b); end`, "Double splat argument should be the last parameter. got: **b. Line: 0"},
+		{`def foo(**opts, opts); end`, "Double splat argument should be the last parameter. got: opts. Line: 0"},
+		{`def foo(a, **a); end`, "Duplicate argument name: \"a\". Line: 0"},
+		{`def foo(**); end`, "expected next token to be IDENT, got )()) instead. Line: 0"},
+	}
+
+	for i, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		_, err := p.ParseProgram()
+
+		if err == nil {
+			t.Fatalf("At test case %d: expect an error", i)
+		}
+
+		if err.Message != tt.expected {
+			t.Errorf("At test case %d: expect error message to be:\n%s\ngot:\n%s", i, tt.expected, err.Message)
+		}
+	}
 }
 
 func TestDefStatementWithYield(t *testing.T) {
@@ -30,6 +30,20 @@ type Parser struct {
 	acceptBlock bool
 	fsm         *fsm.FSM
 	Mode        Mode
+
+	warnings []*Warning
+
+	// depth counts the keywords waiting for their `end`, so parsing can resume at the next top level statement after an error.
+	// curDepth is the depth the current token is at, before it opens or closes anything itself.
+	depth     int
+	curDepth  int
+	prevToken token.Token
+}
+
+// Warning is a piece of code that's valid but likely a mistake, found while parsing
+type Warning struct {
+	Message string
+	Line    int
 }
 
 // Mode determines the running mode. These are the enums for marking parser's mode, which decides whether it should pop unused values.
@@ -64,6 +78,8 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.Ident, p.parseIdentifier)
 	p.registerPrefix(token.Constant, p.parseConstant)
 	p.registerPrefix(token.InstanceVariable, p.parseInstanceVariable)
+	p.registerPrefix(token.ClassVariable, p.parseClassVariable)
+	p.registerPrefix(token.GlobalVariable, p.parseGlobalVariable)
 	p.registerPrefix(token.Int, p.parseIntegerLiteral)
 	p.registerPrefix(token.String, p.parseStringLiteral)
 	p.registerPrefix(token.True, p.parseBooleanLiteral)
@@ -93,11 +109,16 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.Pow, p.parseInfixExpression)
 	p.registerInfix(token.Eq, p.parseInfixExpression)
 	p.registerInfix(token.NotEq, p.parseInfixExpression)
+	p.registerInfix(token.Match, p.parseInfixExpression)
 	p.registerInfix(token.LT, p.parseInfixExpression)
 	p.registerInfix(token.LTE, p.parseInfixExpression)
 	p.registerInfix(token.GT, p.parseInfixExpression)
 	p.registerInfix(token.GTE, p.parseInfixExpression)
 	p.registerInfix(token.COMP, p.parseInfixExpression)
+	p.registerInfix(token.Append, p.parseInfixExpression)
+	p.registerInfix(token.Bar, p.parseInfixExpression)
+	p.registerInfix(token.Caret, p.parseInfixExpression)
+	p.registerInfix(token.BitAnd, p.parseInfixExpression)
 	p.registerInfix(token.And, p.parseInfixExpression)
 	p.registerInfix(token.Or, p.parseInfixExpression)
 	p.registerInfix(token.OrEq, p.parseAssignExpression)
@@ -105,6 +126,7 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.ResolutionOperator, p.parseInfixExpression)
 	p.registerInfix(token.Assign, p.parseAssignExpression)
 	p.registerInfix(token.Range, p.parseRangeExpression)
+	p.registerInfix(token.ExclusiveRange, p.parseRangeExpression)
 	p.registerInfix(token.Dot, p.parseCallExpressionWithReceiver)
 	p.registerInfix(token.LParen, p.parseCallExpressionWithoutReceiver)
 	p.registerInfix(token.LBracket, p.parseIndexExpression)
@@ -119,26 +141,62 @@ func (p *Parser) ParseProgram() (program *ast.Program, err *errors.Error) {
 
 	defer func() {
 		if recover() != nil {
-			err = p.error
-			if err == nil {
-				msg := fmt.Sprintf("Some panic happen token: %s. Line: %d", p.curToken.Literal, p.curToken.Line)
-				err = errors.InitError(msg, errors.SyntaxError)
-			}
+			err = p.panicError()
+		}
+	}()
+
+	program, errs := p.parseProgram(false)
+
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+
+	return program, nil
+}
+
+// ParseProgramWithRecovery parses the whole input even if it has syntax errors, and returns all of them.
+// After an error, parsing resumes at the next top level statement, so the returned program has every statement
+// that parsed. A statement with an error in a nested body, like a method definition, is left out as a whole.
+func (p *Parser) ParseProgramWithRecovery() (program *ast.Program, errs []*errors.Error) {
+
+	defer func() {
+		if recover() != nil {
+			errs = append(errs, p.panicError())
 		}
 	}()
 
+	return p.parseProgram(true)
+}
+
+// Warnings returns the warnings found by the last parse
+func (p *Parser) Warnings() []*Warning {
+	return p.warnings
+}
+
+func (p *Parser) parseProgram(recovering bool) (*ast.Program, []*errors.Error) {
+	var errs []*errors.Error
+
 	p.error = nil
+	p.warnings = nil
+	p.depth, p.curDepth = 0, 0
 	// Read two tokens, so curToken and peekToken are both set.
 	p.nextToken()
 	p.nextToken()
-	program = &ast.Program{}
+	program := &ast.Program{}
 	program.Statements = []ast.Statement{}
 
 	for !p.curTokenIs(token.EOF) {
-		stmt := p.parseStatement()
+		stmt, err := p.parseTopLevelStatement(recovering)
+
+		if err != nil {
+			errs = append(errs, err)
 
-		if p.error != nil {
-			return nil, p.error
+			if !recovering {
+				return nil, errs
+			}
+
+			p.skipToNextStatement(err.Line)
+			continue
 		}
 
 		if stmt != nil {
@@ -148,7 +206,7 @@ func (p *Parser) ParseProgram() (program *ast.Program, err *errors.Error) {
 		p.nextToken()
 	}
 
-	if p.Mode == TestMode {
+	if p.Mode == TestMode && (!recovering || len(program.Statements) > 0) {
 		stmt := program.Statements[len(program.Statements)-1]
 		expStmt, ok := stmt.(*ast.ExpressionStatement)
 
@@ -157,7 +215,67 @@ func (p *Parser) ParseProgram() (program *ast.Program, err *errors.Error) {
 		}
 	}
 
-	return program, nil
+	return program, errs
+}
+
+// parseTopLevelStatement parses a statement and returns the error it has, with its line set.
+// When recovering, a panic while parsing the statement is turned into an error too, so the next statements can still be parsed.
+func (p *Parser) parseTopLevelStatement(recovering bool) (stmt ast.Statement, err *errors.Error) {
+	if recovering {
+		defer func() {
+			if recover() != nil {
+				stmt, err = nil, p.panicError()
+			}
+		}()
+	}
+
+	p.error = nil
+	stmt = p.parseStatement()
+
+	if p.error != nil {
+		err = p.error
+
+		if err.Line < 0 {
+			err.Line = p.curToken.Line
+		}
+
+		return nil, err
+	}
+
+	return stmt, nil
+}
+
+// panicError returns the error the parser panicked on, or a syntax error if it panicked without one
+func (p *Parser) panicError() *errors.Error {
+	if p.error != nil {
+		return p.error
+	}
+
+	msg := fmt.Sprintf("Some panic happen token: %s. Line: %d", p.curToken.Literal, p.curToken.Line)
+	err := errors.InitError(msg, errors.SyntaxError)
+	err.Line = p.curToken.Line
+	return err
+}
+
+// skipToNextStatement skips the rest of the statement that has an error on the given line,
+// including the bodies it opened, until a token on a later line at the top level
+func (p *Parser) skipToNextStatement(line int) {
+	for !p.curTokenIs(token.EOF) {
+		if p.curDepth <= 0 && p.curToken.Line > line && !p.curTokenIs(token.End) {
+			p.depth, p.curDepth = p.depth-p.curDepth, 0
+			return
+		}
+
+		if p.depth <= 0 && p.curTokenIs(token.End) {
+			line = p.curToken.Line
+		}
+
+		p.nextToken()
+	}
+}
+
+func (p *Parser) warn(line int, format string, args ...interface{}) {
+	p.warnings = append(p.warnings, &Warning{Message: fmt.Sprintf(format, args...), Line: line})
 }
 
 func (p *Parser) parseSemicolon() ast.Expression {
@@ -181,8 +299,22 @@ func (p *Parser) curPrecedence() int {
 }
 
 func (p *Parser) nextToken() {
+	p.prevToken = p.curToken
 	p.curToken = p.peekToken
 	p.peekToken = p.Lexer.NextToken()
+	p.curDepth = p.depth
+
+	// A keyword after a dot is a method name, like `foo.class`
+	if p.prevToken.Type == token.Dot {
+		return
+	}
+
+	switch p.curToken.Type {
+	case token.Def, token.Class, token.Module, token.If, token.Case, token.Do:
+		p.depth++
+	case token.End:
+		p.depth--
+	}
 }
 
 func (p *Parser) curTokenIs(t token.Type) bool {
@@ -217,6 +349,7 @@ func (p *Parser) peekTokenAtSameLine() bool {
 func (p *Parser) peekError(t token.Type) {
 	msg := fmt.Sprintf("expected next token to be %s, got %s(%s) instead. Line: %d", t, p.peekToken.Type, p.peekToken.Literal, p.peekToken.Line)
 	p.error = errors.InitError(msg, errors.UnexpectedTokenError)
+	p.error.Line = p.peekToken.Line
 }
 
 func (p *Parser) noPrefixParseFnError(t token.Type) {
@@ -227,22 +360,44 @@ func (p *Parser) noPrefixParseFnError(t token.Type) {
 	} else {
 		p.error = errors.InitError(msg, errors.UnexpectedTokenError)
 	}
+
+	p.error.Line = p.curToken.Line
 }
 
 func (p *Parser) callConstantError(t token.Type) {
 	msg := fmt.Sprintf("cannot call %s with %s. Line: %d", t, p.peekToken.Type, p.peekToken.Line)
 	p.error = errors.InitError(msg, errors.UnexpectedTokenError)
+	p.error.Line = p.peekToken.Line
+}
+
+// operatorMethodNames marks operators that can be defined as methods, like `def <=>(other)`
+var operatorMethodNames = map[token.Type]bool{
+	token.Plus:     true,
+	token.Minus:    true,
+	token.Asterisk: true,
+	token.Pow:      true,
+	token.Slash:    true,
+	token.Modulo:   true,
+	token.LT:       true,
+	token.LTE:      true,
+	token.GT:       true,
+	token.GTE:      true,
+	token.COMP:     true,
+	token.Append:   true,
+	token.Eq:       true,
+	token.Match:    true,
 }
 
 // IsNotDefMethodToken ensures correct naming in Def statement
 func (p *Parser) IsNotDefMethodToken() bool {
 
-	return p.curToken.Type != token.Ident && !(p.peekToken.Type == token.Dot && (p.curToken.Type == token.InstanceVariable || p.curToken.Type == token.Constant || p.curToken.Type == token.Self))
+	return p.curToken.Type != token.Ident && !operatorMethodNames[p.curToken.Type] && !(p.peekToken.Type == token.Dot && (p.curToken.Type == token.InstanceVariable || p.curToken.Type == token.Constant || p.curToken.Type == token.Self))
 }
 
-// Token type InstanceVariable and Constant will trigger IsNotParamsToken()
+// Token type InstanceVariable, ClassVariable and Constant will trigger IsNotParamsToken()
 var invalidParams = map[token.Type]bool{
 	token.InstanceVariable: true,
+	token.ClassVariable:    true,
 	token.Constant:         true,
 }
 
@@ -114,6 +114,18 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"3 < 5 == true",
 			"((3 < 5) == true)",
 		},
+		{
+			"a | b & c ^ d",
+			"((a | (b & c)) ^ d)",
+		},
+		{
+			"a & b == c | d",
+			"((a & b) == (c | d))",
+		},
+		{
+			"a && b | c",
+			"(a && (b | c))",
+		},
 		{
 			"1 + (2 + 3) + 4",
 			"((1 + (2 + 3)) + 4)",
@@ -215,6 +227,38 @@ func TestProhibitingCallingCapitalizedMethod(t *testing.T) {
 	}
 }
 
+// Builtin conversion functions are the exception
+func TestCallingConversionFunction(t *testing.T) {
+	input := `
+	Rational(1, 3)
+	a = Rational(1, 3)
+	foo(Rational(1, 3))
+	`
+
+	l := lexer.New(input)
+	p := New(l)
+	program, err := p.ParseProgram()
+
+	if err != nil {
+		t.Fatal(err.Message)
+	}
+
+	if len(program.Statements) != 3 {
+		t.Fatalf("Expect 3 statements. got: %d", len(program.Statements))
+	}
+
+	exp := program.Statements[0].(*ast.ExpressionStatement).Expression
+	call, ok := exp.(*ast.CallExpression)
+
+	if !ok {
+		t.Fatalf("Expect a call expression. got: %T", exp)
+	}
+
+	if call.Method != "Rational" || len(call.Arguments) != 2 {
+		t.Fatalf("Expect to call Rational with 2 arguments. got: %s with %d arguments", call.Method, len(call.Arguments))
+	}
+}
+
 // If parser doesn't crash then we covered panic successfully
 func TestRecoverMechanism(t *testing.T) {
 	input := `
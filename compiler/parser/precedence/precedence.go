@@ -12,6 +12,9 @@ const (
 	Range
 	Equals
 	Compare
+	BitOr
+	BitAnd
+	Append
 	Sum
 	Product
 	BangPrefix
@@ -24,14 +27,20 @@ const (
 var LookupTable = map[token.Type]int{
 	token.Eq:                 Equals,
 	token.NotEq:              Equals,
+	token.Match:              Equals,
 	token.LT:                 Compare,
 	token.LTE:                Compare,
 	token.GT:                 Compare,
 	token.GTE:                Compare,
 	token.COMP:               Compare,
+	token.Bar:                BitOr,
+	token.Caret:              BitOr,
+	token.BitAnd:             BitAnd,
+	token.Append:             Append,
 	token.And:                Logic,
 	token.Or:                 Logic,
 	token.Range:              Range,
+	token.ExclusiveRange:     Range,
 	token.Plus:               Sum,
 	token.Minus:              Sum,
 	token.Modulo:             Sum,
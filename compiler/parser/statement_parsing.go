@@ -28,7 +28,7 @@ func (p *Parser) parseStatement() ast.Statement {
 	case token.Next:
 		return &ast.NextStatement{BaseNode: &ast.BaseNode{Token: p.curToken}}
 	case token.Break:
-		return &ast.BreakStatement{BaseNode: &ast.BaseNode{Token: p.curToken}}
+		return p.parseBreakStatement()
 	default:
 		exp := p.parseExpressionStatement()
 
@@ -132,7 +132,7 @@ func (p *Parser) parseParameters() []ast.Expression {
 		return nil
 	}
 
-	param := p.parseExpression(precedence.Normal)
+	param := p.parseParameter()
 	params = append(params, param)
 
 	for p.peekTokenIs(token.Comma) {
@@ -150,7 +150,7 @@ func (p *Parser) parseParameters() []ast.Expression {
 			break
 		}
 
-		param := p.parseExpression(precedence.Normal)
+		param := p.parseParameter()
 		params = append(params, param)
 	}
 
@@ -160,6 +160,22 @@ func (p *Parser) parseParameters() []ast.Expression {
 	return params
 }
 
+func (p *Parser) parseParameter() ast.Expression {
+	// `**` is the power operator elsewhere, so the double splat parameter is parsed here
+	if p.curTokenIs(token.Pow) {
+		pe := &ast.PrefixExpression{BaseNode: &ast.BaseNode{Token: p.curToken}, Operator: p.curToken.Literal}
+
+		if !p.expectPeek(token.Ident) {
+			return nil
+		}
+
+		pe.Right = p.parseIdentifier()
+		return pe
+	}
+
+	return p.parseExpression(precedence.Normal)
+}
+
 func (p *Parser) checkMethodParameters(params []ast.Expression) {
 
 	/*
@@ -167,12 +183,19 @@ func (p *Parser) checkMethodParameters(params []ast.Expression) {
 		1 means previous arg is optioned argument
 		2 means previous arg is keyword argument
 		3 means previous arg is splat argument
+		5 means previous arg is double splat argument
 	*/
 	argState := arguments.NormalArg
 
 	checkedParams := []ast.Expression{}
 
 	for _, param := range params {
+		if argState == arguments.DoubleSplatArg {
+			msg := fmt.Sprintf("Double splat argument should be the last parameter. got: %s. Line: %d", param.String(), p.curToken.Line)
+			p.error = errors.InitError(msg, errors.ArgumentError)
+			break
+		}
+
 		switch exp := param.(type) {
 		case *ast.Identifier:
 			switch argState {
@@ -214,6 +237,11 @@ func (p *Parser) checkMethodParameters(params []ast.Expression) {
 				argState = arguments.OptionalKeywordArg
 			}
 		case *ast.PrefixExpression:
+			if exp.Operator == token.Pow {
+				argState = arguments.DoubleSplatArg
+				break
+			}
+
 			switch argState {
 			case arguments.SplatArg:
 				msg := fmt.Sprintf("Can't define splat argument more than once. Line: %d", p.curToken.Line)
@@ -292,9 +320,23 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	return stmt
 }
 
+func (p *Parser) parseBreakStatement() *ast.BreakStatement {
+	stmt := &ast.BreakStatement{BaseNode: &ast.BaseNode{Token: p.curToken}}
+
+	if !p.peekTokenAtSameLine() || p.peekTokenIs(token.End) || p.peekTokenIs(token.Semicolon) {
+		return stmt
+	}
+
+	p.nextToken()
+
+	stmt.Value = p.parseExpression(precedence.Normal)
+
+	return stmt
+}
+
 func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 	stmt := &ast.ExpressionStatement{BaseNode: &ast.BaseNode{Token: p.curToken}}
-	if p.curTokenIs(token.Ident) || p.curTokenIs(token.InstanceVariable) {
+	if p.curTokenIs(token.Ident) || p.curTokenIs(token.InstanceVariable) || p.curTokenIs(token.ClassVariable) {
 		// This is used for identifying method call without parens
 		// Or multiple variable assignment
 		stmt.Expression = p.parseExpression(precedence.Lowest)
@@ -397,6 +439,8 @@ func getArgName(exp ast.Expression) string {
 	switch exp := exp.(type) {
 	case *ast.ArgumentPairExpression:
 		return exp.Key.(*ast.Identifier).Value
+	case *ast.PrefixExpression:
+		return exp.Right.TokenLiteral()
 	}
 
 	return exp.TokenLiteral()
@@ -18,6 +18,8 @@ const (
 	Constant         = "CONSTANT"
 	Ident            = "IDENT"
 	InstanceVariable = "INSTANCE_VAR"
+	ClassVariable    = "CLASS_VAR"
+	GlobalVariable   = "GLOBAL_VAR"
 	Int              = "INT"
 	Float            = "FLOAT"
 	String           = "STRING"
@@ -37,6 +39,8 @@ const (
 	Or       = "||"
 	OrEq     = "||="
 	Modulo   = "%"
+	BitAnd   = "&"
+	Caret    = "^"
 
 	LT   = "<"
 	LTE  = "<="
@@ -44,6 +48,8 @@ const (
 	GTE  = ">="
 	COMP = "<=>"
 
+	Append = "<<"
+
 	Comma     = ","
 	Semicolon = ";"
 	Colon     = ":"
@@ -58,7 +64,10 @@ const (
 
 	Eq    = "=="
 	NotEq = "!="
+	Match = "=~"
 	Range = ".."
+	// ExclusiveRange is a range that doesn't include its end
+	ExclusiveRange = "..."
 
 	True     = "TRUE"
 	False    = "FALSE"
@@ -122,6 +131,8 @@ var operators = map[string]Type{
 	"||":  Or,
 	"||=": OrEq,
 	"%":   Modulo,
+	"&":   BitAnd,
+	"^":   Caret,
 
 	"<":   LT,
 	"<=":  LTE,
@@ -129,9 +140,13 @@ var operators = map[string]Type{
 	">=":  GTE,
 	"<=>": COMP,
 
+	"<<": Append,
+
 	"==": Eq,
 	"!=": NotEq,
-	"..": Range,
+	"=~": Match,
+	"..":  Range,
+	"...": ExclusiveRange,
 
 	"::": ResolutionOperator,
 }
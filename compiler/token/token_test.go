@@ -65,6 +65,8 @@ func TestCreateOperatorIdentTrue(t *testing.T) {
 		"||":  Or,
 		"||=": OrEq,
 		"%":   Modulo,
+		"&":   BitAnd,
+		"^":   Caret,
 
 		"<":   LT,
 		"<=":  LTE,
@@ -74,7 +76,8 @@ func TestCreateOperatorIdentTrue(t *testing.T) {
 
 		"==": Eq,
 		"!=": NotEq,
-		"..": Range,
+		"..":  Range,
+		"...": ExclusiveRange,
 
 		"::": ResolutionOperator,
 	}
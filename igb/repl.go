@@ -324,7 +324,7 @@ reset:
 			instructions := ivm.g.GenerateInstructions(program.Statements)
 			ivm.v.REPLExec(instructions)
 
-			r := ivm.v.GetREPLResult()
+			r := ivm.v.GetPrettyREPLResult()
 
 			// Suppress echo back on trailing ';'
 			if igb.cmds != nil {
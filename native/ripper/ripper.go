@@ -298,6 +298,8 @@ func convertLex(t token.Type) string {
 		s = "dot"
 	case token.Eq:
 		s = "eq"
+	case token.ExclusiveRange:
+		s = "exclusiverange"
 	case token.GT:
 		s = "gt"
 	case token.GTE:
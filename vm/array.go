@@ -1,7 +1,7 @@
 package vm
 
 import (
-	"bytes"
+	"math"
 	"strings"
 
 	"sort"
@@ -10,6 +10,12 @@ import (
 	"github.com/goby-lang/goby/vm/errors"
 )
 
+const (
+	comparisonFailed = "comparison of %s with %s failed"
+	tooBigToProduct  = "Too big to product: more than %d combinations"
+	tooBigToFill     = "Too big to fill: more than %d elements"
+)
+
 // ArrayObject represents an instance from Array class.
 // An array is a collection of different objects that are ordered and indexed.
 // Elements in an array can belong to any class and you can also build a "tuple" within an array.
@@ -23,6 +29,28 @@ type ArrayObject struct {
 // Class methods --------------------------------------------------------
 var builtinArrayClassMethods = []*BuiltinMethodObject{
 	{
+		// Returns a new array with the given size. The elements are nil by default.
+		// If a second argument is given, every element is that very object: it's not copied,
+		// so mutating one element mutates them all.
+		// If a block is given, each element is the block's result for its index instead.
+		// The array's storage is allocated once up front, so no reallocation happens while it's filled.
+		// A negative size raises an ArgumentError.
+		//
+		// ```ruby
+		// Array.new          #=> []
+		// Array.new(3)       #=> [nil, nil, nil]
+		// Array.new(3, true) #=> [true, true, true]
+		// Array.new(3) do |i|
+		//   i * 2
+		// end                #=> [0, 2, 4]
+		//
+		// a = Array.new(2, "a")
+		// a[0].concat("b")
+		// a                  #=> ["ab", "ab"]
+		// ```
+		//
+		// @param size [Integer], default value [Object], block
+		// @return [Array]
 		Name: "new",
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
 			if len(args) > 2 {
@@ -40,11 +68,21 @@ var builtinArrayClassMethods = []*BuiltinMethodObject{
 					return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, "Negative Array Size")
 				}
 
+				if err := t.reserveMemory(multipliedSize(elementSize, n.value), "Array.new", sourceLine); err != nil {
+					return err
+				}
+
 				elems := make([]Object, n.value)
 
 				if blockFrame != nil && !blockIsEmpty(blockFrame) {
 					for i := range elems {
-						elems[i] = t.builtinMethodYield(blockFrame, t.vm.InitIntegerObject(i))
+						elem, erred := t.builtinMethodYield(blockFrame, t.vm.InitIntegerObject(i))
+
+						if erred {
+							return elem
+						}
+
+						elems[i] = elem
 					}
 				} else {
 					var elem Object
@@ -71,8 +109,9 @@ var builtinArrayClassMethods = []*BuiltinMethodObject{
 // Instance methods -----------------------------------------------------
 var builtinArrayInstanceMethods = []*BuiltinMethodObject{
 	{
-		// Retrieves an object in an array using Integer index.
-		// The index starts from 0. It returns `null` if the given index is bigger than its size.
+		// Retrieves an object or a subarray in an array, following Ruby's indexing rules.
+		// The index starts from 0 and a negative index counts from the end of the array.
+		// It returns `nil` if the given index is out of the array.
 		//
 		// ```ruby
 		// a = [1, 2, 3, "a", "b", "c"]
@@ -86,39 +125,28 @@ var builtinArrayInstanceMethods = []*BuiltinMethodObject{
 		// # Double indexing, second argument specifies the count of the elements
 		// a[1, 3]  #=> [2, 3, "a"]
 		// a[1, 0]  #=> [] <-- Zero count is empty
-		// a[1, 5]  #=> [2, 3, "a", "b", "c"]
 		// a[1, 10] #=> [2, 3, "a", "b", "c"]
 		// a[-3, 2] #=> ["a", "b"]
-		// a[-3, 5] #=> ["a", "b", "c"]
-		// a[5, 1]  #=> ["c"]
-		// a[6, 1]  #=> []
+		// a[6, 1]  #=> [] <-- Not nil! The start can be at the end of the array
 		// a[7, 1]  #=> nil
-		//
-		// Special case 1:
-		// a[6]    #=> nil
-		// a[6, 1] #=> []  <-- Not nil!
-		// a[7, 1] #=> nil <-- Because it is really out of the edge of the array
-		//
-		// Special case 2: Second argument is negative
-		// This behaviour is different from Ruby itself, in Ruby, it returns "nil".
-		// However, in Goby, it raises error because there cannot be negative count values.
-		//
-		// a[1, -1]  #=> ArgumentError: Expect second argument to be positive value. got: -1
-		// a[-4, -3] #=> ArgumentError: Expect second argument to be positive value. got: -3
-		//
-		// Special case 3: First argument is negative and exceed the array length
-		// a[-6, 1] #=> [1]
-		// a[-6, 0] #=> []
-		// a[-7, 1] #=> ArgumentError: Index value -7 too small for array. minimum: -6
-		// a[-7, 0] #=> ArgumentError: Index value -7 too small for array. minimum: -6
+		// a[-7, 1] #=> nil
+		// a[1, -1] #=> nil
+		//
+		// # Range indexing
+		// a[1..3]  #=> [2, 3, "a"]
+		// a[-3..-1] #=> ["a", "b", "c"]
+		// a[4..10] #=> ["b", "c"]
+		// a[3..1]  #=> []
+		// a[6..7]  #=> []
+		// a[7..8]  #=> nil
 		// ```
 		//
 		// Note:
 		// * The notations such as `a.[](1)` or `a.[] 1` are unsupported.
-		// * `Range` object is unsupported for now.
 		//
 		// @param index [Integer], (count [Integer])
-		// @return [Array]
+		// @param range [Range]
+		// @return [Object]
 		Name: "[]",
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
 			arr := receiver.(*ArrayObject)
@@ -150,7 +178,7 @@ var builtinArrayInstanceMethods = []*BuiltinMethodObject{
 				return typeErr
 			}
 
-			return receiver.(*ArrayObject).concatenateCopies(t, args[0].Value().(int))
+			return receiver.(*ArrayObject).concatenateCopies(t, args[0].Value().(int), sourceLine)
 		},
 	},
 	{
@@ -186,11 +214,10 @@ var builtinArrayInstanceMethods = []*BuiltinMethodObject{
 		},
 	},
 	{
-		// Assigns one or more values to an array. It requires one or two indices and a value as argument.
-		// The first index should be Integer, and the second index should be zero or positive integer.
-		// The array will expand if the assigned index is bigger than the current size of self.
+		// Assigns one or more values to an array. It requires an index, an index with a count or a range, and a value as arguments.
+		// The array will expand if the assigned index is bigger than the current size of self,
+		// and the gaps will be filled with `nil`, but such operations should be avoided.
 		// Returns the assigned value.
-		// The gaps will be filled with `nil`, but such operations should be avoided.
 		//
 		// ```ruby
 		// a = []
@@ -229,138 +256,52 @@ var builtinArrayInstanceMethods = []*BuiltinMethodObject{
 		// a #=> [1, 2, "a", "b", "c", 5]
 		//
 		// a = [1, 2, 3, 4, 5]
-		// a[-5, 3] = [:a, :b, :c]  # <-- Negative index edge case
-		// a #=> ["a", "b", "c", 4, 5]
-		//
-		// a = [1, 2, 3, 4, 5]
-		// a[-5, 4] = [:a, :b, :c]  # <-- Negative index exceeded case: `4` will be destroyed
-		// a #=> ["a", "b", "c", 5]
+		// a[1..2] = [:a]           # <-- Range case: works like `a[1, 2]`
+		// a #=> [1, "a", 4, 5]
 		//
 		// a = [1, 2, 3, 4, 5]
-		// a[-5, 5] = [:a, :b, :c]  # <-- Negative index exceeded case: `4, 5` will be destroyed
-		// a #=> ["a", "b", "c"]
+		// a[-6, 4] = [:a, :b, :c]  # <-- Invalid: Negative index too small case
+		// # IndexError: Index value -6 too small for array. minimum: -5
 		//
 		// a = [1, 2, 3, 4, 5]
-		// a[-6, 4] = [:a, :b, :c]     # <-- Invalid: Negative index too small case
-		// # ArgumentError: Index value -6 too small for array. minimum: -5
-		//
-		// a = [1, 2, 3, 4, 5]
-		// a[6, -4] = [9, 8, 7]     # <-- Weak array assignment with negative count case
-		// # ArgumentError: Expect second argument to be positive. got: -4
+		// a[6, -4] = [9, 8, 7]     # <-- Invalid: Negative count case
+		// # IndexError: Expect second argument to be positive value. got: -4
 		// ```
 		//
 		// Note that passing multiple values to the method is unavailable.
 		//
 		// @param index [Integer], object [Object]
 		// @param index [Integer], count [Integer], object [Object]
-		// @return [Array]
+		// @param range [Range], object [Object]
+		// @return [Object]
 		Name: "[]=",
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
-
-			// First argument is an index: there exists two cases which will be described in the following code
 			aLen := len(args)
 			if aLen < 2 || aLen > 3 {
 				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgumentRange, 2, 3, aLen)
 			}
 
-
-			typeErr := t.vm.checkArgTypes(args, sourceLine, classes.IntegerClass)
-
-			if typeErr != nil {
-				return typeErr
-			}
-
-			indexValue := args[0].Value().(int)
 			arr := receiver.(*ArrayObject)
+			indices, value := args[:aLen-1], args[aLen-1]
 
-			// <Three Argument Case>
-			// Second argument: the length of successive array values (zero or positive Integer)
-			// Third argument: the assignment value (object)
-			if aLen == 3 {
-				// Negative index value too small
-				if indexValue < 0 {
-					if arr.normalizeIndex(indexValue) == -1 {
-						return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.TooSmallIndexValue, indexValue, -arr.Len())
-					}
-					indexValue = arr.normalizeIndex(indexValue)
-				}
-
-				c := args[1]
-				count, ok := c.(*IntegerObject)
-
-				// Second argument must be an integer
-				if !ok {
-					return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.IntegerClass, args[1].Class().Name)
-				}
-
-				countValue := count.value
-				// Second argument must be a positive value
-				if countValue < 0 {
-					return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.NegativeSecondValue, count.value)
-				}
-
-				a := args[2]
-				assignedValue, isArray := a.(*ArrayObject)
-
-				// Expand the array with nil; the second index is unnecessary in the case
-				if indexValue >= arr.Len() {
-
-					for arr.Len() < indexValue {
-						arr.Elements = append(arr.Elements, NULL)
-					}
-
-					if isArray {
-						arr.Elements = append(arr.Elements, assignedValue.Elements...)
-					} else {
-						arr.Elements = append(arr.Elements, a)
-					}
-					return a
-				}
+			span, tooSmall, err := resolveAssignmentIndex(t, indices, arr.Len(), sourceLine)
 
-				endValue := indexValue + countValue
-				// the case the addition of index and count is too large
-				if endValue > arr.Len() {
-					endValue = arr.Len()
-				}
-
-				arr.Elements = append(arr.Elements[:indexValue], arr.Elements[endValue:]...)
-
-				// If assigned value is an array, then splat the array and push each element to the receiver
-				// following the first and second indices
-				if isArray {
-					arr.Elements = append(arr.Elements[:indexValue], append(assignedValue.Elements, arr.Elements[indexValue:]...)...)
-				} else {
-					arr.Elements = append(arr.Elements[:indexValue], append([]Object{a}, arr.Elements[indexValue:]...)...)
-				}
-
-				return a
+			if err != nil {
+				return err
 			}
 
-			// <Two Argument Case>
-			// Second argument is the assignment value (object)
-
-			// Negative index value condition
-			if indexValue < 0 {
-				if len(arr.Elements) < -indexValue {
-					return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.TooSmallIndexValue, indexValue, -arr.Len())
-				}
-				arr.Elements[len(arr.Elements)+indexValue] = args[1]
-				return arr.Elements[len(arr.Elements)+indexValue]
+			if tooSmall {
+				return t.vm.InitErrorObject(errors.IndexError, sourceLine, errors.TooSmallIndexValue, indexStart(indices[0]), -arr.Len())
 			}
 
-			// Expand the array
-			if len(arr.Elements) < (indexValue + 1) {
-				newArr := make([]Object, indexValue+1)
-				copy(newArr, arr.Elements)
-				for i := len(arr.Elements); i <= indexValue; i++ {
-					newArr[i] = NULL
-				}
-				arr.Elements = newArr
+			// Only a subarray assignment splats the assigned array
+			values := []Object{value}
+			if assignedArray, ok := value.(*ArrayObject); ok && !span.single {
+				values = assignedArray.Elements
 			}
 
-			arr.Elements[indexValue] = args[1]
-
-			return arr.Elements[indexValue]
+			arr.splice(span, values)
+			return value
 
 		},
 	},
@@ -406,12 +347,12 @@ var builtinArrayInstanceMethods = []*BuiltinMethodObject{
 				return FALSE
 			}
 
-			if len(arr.Elements) == 0 {
-				t.callFrameStack.pop()
-			}
-
 			for _, obj := range arr.Elements {
-				result := t.builtinMethodYield(blockFrame, obj)
+				result, erred := t.builtinMethodYield(blockFrame, obj)
+
+				if erred {
+					return result
+				}
 
 				if result.isTruthy() {
 					return TRUE
@@ -422,6 +363,29 @@ var builtinArrayInstanceMethods = []*BuiltinMethodObject{
 
 		},
 	},
+	{
+		// Searches an array of arrays, and returns the first sub-array whose first element equals to the given key.
+		// Elements which aren't arrays are skipped. Returns `nil` if nothing matches.
+		//
+		// ```ruby
+		// a = [["a", 1], ["b", 2], ["b", 3]]
+		// a.assoc("b") #=> ["b", 2]
+		// a.assoc("c") #=> nil
+		// ```
+		//
+		// @param key [Object]
+		// @return [Array]
+		Name: "assoc",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			arr := receiver.(*ArrayObject)
+			return arr.findPair(args[0], 0)
+
+		},
+	},
 	{
 		// Retrieves an object in an array using the given index.
 		// The index is 0-based; `nil` is returned when trying to access the index out of bounds.
@@ -442,11 +406,115 @@ var builtinArrayInstanceMethods = []*BuiltinMethodObject{
 				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
 			}
 
+			typeErr := t.vm.checkArgTypes(args, sourceLine, classes.IntegerClass)
+
+			if typeErr != nil {
+				return typeErr
+			}
+
 			arr := receiver.(*ArrayObject)
 			return arr.index(t, args, sourceLine)
 
 		},
 	},
+	{
+		// Finds an element of a sorted array with binary search, in O(log n) calls of the block.
+		// The array must be sorted with respect to the block, otherwise the result is unspecified.
+		//
+		// In find-minimum mode, the block returns true or false (or nil), and is false for the elements
+		// before some position and true for the ones from it. The first element the block is true for is returned,
+		// or nil if the block is never true.
+		//
+		// ```ruby
+		// a = [0, 4, 7, 10, 12]
+		// a.bsearch do |x|
+		//   x >= 4
+		// end # => 4
+		// a.bsearch do |x|
+		//   x >= 100
+		// end # => nil
+		// ```
+		//
+		// In find-any mode, the block returns a number, which is positive for the elements before the wanted ones,
+		// 0 for the wanted ones and negative for the ones after them. Any of the elements the block returns 0 for is returned,
+		// or nil if there's none.
+		//
+		// ```ruby
+		// a = [0, 100, 100, 100, 200]
+		// a.bsearch do |x|
+		//   100 - x
+		// end # => 100
+		// a.bsearch do |x|
+		//   50 - x
+		// end # => nil
+		// ```
+		//
+		// @param block literal
+		// @return [Object]
+		Name: "bsearch",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			if blockFrame == nil {
+				return t.vm.InitErrorObject(errors.InternalError, sourceLine, errors.CantYieldWithoutBlockFormat)
+			}
+
+			arr := receiver.(*ArrayObject)
+			i, err := t.bsearchIndex(arr.Elements, blockFrame, sourceLine)
+
+			if err != nil {
+				return err
+			}
+
+			if i < 0 {
+				return NULL
+			}
+
+			return arr.Elements[i]
+
+		},
+	},
+	{
+		// The same as `bsearch`, but returns the index of the element found instead of the element itself.
+		//
+		// ```ruby
+		// a = [0, 4, 7, 10, 12]
+		// a.bsearch_index do |x|
+		//   x >= 5
+		// end # => 2
+		// a.bsearch_index do |x|
+		//   10 - x
+		// end # => 3
+		// ```
+		//
+		// @param block literal
+		// @return [Integer]
+		Name: "bsearch_index",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			if blockFrame == nil {
+				return t.vm.InitErrorObject(errors.InternalError, sourceLine, errors.CantYieldWithoutBlockFormat)
+			}
+
+			i, err := t.bsearchIndex(receiver.(*ArrayObject).Elements, blockFrame, sourceLine)
+
+			if err != nil {
+				return err
+			}
+
+			if i < 0 {
+				return NULL
+			}
+
+			return t.vm.InitIntegerObject(i)
+
+		},
+	},
 	{
 		// Removes all elements in the array and returns an empty array.
 		//
@@ -469,6 +537,68 @@ var builtinArrayInstanceMethods = []*BuiltinMethodObject{
 			return arr
 		},
 	},
+	{
+		// Returns a new array without the `nil` elements. The receiver isn't changed, see `compact!` for the destructive version.
+		//
+		// ```ruby
+		// a = [1, nil, 2, nil]
+		// a.compact #=> [1, 2]
+		// a         #=> [1, nil, 2, nil]
+		// ```
+		//
+		// @return [Array]
+		Name: "compact",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			arr := receiver.(*ArrayObject)
+			elems := []Object{}
+
+			for _, e := range arr.Elements {
+				if _, isNull := e.(*NullObject); !isNull {
+					elems = append(elems, e)
+				}
+			}
+
+			return t.vm.InitArrayObject(elems)
+
+		},
+	},
+	{
+		// A destructive method.
+		// Removes all the `nil` elements from the array in place.
+		// Returns the array, or `nil` if no element was removed.
+		//
+		// ```ruby
+		// a = [1, nil, 2, nil]
+		// a.compact! #=> [1, 2]
+		// a          #=> [1, 2]
+		// a.compact! #=> nil
+		// ```
+		//
+		// @return [Array]
+		Name: "compact!",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			arr := receiver.(*ArrayObject)
+			changed := arr.keepIf(func(e Object) bool {
+				_, isNull := e.(*NullObject)
+				return !isNull
+			})
+
+			if !changed {
+				return NULL
+			}
+
+			return arr
+
+		},
+	},
 	{
 		// Concatenation: returns a new array by just concatenating the arrays.
 		// Empty or multiple arrays can be taken.
@@ -535,12 +665,13 @@ var builtinArrayInstanceMethods = []*BuiltinMethodObject{
 				if blockIsEmpty(blockFrame) {
 					return t.vm.InitIntegerObject(0)
 				}
-				if len(arr.Elements) == 0 {
-					t.callFrameStack.pop()
-				}
-
 				for _, obj := range arr.Elements {
-					result := t.builtinMethodYield(blockFrame, obj)
+					result, erred := t.builtinMethodYield(blockFrame, obj)
+
+					if erred {
+						return result
+					}
+
 					if result.isTruthy() {
 						count++
 					}
@@ -625,18 +756,21 @@ var builtinArrayInstanceMethods = []*BuiltinMethodObject{
 		},
 	},
 	{
-		// Returns the value from the nested array, specified by one or more indices,
-		// Returns `nil` if one of the intermediate values are `nil`.
+		// Returns the value from the nested arrays and hashes, specified by one or more keys.
+		// Integer keys index into arrays (negative ones count from the end), and other keys look up nested hashes.
+		// Returns `nil` if an index is out of range, a key is missing, or one of the intermediate values is `nil`.
 		//
 		// ```Ruby
 		// [1 , 2].dig(-2)      #=> 1
 		// [[], 2].dig(0, 1)    #=> nil
 		// [[], 2].dig(0, 1, 2) #=> nil
+		// [nil, 2].dig(0, 1)   #=> nil
 		// [[1, 2, [3, [8, [9]]]], 4, 5].dig(0, 2, 1, 1, 0) #=> 9
+		// [{ a: [1, { b: 2 }] }].dig(-1, :a, -1, :b)       #=> 2
 		// [1, 2].dig(0, 1)     #=> TypeError: Expect target to be Diggable
 		// ```
 		//
-		// @param index [Integer]...
+		// @param key [Object]...
 		// @return [Object]
 		Name: "dig",
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
@@ -695,7 +829,7 @@ var builtinArrayInstanceMethods = []*BuiltinMethodObject{
 	{
 		// Loops through each element in the array, with the given block.
 		// Returns self.
-		// A block literal is required.
+		// Without a block, returns an ArrayEnumerator of the elements instead.
 		//
 		// ```ruby
 		// a = ["a", "b", "c"]
@@ -708,6 +842,10 @@ var builtinArrayInstanceMethods = []*BuiltinMethodObject{
 		// #=> "cc"
 		// puts b
 		// #=> ["a", "b", "c"]
+		//
+		// e = a.each
+		// e.next # => "a"
+		// e.map do |s| s.upcase end # => ["A", "B", "C"]
 		// ```
 		//
 		// @param block literal
@@ -719,7 +857,7 @@ var builtinArrayInstanceMethods = []*BuiltinMethodObject{
 			}
 
 			if blockFrame == nil {
-				return t.vm.InitErrorObject(errors.InternalError, sourceLine, errors.CantYieldWithoutBlockFormat)
+				return t.callMethod(receiver, "to_enum", sourceLine)
 			}
 
 			arr := receiver.(*ArrayObject)
@@ -727,13 +865,10 @@ var builtinArrayInstanceMethods = []*BuiltinMethodObject{
 				return arr
 			}
 
-			// If it's an empty array, pop the block's call frame
-			if len(arr.Elements) == 0 {
-				t.callFrameStack.pop()
-			}
-
 			for _, obj := range arr.Elements {
-				t.builtinMethodYield(blockFrame, obj)
+				if result, erred := t.builtinMethodYield(blockFrame, obj); erred {
+					return result
+				}
 			}
 			return arr
 
@@ -775,18 +910,65 @@ var builtinArrayInstanceMethods = []*BuiltinMethodObject{
 				return arr
 			}
 
-			// If it's an empty array, pop the block's call frame
-			if len(arr.Elements) == 0 {
-				t.callFrameStack.pop()
-			}
-
 			for i := range arr.Elements {
-				t.builtinMethodYield(blockFrame, t.vm.InitIntegerObject(i))
+				if result, erred := t.builtinMethodYield(blockFrame, t.vm.InitIntegerObject(i)); erred {
+					return result
+				}
 			}
 			return arr
 
 		},
 	},
+	{
+		// Calls the block with each element and the given memo object, and returns the memo.
+		// Unlike `reduce`, the block's result isn't passed to the next call:
+		// the same memo is given every time, so the block accumulates into it by mutating it.
+		//
+		// ```ruby
+		// a = ["apple", "fig", "kiwi"]
+		//
+		// a.each_with_object({}) do |word, lengths|
+		//   lengths[word] = word.length
+		// end
+		// #=> { apple: 5, fig: 3, kiwi: 4 }
+		//
+		// a.each_with_object([]) do |word, long|
+		//   if word.length > 3
+		//     long.push(word)
+		//   end
+		// end
+		// #=> ["apple", "kiwi"]
+		// ```
+		//
+		// @param memo [Object], block literal with two block parameters
+		// @return [Object]
+		Name: "each_with_object",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			if blockFrame == nil {
+				return t.vm.InitErrorObject(errors.InternalError, sourceLine, errors.CantYieldWithoutBlockFormat)
+			}
+
+			arr := receiver.(*ArrayObject)
+			memo := args[0]
+
+			if blockIsEmpty(blockFrame) {
+				return memo
+			}
+
+			for _, obj := range arr.Elements {
+				if result, erred := t.builtinMethodYield(blockFrame, obj, memo); erred {
+					return result
+				}
+			}
+
+			return memo
+
+		},
+	},
 	{
 		// A predicate method.
 		// Returns if the array"s length is 0 or not.
@@ -815,32 +997,145 @@ var builtinArrayInstanceMethods = []*BuiltinMethodObject{
 		},
 	},
 	{
-		// Returns the first element of the array.
-		// If a count 'n' is provided as an argument, it returns the array of the first n elements.
+		// A destructive method.
+		// Fills the array with the given value, or with the block's result for each index.
+		// A start index and a length can be given to fill only a part of the array;
+		// the array is extended if the part goes beyond its end.
+		// A negative start index counts from the end of the array.
+		// Returns the array.
 		//
 		// ```ruby
-		// [1, 2, 3].first                            #=> 1
-		// [:apple, :orange, :grape, :melon].first    #=> "apple"
-		// [:apple, :orange, :grape, :melon].first(2) #=> ["apple", "orange"]
+		// a = [1, 2, 3, 4]
+		// a.fill(0)       #=> [0, 0, 0, 0]
+		// a.fill(9, 2)    #=> [0, 0, 9, 9]
+		// a.fill(7, 1, 1) #=> [0, 7, 9, 9]
+		// a.fill(5, 3, 3) #=> [0, 7, 9, 5, 5, 5]
+		// a.fill(1, -2)   #=> [0, 7, 9, 5, 1, 1]
+		//
+		// a.fill do |i|
+		//   i * i
+		// end             #=> [0, 1, 4, 9, 16, 25]
+		//
+		// a.fill(4) do |i|
+		//   0
+		// end             #=> [0, 1, 4, 9, 0, 0]
 		// ```
 		//
-		// @param count [Integer]
-		// @return [Object]
-		Name: "first",
+		// @param value [Object], start [Integer], length [Integer]
+		// @return [Array]
+		Name: "fill",
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			arr := receiver.(*ArrayObject)
 			aLen := len(args)
-			if aLen > 1 {
-				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgumentLess, 1, aLen)
-			}
+			var value Object
+			var bounds []Object
 
-			arr := receiver.(*ArrayObject)
-			arrLength := len(arr.Elements)
-			if arrLength == 0 {
-				return NULL
+			if blockFrame != nil {
+				if aLen > 2 {
+					return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgumentLess, 2, aLen)
+				}
+
+				bounds = args
+			} else {
+				if aLen < 1 || aLen > 3 {
+					return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgumentRange, 1, 3, aLen)
+				}
+
+				value = args[0]
+				bounds = args[1:]
 			}
 
-			if aLen == 0 {
-				return arr.Elements[0]
+			for i, b := range bounds {
+				if _, ok := b.(*IntegerObject); !ok {
+					return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormatNum, aLen-len(bounds)+i+1, classes.IntegerClass, b.Class().Name)
+				}
+			}
+
+			start := 0
+			end := len(arr.Elements)
+
+			if len(bounds) > 0 {
+				start = bounds[0].(*IntegerObject).value
+
+				if start < 0 {
+					start += len(arr.Elements)
+
+					if start < 0 {
+						start = 0
+					}
+				}
+			}
+
+			if len(bounds) > 1 {
+				length := bounds[1].(*IntegerObject).value
+
+				if length > math.MaxInt32-start {
+					return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, tooBigToFill, math.MaxInt32)
+				}
+
+				end = start + length
+			}
+
+			if end > len(arr.Elements) {
+				if err := t.reserveMemory(multipliedSize(elementSize, end-len(arr.Elements)), "Array#fill", sourceLine); err != nil {
+					return err
+				}
+
+				for len(arr.Elements) < start {
+					arr.Elements = append(arr.Elements, NULL)
+				}
+
+				arr.Elements = append(arr.Elements, make([]Object, end-len(arr.Elements))...)
+			}
+
+			for i := start; i < end; i++ {
+				if blockFrame != nil {
+					elem, erred := t.builtinMethodYield(blockFrame, t.vm.InitIntegerObject(i))
+
+					if erred {
+						return elem
+					}
+
+					arr.Elements[i] = elem
+				} else {
+					arr.Elements[i] = value
+				}
+			}
+
+			return arr
+
+		},
+	},
+	{
+		// Returns the first element of the array.
+		// If a count 'n' is provided as an argument, it returns the array of the first n elements.
+		//
+		// ```ruby
+		// [1, 2, 3].first                            #=> 1
+		// [:apple, :orange, :grape, :melon].first    #=> "apple"
+		// [:apple, :orange, :grape, :melon].first(2) #=> ["apple", "orange"]
+		// [1, 2, 3].first(5)                         #=> [1, 2, 3]
+		// [].first(2)                                #=> []
+		// ```
+		//
+		// @param count [Integer]
+		// @return [Object]
+		Name: "first",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			aLen := len(args)
+			if aLen > 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgumentLess, 1, aLen)
+			}
+
+			arr := receiver.(*ArrayObject)
+			arrLength := len(arr.Elements)
+
+			if aLen == 0 {
+				if arrLength == 0 {
+					return NULL
+				}
+
+				return arr.Elements[0]
 			}
 
 			typeErr := t.vm.checkArgTypes(args, sourceLine, classes.IntegerClass)
@@ -851,14 +1146,15 @@ var builtinArrayInstanceMethods = []*BuiltinMethodObject{
 
 			value := args[0].Value().(int)
 
-			if value < 1 {
+			if value < 0 {
 				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.NegativeValue, value)
 			}
 
-			if arrLength > value {
-				return t.vm.InitArrayObject(arr.Elements[:value])
+			if value > arrLength {
+				value = arrLength
 			}
-			return arr
+
+			return t.vm.InitArrayObject(arr.copyElements(0, value))
 
 		},
 	},
@@ -889,6 +1185,38 @@ var builtinArrayInstanceMethods = []*BuiltinMethodObject{
 
 		},
 	},
+	{
+		// A destructive method.
+		// Flattens the array in place like `#flatten`.
+		// Returns the array, or `nil` if the array has no nested arrays.
+		//
+		// ```ruby
+		// a = [1, [2, [3]]]
+		// a.flatten! #=> [1, 2, 3]
+		// a          #=> [1, 2, 3]
+		// a.flatten! #=> nil
+		// ```
+		//
+		// @return [Array]
+		Name: "flatten!",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			arr := receiver.(*ArrayObject)
+
+			for _, e := range arr.Elements {
+				if _, isArray := e.(*ArrayObject); isArray {
+					arr.Elements = arr.flatten()
+					return arr
+				}
+			}
+
+			return NULL
+
+		},
+	},
 	{
 		// Returns a new hash from the element of the receiver (array) as keys, and generates respective values of hash from the keys by using the block provided.
 		// The method can take a default value, and a block is required.
@@ -921,21 +1249,28 @@ var builtinArrayInstanceMethods = []*BuiltinMethodObject{
 			}
 
 			a := receiver.(*ArrayObject)
-			// If it's an empty array, pop the block's call frame
-			if len(a.Elements) == 0 {
-				t.callFrameStack.pop()
-			}
-
 			hash := make(map[string]Object)
 			switch len(args) {
 			case 0:
 				for _, obj := range a.Elements {
-					hash[obj.ToString()] = t.builtinMethodYield(blockFrame, obj)
+					value, erred := t.builtinMethodYield(blockFrame, obj)
+
+					if erred {
+						return value
+					}
+
+					hash[obj.ToString()] = value
 				}
 			case 1:
 				arg := args[0]
 				for _, obj := range a.Elements {
-					switch b := t.builtinMethodYield(blockFrame, obj); b.(type) {
+					b, erred := t.builtinMethodYield(blockFrame, obj)
+
+					if erred {
+						return b
+					}
+
+					switch b.(type) {
 					case *NullObject:
 						hash[obj.ToString()] = arg
 					default:
@@ -950,6 +1285,57 @@ var builtinArrayInstanceMethods = []*BuiltinMethodObject{
 
 		},
 	},
+	{
+		// Inserts the value into a sorted array, after the elements that are less than or equal to it,
+		// so the array stays sorted, and returns the array. The position is found with binary search,
+		// comparing the elements with the value by `<=>`. If the array isn't sorted, the value is inserted
+		// at an unspecified position.
+		//
+		// ```ruby
+		// a = [1, 3, 5]
+		// a.insert_sorted(4) # => [1, 3, 4, 5]
+		// a.insert_sorted(0) # => [0, 1, 3, 4, 5]
+		// a.insert_sorted(9) # => [0, 1, 3, 4, 5, 9]
+		// ["b", "d"].insert_sorted("c") # => ["b", "c", "d"]
+		// [1, 2].insert_sorted("a") # => TypeError: Expect argument to be Numeric. got: String
+		// ```
+		//
+		// @param value [Object]
+		// @return [Array]
+		Name: "insert_sorted",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			arr := receiver.(*ArrayObject)
+			value := args[0]
+
+			low, high := 0, len(arr.Elements)
+
+			for low < high {
+				mid := low + (high-low)/2
+				cmp, err := t.spaceship(arr.Elements[mid], value, sourceLine)
+
+				if err != nil {
+					return err
+				}
+
+				if cmp <= 0 {
+					low = mid + 1
+				} else {
+					high = mid
+				}
+			}
+
+			arr.Elements = append(arr.Elements, nil)
+			copy(arr.Elements[low+1:], arr.Elements[low:])
+			arr.Elements[low] = value
+
+			return arr
+
+		},
+	},
 	{
 		// Returns a string by concatenating each element to string, separated by given separator.
 		// If the array is nested, they will be flattened and then concatenated.
@@ -1002,6 +1388,8 @@ var builtinArrayInstanceMethods = []*BuiltinMethodObject{
 		// [1, 2, 3].last                            #=> 3
 		// [:apple, :orange, :grape, :melon].last    #=> "melon"
 		// [:apple, :orange, :grape, :melon].last(2) #=> ["grape", "melon"]
+		// [1, 2, 3].last(5)                         #=> [1, 2, 3]
+		// [].last(2)                                #=> []
 		// ```
 		//
 		// @param count [Integer]
@@ -1033,15 +1421,15 @@ var builtinArrayInstanceMethods = []*BuiltinMethodObject{
 
 			value := args[0].Value().(int)
 
-
-			if value < 1 {
+			if value < 0 {
 				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.NegativeValue, value)
 			}
 
-			if arrLength > value {
-				return t.vm.InitArrayObject(arr.Elements[arrLength-value : arrLength])
+			if value > arrLength {
+				value = arrLength
 			}
-			return arr
+
+			return t.vm.InitArrayObject(arr.copyElements(arrLength-value, arrLength))
 
 		},
 	},
@@ -1097,18 +1485,19 @@ var builtinArrayInstanceMethods = []*BuiltinMethodObject{
 				return t.vm.InitErrorObject(errors.InternalError, sourceLine, errors.CantYieldWithoutBlockFormat)
 			}
 
-			// If it's an empty array, pop the block's call frame
-			if len(arr.Elements) == 0 {
-				t.callFrameStack.pop()
-			}
-
 			if blockIsEmpty(blockFrame) {
 				for i := 0; i < len(arr.Elements); i++ {
 					elements[i] = NULL
 				}
 			} else {
 				for i, obj := range arr.Elements {
-					elements[i] = t.builtinMethodYield(blockFrame, obj)
+					elem, erred := t.builtinMethodYield(blockFrame, obj)
+
+					if erred {
+						return elem
+					}
+
+					elements[i] = elem
 				}
 			}
 
@@ -1116,6 +1505,53 @@ var builtinArrayInstanceMethods = []*BuiltinMethodObject{
 
 		},
 	},
+	{
+		// A destructive method.
+		// Works like `#map`, but replaces each element with the block's result in place.
+		// Returns the array.
+		//
+		// ```ruby
+		// a = [1, 2, 3]
+		// a.map! do |i|
+		//   i * 10
+		// end #=> [10, 20, 30]
+		// a   #=> [10, 20, 30]
+		// ```
+		//
+		// @param block literal
+		// @return [Array]
+		Name: "map!",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			if blockFrame == nil {
+				return t.vm.InitErrorObject(errors.InternalError, sourceLine, errors.CantYieldWithoutBlockFormat)
+			}
+
+			arr := receiver.(*ArrayObject)
+
+			if blockIsEmpty(blockFrame) {
+				for i := range arr.Elements {
+					arr.Elements[i] = NULL
+				}
+			} else {
+				for i, obj := range arr.Elements {
+					elem, erred := t.builtinMethodYield(blockFrame, obj)
+
+					if erred {
+						return elem
+					}
+
+					arr.Elements[i] = elem
+				}
+			}
+
+			return arr
+
+		},
+	},
 	{
 		// A destructive method.
 		// Removes the last element in the array and returns it.
@@ -1138,6 +1574,71 @@ var builtinArrayInstanceMethods = []*BuiltinMethodObject{
 
 		},
 	},
+	{
+		// Returns the cartesian product of self and the given arrays: an array of all combinations
+		// that take one element from each array, in order. The combinations are ordered like nested loops,
+		// with the last array varying fastest. Without arguments, each element is wrapped in an array.
+		//
+		// The result has as many combinations as the product of the arrays' lengths,
+		// so it grows quickly with the number of arrays. It raises an ArgumentError if there'd be more than 2 ** 31 - 1.
+		//
+		// ```ruby
+		// [1, 2].product([3, 4])     #=> [[1, 3], [1, 4], [2, 3], [2, 4]]
+		// [1, 2].product(["a"], [5]) #=> [[1, "a", 5], [2, "a", 5]]
+		// [1, 2].product             #=> [[1], [2]]
+		// [1, 2].product([])         #=> []
+		// ```
+		//
+		// @param arrays [Array]...
+		// @return [Array]
+		Name: "product",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			arrays := [][]Object{receiver.(*ArrayObject).Elements}
+			size := len(arrays[0])
+
+			for i, arg := range args {
+				arr, ok := arg.(*ArrayObject)
+
+				if !ok {
+					return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormatNum, i+1, classes.ArrayClass, arg.Class().Name)
+				}
+
+				if len(arr.Elements) != 0 && size > math.MaxInt32/len(arr.Elements) {
+					return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, tooBigToProduct, math.MaxInt32)
+				}
+
+				arrays = append(arrays, arr.Elements)
+				size *= len(arr.Elements)
+			}
+
+			combinations := make([]Object, 0, size)
+			indexes := make([]int, len(arrays))
+
+			for n := 0; n < size; n++ {
+				combination := make([]Object, len(arrays))
+
+				for i, elems := range arrays {
+					combination[i] = elems[indexes[i]]
+				}
+
+				combinations = append(combinations, t.vm.InitArrayObject(combination))
+
+				// Advance the indexes like an odometer, the last array first
+				for i := len(arrays) - 1; i >= 0; i-- {
+					indexes[i]++
+
+					if indexes[i] < len(arrays[i]) {
+						break
+					}
+
+					indexes[i] = 0
+				}
+			}
+
+			return t.vm.InitArrayObject(combinations)
+
+		},
+	},
 	{
 		// A destructive method.
 		// Appends the given object to the array and returns the array.
@@ -1165,6 +1666,29 @@ var builtinArrayInstanceMethods = []*BuiltinMethodObject{
 
 		},
 	},
+	{
+		// Searches an array of arrays, and returns the first sub-array whose second element equals to the given value.
+		// Elements which aren't arrays are skipped. Returns `nil` if nothing matches.
+		//
+		// ```ruby
+		// a = [["a", 1], ["b", 2], ["c", 2]]
+		// a.rassoc(2) #=> ["b", 2]
+		// a.rassoc(3) #=> nil
+		// ```
+		//
+		// @param value [Object]
+		// @return [Array]
+		Name: "rassoc",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			arr := receiver.(*ArrayObject)
+			return arr.findPair(args[0], 1)
+
+		},
+	},
 	{
 		// Accumulates the given argument and the results from evaluating each elements
 		// with the first block parameter of the given block.
@@ -1208,10 +1732,6 @@ var builtinArrayInstanceMethods = []*BuiltinMethodObject{
 
 			// If it's an empty array, pop the block's call frame
 			arr := receiver.(*ArrayObject)
-			if len(arr.Elements) == 0 {
-				t.callFrameStack.pop()
-			}
-
 			if blockIsEmpty(blockFrame) {
 				return NULL
 			}
@@ -1228,13 +1748,78 @@ var builtinArrayInstanceMethods = []*BuiltinMethodObject{
 			}
 
 			for i := start; i < len(arr.Elements); i++ {
-				prev = t.builtinMethodYield(blockFrame, prev, arr.Elements[i])
+				var erred bool
+
+				if prev, erred = t.builtinMethodYield(blockFrame, prev, arr.Elements[i]); erred {
+					return prev
+				}
 			}
 
 			return prev
 
 		},
 	},
+	{
+		// A destructive method.
+		// Removes the elements for which the block returns a truthy value, in place.
+		// Returns the array, or `nil` if no element was removed.
+		//
+		// ```ruby
+		// a = [1, 2, 3, 4, 5]
+		// a.reject! do |i|
+		//   i > 3
+		// end #=> [1, 2, 3]
+		// a   #=> [1, 2, 3]
+		// ```
+		//
+		// @param conditional block literal
+		// @return [Array]
+		Name: "reject!",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			if blockFrame == nil {
+				return t.vm.InitErrorObject(errors.InternalError, sourceLine, errors.CantYieldWithoutBlockFormat)
+			}
+
+			arr := receiver.(*ArrayObject)
+
+			if blockIsEmpty(blockFrame) {
+				return NULL
+			}
+
+			var blockErr Object
+
+			// Once the block raises an error, the rest of the elements are kept
+			changed := arr.keepIf(func(e Object) bool {
+				if blockErr != nil {
+					return true
+				}
+
+				result, erred := t.builtinMethodYield(blockFrame, e)
+
+				if erred {
+					blockErr = result
+					return true
+				}
+
+				return !result.isTruthy()
+			})
+
+			if blockErr != nil {
+				return blockErr
+			}
+
+			if !changed {
+				return NULL
+			}
+
+			return arr
+
+		},
+	},
 	{
 		// Returns a new array containing self‘s elements in reverse order. Not destructive.
 		//
@@ -1289,15 +1874,12 @@ var builtinArrayInstanceMethods = []*BuiltinMethodObject{
 				return arr
 			}
 
-			// If it's an empty array, pop the block's call frame
-			if len(arr.Elements) == 0 {
-				t.callFrameStack.pop()
-			}
-
 			reversedArr := arr.reverse()
 
 			for _, obj := range reversedArr.Elements {
-				t.builtinMethodYield(blockFrame, obj)
+				if result, erred := t.builtinMethodYield(blockFrame, obj); erred {
+					return result
+				}
 			}
 
 			return reversedArr
@@ -1306,7 +1888,7 @@ var builtinArrayInstanceMethods = []*BuiltinMethodObject{
 	},
 	{
 		// Returns a new rotated array from the self.
-		// The method is not destructive.
+		// The method is not destructive: the new array doesn't share its elements' storage with the self.
 		// If zero `0` is passed, it returns a new array that has been rotated 1 time to left (default).
 		// If an optional positive integer `n` is passed, it returns a new array that has been rotated `n` times to left.
 		//
@@ -1337,7 +1919,6 @@ var builtinArrayInstanceMethods = []*BuiltinMethodObject{
 
 			var rotate int
 			arr := receiver.(*ArrayObject)
-			rotArr := t.vm.InitArrayObject(arr.Elements)
 
 			if aLen == 0 {
 				rotate = 1
@@ -1351,19 +1932,23 @@ var builtinArrayInstanceMethods = []*BuiltinMethodObject{
 				rotate = args[0].Value().(int)
 			}
 
-			if rotate < 0 {
-				for i := 0; i > rotate; i-- {
-					el := rotArr.pop()
-					rotArr.unshift([]Object{el})
-				}
-			} else {
-				for i := 0; i < rotate; i++ {
-					el := rotArr.shift()
-					rotArr.push([]Object{el})
+			// The elements are copied into a new slice: the receiver's storage is only read,
+			// which Concurrent::Array relies on to rotate under the read lock
+			n := len(arr.Elements)
+			elems := make([]Object, n)
+
+			if n > 0 {
+				rotate %= n
+
+				if rotate < 0 {
+					rotate += n
 				}
+
+				copy(elems, arr.Elements[rotate:])
+				copy(elems[n-rotate:], arr.Elements[:rotate])
 			}
 
-			return rotArr
+			return t.vm.InitArrayObject(elems)
 
 		},
 	},
@@ -1400,13 +1985,13 @@ var builtinArrayInstanceMethods = []*BuiltinMethodObject{
 				return t.vm.InitArrayObject(elements)
 			}
 
-			// If it's an empty array, pop the block's call frame
-			if len(arr.Elements) == 0 {
-				t.callFrameStack.pop()
-			}
-
 			for _, obj := range arr.Elements {
-				result := t.builtinMethodYield(blockFrame, obj)
+				result, erred := t.builtinMethodYield(blockFrame, obj)
+
+				if erred {
+					return result
+				}
+
 				if result.isTruthy() {
 					elements = append(elements, obj)
 				}
@@ -1416,6 +2001,72 @@ var builtinArrayInstanceMethods = []*BuiltinMethodObject{
 
 		},
 	},
+	{
+		// A destructive method.
+		// Keeps only the elements for which the block returns a truthy value, in place.
+		// Returns the array, or `nil` if no element was removed.
+		//
+		// ```ruby
+		// a = [1, 2, 3, 4, 5]
+		// a.select! do |i|
+		//   i > 3
+		// end #=> [4, 5]
+		// a   #=> [4, 5]
+		// ```
+		//
+		// @param conditional block literal
+		// @return [Array]
+		Name: "select!",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			if blockFrame == nil {
+				return t.vm.InitErrorObject(errors.InternalError, sourceLine, errors.CantYieldWithoutBlockFormat)
+			}
+
+			arr := receiver.(*ArrayObject)
+
+			if blockIsEmpty(blockFrame) {
+				if len(arr.Elements) == 0 {
+					return NULL
+				}
+
+				arr.Elements = []Object{}
+				return arr
+			}
+
+			var blockErr Object
+
+			// Once the block raises an error, the rest of the elements are kept
+			changed := arr.keepIf(func(e Object) bool {
+				if blockErr != nil {
+					return true
+				}
+
+				result, erred := t.builtinMethodYield(blockFrame, e)
+
+				if erred {
+					blockErr = result
+					return true
+				}
+
+				return result.isTruthy()
+			})
+
+			if blockErr != nil {
+				return blockErr
+			}
+
+			if !changed {
+				return NULL
+			}
+
+			return arr
+
+		},
+	},
 	{
 		// A destructive method.
 		// Removes the first element from the array and returns the removed element.
@@ -1439,11 +2090,35 @@ var builtinArrayInstanceMethods = []*BuiltinMethodObject{
 		},
 	},
 	{
-		// Return a sorted array
+		// Retrieves an object or a subarray in an array. It is the same as `Array#[]`.
+		//
+		// ```ruby
+		// a = [1, 2, 3, 4, 5]
+		// a.slice(1)     #=> 2
+		// a.slice(-2, 2) #=> [4, 5]
+		// a.slice(1..2)  #=> [2, 3]
+		// a.slice(6, 1)  #=> nil
+		// ```
+		//
+		// @param index [Integer], (count [Integer])
+		// @param range [Range]
+		// @return [Object]
+		Name: "slice",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			arr := receiver.(*ArrayObject)
+			return arr.index(t, args, sourceLine)
+
+		},
+	},
+	{
+		// Return a sorted array. Numbers are sorted numerically and strings lexicographically.
+		// Sorting elements that can't be compared with each other, like an Integer and a String, raises an ArgumentError.
 		//
 		// ```ruby
 		// a = [3, 2, 1]
 		// a.sort #=> [1, 2, 3]
+		// ["b", "c", "a"].sort #=> ["a", "b", "c"]
+		// [1, "a"].sort #=> ArgumentError: comparison of Integer with String failed
 		// ```
 		//
 		// @return [Object]
@@ -1455,11 +2130,66 @@ var builtinArrayInstanceMethods = []*BuiltinMethodObject{
 
 			arr := receiver.(*ArrayObject)
 			newArr := arr.copy().(*ArrayObject)
+
+			if err := t.checkComparable(newArr.Elements, sourceLine); err != nil {
+				return err
+			}
+
 			sort.Sort(newArr)
 			return newArr
 
 		},
 	},
+	{
+		// Returns the sum of the elements added to the initial value, which is 0 by default.
+		// The elements are added with `+`, so an Integer sum becomes a Float once a Float is added,
+		// and Strings or Arrays can be summed with an initial value of the same class.
+		// If a block is given, the block's results for the elements are summed instead.
+		//
+		// ```ruby
+		// [1, 2, 3].sum          # => 6
+		// [1, 2, 3].sum(10)      # => 16
+		// [1, 0.5].sum           # => 1.5
+		// ["a", "b"].sum("")     # => "ab"
+		// [[1, 2], [3]].sum([])  # => [1, 2, 3]
+		// [].sum                 # => 0
+		//
+		// [1, 2, 3].sum do |i|
+		//   i * 2
+		// end                    # => 12
+		// ```
+		//
+		// @param initial value [Object]
+		// @param block [Block]
+		// @return [Object]
+		Name: "sum",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) > 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgumentLess, 1, len(args))
+			}
+
+			var init Object = t.vm.InitIntegerObject(0)
+
+			if len(args) == 1 {
+				init = args[0]
+			}
+
+			elems := receiver.(*ArrayObject).Elements
+
+			if blockFrame != nil {
+				mapped, err := t.mapElements(blockFrame, elems)
+
+				if err != nil {
+					return err
+				}
+
+				elems = mapped
+			}
+
+			return t.sumObjects(init, elems, sourceLine)
+
+		},
+	},
 	{
 		// Returns the result of interpreting ary as an array of [key value] array pairs.
 		// Note that the keys should always be String or symbol literals (using symbol literal is preferable).
@@ -1599,18 +2329,7 @@ func (a *ArrayObject) Value() interface{} {
 
 // ToString returns the object's elements as the string format
 func (a *ArrayObject) ToString() string {
-	var out bytes.Buffer
-
-	elements := []string{}
-	for _, e := range a.Elements {
-		elements = append(elements, e.Inspect())
-	}
-
-	out.WriteString("[")
-	out.WriteString(strings.Join(elements, ", "))
-	out.WriteString("]")
-
-	return out.String()
+	return inspectWithDepth(a, 0)
 }
 
 // Inspect delegates to ToString
@@ -1620,22 +2339,18 @@ func (a *ArrayObject) Inspect() string {
 
 // ToJSON returns the object's elements as the JSON string format
 func (a *ArrayObject) ToJSON(t *Thread) string {
-	var out bytes.Buffer
-	elements := []string{}
-	for _, e := range a.Elements {
-		elements = append(elements, e.ToJSON(t))
-	}
-
-	out.WriteString("[")
-	out.WriteString(strings.Join(elements, ", "))
-	out.WriteString("]")
-
-	return out.String()
+	e := &jsonEncoder{t: t}
+	return e.encodeElements(a.Elements, jsonRootPath)
 }
 
 // concatenateCopies returns a array composed of N copies of the array
-func (a *ArrayObject) concatenateCopies(t *Thread, n int) Object {
+func (a *ArrayObject) concatenateCopies(t *Thread, n int, sourceLine int) Object {
 	aLen := len(a.Elements)
+
+	if err := t.reserveMemory(multipliedSize(aLen*elementSize, n), "Array#*", sourceLine); err != nil {
+		return err
+	}
+
 	result := make([]Object, 0, aLen*n)
 
 	for i := 0; i < n; i++ {
@@ -1662,7 +2377,7 @@ func (a *ArrayObject) dig(t *Thread, keys []Object, sourceLine int) Object {
 	nextKeys := keys[1:]
 	currentValue := a.Elements[normalizedIndex]
 
-	if len(nextKeys) == 0 {
+	if len(nextKeys) == 0 || currentValue == NULL {
 		return currentValue
 	}
 
@@ -1675,66 +2390,56 @@ func (a *ArrayObject) dig(t *Thread, keys []Object, sourceLine int) Object {
 	return diggableCurrentValue.dig(t, nextKeys, sourceLine)
 }
 
-// Retrieves an object in an array using Integer index; common to `[]` and `at()`.
-func (a *ArrayObject) index(t *Thread, args []Object, sourceLine int) Object {
-	aLen := len(args)
-	if aLen < 1 || aLen > 2 {
-		return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgumentRange, 1, 2, aLen)
-	}
-
-	typeErr := t.vm.checkArgTypes(args, sourceLine, classes.IntegerClass)
-
-	if typeErr != nil {
-		return typeErr
-	}
-
-	index := args[0].Value().(int)
-	arrLength := a.Len()
+// findPair returns the first element which is an array holding the given object at the given position; common to `assoc` and `rassoc`.
+func (a *ArrayObject) findPair(obj Object, position int) Object {
+	for _, e := range a.Elements {
+		pair, ok := e.(*ArrayObject)
 
-	if index < 0 && index < -arrLength {
-		return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.TooSmallIndexValue, index, -arrLength)
+		if ok && len(pair.Elements) > position && pair.Elements[position].equalTo(obj) {
+			return pair
+		}
 	}
 
-	/* Validation for the second argument if exists */
-	if aLen == 2 {
-		j := args[1]
-		count, ok := j.(*IntegerObject)
+	return NULL
+}
 
-		if !ok {
-			return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.IntegerClass, args[1].Class().Name)
-		}
-		if count.value < 0 {
-			return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.NegativeSecondValue, count.value)
-		}
+// Retrieves an object or a subarray in an array; common to `[]`, `at()` and `slice()`.
+func (a *ArrayObject) index(t *Thread, args []Object, sourceLine int) Object {
+	span, ok, err := resolveIndex(t, args, a.Len(), sourceLine)
 
-		/*
-		 *  This condition meets the special case (Don't know why ~ ? Ask Ruby or try it on irb!):
-		 *
-		 *  a = [1, 2, 3, 4, 5]
-		 *  a[5, 5] #=> []
-		 */
-		if index > 0 && index == arrLength {
-			return t.vm.InitArrayObject([]Object{})
-		}
+	if err != nil {
+		return err
 	}
 
-	/* Start Indexing */
-	normalizedIndex := a.normalizeIndex(index)
-	if normalizedIndex == -1 {
+	if !ok {
 		return NULL
 	}
 
-	if aLen == 2 {
-		j := args[1]
-		count, _ := j.(*IntegerObject)
+	if span.single {
+		return a.Elements[span.start]
+	}
 
-		if normalizedIndex+count.value > arrLength {
-			return t.vm.InitArrayObject(a.Elements[normalizedIndex:])
-		}
-		return t.vm.InitArrayObject(a.Elements[normalizedIndex : normalizedIndex+count.value])
+	return t.vm.InitArrayObject(a.copyElements(span.start, span.end))
+}
+
+// splice replaces the elements in the given span with the values, padding the array with `nil` up to the span's start
+func (a *ArrayObject) splice(span indexSpan, values []Object) {
+	for len(a.Elements) < span.start {
+		a.Elements = append(a.Elements, NULL)
 	}
 
-	return a.Elements[normalizedIndex]
+	elems := make([]Object, 0, len(a.Elements)-(span.end-span.start)+len(values))
+	elems = append(elems, a.Elements[:span.start]...)
+	elems = append(elems, values...)
+	elems = append(elems, a.Elements[span.end:]...)
+	a.Elements = elems
+}
+
+// copyElements returns a copy of the elements from start up to but not including end
+func (a *ArrayObject) copyElements(start, end int) []Object {
+	elems := make([]Object, end-start)
+	copy(elems, a.Elements[start:end])
+	return elems
 }
 
 // flatten returns a array of Objects that is one-dimensional flattening of Elements
@@ -1753,6 +2458,28 @@ func (a *ArrayObject) flatten() []Object {
 	return result
 }
 
+// keepIf removes the elements for which keep returns false, reusing the array's storage.
+// It returns true if any element was removed.
+func (a *ArrayObject) keepIf(keep func(Object) bool) bool {
+	kept := a.Elements[:0]
+
+	for _, e := range a.Elements {
+		if keep(e) {
+			kept = append(kept, e)
+		}
+	}
+
+	// Drop the references left behind so they can be garbage collected
+	for i := len(kept); i < len(a.Elements); i++ {
+		a.Elements[i] = nil
+	}
+
+	changed := len(kept) != len(a.Elements)
+	a.Elements = kept
+
+	return changed
+}
+
 // Len returns the length of array's elements
 func (a *ArrayObject) Len() int {
 	return len(a.Elements)
@@ -1765,20 +2492,149 @@ func (a *ArrayObject) Swap(i, j int) {
 
 // Less is one of the required method to fulfill sortable interface
 func (a *ArrayObject) Less(i, j int) bool {
-	leftObj, rightObj := a.Elements[i], a.Elements[j]
-	switch leftObj := leftObj.(type) {
+	result, _ := compareObjects(a.Elements[i], a.Elements[j])
+	return result < 0
+}
+
+// mapElements returns the block's results for the elements, or the error the block raises
+func (t *Thread) mapElements(blockFrame *normalCallFrame, elems []Object) ([]Object, Object) {
+	mapped := make([]Object, len(elems))
+
+	if blockIsEmpty(blockFrame) {
+		for i := range mapped {
+			mapped[i] = NULL
+		}
+
+		return mapped, nil
+	}
+
+	for i, elem := range elems {
+		result, erred := t.builtinMethodYield(blockFrame, elem)
+
+		if erred {
+			return nil, result
+		}
+
+		mapped[i] = result
+	}
+
+	return mapped, nil
+}
+
+// bsearchIndex finds an element with binary search in either mode of `bsearch`, and returns its index, or -1 if there's none
+func (t *Thread) bsearchIndex(elems []Object, blockFrame *normalCallFrame, sourceLine int) (int, *Error) {
+	if blockIsEmpty(blockFrame) {
+		return -1, nil
+	}
+
+	found := -1
+	low, high := 0, len(elems)
+
+	for low < high {
+		mid := low + (high-low)/2
+		result, erred := t.builtinMethodYield(blockFrame, elems[mid])
+
+		if erred {
+			return -1, result.(*Error)
+		}
+
+		var cmp float64
+
+		switch r := result.(type) {
+		case *BooleanObject, *NullObject:
+			// find-minimum mode: a true block means the element is found, but an earlier one may be too
+			if r == TRUE {
+				found = mid
+				high = mid
+			} else {
+				low = mid + 1
+			}
+
+			continue
+		case *IntegerObject:
+			cmp = float64(r.value)
+		case *FloatObject:
+			cmp = r.value
+		default:
+			return -1, t.vm.InitErrorObject(errors.TypeError, sourceLine, "Expect block to return Numeric, Boolean or nil. got: %s", r.Class().Name)
+		}
+
+		// find-any mode
+		switch {
+		case cmp == 0:
+			return mid, nil
+		case cmp > 0:
+			low = mid + 1
+		default:
+			high = mid
+		}
+	}
+
+	return found, nil
+}
+
+// checkComparable returns an ArgumentError if any two of the elements can't be compared with compareObjects
+func (t *Thread) checkComparable(elems []Object, sourceLine int) *Error {
+	// Elements comparable with the first one are comparable with each other as well
+	for i := 1; i < len(elems); i++ {
+		if _, ok := compareObjects(elems[0], elems[i]); !ok {
+			return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, comparisonFailed, elems[0].Class().Name, elems[i].Class().Name)
+		}
+	}
+
+	return nil
+}
+
+// compareObjects is the default comparison for sorting built-in types: numbers are compared by their values,
+// strings lexicographically and times chronologically. It returns -1, 0 or 1 like `<=>`, and false if the objects can't be compared.
+func compareObjects(left, right Object) (int, bool) {
+	switch l := left.(type) {
 	case Numeric:
-		return leftObj.lessThan(rightObj)
+		r, ok := right.(Numeric)
+
+		if !ok {
+			return 0, false
+		}
+
+		switch {
+		case l.lessThan(right):
+			return -1, true
+		case r.lessThan(left):
+			return 1, true
+		// not every pair of numeric types can be compared with lessThan
+		case l.floatValue() < r.floatValue():
+			return -1, true
+		case l.floatValue() > r.floatValue():
+			return 1, true
+		default:
+			return 0, true
+		}
+	case *DecimalObject:
+		r, ok := right.(*DecimalObject)
+
+		if !ok {
+			return 0, false
+		}
+
+		return l.value.Cmp(r.value), true
 	case *StringObject:
-		right, ok := rightObj.(*StringObject)
+		r, ok := right.(*StringObject)
 
-		if ok {
-			return leftObj.value < right.value
+		if !ok {
+			return 0, false
 		}
 
-		return false
+		return strings.Compare(l.value, r.value), true
+	case *TimeObject:
+		r, ok := right.(*TimeObject)
+
+		if !ok {
+			return 0, false
+		}
+
+		return l.compare(r), true
 	default:
-		return false
+		return 0, false
 	}
 }
 
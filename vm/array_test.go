@@ -1,6 +1,10 @@
 package vm
 
 import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -24,6 +28,24 @@ func TestArrayInitialization(t *testing.T) {
              i * 2
 			end
 		`, []interface{}{0, 2, 4}},
+		{`
+			Array.new(0) do |i|
+             i * 2
+			end
+		`, []interface{}{}},
+		{`
+			# the default value isn't copied: every element is the same object
+			a = Array.new(2, "a")
+			a[0].concat("b")
+			a
+		`, []interface{}{"ab", "ab"}},
+		{`
+			a = Array.new(2) do |i|
+             "a"
+			end
+			a[0].concat("b")
+			a
+		`, []interface{}{"ab", "a"}},
 	}
 
 	for i, tt := range tests {
@@ -156,6 +178,9 @@ func TestArrayIndex(t *testing.T) {
 		{`
 		    [1, "a", 10, "b"][-2]
 		`, 10},
+		{`
+		    [1, "a", 10, "b"][-5]
+		`, nil},
 		{`
 			a = [1, "a", 10, 5]
 			a[0]
@@ -196,8 +221,8 @@ func TestArrayIndex(t *testing.T) {
 			code[101] = 'Switching Protocols'
 			code[102] = 'Processing'
 			code[200] = 'OK'
-			code.to_s
-		`, `[nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, "Continue", "Switching Protocols", "Processing", nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, "OK"]`},
+			code
+		`, canonical(`[nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, "Continue", "Switching Protocols", "Processing", nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, "OK"]`)},
 	}
 
 	for i, tt := range tests {
@@ -214,10 +239,7 @@ func TestArrayIndexFail(t *testing.T) {
 		{`
 			a = [1, 2, 3, 4, 5, 6, 7, 8, 9, 10]
 			a[-11] = 123
-		`, "ArgumentError: Index value -11 too small for array. minimum: -10", 1},
-		{`
-		    [1, "a", 10, "b"][-5]
-		`, "ArgumentError: Index value -5 too small for array. minimum: -4", 1},
+		`, "IndexError: Index value -11 too small for array. minimum: -10", 1},
 	}
 
 	for i, tt := range testsFail {
@@ -410,6 +432,18 @@ func TestArrayIndexWithSuccessiveValuesNullCases(t *testing.T) {
 			a = [1, 2, 3, 4, 5]
 			a[6, 5] # Range exceeded
 		`, nil},
+		{`
+			a = [1, 2, 3, 4, 5]
+			a[-6, 5]
+		`, nil},
+		{`
+			a = [1, 2, 3, 4, 5, 6, 7, 8, 9, 10]
+			a[3, -1]
+		`, nil},
+		{`
+			a = [1, 2, 3, 4, 5, 6, 7, 8, 9, 10]
+			a[-1, -4] # Both negative case
+		`, nil},
 	}
 
 	for i, tt := range tests {
@@ -426,11 +460,11 @@ func TestArrayIndexWithSuccessiveValuesFail(t *testing.T) {
 		{`
 			a = [1, 2, 3, 4, 5]
 			a["1", 5]
-		`, "TypeError: Expect argument to be Integer. got: String", 1},
+		`, "TypeError: Expect argument to be Range or Integer. got: String", 1},
 		{`
 			a = [1, 2, 3, 4, 5]
 			a[1, "5"]
-		`, "TypeError: Expect argument to be Integer. got: String", 1},
+		`, "TypeError: Expect argument #2 to be Integer. got: String", 1},
 		{`
 			a = [1, 2, 3, 4, 5]
 			a[1, 3, 5]
@@ -446,39 +480,27 @@ func TestArrayIndexWithSuccessiveValuesFail(t *testing.T) {
 		{`
 			a = [1, 2, 3, 4, 5, 6, 7, 8, 9, 10]
 			a[1, "5"] = 6
-		`, "TypeError: Expect argument to be Integer. got: String", 1},
+		`, "TypeError: Expect argument #2 to be Integer. got: String", 1},
 		{`
 			a = [1, 2, 3, 4, 5, 6, 7, 8, 9, 10]
 			a[1, "5", 6] = 123
 		`, "ArgumentError: Expect 2 to 3 argument(s). got: 4", 1},
-		{`
-			a = [1, 2, 3, 4, 5]
-			a[-6, 5]
-		`, "ArgumentError: Index value -6 too small for array. minimum: -5", 1},
 		{`
 			a = [1, 2, 3, 4, 5, 6, 7, 8, 9, 10]
 			a[1, -3] = [1, 2, 3, 4, 5]
-		`, "ArgumentError: Expect second argument to be positive value. got: -3", 1},
+		`, "IndexError: Expect second argument to be positive value. got: -3", 1},
 		{`
 			a = [1, 2, 3, 4, 5]
 			a[-1, -1] = 555
-		`, "ArgumentError: Expect second argument to be positive value. got: -1", 1},
+		`, "IndexError: Expect second argument to be positive value. got: -1", 1},
 		{`
 			a = [1, 2, 3, 4, 5]
 			a[6, -1] = 555
-		`, "ArgumentError: Expect second argument to be positive value. got: -1", 1},
+		`, "IndexError: Expect second argument to be positive value. got: -1", 1},
 		{`
 			a = [1, 2, 3, 4, 5, 6, 7, 8, 9, 10]
 			a[-11, 2] = [1, 2, 3, 4, 5]
-		`, "ArgumentError: Index value -11 too small for array. minimum: -10", 1},
-		{`
-			a = [1, 2, 3, 4, 5, 6, 7, 8, 9, 10]
-			a[3, -1]
-		`, "ArgumentError: Expect second argument to be positive value. got: -1", 1},
-		{`
-			a = [1, 2, 3, 4, 5, 6, 7, 8, 9, 10]
-			a[-1, -4] # Both negative case
-		`, "ArgumentError: Expect second argument to be positive value. got: -4", 1},
+		`, "IndexError: Index value -11 too small for array. minimum: -10", 1},
 	}
 
 	for i, tt := range testsFail {
@@ -558,6 +580,42 @@ func TestArrayAnyMethodFail(t *testing.T) {
 	}
 }
 
+func TestArrayAssocMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`[["a", 1], ["b", 2], ["b", 3]].assoc("b")`, []interface{}{"b", 2}},
+		{`[["a", 1], ["b", 2]].assoc("c")`, nil},
+		{`[1, [], ["a"], [2, 3]].assoc(2)`, []interface{}{2, 3}},
+		{`[[[1], 1], [[2], 2]].assoc([2])`, []interface{}{[]interface{}{2}, 2}},
+		{`[].assoc(1)`, nil},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestArrayAssocMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`[["a", 1]].assoc`, "ArgumentError: Expect 1 argument(s). got: 0", 1},
+		{`[["a", 1]].assoc("a", "b")`, "ArgumentError: Expect 1 argument(s). got: 2", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestArrayAtMethod(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -578,6 +636,9 @@ func TestArrayAtMethod(t *testing.T) {
 		{`
 			[1, "a", 10, 5].at(-2)
 		`, 10},
+		{`
+			[1, "a", 10, 5].at(-5)
+		`, nil},
 		{`
 			a = [1, "a", 10, 5]
 			a.at(0)
@@ -609,7 +670,6 @@ func TestArrayAtMethodFail(t *testing.T) {
 		{`[1, 2, 3].at(2, 3)`, "ArgumentError: Expect 1 argument(s). got: 2", 1},
 		{`[1, 2, 3].at(true)`, "TypeError: Expect argument to be Integer. got: Boolean", 1},
 		{`[1, 2, 3].at(1..3)`, "TypeError: Expect argument to be Integer. got: Range", 1},
-		{`[1, "a", 10, 5].at(-5)`, "ArgumentError: Index value -5 too small for array. minimum: -4", 1},
 	}
 
 	for i, tt := range testsFail {
@@ -621,6 +681,177 @@ func TestArrayAtMethodFail(t *testing.T) {
 	}
 }
 
+func TestArrayBsearchMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		// find-minimum mode
+		{`
+		[0, 4, 7, 10, 12].bsearch do |x|
+		  x >= 4
+		end
+		`, 4},
+		{`
+		[0, 4, 7, 10, 12].bsearch do |x|
+		  x >= 6
+		end
+		`, 7},
+		{`
+		[0, 4, 7, 10, 12].bsearch do |x|
+		  x >= -1
+		end
+		`, 0},
+		{`
+		[0, 4, 7, 10, 12].bsearch do |x|
+		  x >= 100
+		end
+		`, nil},
+		{`
+		[0, 4, 7, 10, 12].bsearch do |x|
+		  if x >= 10
+		    true
+		  end
+		end
+		`, 10},
+		{`
+		["apple", "fig", "kiwi", "pear"].bsearch do |s|
+		  (s <=> "g") >= 0
+		end
+		`, "kiwi"},
+		// find-any mode
+		{`
+		[0, 100, 100, 100, 200].bsearch do |x|
+		  100 - x
+		end
+		`, 100},
+		{`
+		[0, 4, 7, 10, 12].bsearch do |x|
+		  7 - x
+		end
+		`, 7},
+		{`
+		[0, 100, 100, 100, 200].bsearch do |x|
+		  50 - x
+		end
+		`, nil},
+		{`
+		[1.5, 2.5, 3.5].bsearch do |x|
+		  3.0 - x + 0.5
+		end
+		`, 3.5},
+		// empty arrays and blocks
+		{`
+		[].bsearch do |x|
+		  true
+		end
+		`, nil},
+		{`
+		[1, 2, 3].bsearch do |x|
+		end
+		`, nil},
+		// the result on unsorted arrays is unspecified, but they can be searched
+		{`
+		[12, 0, 10, 4, 7].bsearch do |x|
+		  x >= 7
+		end.class.name
+		`, "Integer"},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestArrayBsearchMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`[1, 2, 3].bsearch`, "InternalError: Can't yield without a block", 1},
+		{`
+		[1, 2, 3].bsearch(1) do |x|
+		  true
+		end
+		`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+		{`
+		[1, 2, 3].bsearch do |x|
+		  "a"
+		end
+		`, "TypeError: Expect block to return Numeric, Boolean or nil. got: String", 1},
+		{`
+		[1, 2, 3].bsearch_index do |x|
+		  [x]
+		end
+		`, "TypeError: Expect block to return Numeric, Boolean or nil. got: Array", 1},
+		{`[1, 2, 3].bsearch_index`, "InternalError: Can't yield without a block", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestArrayBsearchIndexMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		[0, 4, 7, 10, 12].bsearch_index do |x|
+		  x >= 5
+		end
+		`, 2},
+		{`
+		[0, 4, 7, 10, 12].bsearch_index do |x|
+		  x >= 13
+		end
+		`, nil},
+		{`
+		[0, 4, 4, 4, 12].bsearch_index do |x|
+		  x >= 4
+		end
+		`, 1},
+		{`
+		[0, 4, 7, 10, 12].bsearch_index do |x|
+		  10 - x
+		end
+		`, 3},
+		{`
+		[0, 4, 7, 10, 12].bsearch_index do |x|
+		  5 - x
+		end
+		`, nil},
+		{`
+		[].bsearch_index do |x|
+		  0
+		end
+		`, nil},
+		{`
+		r = []
+		10.times do |n|
+		  r.push([0, 4, 7, 10, 12].bsearch_index do |x|
+		    x >= n
+		  end)
+		end
+		r
+		`, []interface{}{0, 1, 1, 1, 1, 2, 2, 2, 3, 3}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestArrayClearMethod(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -660,6 +891,93 @@ func TestArrayClearMethodFail(t *testing.T) {
 	}
 }
 
+func TestArrayCompactMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`[1, nil, 2, nil].compact`, []interface{}{1, 2}},
+		{`
+		a = [1, nil, 2, nil]
+		a.compact
+		a
+		`, []interface{}{1, nil, 2, nil}},
+		{`
+		a = [1, 2]
+		a.compact.object_id == a.object_id
+		`, false},
+		{`[nil, nil].compact`, []interface{}{}},
+		{`[].compact`, []interface{}{}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestArrayCompactMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`[1, nil].compact(1)`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestArrayCompactBangMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		a = [1, nil, 2, nil]
+		a.compact!
+		`, []interface{}{1, 2}},
+		{`
+		a = [1, nil, 2, nil]
+		a.compact!
+		a
+		`, []interface{}{1, 2}},
+		{`
+		a = [1, 2]
+		a.compact!
+		`, nil},
+		{`[].compact!`, nil},
+		{`[nil, nil].compact!`, []interface{}{}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestArrayCompactBangMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`[1, nil].compact!(1)`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestArrayConcatMethod(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -898,6 +1216,15 @@ func TestArrayDigMethod(t *testing.T) {
 			[[], 2].dig(0, 1, 2)
 		`, nil},
 		{`[[1, 2, [3, [8, [9]]]], 4, 5].dig(0, 2, 1, 1, 0)`, 9},
+		{`[[1, [2, 3]], 4].dig(-2, -1, -2)`, 2},
+		{`[{ a: [1, { b: { c: [5, 6] } }] }].dig(0, :a, 1, :b, :c, -1)`, 6},
+		{`[[1, 2]].dig(0, 2)`, nil},
+		{`[[1, 2]].dig(0, -3)`, nil},
+		{`[{ a: 1 }].dig(0, :b)`, nil},
+		// the remaining keys aren't used once an intermediate value is nil
+		{`[nil, 2].dig(0, 1)`, nil},
+		{`[[nil]].dig(0, 0, :a, 1)`, nil},
+		{`[{ a: nil }].dig(0, :a, 1)`, nil},
 	}
 
 	for i, tt := range tests {
@@ -975,9 +1302,36 @@ func TestArrayEachMethod(t *testing.T) {
 	}
 }
 
+func TestArrayEachMethodWithoutBlock(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`['M', 'A', 'X'].each.class.name`, "ArrayEnumerator"},
+		{`
+		e = ['M', 'A', 'X'].each
+		[e.next, e.next, e.has_next?, e.next, e.has_next?]
+		`, []interface{}{"M", "A", true, "X", false}},
+		// iterating with a block doesn't move the position of next
+		{`
+		e = [1, 2, 3].each
+		e.next
+		[e.to_a, e.map do |i| i * 10 end, e.next]
+		`, []interface{}{[]interface{}{1, 2, 3}, []interface{}{10, 20, 30}, 2}},
+		{`[].each.to_a`, []interface{}{}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestArrayEachMethodFail(t *testing.T) {
 	testsFail := []errorTestCase{
-		{`['M', 'A', 'X', 'W', 'E', 'L', 'L'].each`, "InternalError: Can't yield without a block", 1},
 		{`
 		['T', 'A', 'I', 'P', 'E', 'I'].each(101) do |char|
 		  puts char
@@ -1064,13 +1418,91 @@ func TestArrayEachIndexMethodFail(t *testing.T) {
 	}
 }
 
-func TestArrayEmptyMethod(t *testing.T) {
+func TestArrayEachWithObjectMethod(t *testing.T) {
 	tests := []struct {
 		input    string
-		expected bool
+		expected interface{}
 	}{
-		{
-			`
+		{`
+		h = ["apple", "fig", "kiwi"].each_with_object({}) do |word, lengths|
+		  lengths[word] = word.length
+		end
+		[h["apple"], h["fig"], h["kiwi"], h.length]
+		`, []interface{}{5, 3, 4, 3}},
+		{`
+		[1, 2, 3, 4].each_with_object([]) do |n, evens|
+		  if n.even?
+		    evens.push(n * 10)
+		  end
+		end
+		`, []interface{}{20, 40}},
+		// the memo itself is returned, whatever the block returns
+		{`
+		memo = []
+		r = [1, 2].each_with_object(memo) do |n, m|
+		  m.push(n)
+		  nil
+		end
+		[r.object_id == memo.object_id, memo]
+		`, []interface{}{true, []interface{}{1, 2}}},
+		{`
+		[].each_with_object([1]) do |n, m|
+		  m.push(n)
+		end
+		`, []interface{}{1}},
+		{`
+		[1, 2].each_with_object("memo") do
+		end
+		`, "memo"},
+		{`
+		[].each_with_object(nil) do |n, m|
+		end
+		`, nil},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestArrayEachWithObjectMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`[1, 2].each_with_object({})`, "InternalError: Can't yield without a block", 1},
+		{`
+		[1, 2].each_with_object do |n, m|
+		end
+		`, "ArgumentError: Expect 1 argument(s). got: 0", 1},
+		{`
+		[1, 2].each_with_object({}, []) do |n, m|
+		end
+		`, "ArgumentError: Expect 1 argument(s). got: 2", 1},
+		{`
+		[1, 2].each_with_object({}) do |n, m|
+		  m[n] = n + "a"
+		end
+		`, "TypeError: Expect argument to be Numeric. got: String", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestArrayEmptyMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{
+			`
 			[1, 2, 3].empty?
 			`, false},
 		{
@@ -1112,6 +1544,86 @@ func TestArrayEmptyMethodFail(t *testing.T) {
 	}
 }
 
+func TestArrayFillMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`[1, 2, 3].fill(0)`, []interface{}{0, 0, 0}},
+		{`
+		a = [1, 2, 3]
+		a.fill(0)
+		a
+		`, []interface{}{0, 0, 0}},
+		{`[1, 2, 3, 4].fill(9, 2)`, []interface{}{1, 2, 9, 9}},
+		{`[1, 2, 3, 4].fill(9, 1, 2)`, []interface{}{1, 9, 9, 4}},
+		{`[1, 2, 3, 4].fill(9, 3, 3)`, []interface{}{1, 2, 3, 9, 9, 9}},
+		{`[1, 2].fill(9, 3, 1)`, []interface{}{1, 2, nil, 9}},
+		{`[1, 2, 3, 4].fill(9, -2)`, []interface{}{1, 2, 9, 9}},
+		{`[1, 2, 3, 4].fill(9, -10, 1)`, []interface{}{9, 2, 3, 4}},
+		{`[1, 2, 3, 4].fill(9, 1, -1)`, []interface{}{1, 2, 3, 4}},
+		{`[1, 2].fill(9, 5)`, []interface{}{1, 2}},
+		{`[].fill(0)`, []interface{}{}},
+		{`
+		[1, 2, 3].fill do |i|
+		  i * i
+		end
+		`, []interface{}{0, 1, 4}},
+		{`
+		[1, 2, 3, 4].fill(2) do |i|
+		  i * 10
+		end
+		`, []interface{}{1, 2, 20, 30}},
+		{`
+		[1, 2, 3, 4].fill(1, 5) do |i|
+		  i * 10
+		end
+		`, []interface{}{1, 10, 20, 30, 40, 50}},
+		{`
+		[].fill do |i|
+		  i
+		end
+		`, []interface{}{}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestArrayFillMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`[1, 2].fill`, "ArgumentError: Expect 1 to 3 argument(s). got: 0", 1},
+		{`[1, 2].fill(1, 2, 3, 4)`, "ArgumentError: Expect 1 to 3 argument(s). got: 4", 1},
+		{`[1, 2].fill(1, "2")`, "TypeError: Expect argument #2 to be Integer. got: String", 1},
+		{`[1, 2].fill(1, 0, "2")`, "TypeError: Expect argument #3 to be Integer. got: String", 1},
+		{`
+		[1, 2].fill(1, 2, 3) do |i|
+		  i
+		end
+		`, "ArgumentError: Expect 2 or less argument(s). got: 3", 1},
+		{`
+		[1, 2].fill("1") do |i|
+		  i
+		end
+		`, "TypeError: Expect argument #1 to be Integer. got: String", 1},
+		{`[1, 2].fill(1, 1, 2147483647)`, "ArgumentError: Too big to fill: more than 2147483647 elements", 1},
+		{`[1, 2].fill(1, 9223372036854775807, 1)`, "ArgumentError: Too big to fill: more than 2147483647 elements", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestArrayFirstMethod(t *testing.T) {
 	testsInt := []struct {
 		input    string
@@ -1152,6 +1664,16 @@ func TestArrayFirstMethod(t *testing.T) {
 		{`
 		a = ["M", "A", "X", "W", "E", "L", "L"]
 		a.first(11)`, []interface{}{"M", "A", "X", "W", "E", "L", "L"}},
+		{`
+		a = [1, 2]
+		a.first(0)`, []interface{}{}},
+		{`
+		a = []
+		a.first(2)`, []interface{}{}},
+		{`
+		a = [1, 2]
+		a.first(2).push(3)
+		a`, []interface{}{1, 2}},
 	}
 
 	for i, tt := range testsArray {
@@ -1249,6 +1771,44 @@ func TestArrayFlattenMethodFail(t *testing.T) {
 	}
 }
 
+func TestArrayFlattenBangMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		a = [1, [2, [3, 4]]]
+		a.flatten!
+		a
+		`, []interface{}{1, 2, 3, 4}},
+		{`[[1], [2]].flatten!`, []interface{}{1, 2}},
+		{`[1, 2].flatten!`, nil},
+		{`[].flatten!`, nil},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestArrayFlattenBangMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`[1, [2]].flatten!(1)`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestArrayIndexWithMethod(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -1297,6 +1857,95 @@ func TestArrayIncludeMethod(t *testing.T) {
 	}
 }
 
+func TestArrayInsertSortedMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		a = [1, 3, 5]
+		a.insert_sorted(4)
+		a
+		`, []interface{}{1, 3, 4, 5}},
+		{`[1, 3, 5].insert_sorted(0)`, []interface{}{0, 1, 3, 5}},
+		{`[1, 3, 5].insert_sorted(9)`, []interface{}{1, 3, 5, 9}},
+		{`[].insert_sorted(1)`, []interface{}{1}},
+		{`[1, 2, 2, 3].insert_sorted(2.0)`, []interface{}{1, 2, 2, 2.0, 3}},
+		{`["b", "d"].insert_sorted("c")`, []interface{}{"b", "c", "d"}},
+		// elements are compared by <=>
+		{`
+		class Version
+		  attr_reader :n
+
+		  def initialize(n)
+		    @n = n
+		  end
+
+		  def <=>(other)
+		    @n <=> other.n
+		  end
+		end
+
+		a = [Version.new(1), Version.new(5)]
+		a.insert_sorted(Version.new(3))
+		a.map do |v| v.n end
+		`, []interface{}{1, 3, 5}},
+		// an unsorted array still gets the value
+		{`[5, 1, 3].insert_sorted(2).length`, 4},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestArrayInsertSortedShuffledValues(t *testing.T) {
+	r := rand.New(rand.NewSource(1748))
+
+	for i := 0; i < 20; i++ {
+		values := make([]string, r.Intn(50))
+		for j := range values {
+			// small values, so there are duplicates
+			values[j] = strconv.Itoa(r.Intn(20) - 5)
+		}
+
+		input := fmt.Sprintf(`
+		a = []
+		[%s].each do |x|
+		  a.insert_sorted(x)
+		end
+		[a == a.sort, a.length]
+		`, strings.Join(values, ", "))
+
+		v := initTestVM()
+		evaluated := v.testEval(t, input, getFilename())
+		VerifyExpected(t, i, evaluated, []interface{}{true, len(values)})
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestArrayInsertSortedMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`[1, 2].insert_sorted`, "ArgumentError: Expect 1 argument(s). got: 0", 1},
+		{`[1, 2].insert_sorted(1, 2)`, "ArgumentError: Expect 1 argument(s). got: 2", 1},
+		{`[1, 2].insert_sorted("a")`, "TypeError: Expect argument to be Numeric. got: String", 1},
+		{`["a"].insert_sorted(1)`, "TypeError: Expect argument to be String. got: Integer", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestArrayJoinMethod(t *testing.T) {
 	testsInt := []struct {
 		input    string
@@ -1379,6 +2028,19 @@ func TestArrayLastMethod(t *testing.T) {
 		a = ["M", "A", "X", "W", "E", "L", "L"]
 		a.last(10)
 		`, []interface{}{"M", "A", "X", "W", "E", "L", "L"}},
+		{`
+		a = [1, 2]
+		a.last(0)
+		`, []interface{}{}},
+		{`
+		a = []
+		a.last(2)
+		`, []interface{}{}},
+		{`
+		a = [1, 2]
+		a.last(2).push(3)
+		a
+		`, []interface{}{1, 2}},
 	}
 
 	for i, tt := range testsArray {
@@ -1518,6 +2180,69 @@ func TestArrayMapMethod(t *testing.T) {
 	}
 }
 
+func TestArrayMapBangMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		a = [1, 2, 3]
+		a.map! do |i|
+		  i * 10
+		end
+		`, []interface{}{10, 20, 30}},
+		{`
+		a = [1, 2, 3]
+		b = a
+		a.map! do |i|
+		  i.to_s
+		end
+		b
+		`, []interface{}{"1", "2", "3"}},
+		{`
+		a = [1, 2, 3]
+		a.map! do |i|
+		  i
+		end
+		`, []interface{}{1, 2, 3}},
+		{`
+		[1, 2].map! do; end
+		`, []interface{}{nil, nil}},
+		{`
+		[].map! do |i|
+		  i * 10
+		end
+		`, []interface{}{}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestArrayMapBangMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`[1, 2].map!`, "InternalError: Can't yield without a block", 1},
+		{`
+		[1, 2].map!(1) do |i|
+		  i
+		end
+		`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestArrayPlusOperator(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -1640,11 +2365,84 @@ func TestArrayPushMethod(t *testing.T) {
 			a[0]
 			`, "foo"},
 		{`
-			[1, 2, 3, 4].push(5, 6, 7).to_s
-			`, "[1, 2, 3, 4, 5, 6, 7]"},
+			[1, 2, 3, 4].push(5, 6, 7)
+			`, canonical("[1, 2, 3, 4, 5, 6, 7]")},
 		{`
-			[].push(nil, "", '').to_s
-	`, `[nil, "", ""]`},
+			[].push(nil, "", '')
+	`, canonical(`[nil, "", ""]`)},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestArrayProductMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`[1, 2].product([3, 4])`, []interface{}{
+			[]interface{}{1, 3}, []interface{}{1, 4}, []interface{}{2, 3}, []interface{}{2, 4},
+		}},
+		{`[1, 2].product(["a"], [5, 6])`, []interface{}{
+			[]interface{}{1, "a", 5}, []interface{}{1, "a", 6}, []interface{}{2, "a", 5}, []interface{}{2, "a", 6},
+		}},
+		{`[1, 2].product`, []interface{}{[]interface{}{1}, []interface{}{2}}},
+		{`[1, 2].product([])`, []interface{}{}},
+		{`[].product([1, 2])`, []interface{}{}},
+		{`[].product`, []interface{}{}},
+		{`
+		a = [1, 2]
+		a.product(a).length
+		`, 4},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestArrayProductMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`[1].product(1)`, "TypeError: Expect argument #1 to be Array. got: Integer", 1},
+		{`[1].product([1], "a")`, "TypeError: Expect argument #2 to be Array. got: String", 1},
+		{`
+		a = []
+		1000.times do |i|
+		  a.push(i)
+		end
+
+		a.product(a, a, a)
+		`, "ArgumentError: Too big to product: more than 2147483647 combinations", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestArrayRassocMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`[["a", 1], ["b", 2], ["c", 2]].rassoc(2)`, []interface{}{"b", 2}},
+		{`[["a", 1], ["b", 2]].rassoc(3)`, nil},
+		{`[1, ["a"], ["b", nil, 3]].rassoc(nil)`, []interface{}{"b", nil, 3}},
+		{`[].rassoc(1)`, nil},
 	}
 
 	for i, tt := range tests {
@@ -1656,6 +2454,21 @@ func TestArrayPushMethod(t *testing.T) {
 	}
 }
 
+func TestArrayRassocMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`[["a", 1]].rassoc`, "ArgumentError: Expect 1 argument(s). got: 0", 1},
+		{`[["a", 1]].rassoc(1, 2)`, "ArgumentError: Expect 1 argument(s). got: 2", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestArrayReduceMethod(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -1755,6 +2568,66 @@ func TestArrayReduceMethodFail(t *testing.T) {
 	}
 }
 
+func TestArrayRejectBangMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		a = [1, 2, 3, 4, 5]
+		a.reject! do |i|
+		  i > 3
+		end
+		a
+		`, []interface{}{1, 2, 3}},
+		{`
+		[1, 2, 3].reject! do |i|
+		  i > 3
+		end
+		`, nil},
+		{`
+		[1, 2, 3].reject! do |i|
+		  true
+		end
+		`, []interface{}{}},
+		{`
+		[1, 2, 3].reject! do; end
+		`, nil},
+		{`
+		[].reject! do |i|
+		  true
+		end
+		`, nil},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestArrayRejectBangMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`[1, 2].reject!`, "InternalError: Can't yield without a block", 1},
+		{`
+		[1, 2].reject!(1) do |i|
+		  i
+		end
+		`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestArrayReverseMethod(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -1881,6 +2754,26 @@ func TestArrayRotateMethod(t *testing.T) {
 		a = [1, 2, 3, 4]
 		a.rotate(-1)
 		`, []interface{}{4, 1, 2, 3}},
+		{`
+		a = [1, 2, 3, 4]
+		a.rotate(10)
+		`, []interface{}{3, 4, 1, 2}},
+		{`
+		a = [1, 2, 3, 4]
+		a.rotate(-6)
+		`, []interface{}{3, 4, 1, 2}},
+		{`
+		[].rotate(3)
+		`, []interface{}{}},
+		// the rotated array doesn't share its storage with the receiver
+		{`
+		a = [1, 2, 3]
+		a.push(4)
+		b = a.rotate
+		a.push(5)
+		b.push(6)
+		a.concat(b)
+		`, []interface{}{1, 2, 3, 4, 5, 2, 3, 4, 1, 6}},
 	}
 
 	for i, tt := range tests {
@@ -1977,6 +2870,69 @@ func TestArraySelectMethodFail(t *testing.T) {
 	}
 }
 
+func TestArraySelectBangMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		a = [1, 2, 3, 4, 5]
+		a.select! do |i|
+		  i > 3
+		end
+		a
+		`, []interface{}{4, 5}},
+		{`
+		a = [1, 2, 3]
+		b = a
+		a.select! do |i|
+		  i != 2
+		end
+		b
+		`, []interface{}{1, 3}},
+		{`
+		[1, 2, 3].select! do |i|
+		  i < 5
+		end
+		`, nil},
+		{`
+		[1, 2, 3].select! do; end
+		`, []interface{}{}},
+		{`
+		[].select! do |i|
+		  true
+		end
+		`, nil},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestArraySelectBangMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`[1, 2].select!`, "InternalError: Can't yield without a block", 1},
+		{`
+		[1, 2].select!(1) do |i|
+		  i
+		end
+		`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestArrayShiftMethod(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -2045,6 +3001,18 @@ func TestArraySortMethod(t *testing.T) {
 		{`
 		["abc", "aaaaaa"].sort
 		`, []interface{}{"aaaaaa", "abc"}},
+		{`
+		["banana", "Apple", "cherry", "apple", "b"].sort
+		`, []interface{}{"Apple", "apple", "b", "banana", "cherry"}},
+		{`
+		[10, -3, 2, 100, 0, 2].sort
+		`, []interface{}{-3, 0, 2, 2, 10, 100}},
+		{`
+		[].sort
+		`, []interface{}{}},
+		{`
+		["a"].sort
+		`, []interface{}{"a"}},
 	}
 
 	for i, tt := range tests {
@@ -2062,6 +3030,81 @@ func TestArraySortMethodFail(t *testing.T) {
 		a.sort(3, 3, 4, 5)
 		`,
 			"ArgumentError: Expect 0 argument. got=4", 1},
+		{`[1, "a"].sort`, "ArgumentError: comparison of Integer with String failed", 1},
+		{`["a", "b", 3].sort`, "ArgumentError: comparison of String with Integer failed", 1},
+		{`[2, nil, 1.5].sort`, "ArgumentError: comparison of Integer with Null failed", 1},
+		{`[[1], [2]].sort`, "ArgumentError: comparison of Array with Array failed", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestArraySumMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`[1, 2, 3].sum`, 6},
+		{`[].sum`, 0},
+		{`[1, 2, 3].sum(10)`, 16},
+		{`[1, 0.5].sum`, 1.5},
+		{`[0.5, 1, 2].sum(1)`, 4.5},
+		{`["a", "b"].sum("")`, "ab"},
+		{`[[1, 2], [3]].sum([])`, []interface{}{1, 2, 3}},
+		{`
+		[1, 2, 3].sum do |i|
+		  i * 2
+		end
+		`, 12},
+		{`
+		init = 0.5
+		[1, 2].sum(init) do |i|
+		  i * 10
+		end
+		`, 30.5},
+		{`
+		["a", "b"].sum("") do |s|
+		  s.upcase
+		end
+		`, "AB"},
+		{`
+		[].sum(1) do |i|
+		  i * 2
+		end
+		`, 1},
+		// the receiver and the initial value aren't modified
+		{`
+		a = [[1]]
+		init = [0]
+		a.sum(init)
+		[a, init]
+		`, []interface{}{[]interface{}{[]interface{}{1}}, []interface{}{0}}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestArraySumMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`[1, "a"].sum`, "TypeError: Expect argument to be Numeric. got: String", 1},
+		{`["a"].sum`, "TypeError: Expect argument to be Numeric. got: String", 1},
+		{`[1].sum(0, 1)`, "ArgumentError: Expect 1 or less argument(s). got: 2", 1},
+		{`
+		[1].sum do |i|
+		  nil
+		end`, "TypeError: Expect argument to be Numeric. got: Null", 1},
 	}
 
 	for i, tt := range testsFail {
@@ -1,6 +1,8 @@
 package vm
 
 import (
+	"strconv"
+	"sync"
 	"testing"
 
 	"github.com/goby-lang/goby/compiler"
@@ -138,3 +140,175 @@ func BenchmarkContextSwitch(b *testing.B) {
 		runBench(b, script)
 	})
 }
+
+func BenchmarkArrayBuilding(b *testing.B) {
+	b.Run("push", func(b *testing.B) {
+		runBench(b, `
+			a = []
+			i = 0
+			while i < 1000000 do
+			  a.push(i)
+			  i += 1
+			end
+		`)
+	})
+	b.Run("preallocated", func(b *testing.B) {
+		runBench(b, `
+			a = Array.new(1000000) do |i|
+			  i
+			end
+		`)
+	})
+}
+
+// largeHashPairs returns the pairs of a hash with n keys
+func largeHashPairs(v *VM, n int) map[string]Object {
+	pairs := make(map[string]Object, n)
+
+	for i := 0; i < n; i++ {
+		pairs[strconv.Itoa(i)] = v.InitIntegerObject(i)
+	}
+
+	return pairs
+}
+
+func BenchmarkConcurrentHashInit(b *testing.B) {
+	v := initTestVM()
+	iss, _ := compiler.CompileToInstructions(`require 'concurrent/hash'`, parser.NormalMode)
+	v.ExecInstructions(iss, getFilename())
+	pairs := largeHashPairs(v, 10000)
+
+	// The naive way of storing each pair in a fresh sync.Map, which Concurrent::Hash used to do
+	b.Run("per-key store", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var m sync.Map
+
+			for key, value := range pairs {
+				m.Store(key, value)
+			}
+		}
+	})
+	b.Run("copy", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			v.initConcurrentHashObject(pairs)
+		}
+	})
+}
+
+func TestConcurrentHashInitIsFasterThanPerKeyStore(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skip comparing benchmarks in short mode")
+	}
+
+	v := initTestVM()
+	v.testEval(t, `require 'concurrent/hash'`, getFilename())
+	pairs := largeHashPairs(v, 10000)
+
+	perKey := testing.Benchmark(func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var m sync.Map
+
+			for key, value := range pairs {
+				m.Store(key, value)
+			}
+		}
+	})
+	copied := testing.Benchmark(func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			v.initConcurrentHashObject(pairs)
+		}
+	})
+
+	if copied.NsPerOp() >= perKey.NsPerOp() {
+		t.Errorf("Expect creating a Concurrent::Hash to be faster than storing each pair in a sync.Map. got: %s, baseline: %s", copied, perKey)
+	}
+}
+
+func BenchmarkThreadSpawning(b *testing.B) {
+	script := `
+	require 'concurrent/future'
+
+	def depth(n)
+	  if n == 0
+	    0
+	  else
+	    depth(n - 1) + 1
+	  end
+	end
+
+	fs = []
+	100.times do |i|
+	  fs.push(Concurrent::Future.execute do
+	    depth(20)
+	  end)
+	end
+
+	fs.each do |f|
+	  f.value(5)
+	end
+	`
+	iss, err := compiler.CompileToInstructions(script, parser.NormalMode)
+
+	if err != nil {
+		b.Fatal(err.Error())
+	}
+
+	run := func(b *testing.B, poolSize int) {
+		v := initTestVM()
+		v.SetThreadPool(poolSize)
+		filepath := getFilename()
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			v.ExecInstructions(iss, filepath)
+		}
+	}
+
+	b.Run("fresh", func(b *testing.B) {
+		run(b, 0)
+	})
+	b.Run("pooled", func(b *testing.B) {
+		run(b, 100)
+	})
+}
+
+func BenchmarkConcurrentHashMerge(b *testing.B) {
+	// One large hash merged with several smaller ones, the way configs are assembled
+	setup := `
+	require 'concurrent/hash'
+	base = {}
+	5000.times do |i|
+	  base["key" + i.to_s] = i
+	end
+	h = Concurrent::Hash.new(base)
+	o1 = { key1: 1, extra1: 1 }
+	o2 = Concurrent::Hash.new({ key2: 2, extra2: 2 })
+	o3 = { key3: 3, extra3: 3 }
+	o4 = { key4: 4, extra4: 4 }
+	`
+
+	b.Run("merge many", func(b *testing.B) {
+		runBench(b, setup+`
+		100.times do
+		  h.merge(o1, o2, o3, o4)
+		end
+		`)
+	})
+
+	b.Run("merge sequentially", func(b *testing.B) {
+		runBench(b, setup+`
+		100.times do
+		  h.merge(o1).merge(o2).merge(o3).merge(o4)
+		end
+		`)
+	})
+
+	b.Run("merge! many", func(b *testing.B) {
+		runBench(b, setup+`
+		100.times do
+		  h.merge!(o1, o2, o3, o4)
+		end
+		`)
+	})
+}
@@ -0,0 +1,433 @@
+package vm
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/goby-lang/goby/vm/classes"
+	"github.com/goby-lang/goby/vm/errors"
+)
+
+// BigIntegerObject represents an integer of arbitrary size, backed by Go's `big.Int` from math/big package.
+//
+// BigIntegers can't be created directly for now; they're the results of Integer arithmetic
+// which overflows when the VM's overflow policy is set to promotion.
+//
+// BigIntegers work with Integers and Floats just like Integers do, so users rarely need to tell them apart:
+// when the result of a BigInteger operation fits in an Integer, an Integer is returned.
+//
+// ```ruby
+// a = 9223372036854775807 + 1 # => 9223372036854775808
+// a.class                     # => BigInteger
+// a * 2                       # => 18446744073709551616
+// (a / 2).class               # => Integer
+// ```
+//
+type BigIntegerObject struct {
+	*BaseObj
+	value *Int
+}
+
+// Class methods --------------------------------------------------------
+var builtinBigIntegerClassMethods = []*BuiltinMethodObject{
+	{
+		Name: "new",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return t.vm.InitNoMethodError(sourceLine, "new", receiver)
+
+		},
+	},
+}
+
+// Instance methods -----------------------------------------------------
+var builtinBigIntegerInstanceMethods = []*BuiltinMethodObject{
+	{
+		// Returns the sum of self and another Numeric.
+		//
+		// ```ruby
+		// a = 9223372036854775807 + 1
+		// a + 1 # => 9223372036854775809
+		// ```
+		// @return [Numeric]
+		Name: "+",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			floatOperation := func(leftValue float64, rightValue float64) float64 {
+				return leftValue + rightValue
+			}
+
+			return receiver.(*BigIntegerObject).arithmeticOperation(t, args[0], "+", (*Int).Add, floatOperation, sourceLine, false)
+
+		},
+	},
+	{
+		// Returns the modulo of self divided by another Numeric. Like Integer's, it has the sign of the divisor.
+		//
+		// ```ruby
+		// a = 9223372036854775807 + 1
+		// a % 10  # => 8
+		// a % -10 # => -2
+		// ```
+		// @return [Numeric]
+		Name: "%",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return receiver.(*BigIntegerObject).arithmeticOperation(t, args[0], "%", floorModBig, floorModFloat, sourceLine, true)
+
+		},
+	},
+	{
+		// Returns the subtraction of another Numeric from self.
+		//
+		// ```ruby
+		// a = 9223372036854775807 + 1
+		// a - 1 # => 9223372036854775807
+		// ```
+		// @return [Numeric]
+		Name: "-",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			floatOperation := func(leftValue float64, rightValue float64) float64 {
+				return leftValue - rightValue
+			}
+
+			return receiver.(*BigIntegerObject).arithmeticOperation(t, args[0], "-", (*Int).Sub, floatOperation, sourceLine, false)
+
+		},
+	},
+	{
+		// Returns self multiplying another Numeric.
+		//
+		// ```ruby
+		// a = 9223372036854775807 + 1
+		// a * 2 # => 18446744073709551616
+		// ```
+		// @return [Numeric]
+		Name: "*",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			floatOperation := func(leftValue float64, rightValue float64) float64 {
+				return leftValue * rightValue
+			}
+
+			return receiver.(*BigIntegerObject).arithmeticOperation(t, args[0], "*", (*Int).Mul, floatOperation, sourceLine, false)
+
+		},
+	},
+	{
+		// Returns self to the power of another Numeric.
+		// Like Integer's, a negative Integer exponent results in 0, and a BigInteger exponent isn't supported.
+		//
+		// ```ruby
+		// a = 9223372036854775807 + 1
+		// a ** 2 # => 85070591730234615865843651857942052864
+		// ```
+		// @return [Numeric]
+		Name: "**",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			bigOperation := func(z *Int, x *Int, y *Int) *Int {
+				if y.Sign() < 0 {
+					return z.SetInt64(0)
+				}
+
+				return z.Exp(x, y, nil)
+			}
+
+			if _, ok := args[0].(*BigIntegerObject); ok {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.ExponentTooLarge, args[0].ToString())
+			}
+
+			return receiver.(*BigIntegerObject).arithmeticOperation(t, args[0], "**", bigOperation, math.Pow, sourceLine, false)
+
+		},
+	},
+	{
+		// Returns self divided by another Numeric. Like Integer's, the division of Integers is rounded toward negative infinity,
+		// and the division by a Float follows IEEE 754.
+		//
+		// ```ruby
+		// a = 9223372036854775807 + 1
+		// a / 2 # => 4611686018427387904
+		// ```
+		// @return [Numeric]
+		Name: "/",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			floatOperation := func(leftValue float64, rightValue float64) float64 {
+				return leftValue / rightValue
+			}
+
+			return receiver.(*BigIntegerObject).arithmeticOperation(t, args[0], "/", floorDivBig, floatOperation, sourceLine, true)
+
+		},
+	},
+	{
+		// Returns if self is larger than another Numeric.
+		//
+		// ```ruby
+		// a = 9223372036854775807 + 1
+		// a > 1 # => true
+		// ```
+		// @return [Boolean]
+		Name: ">",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return receiver.(*BigIntegerObject).numericComparison(t, args[0], sourceLine, func(result int) bool {
+				return result > 0
+			})
+
+		},
+	},
+	{
+		// Returns if self is larger than or equals to another Numeric.
+		//
+		// ```ruby
+		// a = 9223372036854775807 + 1
+		// a >= a # => true
+		// ```
+		// @return [Boolean]
+		Name: ">=",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return receiver.(*BigIntegerObject).numericComparison(t, args[0], sourceLine, func(result int) bool {
+				return result >= 0
+			})
+
+		},
+	},
+	{
+		// Returns if self is smaller than another Numeric.
+		//
+		// ```ruby
+		// a = 9223372036854775807 + 1
+		// a < 1 # => false
+		// ```
+		// @return [Boolean]
+		Name: "<",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return receiver.(*BigIntegerObject).numericComparison(t, args[0], sourceLine, func(result int) bool {
+				return result < 0
+			})
+
+		},
+	},
+	{
+		// Returns if self is smaller than or equals to another Numeric.
+		//
+		// ```ruby
+		// a = 9223372036854775807 + 1
+		// a <= a # => true
+		// ```
+		// @return [Boolean]
+		Name: "<=",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return receiver.(*BigIntegerObject).numericComparison(t, args[0], sourceLine, func(result int) bool {
+				return result <= 0
+			})
+
+		},
+	},
+	{
+		// Returns 1 if self is larger than the incoming Numeric, -1 if smaller. Otherwise 0.
+		//
+		// ```ruby
+		// a = 9223372036854775807 + 1
+		// a <=> 1 # => 1
+		// a <=> a # => 0
+		// ```
+		// @return [Integer]
+		Name: "<=>",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if _, ok := args[0].(Numeric); !ok {
+				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, "Numeric", args[0].Class().Name)
+			}
+
+			result, ok := receiver.(*BigIntegerObject).compare(args[0])
+
+			if !ok {
+				return NULL
+			}
+
+			return t.vm.InitIntegerObject(result)
+
+		},
+	},
+	{
+		// Returns the BigInteger converted to a Float, which may lose precision.
+		//
+		// ```ruby
+		// (9223372036854775807 + 1).to_f # => 9223372036854775808.0
+		// ```
+		//
+		// @return [Float]
+		Name: "to_f",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			return t.vm.initFloatObject(receiver.(*BigIntegerObject).floatValue())
+
+		},
+	},
+	{
+		// Returns self, since a BigInteger is already an integer.
+		//
+		// ```ruby
+		// (9223372036854775807 + 1).to_i # => 9223372036854775808
+		// ```
+		//
+		// @return [BigInteger]
+		Name: "to_i",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			return receiver
+
+		},
+	},
+	{
+		// Returns the decimal representation of the BigInteger.
+		//
+		// ```ruby
+		// (9223372036854775807 + 1).to_s # => "9223372036854775808"
+		// ```
+		//
+		// @return [String]
+		Name: "to_s",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return t.vm.InitStringObject(receiver.(*BigIntegerObject).ToString())
+
+		},
+	},
+}
+
+// Internal functions ===================================================
+
+// Functions for initialization -----------------------------------------
+
+func (vm *VM) initBigIntegerObject(value *Int) *BigIntegerObject {
+	return &BigIntegerObject{
+		BaseObj: NewBaseObject(vm.TopLevelClass(classes.BigIntegerClass)),
+		value:   value,
+	}
+}
+
+// initIntegerObjectFromBig returns an Integer if the value fits in one, or a BigInteger otherwise
+func (vm *VM) initIntegerObjectFromBig(value *Int) Object {
+	if v := value.Int64(); value.IsInt64() && int64(int(v)) == v {
+		return vm.InitIntegerObject(int(v))
+	}
+
+	return vm.initBigIntegerObject(value)
+}
+
+func (vm *VM) initBigIntegerClass() *RClass {
+	bc := vm.initializeClass(classes.BigIntegerClass)
+	bc.setBuiltinMethods(builtinBigIntegerInstanceMethods, false)
+	bc.setBuiltinMethods(builtinBigIntegerClassMethods, true)
+	return bc
+}
+
+// Polymorphic helper functions -----------------------------------------
+
+// Value returns the object
+func (b *BigIntegerObject) Value() interface{} {
+	return b.value
+}
+
+// Numeric interface
+func (b *BigIntegerObject) floatValue() float64 {
+	f, _ := new(Float).SetInt(b.value).Float64()
+	return f
+}
+
+// Apply the passed arithmetic operation, while performing type conversion.
+func (b *BigIntegerObject) arithmeticOperation(
+	t *Thread,
+	rightObject Object,
+	operator string,
+	bigOperation func(z *Int, x *Int, y *Int) *Int,
+	floatOperation func(leftValue float64, rightValue float64) float64,
+	sourceLine int,
+	division bool,
+) Object {
+	switch rightObject := rightObject.(type) {
+	case *IntegerObject:
+		if division && rightObject.value == 0 {
+			return t.vm.InitErrorObject(errors.ZeroDivisionError, sourceLine, errors.DividedByZero)
+		}
+
+		return t.vm.initIntegerObjectFromBig(bigOperation(new(Int), b.value, big.NewInt(int64(rightObject.value))))
+	case *BigIntegerObject:
+		return t.vm.initIntegerObjectFromBig(bigOperation(new(Int), b.value, rightObject.value))
+	case *FloatObject:
+		return t.vm.initFloatObject(floatOperation(b.floatValue(), rightObject.value))
+	default:
+		return t.coerceOperation(b, rightObject, operator, sourceLine)
+	}
+}
+
+// compare returns -1, 0 or 1 like big.Int's Cmp does, and false if the object isn't a Numeric or is NaN
+func (b *BigIntegerObject) compare(rightObject Object) (int, bool) {
+	switch rightObject := rightObject.(type) {
+	case *IntegerObject:
+		return b.value.Cmp(big.NewInt(int64(rightObject.value))), true
+	case *BigIntegerObject:
+		return b.value.Cmp(rightObject.value), true
+	case *FloatObject:
+		if math.IsNaN(rightObject.value) {
+			return 0, false
+		}
+
+		return new(Float).SetInt(b.value).Cmp(big.NewFloat(rightObject.value)), true
+	default:
+		return 0, false
+	}
+}
+
+// Apply the passed comparison to the result of compare, or raise a TypeError if the object isn't a Numeric.
+// Comparisons with NaN are always false.
+func (b *BigIntegerObject) numericComparison(t *Thread, rightObject Object, sourceLine int, comparison func(result int) bool) Object {
+	if _, ok := rightObject.(Numeric); !ok {
+		return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, "Numeric", rightObject.Class().Name)
+	}
+
+	result, ok := b.compare(rightObject)
+
+	return toBooleanObject(ok && comparison(result))
+}
+
+func (b *BigIntegerObject) lessThan(arg Object) bool {
+	result, ok := b.compare(arg)
+	return ok && result < 0
+}
+
+// ToString returns the object's decimal representation
+func (b *BigIntegerObject) ToString() string {
+	return b.value.String()
+}
+
+// Inspect delegates to ToString
+func (b *BigIntegerObject) Inspect() string {
+	return b.ToString()
+}
+
+// ToJSON just delegates to ToString
+func (b *BigIntegerObject) ToJSON(t *Thread) string {
+	return b.ToString()
+}
+
+func (b *BigIntegerObject) equalTo(with Object) bool {
+	w, ok := with.(*BigIntegerObject)
+	return ok && b.value.Cmp(w.value) == 0
+}
+
+// Other helper functions -----------------------------------------------
+
+// bigIntOf returns the value of an Integer or a BigInteger as a big.Int
+func bigIntOf(obj Object) (*Int, bool) {
+	switch obj := obj.(type) {
+	case *IntegerObject:
+		return big.NewInt(int64(obj.value)), true
+	case *BigIntegerObject:
+		return obj.value, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,192 @@
+package vm
+
+import (
+	"testing"
+)
+
+func TestBigIntegerFactorial(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		def factorial(n)
+		  result = 1
+		  i = 1
+		  while i <= n do
+		    result = result * i
+		    i += 1
+		  end
+		  result
+		end
+
+		factorial(30).to_s
+		`, "265252859812191058636308480000000"},
+		{`
+		def factorial(n)
+		  result = 1
+		  i = 1
+		  while i <= n do
+		    result = result * i
+		    i += 1
+		  end
+		  result
+		end
+
+		factorial(30).class.name
+		`, "BigInteger"},
+		{`
+		def factorial(n)
+		  result = 1
+		  i = 1
+		  while i <= n do
+		    result = result * i
+		    i += 1
+		  end
+		  result
+		end
+
+		# the quotient fits in an Integer again
+		factorial(30) / factorial(28)
+		`, 870},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		v.SetIntegerOverflowPolicy(OverflowPromote)
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestBigIntegerArithmetic(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`a = 9223372036854775807 + 1
+		(a + 1).to_s`, "9223372036854775809"},
+		{`a = 9223372036854775807 + 1
+		(a + a).to_s`, "18446744073709551616"},
+		{`a = 9223372036854775807 + 1
+		(1 + a).to_s`, "9223372036854775809"},
+		{`a = 9223372036854775807 + 1
+		a - 1`, 9223372036854775807},
+		{`a = 9223372036854775807 + 1
+		a - a`, 0},
+		{`a = 9223372036854775807 + 1
+		(-1 - a).to_s`, "-9223372036854775809"},
+		{`a = 9223372036854775807 + 1
+		(a * 2).to_s`, "18446744073709551616"},
+		{`a = 9223372036854775807 + 1
+		(3 * a).to_s`, "27670116110564327424"},
+		{`a = 9223372036854775807 + 1
+		a / 2`, 4611686018427387904},
+		{`a = 9223372036854775807 + 1
+		a / a`, 1},
+		{`a = 9223372036854775807 + 1
+		10 / a`, 0},
+		{`a = 9223372036854775807 + 1
+		a % 10`, 8},
+		{`a = 9223372036854775807 + 1
+		10 % a`, 10},
+		{`a = 9223372036854775807 + 1
+		(a ** 2).to_s`, "85070591730234615865843651857942052864"},
+		{`a = 9223372036854775807 + 1
+		a ** -1`, 0},
+		{`(2 ** 64).to_s`, "18446744073709551616"},
+		{`(2 ** 64).class.name`, "BigInteger"},
+		{`2 ** 62`, 4611686018427387904},
+		{`a = 9223372036854775807 + 1
+		a * 0.5`, 4611686018427387904.0},
+		{`a = 9223372036854775807 + 1
+		a.to_f`, 9223372036854775808.0},
+		{`a = 9223372036854775807 + 1
+		a.to_i.to_s`, "9223372036854775808"},
+		{`a = 9223372036854775807 + 1
+		a.to_i.class.name`, "BigInteger"},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		v.SetIntegerOverflowPolicy(OverflowPromote)
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestBigIntegerComparison(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`a = 9223372036854775807 + 1
+		a > 1`, true},
+		{`a = 9223372036854775807 + 1
+		a < 1`, false},
+		{`a = 9223372036854775807 + 1
+		a >= a`, true},
+		{`a = 9223372036854775807 + 1
+		a <= a + 1`, true},
+		{`a = 9223372036854775807 + 1
+		1 < a`, true},
+		{`a = 9223372036854775807 + 1
+		1 >= a`, false},
+		{`a = -9223372036854775807 - 2
+		1 > a`, true},
+		{`a = 9223372036854775807 + 1
+		a > 1.5`, true},
+		{`a = 9223372036854775807 + 1
+		a <=> 1`, 1},
+		{`a = 9223372036854775807 + 1
+		a <=> a`, 0},
+		{`a = 9223372036854775807 + 1
+		1 <=> a`, -1},
+		{`a = 9223372036854775807 + 1
+		a == a * 1`, true},
+		{`a = 9223372036854775807 + 1
+		a == 1`, false},
+		{`a = 9223372036854775807 + 1
+		a != a + 1`, true},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		v.SetIntegerOverflowPolicy(OverflowPromote)
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestBigIntegerMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`BigInteger.new`, "NoMethodError: Undefined Method 'new' for BigInteger", 1},
+		{`a = 9223372036854775807 + 1
+		a / 0`, "ZeroDivisionError: Divided by 0", 1},
+		{`a = 9223372036854775807 + 1
+		a + "1"`, "TypeError: Expect argument to be Numeric. got: String", 1},
+		{`a = 9223372036854775807 + 1
+		a > "1"`, "TypeError: Expect argument to be Numeric. got: String", 1},
+		{`a = 9223372036854775807 + 1
+		a ** a`, "ArgumentError: Exponent is too large: 9223372036854775808", 1},
+		{`a = 9223372036854775807 + 1
+		2 ** a`, "ArgumentError: Exponent is too large: 9223372036854775808", 1},
+		{`a = 9223372036854775807 + 1
+		a.to_i(1)`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		v.SetIntegerOverflowPolicy(OverflowPromote)
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
@@ -50,6 +50,8 @@ type BlockObject struct {
 	instructionSet *instructionSet
 	ep             *normalCallFrame
 	self           Object
+	// sourceLine is where the block is defined
+	sourceLine int
 }
 
 // Class methods --------------------------------------------------------
@@ -63,7 +65,7 @@ var builtinBlockClassMethods = []*BuiltinMethodObject{
 				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, "Can't initialize block object without block argument")
 			}
 
-			return t.vm.initBlockObject(blockFrame.instructionSet, blockFrame.ep, blockFrame.self)
+			return t.vm.initBlockObject(blockFrame.instructionSet, blockFrame.ep, blockFrame.self, blockFrame.sourceLine)
 		},
 	},
 }
@@ -115,7 +117,8 @@ var builtinBlockInstanceMethods = []*BuiltinMethodObject{
 			c.self = block.self
 			c.isBlock = true
 
-			return t.builtinMethodYield(c, args...)
+			result, _ := t.builtinMethodYield(c, args...)
+			return result
 		},
 	},
 }
@@ -131,12 +134,13 @@ func (vm *VM) initBlockClass() *RClass {
 	return class
 }
 
-func (vm *VM) initBlockObject(is *instructionSet, ep *normalCallFrame, self Object) *BlockObject {
+func (vm *VM) initBlockObject(is *instructionSet, ep *normalCallFrame, self Object, sourceLine int) *BlockObject {
 	return &BlockObject{
 		BaseObj:        NewBaseObject(vm.TopLevelClass(classes.BlockClass)),
 		instructionSet: is,
 		ep:             ep,
 		self:           self,
+		sourceLine:     sourceLine,
 	}
 }
 
@@ -147,9 +151,20 @@ func (bo *BlockObject) Value() interface{} {
 	return bo.instructionSet
 }
 
-// ToString returns the object's name as the string format
+// ToString returns the block's parameters, arity and where it's defined, like `#<Block: |a, b| arity 2 at foo.gb:3>`
 func (bo *BlockObject) ToString() string {
-	return fmt.Sprintf("<Block: %s>", bo.instructionSet.filename)
+	params := bo.instructionSet.paramTypes
+	arity := 0
+
+	if params != nil {
+		arity = paramsArity(params)
+	}
+
+	if arity == 0 {
+		return fmt.Sprintf("#<Block: arity 0 at %s:%d>", bo.instructionSet.filename, bo.sourceLine)
+	}
+
+	return fmt.Sprintf("#<Block: |%s| arity %d at %s:%d>", formatParams(params, nil), arity, bo.instructionSet.filename, bo.sourceLine)
 }
 
 // Inspect delegates to ToString
@@ -159,7 +174,7 @@ func (bo *BlockObject) Inspect() string {
 
 // ToJSON just delegates to ToString
 func (bo *BlockObject) ToJSON(t *Thread) string {
-	return bo.ToString()
+	return unsupportedJSON(bo)
 }
 
 // copy returns the duplicate of the Array object
@@ -169,5 +184,6 @@ func (bo *BlockObject) copy() Object {
 		instructionSet: bo.instructionSet,
 		ep:             bo.ep,
 		self:           bo.self,
+		sourceLine:     bo.sourceLine,
 	}
 }
@@ -59,3 +59,179 @@ p.call(1, 2, 3, 4, 5)`, []interface{}{1, 2, 3}},
 		v.checkSP(t, i, 1)
 	}
 }
+
+func TestBlockInspect(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+Block.new do
+end.inspect`, "#<Block: arity 0 at block.gb:2>"},
+		{`
+Block.new do |a, b|
+  a
+end.to_s`, "#<Block: |a, b| arity 2 at block.gb:2>"},
+		{`
+def block_of
+  get_block
+end
+
+b = block_of do |x|
+  x
+end
+b.inspect
+`, "#<Block: |x| arity 1 at block.gb:6>"},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, "block.gb")
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+// The scoping rules of blocks:
+//
+// - block parameters are always local to the block, and shadow outer locals with the same names
+// - each call of a block gets fresh bindings of its parameters and locals, so closures created in different calls don't share them
+// - assigning to a local defined outside the block updates the outer local
+// - block-local variables declared like `|x; tmp|` shadow outer locals too
+func TestBlockClosureScoping(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		// closures built in a loop capture each iteration's parameter
+		{`
+		blocks = []
+		[1, 2, 3].each do |i|
+		  blocks.push(Block.new do i end)
+		end
+		blocks.map do |b| b.call end
+		`, []interface{}{1, 2, 3}},
+		{`
+		blocks = []
+		3.times do |i|
+		  blocks.push(Block.new do i end)
+		end
+		blocks.map do |b| b.call end
+		`, []interface{}{0, 1, 2}},
+		// and each iteration's locals
+		{`
+		blocks = []
+		[1, 2, 3].each do |i|
+		  doubled = i * 2
+		  blocks.push(Block.new do doubled end)
+		end
+		blocks.map do |b| b.call end
+		`, []interface{}{2, 4, 6}},
+		{`
+		def each_twice
+		  yield(1)
+		  yield(2)
+		end
+
+		blocks = []
+		each_twice do |i|
+		  blocks.push(Block.new do i end)
+		end
+		blocks.map do |b| b.call end
+		`, []interface{}{1, 2}},
+		// `while` doesn't create a scope, so closures in one iteration of the outer block share its locals
+		{`
+		blocks = []
+		[1, 2].each do |i|
+		  n = 0
+		  while n < 2 do
+		    v = i * 10 + n
+		    blocks.push(Block.new do v end)
+		    n += 1
+		  end
+		end
+		blocks.map do |b| b.call end
+		`, []interface{}{11, 11, 21, 21}},
+		{`
+		make_counter = Block.new do
+		  count = 0
+		  Block.new do
+		    count += 1
+		  end
+		end
+
+		a = make_counter.call
+		b = make_counter.call
+		a.call
+		a.call
+		[a.call, b.call]
+		`, []interface{}{3, 1}},
+		// parameters shadow outer locals
+		{`
+		i = 100
+		[1, 2].each do |i|
+		  i = i * 2
+		end
+		i
+		`, 100},
+		{`
+		r = [1, 2].map do |i|
+		  [3].map do |i|
+		    i
+		  end
+		end
+		r
+		`, []interface{}{[]interface{}{3}, []interface{}{3}}},
+		// outer locals are written through
+		{`
+		total = 0
+		[1, 2, 3].each do |v|
+		  total += v
+		end
+		total
+		`, 6},
+		{`
+		last = nil
+		Block.new do |v|
+		  last = v
+		end.call(5)
+		last
+		`, 5},
+		// block-local variables
+		{`
+		tmp = 5
+		[1, 2].each do |i; tmp|
+		  tmp = i
+		end
+		tmp
+		`, 5},
+		{`
+		a = 1
+		b = 2
+		[3].each do |i; a, b|
+		  a = i
+		  b = a
+		end
+		[a, b]
+		`, []interface{}{1, 2}},
+		{`
+		seen = []
+		b = Block.new do |; tmp|
+		  seen.push(tmp)
+		  tmp = 1
+		end
+		b.call
+		b.call
+		seen
+		`, []interface{}{nil, nil}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
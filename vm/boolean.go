@@ -4,12 +4,22 @@ import (
 	"fmt"
 
 	"github.com/goby-lang/goby/vm/classes"
+	"github.com/goby-lang/goby/vm/errors"
 )
 
-// BooleanObject represents boolean object in goby and no instance methods are contained within it.
-// `Boolean` class is just a dummy to hold logical `true` and `false` representation and no other active usage.
+// BooleanObject represents boolean object in goby.
+// `Boolean` class holds logical `true` and `false` representation and their logical operators.
 // `Boolean.new` is not supported.
 //
+// The operators `&`, `|` and `^` take the truthiness of the argument, so `nil` and `false` are false,
+// and everything else including `0` and `""` is true. Unlike `&&` and `||`, the argument is always evaluated.
+//
+// ```ruby
+// true & 0     # => true
+// false | nil  # => false
+// true ^ "foo" # => false
+// ```
+//
 // Please note that class checking such as `#is_a?(Boolean)` **should be avoided in principle**.
 // `#is_a?` often leads to redundant code. Consider using `respond_to?` first, but actually it is unnecessary
 // in almost all cases.
@@ -35,6 +45,76 @@ var builtinBooleanClassMethods = []*BuiltinMethodObject{
 	},
 }
 
+// Instance methods -----------------------------------------------------
+var builtinBooleanInstanceMethods = []*BuiltinMethodObject{
+	{
+		// Returns true if both the receiver and the argument are truthy, false otherwise.
+		//
+		// ```ruby
+		// true & true   # => true
+		// true & false  # => false
+		// true & 0      # => true
+		// false & true  # => false
+		// ```
+		//
+		// @param object [Object]
+		// @return [Boolean]
+		Name: "&",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			return toBooleanObject(receiver.(*BooleanObject).value && args[0].isTruthy())
+
+		},
+	},
+	{
+		// Returns true if either the receiver or the argument is truthy, false otherwise.
+		//
+		// ```ruby
+		// false | true  # => true
+		// false | false # => false
+		// false | nil   # => false
+		// true | nil    # => true
+		// ```
+		//
+		// @param object [Object]
+		// @return [Boolean]
+		Name: "|",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			return toBooleanObject(receiver.(*BooleanObject).value || args[0].isTruthy())
+
+		},
+	},
+	{
+		// Returns true if exactly one of the receiver and the argument is truthy, false otherwise.
+		//
+		// ```ruby
+		// true ^ false  # => true
+		// true ^ true   # => false
+		// false ^ "foo" # => true
+		// false ^ nil   # => false
+		// ```
+		//
+		// @param object [Object]
+		// @return [Boolean]
+		Name: "^",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			return toBooleanObject(receiver.(*BooleanObject).value != args[0].isTruthy())
+
+		},
+	},
+}
+
 // Internal functions ===================================================
 
 // Functions for initialization -----------------------------------------
@@ -42,6 +122,7 @@ var builtinBooleanClassMethods = []*BuiltinMethodObject{
 func (vm *VM) initBoolClass() *RClass {
 	b := vm.initializeClass(classes.BooleanClass)
 	b.setBuiltinMethods(builtinBooleanClassMethods, true)
+	b.setBuiltinMethods(builtinBooleanInstanceMethods, false)
 
 	TRUE = &BooleanObject{value: true, BaseObj: NewBaseObject(b)}
 	FALSE = &BooleanObject{value: false, BaseObj: NewBaseObject(b)}
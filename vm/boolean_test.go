@@ -232,3 +232,140 @@ func TestBooleanDupMethod(t *testing.T) {
 		v.checkSP(t, i, 1)
 	}
 }
+
+func TestBooleanBitwiseOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"true & true", true},
+		{"true & false", false},
+		{"false & true", false},
+		{"false & false", false},
+		{"true | true", true},
+		{"true | false", true},
+		{"false | true", true},
+		{"false | false", false},
+		{"true ^ true", false},
+		{"true ^ false", true},
+		{"false ^ true", true},
+		{"false ^ false", false},
+		// the argument's truthiness is taken
+		{"true & nil", false},
+		{"true & 0", true},
+		{`true & ""`, true},
+		{"false | []", true},
+		{"true ^ 0", false},
+		{"false ^ nil", false},
+		{"true & 1 == 2", false},
+		{"false | true & false", false},
+		{"true ^ true | true", true},
+		// the argument is evaluated even if the result is decided by the receiver
+		{`
+		a = 0
+		false & (a = 10)
+		a
+		`, 10},
+		{`
+		a = 0
+		true | (a = 10)
+		a
+		`, 10},
+		{`
+		r = []
+		[[true, true], [true, false], [false, true], [false, false]].each do |pair|
+		  r.push((pair[0] & pair[1]) | (pair[0] ^ pair[1]))
+		end
+		r.to_s
+		`, "[true, true, true, false]"},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestBooleanBitwiseOperatorsFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`true.send("&")`, "ArgumentError: Expect 1 argument(s). got: 0", 2},
+		{`false.send("|", 1, 2)`, "ArgumentError: Expect 1 argument(s). got: 2", 2},
+		{`true.send("^")`, "ArgumentError: Expect 1 argument(s). got: 0", 2},
+		{`1 & true`, "NoMethodError: Undefined Method '&' for 1", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestBooleanConversion(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"true.to_bool", true},
+		{"false.to_bool", false},
+		{"nil.to_bool", false},
+		{"0.to_bool", true},
+		{`"".to_bool`, true},
+		{"[].to_bool", true},
+		{"{}.to_bool", true},
+		{"Object.to_bool", true},
+		{"true.to_s", "true"},
+		{"false.to_s", "false"},
+		{"true.class.name", "Boolean"},
+		{"false.class == true.class", true},
+		{"true.to_json", "true"},
+		{`
+		require "json"
+		JSON.parse({ a: true, b: [false] }.to_json).to_s
+		`, `{ a: true, b: [false] }`},
+		{`
+		require "json"
+		h = JSON.parse('{"ok": true, "ng": false}')
+		[h["ok"] & true, h["ng"] | false]
+		`, []interface{}{true, false}},
+		{`
+		r = []
+		[true, false, nil].each do |x|
+		  case x.to_bool
+		  when true
+		    r.push("yes")
+		  when false
+		    r.push("no")
+		  end
+		end
+		r.to_s
+		`, `["yes", "no", "no"]`},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestBooleanConversionFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`nil.to_bool(1)`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
@@ -0,0 +1,478 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/goby-lang/goby/vm/classes"
+	"github.com/goby-lang/goby/vm/errors"
+)
+
+// Bridge passes values between a Go program embedding Goby and one of its vms, like a channel.
+// The host side is the Bridge itself, and the vm side is a `Channel::Bridge` object, see NewBridge.
+//
+// Values never cross a bridge as Goby objects: they're converted to plain Go values when they leave a vm,
+// and to new objects when they enter one, so a program can relay them from a vm to another without sharing anything.
+// The values a bridge carries are nil, booleans, integers, floats, strings,
+// and slices (`[]interface{}`) and maps (`map[string]interface{}`) of them, which are Arrays and Hashes in Goby.
+//
+// Both sides are unbuffered: sending waits until the other side receives the value, or until the bridge is closed.
+// The bridge can be closed from either side, and the other side sees it right away.
+//
+// ```go
+// jobs, _ := vm.NewBridge(v, "Jobs")
+//
+// go func() {
+//   jobs.Send(map[string]interface{}{"id": 1, "tags": []interface{}{"a", "b"}})
+//   result, err := jobs.Receive(context.Background())
+//   // ...
+// }()
+// ```
+//
+// ```ruby
+// job = Jobs.receive
+// Jobs.deliver({ id: job["id"], done: true })
+// Jobs.close
+// ```
+type Bridge struct {
+	toVM      chan interface{}
+	toHost    chan interface{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// ErrBridgeClosed is returned by Send and Receive once the bridge is closed.
+var ErrBridgeClosed = fmt.Errorf("vm: bridge is closed")
+
+// BridgeObject is the vm side of a Bridge. It responds to `deliver`, `receive` and `close` like a Channel.
+type BridgeObject struct {
+	*BaseObj
+	bridge *Bridge
+}
+
+const (
+	cantBridge          = "Can't deliver %s through a Bridge"
+	cantBridgeRecursive = "Can't deliver a recursive %s through a Bridge"
+)
+
+// Instance methods -----------------------------------------------------
+var builtinBridgeInstanceMethods = []*BuiltinMethodObject{
+	{
+		// Closes the bridge, so both the vm and the host can't send through it anymore, and returns nil.
+		// Closing a closed bridge is a ChannelCloseError, like for a Channel.
+		//
+		// ```ruby
+		// Jobs.close
+		// Jobs.closed? # => true
+		// ```
+		//
+		// @return [Null]
+		Name: "close",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			if !receiver.(*BridgeObject).bridge.close() {
+				return t.vm.InitErrorObject(errors.ChannelCloseError, sourceLine, errors.ChannelIsClosed)
+			}
+
+			return NULL
+
+		},
+	},
+	{
+		// Returns true if the bridge has been closed by the vm or by the host.
+		//
+		// @return [Boolean]
+		Name: "closed?",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			return toBooleanObject(receiver.(*BridgeObject).bridge.closed())
+
+		},
+	},
+	{
+		// Sends a copy of the object to the host, and returns the object once the host has received it.
+		// The object can be nil, a Boolean, an Integer, a Float, a String, or an Array or a Hash of them;
+		// a Concurrent::Array or a Concurrent::Hash is sent as a snapshot of its elements.
+		// Sending another kind of object is a TypeError, and sending through a closed bridge is a ChannelCloseError.
+		//
+		// ```ruby
+		// Results.deliver({ id: 1, scores: [1.5, 2] })
+		// ```
+		//
+		// @param object [Object]
+		// @return [Object]
+		Name: "deliver",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			b := receiver.(*BridgeObject).bridge
+
+			if b.closed() {
+				return t.vm.InitErrorObject(errors.ChannelCloseError, sourceLine, errors.ChannelIsClosed)
+			}
+
+			value, err := t.bridgeValueOf(args[0], sourceLine, map[Object]bool{})
+
+			if err != nil {
+				return err
+			}
+
+			select {
+			case b.toHost <- value:
+				return args[0]
+			case <-b.done:
+				return t.vm.InitErrorObject(errors.ChannelCloseError, sourceLine, errors.ChannelIsClosed)
+			}
+
+		},
+	},
+	{
+		// Calls the block with every value the host sends, until the bridge is closed, and then returns nil.
+		// Breaking from the block stops receiving and returns the break value.
+		//
+		// ```ruby
+		// Jobs.each do |job|
+		//   Results.deliver(job["id"] * 2)
+		// end
+		// ```
+		//
+		// @param block literal
+		// @return [Object]
+		Name: "each",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			if blockFrame == nil {
+				return t.vm.InitErrorObject(errors.InternalError, sourceLine, errors.CantYieldWithoutBlockFormat)
+			}
+
+			b := receiver.(*BridgeObject).bridge
+
+			for {
+				value, ok := b.receiveInVM()
+
+				if !ok {
+					break
+				}
+
+				result, erred := t.builtinMethodYield(blockFrame, t.vm.initObjectFromBridge(value))
+
+				if erred {
+					return result
+				}
+
+				if blockFrame.IsRemoved() {
+					if blockFrame.breakValue != nil {
+						return blockFrame.breakValue
+					}
+
+					return NULL
+				}
+			}
+
+			return NULL
+
+		},
+	},
+	{
+		// Waits for a value from the host and returns it as a new object.
+		// Receiving from a closed bridge, or while it's being closed, is a ChannelCloseError, like for a Channel.
+		//
+		// ```ruby
+		// job = Jobs.receive
+		// job["id"] # => 1
+		// ```
+		//
+		// @return [Object]
+		Name: "receive",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			value, ok := receiver.(*BridgeObject).bridge.receiveInVM()
+
+			if !ok {
+				return t.vm.InitErrorObject(errors.ChannelCloseError, sourceLine, errors.ChannelIsClosed)
+			}
+
+			return t.vm.initObjectFromBridge(value)
+
+		},
+	},
+}
+
+// Functions for initialization -----------------------------------------
+
+// NewBridge creates a bridge between the host and the vm, and sets its vm side as the top-level constant name,
+// which is returned as well. It should be called before running code using the constant.
+func NewBridge(v *VM, name string) (*Bridge, Object) {
+	b := &Bridge{
+		toVM:   make(chan interface{}),
+		toHost: make(chan interface{}),
+		done:   make(chan struct{}),
+	}
+
+	class := v.initializeClass("Bridge")
+	class.setBuiltinMethods(builtinBridgeInstanceMethods, false)
+	class = v.TopLevelClass(classes.ChannelClass).loadOrStoreClassConstant(class)
+
+	obj := &BridgeObject{BaseObj: NewBaseObject(class), bridge: b}
+	v.objectClass.setConstant(name, &Pointer{Target: obj})
+
+	return b, obj
+}
+
+// Send converts the value and sends it to the vm, and waits until the vm receives it.
+// It returns an error if the value can't be sent through a bridge, or ErrBridgeClosed if the bridge is closed meanwhile.
+func (b *Bridge) Send(value interface{}) error {
+	v, err := copyBridgeValue(value, 0)
+	if err != nil {
+		return err
+	}
+
+	if b.closed() {
+		return ErrBridgeClosed
+	}
+
+	select {
+	case b.toVM <- v:
+		return nil
+	case <-b.done:
+		return ErrBridgeClosed
+	}
+}
+
+// Receive waits for a value from the vm and returns it. It returns ErrBridgeClosed if the bridge is closed,
+// or the context's error if the context is done first.
+func (b *Bridge) Receive(ctx context.Context) (interface{}, error) {
+	select {
+	case v := <-b.toHost:
+		return v, nil
+	case <-b.done:
+		return nil, ErrBridgeClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Close closes the bridge, so Send and Receive return ErrBridgeClosed, and so do `deliver` and `receive` in the vm.
+// Closing a closed bridge does nothing.
+func (b *Bridge) Close() {
+	b.close()
+}
+
+// Done returns a channel that's closed once the bridge is closed by the host or the vm.
+func (b *Bridge) Done() <-chan struct{} {
+	return b.done
+}
+
+// Polymorphic helper functions -----------------------------------------
+
+// Value returns the host side of the bridge
+func (bo *BridgeObject) Value() interface{} {
+	return bo.bridge
+}
+
+// ToString returns the object's name as the string format
+func (bo *BridgeObject) ToString() string {
+	return fmt.Sprintf("<Channel::Bridge: %p>", bo.bridge)
+}
+
+// Inspect delegates to ToString
+func (bo *BridgeObject) Inspect() string {
+	return bo.ToString()
+}
+
+// ToJSON just delegates to ToString
+func (bo *BridgeObject) ToJSON(t *Thread) string {
+	return unsupportedJSON(bo)
+}
+
+// Other helper functions -----------------------------------------------
+
+// close closes the bridge and returns true, or returns false if it was already closed
+func (b *Bridge) close() bool {
+	closed := false
+
+	b.closeOnce.Do(func() {
+		close(b.done)
+		closed = true
+	})
+
+	return closed
+}
+
+func (b *Bridge) closed() bool {
+	select {
+	case <-b.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// receiveInVM waits for a value from the host, and returns false if the bridge is closed instead
+func (b *Bridge) receiveInVM() (interface{}, bool) {
+	select {
+	case v := <-b.toVM:
+		return v, true
+	case <-b.done:
+		return nil, false
+	}
+}
+
+// bridgeValueOf converts the object to the Go value sent through a bridge.
+// seen holds the Arrays and Hashes being converted, to refuse the ones containing themselves.
+func (t *Thread) bridgeValueOf(obj Object, sourceLine int, seen map[Object]bool) (interface{}, *Error) {
+	switch o := obj.(type) {
+	case *NullObject:
+		return nil, nil
+	case *BooleanObject:
+		return o.value, nil
+	case *IntegerObject:
+		return o.value, nil
+	case *FloatObject:
+		return o.value, nil
+	case *StringObject:
+		return o.value, nil
+	case *ArrayObject:
+		return t.bridgeSliceOf(obj, o.Elements, sourceLine, seen)
+	case *ConcurrentArrayObject:
+		return t.bridgeSliceOf(obj, o.snapshot(), sourceLine, seen)
+	case *HashObject:
+		return t.bridgeMapOf(obj, o.Pairs, sourceLine, seen)
+	case *ConcurrentHashObject:
+		pairs := map[string]Object{}
+		o.copyInto(pairs)
+		return t.bridgeMapOf(obj, pairs, sourceLine, seen)
+	default:
+		return nil, t.vm.InitErrorObject(errors.TypeError, sourceLine, cantBridge, obj.Class().Name)
+	}
+}
+
+func (t *Thread) bridgeSliceOf(obj Object, elems []Object, sourceLine int, seen map[Object]bool) (interface{}, *Error) {
+	if seen[obj] {
+		return nil, t.vm.InitErrorObject(errors.ArgumentError, sourceLine, cantBridgeRecursive, obj.Class().Name)
+	}
+
+	seen[obj] = true
+	defer delete(seen, obj)
+
+	values := make([]interface{}, len(elems))
+
+	for i, elem := range elems {
+		v, err := t.bridgeValueOf(elem, sourceLine, seen)
+
+		if err != nil {
+			return nil, err
+		}
+
+		values[i] = v
+	}
+
+	return values, nil
+}
+
+func (t *Thread) bridgeMapOf(obj Object, pairs map[string]Object, sourceLine int, seen map[Object]bool) (interface{}, *Error) {
+	if seen[obj] {
+		return nil, t.vm.InitErrorObject(errors.ArgumentError, sourceLine, cantBridgeRecursive, obj.Class().Name)
+	}
+
+	seen[obj] = true
+	defer delete(seen, obj)
+
+	values := make(map[string]interface{}, len(pairs))
+
+	for key, value := range pairs {
+		v, err := t.bridgeValueOf(value, sourceLine, seen)
+
+		if err != nil {
+			return nil, err
+		}
+
+		values[key] = v
+	}
+
+	return values, nil
+}
+
+// maxBridgeDepth bounds how deeply the values the host sends can be nested, since they may contain themselves
+const maxBridgeDepth = 1000
+
+// copyBridgeValue copies a value the host sends, so the host can't change it while the vm converts it
+func copyBridgeValue(value interface{}, depth int) (interface{}, error) {
+	if depth > maxBridgeDepth {
+		return nil, fmt.Errorf("vm: can't send values nested more than %d levels through a bridge", maxBridgeDepth)
+	}
+
+	switch v := value.(type) {
+	case nil, bool, int, int32, int64, float64, string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case []interface{}:
+		values := make([]interface{}, len(v))
+
+		for i, elem := range v {
+			c, err := copyBridgeValue(elem, depth+1)
+			if err != nil {
+				return nil, err
+			}
+
+			values[i] = c
+		}
+
+		return values, nil
+	case map[string]interface{}:
+		values := make(map[string]interface{}, len(v))
+
+		for key, elem := range v {
+			c, err := copyBridgeValue(elem, depth+1)
+			if err != nil {
+				return nil, err
+			}
+
+			values[key] = c
+		}
+
+		return values, nil
+	default:
+		return nil, fmt.Errorf("vm: can't send %T through a bridge", value)
+	}
+}
+
+// initObjectFromBridge converts a value received through a bridge to new objects
+func (vm *VM) initObjectFromBridge(value interface{}) Object {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		pairs := make(map[string]Object, len(v))
+
+		for key, elem := range v {
+			pairs[key] = vm.initObjectFromBridge(elem)
+		}
+
+		return vm.InitHashObject(pairs)
+	case []interface{}:
+		elems := make([]Object, len(v))
+
+		for i, elem := range v {
+			elems[i] = vm.initObjectFromBridge(elem)
+		}
+
+		return vm.InitArrayObject(elems)
+	default:
+		return vm.InitObjectFromGoType(v)
+	}
+}
@@ -0,0 +1,257 @@
+package vm
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// New sets the vm-wide singletons like NULL, so the vms are created before any of them runs,
+// and no vm thread outlives a test.
+func TestBridgeBetweenVMs(t *testing.T) {
+	producer, consumer := initTestVM(), initTestVM()
+	work, _ := NewBridge(producer, "Work")
+	jobs, _ := NewBridge(consumer, "Jobs")
+
+	var relays sync.WaitGroup
+	relay := func(from, to *Bridge) {
+		defer relays.Done()
+
+		for {
+			v, err := from.Receive(context.Background())
+
+			if err != nil {
+				// the producer closing its bridge tells the consumer there's no more work
+				if err == ErrBridgeClosed {
+					to.Close()
+					return
+				}
+
+				t.Errorf("Unexpected error: %s", err)
+				return
+			}
+
+			if err := to.Send(v); err != nil && err != ErrBridgeClosed {
+				t.Errorf("Unexpected error: %s", err)
+			}
+		}
+	}
+
+	relays.Add(2)
+	go relay(work, jobs)
+	go relay(jobs, work)
+
+	var results [2]Object
+	var vms sync.WaitGroup
+	vms.Add(2)
+
+	go func() {
+		defer vms.Done()
+
+		results[0] = producer.testEval(t, `
+		results = []
+		5.times do |i|
+		  Work.deliver({ id: i, payload: { tags: ["a", i.to_s], weight: 1.5, done: false, note: nil } })
+		  results.push(Work.receive)
+		end
+		Work.close
+
+		r = results[4]
+		[results.map do |r| r["id"] end, r["size"], r["tags"], r["weight"], r["done"], r["note"], Work.closed?]
+		`, getFilename())
+	}()
+
+	go func() {
+		defer vms.Done()
+
+		results[1] = consumer.testEval(t, `
+		count = 0
+		Jobs.each do |job|
+		  payload = job["payload"]
+		  Jobs.deliver({ id: job["id"], size: payload["tags"].length, tags: payload["tags"], weight: payload["weight"] * 2, done: !payload["done"], note: payload["note"] })
+		  count += 1
+		end
+		[count, Jobs.closed?]
+		`, getFilename())
+	}()
+
+	vms.Wait()
+	relays.Wait()
+
+	VerifyExpected(t, 0, results[0], []interface{}{[]interface{}{0, 1, 2, 3, 4}, 2, []interface{}{"a", "4"}, 3.0, true, nil, true})
+	VerifyExpected(t, 1, results[1], []interface{}{5, true})
+	producer.checkCFP(t, 0, 0)
+	consumer.checkCFP(t, 1, 0)
+}
+
+func TestBridgeHostSide(t *testing.T) {
+	v := initTestVM()
+	b, obj := NewBridge(v, "Host")
+
+	if _, ok := obj.(*BridgeObject); !ok || obj.Class().Name != "Bridge" {
+		t.Fatalf("Expect NewBridge to return a Channel::Bridge. got: %s", obj.Class().Name)
+	}
+
+	tags := []interface{}{"a", []byte("b")}
+	sent := make(chan error, 1)
+	var received interface{}
+
+	go func() {
+		err := b.Send(map[string]interface{}{"n": 1, "big": int64(2), "f": 0.5, "ok": true, "none": nil, "tags": tags})
+
+		// the Go slice is copied when it's sent, so changing it doesn't affect the vm
+		tags[0] = "changed"
+
+		if err == nil {
+			received, err = b.Receive(context.Background())
+		}
+
+		sent <- err
+	}()
+
+	evaluated := v.testEval(t, `
+	h = Host.receive
+	Host.deliver([h["tags"], { nested: [[1], {}] }])
+	[h["n"] + h["big"], h["f"], h["ok"], h["none"], h["tags"], h.keys.sort]
+	`, getFilename())
+
+	if err := <-sent; err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	VerifyExpected(t, 0, evaluated, []interface{}{3, 0.5, true, nil, []interface{}{"a", "b"}, []interface{}{"big", "f", "n", "none", "ok", "tags"}})
+	v.checkCFP(t, 0, 0)
+
+	expected := []interface{}{[]interface{}{"a", "b"}, map[string]interface{}{"nested": []interface{}{[]interface{}{1}, map[string]interface{}{}}}}
+	if !reflect.DeepEqual(received, expected) {
+		t.Errorf("Expect to receive %#v. got: %#v", expected, received)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := b.Receive(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expect Receive to return the context's error. got: %v", err)
+	}
+
+	if err := b.Send(map[string]interface{}{"obj": v.InitStringObject("x")}); err == nil || err.Error() != "vm: can't send *vm.StringObject through a bridge" {
+		t.Errorf("Expect Send to refuse Goby objects. got: %v", err)
+	}
+
+	if err := b.Send(struct{}{}); err == nil || err.Error() != "vm: can't send struct {} through a bridge" {
+		t.Errorf("Expect Send to refuse values it can't convert. got: %v", err)
+	}
+}
+
+func TestBridgeClose(t *testing.T) {
+	// closing on the host is seen by the vm
+	v := initTestVM()
+	b, _ := NewBridge(v, "Host")
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		b.Close()
+		b.Close()
+	}()
+
+	evaluated := v.testEval(t, `
+	r = []
+	Host.each do |x|
+	  r.push(x)
+	end
+	[r, Host.closed?]
+	`, getFilename())
+	VerifyExpected(t, 0, evaluated, []interface{}{[]interface{}{}, true})
+	v.checkCFP(t, 0, 0)
+	v.checkSP(t, 0, 1)
+
+	// closing in the vm is seen by the host
+	v = initTestVM()
+	b, _ = NewBridge(v, "Host")
+	received := make(chan error, 1)
+
+	go func() {
+		_, err := b.Receive(context.Background())
+		received <- err
+	}()
+
+	evaluated = v.testEval(t, `
+	Host.close
+	Host.closed?
+	`, getFilename())
+	VerifyExpected(t, 1, evaluated, true)
+
+	select {
+	case <-b.Done():
+	default:
+		t.Error("Expect Done to be closed once the vm closes the bridge")
+	}
+
+	if err := <-received; err != ErrBridgeClosed {
+		t.Errorf("Expect a waiting Receive to return ErrBridgeClosed. got: %v", err)
+	}
+
+	if err := b.Send(1); err != ErrBridgeClosed {
+		t.Errorf("Expect Send to return ErrBridgeClosed. got: %v", err)
+	}
+
+	// a break stops receiving
+	v = initTestVM()
+	b, _ = NewBridge(v, "Host")
+
+	go func() {
+		for i := 1; b.Send(i) == nil; i++ {
+		}
+	}()
+
+	evaluated = v.testEval(t, `
+	r = Host.each do |i|
+	  if i == 3
+	    break i * 10
+	  end
+	end
+	[r, Host.closed?]
+	`, getFilename())
+	b.Close()
+	VerifyExpected(t, 2, evaluated, []interface{}{30, false})
+	v.checkCFP(t, 2, 0)
+	v.checkSP(t, 2, 1)
+}
+
+func TestBridgeMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`Host.deliver(1..2)`, "TypeError: Can't deliver Range through a Bridge", 1},
+		{`Host.deliver({ a: [1, Object.new] })`, "TypeError: Can't deliver Object through a Bridge", 1},
+		{`
+		a = [1]
+		a.push(a)
+		Host.deliver(a)
+		`, "ArgumentError: Can't deliver a recursive Array through a Bridge", 1},
+		{`Host.deliver`, "ArgumentError: Expect 1 argument(s). got: 0", 1},
+		{`Host.receive(1)`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+		{`Host.each`, "InternalError: Can't yield without a block", 1},
+		{`
+		Host.close
+		Host.close
+		`, "ChannelCloseError: The channel is already closed.", 1},
+		{`
+		Host.close
+		Host.receive
+		`, "ChannelCloseError: The channel is already closed.", 1},
+		{`
+		Host.close
+		Host.deliver(1)
+		`, "ChannelCloseError: The channel is already closed.", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		NewBridge(v, "Host")
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
@@ -66,6 +66,10 @@ type normalCallFrame struct {
 	instructionSet *instructionSet
 	// program counter
 	pc int
+	// the value given to `break` when the frame is a block source frame
+	breakValue Object
+	// the visibility of the methods defined next in a class body, set by `private`, `protected` or `public` without arguments
+	defaultVisibility visibility
 }
 
 func (n *normalCallFrame) instructionsCount() int {
@@ -176,19 +180,28 @@ func (b *baseFrame) storeConstant(constName string, constant interface{}) *Point
 
 	switch scope := b.self.(type) {
 	case *RClass:
-		scope.constants[constName] = ptr
+		scope.setConstant(constName, ptr)
 
 		if class, ok := ptr.Target.(*RClass); ok {
 			class.scope = scope
 		}
 	default:
 		c := b.self.Class()
-		c.constants[constName] = ptr
+		c.setConstant(constName, ptr)
 	}
 
 	return ptr
 }
 
+// classVariableScope returns the class whose class variables the frame accesses
+func (b *baseFrame) classVariableScope() *RClass {
+	if class, ok := b.self.(*RClass); ok {
+		return class
+	}
+
+	return b.self.Class()
+}
+
 func (b *baseFrame) lookupConstantUnderAllScope(constName string) *Pointer {
 	var c *Pointer
 
@@ -89,8 +89,8 @@ func (co *callObject) assignNormalAndOptionedArguments(paramIndex int, stack []*
 
 		This loop is for skipping other types of arguments and get the correct argument index.
 	*/
-	for argIndex, at := range co.argTypes() {
-		if co.lastArgIndex < argIndex && (at == bytecode.NormalArg || at == bytecode.OptionedArg) {
+	for argIndex := co.lastArgIndex + 1; argIndex < co.argCount; argIndex++ {
+		if !co.isKeywordArgAt(argIndex) {
 			co.callFrame.insertLCL(paramIndex, 0, stack[co.argPtr()+argIndex].Target)
 
 			// Store latest index value (and equalTo them to current argument index)
@@ -101,7 +101,9 @@ func (co *callObject) assignNormalAndOptionedArguments(paramIndex int, stack []*
 	}
 }
 
-func (co *callObject) assignKeywordArguments(stack []*Pointer) (err error) {
+// assignKeywordArguments assigns the keyword arguments to the parameters with the same names.
+// The ones without a parameter are collected in the given hash for the double splat parameter, or they're reported as an error if it's nil.
+func (co *callObject) assignKeywordArguments(stack []*Pointer, extras *HashObject) (err error) {
 	for argIndex, argType := range co.argTypes() {
 		if argType == bytecode.RequiredKeywordArg || argType == bytecode.OptionalKeywordArg {
 			argName := co.argSet.Names()[argIndex]
@@ -109,24 +111,47 @@ func (co *callObject) assignKeywordArguments(stack []*Pointer) (err error) {
 
 			if ok {
 				co.callFrame.insertLCL(paramIndex, 0, stack[co.argPtr()+argIndex].Target)
+			} else if extras != nil {
+				extras.Pairs[argName] = stack[co.argPtr()+argIndex].Target
 			} else {
 				err = fmt.Errorf("unknown key %s for method %s", argName, co.methodName())
 			}
 		}
 	}
 
+	if extras != nil {
+		for paramIndex, paramType := range co.paramTypes() {
+			if paramType == bytecode.DoubleSplatArg {
+				co.callFrame.insertLCL(paramIndex, 0, extras)
+			}
+		}
+	}
+
 	return
 }
 
-func (co *callObject) assignSplatArgument(stack []*Pointer, arr *ArrayObject) {
-	index := len(co.paramTypes()) - 1
+// assignSplatArgument collects the positional arguments that are left after the normal and optioned parameters took theirs
+func (co *callObject) assignSplatArgument(paramIndex int, stack []*Pointer, arr *ArrayObject) {
+	for argIndex := co.lastArgIndex + 1; argIndex < co.argCount; argIndex++ {
+		if !co.isKeywordArgAt(argIndex) {
+			arr.Elements = append(arr.Elements, stack[co.argPtr()+argIndex].Target)
+			co.lastArgIndex = argIndex
+		}
+	}
+
+	co.callFrame.insertLCL(paramIndex, 0, arr)
+}
+
+// isKeywordArgAt tells if the argument at the index is passed with a key.
+// The arguments without type information, like the ones passed through `send`, are positional.
+func (co *callObject) isKeywordArgAt(argIndex int) bool {
+	argTypes := co.argTypes()
 
-	for co.argIndex < co.argCount {
-		arr.Elements = append(arr.Elements, stack[co.argPosition()].Target)
-		co.argIndex++
+	if argIndex >= len(argTypes) {
+		return false
 	}
 
-	co.callFrame.insertLCL(index, 0, arr)
+	return argTypes[argIndex] == bytecode.RequiredKeywordArg || argTypes[argIndex] == bytecode.OptionalKeywordArg
 }
 
 func (co *callObject) hasKeywordParam(name string) (index int, result bool) {
@@ -167,6 +192,26 @@ func (co *callObject) normalParamsCount() (n int) {
 	return
 }
 
+func (co *callObject) keywordArgsCount() (n int) {
+	for argIndex := range co.argTypes() {
+		if co.isKeywordArgAt(argIndex) {
+			n++
+		}
+	}
+
+	return
+}
+
+func (co *callObject) positionalParamsCount() (n int) {
+	for _, pt := range co.paramTypes() {
+		if pt == bytecode.NormalArg || pt == bytecode.OptionedArg {
+			n++
+		}
+	}
+
+	return
+}
+
 func (co *callObject) normalArgsCount() (n int) {
 	for _, at := range co.argTypes() {
 		if at == bytecode.NormalArg {
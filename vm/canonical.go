@@ -0,0 +1,132 @@
+package vm
+
+import (
+	"sort"
+	"strings"
+)
+
+// Canonicalize returns a deterministic representation of the object graph, meant to be compared with golden values in tests.
+//
+// Unlike Inspect, it never includes object ids or addresses, and the output doesn't depend on the order pairs or instance variables were set:
+// literal values are written like their inspect, arrays as `[1, 2]`, hashes as `{"a": 1, "b": 2}` with sorted keys,
+// objects as `ClassName{@a: 1, @b: 2}` with sorted instance variables, and classes by their names.
+// The concurrent collections are prefixed by their class names, like `Concurrent::Array[1, 2]`,
+// and the other objects are written as `#<ClassName>`.
+//
+// A collection or object that contains itself is written as `[...]`, `{...}` or `ClassName{...}` where it recurs.
+func Canonicalize(obj Object) string {
+	c := &canonicalizer{visiting: make(map[Object]bool)}
+	c.write(obj)
+	return c.out.String()
+}
+
+type canonicalizer struct {
+	out strings.Builder
+	// visiting holds the containers on the path from the root to the current object, for detecting cycles
+	visiting map[Object]bool
+}
+
+func (c *canonicalizer) write(obj Object) {
+	switch o := obj.(type) {
+	case *IntegerObject, *FloatObject, *DecimalObject, *BigIntegerObject, *RationalObject,
+		*StringObject, *BooleanObject, *NullObject, *RangeObject, *MatchDataObject, *RClass:
+		c.out.WriteString(o.Inspect())
+	case *RegexpObject:
+		c.out.WriteString("/" + o.ToString() + "/")
+	case *Error:
+		c.out.WriteString("#<" + o.message + ">")
+	case *ArrayObject:
+		c.writeElements(o, "", o.Elements)
+	case *ConcurrentArrayObject:
+		c.writeElements(o, "Concurrent::Array", o.snapshot())
+	case *HashObject:
+		c.writePairs(o, "", o.Pairs)
+	case *ConcurrentHashObject:
+		c.writePairs(o, "Concurrent::Hash", o.pairs())
+	case *RObject:
+		c.writeObject(o)
+	default:
+		c.out.WriteString("#<" + obj.Class().Name + ">")
+	}
+}
+
+func (c *canonicalizer) writeElements(container Object, prefix string, elems []Object) {
+	c.out.WriteString(prefix)
+
+	if c.visiting[container] {
+		c.out.WriteString("[...]")
+		return
+	}
+
+	c.visiting[container] = true
+	c.out.WriteString("[")
+
+	for i, elem := range elems {
+		if i > 0 {
+			c.out.WriteString(", ")
+		}
+
+		c.write(elem)
+	}
+
+	c.out.WriteString("]")
+	delete(c.visiting, container)
+}
+
+func (c *canonicalizer) writePairs(container Object, prefix string, pairs map[string]Object) {
+	c.out.WriteString(prefix)
+
+	if c.visiting[container] {
+		c.out.WriteString("{...}")
+		return
+	}
+
+	keys := make([]string, 0, len(pairs))
+
+	for key := range pairs {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	c.visiting[container] = true
+	c.out.WriteString("{")
+
+	for i, key := range keys {
+		if i > 0 {
+			c.out.WriteString(", ")
+		}
+
+		c.out.WriteString(`"` + escapeSpecialChars(escapeBackslash(key)) + `": `)
+		c.write(pairs[key])
+	}
+
+	c.out.WriteString("}")
+	delete(c.visiting, container)
+}
+
+func (c *canonicalizer) writeObject(o *RObject) {
+	c.out.WriteString(o.class.Name)
+
+	if c.visiting[o] {
+		c.out.WriteString("{...}")
+		return
+	}
+
+	c.visiting[o] = true
+	c.out.WriteString("{")
+
+	// names are sorted already
+	for i, name := range o.InstanceVariables.names() {
+		if i > 0 {
+			c.out.WriteString(", ")
+		}
+
+		v, _ := o.InstanceVariableGet(name)
+		c.out.WriteString(name + ": ")
+		c.write(v)
+	}
+
+	c.out.WriteString("}")
+	delete(c.visiting, o)
+}
@@ -0,0 +1,191 @@
+package vm
+
+import (
+	"testing"
+)
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`1`, `1`},
+		{`-2.5`, `-2.5`},
+		{`'13.5'.to_d`, `13.5`},
+		{`Rational(2, 4)`, `(1/2)`},
+		{`9223372036854775807 + 1`, `9223372036854775808`},
+		{`"a\"b\n"`, `"a\"b\n"`},
+		{`true`, `true`},
+		{`nil`, `nil`},
+		{`(1..3)`, `(1..3)`},
+		{`Regexp.new("a+")`, `/a+/`},
+		{`'a1b'.match(Regexp.new('(a.)'))`, `#<MatchData 0:"a1" 1:"a1">`},
+		{`String`, `String`},
+		{`[]`, `[]`},
+		{`[1, "a", [nil, [true]]]`, `[1, "a", [nil, [true]]]`},
+		{`{}`, `{}`},
+		{`{ b: 1, a: { d: [1], c: "x" } }`, `{"a": {"c": "x", "d": [1]}, "b": 1}`},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([2, [1]])
+		`, `Concurrent::Array[2, [1]]`},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ b: 2, a: 1 })
+		`, `Concurrent::Hash{"a": 1, "b": 2}`},
+		{`
+		class Foo; end
+		Foo.new
+		`, `Foo{}`},
+		{`
+		class Foo
+		  def initialize
+		    @zeta = { b: 2, a: 1 }
+		    @alpha = [1]
+		  end
+		end
+
+		Foo.new
+		`, `Foo{@alpha: [1], @zeta: {"a": 1, "b": 2}}`},
+		// the same object referred twice isn't a cycle
+		{`
+		a = [1]
+		[a, a]
+		`, `[[1], [1]]`},
+		{`Channel.new`, `#<Channel>`},
+		{`
+		Block.new do
+		end
+		`, `#<Block>`},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		v.SetIntegerOverflowPolicy(OverflowPromote)
+		evaluated := v.testEval(t, tt.input, getFilename())
+
+		if isError(evaluated) {
+			t.Fatalf("At test case %d: %s", i, evaluated.ToString())
+		}
+
+		if got := Canonicalize(evaluated); got != tt.expected {
+			t.Errorf("At test case %d: expect canonical form to be %s. got: %s", i, tt.expected, got)
+		}
+
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestCanonicalizeError(t *testing.T) {
+	v := initTestVM()
+	err := v.InitErrorObject("ArgumentError", 1, "Expect %d argument(s). got: %d", 1, 2)
+
+	expected := `#<ArgumentError: Expect 1 argument(s). got: 2>`
+
+	if got := Canonicalize(err); got != expected {
+		t.Errorf("Expect canonical form to be %s. got: %s", expected, got)
+	}
+}
+
+func TestCanonicalizeCycles(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`
+		a = [1]
+		a.push(a)
+		a
+		`, `[1, [...]]`},
+		{`
+		h = { a: 1 }
+		h[:b] = [h]
+		h
+		`, `{"a": 1, "b": [{...}]}`},
+		{`
+		class Node
+		  def initialize(value)
+		    @value = value
+		  end
+
+		  def next=(node)
+		    @next = node
+		  end
+		end
+
+		a = Node.new(1)
+		b = Node.new(2)
+		a.next = b
+		b.next = a
+		a
+		`, `Node{@next: Node{@next: Node{...}, @value: 2}, @value: 1}`},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+
+		if isError(evaluated) {
+			t.Fatalf("At test case %d: %s", i, evaluated.ToString())
+		}
+
+		if got := Canonicalize(evaluated); got != tt.expected {
+			t.Errorf("At test case %d: expect canonical form to be %s. got: %s", i, tt.expected, got)
+		}
+
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestToCanonicalMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`[1, { b: 2, a: nil }].to_canonical`, `[1, {"a": nil, "b": 2}]`},
+		{`
+		class Point
+		  def initialize(x, y)
+		    @y = y
+		    @x = x
+		  end
+		end
+
+		Point.new(1, Point.new(2, 3)).to_canonical
+		`, `Point{@x: 1, @y: Point{@x: 2, @y: 3}}`},
+		// it doesn't depend on the order the pairs are inserted
+		{`
+		h1 = {}
+		h1[:a] = 1
+		h1[:b] = 2
+		h2 = {}
+		h2[:b] = 2
+		h2[:a] = 1
+		h1.to_canonical == h2.to_canonical
+		`, true},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestToCanonicalMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`1.to_canonical(1)`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
@@ -262,7 +262,7 @@ func (co *ChannelObject) Inspect() string {
 
 // ToJSON just delegates to ToString
 func (co *ChannelObject) ToJSON(t *Thread) string {
-	return co.ToString()
+	return unsupportedJSON(co)
 }
 
 // copy returns the duplicate of the Array object
@@ -1,15 +1,19 @@
 package vm
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path"
+	"strings"
 	"sync"
 	"time"
 
+	"math/big"
 	"math/rand"
 	"sort"
 
+	"github.com/goby-lang/goby/compiler/bytecode"
 	"github.com/goby-lang/goby/vm/classes"
 	"github.com/goby-lang/goby/vm/errors"
 )
@@ -18,8 +22,8 @@ import (
 type RClass struct {
 	// Name is the class's name
 	Name string
-	// Methods contains its instances' methods
-	Methods *environment
+	// Methods contains its instances' methods, in definition order
+	Methods *methodTable
 	// pseudoSuperClass points to the class it inherits
 	pseudoSuperClass *RClass
 	// This is the class where we should looking for a method.
@@ -31,6 +35,13 @@ type RClass struct {
 	constants             map[string]*Pointer
 	scope                 *RClass
 	inheritsMethodMissing bool
+	// jsonAttributes are the instance variable names serialized by the default `to_json`, nil means all of them
+	jsonAttributes []string
+	// classVariables holds the `@@` variables defined on this class, they're shared with its subclasses
+	classVariables    map[string]Object
+	classVariableLock sync.RWMutex
+	// constantLock guards constants, which threads may define while others look them up
+	constantLock sync.RWMutex
 	*BaseObj
 }
 
@@ -69,7 +80,7 @@ func NewExternalClassLoader(className, libPath string, classMethods, instanceMet
 			return nil
 		}
 
-		return v.mainThread.execGobyLib(libPath)
+		return v.execGobyLibInNewThread(libPath)
 	}
 }
 
@@ -320,6 +331,132 @@ var builtinModuleCommonClassMethods = []*BuiltinMethodObject{
 			return NULL
 		},
 	},
+	{
+		// Defines a new name for an instance method of the class or its ancestors. The alias keeps the method
+		// as it's defined now, so redefining or removing the original method later doesn't affect the alias.
+		//
+		// ```ruby
+		// class Foo
+		//   def bar
+		//     1
+		//   end
+		//
+		//   alias_method(:baz, :bar)
+		//
+		//   def bar
+		//     2
+		//   end
+		// end
+		//
+		// Foo.new.baz #=> 1
+		// Foo.new.bar #=> 2
+		// ```
+		//
+		// @param new name [String], old name [String]
+		// @return [String] The new name
+		Name: "alias_method",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 2 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 2, len(args))
+			}
+
+			err := t.vm.checkArgTypes(args, sourceLine, classes.StringClass, classes.StringClass)
+
+			if err != nil {
+				return err
+			}
+
+			c, ok := receiver.(*RClass)
+
+			if !ok {
+				return t.vm.InitNoMethodError(sourceLine, "alias_method", receiver)
+			}
+
+			newName, oldName := args[0].Value().(string), args[1].Value().(string)
+			method := c.lookupMethod(oldName)
+
+			if method == nil {
+				return t.vm.InitErrorObject(errors.NameError, sourceLine, errors.UndefinedMethodForClass, oldName, c.Name)
+			}
+
+			c.Methods.set(newName, method)
+			return args[0]
+		},
+	},
+	{
+		// Makes the named instance methods cache their results for each instance and each set of arguments,
+		// so an expensive method is computed once and later calls return the cached result, even nil or false.
+		// Arguments are the same when they're equal, like `[1, 2]` and another `[1, 2]`.
+		// If several threads call a method for the first time at once, one computes the result and the others wait for it.
+		// A call raising an error isn't cached. Use `clear_memoized` on an instance to forget its results.
+		// Methods taking a block can't be memoized, and calling a memoized method with a block is an ArgumentError.
+		//
+		// ```ruby
+		// class Foo
+		//   def square(n)
+		//     sleep(1)
+		//     n * n
+		//   end
+		//
+		//   memoize(:square)
+		// end
+		//
+		// f = Foo.new
+		// f.square(3) #=> 9, after a second
+		// f.square(3) #=> 9, right away
+		// f.clear_memoized(:square)
+		// ```
+		//
+		// @param *names [String] The method names
+		// @return [Class] self
+		Name: "memoize",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			c, ok := receiver.(*RClass)
+
+			if !ok {
+				return t.vm.InitNoMethodError(sourceLine, "memoize", receiver)
+			}
+
+			err := t.forEachMethodName(args, sourceLine, func(name string) *Error {
+				return t.memoize(c, name, sourceLine)
+			})
+
+			if err != nil {
+				return err
+			}
+
+			return c
+		},
+	},
+	{
+		// Returns the number of arguments the given instance method takes.
+		// For methods taking optional arguments, it returns `-n-1` where `n` is the number of required arguments.
+		// Methods written in Go always return `-1`.
+		//
+		// ```ruby
+		// class Foo
+		//   def bar(a, b); end
+		//   def baz(a, b = 1); end
+		// end
+		//
+		// Foo.arity_of("bar")    #=> 2
+		// Foo.arity_of("baz")    #=> -2
+		// Foo.arity_of("to_s")   #=> -1
+		// ```
+		//
+		// @param method name [String]
+		// @return [Integer]
+		Name: "arity_of",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			entry, err := methodEntryOf(t, receiver, sourceLine, args)
+
+			if err != nil {
+				return err
+			}
+
+			return t.vm.InitIntegerObject(entry.arity)
+		},
+	},
 	{
 		// Creates instance variables and corresponding methods that return the value of
 		// each instance variable and assign an argument to each instance variable.
@@ -353,6 +490,10 @@ var builtinModuleCommonClassMethods = []*BuiltinMethodObject{
 		// @return [Null]
 		Name: "attr_accessor",
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if err := t.checkAttrNames(args, sourceLine); err != nil {
+				return err
+			}
+
 			r := receiver.(*RClass)
 			r.setAttrAccessor(args)
 
@@ -387,6 +528,10 @@ var builtinModuleCommonClassMethods = []*BuiltinMethodObject{
 		// @return [Null]
 		Name: "attr_reader",
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if err := t.checkAttrNames(args, sourceLine); err != nil {
+				return err
+			}
+
 			r := receiver.(*RClass)
 			r.setAttrReader(args)
 
@@ -421,12 +566,114 @@ var builtinModuleCommonClassMethods = []*BuiltinMethodObject{
 		// @return [Null]
 		Name: "attr_writer",
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if err := t.checkAttrNames(args, sourceLine); err != nil {
+				return err
+			}
+
 			r := receiver.(*RClass)
 			r.setAttrWriter(args)
 
 			return r
 		},
 	},
+	{
+		// Returns the value of the given class variable, which can be inherited from the superclasses.
+		// A warning is printed and nil is returned if the variable isn't initialized.
+		//
+		// ```ruby
+		// class Foo
+		//   @@count = 1
+		// end
+		//
+		// class Bar < Foo; end
+		//
+		// Foo.class_variable_get("@@count") #=> 1
+		// Bar.class_variable_get("@@count") #=> 1
+		// ```
+		//
+		// @param name [String] the class variable's name, with the `@@` prefix
+		// @return [Object]
+		Name: "class_variable_get",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			name, err := t.vm.classVariableNameArg(args[0], sourceLine)
+
+			if err != nil {
+				return err
+			}
+
+			return t.getClassVariable(receiver.(*RClass), name)
+		},
+	},
+	{
+		// Sets the given class variable and returns the value.
+		// Like an assignment in the class body, it updates the variable on the superclass that defines it.
+		//
+		// ```ruby
+		// class Foo
+		//   @@count = 1
+		// end
+		//
+		// class Bar < Foo; end
+		//
+		// Bar.class_variable_set("@@count", 2) #=> 2
+		// Foo.class_variable_get("@@count")    #=> 2
+		// ```
+		//
+		// @param name [String] the class variable's name, with the `@@` prefix
+		// @param value [Object]
+		// @return [Object]
+		Name: "class_variable_set",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 2 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 2, len(args))
+			}
+
+			name, err := t.vm.classVariableNameArg(args[0], sourceLine)
+
+			if err != nil {
+				return err
+			}
+
+			receiver.(*RClass).setClassVariable(name, args[1])
+			return args[1]
+		},
+	},
+	{
+		// Returns the sorted names of the class variables the receiver can access, including the inherited ones.
+		//
+		// ```ruby
+		// class Foo
+		//   @@count = 1
+		// end
+		//
+		// class Bar < Foo
+		//   @@name = "bar"
+		// end
+		//
+		// Foo.class_variables #=> ["@@count"]
+		// Bar.class_variables #=> ["@@count", "@@name"]
+		// ```
+		//
+		// @return [Array]
+		Name: "class_variables",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			var names []Object
+
+			for _, name := range receiver.(*RClass).classVariableNames() {
+				names = append(names, t.vm.InitStringObject(name))
+			}
+
+			return t.vm.InitArrayObject(names)
+		},
+	},
 	{
 		Name: "constants",
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
@@ -434,9 +681,11 @@ var builtinModuleCommonClassMethods = []*BuiltinMethodObject{
 			var objs []Object
 			r := receiver.(*RClass)
 
+			r.constantLock.RLock()
 			for n := range r.constants {
 				constantNames = append(constantNames, n)
 			}
+			r.constantLock.RUnlock()
 			sort.Strings(constantNames)
 
 			for _, cn := range constantNames {
@@ -477,6 +726,10 @@ var builtinModuleCommonClassMethods = []*BuiltinMethodObject{
 	{
 		Name: "extend",
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
 			var class *RClass
 			module, ok := args[0].(*RClass)
 
@@ -624,6 +877,85 @@ var builtinModuleCommonClassMethods = []*BuiltinMethodObject{
 			return class
 		},
 	},
+	{
+		// Declares which instance variables are included when the class's instances are converted to JSON.
+		// Without it, all the instance variables are included. Subclasses inherit the declaration.
+		// It has no effect if the class defines its own `to_json`.
+		//
+		// ```ruby
+		// class User
+		//   json_attributes("name", "age")
+		//
+		//   def initialize(name, age, password)
+		//     @name = name
+		//     @age = age
+		//     @password = password
+		//   end
+		// end
+		//
+		// User.new("Stan", 23, "secret").to_json # => {"name":"Stan","age":23}
+		// ```
+		//
+		// @param *args [String] One or more instance variable names, without the `@` prefix
+		// @return [Class]
+		Name: "json_attributes",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			r := receiver.(*RClass)
+			names := []string{}
+
+			for i, arg := range args {
+				name, ok := arg.(*StringObject)
+
+				if !ok {
+					return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormatNum, i+1, classes.StringClass, arg.Class().Name)
+				}
+
+				names = append(names, "@"+name.value)
+			}
+
+			r.jsonAttributes = names
+
+			return r
+		},
+	},
+	{
+		// Returns true if the class or its ancestors define the given instance method.
+		//
+		// ```ruby
+		// class Foo
+		//   def bar; end
+		// end
+		//
+		// Foo.method_defined?("bar")  #=> true
+		// Foo.method_defined?("to_s") #=> true
+		// Foo.method_defined?("baz")  #=> false
+		// ```
+		//
+		// @param method name [String]
+		// @return [Boolean]
+		Name: "method_defined?",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			err := t.vm.checkArgTypes(args, sourceLine, classes.StringClass)
+
+			if err != nil {
+				return err
+			}
+
+			c, ok := receiver.(*RClass)
+
+			if !ok {
+				return t.vm.InitNoMethodError(sourceLine, "#method_defined?", receiver)
+			}
+
+			_, ok = c.lookupMethodEntry(args[0].Value().(string))
+
+			return toBooleanObject(ok)
+		},
+	},
 	{
 		// Returns the name of the class (receiver).
 		//
@@ -651,33 +983,181 @@ var builtinModuleCommonClassMethods = []*BuiltinMethodObject{
 			return nameString
 		},
 	},
+	{
+		// Makes methods private, so they can only be called without a receiver, like `foo`.
+		// A private writer method can also be called on `self`, like `self.foo = 1`.
+		// Without arguments, the methods defined after it in the class body become private.
+		// Otherwise the named methods become private.
+		//
+		// ```ruby
+		// class Foo
+		//   def bar
+		//     baz
+		//   end
+		//
+		//   private
+		//
+		//   def baz
+		//     10
+		//   end
+		// end
+		//
+		// Foo.new.bar #=> 10
+		// Foo.new.baz #=> NoMethodError: private method 'baz' called for #<Foo:...>
+		// ```
+		//
+		// @param *names [String] The method names
+		// @return [Object] nil without arguments, the name with one, or an array of the names
+		Name: "private",
+		Fn:   visibilityMethod(privateMethod),
+	},
+	{
+		// Makes methods protected, so they can also be called on another receiver
+		// from an instance of the class that defines them, including the subclasses' instances.
+		// Without arguments, the methods defined after it in the class body become protected.
+		// Otherwise the named methods become protected.
+		//
+		// ```ruby
+		// class Account
+		//   def initialize(balance)
+		//     @balance = balance
+		//   end
+		//
+		//   def >(other)
+		//     balance > other.balance
+		//   end
+		//
+		//   protected
+		//
+		//   def balance
+		//     @balance
+		//   end
+		// end
+		//
+		// Account.new(2) > Account.new(1) #=> true
+		// Account.new(2).balance          #=> NoMethodError: protected method 'balance' called for #<Account:...>
+		// ```
+		//
+		// @param *names [String] The method names
+		// @return [Object] nil without arguments, the name with one, or an array of the names
+		Name: "protected",
+		Fn:   visibilityMethod(protectedMethod),
+	},
+	{
+		// Makes methods public, which is the default visibility.
+		// Without arguments, the methods defined after it in the class body become public.
+		// Otherwise the named methods become public.
+		//
+		// ```ruby
+		// class Foo
+		//   private
+		//
+		//   def bar; end
+		//
+		//   public
+		//
+		//   def baz; end
+		// end
+		// ```
+		//
+		// @param *names [String] The method names
+		// @return [Object] nil without arguments, the name with one, or an array of the names
+		Name: "public",
+		Fn:   visibilityMethod(publicMethod),
+	},
+	{
+		// Removes the named instance methods from the class. Unlike `undef_method`, the methods of the same names
+		// in the ancestors can still be called. Raises a NameError if the class itself doesn't define a method.
+		//
+		// ```ruby
+		// class Foo
+		//   def bar
+		//     1
+		//   end
+		// end
+		//
+		// class Baz < Foo
+		//   def bar
+		//     2
+		//   end
+		//
+		//   remove_method(:bar)
+		// end
+		//
+		// Baz.new.bar #=> 1
+		// ```
+		//
+		// @param *names [String] The method names
+		// @return [Class] self
+		Name: "remove_method",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			c, ok := receiver.(*RClass)
+
+			if !ok {
+				return t.vm.InitNoMethodError(sourceLine, "remove_method", receiver)
+			}
+
+			err := t.forEachMethodName(args, sourceLine, func(name string) *Error {
+				if !c.Methods.remove(name) {
+					return t.vm.InitErrorObject(errors.NameError, sourceLine, errors.MethodNotDefinedIn, name, c.Name)
+				}
+
+				return nil
+			})
+
+			if err != nil {
+				return err
+			}
+
+			return c
+		},
+	},
 	{
 		// A predicate class method that returns `true` if the object has an ability to respond to the method, otherwise `false`.
 		// Note that signs like `+` or `?` should be String literal.
+		// Private and protected methods are only counted if the second argument is true.
 		//
 		// ```ruby
 		// Class.respond_to? "respond_to?"            #=> true
 		// Class.respond_to? :numerator        #=> false
 		// ```
 		//
-		// @param [String]
+		// @param [String], include_private [Boolean]
 		// @return [Boolean]
 		Name: "respond_to?",
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
-			if len(args) != 1 {
-				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
-			}
-
-			err := t.vm.checkArgTypes(args, sourceLine, classes.StringClass)
+			return t.respondTo(receiver, args, sourceLine)
+		},
+	},
+	{
+		// Returns the file name and the line number where the given instance method is defined,
+		// or `nil` if the method is written in Go.
+		//
+		// ```ruby
+		// # foo.gb
+		// class Foo
+		//   def bar; end
+		// end
+		//
+		// Foo.source_location_of("bar")  #=> ["foo.gb", 2]
+		// Foo.source_location_of("to_s") #=> nil
+		// ```
+		//
+		// @param method name [String]
+		// @return [Array]
+		Name: "source_location_of",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			entry, err := methodEntryOf(t, receiver, sourceLine, args)
 
 			if err != nil {
 				return err
 			}
 
-			if receiver.findMethod(args[0].Value().(string)) == nil {
-				return FALSE
+			if entry.builtin {
+				return NULL
 			}
-			return TRUE
+
+			return t.vm.InitArrayObject([]Object{t.vm.InitStringObject(entry.fileName), t.vm.InitIntegerObject(entry.sourceLine)})
 		},
 	},
 	{
@@ -730,6 +1210,44 @@ var builtinModuleCommonClassMethods = []*BuiltinMethodObject{
 			return superClass
 		},
 	},
+	{
+		// Undefines the named instance methods, so calling them on the class's instances raises a NoMethodError,
+		// even if the ancestors define them. Raises a NameError if neither the class nor its ancestors define a method.
+		//
+		// ```ruby
+		// class Foo
+		//   undef_method(:to_s)
+		// end
+		//
+		// Foo.new.to_s #=> NoMethodError
+		// ```
+		//
+		// @param *names [String] The method names
+		// @return [Class] self
+		Name: "undef_method",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			c, ok := receiver.(*RClass)
+
+			if !ok {
+				return t.vm.InitNoMethodError(sourceLine, "undef_method", receiver)
+			}
+
+			err := t.forEachMethodName(args, sourceLine, func(name string) *Error {
+				if c.lookupMethod(name) == nil {
+					return t.vm.InitErrorObject(errors.NameError, sourceLine, errors.UndefinedMethodForClass, name, c.Name)
+				}
+
+				c.Methods.undefine(name)
+				return nil
+			})
+
+			if err != nil {
+				return err
+			}
+
+			return c
+		},
+	},
 	{
 		// Defines an instance method in the receiver.
 		Name: "define_method",
@@ -748,7 +1266,7 @@ var builtinModuleCommonClassMethods = []*BuiltinMethodObject{
 				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, "can't define a method without a block")
 			}
 
-			method := &MethodObject{Name: args[0].Value().(string), argc: len(blockFrame.locals), instructionSet: blockFrame.instructionSet, BaseObj: NewBaseObject(t.vm.TopLevelClass(classes.MethodClass))}
+			method := &MethodObject{Name: args[0].Value().(string), argc: len(blockFrame.locals), instructionSet: blockFrame.instructionSet, sourceLine: sourceLine, BaseObj: NewBaseObject(t.vm.TopLevelClass(classes.MethodClass))}
 
 			t.vm.defineMethodOn(receiver, method)
 
@@ -807,6 +1325,10 @@ var builtinClassCommonInstanceMethods = []*BuiltinMethodObject{
 		// @return [@boolean]
 		Name: "==",
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
 			if receiver.equalTo(args[0]) {
 				return TRUE
 			}
@@ -831,11 +1353,27 @@ var builtinClassCommonInstanceMethods = []*BuiltinMethodObject{
 		// # Array will concern about the order of the elements
 		// [1, 2, 3] != [1, 2, 3] # => false
 		// [1, 2, 3] != [3, 2, 1] # => true
+		//
+		// # The negation of `==` if the object's class defines or includes one
 		// ```
 		//
 		// @return [Boolean]
 		Name: "!=",
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			if _, ok := receiver.(*RObject); ok {
+				result := t.callMethod(receiver, "==", sourceLine, args[0])
+
+				if err, ok := result.(*Error); ok {
+					return err
+				}
+
+				return toBooleanObject(!result.isTruthy())
+			}
+
 			if !receiver.equalTo(args[0]) {
 				return TRUE
 			}
@@ -869,6 +1407,28 @@ var builtinClassCommonInstanceMethods = []*BuiltinMethodObject{
 
 		},
 	},
+	{
+		// Returns the truthiness of the object as a Boolean. Only `nil` and `false` are false,
+		// so `0`, `""` and `[]` are all true.
+		//
+		// ```ruby
+		// nil.to_bool   # => false
+		// false.to_bool # => false
+		// 0.to_bool     # => true
+		// "".to_bool    # => true
+		// ```
+		//
+		// @return [Boolean]
+		Name: "to_bool",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			return toBooleanObject(receiver.isTruthy())
+
+		},
+	},
 	{
 		// Returns true if a block is given in the current context and `yield` is ready to call.
 		//
@@ -902,6 +1462,63 @@ var builtinClassCommonInstanceMethods = []*BuiltinMethodObject{
 
 		},
 	},
+	{
+		// Runs the block and returns its result, unless `throw` is called with the tag while it runs:
+		// the execution then jumps out of any nested blocks and method calls right to the end of `catch`,
+		// which returns the value given to `throw`.
+		// Tags are compared with `==`. Without a tag, a new Object is used, which is yielded to the block.
+		//
+		// ```ruby
+		// catch(:found) do
+		//   [[1, 2], [3, 4]].each do |row|
+		//     row.each do |n|
+		//       if n > 2
+		//         throw(:found, n)
+		//       end
+		//     end
+		//   end
+		//   nil
+		// end # => 3
+		//
+		// catch do |tag|
+		//   throw(tag, 10)
+		// end # => 10
+		// ```
+		//
+		// @param tag [Object]
+		// @return [Object]
+		Name: "catch",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) > 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgumentLess, 1, len(args))
+			}
+
+			if blockFrame == nil {
+				return t.vm.InitErrorObject(errors.InternalError, sourceLine, errors.CantYieldWithoutBlockFormat)
+			}
+
+			var tag Object
+
+			if len(args) == 1 {
+				tag = args[0]
+			} else {
+				tag = t.vm.objectClass.initializeInstance()
+			}
+
+			var result Object
+
+			value, thrown := t.catch(tag, func() {
+				result, _ = t.builtinMethodYield(blockFrame, tag)
+			})
+
+			if thrown {
+				return value
+			}
+
+			return result
+
+		},
+	},
 	{
 		// Returns the class of the object. Receiver cannot be omitted.
 		//
@@ -940,7 +1557,7 @@ var builtinClassCommonInstanceMethods = []*BuiltinMethodObject{
 				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, "can't define a method without a block")
 			}
 
-			method := &MethodObject{Name: args[0].Value().(string), argc: len(blockFrame.locals), instructionSet: blockFrame.instructionSet, BaseObj: NewBaseObject(t.vm.TopLevelClass(classes.MethodClass))}
+			method := &MethodObject{Name: args[0].Value().(string), argc: len(blockFrame.locals), instructionSet: blockFrame.instructionSet, sourceLine: sourceLine, BaseObj: NewBaseObject(t.vm.TopLevelClass(classes.MethodClass))}
 
 			t.vm.defineSingletonMethodOn(receiver, method)
 
@@ -1020,6 +1637,36 @@ var builtinClassCommonInstanceMethods = []*BuiltinMethodObject{
 
 		},
 	},
+	{
+		// Returns the string formatted with the arguments, like Ruby's `sprintf`.
+		// A directive is written as `%[flags][width][.precision]type`, and the types `d`, `i`, `u`, `f`, `e`, `E`,
+		// `g`, `G`, `x`, `X`, `o`, `b`, `B`, `s`, `p` and `c` are supported.
+		// Raises an ArgumentError if the number of the arguments doesn't match the directives.
+		//
+		// ```ruby
+		// format("%05.2f", 3.14159)      # => "03.14"
+		// format("%-5s|%x", "ab", 255)   # => "ab   |ff"
+		// format("%d%%", 42)             # => "42%"
+		// format("%s and %s", "Goby")    # => ArgumentError
+		// ```
+		//
+		// @param format [String], *args [Object]
+		// @return [String]
+		Name: "format",
+		Fn:   formatMethod,
+	},
+	{
+		// An alias of `format`.
+		//
+		// ```ruby
+		// sprintf("%08b", 10) # => "00001010"
+		// ```
+		//
+		// @param format [String], *args [Object]
+		// @return [String]
+		Name: "sprintf",
+		Fn:   formatMethod,
+	},
 	{
 		// Returns true if Object class is equal to the input argument class
 		//
@@ -1200,7 +1847,8 @@ var builtinClassCommonInstanceMethods = []*BuiltinMethodObject{
 
 			blockFrame.self = receiver
 
-			return t.builtinMethodYield(blockFrame)
+			result, _ := t.builtinMethodYield(blockFrame)
+			return result
 
 		},
 	},
@@ -1279,6 +1927,60 @@ var builtinClassCommonInstanceMethods = []*BuiltinMethodObject{
 
 		},
 	},
+	{
+		// Repeatedly executes the block until it's interrupted by `break`, or a `StopIteration` error
+		// is raised in the block.
+		// Returns the value given to `break`, or `nil` if none is given or the loop is stopped by `StopIteration`.
+		// This pairs nicely with enumerators' `#next`.
+		//
+		// ```ruby
+		// i = 0
+		// loop do
+		//   i += 1
+		//   if i == 3
+		//     break i * 10
+		//   end
+		// end            # => 30
+		//
+		// e = [1, 2].to_enum
+		// loop do
+		//   puts(e.next)
+		// end            # => nil
+		// ```
+		//
+		// @param block literal
+		// @return [Object]
+		Name: "loop",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			if blockFrame == nil {
+				return t.vm.InitErrorObject(errors.InternalError, sourceLine, errors.CantYieldWithoutBlockFormat)
+			}
+
+			for {
+				if result, erred := t.builtinMethodYield(blockFrame); erred {
+					// StopIteration ends the loop
+					if result.(*Error).Type == errors.StopIteration {
+						return NULL
+					}
+
+					return result
+				}
+
+				if blockFrame.IsRemoved() {
+					if blockFrame.breakValue != nil {
+						return blockFrame.breakValue
+					}
+
+					return NULL
+				}
+			}
+
+		},
+	},
 	// Returns an array that contains the method names of the receiver.
 	//
 	// ```ruby
@@ -1301,7 +2003,12 @@ var builtinClassCommonInstanceMethods = []*BuiltinMethodObject{
 				for _, name := range klass.Methods.names() {
 					if set[name] == nil {
 						set[name] = true
-						methods = append(methods, t.vm.InitStringObject(name))
+						method, _ := klass.Methods.get(name)
+
+						// An undefined method hides the ancestors' ones
+						if method != nil && methodVisibility(method) != privateMethod {
+							methods = append(methods, t.vm.InitStringObject(name))
+						}
 					}
 				}
 			}
@@ -1341,29 +2048,151 @@ var builtinClassCommonInstanceMethods = []*BuiltinMethodObject{
 
 		},
 	},
+	{
+		// Prints the inspected form of each object on its own line, which is handy for debugging.
+		// Returns the object if one is given, an Array of the objects if more are given, and `nil` if none is.
+		//
+		// ```ruby
+		// p("foo", nil)
+		// # => "foo"
+		// # => nil
+		// p(1) + 1 # => 2
+		// ```
+		//
+		// @param *args [Object]
+		// @return [Object]
+		Name: "p",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			var out bytes.Buffer
+
+			for _, arg := range args {
+				out.WriteString(arg.Inspect())
+				out.WriteString("\n")
+			}
+
+			t.vm.stdout.Write(out.Bytes())
+
+			switch len(args) {
+			case 0:
+				return NULL
+			case 1:
+				return args[0]
+			default:
+				return t.vm.InitArrayObject(args)
+			}
+
+		},
+	},
+	{
+		// Works like `p`, but prints each object in the form of `pretty_inspect`,
+		// so nested arrays, hashes and objects that don't fit in 80 columns are broken into indented lines.
+		// Arrays and hashes longer than 100 items, and strings longer than 1000 characters, are elided like `... 990 more`,
+		// and the values are colored by their types when the output is a terminal. See VM.SetPrettyPrintOptions.
+		//
+		// ```ruby
+		// pp({ name: "goby", tags: ["language", "vm"] })
+		// # => { name: "goby", tags: ["language", "vm"] }
+		// ```
+		//
+		// @param *args [Object]
+		// @return [Object]
+		Name: "pp",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			var out bytes.Buffer
+
+			for _, arg := range args {
+				out.WriteString(t.vm.prettyPrint(arg))
+				out.WriteString("\n")
+			}
+
+			t.vm.stdout.Write(out.Bytes())
+
+			switch len(args) {
+			case 0:
+				return NULL
+			case 1:
+				return args[0]
+			default:
+				return t.vm.InitArrayObject(args)
+			}
+
+		},
+	},
+	{
+		// Returns the inspected form of the object. Unlike `inspect`, arrays, hashes and objects that don't fit in the width
+		// are broken into lines, with their elements indented by two spaces. The width is 80 by default.
+		//
+		// ```ruby
+		// [[1, 2], { a: [3, 4] }].pretty_inspect(14)
+		// # => [
+		// #      [1, 2],
+		// #      {
+		// #        a: [3, 4]
+		// #      }
+		// #    ]
+		// ```
+		//
+		// @param width [Integer]
+		// @return [String]
+		Name: "pretty_inspect",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			width := defaultPrettyPrintWidth
+
+			switch len(args) {
+			case 0:
+			case 1:
+				w, ok := args[0].(*IntegerObject)
+
+				if !ok {
+					return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.IntegerClass, args[0].Class().Name)
+				}
+
+				if w.value <= 0 {
+					return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.NegativeValue, w.value)
+				}
+
+				width = w.value
+			default:
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgumentLess, 1, len(args))
+			}
+
+			return t.vm.InitStringObject(prettyInspect(receiver, width))
+
+		},
+	},
 	{
 		// Print an object, without the newline, converting into String if needed.
+		// `nil` is printed as an empty string.
 		//
 		// ```ruby
 		// print("foo", "bar")
 		// # => foobar
+		// print("foo", nil, 1)
+		// # => foo1
 		// ```
 		//
 		// @param *args [Class] String literals, or other objects that can be converted into String.
 		// @return [Null]
 		Name: "print",
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			var out bytes.Buffer
+
 			for _, arg := range args {
-				fmt.Print(arg.ToString())
+				out.WriteString(arg.ToString())
 			}
 
+			t.vm.stdout.Write(out.Bytes())
+
 			return NULL
 
 		},
 	},
 	{
 		// Puts string literals or objects into stdout with a tailing line feed, converting into String
-		// if needed.
+		// if needed. A line feed isn't added to strings already ending with one.
+		//
+		// Arrays are flattened, and each element is put on its own line.
+		// `nil` is put as an empty line, and so is calling `puts` without arguments.
 		//
 		// ```ruby
 		// puts("foo", "bar")
@@ -1374,6 +2203,15 @@ var builtinClassCommonInstanceMethods = []*BuiltinMethodObject{
 		// # => String
 		// puts("foo" + "bar")
 		// # => foobar
+		// puts([1, [2, nil]])
+		// # => 1
+		// # => 2
+		// # =>
+		// a = [1]
+		// a.push(a)
+		// puts(a)
+		// # => 1
+		// # => [...]
 		// ```
 		// TODO: interpolation is needed to be implemented.
 		//
@@ -1381,10 +2219,18 @@ var builtinClassCommonInstanceMethods = []*BuiltinMethodObject{
 		// @return [Null]
 		Name: "puts",
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			var out bytes.Buffer
+
+			if len(args) == 0 {
+				out.WriteString("\n")
+			}
+
 			for _, arg := range args {
-				fmt.Println(arg.ToString())
+				writePutsLines(&out, arg, map[*ArrayObject]bool{})
 			}
 
+			t.vm.stdout.Write(out.Bytes())
+
 			return NULL
 
 		},
@@ -1448,9 +2294,50 @@ var builtinClassCommonInstanceMethods = []*BuiltinMethodObject{
 			}
 		},
 	},
+	{
+		// Returns a Rational of the given numerator and denominator, in lowest terms.
+		// The denominator is 1 if omitted.
+		//
+		// ```ruby
+		// Rational(1, 3)  # => (1/3)
+		// Rational(2, -4) # => (-1/2)
+		// Rational(3)     # => (3/1)
+		// ```
+		//
+		// @param numerator [Integer], denominator [Integer]
+		// @return [Rational]
+		Name: "Rational",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			aLen := len(args)
+
+			if aLen < 1 || aLen > 2 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgumentRange, 1, 2, aLen)
+			}
+
+			terms := []*Int{big.NewInt(1), big.NewInt(1)}
+
+			for i, arg := range args {
+				term, ok := bigIntOf(arg)
+
+				if !ok {
+					return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormatNum, i+1, classes.IntegerClass, arg.Class().Name)
+				}
+
+				terms[i] = term
+			}
+
+			if terms[1].Sign() == 0 {
+				return t.vm.InitErrorObject(errors.ZeroDivisionError, sourceLine, errors.DividedByZero)
+			}
+
+			return t.vm.initRationalObject(new(big.Rat).SetFrac(terms[0], terms[1]))
+
+		},
+	},
 	{
 		// A predicate class method that returns `true` if the object has an ability to respond to the method, otherwise `false`.
 		// Note that signs like `+` or `?` should be String literal.
+		// Private and protected methods are only counted if the second argument is true.
 		//
 		// ```ruby
 		// 1.respond_to? :to_i               #=> true
@@ -1458,24 +2345,11 @@ var builtinClassCommonInstanceMethods = []*BuiltinMethodObject{
 		// 1.respond_to? :numerator          #=> false
 		// ```
 		//
-		// @param [String]
+		// @param [String], include_private [Boolean]
 		// @return [Boolean]
 		Name: "respond_to?",
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
-			if len(args) != 1 {
-				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
-			}
-
-			arg, ok := args[0].(*StringObject)
-			if !ok {
-				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.StringClass, arg.Class().Name)
-			}
-
-			r := receiver
-			if r.findMethod(arg.value) == nil {
-				return FALSE
-			}
-			return TRUE
+			return t.respondTo(receiver, args, sourceLine)
 
 		},
 	},
@@ -1483,6 +2357,10 @@ var builtinClassCommonInstanceMethods = []*BuiltinMethodObject{
 		// Loads the given Goby library name without extension (mainly for modules), returning `true`
 		// if successful and `false` if the feature is already loaded.
 		//
+		// Native libraries (the standard libraries implemented in Go, and the ones registered by the host
+		// with `RegisterExternalClass`) are looked up first, then the resolvers registered by the host
+		// with `VM.AddRequireResolver`, and finally the lib directory.
+		//
 		// ```ruby
 		// require("db")
 		// File.extname("foo.rb")
@@ -1501,28 +2379,17 @@ var builtinClassCommonInstanceMethods = []*BuiltinMethodObject{
 			switch args[0].(type) {
 			case *StringObject:
 				libName := args[0].(*StringObject).value
-				initFunc, ok := standardLibraries[libName]
 
-				if !ok {
-					externalClassLock.Lock()
-					loaders, ok := externalClasses[libName]
-					externalClassLock.Unlock()
-					if !ok {
-						err := t.execGobyLib(libName + ".gb")
-						if err != nil {
-							return t.vm.InitErrorObject(errors.IOError, sourceLine, errors.CantLoadFile, libName)
-						}
-					}
-					initFunc = func(v *VM) {
-						for _, l := range loaders {
-							l(v)
-						}
-					}
+				if loaded, found := t.vm.loadNativeLibrary(libName); found {
+					return toBooleanObject(loaded)
 				}
 
-				initFunc(t.vm)
+				loaded, err := t.requireFile(libName)
+				if err != nil {
+					return t.vm.InitErrorObject(errors.IOError, sourceLine, errors.CantLoadFile, libName)
+				}
 
-				return TRUE
+				return toBooleanObject(loaded)
 			default:
 				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.CantRequireNonString, args[0].(Object).Class().Name)
 			}
@@ -1569,6 +2436,7 @@ var builtinClassCommonInstanceMethods = []*BuiltinMethodObject{
 	// - Method name should be either a symbol or String (required).
 	// - You can pass one or more arguments (option).
 	// - A block can also be provided (option).
+	// - Private and protected methods can be called as well. Use `public_send` to respect the visibility.
 	//
 	//
 	// ```ruby
@@ -1613,7 +2481,44 @@ var builtinClassCommonInstanceMethods = []*BuiltinMethodObject{
 				return err
 			}
 
-			t.sendMethod(args[0].Value().(string), len(args)-1, blockFrame, sourceLine)
+			t.sendMethod(args[0].Value().(string), len(args)-1, blockFrame, sourceLine, bytecode.ImplicitReceiver)
+
+			return t.Stack.top().Target
+
+		},
+	},
+	{
+		// Invokes the method like `send`, but only if it can be called on the receiver from where `public_send` is called:
+		// a private method raises a NoMethodError, and so does a protected one unless it's called from an instance of its class.
+		//
+		// ```ruby
+		// class Foo
+		//   private
+		//
+		//   def bar
+		//     10
+		//   end
+		// end
+		//
+		// Foo.new.send(:bar)        #=> 10
+		// Foo.new.public_send(:bar) #=> NoMethodError: private method 'bar' called for #<Foo:...>
+		// ```
+		//
+		// @param name [String/symbol], args [Object], block
+		// @return [Object]
+		Name: "public_send",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) == 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgumentMore, 1, 0)
+			}
+
+			err := t.vm.checkArgTypes(args, sourceLine, classes.StringClass)
+
+			if err != nil {
+				return err
+			}
+
+			t.sendMethod(args[0].Value().(string), len(args)-1, blockFrame, sourceLine, bytecode.ExplicitReceiver)
 
 			return t.Stack.top().Target
 
@@ -1727,11 +2632,44 @@ var builtinClassCommonInstanceMethods = []*BuiltinMethodObject{
 				return t.vm.InitErrorObject(errors.InternalError, sourceLine, errors.CantYieldWithoutBlockFormat)
 			}
 
-			t.builtinMethodYield(blockFrame, receiver)
+			if result, erred := t.builtinMethodYield(blockFrame, receiver); erred {
+				return result
+			}
 
 			return receiver
 		},
 	},
+	{
+		// Jumps to the end of the running `catch` with the given tag, which then returns the value (nil by default).
+		// Raises an UncaughtThrowError if there's no such `catch`. See `catch`.
+		//
+		// ```ruby
+		// catch(:done) do
+		//   throw(:done, "early")
+		//   "late"
+		// end # => "early"
+		//
+		// throw(:done) # => UncaughtThrowError: uncaught throw "done"
+		// ```
+		//
+		// @param tag [Object], value [Object]
+		// @return [Object]
+		Name: "throw",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) < 1 || len(args) > 2 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgumentRange, 1, 2, len(args))
+			}
+
+			var value Object = NULL
+
+			if len(args) == 2 {
+				value = args[1]
+			}
+
+			return t.throw(args[0], value, sourceLine)
+
+		},
+	},
 	{
 		Name: "thread",
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
@@ -1739,10 +2677,15 @@ var builtinClassCommonInstanceMethods = []*BuiltinMethodObject{
 				return t.vm.InitErrorObject(errors.InternalError, sourceLine, errors.CantYieldWithoutBlockFormat)
 			}
 
-			newT := t.vm.newThread()
+			newT := t.vm.acquireThread()
 
 			go func() {
-				newT.builtinMethodYield(blockFrame, args...)
+				// Nothing can handle an error raised in the thread, so it's fatal like before
+				if err, erred := newT.builtinMethodYield(blockFrame, args...); erred {
+					panic(err)
+				}
+
+				t.vm.releaseThread(newT)
 			}()
 
 			// We need to pop this frame from main thread manually,
@@ -1753,6 +2696,63 @@ var builtinClassCommonInstanceMethods = []*BuiltinMethodObject{
 
 		},
 	},
+	{
+		// Returns a deterministic representation of the object and everything it refers to, for comparing with golden values in tests.
+		// It has no object ids, and hash keys and instance variables are sorted. An object that contains itself is written as `...` where it recurs.
+		//
+		// ```ruby
+		// class Point
+		//   def initialize(x, y)
+		//     @y = y
+		//     @x = x
+		//   end
+		// end
+		//
+		// Point.new(1, [2]).to_canonical    # => "Point{@x: 1, @y: [2]}"
+		// { b: 1, a: "x" }.to_canonical      # => "{\"a\": \"x\", \"b\": 1}"
+		//
+		// a = [1]
+		// a.push(a)
+		// a.to_canonical                     # => "[1, [...]]"
+		// ```
+		//
+		// @return [String]
+		Name: "to_canonical",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			return t.vm.InitStringObject(Canonicalize(receiver))
+
+		},
+	},
+	{
+		// Returns object's JSON representation.
+		// By default, it's a JSON object of the object's instance variables. See `json_attributes` for picking them.
+		//
+		// ```ruby
+		// class Point
+		//   def initialize(x, y)
+		//     @x = x
+		//     @y = y
+		//   end
+		// end
+		//
+		// Point.new(1, 2).to_json # => {"x":1,"y":2}
+		// ```
+		//
+		// @return [String]
+		Name: "to_json",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			return t.encodeJSON(receiver, sourceLine)
+
+		},
+	},
 	{
 		// Returns object's string representation.
 		// @param n/a []
@@ -1765,11 +2765,36 @@ var builtinClassCommonInstanceMethods = []*BuiltinMethodObject{
 	},
 	{
 		// Returns object's inspect representation.
-		// @param n/a []
+		// An optional depth limits how deep nested arrays, hashes and objects are rendered, and the deeper ones
+		// are written as `...`. Every level is rendered by default.
+		//
+		// ```ruby
+		// [1, [2, [3, [4]]]].inspect           # => "[1, [2, [3, [4]]]]"
+		// [1, [2, [3, [4]]]].inspect(depth: 2) # => "[1, [2, ...]]"
+		// ```
+		//
+		// @param depth [Integer]
 		// @return [String] Object's inspect representation.
 		Name: "inspect",
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
-			return t.vm.InitStringObject(receiver.Inspect())
+			switch len(args) {
+			case 0:
+				return t.vm.InitStringObject(receiver.Inspect())
+			case 1:
+				depth, ok := args[0].(*IntegerObject)
+
+				if !ok {
+					return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.IntegerClass, args[0].Class().Name)
+				}
+
+				if depth.value <= 0 {
+					return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.NegativeValue, depth.value)
+				}
+
+				return t.vm.InitStringObject(inspectWithDepth(receiver, depth.value))
+			default:
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgumentLess, 1, len(args))
+			}
 		},
 	},
 }
@@ -1811,7 +2836,7 @@ func (vm *VM) createRClass(className string) *RClass {
 
 	return &RClass{
 		Name:             className,
-		Methods:          newEnvironment(),
+		Methods:          newMethodTable(),
 		pseudoSuperClass: objectClass,
 		superClass:       objectClass,
 		constants:        make(map[string]*Pointer),
@@ -1820,26 +2845,108 @@ func (vm *VM) createRClass(className string) *RClass {
 	}
 }
 
+// writePutsLines writes the object in the way `puts` puts it.
+// An array that contains itself is written as `[...]` where it recurs; visiting holds the arrays being written.
+func writePutsLines(out *bytes.Buffer, obj Object, visiting map[*ArrayObject]bool) {
+	arr, ok := obj.(*ArrayObject)
+
+	if !ok {
+		line := obj.ToString()
+		out.WriteString(line)
+
+		if !strings.HasSuffix(line, "\n") {
+			out.WriteString("\n")
+		}
+
+		return
+	}
+
+	// An empty array is put as an empty line, like `puts` without arguments
+	if len(arr.Elements) == 0 {
+		out.WriteString("\n")
+	}
+
+	if visiting[arr] {
+		out.WriteString("[...]\n")
+		return
+	}
+
+	visiting[arr] = true
+
+	for _, e := range arr.Elements {
+		writePutsLines(out, e, visiting)
+	}
+
+	delete(visiting, arr)
+}
+
+// forEachMethodName calls fn with each of the method names in args, which must be Strings, and stops at the first error.
+// At least one name is required.
+func (t *Thread) forEachMethodName(args []Object, sourceLine int, fn func(name string) *Error) *Error {
+	if len(args) == 0 {
+		return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgumentMore, 1, 0)
+	}
+
+	for i, arg := range args {
+		name, ok := arg.(*StringObject)
+
+		if !ok {
+			return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormatNum, i+1, classes.StringClass, arg.Class().Name)
+		}
+
+		if err := fn(name.value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// methodEntryOf looks up the metadata of the instance method named by the argument; common to `arity_of` and `source_location_of`.
+func methodEntryOf(t *Thread, receiver Object, sourceLine int, args []Object) (*methodEntry, *Error) {
+	if len(args) != 1 {
+		return nil, t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+	}
+
+	err := t.vm.checkArgTypes(args, sourceLine, classes.StringClass)
+
+	if err != nil {
+		return nil, err
+	}
+
+	entry, ok := receiver.(*RClass).lookupMethodEntry(args[0].Value().(string))
+
+	if !ok {
+		return nil, t.vm.InitNoMethodError(sourceLine, args[0].Value().(string), receiver)
+	}
+
+	return entry, nil
+}
+
 func (vm *VM) defineMethodOn(obj Object, method *MethodObject) {
 	switch obj := obj.(type) {
 	case *RClass:
-		obj.Methods.set(method.Name, method)
+		method.owner = obj
 	default:
 		if obj.Class().Name == classes.ObjectClass {
-			obj.Class().Methods.set(method.Name, method)
+			method.owner = obj.Class()
 		} else {
-			vm.findOrCreateSingletonClass(obj).Methods.set(method.Name, method)
+			method.owner = vm.findOrCreateSingletonClass(obj)
 		}
 	}
+
+	method.owner.Methods.set(method.Name, method)
 }
 
 func (vm *VM) defineSingletonMethodOn(obj Object, method *MethodObject) {
 	switch obj := obj.(type) {
 	case *RClass:
-		obj.SingletonClass().Methods.set(method.Name, method)
+		method.owner = obj.SingletonClass()
 	default:
-		vm.findOrCreateSingletonClass(obj).Methods.set(method.Name, method)
+		method.owner = vm.findOrCreateSingletonClass(obj)
 	}
+
+	method.owner.Methods.set(method.Name, method)
 }
 
 func (vm *VM) findOrCreateSingletonClass(obj Object) (singletonClass *RClass) {
@@ -1857,14 +2964,14 @@ func (vm *VM) findOrCreateSingletonClass(obj Object) (singletonClass *RClass) {
 func initModuleClass(classClass *RClass) *RClass {
 	moduleClass := &RClass{
 		Name:      classes.ModuleClass,
-		Methods:   newEnvironment(),
+		Methods:   newMethodTable(),
 		constants: make(map[string]*Pointer),
-		BaseObj:   &BaseObj{},
+		BaseObj:   &BaseObj{InstanceVariables: newEnvironment()},
 	}
 
 	moduleSingletonClass := &RClass{
 		Name:        "#<Class:Module>",
-		Methods:     newEnvironment(),
+		Methods:     newMethodTable(),
 		constants:   make(map[string]*Pointer),
 		isModule:    false,
 		BaseObj:     NewBaseObject(classClass),
@@ -1885,14 +2992,14 @@ func initModuleClass(classClass *RClass) *RClass {
 func initClassClass() *RClass {
 	classClass := &RClass{
 		Name:      classes.ClassClass,
-		Methods:   newEnvironment(),
+		Methods:   newMethodTable(),
 		constants: make(map[string]*Pointer),
-		BaseObj:   &BaseObj{},
+		BaseObj:   &BaseObj{InstanceVariables: newEnvironment()},
 	}
 
 	classSingletonClass := &RClass{
 		Name:        "#<Class:Class>",
-		Methods:     newEnvironment(),
+		Methods:     newMethodTable(),
 		constants:   make(map[string]*Pointer),
 		isModule:    false,
 		BaseObj:     NewBaseObject(classClass),
@@ -1910,14 +3017,14 @@ func initClassClass() *RClass {
 func initObjectClass(c *RClass) *RClass {
 	objectClass := &RClass{
 		Name:      classes.ObjectClass,
-		Methods:   newEnvironment(),
+		Methods:   newMethodTable(),
 		constants: make(map[string]*Pointer),
 		BaseObj:   NewBaseObject(c),
 	}
 
 	singletonClass := &RClass{
 		Name:        "#<Class:Object>",
-		Methods:     newEnvironment(),
+		Methods:     newMethodTable(),
 		constants:   make(map[string]*Pointer),
 		isModule:    false,
 		BaseObj:     NewBaseObject(c),
@@ -1959,7 +3066,7 @@ func (c *RClass) Inspect() string {
 
 // ToJSON just delegates to `ToString`
 func (c *RClass) ToJSON(t *Thread) string {
-	return c.ToString()
+	return quoteJSON(c.ToString())
 }
 
 // Value returns class itself
@@ -1976,12 +3083,12 @@ func (c *RClass) inherits(sc *RClass) {
 
 func (c *RClass) setBuiltinMethods(methodList []*BuiltinMethodObject, classMethods bool) {
 	for _, m := range methodList {
-		c.Methods.set(m.Name, m)
+		c.Methods.set(m.Name, m.ownedBy(c))
 	}
 
 	if classMethods {
 		for _, m := range methodList {
-			c.singletonClass.Methods.set(m.Name, m)
+			c.singletonClass.Methods.set(m.Name, m.ownedBy(c.singletonClass))
 		}
 	}
 }
@@ -2000,20 +3107,32 @@ func (c *RClass) lookupMethod(methodName string) Object {
 	return method
 }
 
-func (c *RClass) lookupConstantInCurrentScope(constName string) *Pointer {
-	constant, ok := c.constants[constName]
+// lookupMethodEntry is like lookupMethod, but returns the method's metadata
+func (c *RClass) lookupMethodEntry(methodName string) (*methodEntry, bool) {
+	entry, ok := c.Methods.entry(methodName)
 
-	if !ok {
-		return nil
+	if !ok && c.superClass != nil && c.superClass != c {
+		return c.superClass.lookupMethodEntry(methodName)
 	}
 
-	return constant
+	if ok && entry.undefined {
+		return nil, false
+	}
+
+	return entry, ok
+}
+
+func (c *RClass) lookupConstantInCurrentScope(constName string) *Pointer {
+	c.constantLock.RLock()
+	defer c.constantLock.RUnlock()
+
+	return c.constants[constName]
 }
 
 func (c *RClass) lookupConstantUnderCurrentScope(constName string) *Pointer {
-	constant, ok := c.constants[constName]
+	constant := c.lookupConstantInCurrentScope(constName)
 
-	if !ok {
+	if constant == nil {
 		if c.scope != nil {
 			return c.scope.lookupConstantUnderCurrentScope(constName)
 		}
@@ -2025,31 +3144,129 @@ func (c *RClass) lookupConstantUnderCurrentScope(constName string) *Pointer {
 }
 
 func (c *RClass) lookupConstantUnderAllScope(constName string) *Pointer {
-	constant, ok := c.constants[constName]
+	constant := c.lookupConstantInCurrentScope(constName)
 
-	if !ok {
+	if constant == nil {
 		if c.scope != nil {
 			return c.scope.lookupConstantUnderCurrentScope(constName)
 		}
 
-		// Finding constant in superclass means it's out of the scope
-		if c.superClass != nil && c.Name != classes.ObjectClass {
-			constant, _ = c.constants[constName]
-			return constant
-		}
-
 		return nil
 	}
 
 	return constant
 }
 
+// lookupClassVariable finds the class variable in the class's ancestors and returns it with the class that holds it
+func (c *RClass) lookupClassVariable(name string) (Object, *RClass) {
+	for _, klass := range c.ancestors() {
+		klass.classVariableLock.RLock()
+		v, ok := klass.classVariables[name]
+		klass.classVariableLock.RUnlock()
+
+		if ok {
+			return v, klass
+		}
+	}
+
+	return nil, nil
+}
+
+// setClassVariable updates the class variable where it's defined, or defines it on the class if no ancestor has it
+func (c *RClass) setClassVariable(name string, value Object) {
+	_, owner := c.lookupClassVariable(name)
+
+	if owner == nil {
+		owner = c
+	}
+
+	owner.classVariableLock.Lock()
+
+	if owner.classVariables == nil {
+		owner.classVariables = make(map[string]Object)
+	}
+
+	owner.classVariables[name] = value
+	owner.classVariableLock.Unlock()
+}
+
+// classVariableNames returns the sorted names of the class variables the class can access, including the inherited ones
+func (c *RClass) classVariableNames() []string {
+	seen := make(map[string]bool)
+	var names []string
+
+	for _, klass := range c.ancestors() {
+		klass.classVariableLock.RLock()
+
+		for name := range klass.classVariables {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+
+		klass.classVariableLock.RUnlock()
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// getClassVariable returns the class variable the class can access, it warns and returns nil if the variable isn't initialized
+func (t *Thread) getClassVariable(c *RClass, name string) Object {
+	v, owner := c.lookupClassVariable(name)
+
+	if owner == nil {
+		fmt.Fprintf(t.vm.stderr, "warning: class variable %s not initialized in %s\n", name, c.Name)
+		return NULL
+	}
+
+	return v
+}
+
+// classVariableNameArg validates the class variable name given to the reflection methods
+func (vm *VM) classVariableNameArg(arg Object, sourceLine int) (string, *Error) {
+	s, ok := arg.(*StringObject)
+
+	if !ok {
+		return "", vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.StringClass, arg.Class().Name)
+	}
+
+	name := s.value
+
+	if !strings.HasPrefix(name, "@@") || len(name) == 2 || strings.ContainsAny(name[2:], "@ ") {
+		return "", vm.InitErrorObject(errors.NameError, sourceLine, errors.InvalidClassVariableName, name)
+	}
+
+	return name, nil
+}
+
 func (c *RClass) setClassConstant(constant *RClass) {
+	c.setConstant(constant.Name, &Pointer{Target: constant})
+}
+
+func (c *RClass) setConstant(constName string, ptr *Pointer) {
+	c.constantLock.Lock()
+	c.constants[constName] = ptr
+	c.constantLock.Unlock()
+}
+
+// loadOrStoreClassConstant returns the class constant with the class's name if it's defined already.
+// Otherwise, it sets the class as the constant and returns it.
+func (c *RClass) loadOrStoreClassConstant(constant *RClass) *RClass {
+	c.constantLock.Lock()
+	defer c.constantLock.Unlock()
+
+	if ptr, ok := c.constants[constant.Name]; ok {
+		return ptr.Target.(*RClass)
+	}
+
 	c.constants[constant.Name] = &Pointer{Target: constant}
+	return constant
 }
 
 func (c *RClass) getClassConstant(constName string) (class *RClass) {
-	t := c.constants[constName].Target
+	t := c.lookupConstantInCurrentScope(constName).Target
 	class, ok := t.(*RClass)
 
 	if ok {
@@ -2085,11 +3302,11 @@ func (c *RClass) setAttrWriter(args interface{}) {
 	case []Object:
 		for _, attr := range args {
 			attrName := attr.(*StringObject).value
-			c.Methods.set(attrName+"=", generateAttrWriteMethod(attrName))
+			c.Methods.set(attrName+"=", generateAttrWriteMethod(c, attrName))
 		}
 	case []string:
 		for _, attrName := range args {
-			c.Methods.set(attrName+"=", generateAttrWriteMethod(attrName))
+			c.Methods.set(attrName+"=", generateAttrWriteMethod(c, attrName))
 		}
 	}
 
@@ -2100,14 +3317,14 @@ func (c *RClass) setAttrReader(args interface{}) {
 	case []Object:
 		for _, attr := range args {
 			attrName := attr.(*StringObject).value
-			c.Methods.set(attrName, generateAttrReadMethod(attrName))
+			c.Methods.set(attrName, generateAttrReadMethod(c, attrName))
 		}
 	case []string:
 		for _, attrName := range args {
-			c.Methods.set(attrName, generateAttrReadMethod(attrName))
+			c.Methods.set(attrName, generateAttrReadMethod(c, attrName))
 		}
 	case string:
-		c.Methods.set(args, generateAttrReadMethod(args))
+		c.Methods.set(args, generateAttrReadMethod(c, args))
 	}
 
 }
@@ -2117,6 +3334,21 @@ func (c *RClass) setAttrAccessor(args interface{}) {
 	c.setAttrWriter(args)
 }
 
+// lookupJSONAttributes returns the instance variables declared with `json_attributes` by the class or its closest superclass
+func (c *RClass) lookupJSONAttributes() []string {
+	for {
+		if c.jsonAttributes != nil {
+			return c.jsonAttributes
+		}
+
+		if c.Name == classes.ObjectClass {
+			return nil
+		}
+
+		c = c.pseudoSuperClass
+	}
+}
+
 func (c *RClass) ancestors() []*RClass {
 	klasses := []*RClass{c}
 	for {
@@ -2142,19 +3374,36 @@ func (c *RClass) equalTo(with Object) bool {
 
 // Other helper functions -----------------------------------------------
 
-func generateAttrWriteMethod(attrName string) *BuiltinMethodObject {
+// checkAttrNames returns a TypeError if any of the attribute names given to `attr_reader` and its friends isn't a String
+func (t *Thread) checkAttrNames(args []Object, sourceLine int) *Error {
+	for _, arg := range args {
+		if _, ok := arg.(*StringObject); !ok {
+			return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.StringClass, arg.Class().Name)
+		}
+	}
+
+	return nil
+}
+
+func generateAttrWriteMethod(owner *RClass, attrName string) *BuiltinMethodObject {
 	return &BuiltinMethodObject{
-		Name: attrName + "=",
+		Name:  attrName + "=",
+		owner: owner,
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
 			v := receiver.InstanceVariableSet("@"+attrName, args[0])
 			return v
 		},
 	}
 }
 
-func generateAttrReadMethod(attrName string) *BuiltinMethodObject {
+func generateAttrReadMethod(owner *RClass, attrName string) *BuiltinMethodObject {
 	return &BuiltinMethodObject{
-		Name: attrName,
+		Name:  attrName,
+		owner: owner,
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
 			v, ok := receiver.InstanceVariableGet("@" + attrName)
 
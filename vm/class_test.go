@@ -1,6 +1,9 @@
 package vm
 
-import "testing"
+import (
+	"bytes"
+	"testing"
+)
 
 func TestClassClassSuperclass(t *testing.T) {
 	tests := []struct {
@@ -97,6 +100,41 @@ func TestAttrReaderAndWriter(t *testing.T) {
 	}
 }
 
+func TestAttrReaderAndWriterFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`
+		class Foo
+		  attr_reader(:bar, 1)
+		end
+		`, "TypeError: Expect argument to be String. got: Integer", 2},
+		{`
+		class Foo
+		  attr_writer(nil)
+		end
+		`, "TypeError: Expect argument to be String. got: Null", 2},
+		{`
+		class Foo
+		  attr_accessor([:bar])
+		end
+		`, "TypeError: Expect argument to be String. got: Array", 2},
+		{`
+		class Foo
+		  attr_writer :bar
+		end
+
+		Foo.new.send("bar=")
+		`, "ArgumentError: Expect 1 argument(s). got: 0", 2},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestClassInheritModuleError(t *testing.T) {
 	input := `module Foo
 end
@@ -152,6 +190,10 @@ func TestClassInstanceVariable(t *testing.T) {
 		Bar.instance_variable_set("@foo", 20)
 		Bar.instance_variable_get("@foo") + Bar.instance_variable_get("@bar")
 		`, 120},
+		{`
+		Class.instance_variable_set("@foo", 3)
+		Module.instance_variable_get("@foo").to_i + Class.instance_variable_get("@foo")
+		`, 3},
 	}
 
 	for i, tt := range tests {
@@ -211,6 +253,245 @@ func TestClassInstanceVariableFail(t *testing.T) {
 	}
 }
 
+func TestClassLevelInstanceVariableIsNotInherited(t *testing.T) {
+	input := `
+	class Foo
+	  def self.count=(n)
+	    @count = n
+	  end
+
+	  def self.count
+	    @count
+	  end
+	end
+
+	class Bar < Foo; end
+	class Baz < Foo; end
+
+	Foo.count = 1
+	Bar.count = 2
+	[Foo.count, Bar.count, Baz.count]
+	`
+
+	v := initTestVM()
+	evaluated := v.testEval(t, input, getFilename())
+	VerifyExpected(t, 0, evaluated, []interface{}{1, 2, nil})
+	v.checkCFP(t, 0, 0)
+	v.checkSP(t, 0, 1)
+}
+
+func TestClassVariable(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		class Foo
+		  @@count = 1
+
+		  def count
+		    @@count
+		  end
+		end
+
+		Foo.new.count
+		`, 1},
+		// a subclass shares the class variable defined by its superclass
+		{`
+		class Foo
+		  @@count = 0
+
+		  def self.increment
+		    @@count += 1
+		  end
+
+		  def self.count
+		    @@count
+		  end
+		end
+
+		class Bar < Foo; end
+
+		Bar.increment
+		Bar.increment
+		Foo.count
+		`, 2},
+		{`
+		class Foo
+		  @@name = "foo"
+		end
+
+		class Bar < Foo
+		  @@name = "bar"
+		end
+
+		class Baz < Foo
+		  def name
+		    @@name
+		  end
+		end
+
+		Baz.new.name
+		`, "bar"},
+		// a class variable first assigned in a subclass isn't visible to its superclass or siblings
+		{`
+		class Foo; end
+
+		class Bar < Foo
+		  @@only_bar = 1
+		end
+
+		class Baz < Foo; end
+
+		[Foo.class_variables, Bar.class_variables, Baz.class_variables]
+		`, []interface{}{[]interface{}{}, []interface{}{"@@only_bar"}, []interface{}{}}},
+		{`
+		class Foo
+		  def self.cache
+		    @@cache ||= {}
+		  end
+		end
+
+		Foo.cache[:a] = 1
+		Foo.cache[:a]
+		`, 1},
+		{`
+		class Foo
+		  @@a, @@b = [1, 2]
+
+		  def sum
+		    @@a + @@b
+		  end
+		end
+
+		Foo.new.sum
+		`, 3},
+		{`
+		class Foo
+		  def missing
+		    @@missing
+		  end
+		end
+
+		Foo.new.missing
+		`, nil},
+	}
+
+	for i, tt := range tests {
+		var stderr bytes.Buffer
+		v := initTestVM()
+		v.SetStderr(&stderr)
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestClassVariableUninitializedWarning(t *testing.T) {
+	input := `
+	class Foo
+	  def missing
+	    @@missing
+	  end
+	end
+
+	Foo.new.missing
+	Foo.class_variable_get("@@other")
+	`
+
+	var stderr bytes.Buffer
+	v := initTestVM()
+	v.SetStderr(&stderr)
+	evaluated := v.testEval(t, input, getFilename())
+	VerifyExpected(t, 0, evaluated, nil)
+	VerifyExpected(t, 0, v.InitStringObject(stderr.String()), "warning: class variable @@missing not initialized in Foo\nwarning: class variable @@other not initialized in Foo\n")
+	v.checkCFP(t, 0, 0)
+	v.checkSP(t, 0, 1)
+}
+
+func TestClassVariableReflectionMethods(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		class Foo
+		  @@count = 1
+		end
+
+		class Bar < Foo; end
+
+		Bar.class_variable_get("@@count")
+		`, 1},
+		// setting through a subclass updates the superclass's variable
+		{`
+		class Foo
+		  @@count = 1
+		end
+
+		class Bar < Foo; end
+
+		Bar.class_variable_set("@@count", 5)
+		Foo.class_variable_get("@@count")
+		`, 5},
+		{`
+		class Foo
+		  def count
+		    @@count
+		  end
+		end
+
+		Foo.class_variable_set("@@count", 3)
+		Foo.new.count
+		`, 3},
+		{`
+		class Foo; end
+		Foo.class_variable_set("@@count", 3)
+		`, 3},
+		{`
+		class Foo
+		  @@b = 1
+		  @@a = 2
+		end
+
+		class Bar < Foo
+		  @@c = 3
+		end
+
+		Bar.class_variables
+		`, []interface{}{"@@a", "@@b", "@@c"}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestClassVariableReflectionMethodsFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`Object.class_variable_get`, "ArgumentError: Expect 1 argument(s). got: 0", 1},
+		{`Object.class_variable_get(1)`, "TypeError: Expect argument to be String. got: Integer", 1},
+		{`Object.class_variable_get("@foo")`, "NameError: '@foo' is not allowed as a class variable name", 1},
+		{`Object.class_variable_get("@@")`, "NameError: '@@' is not allowed as a class variable name", 1},
+		{`Object.class_variable_set("@@foo")`, "ArgumentError: Expect 2 argument(s). got: 1", 1},
+		{`Object.class_variable_set("foo", 1)`, "NameError: 'foo' is not allowed as a class variable name", 1},
+		{`Object.class_variables(1)`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestCustomClassConstructor(t *testing.T) {
 	input := `
 		class Foo
@@ -674,48 +955,74 @@ func TestDefineSingletonMethodFail(t *testing.T) {
 	}
 }
 
-func TestMethodsMethod(t *testing.T) {
+func TestLoopMethod(t *testing.T) {
 	tests := []struct {
 		input    string
-		expected bool
+		expected interface{}
 	}{
 		{`
-		class C
-		  def hola
-		  end
-
-		  def hi
+		i = 0
+		loop do
+		  i += 1
+		  if i == 5
+		    break
 		  end
 		end
-		C.new.methods.first(2) == ["hi", "hola"]
-		`, true},
+		i
+		`, 5},
 		{`
-		class C
-		  def hi
+		i = 0
+		loop do
+		  i += 1
+		  if i == 3
+		    break
 		  end
 		end
-		class D < C
-		  def hola
+		`, nil},
+		{`
+		i = 0
+		loop do
+		  i += 1
+		  if i == 3
+		    break i * 10
 		  end
 		end
-		D.new.methods.first(2) == ["hola", "hi"]
-		`, true},
+		`, 30},
 		{`
-		class C
-		  def hi
-		  end
+		loop do
+		  break "foo"
 		end
-		c = C.new
-		def c.hola
+		`, "foo"},
+		{`
+		e = [1, 2, 3].to_enum
+		sum = 0
+		r = loop do
+		  sum += e.next
 		end
-		c.methods.first(2) == ["hola", "hi"]
-		`, true},
+		[r, sum]
+		`, []interface{}{nil, 6}},
 		{`
-		class C
+		i = 0
+		loop do
+		  i += 1
+		  if i == 2
+		    raise StopIteration
+		  end
 		end
-		C.new.methods.include?("to_s")
-		`, true},
+		i
+		`, 2},
+		{`
+		result = []
+		loop do
+		  [1, 2, 3].each do |i|
+		    result.push(i)
+		  end
+		  break
+		end
+		result
+		`, []interface{}{1, 2, 3}},
 	}
+
 	for i, tt := range tests {
 		v := initTestVM()
 		evaluated := v.testEval(t, tt.input, getFilename())
@@ -725,29 +1032,102 @@ func TestMethodsMethod(t *testing.T) {
 	}
 }
 
-func TestAncestorsMethod(t *testing.T) {
+func TestLoopMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`loop`, "InternalError: Can't yield without a block", 1},
+		{`loop(1) do
+		end`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestCatchAndThrowMethods(t *testing.T) {
 	tests := []struct {
 		input    string
-		expected bool
+		expected interface{}
 	}{
 		{`
-		class C
+		catch(:done) do
+		  10
 		end
-		C.ancestors == [C, Object]
-		`, true},
+		`, 10},
 		{`
-		module M
+		catch(:done) do
+		  throw(:done)
+		  10
 		end
-		class C
+		`, nil},
+		{`
+		def find(rows)
+		  rows.each do |row|
+		    row.each do |n|
+		      if n > 2
+		        throw(:found, n)
+		      end
+		    end
+		  end
+		  nil
 		end
-		class C2 < C
-		  include M
+
+		catch(:found) do
+		  find([[1, 2], [3, 4]])
+		  0
 		end
-		class C3 < C2
+		`, 3},
+		// the innermost catch with the tag gets the value
+		{`
+		catch(:a) do
+		  x = catch(:a) do
+		    throw(:a, 1)
+		  end
+		  x + 10
 		end
-		C3.ancestors == [C3, C2, M, C, Object]
-		`, true},
+		`, 11},
+		// catches with other tags are skipped
+		{`
+		catch(:outer) do
+		  catch(:inner) do
+		    throw(:outer, "out")
+		  end
+		  "in"
+		end
+		`, "out"},
+		{`
+		catch do |tag|
+		  throw(tag, [1, 2])
+		end
+		`, []interface{}{1, 2}},
+		// the thread can go on after a throw
+		{`
+		sum = 0
+		[1, 2, 3].each do |i|
+		  sum += catch(:q) do
+		    throw(:q, i * 2)
+		  end
+		end
+		sum
+		`, 12},
+		{`
+		i = 0
+		catch(:stop) do
+		  loop do
+		    i += 1
+		    if i == 3
+		      throw(:stop)
+		    end
+		  end
+		end
+		i
+		`, 3},
 	}
+
 	for i, tt := range tests {
 		v := initTestVM()
 		evaluated := v.testEval(t, tt.input, getFilename())
@@ -757,13 +1137,496 @@ func TestAncestorsMethod(t *testing.T) {
 	}
 }
 
-func TestBuiltinClassMonkeyPatching(t *testing.T) {
-	input := `
-	class String
-	  def buz
-	    "buz"
-	  end
-	end
+func TestCatchAndThrowMethodsFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`throw(:done)`, "UncaughtThrowError: uncaught throw \"done\"", 1},
+		{`throw`, "ArgumentError: Expect 1 to 2 argument(s). got: 0", 1},
+		{`catch(:a)`, "InternalError: Can't yield without a block", 1},
+		{`catch(:a, :b) do
+		end`, "ArgumentError: Expect 1 or less argument(s). got: 2", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestBreakWithValue(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		[1, 2, 3].each do |i|
+		  if i == 2
+		    break i * 100
+		  end
+		end
+		`, 200},
+		{`
+		[1, 2, 3].each do |i|
+		  if i == 2
+		    break
+		  end
+		end
+		`, []interface{}{1, 2, 3}},
+		{`
+		a = [1, 2, 3].map do |i|
+		  break "stopped"
+		end
+		a
+		`, "stopped"},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestMethodsMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`
+		class C
+		  def hola
+		  end
+
+		  def hi
+		  end
+		end
+		C.new.methods.first(2) == ["hola", "hi"]
+		`, true},
+		// redefined methods keep their original position
+		{`
+		class C
+		  def hola
+		  end
+
+		  def hi
+		  end
+
+		  def hola
+		  end
+		end
+		C.new.methods.first(2) == ["hola", "hi"]
+		`, true},
+		{`
+		class C
+		  def hi
+		  end
+		end
+		class D < C
+		  def hola
+		  end
+		end
+		D.new.methods.first(2) == ["hola", "hi"]
+		`, true},
+		{`
+		class C
+		  def hi
+		  end
+		end
+		c = C.new
+		def c.hola
+		end
+		c.methods.first(2) == ["hola", "hi"]
+		`, true},
+		{`
+		class C
+		end
+		C.new.methods.include?("to_s")
+		`, true},
+	}
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestArityOfMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		class C
+		  def foo; end
+		  def bar(a, b); end
+		  def baz(a, b = 1); end
+		  def qux(a, *b); end
+		  def quux(a, b:); end
+		  def corge(a, b: 1); end
+		end
+		[C.arity_of("foo"), C.arity_of("bar"), C.arity_of("baz"), C.arity_of("qux"), C.arity_of("quux"), C.arity_of("corge")]
+		`, []interface{}{0, 2, -2, -2, 2, -2}},
+		{`
+		class C
+		  define_method :foo do |a, b|
+		  end
+		end
+		C.arity_of("foo")
+		`, 2},
+		{`
+		class C; end
+		C.arity_of("to_s")
+		`, -1},
+		// inherited methods are looked up
+		{`
+		class C
+		  def foo(a); end
+		end
+		class D < C; end
+		D.arity_of("foo")
+		`, 1},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestArityOfMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`Object.arity_of`, "ArgumentError: Expect 1 argument(s). got: 0", 1},
+		{`Object.arity_of(1)`, "TypeError: Expect argument to be String. got: Integer", 1},
+		{`Object.arity_of("foo")`, "NoMethodError: Undefined Method 'foo' for Object", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestMethodDefinedMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		class C
+		  def foo; end
+		end
+		class D < C; end
+		[C.method_defined?("foo"), D.method_defined?("foo"), D.method_defined?("to_s"), C.method_defined?("bar")]
+		`, []interface{}{true, true, true, false}},
+		{`
+		class C; end
+		C.define_method(:foo) do; end
+		C.method_defined?("foo")
+		`, true},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestMethodDefinedMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`Object.method_defined?`, "ArgumentError: Expect 1 argument(s). got: 0", 1},
+		{`Object.method_defined?(1)`, "TypeError: Expect argument to be String. got: Integer", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestAliasMethodMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		// the alias keeps the old body after the method is redefined
+		{`
+		class Foo
+		  def bar
+		    1
+		  end
+
+		  alias_method(:baz, :bar)
+
+		  def bar
+		    2
+		  end
+		end
+
+		[Foo.new.baz, Foo.new.bar]
+		`, []interface{}{1, 2}},
+		{`
+		class Foo; end
+		class Bar < Foo
+		  alias_method(:name, :to_s)
+		end
+
+		Bar.new.name == Bar.new.to_s
+		`, false},
+		{`
+		class String
+		  alias_method(:len, :length)
+		end
+
+		["goby".len, "goby".size]
+		`, []interface{}{4, 4}},
+		{`
+		class Foo
+		  def bar; end
+		end
+
+		Foo.alias_method(:baz, :bar)
+		`, "baz"},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestAliasMethodMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`Object.alias_method(:foo)`, "ArgumentError: Expect 2 argument(s). got: 1", 1},
+		{`Object.alias_method(:foo, 1)`, "TypeError: Expect argument to be String. got: Integer", 1},
+		{`Object.alias_method(:foo, :bar)`, "NameError: undefined method 'bar' for class 'Object'", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestRemoveAndUndefMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		// removing a method exposes the superclass's one
+		{`
+		class Foo
+		  def bar
+		    1
+		  end
+		end
+
+		class Baz < Foo
+		  def bar
+		    2
+		  end
+
+		  remove_method(:bar)
+		end
+
+		Baz.new.bar
+		`, 1},
+		{`
+		class Foo
+		  def bar; end
+		  def baz; end
+
+		  remove_method(:bar, :baz)
+		end
+
+		[Foo.method_defined?(:bar), Foo.method_defined?(:baz)]
+		`, []interface{}{false, false}},
+		// undefining a method hides the superclass's one too
+		{`
+		class Foo
+		  def bar; end
+		end
+
+		class Baz < Foo
+		  undef_method(:bar)
+		end
+
+		b = Baz.new
+		[b.respond_to?(:bar), b.methods.include?("bar"), Baz.method_defined?(:bar), Foo.new.respond_to?(:bar)]
+		`, []interface{}{false, false, false, true}},
+		// a method can be defined again after it's undefined
+		{`
+		class Foo
+		  undef_method(:to_s)
+
+		  def to_s
+		    "foo"
+		  end
+		end
+
+		Foo.new.to_s
+		`, "foo"},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestRemoveAndUndefMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`
+		class Foo
+		  def bar; end
+		end
+
+		class Baz < Foo
+		  undef_method(:bar)
+		end
+
+		Baz.new.bar
+		`, "NoMethodError: Undefined Method 'bar' for #<Baz:##OBJECTID## >", 1},
+		{`
+		class Foo
+		  def bar; end
+		end
+
+		class Baz < Foo; end
+
+		Baz.remove_method(:bar)
+		`, "NameError: method 'bar' not defined in Baz", 1},
+		{`
+		class Foo
+		  undef_method(:to_s)
+		end
+
+		Foo.remove_method(:to_s)
+		`, "NameError: method 'to_s' not defined in Foo", 1},
+		{`Object.undef_method(:bar)`, "NameError: undefined method 'bar' for class 'Object'", 1},
+		{`Object.remove_method`, "ArgumentError: Expect 1 or more argument(s). got: 0", 1},
+		{`Object.undef_method(1)`, "TypeError: Expect argument #1 to be String. got: Integer", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkFuzzifiedErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestSourceLocationOfMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		class C
+		  def foo
+		  end
+
+		  define_method :bar do
+		  end
+		end
+		[C.source_location_of("foo"), C.source_location_of("bar")]
+		`, []interface{}{[]interface{}{getFilename(), 3}, []interface{}{getFilename(), 6}}},
+		{`
+		class C; end
+		C.source_location_of("to_s")
+		`, nil},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestSourceLocationOfMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`Object.source_location_of`, "ArgumentError: Expect 1 argument(s). got: 0", 1},
+		{`Object.source_location_of("foo")`, "NoMethodError: Undefined Method 'foo' for Object", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestAncestorsMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`
+		class C
+		end
+		C.ancestors == [C, Object]
+		`, true},
+		{`
+		module M
+		end
+		class C
+		end
+		class C2 < C
+		  include M
+		end
+		class C3 < C2
+		end
+		C3.ancestors == [C3, C2, M, C, Object]
+		`, true},
+	}
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestBuiltinClassMonkeyPatching(t *testing.T) {
+	input := `
+	class String
+	  def buz
+	    "buz"
+	  end
+	end
 
 	"123".buz
 	`
@@ -1097,6 +1960,126 @@ func TestInheritsMethodMissingMethod(t *testing.T) {
 	}
 }
 
+func TestJSONAttributesMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		class User
+		  def initialize(name, age, password)
+		    @name = name
+		    @age = age
+		    @password = password
+		  end
+		end
+
+		User.new("Stan", 23, "secret").to_json
+		`, `{"age":23,"name":"Stan","password":"secret"}`},
+		{`
+		class User
+		  json_attributes("name", "age")
+
+		  def initialize(name, age, password)
+		    @name = name
+		    @age = age
+		    @password = password
+		  end
+		end
+
+		User.new("Stan", 23, "secret").to_json
+		`, `{"name":"Stan","age":23}`},
+		{`
+		class User
+		  json_attributes("name", "email")
+
+		  def initialize(name)
+		    @name = name
+		  end
+		end
+
+		User.new("Stan").to_json
+		`, `{"name":"Stan","email":null}`},
+		{`
+		class User
+		  json_attributes("name")
+
+		  def initialize(name, password)
+		    @name = name
+		    @password = password
+		  end
+		end
+
+		class Admin < User
+		end
+
+		{ admin: Admin.new("Stan", "secret") }.to_json
+		`, `{"admin":{"name":"Stan"}}`},
+		{`
+		class Point
+		  def initialize(x, y)
+		    @x = x
+		    @y = y
+		  end
+		end
+
+		class Line
+		  def initialize(from, to)
+		    @from = from
+		    @to = to
+		  end
+		end
+
+		Line.new(Point.new(0, 0), Point.new(1, [2, 3])).to_json
+		`, `{"from":{"x":0,"y":0},"to":{"x":1,"y":[2, 3]}}`},
+		{`
+		class User
+		  json_attributes("name")
+
+		  def initialize(name)
+		    @name = name
+		  end
+
+		  def to_json
+		    "custom"
+		  end
+		end
+
+		{ user: User.new("Stan") }.to_json
+		`, `{"user":custom}`},
+		{`Object.new.to_json`, `{}`},
+		{`1.to_json`, `1`},
+		{`"Goby".to_json`, `"Goby"`},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestJSONAttributesMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`
+		class User
+		  json_attributes("name", 1)
+		end
+		`, "TypeError: Expect argument #2 to be String. got: Integer", 2},
+		{`Object.new.to_json(1)`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestInspectMethod(t *testing.T) {
 	tests := []errorTestCase{
 		{`inspect`, "#<Object:##OBJECTID## >", 1},
@@ -1124,6 +2107,7 @@ func TestInspectMethod(t *testing.T) {
 		{`require 'concurrent/array';Concurrent::Array.inspect`, "Array", 1},
 		{`require 'concurrent/hash';Concurrent::Hash.inspect`, "Hash", 1},
 		{`require 'concurrent/rw_lock';Concurrent::RWLock.inspect`, "RWLock", 1},
+		{`require 'net/dns';Net::DNS.inspect`, "DNS", 1},
 		{`require 'net/simple_server';Net::SimpleServer.inspect`, "SimpleServer", 1},
 		{`require 'spec';Spec.inspect`, "Spec", 1},
 		{`require 'uri';URI.inspect`, "URI", 1},
@@ -1152,6 +2136,57 @@ func TestInspectMethod(t *testing.T) {
 		 end
 		end
 		Foo.new.inspect`, `#<Foo:##OBJECTID## @bar={ decimal: 3.14, float: 2.71 } @foo=[42, "string", { key: "value" }] >`, 1},
+		{`
+		class Foo
+		 def initialize
+		   @zeta = 3
+		   @alpha = 1
+		   @mu = 2
+		 end
+		end
+		Foo.new.inspect`, `#<Foo:##OBJECTID## @alpha=1 @mu=2 @zeta=3 >`, 1},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestInspectMethodWithDepth(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`[1, [2, [3, [4]]]].inspect(2)`, `[1, [2, ...]]`},
+		{`[1, [2, [3, [4]]]].inspect(depth: 2)`, `[1, [2, ...]]`},
+		{`[1, [2, [3, [4]]]].inspect(depth: 10)`, `[1, [2, [3, [4]]]]`},
+		{`[1, [2]].inspect(depth: 1)`, `[1, ...]`},
+		{`{ a: { b: { c: 1 } }, d: [1] }.inspect(depth: 2)`, `{ a: { b: ... }, d: [1] }`},
+		{`"foo".inspect`, `"foo"`},
+		{`1.inspect(depth: 1)`, `1`},
+		{`
+		class Foo
+		  def initialize
+		    @bar = [1, [2, [3]]]
+		  end
+		end
+		Foo.new.inspect(depth: 2)`, `#<Foo:##OBJECTID## @bar=[1, ...] >`},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, [2, [3]]]).inspect(depth: 2)`, `[1, [2, ...]]`},
+		// a collection that contains itself is written as `[...]` or `{...}` where it recurs
+		{`
+		a = [1]
+		a.push(a)
+		a.inspect`, `[1, [...]]`},
+		{`
+		h = { a: 1 }
+		h[:b] = [h]
+		h.inspect`, `{ a: 1, b: [{...}] }`},
 	}
 
 	for i, tt := range tests {
@@ -1163,6 +2198,110 @@ func TestInspectMethod(t *testing.T) {
 	}
 }
 
+func TestInspectMethodWithDepthFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`[1].inspect(depth: 0)`, "ArgumentError: Expect argument to be positive value. got: 0", 1},
+		{`[1].inspect("1")`, "TypeError: Expect argument to be Integer. got: String", 1},
+		{`[1].inspect(1, 2)`, "ArgumentError: Expect 1 or less argument(s). got: 2", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestPMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		output   string
+		expected interface{}
+	}{
+		{`p`, "", nil},
+		{`p(1)`, "1\n", 1},
+		{`p("foo")`, "\"foo\"\n", "foo"},
+		{`p(nil)`, "nil\n", nil},
+		{`p(1, "a", nil)`, "1\n\"a\"\nnil\n", []interface{}{1, "a", nil}},
+		{`p([1, ["a"]])`, "[1, [\"a\"]]\n", []interface{}{1, []interface{}{"a"}}},
+		{`p({ a: 1 })[:a]`, "{ a: 1 }\n", 1},
+		{`p(1) + 1`, "1\n", 2},
+	}
+
+	for i, tt := range tests {
+		var out bytes.Buffer
+		v := initTestVM()
+		v.SetStdout(&out)
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		VerifyExpected(t, i, v.InitStringObject(out.String()), tt.output)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestPrintMethod(t *testing.T) {
+	tests := []struct {
+		input  string
+		output string
+	}{
+		{`print`, ""},
+		{`print("foo")`, "foo"},
+		{`print("foo", "bar")`, "foobar"},
+		{`print("foo", nil, 1)`, "foo1"},
+		{`print(nil)`, ""},
+		{`print([1, "a", nil])`, "[1, \"a\", nil]"},
+		{`print("foo\n")`, "foo\n"},
+	}
+
+	for i, tt := range tests {
+		var out bytes.Buffer
+		v := initTestVM()
+		v.SetStdout(&out)
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, nil)
+		VerifyExpected(t, i, v.InitStringObject(out.String()), tt.output)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestPutsMethod(t *testing.T) {
+	tests := []struct {
+		input  string
+		output string
+	}{
+		{`puts`, "\n"},
+		{`puts("foo")`, "foo\n"},
+		{`puts("foo", "bar")`, "foo\nbar\n"},
+		{`puts(nil)`, "\n"},
+		{`puts(1, nil, 2)`, "1\n\n2\n"},
+		{`puts("foo\n")`, "foo\n"},
+		{`puts("")`, "\n"},
+		{`puts([1, "a"])`, "1\na\n"},
+		{`puts([1, [2, [nil, 3]]])`, "1\n2\n\n3\n"},
+		{`puts([])`, "\n"},
+		{`puts([[], 1])`, "\n1\n"},
+		{`puts({ a: 1 })`, "{ a: 1 }\n"},
+		// an array that contains itself is put as `[...]` where it recurs
+		{`a = [1]; a.push(a); puts(a)`, "1\n[...]\n"},
+		{`a = [1]; b = [a, a]; puts(b)`, "1\n1\n"},
+	}
+
+	for i, tt := range tests {
+		var out bytes.Buffer
+		v := initTestVM()
+		v.SetStdout(&out)
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, nil)
+		VerifyExpected(t, i, v.InitStringObject(out.String()), tt.output)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestRaiseMethod(t *testing.T) {
 	testsFail := []struct {
 		input       string
@@ -1285,7 +2424,7 @@ func TestRespondToMethod(t *testing.T) {
 
 func TestRespondToMethodFail(t *testing.T) {
 	testsFail := []errorTestCase{
-		{`1.respond_to?`, "ArgumentError: Expect 1 argument(s). got: 0", 1},
+		{`1.respond_to?`, "ArgumentError: Expect 1 to 2 argument(s). got: 0", 1},
 	}
 
 	for i, tt := range testsFail {
@@ -1458,6 +2597,11 @@ func TestSendMethodFail(t *testing.T) {
 	testsFail := []errorTestCase{
 		{`send`, `ArgumentError: Expect 1 or more argument(s). got: 0`, 1},
 		{`send(["foo"])`, `TypeError: Expect argument to be String. got: Array`, 1},
+		// methods called without the arguments they need
+		{`Object.new.send("==")`, `ArgumentError: Expect 1 argument(s). got: 0`, 2},
+		{`1.send("!=")`, `ArgumentError: Expect 1 argument(s). got: 0`, 2},
+		{`Object.send("extend")`, `ArgumentError: Expect 1 argument(s). got: 0`, 2},
+		{`"Taipei".send("+")`, `ArgumentError: Expect 1 argument(s). got: 0`, 2},
 	}
 
 	for i, tt := range testsFail {
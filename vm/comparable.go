@@ -0,0 +1,239 @@
+package vm
+
+import (
+	"github.com/goby-lang/goby/vm/classes"
+	"github.com/goby-lang/goby/vm/errors"
+)
+
+// Comparable is a module that derives comparison methods from the `<=>` method of the class that includes it.
+// `<=>` should return a negative Integer, 0 or a positive Integer when self is less than, equal to or greater than
+// the other object, and `nil` when they can't be compared.
+//
+// ```ruby
+// class Version
+//   include(Comparable)
+//
+//   attr_reader :major
+//
+//   def initialize(major)
+//     @major = major
+//   end
+//
+//   def <=>(other)
+//     @major <=> other.major
+//   end
+// end
+//
+// Version.new(1) < Version.new(2) # => true
+// Version.new(3).clamp(Version.new(1), Version.new(2)).major # => 2
+// ```
+
+const clampRangeReversed = "min argument must be less than or equal to max argument"
+
+// Instance methods -----------------------------------------------------
+var builtinComparableInstanceMethods = []*BuiltinMethodObject{
+	{
+		// Returns true if `self <=> other` is negative.
+		// Raises an ArgumentError if they can't be compared.
+		//
+		// @param other [Object]
+		// @return [Boolean]
+		Name: "<",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return t.compareWithSpaceship(receiver, args, func(cmp int) bool { return cmp < 0 }, sourceLine)
+
+		},
+	},
+	{
+		// Returns true if `self <=> other` is negative or 0.
+		// Raises an ArgumentError if they can't be compared.
+		//
+		// @param other [Object]
+		// @return [Boolean]
+		Name: "<=",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return t.compareWithSpaceship(receiver, args, func(cmp int) bool { return cmp <= 0 }, sourceLine)
+
+		},
+	},
+	{
+		// Returns true if self is the other object, or `self <=> other` is 0.
+		// It's false if they can't be compared.
+		//
+		// @param other [Object]
+		// @return [Boolean]
+		Name: "==",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			if receiver == args[0] {
+				return TRUE
+			}
+
+			result := t.callMethod(receiver, "<=>", sourceLine, args[0])
+
+			if err, ok := result.(*Error); ok {
+				return err
+			}
+
+			cmp, ok := result.(*IntegerObject)
+			return toBooleanObject(ok && cmp.value == 0)
+
+		},
+	},
+	{
+		// Returns true if `self <=> other` is positive.
+		// Raises an ArgumentError if they can't be compared.
+		//
+		// @param other [Object]
+		// @return [Boolean]
+		Name: ">",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return t.compareWithSpaceship(receiver, args, func(cmp int) bool { return cmp > 0 }, sourceLine)
+
+		},
+	},
+	{
+		// Returns true if `self <=> other` is positive or 0.
+		// Raises an ArgumentError if they can't be compared.
+		//
+		// @param other [Object]
+		// @return [Boolean]
+		Name: ">=",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return t.compareWithSpaceship(receiver, args, func(cmp int) bool { return cmp >= 0 }, sourceLine)
+
+		},
+	},
+	{
+		// Returns true if self is between min and max, both inclusive.
+		//
+		// ```ruby
+		// Version.new(2).between?(Version.new(1), Version.new(2)) # => true
+		// ```
+		//
+		// @param min [Object]
+		// @param max [Object]
+		// @return [Boolean]
+		Name: "between?",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 2 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 2, len(args))
+			}
+
+			lower, err := t.spaceship(receiver, args[0], sourceLine)
+
+			if err != nil {
+				return err
+			}
+
+			upper, err := t.spaceship(receiver, args[1], sourceLine)
+
+			if err != nil {
+				return err
+			}
+
+			return toBooleanObject(lower >= 0 && upper <= 0)
+
+		},
+	},
+	{
+		// Returns min if self is less than min, max if self is greater than max, and self otherwise.
+		// Raises an ArgumentError if min is greater than max.
+		//
+		// ```ruby
+		// Version.new(3).clamp(Version.new(1), Version.new(2)).major # => 2
+		// Version.new(0).clamp(Version.new(1), Version.new(2)).major # => 1
+		// ```
+		//
+		// @param min [Object]
+		// @param max [Object]
+		// @return [Object]
+		Name: "clamp",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 2 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 2, len(args))
+			}
+
+			min, max := args[0], args[1]
+			bounds, err := t.spaceship(min, max, sourceLine)
+
+			if err != nil {
+				return err
+			}
+
+			if bounds > 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, clampRangeReversed)
+			}
+
+			if cmp, err := t.spaceship(receiver, min, sourceLine); err != nil {
+				return err
+			} else if cmp < 0 {
+				return min
+			}
+
+			if cmp, err := t.spaceship(receiver, max, sourceLine); err != nil {
+				return err
+			} else if cmp > 0 {
+				return max
+			}
+
+			return receiver
+
+		},
+	},
+}
+
+// Internal functions ===================================================
+
+// Functions for initialization -----------------------------------------
+
+func (vm *VM) initComparableModule() *RClass {
+	module := vm.initializeModule(classes.ComparableModule)
+	module.setBuiltinMethods(builtinComparableInstanceMethods, false)
+	return module
+}
+
+// Other helper functions -----------------------------------------------
+
+// compareWithSpaceship returns whether `receiver <=> args[0]` satisfies the predicate
+func (t *Thread) compareWithSpaceship(receiver Object, args []Object, predicate func(cmp int) bool, sourceLine int) Object {
+	if len(args) != 1 {
+		return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+	}
+
+	cmp, err := t.spaceship(receiver, args[0], sourceLine)
+
+	if err != nil {
+		return err
+	}
+
+	return toBooleanObject(predicate(cmp))
+}
+
+// spaceship calls `left <=> right` and returns the sign of the result.
+// It's an ArgumentError if the result isn't an Integer, which means they can't be compared.
+func (t *Thread) spaceship(left, right Object, sourceLine int) (int, *Error) {
+	result := t.callMethod(left, "<=>", sourceLine, right)
+
+	if err, ok := result.(*Error); ok {
+		return 0, err
+	}
+
+	cmp, ok := result.(*IntegerObject)
+
+	if !ok {
+		return 0, t.vm.InitErrorObject(errors.ArgumentError, sourceLine, comparisonFailed, left.Class().Name, right.Class().Name)
+	}
+
+	switch {
+	case cmp.value < 0:
+		return -1, nil
+	case cmp.value > 0:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
@@ -0,0 +1,99 @@
+package vm
+
+import (
+	"testing"
+)
+
+const comparableVersionClass = `
+class Version
+  include(Comparable)
+
+  attr_reader :major
+
+  def initialize(major)
+    @major = major
+  end
+
+  def <=>(other)
+    if other.is_a?(Version)
+      @major <=> other.major
+    end
+  end
+end
+`
+
+func TestComparableModule(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`Version.new(1) < Version.new(2)`, true},
+		{`Version.new(2) < Version.new(2)`, false},
+		{`Version.new(2) <= Version.new(2)`, true},
+		{`Version.new(3) <= Version.new(2)`, false},
+		{`Version.new(3) > Version.new(2)`, true},
+		{`Version.new(2) > Version.new(2)`, false},
+		{`Version.new(2) >= Version.new(2)`, true},
+		{`Version.new(1) >= Version.new(2)`, false},
+		{`Version.new(2) == Version.new(2)`, true},
+		{`Version.new(1) == Version.new(2)`, false},
+		{`Version.new(1) == 1`, false},
+		{`Version.new(1) != Version.new(2)`, true},
+		{`Version.new(2) != Version.new(2)`, false},
+		{`Version.new(2).between?(Version.new(1), Version.new(3))`, true},
+		{`Version.new(1).between?(Version.new(1), Version.new(3))`, true},
+		{`Version.new(3).between?(Version.new(1), Version.new(3))`, true},
+		{`Version.new(4).between?(Version.new(1), Version.new(3))`, false},
+		{`Version.new(0).clamp(Version.new(1), Version.new(3)).major`, 1},
+		{`Version.new(2).clamp(Version.new(1), Version.new(3)).major`, 2},
+		{`Version.new(4).clamp(Version.new(1), Version.new(3)).major`, 3},
+		{`Version.new(4).clamp(Version.new(3), Version.new(3)).major`, 3},
+		{`Version.ancestors.to_s`, "[Version, Comparable, Object]"},
+		{`Version.new(1).is_a?(Comparable)`, true},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, comparableVersionClass+tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestComparableModuleWithOverriddenMethod(t *testing.T) {
+	input := comparableVersionClass + `
+	class Version
+	  def ==(other)
+	    "overridden"
+	  end
+	end
+
+	[Version.new(1) == Version.new(1), Version.new(1) < Version.new(2)]
+	`
+
+	v := initTestVM()
+	evaluated := v.testEval(t, input, getFilename())
+	VerifyExpected(t, 0, evaluated, []interface{}{"overridden", true})
+	v.checkCFP(t, 0, 0)
+	v.checkSP(t, 0, 1)
+}
+
+func TestComparableModuleFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`Version.new(1) < 1`, "ArgumentError: comparison of Version with Integer failed", 1},
+		{`Version.new(1) >= nil`, "ArgumentError: comparison of Version with Null failed", 1},
+		{`Version.new(1).between?(Version.new(1), "a")`, "ArgumentError: comparison of Version with String failed", 1},
+		{`Version.new(1).clamp(Version.new(3), Version.new(1))`, "ArgumentError: min argument must be less than or equal to max argument", 1},
+		{`Version.new(1).clamp(Version.new(1))`, "ArgumentError: Expect 2 argument(s). got: 1", 1},
+		{`Version.new(1).between?(Version.new(1))`, "ArgumentError: Expect 2 argument(s). got: 1", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, comparableVersionClass+tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
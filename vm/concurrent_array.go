@@ -1,6 +1,7 @@
 package vm
 
 import (
+	"sort"
 	"sync"
 
 	"github.com/goby-lang/goby/vm/classes"
@@ -12,41 +13,51 @@ import (
 //
 // We don't implement dig, as it has no concurrency guarantees.
 var ConcurrentArrayMethodsForwardingTable = map[string]bool{
-	"[]":           false,
-	"*":            false,
-	"+":            false,
-	"[]=":          true,
-	"any?":         false,
-	"at":           false,
-	"clear":        true,
-	"concat":       true,
-	"count":        false,
-	"delete_at":    true,
-	"each":         false,
-	"each_index":   false,
-	"empty?":       false,
-	"first":        false,
-	"flatten":      false,
-	"join":         false,
-	"last":         false,
-	"length":       false,
-	"map":          false,
-	"pop":          true,
-	"push":         true,
-	"reduce":       false,
-	"reverse":      false,
-	"reverse_each": false,
-	"rotate":       false,
-	"select":       false,
-	"shift":        true,
-	"unshift":      true,
-	"values_at":    false,
+	"[]":               false,
+	"*":                false,
+	"+":                false,
+	"[]=":              true,
+	"any?":             false,
+	"assoc":            false,
+	"at":               false,
+	"bsearch":          false,
+	"bsearch_index":    false,
+	"clear":            true,
+	"compact":          false,
+	"compact!":         true,
+	"concat":           true,
+	"count":            false,
+	"delete_at":        true,
+	"each_index":       false,
+	"each_with_object": false,
+	"empty?":           false,
+	"fill":             true,
+	"first":            false,
+	"flatten":          false,
+	"insert_sorted":    true,
+	"join":             false,
+	"last":             false,
+	"length":           false,
+	"map":              false,
+	"pop":              true,
+	"push":             true,
+	"rassoc":           false,
+	"reduce":           false,
+	"reverse":          false,
+	"reverse_each":     false,
+	"rotate":           false,
+	"select":           false,
+	"shift":            true,
+	"unshift":          true,
+	"values_at":        false,
 }
 
 // ConcurrentArrayObject is a thread-safe Array, implemented as a wrapper of an ArrayObject, coupled
 // with an R/W mutex.
 //
-// Arrays returned by any of the methods are in turn thread-safe.
+// Arrays returned by any of the methods are in turn thread-safe, and are snapshots taken under the lock:
+// they don't share their elements' storage with the receiver. Destructive methods returning the array itself,
+// like `push` and `fill`, return the receiver instead.
 //
 // For implementation simplicity, methods are simple redirection, and defined via a table.
 //
@@ -82,6 +93,486 @@ var builtinConcurrentArrayClassMethods = []*BuiltinMethodObject{
 
 		},
 	},
+	{
+		// Creates a Concurrent::Array from a JSON array, like the one `persist` writes.
+		// Nested objects and arrays become plain Hashes and Arrays.
+		//
+		// ```ruby
+		// a = Concurrent::Array.from_json('[1, {"b": 2}]')
+		// a[1]["b"] # => 2
+		// Concurrent::Array.from_json('{}') # => TypeError
+		// ```
+		//
+		// @param json [String]
+		// @return [Concurrent::Array]
+		Name: "from_json",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			jsonString, ok := args[0].(*StringObject)
+
+			if !ok {
+				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.StringClass, args[0].Class().Name)
+			}
+
+			return t.concurrentArrayFromJSON(t.decodeJSON(jsonString.value, sourceLine), sourceLine)
+
+		},
+	},
+	{
+		// Creates a Concurrent::Array from the JSON array in the file at the given path, like the one `persist` writes.
+		//
+		// ```ruby
+		// a = Concurrent::Array.new([1, 2])
+		// a.persist("/tmp/jobs.json")
+		// Concurrent::Array.load("/tmp/jobs.json").length # => 2
+		// ```
+		//
+		// @param path [String]
+		// @return [Concurrent::Array]
+		Name: "load",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			path, ok := args[0].(*StringObject)
+
+			if !ok {
+				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.StringClass, args[0].Class().Name)
+			}
+
+			return t.concurrentArrayFromJSON(t.readJSONFile(path.value, sourceLine), sourceLine)
+
+		},
+	},
+}
+
+// Instance methods -----------------------------------------------------
+var builtinConcurrentArrayInstanceMethods = []*BuiltinMethodObject{
+	{
+		// Splits the array into runs of consecutive elements, and returns them as a concurrent array of arrays.
+		// The block is called with each pair of adjacent elements, and the run goes on while it returns a truthy value.
+		// The block is called with a snapshot of the elements taken under the read lock, so it can modify the receiver.
+		//
+		// ```ruby
+		// a = Concurrent::Array.new([1, 2, 4, 9, 10, 11, 12, 15])
+		// a.chunk_while do |prev, cur|
+		//   prev + 1 == cur
+		// end
+		// # => [[1, 2], [4], [9, 10, 11, 12], [15]]
+		// ```
+		//
+		// @return [Concurrent::Array]
+		Name: "chunk_while",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			if blockFrame == nil {
+				return t.vm.InitErrorObject(errors.InternalError, sourceLine, errors.CantYieldWithoutBlockFormat)
+			}
+
+			runs, err := t.splitRuns(blockFrame, receiver.(*ConcurrentArrayObject).snapshot(), false)
+
+			if err != nil {
+				return err
+			}
+
+			return t.vm.initConcurrentArrayObject(runs)
+
+		},
+	},
+	{
+		// Groups the elements by the block's result, and returns a Hash of how many elements are in each group.
+		// Like the keys of `Array#index_with`, the block's results are converted to Strings to be the Hash's keys.
+		// The block is called with a snapshot of the elements taken under the read lock, so it can modify the receiver.
+		//
+		// ```ruby
+		// a = Concurrent::Array.new(["apple", "fig", "kiwi", "pear", "plum"])
+		// a.count_by do |word|
+		//   word.length
+		// end
+		// # => { 3: 1, 4: 3, 5: 1 }, whose keys are "3", "4" and "5"
+		// ```
+		//
+		// @return [Hash]
+		Name: "count_by",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			if blockFrame == nil {
+				return t.vm.InitErrorObject(errors.InternalError, sourceLine, errors.CantYieldWithoutBlockFormat)
+			}
+
+			elems := receiver.(*ConcurrentArrayObject).snapshot()
+
+			counts := make(map[string]int)
+
+			for _, elem := range elems {
+				key, erred := t.builtinMethodYield(blockFrame, elem)
+
+				if erred {
+					return key
+				}
+
+				counts[key.ToString()]++
+			}
+
+			pairs := make(map[string]Object, len(counts))
+
+			for key, count := range counts {
+				pairs[key] = t.vm.InitIntegerObject(count)
+			}
+
+			return t.vm.InitHashObject(pairs)
+
+		},
+	},
+	{
+		// Removes the elements for which the block returns a truthy value, and returns self.
+		// The block is called with a snapshot of the elements taken under the read lock, so it can modify the receiver,
+		// then the elements are removed at once under the write lock. Elements pushed by other threads meanwhile are kept.
+		// Nothing is removed if the block raises an error.
+		//
+		// ```ruby
+		// a = Concurrent::Array.new([1, 2, 3, 4, 5])
+		// a.delete_if do |i|
+		//   i.even?
+		// end
+		// a # => [1, 3, 5]
+		// ```
+		//
+		// @return [Concurrent::Array]
+		Name: "delete_if",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return t.removeElementsIf(receiver.(*ConcurrentArrayObject), args, blockFrame, true, sourceLine)
+
+		},
+	},
+	{
+		// Works like `Array#each` under the read lock. Without a block, returns an ArrayEnumerator
+		// of a snapshot taken under the read lock instead, so it isn't affected by later modifications.
+		//
+		// ```ruby
+		// a = Concurrent::Array.new([1, 2])
+		// e = a.each
+		// a.push(3)
+		// e.to_a # => [1, 2]
+		// ```
+		//
+		// @return [Concurrent::Array]
+		Name: "each",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) == 0 && blockFrame == nil {
+				return t.callMethod(t.vm.InitArrayObject(receiver.(*ConcurrentArrayObject).snapshot()), "to_enum", sourceLine)
+			}
+
+			return DefineForwardedConcurrentArrayMethod("each", false).Fn(receiver, sourceLine, t, args, blockFrame)
+
+		},
+	},
+	{
+		// Keeps only the elements for which the block returns a truthy value, and returns self.
+		// It's the opposite of `delete_if`, and works the same way.
+		//
+		// ```ruby
+		// a = Concurrent::Array.new([1, 2, 3, 4, 5])
+		// a.keep_if do |i|
+		//   i.even?
+		// end
+		// a # => [2, 4]
+		// ```
+		//
+		// @return [Concurrent::Array]
+		Name: "keep_if",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return t.removeElementsIf(receiver.(*ConcurrentArrayObject), args, blockFrame, false, sourceLine)
+
+		},
+	},
+	{
+		// Returns the largest element, or nil if the array is empty.
+		// If a count is given, returns the largest `n` elements as a concurrent array in descending order instead,
+		// with `n` clamped to the array's length.
+		// Elements are compared like `Array#sort` does, over a snapshot taken under the read lock.
+		//
+		// ```ruby
+		// a = Concurrent::Array.new([3, 8, 1, 5])
+		// a.max     # => 8
+		// a.max(2)  # => [8, 5]
+		// a.max(10) # => [8, 5, 3, 1]
+		// ```
+		//
+		// @param n [Integer]
+		// @return [Object]
+		Name: "max",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return receiver.(*ConcurrentArrayObject).extremes(t, args, true, sourceLine)
+
+		},
+	},
+	{
+		// Returns the smallest element, or nil if the array is empty.
+		// If a count is given, returns the smallest `n` elements as a concurrent array in ascending order instead,
+		// with `n` clamped to the array's length.
+		// Elements are compared like `Array#sort` does, over a snapshot taken under the read lock.
+		//
+		// ```ruby
+		// a = Concurrent::Array.new([3, 8, 1, 5])
+		// a.min     # => 1
+		// a.min(2)  # => [1, 3]
+		// a.min(0)  # => []
+		// ```
+		//
+		// @param n [Integer]
+		// @return [Object]
+		Name: "min",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return receiver.(*ConcurrentArrayObject).extremes(t, args, false, sourceLine)
+
+		},
+	},
+	{
+		// Writes a snapshot of the array as JSON to the file at the given path, and returns the receiver.
+		// The file is replaced atomically: the JSON is written to a temporary file in the same directory first,
+		// which is then renamed to the path, so readers never see a partially written file.
+		// It raises the same errors as `snapshot` if an element can't be written as JSON.
+		//
+		// ```ruby
+		// a = Concurrent::Array.new([1, "a"])
+		// a.persist("/tmp/jobs.json")
+		// File.new("/tmp/jobs.json").read # => [1,"a"]
+		// ```
+		//
+		// @param path [String]
+		// @return [Concurrent::Array]
+		Name: "persist",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			path, ok := args[0].(*StringObject)
+
+			if !ok {
+				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.StringClass, args[0].Class().Name)
+			}
+
+			snapshot := receiver.(*ConcurrentArrayObject).deepSnapshot(t, sourceLine)
+
+			if err, ok := snapshot.(*Error); ok {
+				return err
+			}
+
+			if err := t.persistSnapshot(snapshot, path.value, sourceLine); err != nil {
+				return err
+			}
+
+			return receiver
+
+		},
+	},
+	{
+		// Returns a deep copy of the array as a plain Array, with nested Arrays, Hashes and concurrent collections copied too,
+		// so later writes to the receiver don't affect it.
+		// The copy is taken under the read lock, so it's a single point-in-time view of the array.
+		//
+		// Only the values JSON can represent are supported: nil, Boolean, Integer, Float, String, Array and Hash.
+		// A `TypeError` locating the first other value is raised otherwise.
+		//
+		// ```ruby
+		// a = Concurrent::Array.new([[1, 2]])
+		// s = a.snapshot # => [[1, 2]]
+		// a[0].push(3)
+		// s[0]           # => [1, 2]
+		// Concurrent::Array.new([1, Channel.new]).snapshot # => TypeError: Can't snapshot Channel at [1]: ...
+		// ```
+		//
+		// @return [Array]
+		Name: "snapshot",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			return receiver.(*ConcurrentArrayObject).deepSnapshot(t, sourceLine)
+
+		},
+	},
+	{
+		// Splits the array into runs of consecutive elements, and returns them as a concurrent array of arrays.
+		// The block is called with each pair of adjacent elements, and a new run starts between them when it returns
+		// a truthy value. It's the opposite of `chunk_while`.
+		// The block is called with a snapshot of the elements taken under the read lock, so it can modify the receiver.
+		//
+		// ```ruby
+		// a = Concurrent::Array.new([1, 2, 6, 7, 15, 16])
+		// a.slice_when do |prev, cur|
+		//   cur - prev > 3
+		// end
+		// # => [[1, 2], [6, 7], [15, 16]]
+		// ```
+		//
+		// @return [Concurrent::Array]
+		Name: "slice_when",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			if blockFrame == nil {
+				return t.vm.InitErrorObject(errors.InternalError, sourceLine, errors.CantYieldWithoutBlockFormat)
+			}
+
+			runs, err := t.splitRuns(blockFrame, receiver.(*ConcurrentArrayObject).snapshot(), true)
+
+			if err != nil {
+				return err
+			}
+
+			return t.vm.initConcurrentArrayObject(runs)
+
+		},
+	},
+	{
+		// Returns the Cartesian product of the receiver and the given arrays, as a concurrent array of arrays.
+		// The arguments can be Arrays or Concurrent::Arrays. Each of them, like the receiver, is snapshotted under
+		// its read lock first, so the product is computed without holding any lock.
+		//
+		// If a block is given, each combination is yielded to it instead and the receiver is returned.
+		//
+		// The result has as many elements as the product of all the arrays' lengths, so it grows quickly:
+		// prefer the block form for large inputs, as it doesn't hold all the combinations in memory.
+		//
+		// ```Ruby
+		// a = Concurrent::Array.new([1, 2])
+		// a.product(["a", "b"])   # => [[1, "a"], [1, "b"], [2, "a"], [2, "b"]]
+		// a.product               # => [[1], [2]]
+		// a.product([])           # => []
+		// a.product([3]) do |pair|
+		//   puts(pair.to_s)
+		// end
+		// ```
+		//
+		// @param *arrays [Array]
+		// @return [Concurrent::Array]
+		Name: "product",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			lists := [][]Object{receiver.(*ConcurrentArrayObject).snapshot()}
+
+			for i, arg := range args {
+				switch a := arg.(type) {
+				case *ArrayObject:
+					lists = append(lists, append([]Object{}, a.Elements...))
+				case *ConcurrentArrayObject:
+					lists = append(lists, a.snapshot())
+				default:
+					return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormatNum, i+1, classes.ArrayClass, arg.Class().Name)
+				}
+			}
+
+			if blockFrame != nil {
+				if blockIsEmpty(blockFrame) {
+					return receiver
+				}
+
+				var blockErr Object
+
+				eachProduct(lists, func(tuple []Object) bool {
+					result, erred := t.builtinMethodYield(blockFrame, t.vm.InitArrayObject(tuple))
+
+					if erred {
+						blockErr = result
+					}
+
+					return !erred
+				})
+
+				if blockErr != nil {
+					return blockErr
+				}
+
+				return receiver
+			}
+
+			var tuples []Object
+
+			eachProduct(lists, func(tuple []Object) bool {
+				tuples = append(tuples, t.vm.InitArrayObject(tuple))
+				return true
+			})
+
+			return t.vm.initConcurrentArrayObject(tuples)
+
+		},
+	},
+	{
+		// Returns the sum of the elements added to the initial value, which is 0 by default.
+		// The elements are added with `+`, so they can be any objects that can be added to the initial value.
+		//
+		// If a block is given, the block's results for the elements are summed instead.
+		//
+		// When the initial value is an Array, the elements should be arrays, and they're concatenated into
+		// a new concurrent array instead. It's a TypeError if one of them isn't an array.
+		// The elements are a snapshot taken under the read lock, so the block can modify the array.
+		//
+		// ```ruby
+		// Concurrent::Array.new([1, 2, 3]).sum         # => 6
+		// Concurrent::Array.new([1, 2, 3]).sum(10)     # => 16
+		// Concurrent::Array.new([0.5, 1]).sum          # => 1.5
+		// Concurrent::Array.new(["a", "b"]).sum("")    # => "ab"
+		// Concurrent::Array.new([[1, 2], [3]]).sum([]) # => [1, 2, 3]
+		// Concurrent::Array.new([]).sum                # => 0
+		//
+		// Concurrent::Array.new([1, 2, 3]).sum do |i|
+		//   i * 2
+		// end                                          # => 12
+		// ```
+		//
+		// @param initial value [Object]
+		// @param block [Block]
+		// @return [Object]
+		Name: "sum",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) > 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgumentLess, 1, len(args))
+			}
+
+			var init Object = t.vm.InitIntegerObject(0)
+
+			if len(args) == 1 {
+				init = args[0]
+			}
+
+			elems := receiver.(*ConcurrentArrayObject).snapshot()
+
+			if blockFrame != nil {
+				mapped, err := t.mapElements(blockFrame, elems)
+
+				if err != nil {
+					return err
+				}
+
+				elems = mapped
+			}
+
+			switch a := init.(type) {
+			case *ArrayObject:
+				return t.concatenateArrays(append([]Object{}, a.Elements...), elems, sourceLine)
+			case *ConcurrentArrayObject:
+				return t.concatenateArrays(a.snapshot(), elems, sourceLine)
+			}
+
+			return t.sumObjects(init, elems, sourceLine)
+
+		},
+	},
 }
 
 // Internal functions ===================================================
@@ -93,7 +584,7 @@ func (vm *VM) initConcurrentArrayObject(elements []Object) *ConcurrentArrayObjec
 
 	return &ConcurrentArrayObject{
 		BaseObj:       NewBaseObject(concurrent.getClassConstant(classes.ArrayClass)),
-		InternalArray: vm.InitArrayObject(elements[:]),
+		InternalArray: vm.InitArrayObject(append([]Object{}, elements...)),
 	}
 }
 
@@ -109,6 +600,7 @@ func initConcurrentArrayClass(vm *VM) {
 	}
 
 	array.setBuiltinMethods(arrayMethodDefinitions, false)
+	array.setBuiltinMethods(builtinConcurrentArrayInstanceMethods, false)
 	array.setBuiltinMethods(builtinConcurrentArrayClassMethods, true)
 
 	concurrent.setClassConstant(array)
@@ -118,7 +610,8 @@ func initConcurrentArrayClass(vm *VM) {
 
 // ToJSON returns the object's name as the JSON string format
 func (cao *ConcurrentArrayObject) ToJSON(t *Thread) string {
-	return cao.InternalArray.ToJSON(t)
+	e := &jsonEncoder{t: t}
+	return e.encodeElements(cao.snapshot(), jsonRootPath)
 }
 
 // ToString returns the object's name as the string format
@@ -148,6 +641,249 @@ func (cao *ConcurrentArrayObject) equalTo(compared Object) bool {
 
 // Helper functions -----------------------------------------------------
 
+// snapshot copies the elements under the read lock
+func (cao *ConcurrentArrayObject) snapshot() []Object {
+	cao.RLock()
+	defer cao.RUnlock()
+
+	return append([]Object{}, cao.InternalArray.Elements...)
+}
+
+// deepSnapshot copies the elements deeply under the read lock. See `Thread.snapshotValue`.
+func (cao *ConcurrentArrayObject) deepSnapshot(t *Thread, sourceLine int) Object {
+	cao.RLock()
+	defer cao.RUnlock()
+
+	return t.snapshotElements(cao.InternalArray.Elements, "", sourceLine)
+}
+
+// extremes implements `min` and `max`: it returns the smallest or the largest element,
+// or the `n` smallest or largest ones if a count is given in the arguments
+func (cao *ConcurrentArrayObject) extremes(t *Thread, args []Object, largest bool, sourceLine int) Object {
+	if len(args) > 1 {
+		return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgumentLess, 1, len(args))
+	}
+
+	n := 1
+
+	if len(args) == 1 {
+		count, ok := args[0].(*IntegerObject)
+
+		if !ok {
+			return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.IntegerClass, args[0].Class().Name)
+		}
+
+		if count.value < 0 {
+			return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.NegativeValue, count.value)
+		}
+
+		n = count.value
+	}
+
+	elems := cao.snapshot()
+
+	if err := t.checkComparable(elems, sourceLine); err != nil {
+		return err
+	}
+
+	sort.SliceStable(elems, func(i, j int) bool {
+		result, _ := compareObjects(elems[i], elems[j])
+
+		if largest {
+			return result > 0
+		}
+
+		return result < 0
+	})
+
+	if n > len(elems) {
+		n = len(elems)
+	}
+
+	if len(args) == 0 {
+		if n == 0 {
+			return NULL
+		}
+
+		return elems[0]
+	}
+
+	return t.vm.initConcurrentArrayObject(elems[:n])
+}
+
+// concurrentArrayFromJSON creates a Concurrent::Array from the decoded JSON, which should be an array
+func (t *Thread) concurrentArrayFromJSON(decoded Object, sourceLine int) Object {
+	switch d := decoded.(type) {
+	case *Error:
+		return d
+	case *ArrayObject:
+		return t.vm.initConcurrentArrayObject(d.Elements)
+	default:
+		return t.vm.InitErrorObject(errors.TypeError, sourceLine, unexpectedJSON, "an array", jsonKind(decoded))
+	}
+}
+
+// removeElementsIf implements `delete_if` and `keep_if`. It calls the block with each element of a snapshot,
+// and removes the elements whose result's truthiness is removeOn under the write lock.
+// The elements are removed by identity, so the ones pushed or removed by other threads while the block runs stay that way.
+func (t *Thread) removeElementsIf(cao *ConcurrentArrayObject, args []Object, blockFrame *normalCallFrame, removeOn bool, sourceLine int) Object {
+	if len(args) != 0 {
+		return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+	}
+
+	if blockFrame == nil {
+		return t.vm.InitErrorObject(errors.InternalError, sourceLine, errors.CantYieldWithoutBlockFormat)
+	}
+
+	elems := cao.snapshot()
+	removed := map[Object]int{}
+
+	if blockIsEmpty(blockFrame) {
+		// The block returns nil, which is falsy
+		if !removeOn {
+			for _, e := range elems {
+				removed[e]++
+			}
+		}
+	} else {
+		for _, e := range elems {
+			result, erred := t.builtinMethodYield(blockFrame, e)
+
+			if erred {
+				return result
+			}
+
+			if blockFrame.IsRemoved() {
+				return NULL
+			}
+
+			if result.isTruthy() == removeOn {
+				removed[e]++
+			}
+		}
+	}
+
+	if len(removed) == 0 {
+		return cao
+	}
+
+	cao.Lock()
+	defer cao.Unlock()
+
+	cao.InternalArray.keepIf(func(e Object) bool {
+		if removed[e] > 0 {
+			removed[e]--
+			return false
+		}
+
+		return true
+	})
+
+	return cao
+}
+
+// splitRuns implements `chunk_while` and `slice_when`. It calls the block with each pair of adjacent elements,
+// and starts a new run between them when the truthiness of the block's result is splitOn.
+// It returns the error instead if the block raises one.
+func (t *Thread) splitRuns(blockFrame *normalCallFrame, elems []Object, splitOn bool) ([]Object, Object) {
+	if len(elems) == 0 {
+		return []Object{}, nil
+	}
+
+	var runs []Object
+	run := []Object{elems[0]}
+
+	for i := 1; i < len(elems); i++ {
+		result, erred := t.builtinMethodYield(blockFrame, elems[i-1], elems[i])
+
+		if erred {
+			return nil, result
+		}
+
+		if result.isTruthy() == splitOn {
+			runs = append(runs, t.vm.InitArrayObject(run))
+			run = []Object{}
+		}
+
+		run = append(run, elems[i])
+	}
+
+	return append(runs, t.vm.InitArrayObject(run)), nil
+}
+
+// sumObjects adds the elements to the initial value one by one with `+`, and returns the sum or the error `+` raises
+func (t *Thread) sumObjects(init Object, elems []Object, sourceLine int) Object {
+	sum := init
+
+	for _, elem := range elems {
+		sum = t.callMethod(sum, "+", sourceLine, elem)
+
+		if err, ok := sum.(*Error); ok {
+			return err
+		}
+	}
+
+	return sum
+}
+
+// concatenateArrays appends the elements of the arrays to the initial elements, and returns them as a concurrent array.
+// It's a TypeError if one of the arrays isn't an array.
+func (t *Thread) concatenateArrays(init []Object, arrays []Object, sourceLine int) Object {
+	for _, arr := range arrays {
+		switch a := arr.(type) {
+		case *ArrayObject:
+			init = append(init, a.Elements...)
+		case *ConcurrentArrayObject:
+			init = append(init, a.snapshot()...)
+		default:
+			return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.ArrayClass, arr.Class().Name)
+		}
+	}
+
+	return t.vm.initConcurrentArrayObject(init)
+}
+
+// eachProduct calls fn with every combination of the lists' elements, in lexicographic order of the lists' indexes.
+// Each tuple is a new slice, and nothing is called if any list is empty. It stops when fn returns false.
+func eachProduct(lists [][]Object, fn func(tuple []Object) bool) {
+	for _, list := range lists {
+		if len(list) == 0 {
+			return
+		}
+	}
+
+	indexes := make([]int, len(lists))
+
+	for {
+		tuple := make([]Object, len(lists))
+
+		for i, list := range lists {
+			tuple[i] = list[indexes[i]]
+		}
+
+		if !fn(tuple) {
+			return
+		}
+
+		// Advance the indexes like an odometer, the last list changes fastest
+		i := len(indexes) - 1
+
+		for ; i >= 0; i-- {
+			indexes[i]++
+
+			if indexes[i] < len(lists[i]) {
+				break
+			}
+
+			indexes[i] = 0
+		}
+
+		if i < 0 {
+			return
+		}
+	}
+}
+
 // DefineForwardedConcurrentArrayMethod defines methods for ConcurrentArrayObject
 func DefineForwardedConcurrentArrayMethod(methodName string, requireWriteLock bool) *BuiltinMethodObject {
 	return &BuiltinMethodObject{
@@ -170,6 +906,11 @@ func DefineForwardedConcurrentArrayMethod(methodName string, requireWriteLock bo
 				concurrentArray.RUnlock()
 			}
 
+			// A destructive method returning the array itself, like `fill`, returns the receiver
+			if result == concurrentArray.InternalArray {
+				return concurrentArray
+			}
+
 			switch result.(type) {
 			case *ArrayObject:
 				return t.vm.initConcurrentArrayObject(result.(*ArrayObject).Elements)
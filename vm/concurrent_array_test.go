@@ -1,6 +1,10 @@
 package vm
 
 import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -53,6 +57,10 @@ func TestConcurrentArrayIndex(t *testing.T) {
 		`, 10},
 		{`
 		require 'concurrent/array'
+		Concurrent::Array.new([1, "a", 10, "b"])[-5]
+		`, nil},
+		{`
+		require 'concurrent/array'
 		a = Concurrent::Array.new([1, "a", 10, 5])
 		a[0]
 		`, 1},
@@ -98,8 +106,8 @@ func TestConcurrentArrayIndex(t *testing.T) {
 		code[2] = 'Continue'
 		code[3] = 'Switching Protocols'
 		code[5] = 'OK'
-		code.to_s
-		`, `[nil, nil, "Continue", "Switching Protocols", nil, "OK"]`},
+		code
+		`, canonical(`[nil, nil, "Continue", "Switching Protocols", nil, "OK"]`)},
 	}
 
 	for i, tt := range tests {
@@ -113,10 +121,6 @@ func TestConcurrentArrayIndex(t *testing.T) {
 
 func TestConcurrentArrayIndexFail(t *testing.T) {
 	testsFail := []errorTestCase{
-		{`
-		require 'concurrent/array'
-		Concurrent::Array.new([1, "a", 10, "b"])[-5]
-		`, "ArgumentError: Index value -5 too small for array. minimum: -4", 1},
 		{`
 		require 'concurrent/array'
 		Concurrent::Array.new([1, "a", 10, "b"], 1)[-5]
@@ -288,6 +292,46 @@ func TestConcurrentArrayAnyMethodFail(t *testing.T) {
 	}
 }
 
+func TestConcurrentArrayAssocMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new([["a", 1], ["b", 2], ["b", 3]])
+		r = a.assoc("b")
+		[r[0], r[1]]
+		`, []interface{}{"b", 2}},
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new([["a", 1], ["b", 2]])
+		a.assoc("c")
+		`, nil},
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new([["a", 1], ["b", 2]])
+		a.assoc("b").class.name
+		`, "Array"},
+		// the returned pair is a snapshot
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new([["a", 1], ["b", 2]])
+		r = a.assoc("b")
+		r[1] = 3
+		a.assoc("b")[1]
+		`, 2},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestConcurrentArrayAtMethod(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -315,6 +359,10 @@ func TestConcurrentArrayAtMethod(t *testing.T) {
 		`, 10},
 		{`
 		require 'concurrent/array'
+		Concurrent::Array.new([1, "a", 10, 5]).at(-5)
+		`, nil},
+		{`
+		require 'concurrent/array'
 		a = Concurrent::Array.new([1, "a", 10, 5])
 		a.at(0)
 		`, 1},
@@ -355,9 +403,133 @@ func TestConcurrentArrayAtMethodFail(t *testing.T) {
 		{`
 		require 'concurrent/array'
 		Concurrent::Array.new([1, 2, 3]).at(1..3)`, "TypeError: Expect argument to be Integer. got: Range", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentArrayBsearchMethods(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new([0, 4, 7, 10, 12])
+		[a.bsearch do |x| x >= 5 end, a.bsearch_index do |x| 10 - x end]
+		`, []interface{}{7, 3}},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new.bsearch do |x|
+		  true
+		end
+		`, nil},
+		// insert_sorted returns the receiver
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new([1, 5])
+		r = a.insert_sorted(3)
+		[r.to_s, a.to_s, r.object_id == a.object_id]
+		`, []interface{}{"[1, 3, 5]", "[1, 3, 5]", true}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentArrayBsearchMethodsFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, 2]).bsearch`, "InternalError: Can't yield without a block", 1},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, 2]).insert_sorted("a")`, "TypeError: Expect argument to be Numeric. got: String", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentArrayChunkWhileMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []interface{}
+	}{
+		// ascending runs
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new([1, 2, 4, 9, 10, 11, 12, 15])
+		a.chunk_while do |prev, cur|
+		  prev + 1 == cur
+		end
+		`, []interface{}{
+			[]interface{}{1, 2}, []interface{}{4}, []interface{}{9, 10, 11, 12}, []interface{}{15},
+		}},
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new([1, 2, 3])
+		a.chunk_while do |prev, cur|
+		  true
+		end
+		`, []interface{}{[]interface{}{1, 2, 3}}},
 		{`
 		require 'concurrent/array'
-		Concurrent::Array.new([1, "a", 10, 5]).at(-5)`, "ArgumentError: Index value -5 too small for array. minimum: -4", 1},
+		Concurrent::Array.new([1]).chunk_while do |prev, cur|
+		  false
+		end
+		`, []interface{}{[]interface{}{1}}},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([]).chunk_while do |prev, cur|
+		  false
+		end
+		`, []interface{}{}},
+		// the block works on a snapshot, so it can modify the receiver
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new([1, 2])
+		a.chunk_while do |prev, cur|
+		  a.push(cur)
+		  false
+		end
+		a
+		`, []interface{}{1, 2, 2}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		verifyConcurrentArrayObject(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentArrayChunkWhileMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, 2]).chunk_while(1) do end`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, 2]).chunk_while`, "InternalError: Can't yield without a block", 1},
 	}
 
 	for i, tt := range testsFail {
@@ -414,6 +586,64 @@ func TestConcurrentArrayClearMethodFail(t *testing.T) {
 	}
 }
 
+func TestConcurrentArrayCompactMethods(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new([1, nil, 2, nil])
+		b = a.compact
+		[a.to_s, b.to_s, b.class.name]
+		`, []interface{}{"[1, nil, 2, nil]", "[1, 2]", "Array"}},
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new([1, nil, 2, nil])
+		b = a.compact!
+		[a.to_s, b.object_id == a.object_id]
+		`, []interface{}{"[1, 2]", true}},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, 2]).compact!
+		`, nil},
+		// compact! doesn't lose the elements other threads push meanwhile
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new
+		c = Channel.new
+
+		thread do
+		  i = 0
+		  while i < 200 do
+		    a.push(i)
+		    a.push(nil)
+		    i += 1
+		  end
+		  c.deliver(nil)
+		end
+
+		j = 0
+		while j < 100 do
+		  a.compact!
+		  j += 1
+		end
+
+		c.receive
+		a.compact!
+		a.to_s == (0..199).to_a.to_s
+		`, true},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestConcurrentArrayConcatMethod(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -547,35 +777,43 @@ func TestConcurrentArrayCountMethodFail(t *testing.T) {
 	}
 }
 
-func TestConcurrentArrayDeleteAtMethod(t *testing.T) {
+func TestConcurrentArrayCountByMethod(t *testing.T) {
 	tests := []struct {
 		input    string
 		expected interface{}
 	}{
 		{`
 		require 'concurrent/array'
-		Concurrent::Array.new([]).delete_at(1)
-		`, nil},
-		{`
-		require 'concurrent/array'
-		Concurrent::Array.new([1, 2, 10, 5]).delete_at(2)
-		`, 10},
-		{`
-		require 'concurrent/array'
-		Concurrent::Array.new([1, "a", 10, 5]).delete_at(1)
-		`, "a"},
+		a = Concurrent::Array.new(["apple", "fig", "kiwi", "pear", "plum"])
+		counts = a.count_by do |word|
+		  word.length
+		end
+		[counts["3"], counts["4"], counts["5"], counts.length]
+		`, []interface{}{1, 3, 1, 3}},
 		{`
 		require 'concurrent/array'
-		Concurrent::Array.new([1, "a", 10, 5]).delete_at(4)
-		`, nil},
+		a = Concurrent::Array.new([1, 2, 3, 4, 5])
+		counts = a.count_by do |i|
+		  i.odd?
+		end
+		[counts["true"], counts["false"]]
+		`, []interface{}{3, 2}},
 		{`
 		require 'concurrent/array'
-		Concurrent::Array.new([1, "a", 10, 5]).delete_at(-2)
-		`, 10},
+		Concurrent::Array.new([]).count_by do |i|
+		  i
+		end.length
+		`, 0},
+		// the block can modify the array
 		{`
 		require 'concurrent/array'
-		Concurrent::Array.new([1, "a", 10, 5]).delete_at(-5)
-		`, nil},
+		a = Concurrent::Array.new([1, 2])
+		counts = a.count_by do |i|
+		  a.push(i)
+		  i
+		end
+		[counts.length, a.length]
+		`, []interface{}{2, 4}},
 	}
 
 	for i, tt := range tests {
@@ -585,61 +823,24 @@ func TestConcurrentArrayDeleteAtMethod(t *testing.T) {
 		v.checkCFP(t, i, 0)
 		v.checkSP(t, i, 1)
 	}
-
-	testsArray := []struct {
-		input    string
-		expected []interface{}
-	}{
-		{`
-		require 'concurrent/array'
-		a = Concurrent::Array.new([1, 2, 10, 5])
-		a.delete_at(2)
-		a
-
-		`, []interface{}{1, 2, 5}},
-		{`
-		require 'concurrent/array'
-		a = Concurrent::Array.new([1, "a", 10, 5])
-		a.delete_at(4)
-		a
-		`, []interface{}{1, "a", 10, 5}},
-		{`
-		require 'concurrent/array'
-		a = Concurrent::Array.new([1, "a", 10, 5])
-		a.delete_at(-2)
-		a
-		`, []interface{}{1, "a", 5}},
-		{`
-		require 'concurrent/array'
-		a = Concurrent::Array.new([1, "a", 10, 5])
-		a.delete_at(-5)
-		a
-		`, []interface{}{1, "a", 10, 5}},
-	}
-
-	for i, tt := range testsArray {
-		vm := initTestVM()
-		evaluated := vm.testEval(t, tt.input, getFilename())
-		verifyConcurrentArrayObject(t, i, evaluated, tt.expected)
-		vm.checkCFP(t, i, 0)
-		vm.checkSP(t, i, 1)
-	}
 }
 
-func TestConcurrentArrayDeleteAtMethodFail(t *testing.T) {
+func TestConcurrentArrayCountByMethodFail(t *testing.T) {
 	testsFail := []errorTestCase{
 		{`
 		require 'concurrent/array'
-		Concurrent::Array.new([1, 2, 3]).delete_at`, "ArgumentError: Expect 1 argument(s). got: 0", 1},
-		{`
-		require 'concurrent/array'
-		Concurrent::Array.new([1, 2, 3]).delete_at(2, 3)`, "ArgumentError: Expect 1 argument(s). got: 2", 1},
+		Concurrent::Array.new([1, 2]).count_by(1) do |i| i end
+		`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
 		{`
 		require 'concurrent/array'
-		Concurrent::Array.new([1, 2, 3]).delete_at(true)`, "TypeError: Expect argument to be Integer. got: Boolean", 1},
+		Concurrent::Array.new([1, 2]).count_by
+		`, "InternalError: Can't yield without a block", 1},
 		{`
 		require 'concurrent/array'
-		Concurrent::Array.new([1, 2, 3]).delete_at(1..3)`, "TypeError: Expect argument to be Integer. got: Range", 1},
+		Concurrent::Array.new([1, 2]).count_by do |i|
+		  i.foo
+		end
+		`, "NoMethodError: Undefined Method 'foo' for 1", 1},
 	}
 
 	for i, tt := range testsFail {
@@ -651,47 +852,294 @@ func TestConcurrentArrayDeleteAtMethodFail(t *testing.T) {
 	}
 }
 
-func TestConcurrentArrayEachMethod(t *testing.T) {
+func TestConcurrentArrayDeleteIfAndKeepIfMethods(t *testing.T) {
 	tests := []struct {
 		input    string
-		expected int
+		expected interface{}
 	}{
 		{`
 		require 'concurrent/array'
-		sum = 0
-		Concurrent::Array.new([1, 2, 3, 4, 5]).each do |i|
-			sum = sum + i
+		a = Concurrent::Array.new([1, 2, 3, 4, 5])
+		b = a.delete_if do |i|
+		  i.even?
 		end
-		sum
-		`, 15},
+		[a.to_s, b.object_id == a.object_id]
+		`, []interface{}{"[1, 3, 5]", true}},
 		{`
 		require 'concurrent/array'
-		sum = 0
-		Concurrent::Array.new([]).each do |i|
-			sum += i
+		a = Concurrent::Array.new([1, 2, 3, 4, 5])
+		b = a.keep_if do |i|
+		  i.even?
 		end
-		sum
-		`, 0},
-	}
-
-	for i, tt := range tests {
-		v := initTestVM()
-		evaluated := v.testEval(t, tt.input, getFilename())
-		VerifyExpected(t, i, evaluated, tt.expected)
-		v.checkCFP(t, i, 0)
-		v.checkSP(t, i, 1)
-	}
-}
-
-func TestConcurrentArrayEachMethodFail(t *testing.T) {
-	testsFail := []errorTestCase{
+		[a.to_s, b.object_id == a.object_id]
+		`, []interface{}{"[2, 4]", true}},
 		{`
 		require 'concurrent/array'
-		Concurrent::Array.new(['M', 'A', 'X', 'W', 'E', 'L', 'L']).each`, "InternalError: Can't yield without a block", 1},
+		a = Concurrent::Array.new(["a", nil, "a", false])
+		a.delete_if do |e|
+		  e == "a"
+		end
+		a.to_s
+		`, "[nil, false]"},
 		{`
 		require 'concurrent/array'
-		Concurrent::Array.new(['T', 'A', 'I', 'P', 'E', 'I']).each(101) do |char|
-			puts char
+		a = Concurrent::Array.new([])
+		a.delete_if do |i|
+		  true
+		end
+		a.keep_if do |i|
+		  false
+		end
+		a.length
+		`, 0},
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new([1, 2])
+		a.delete_if do end
+		a.length
+		`, 2},
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new([1, 2])
+		a.keep_if do end
+		a.length
+		`, 0},
+		// the block can modify the array, and the elements it pushes are kept
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new([1, 2, 3])
+		a.delete_if do |i|
+		  a.push(i * 10)
+		  i > 1
+		end
+		a.to_s
+		`, "[1, 10, 20, 30]"},
+		// the elements other threads push meanwhile are kept
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new
+		c = Channel.new
+
+		thread do
+		  i = 0
+		  while i < 200 do
+		    a.push(i)
+		    a.push(-1)
+		    i += 1
+		  end
+		  c.deliver(nil)
+		end
+
+		j = 0
+		while j < 50 do
+		  a.delete_if do |i|
+		    i < 0
+		  end
+		  a.keep_if do |i|
+		    i >= 0
+		  end
+		  j += 1
+		end
+
+		c.receive
+		a.delete_if do |i|
+		  i < 0
+		end
+		a.to_s == (0..199).to_a.to_s
+		`, true},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentArrayDeleteIfAndKeepIfMethodsFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, 2]).delete_if(1) do |i| i end
+		`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, 2]).delete_if
+		`, "InternalError: Can't yield without a block", 1},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, 2]).keep_if
+		`, "InternalError: Can't yield without a block", 1},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, 2]).keep_if do |i|
+		  i.foo
+		end
+		`, "NoMethodError: Undefined Method 'foo' for 1", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentArrayDeleteAtMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([]).delete_at(1)
+		`, nil},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, 2, 10, 5]).delete_at(2)
+		`, 10},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, "a", 10, 5]).delete_at(1)
+		`, "a"},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, "a", 10, 5]).delete_at(4)
+		`, nil},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, "a", 10, 5]).delete_at(-2)
+		`, 10},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, "a", 10, 5]).delete_at(-5)
+		`, nil},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+
+	testsArray := []struct {
+		input    string
+		expected []interface{}
+	}{
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new([1, 2, 10, 5])
+		a.delete_at(2)
+		a
+
+		`, []interface{}{1, 2, 5}},
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new([1, "a", 10, 5])
+		a.delete_at(4)
+		a
+		`, []interface{}{1, "a", 10, 5}},
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new([1, "a", 10, 5])
+		a.delete_at(-2)
+		a
+		`, []interface{}{1, "a", 5}},
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new([1, "a", 10, 5])
+		a.delete_at(-5)
+		a
+		`, []interface{}{1, "a", 10, 5}},
+	}
+
+	for i, tt := range testsArray {
+		vm := initTestVM()
+		evaluated := vm.testEval(t, tt.input, getFilename())
+		verifyConcurrentArrayObject(t, i, evaluated, tt.expected)
+		vm.checkCFP(t, i, 0)
+		vm.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentArrayDeleteAtMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, 2, 3]).delete_at`, "ArgumentError: Expect 1 argument(s). got: 0", 1},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, 2, 3]).delete_at(2, 3)`, "ArgumentError: Expect 1 argument(s). got: 2", 1},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, 2, 3]).delete_at(true)`, "TypeError: Expect argument to be Integer. got: Boolean", 1},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, 2, 3]).delete_at(1..3)`, "TypeError: Expect argument to be Integer. got: Range", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentArrayEachMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{`
+		require 'concurrent/array'
+		sum = 0
+		Concurrent::Array.new([1, 2, 3, 4, 5]).each do |i|
+			sum = sum + i
+		end
+		sum
+		`, 15},
+		{`
+		require 'concurrent/array'
+		sum = 0
+		Concurrent::Array.new([]).each do |i|
+			sum += i
+		end
+		sum
+		`, 0},
+		// without a block, the enumerator iterates a snapshot
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new([1, 2, 3])
+		e = a.each
+		a.push(4)
+		a.delete_at(0)
+		e.next + e.to_a.length
+		`, 4},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentArrayEachMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new(['T', 'A', 'I', 'P', 'E', 'I']).each(101) do |char|
+			puts char
 		end
 		`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
 	}
@@ -759,6 +1207,67 @@ func TestConcurrentArrayEachIndexMethodFail(t *testing.T) {
 	}
 }
 
+func TestConcurrentArrayEachWithObjectMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new(["apple", "fig", "kiwi"])
+		h = a.each_with_object({}) do |word, lengths|
+		  lengths[word] = word.length
+		end
+		[h["apple"], h["fig"], h["kiwi"], h.length]
+		`, []interface{}{5, 3, 4, 3}},
+		// like the other arrays it returns, the memo comes back as a concurrent copy
+		{`
+		require 'concurrent/array'
+		memo = []
+		r = Concurrent::Array.new([1, 2, 3, 4]).each_with_object(memo) do |n, evens|
+		  if n.even?
+		    evens.push(n * 10)
+		  end
+		end
+		[memo, r.to_s]
+		`, []interface{}{[]interface{}{20, 40}, "[20, 40]"}},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new.each_with_object({ a: 1 }) do |n, m|
+		end["a"]
+		`, 1},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentArrayEachWithObjectMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, 2]).each_with_object({})`, "InternalError: Can't yield without a block", 1},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, 2]).each_with_object do |n, m|
+		end
+		`, "ArgumentError: Expect 1 argument(s). got: 0", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestConcurrentArrayEmptyMethod(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -811,6 +1320,78 @@ func TestConcurrentArrayEmptyMethodFail(t *testing.T) {
 	}
 }
 
+func TestConcurrentArrayFillMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new([1, 2, 3])
+		b = a.fill(0)
+		[a.to_s, b.object_id == a.object_id]
+		`, []interface{}{"[0, 0, 0]", true}},
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new([1, 2, 3, 4])
+		a.fill(9, 1, 2)
+		a.to_s
+		`, "[1, 9, 9, 4]"},
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new([1, 2])
+		a.fill(9, 3, 1)
+		a.to_s
+		`, "[1, 2, nil, 9]"},
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new([1, 2, 3])
+		a.fill do |i|
+		  i * 10
+		end
+		a.to_s
+		`, "[0, 10, 20]"},
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new([1, 2, 3])
+		a.fill(-1) do |i|
+		  i
+		end
+		a.to_s
+		`, "[1, 2, 2]"},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentArrayFillMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, 2]).fill`, "ArgumentError: Expect 1 to 3 argument(s). got: 0", 1},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, 2]).fill(1, "2")`, "TypeError: Expect argument #2 to be Integer. got: String", 1},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, 2]).fill(1, 1, 2147483647)`, "ArgumentError: Too big to fill: more than 2147483647 elements", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestConcurrentArrayFirstMethod(t *testing.T) {
 	testsInt := []struct {
 		input    string
@@ -1151,6 +1732,122 @@ func TestConcurrentArrayMapMethod(t *testing.T) {
 	}
 }
 
+func TestConcurrentArrayMinAndMaxMethods(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([3, 8, 1, 5]).min
+		`, 1},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([3, 8, 1, 5]).max
+		`, 8},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new(["b", "c", "a"]).max
+		`, "c"},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([2, 0.5, 1]).min
+		`, 0.5},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([]).min
+		`, nil},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([]).max
+		`, nil},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentArrayMinAndMaxMethodsWithCount(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []interface{}
+	}{
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([7, 3, 9, 1, 5]).min(2)
+		`, []interface{}{1, 3}},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([7, 3, 9, 1, 5]).max(3)
+		`, []interface{}{9, 7, 5}},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([7, 3, 9]).min(10)
+		`, []interface{}{3, 7, 9}},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([7, 3, 9]).max(10)
+		`, []interface{}{9, 7, 3}},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([7, 3, 9]).max(0)
+		`, []interface{}{}},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([]).min(2)
+		`, []interface{}{}},
+		// the receiver isn't sorted
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new([2, 1])
+		a.min(2)
+		a
+		`, []interface{}{2, 1}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		verifyConcurrentArrayObject(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentArrayMinAndMaxMethodsFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, 2]).min(1, 2)
+		`, "ArgumentError: Expect 1 or less argument(s). got: 2", 1},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, 2]).max("1")
+		`, "TypeError: Expect argument to be Integer. got: String", 1},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, 2]).max(-1)
+		`, "ArgumentError: Expect argument to be positive value. got: -1", 1},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, "a"]).min(1)
+		`, "ArgumentError: comparison of Integer with String failed", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestConcurrentArrayPlusMethod(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -1230,6 +1927,82 @@ func TestConcurrentArrayPopMethod(t *testing.T) {
 	}
 }
 
+func TestConcurrentArrayProductMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []interface{}
+	}{
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new([1, 2])
+		a.product(["a", "b", "c"])
+		`, []interface{}{
+			[]interface{}{1, "a"}, []interface{}{1, "b"}, []interface{}{1, "c"},
+			[]interface{}{2, "a"}, []interface{}{2, "b"}, []interface{}{2, "c"},
+		}},
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new([1, 2])
+		a.product(Concurrent::Array.new([3]), [4, 5])
+		`, []interface{}{
+			[]interface{}{1, 3, 4}, []interface{}{1, 3, 5}, []interface{}{2, 3, 4}, []interface{}{2, 3, 5},
+		}},
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new([1, 2])
+		a.product(a)
+		`, []interface{}{
+			[]interface{}{1, 1}, []interface{}{1, 2}, []interface{}{2, 1}, []interface{}{2, 2},
+		}},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, 2]).product
+		`, []interface{}{[]interface{}{1}, []interface{}{2}}},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, 2]).product([])
+		`, []interface{}{}},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([]).product([1, 2])
+		`, []interface{}{}},
+		{`
+		require 'concurrent/array'
+		r = []
+		a = Concurrent::Array.new([1, 2])
+		a.product([3, 4]) do |pair|
+		  r.push(pair[0] * pair[1])
+		end
+		a.push(r)
+		`, []interface{}{1, 2, []interface{}{3, 4, 6, 8}}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		verifyConcurrentArrayObject(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentArrayProductMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, 2]).product([3], 4)
+		`, "TypeError: Expect argument #2 to be Array. got: Integer", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestConcurrentArrayPushMethod(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -1272,6 +2045,33 @@ func TestConcurrentArrayPushMethod(t *testing.T) {
 	}
 }
 
+func TestConcurrentArrayRassocMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new([["a", 1], ["b", 2], ["c", 2]])
+		r = a.rassoc(2)
+		[r[0], r[1]]
+		`, []interface{}{"b", 2}},
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new([["a", 1], ["b", 2]])
+		a.rassoc(3)
+		`, nil},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestConcurrentArrayReduceMethod(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -1447,7 +2247,56 @@ func TestConcurrentArrayRotateMethod(t *testing.T) {
 	for i, tt := range tests {
 		v := initTestVM()
 		evaluated := v.testEval(t, tt.input, getFilename())
-		verifyConcurrentArrayObject(t, i, evaluated, tt.expected)
+		verifyConcurrentArrayObject(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentArrayRotateConcurrently(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new([1, 2, 3])
+		a.push(4)
+		b = a.rotate(-1)
+		a.push(5)
+		[a.to_s, b.to_s, b.class.name]
+		`, []interface{}{"[1, 2, 3, 4, 5]", "[4, 1, 2, 3]", "Array"}},
+		// rotating only reads the receiver, so concurrent readers always see it unchanged
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new([1, 2, 3])
+		a.push(4)
+		c = Channel.new
+
+		i = 0
+		while i < 4 do
+		  thread do
+		    ok = true
+		    j = 0
+		    while j < 100 do
+		      if a.rotate(j).to_s != [1, 2, 3, 4].rotate(j).to_s || a.to_s != "[1, 2, 3, 4]"
+		        ok = false
+		      end
+		      j += 1
+		    end
+		    c.deliver(ok)
+		  end
+		  i += 1
+		end
+
+		[c.receive, c.receive, c.receive, c.receive, a.to_s]
+		`, []interface{}{true, true, true, true, "[1, 2, 3, 4]"}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
 		v.checkCFP(t, i, 0)
 		v.checkSP(t, i, 1)
 	}
@@ -1567,6 +2416,76 @@ func TestConcurrentArrayShiftMethodFail(t *testing.T) {
 	}
 }
 
+func TestConcurrentArraySliceWhenMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []interface{}
+	}{
+		// runs separated by a gap
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new([1, 2, 6, 7, 15, 16])
+		a.slice_when do |prev, cur|
+		  cur - prev > 3
+		end
+		`, []interface{}{
+			[]interface{}{1, 2}, []interface{}{6, 7}, []interface{}{15, 16},
+		}},
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new(["a", "b"])
+		a.slice_when do |prev, cur|
+		  nil
+		end
+		`, []interface{}{[]interface{}{"a", "b"}}},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, 2, 3]).slice_when do |prev, cur|
+		  true
+		end
+		`, []interface{}{[]interface{}{1}, []interface{}{2}, []interface{}{3}}},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1]).slice_when do |prev, cur|
+		  true
+		end
+		`, []interface{}{[]interface{}{1}}},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([]).slice_when do |prev, cur|
+		  true
+		end
+		`, []interface{}{}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		verifyConcurrentArrayObject(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentArraySliceWhenMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, 2]).slice_when(1) do end`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, 2]).slice_when`, "InternalError: Can't yield without a block", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestConcurrentArrayStarMethod(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -1729,3 +2648,348 @@ func TestConcurrentArrayValuesAtMethodFail(t *testing.T) {
 		v.checkSP(t, i, 1)
 	}
 }
+
+func TestConcurrentArraySnapshotMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new.snapshot
+		`, canonical(`[]`)},
+		{`
+		require 'concurrent/array'
+		require 'concurrent/hash'
+		a = Concurrent::Array.new([true, 1, { b: ["c"] }, Concurrent::Hash.new({ d: 2.5 })])
+		a.push(nil)
+		a.snapshot
+		`, canonical(`[true, 1, {"b": ["c"]}, {"d": 2.5}, nil]`)},
+		// the snapshot doesn't share anything with the receiver
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new([[1], "x"])
+		s = a.snapshot
+		a[0].push(2)
+		a[1].concat("y")
+		a.push(3)
+		s
+		`, canonical(`[[1], "x"]`)},
+		// the whole array is copied at a single point in time, while another thread keeps pushing to it
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new
+		c = Channel.new
+
+		thread do
+		  i = 0
+		  while i < 200 do
+		    a.push(i)
+		    i += 1
+		  end
+		  c.deliver(nil)
+		end
+
+		consistent = true
+		j = 0
+		while j < 200 do
+		  s = a.snapshot
+		  s.each_index do |k|
+		    if s[k] != k
+		      consistent = false
+		    end
+		  end
+		  j += 1
+		end
+
+		c.receive
+		consistent
+		`, true},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentArraySnapshotMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1]).snapshot(1)`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, { a: [Channel.new] }]).snapshot`, "TypeError: Can't snapshot Channel at [1][\"a\"][0]: only nil, Boolean, Integer, Float, String, Array and Hash values are supported", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentArraySumMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, 2, 3]).sum
+		`, 6},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, 2, 3]).sum(10)
+		`, 16},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([0.5, 1]).sum
+		`, 1.5},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new.sum
+		`, 0},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new(["a", "b"]).sum("")
+		`, "ab"},
+		// the initial array isn't modified
+		{`
+		require 'concurrent/array'
+		init = [0]
+		Concurrent::Array.new([[1]]).sum(init)
+		init
+		`, []interface{}{0}},
+		// the sum is taken from a snapshot, while another thread keeps pushing to the array
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new
+		c = Channel.new
+
+		thread do
+		  i = 0
+		  while i < 200 do
+		    a.push(1)
+		    i += 1
+		  end
+		  c.deliver(nil)
+		end
+
+		consistent = true
+		j = 0
+		while j < 100 do
+		  s = a.sum
+		  if s > 200
+		    consistent = false
+		  end
+		  j += 1
+		end
+
+		c.receive
+		[consistent, a.sum]
+		`, []interface{}{true, 200}},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, 2, 3]).sum do |i|
+		  i * 2
+		end
+		`, 12},
+		{`
+		require 'concurrent/array'
+		init = 0.5
+		Concurrent::Array.new([1, 2]).sum(init) do |i|
+		  i * 10
+		end
+		`, 30.5},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new.sum do |i|
+		  i * 2
+		end
+		`, 0},
+		// the block can modify the array
+		{`
+		require 'concurrent/array'
+		a = Concurrent::Array.new([1, 2])
+		s = a.sum do |i|
+		  a.push(i)
+		  i
+		end
+		[s, a.length]
+		`, []interface{}{3, 4}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+// With an initial array, the arrays are concatenated into a concurrent array
+func TestConcurrentArraySumMethodWithArrays(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []interface{}
+	}{
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([[1, 2], [3], []]).sum([])
+		`, []interface{}{1, 2, 3}},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([[2], Concurrent::Array.new([3])]).sum([1])
+		`, []interface{}{1, 2, 3}},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new.sum([])
+		`, []interface{}{}},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, 2]).sum([]) do |i|
+		  [i, i]
+		end
+		`, []interface{}{1, 1, 2, 2}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		verifyConcurrentArrayObject(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentArraySumMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1, "a"]).sum`, "TypeError: Expect argument to be Numeric. got: String", 1},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([[1], 2]).sum([])`, "TypeError: Expect argument to be Array. got: Integer", 1},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([[1]]).sum`, "TypeError: Expect argument to be Numeric. got: Array", 1},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1]).sum(0, 1)`, "ArgumentError: Expect 1 or less argument(s). got: 2", 1},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([1]).sum do |i|
+		  "a"
+		end`, "TypeError: Expect argument to be Numeric. got: String", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentArrayFromJSONMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.from_json('[]')
+		`, canonical(`Concurrent::Array[]`)},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.from_json('[1, 2.0, 3e2, "a", true, null, [1], {"b": 2}]')
+		`, canonical(`Concurrent::Array[1, 2.0, 300.0, "a", true, nil, [1], {"b": 2}]`)},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentArrayFromJSONMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.from_json('{}')`, "TypeError: Expect the JSON to be an array. got: an object", 1},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.from_json('[1,')`, "ArgumentError: Can't parse string `[1,` as json: unexpected EOF", 1},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.from_json(nil)`, "TypeError: Expect argument to be String. got: Null", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentArrayPersistAndLoadMethods(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goby_concurrent_array")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "jobs.json")
+
+	input := fmt.Sprintf(`
+	require 'concurrent/array'
+	a = Concurrent::Array.new([1, "two", { three: [3] }])
+	a.persist("%[1]s")
+	a.push(4)
+	Concurrent::Array.load("%[1]s")
+	`, path)
+
+	v := initTestVM()
+	evaluated := v.testEval(t, input, getFilename())
+	VerifyExpected(t, 0, evaluated, canonical(`Concurrent::Array[1, "two", {"three": [3]}]`))
+	v.checkCFP(t, 0, 0)
+	v.checkSP(t, 0, 1)
+}
+
+func TestConcurrentArrayPersistAndLoadMethodsFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new([Channel.new]).persist("/tmp/never_written.json")`, "TypeError: Can't snapshot Channel at [0]: only nil, Boolean, Integer, Float, String, Array and Hash values are supported", 1},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.new.persist("/goby_no_such_dir/jobs.json")`, "IOError: Can't persist to /goby_no_such_dir/jobs.json: no such file or directory", 1},
+		{`
+		require 'concurrent/array'
+		Concurrent::Array.load(1)`, "TypeError: Expect argument to be String. got: Integer", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
@@ -0,0 +1,406 @@
+package vm
+
+import (
+	"time"
+
+	"github.com/goby-lang/goby/vm/errors"
+)
+
+// ConcurrentFutureObject represents the result of an asynchronous computation.
+//
+// `Future.execute` runs the given block on a separate thread and returns a future right away.
+// The caller is never blocked until it asks for the value.
+// Futures can be chained with `#then`, and combined with `Future.zip`.
+//
+// If the block raises an error, the future is "rejected": `#value` returns `nil` and `#reason`
+// returns the error. A rejected future short-circuits its `#then` chain,
+// and every chained future is rejected with the very same error.
+//
+// ```ruby
+// require 'concurrent/future'
+//
+// f = Concurrent::Future.execute do
+//   40
+// end
+//
+// f.then do |v|
+//   v + 2
+// end.value(1) # => 42
+// ```
+//
+type ConcurrentFutureObject struct {
+	*BaseObj
+	done   chan struct{}
+	value  Object
+	reason *Error
+}
+
+// Class methods --------------------------------------------------------
+var builtinConcurrentFutureClassMethods = []*BuiltinMethodObject{
+	{
+		// Runs the given block on a separate thread and returns a future of its result.
+		//
+		// ```ruby
+		// f = Concurrent::Future.execute do
+		//   1 + 1
+		// end
+		// f.value # => 2
+		// ```
+		//
+		// @param block literal
+		// @return [Future]
+		Name: "execute",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			if blockFrame == nil {
+				return t.vm.InitErrorObject(errors.InternalError, sourceLine, errors.CantYieldWithoutBlockFormat)
+			}
+
+			f := t.vm.initConcurrentFutureObject()
+			block := t.vm.initBlockObject(blockFrame.instructionSet, blockFrame.ep, blockFrame.self, blockFrame.sourceLine)
+
+			go f.execute(t.vm, block)
+
+			return f
+
+		},
+	},
+	{
+		// Returns a future which completes when all the given futures complete.
+		// Its value is an Array of the futures' values, in the order they were given.
+		// If any of the futures is rejected, the returned future is rejected with the first error.
+		//
+		// ```ruby
+		// f1 = Concurrent::Future.execute do
+		//   sleep(1)
+		//   1
+		// end
+		// f2 = Concurrent::Future.execute do
+		//   2
+		// end
+		// Concurrent::Future.zip(f1, f2).value # => [1, 2]
+		// ```
+		//
+		// @param futures [Future]...
+		// @return [Future]
+		Name: "zip",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			futures := make([]*ConcurrentFutureObject, len(args))
+
+			for i, arg := range args {
+				f, ok := arg.(*ConcurrentFutureObject)
+
+				if !ok {
+					return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormatNum, i+1, "Future", arg.Class().Name)
+				}
+
+				futures[i] = f
+			}
+
+			zipped := t.vm.initConcurrentFutureObject()
+
+			go zipped.zip(t.vm, futures)
+
+			return zipped
+
+		},
+	},
+}
+
+// Instance methods -----------------------------------------------------
+var builtinConcurrentFutureInstanceMethods = []*BuiltinMethodObject{
+	{
+		// Returns true if the future has completed, either fulfilled or rejected.
+		//
+		// ```ruby
+		// f = Concurrent::Future.execute do
+		//   1
+		// end
+		// f.value
+		// f.complete? # => true
+		// ```
+		//
+		// @return [Boolean]
+		Name: "complete?",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			return toBooleanObject(receiver.(*ConcurrentFutureObject).isComplete())
+
+		},
+	},
+	{
+		// Returns the error of a rejected future, or `nil` if the future is pending or fulfilled.
+		// The error is returned as it is, not raised, so its class and message can be inspected.
+		//
+		// ```ruby
+		// f = Concurrent::Future.execute do
+		//   raise ArgumentError, "boom"
+		// end
+		// f.value
+		// f.reason.class.name # => "ArgumentError"
+		// f.reason.to_s       # => "ArgumentError: \"boom\""
+		// ```
+		//
+		// @return [Error]
+		Name:         "reason",
+		returnsError: true,
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			f := receiver.(*ConcurrentFutureObject)
+
+			if !f.isComplete() || f.reason == nil {
+				return NULL
+			}
+
+			return f.reason
+
+		},
+	},
+	{
+		// Returns true if the future has completed with an error.
+		//
+		// ```ruby
+		// f = Concurrent::Future.execute do
+		//   raise ArgumentError, "boom"
+		// end
+		// f.value
+		// f.rejected? # => true
+		// ```
+		//
+		// @return [Boolean]
+		Name: "rejected?",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			f := receiver.(*ConcurrentFutureObject)
+
+			return toBooleanObject(f.isComplete() && f.reason != nil)
+
+		},
+	},
+	{
+		// Returns a new future which runs the given block with the receiver's value once the receiver is fulfilled.
+		// The call doesn't block; the block runs on a separate thread.
+		// If the receiver is rejected, the block is skipped and the new future is rejected with the same error.
+		//
+		// ```ruby
+		// f = Concurrent::Future.execute do
+		//   1
+		// end
+		// f.then do |v|
+		//   v * 10
+		// end.value # => 10
+		// ```
+		//
+		// @param block literal
+		// @return [Future]
+		Name: "then",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			if blockFrame == nil {
+				return t.vm.InitErrorObject(errors.InternalError, sourceLine, errors.CantYieldWithoutBlockFormat)
+			}
+
+			parent := receiver.(*ConcurrentFutureObject)
+			f := t.vm.initConcurrentFutureObject()
+			block := t.vm.initBlockObject(blockFrame.instructionSet, blockFrame.ep, blockFrame.self, blockFrame.sourceLine)
+
+			go func() {
+				<-parent.done
+
+				if parent.reason != nil {
+					f.reject(parent.reason)
+					return
+				}
+
+				f.execute(t.vm, block, parent.value)
+			}()
+
+			return f
+
+		},
+	},
+	{
+		// Blocks until the future completes and returns its value.
+		// An optional timeout (in seconds) can be given; `nil` is returned if the future doesn't complete in time.
+		// A rejected future's value is `nil`.
+		//
+		// ```ruby
+		// f = Concurrent::Future.execute do
+		//   sleep(1)
+		//   1
+		// end
+		// f.value(0.1) # => nil
+		// f.value      # => 1
+		// ```
+		//
+		// @param timeout [Numeric]
+		// @return [Object]
+		Name: "value",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			aLen := len(args)
+
+			if aLen > 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgumentLess, 1, aLen)
+			}
+
+			f := receiver.(*ConcurrentFutureObject)
+
+			if aLen == 0 {
+				<-f.done
+			} else {
+				timeout, ok := args[0].(Numeric)
+
+				if !ok {
+					return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, "Numeric", args[0].Class().Name)
+				}
+
+				select {
+				case <-f.done:
+				case <-time.After(time.Duration(timeout.floatValue() * float64(time.Second))):
+					return NULL
+				}
+			}
+
+			if f.reason != nil {
+				return NULL
+			}
+
+			return f.value
+
+		},
+	},
+}
+
+// Internal functions ===================================================
+
+// Functions for initialization -----------------------------------------
+
+func (vm *VM) initConcurrentFutureObject() *ConcurrentFutureObject {
+	concurrentModule := vm.loadConstant("Concurrent", true)
+	futureClass := concurrentModule.getClassConstant("Future")
+
+	return &ConcurrentFutureObject{
+		BaseObj: NewBaseObject(futureClass),
+		done:    make(chan struct{}),
+	}
+}
+
+func initConcurrentFutureClass(vm *VM) {
+	concurrentModule := vm.loadConstant("Concurrent", true)
+	futureClass := vm.initializeClass("Future")
+
+	futureClass.setBuiltinMethods(builtinConcurrentFutureInstanceMethods, false)
+	futureClass.setBuiltinMethods(builtinConcurrentFutureClassMethods, true)
+
+	concurrentModule.setClassConstant(futureClass)
+}
+
+// Polymorphic helper functions -----------------------------------------
+
+// Value returns the future's value, which is nil until it's fulfilled
+func (f *ConcurrentFutureObject) Value() interface{} {
+	if !f.isComplete() {
+		return nil
+	}
+
+	return f.value
+}
+
+// ToString returns the object's name as the string format
+func (f *ConcurrentFutureObject) ToString() string {
+	return "#<" + f.class.Name + " >"
+}
+
+// Inspect delegates to ToString
+func (f *ConcurrentFutureObject) Inspect() string {
+	return f.ToString()
+}
+
+// ToJSON just delegates to ToString
+func (f *ConcurrentFutureObject) ToJSON(t *Thread) string {
+	return unsupportedJSON(f)
+}
+
+// Other helper functions -----------------------------------------------
+
+// execute runs the block on a new thread and settles the future with the result.
+// It's meant to be called in its own goroutine.
+func (f *ConcurrentFutureObject) execute(vm *VM, block *BlockObject, args ...Object) {
+	newT := vm.acquireThread()
+
+	c := newNormalCallFrame(block.instructionSet, block.instructionSet.filename, block.sourceLine)
+	c.ep = block.ep
+	c.self = block.self
+	c.isBlock = true
+
+	result, erred := newT.builtinMethodYield(c, args...)
+	vm.releaseThread(newT)
+
+	if erred {
+		f.reject(result.(*Error))
+		return
+	}
+
+	f.fulfill(result)
+}
+
+// zip settles the future once all the given futures complete, or as soon as one of them is rejected.
+func (f *ConcurrentFutureObject) zip(vm *VM, futures []*ConcurrentFutureObject) {
+	settled := make(chan *ConcurrentFutureObject, len(futures))
+
+	for _, future := range futures {
+		go func(future *ConcurrentFutureObject) {
+			<-future.done
+			settled <- future
+		}(future)
+	}
+
+	for range futures {
+		if future := <-settled; future.reason != nil {
+			f.reject(future.reason)
+			return
+		}
+	}
+
+	values := make([]Object, len(futures))
+
+	for i, future := range futures {
+		values[i] = future.value
+	}
+
+	f.fulfill(vm.InitArrayObject(values))
+}
+
+func (f *ConcurrentFutureObject) fulfill(value Object) {
+	f.value = value
+	close(f.done)
+}
+
+func (f *ConcurrentFutureObject) reject(err *Error) {
+	f.reason = err
+	close(f.done)
+}
+
+func (f *ConcurrentFutureObject) isComplete() bool {
+	select {
+	case <-f.done:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,333 @@
+package vm
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestConcurrentFutureExecute(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require 'concurrent/future'
+		f = Concurrent::Future.execute do
+		  1 + 1
+		end
+		f.value(5)
+		`, 2},
+		{`
+		require 'concurrent/future'
+		f = Concurrent::Future.execute do
+		  10
+		end
+		f.value(5)
+		f.complete?
+		`, true},
+		{`
+		require 'concurrent/future'
+		c = Channel.new
+		f = Concurrent::Future.execute do
+		  c.receive
+		end
+		r = f.complete?
+		c.deliver(1)
+		f.value(5)
+		r
+		`, false},
+		{`
+		require 'concurrent/future'
+		c = Channel.new
+		f = Concurrent::Future.execute do
+		  c.receive
+		end
+		r = f.value(0.1)
+		c.deliver(1)
+		r
+		`, nil},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentFutureThen(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require 'concurrent/future'
+		f = Concurrent::Future.execute do
+		  1
+		end
+		f.then do |v|
+		  v + 1
+		end.then do |v|
+		  v * 10
+		end.then do |v|
+		  v.to_s
+		end.value(5)
+		`, "20"},
+		{`
+		require 'concurrent/future'
+		c = Channel.new
+		f = Concurrent::Future.execute do
+		  c.receive
+		end
+		chained = f.then do |v|
+		  v + 1
+		end
+		# "then" doesn't block the caller even though f is still pending
+		c.deliver(41)
+		chained.value(5)
+		`, 42},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentFutureZip(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require 'concurrent/future'
+		c1 = Channel.new
+		c2 = Channel.new
+		f1 = Concurrent::Future.execute do
+		  c1.receive
+		end
+		f2 = Concurrent::Future.execute do
+		  c2.receive
+		end
+		f3 = Concurrent::Future.execute do
+		  3
+		end
+		z = Concurrent::Future.zip(f1, f2, f3)
+		f3.value(5)
+		c2.deliver(2)
+		f2.value(5)
+		c1.deliver(1)
+		z.value(5)
+		`, []interface{}{1, 2, 3}},
+		{`
+		require 'concurrent/future'
+		Concurrent::Future.zip.value(5)
+		`, []interface{}{}},
+		{`
+		require 'concurrent/future'
+		c = Channel.new
+		f1 = Concurrent::Future.execute do
+		  c.receive
+		end
+		f2 = Concurrent::Future.execute do
+		  raise ArgumentError, "boom"
+		end
+		z = Concurrent::Future.zip(f1, f2)
+		# the zipped future is rejected without waiting for f1
+		z.value(5)
+		r = z.reason
+		c.deliver(1)
+		[r.class.name, r.to_s]
+		`, []interface{}{"ArgumentError", `ArgumentError: "boom"`}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentFutureRejection(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require 'concurrent/future'
+		f = Concurrent::Future.execute do
+		  raise ArgumentError, "boom"
+		end
+		f.value(5)
+		`, nil},
+		{`
+		require 'concurrent/future'
+		f = Concurrent::Future.execute do
+		  raise ArgumentError, "boom"
+		end
+		f.value(5)
+		f.rejected?
+		`, true},
+		{`
+		require 'concurrent/future'
+		f = Concurrent::Future.execute do
+		  1
+		end
+		f.value(5)
+		f.rejected?
+		`, false},
+		{`
+		require 'concurrent/future'
+		f = Concurrent::Future.execute do
+		  1
+		end
+		f.value(5)
+		f.reason
+		`, nil},
+		{`
+		require 'concurrent/future'
+		called = false
+		f = Concurrent::Future.execute do
+		  raise ArgumentError, "boom"
+		end
+		chained = f.then do |v|
+		  called = true
+		  v
+		end.then do |v|
+		  called = true
+		  v
+		end
+		chained.value(5)
+		[chained.rejected?, chained.reason.class.name, chained.reason.to_s, called]
+		`, []interface{}{true, "ArgumentError", `ArgumentError: "boom"`, false}},
+		// the error is returned as it is, and can be compared with its class
+		{`
+		require 'concurrent/future'
+		f = Concurrent::Future.execute do
+		  raise ArgumentError, "boom"
+		end
+		chained = f.then do |v|
+		  v
+		end
+		chained.value(5)
+		[f.reason.class == ArgumentError, f.reason.object_id == chained.reason.object_id]
+		`, []interface{}{true, true}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentFutureRejectionPreservesError(t *testing.T) {
+	input := `
+	require 'concurrent/future'
+	f = Concurrent::Future.execute do
+	  raise ArgumentError, "boom"
+	end
+	chained = f.then do |v|
+	  v
+	end.then do |v|
+	  v
+	end
+	chained.value(5)
+	[f, chained]
+	`
+
+	v := initTestVM()
+	evaluated := v.testEval(t, input, getFilename())
+	futures := evaluated.(*ArrayObject).Elements
+	original := futures[0].(*ConcurrentFutureObject)
+	chained := futures[1].(*ConcurrentFutureObject)
+
+	<-original.done
+	<-chained.done
+
+	if original.reason == nil || chained.reason != original.reason {
+		t.Errorf("Expect chained future to be rejected with the original error. got: %v", chained.reason)
+	}
+}
+
+func TestConcurrentFutureErrorIsRaisedFromItsThread(t *testing.T) {
+	input := `
+	require 'concurrent/future'
+	def fail(n)
+	  Concurrent::Future.execute do
+	    raise ArgumentError, "boom"
+	  end
+	end
+	futures = []
+	20.times do |i|
+	  futures.push(fail(i))
+	end
+	futures.each do |f|
+	  f.value(5)
+	end
+	futures.first
+	`
+
+	fileName := getFilename()
+	v := initTestVM()
+	evaluated := v.testEval(t, input, fileName)
+	f := evaluated.(*ConcurrentFutureObject)
+
+	// Raising the errors on the futures' threads mustn't touch the main thread's call frames
+	v.checkCFP(t, 0, 0)
+	v.checkSP(t, 0, 1)
+
+	if f.reason == nil {
+		t.Fatal("Expect the future to be rejected")
+	}
+
+	expected := fmt.Sprintf("from %s:5", fileName)
+
+	if f.reason.stackTraces[0] != expected {
+		t.Errorf("Expect the error to be raised from %q. got: %q", expected, f.reason.stackTraces[0])
+	}
+}
+
+func TestConcurrentFutureMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`
+		require 'concurrent/future'
+		Concurrent::Future.execute
+		`, "InternalError: Can't yield without a block", 1},
+		{`
+		require 'concurrent/future'
+		Concurrent::Future.zip(1)
+		`, "TypeError: Expect argument #1 to be Future. got: Integer", 1},
+		{`
+		require 'concurrent/future'
+		f = Concurrent::Future.execute do
+		  1
+		end
+		f.value("1")
+		`, "TypeError: Expect argument to be Numeric. got: String", 1},
+		{`
+		require 'concurrent/future'
+		f = Concurrent::Future.execute do
+		  1
+		end
+		f.value(1, 2)
+		`, "ArgumentError: Expect 1 or less argument(s). got: 2", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
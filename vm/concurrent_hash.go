@@ -3,6 +3,8 @@ package vm
 import (
 	"bytes"
 	"fmt"
+	"math/rand"
+	"sort"
 	"strings"
 	"sync"
 
@@ -10,31 +12,110 @@ import (
 	"github.com/goby-lang/goby/vm/errors"
 )
 
+const (
+	invalidSampleOption = "Expect option %s to be %s. got: %s"
+	unknownSampleOption = "Unknown option %s for Concurrent::Hash#sample"
+)
+
 // ConcurrentHashObject is an implementation of thread-safe associative arrays (Hash).
 //
-// The implementation internally uses Go's `sync.Map` type, with some advantages and disadvantages:
+// The implementation internally uses a Go map guarded by an R/W mutex, like Concurrent::Array does:
 //
-// - it is highly performant and predictable for a certain pattern of usage (`concurrent loops with keys that are stable over time, and either few steady-state stores, or stores localized to one goroutine per key.`); performance and predictability in other conditions are unspecified;
-// - iterations are non-deterministic; during iterations, keys may not be included;
-// - size can't be retrieved;
-// - for the reasons above, the Hash APIs implemented are minimal.
+// - reads can happen at the same time, and writes are serialized;
+// - iterations go over a snapshot of the pairs taken under the lock, in no particular order,
+//   so the block can read and write the hash freely;
+// - a hash built from a known set of pairs, like the results of `merge` or `transform_keys`, is populated at once
+//   instead of key by key.
 //
-// For details, see https://golang.org/pkg/sync/#Map.
+// Keys are always stored as strings. Since symbol literals are String objects in Goby,
+// a key set with a symbol can be looked up with the equivalent string and vice versa:
+// `hash[:a]` and `hash["a"]` always refer to the same entry. Keys of any other type raise a `TypeError`.
 //
 // ```ruby
 // require 'concurrent/hash'
-// hash = Concurrent::Hash.new({ "a": 1, "b": 2 })
+// hash = Concurrent::Hash.new({ a: 1, b: 2 })
 // hash["a"]  # => 1
+// hash[:a]   # => 1
+// hash["c"] = 3
+// hash[:c]   # => 3
 // ```
 //
 type ConcurrentHashObject struct {
 	*BaseObj
-	internalMap *sync.Map
+	// internalMap holds the pairs, and is guarded by the lock
+	internalMap map[string]Object
+	lock        sync.RWMutex
 }
 
 // Class methods --------------------------------------------------------
 var builtinConcurrentHashClassMethods = []*BuiltinMethodObject{
 	{
+		// Creates a Concurrent::Hash from a JSON object, like the one `persist` writes.
+		// Nested objects and arrays become plain Hashes and Arrays.
+		//
+		// ```ruby
+		// h = Concurrent::Hash.from_json('{"a": 1, "b": [2, 3]}')
+		// h["b"] # => [2, 3]
+		// Concurrent::Hash.from_json('[1]') # => TypeError
+		// ```
+		//
+		// @param json [String]
+		// @return [Concurrent::Hash]
+		Name: "from_json",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			jsonString, ok := args[0].(*StringObject)
+
+			if !ok {
+				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.StringClass, args[0].Class().Name)
+			}
+
+			return t.concurrentHashFromJSON(t.decodeJSON(jsonString.value, sourceLine), sourceLine)
+
+		},
+	},
+	{
+		// Creates a Concurrent::Hash from the JSON object in the file at the given path, like the one `persist` writes.
+		//
+		// ```ruby
+		// h = Concurrent::Hash.new({ count: 1 })
+		// h.persist("/tmp/state.json")
+		// Concurrent::Hash.load("/tmp/state.json")["count"] # => 1
+		// ```
+		//
+		// @param path [String]
+		// @return [Concurrent::Hash]
+		Name: "load",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			path, ok := args[0].(*StringObject)
+
+			if !ok {
+				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.StringClass, args[0].Class().Name)
+			}
+
+			return t.concurrentHashFromJSON(t.readJSONFile(path.value, sourceLine), sourceLine)
+
+		},
+	},
+	{
+		// Creates a Concurrent::Hash, which is empty if no argument is given.
+		// The pairs are copied from a Hash, or taken from an Array of `[key, value]` pairs, like the one `to_a` returns.
+		//
+		// ```ruby
+		// Concurrent::Hash.new({ a: 1 })          # => a Concurrent::Hash with "a" => 1
+		// Concurrent::Hash.new([[:a, 1], [:b, 2]]) # => a Concurrent::Hash with "a" => 1 and "b" => 2
+		// Concurrent::Hash.new([[:a]])             # => ArgumentError
+		// ```
+		//
+		// @param pairs [Hash, Array]
+		// @return [Concurrent::Hash]
 		Name: "new",
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
 			aLen := len(args)
@@ -43,16 +124,35 @@ var builtinConcurrentHashClassMethods = []*BuiltinMethodObject{
 			}
 
 			if aLen == 0 {
-				return t.vm.initConcurrentHashObject(make(map[string]Object))
+				return t.vm.newConcurrentHashObject(make(map[string]Object))
 			}
 
-			hashArg, ok := args[0].(*HashObject)
+			switch arg := args[0].(type) {
+			case *HashObject:
+				return t.vm.initConcurrentHashObject(arg.Pairs)
+			case *ArrayObject:
+				pairs := make(map[string]Object)
 
-			if !ok {
-				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.HashClass, args[0].Class().Name)
-			}
+				for i, el := range arg.Elements {
+					kv, ok := el.(*ArrayObject)
+
+					if !ok || len(kv.Elements) != 2 {
+						return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, "Expect element #%d to be a [key, value] pair. got: %s", i, el.Inspect())
+					}
+
+					key, ok := kv.Elements[0].(*StringObject)
 
-			return t.vm.initConcurrentHashObject(hashArg.Pairs)
+					if !ok {
+						return t.vm.InitErrorObject(errors.TypeError, sourceLine, "Expect the key in the Array's element #%d to be String. got: %s", i, kv.Elements[0].Class().Name)
+					}
+
+					pairs[key.value] = kv.Elements[1]
+				}
+
+				return t.vm.newConcurrentHashObject(pairs)
+			default:
+				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.HashClass+" or "+classes.ArrayClass, args[0].Class().Name)
+			}
 
 		},
 	},
@@ -60,6 +160,74 @@ var builtinConcurrentHashClassMethods = []*BuiltinMethodObject{
 
 // Instance methods -----------------------------------------------------
 var builtinConcurrentHashInstanceMethods = []*BuiltinMethodObject{
+	{
+		// Writes a snapshot of the hash as JSON to the file at the given path, and returns the receiver.
+		// The file is replaced atomically: the JSON is written to a temporary file in the same directory first,
+		// which is then renamed to the path, so readers never see a partially written file.
+		// It raises the same errors as `snapshot` if a value can't be written as JSON.
+		//
+		// ```ruby
+		// h = Concurrent::Hash.new({ count: 1 })
+		// h.persist("/tmp/state.json")
+		// File.new("/tmp/state.json").read # => {"count":1}
+		// ```
+		//
+		// @param path [String]
+		// @return [Concurrent::Hash]
+		Name: "persist",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			path, ok := args[0].(*StringObject)
+
+			if !ok {
+				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.StringClass, args[0].Class().Name)
+			}
+
+			snapshot := t.snapshotValue(receiver, "", sourceLine)
+
+			if err, ok := snapshot.(*Error); ok {
+				return err
+			}
+
+			if err := t.persistSnapshot(snapshot, path.value, sourceLine); err != nil {
+				return err
+			}
+
+			return receiver
+
+		},
+	},
+	{
+		// Returns a deep copy of the hash as a plain Hash, with nested Arrays, Hashes and concurrent collections copied too,
+		// so later writes to the receiver don't affect it.
+		// Each value is read at a single point in time, but writes to other keys may happen while the copy is taken,
+		// as with `each`.
+		//
+		// Only the values JSON can represent are supported: nil, Boolean, Integer, Float, String, Array and Hash.
+		// A `TypeError` locating the first other value is raised otherwise.
+		//
+		// ```ruby
+		// h = Concurrent::Hash.new({ a: [1, 2] })
+		// s = h.snapshot # => { a: [1, 2] }
+		// h["a"].push(3)
+		// s["a"]         # => [1, 2]
+		// Concurrent::Hash.new({ a: [Channel.new] }).snapshot # => TypeError: Can't snapshot Channel at ["a"][0]: ...
+		// ```
+		//
+		// @return [Hash]
+		Name: "snapshot",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			return t.snapshotValue(receiver, "", sourceLine)
+
+		},
+	},
 	{
 		// Retrieves the value (object) that corresponds to the key specified.
 		// When a key doesn't exist, `nil` is returned, or the default, if set.
@@ -86,13 +254,13 @@ var builtinConcurrentHashInstanceMethods = []*BuiltinMethodObject{
 
 			h := receiver.(*ConcurrentHashObject)
 
-			value, ok := h.internalMap.Load(args[0].Value().(string))
+			value, ok := h.load(args[0].Value().(string))
 
 			if !ok {
 				return NULL
 			}
 
-			return value.(Object)
+			return value
 
 		},
 	},
@@ -101,7 +269,7 @@ var builtinConcurrentHashInstanceMethods = []*BuiltinMethodObject{
 		// Returns the `value`.
 		//
 		// ```Ruby
-		// h = Concurrent::Hash.new{ a: 1, b: "2" })
+		// h = Concurrent::Hash.new({ a: 1, b: "2" })
 		// h['a'] = 2          #=> 2
 		// h                   #=> { a: 2, b: "2" }
 		// ```
@@ -122,12 +290,71 @@ var builtinConcurrentHashInstanceMethods = []*BuiltinMethodObject{
 			}
 
 			h := receiver.(*ConcurrentHashObject)
-			h.internalMap.Store(args[0].Value().(string), args[1])
+			h.store(args[0].Value().(string), args[1])
 
 			return args[1]
 
 		},
 	},
+	{
+		// Returns a new concurrent hash with the argument's pairs merged into the receiver's ones.
+		// When both values of a key are hashes, they're merged recursively instead of being overwritten.
+		// Other conflicting values are taken from the argument, or from the block if it's given,
+		// which receives the key, the receiver's value and the argument's value.
+		// The argument can be a Hash or a Concurrent::Hash; neither the receiver nor the argument is modified.
+		//
+		// ```Ruby
+		// h = Concurrent::Hash.new({ a: { x: 1 }, b: 1 })
+		// h.deep_merge({ a: { y: 2 }, b: 2 })   # => { a: { x: 1, y: 2 }, b: 2 }
+		// h.deep_merge({ b: 2 }) do |key, old, new|
+		//   old + new
+		// end                                   # => { a: { x: 1 }, b: 3 }
+		// ```
+		//
+		// @param hash [Hash]
+		// @return [Concurrent::Hash]
+		Name: "deep_merge",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			other, ok := hashPairsOf(args[0])
+
+			if !ok {
+				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.HashClass, args[0].Class().Name)
+			}
+
+			var resolve func(key string, oldValue, newValue Object) Object
+			var blockErr Object
+
+			if blockFrame != nil {
+				resolve = func(key string, oldValue, newValue Object) Object {
+					// Once the block raises an error, the merged hash is discarded
+					if blockErr != nil {
+						return newValue
+					}
+
+					result, erred := t.builtinMethodYield(blockFrame, t.vm.InitStringObject(key), oldValue, newValue)
+
+					if erred {
+						blockErr = result
+					}
+
+					return result
+				}
+			}
+
+			pairs := deepMergePairs(t.vm, receiver.(*ConcurrentHashObject).pairs(), other, resolve)
+
+			if blockErr != nil {
+				return blockErr
+			}
+
+			return t.vm.newConcurrentHashObject(pairs)
+
+		},
+	},
 	{
 		// Remove the key from the hash if key exist.
 		//
@@ -150,7 +377,7 @@ var builtinConcurrentHashInstanceMethods = []*BuiltinMethodObject{
 				return err
 			}
 
-			receiver.(*ConcurrentHashObject).internalMap.Delete(args[0].Value().(string))
+			receiver.(*ConcurrentHashObject).delete(args[0].Value().(string))
 
 			return NULL
 
@@ -183,25 +410,86 @@ var builtinConcurrentHashInstanceMethods = []*BuiltinMethodObject{
 			}
 
 			hash := receiver.(*ConcurrentHashObject)
-			framePopped := false
+			var blockErr Object
+
+			for key, value := range hash.pairs() {
+				result, erred := t.builtinMethodYield(blockFrame, t.vm.InitStringObject(key), value)
+
+
+				if erred {
+					blockErr = result
+					break
+				}
+			}
+
+			if blockErr != nil {
+				return blockErr
+			}
+
+			return hash
 
-			iterator := func(key, value interface{}) bool {
-				keyObject := t.vm.InitStringObject(key.(string))
+		},
+	},
+	{
+		// Calls the block with each key-value pair, in sorted key order, and returns an Array of the results.
+		// An Array returned by the block is flattened one level into the result, so each pair can be
+		// expanded into several elements, or none with an empty Array.
+		// The block is called with a snapshot of the pairs taken before the first call, so it can modify the hash.
+		//
+		// ```Ruby
+		// h = Concurrent::Hash.new({ a: 1, b: 2 })
+		// h.flat_map do |k, v|
+		//   [k, v]
+		// end
+		// # => ["a", 1, "b", 2]
+		//
+		// h.flat_map do |k, v|
+		//   if v > 1
+		//     [[k, v]]
+		//   else
+		//     k
+		//   end
+		// end
+		// # => ["a", ["b", 2]]
+		// ```
+		//
+		// @return [Array]
+		Name: "flat_map",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
 
-				t.builtinMethodYield(blockFrame, keyObject, value.(Object))
+			if blockFrame == nil {
+				return t.vm.InitErrorObject(errors.InternalError, sourceLine, errors.CantYieldWithoutBlockFormat)
+			}
 
-				framePopped = true
+			pairs := receiver.(*ConcurrentHashObject).pairs()
+			keys := make([]string, 0, len(pairs))
 
-				return true
+			for key := range pairs {
+				keys = append(keys, key)
 			}
 
-			hash.internalMap.Range(iterator)
+			sort.Strings(keys)
+
+			elements := []Object{}
+
+			for _, key := range keys {
+				result, erred := t.builtinMethodYield(blockFrame, t.vm.InitStringObject(key), pairs[key])
+
+				if erred {
+					return result
+				}
 
-			if !framePopped {
-				t.callFrameStack.pop()
+				if arr, ok := result.(*ArrayObject); ok {
+					elements = append(elements, arr.Elements...)
+				} else {
+					elements = append(elements, result)
+				}
 			}
 
-			return hash
+			return t.vm.InitArrayObject(elements)
 
 		},
 	},
@@ -227,7 +515,7 @@ var builtinConcurrentHashInstanceMethods = []*BuiltinMethodObject{
 				return err
 			}
 
-			if _, ok := receiver.(*ConcurrentHashObject).internalMap.Load(args[0].Value().(string)); ok {
+			if _, ok := receiver.(*ConcurrentHashObject).load(args[0].Value().(string)); ok {
 				return TRUE
 			}
 
@@ -235,6 +523,212 @@ var builtinConcurrentHashInstanceMethods = []*BuiltinMethodObject{
 
 		},
 	},
+	{
+		// Returns a new concurrent hash with the pairs of the receiver and all the arguments.
+		// When a key exists in more than one of them, the value from the last argument wins.
+		// The arguments can be Hashes or Concurrent::Hashes, and the receiver isn't modified.
+		//
+		// ```Ruby
+		// h = Concurrent::Hash.new({ a: 1, b: 2 })
+		// h.merge({ b: 3, c: 4 })            # => { a: 1, b: 3, c: 4 }
+		// h.merge({ a: 0 }, { a: 9, d: 5 })  # => { a: 9, b: 2, d: 5 }
+		// h                                  # => { a: 1, b: 2 }
+		// ```
+		//
+		// @param hash [Hash]
+		// @return [Concurrent::Hash]
+		Name: "merge",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) < 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgumentMore, 1, len(args))
+			}
+
+			pairs, err := t.mergeHashArgs(receiver.(*ConcurrentHashObject).pairs(), args, sourceLine)
+
+			if err != nil {
+				return err
+			}
+
+			return t.vm.newConcurrentHashObject(pairs)
+
+		},
+	},
+	{
+		// Merges the pairs of all the arguments into the receiver and returns the receiver.
+		// When a key exists in more than one of them, the value from the last argument wins.
+		// The pairs are stored at once, so other threads never see a partially merged hash.
+		//
+		// ```Ruby
+		// h = Concurrent::Hash.new({ a: 1, b: 2 })
+		// h.merge!({ b: 3 }, { c: 4 })  # => { a: 1, b: 3, c: 4 }
+		// h                             # => { a: 1, b: 3, c: 4 }
+		// ```
+		//
+		// @param hash [Hash]
+		// @return [Concurrent::Hash]
+		Name: "merge!",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) < 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgumentMore, 1, len(args))
+			}
+
+			// The arguments are combined before taking the lock, since the receiver can be one of them
+			pairs, err := t.mergeHashArgs(make(map[string]Object), args, sourceLine)
+
+			if err != nil {
+				return err
+			}
+
+			h := receiver.(*ConcurrentHashObject)
+			h.storeAll(pairs)
+
+			return h
+
+		},
+	},
+	{
+		// Returns a new concurrent hash with the pairs the block returns a falsy value for, leaving self untouched.
+		// The block is called with a snapshot of the pairs in sorted key order, so it can modify the hash.
+		//
+		// ```Ruby
+		// h = Concurrent::Hash.new({ a: 1, b: 2, c: 3 })
+		// h.reject do |k, v|
+		//   v.odd?
+		// end
+		// # => { b: 2 }
+		// h # => { a: 1, b: 2, c: 3 }
+		// ```
+		//
+		// @return [Concurrent::Hash]
+		Name: "reject",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			if blockFrame == nil {
+				return t.vm.InitErrorObject(errors.InternalError, sourceLine, errors.CantYieldWithoutBlockFormat)
+			}
+
+			pairs := receiver.(*ConcurrentHashObject).pairs()
+			keys := make([]string, 0, len(pairs))
+
+			for key := range pairs {
+				keys = append(keys, key)
+			}
+
+			sort.Strings(keys)
+
+			result := make(map[string]Object, len(pairs))
+
+			for _, key := range keys {
+				rejected, erred := t.builtinMethodYield(blockFrame, t.vm.InitStringObject(key), pairs[key])
+
+				if erred {
+					return rejected
+				}
+
+				if !rejected.isTruthy() {
+					result[key] = pairs[key]
+				}
+			}
+
+			return t.vm.newConcurrentHashObject(result)
+
+		},
+	},
+	{
+		// Returns a random `[key, value]` pair of the hash, or nil if the hash is empty.
+		// With a count, returns an Array of that many distinct pairs in random order, or of all the pairs if the hash has fewer.
+		// The pairs are picked from a snapshot of the hash, so they're consistent even while other threads modify it.
+		//
+		// The options Hash can have a `seed` Integer, which makes the result the same every time for the same pairs.
+		//
+		// ```Ruby
+		// h = Concurrent::Hash.new({ a: 1, b: 2, c: 3 })
+		// h.sample                   # => ["b", 2]
+		// h.sample(2)                # => [["c", 3], ["a", 1]]
+		// h.sample(5).length         # => 3
+		// h.sample({ seed: 42 })     # => always the same pair
+		// h.sample(2, { seed: 42 })  # => always the same pairs
+		// Concurrent::Hash.new.sample # => nil
+		// ```
+		//
+		// @param count [Integer]
+		// @param options [Hash]
+		// @return [Array]
+		Name: "sample",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) > 2 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgumentLess, 2, len(args))
+			}
+
+			count := -1
+
+			if len(args) > 0 {
+				if c, ok := args[0].(*IntegerObject); ok {
+					if c.value < 0 {
+						return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.NegativeValue, c.value)
+					}
+
+					count = c.value
+					args = args[1:]
+				}
+			}
+
+			if len(args) > 1 {
+				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormatNum, 1, classes.IntegerClass, args[0].Class().Name)
+			}
+
+			intn := rand.Intn
+
+			if len(args) == 1 {
+				var err *Error
+
+				if intn, err = t.sampleRandom(args[0], count >= 0, sourceLine); err != nil {
+					return err
+				}
+			}
+
+			pairs := receiver.(*ConcurrentHashObject).pairs()
+			keys := make([]string, 0, len(pairs))
+
+			for key := range pairs {
+				keys = append(keys, key)
+			}
+
+			// The keys are sorted, so a seed picks the same pairs whatever order the map has
+			sort.Strings(keys)
+
+			n := count
+			if count < 0 {
+				n = 1
+			}
+			if n > len(keys) {
+				n = len(keys)
+			}
+
+			// A partial Fisher-Yates shuffle puts n distinct random keys at the front
+			sampled := make([]Object, n)
+
+			for i := 0; i < n; i++ {
+				j := i + intn(len(keys)-i)
+				keys[i], keys[j] = keys[j], keys[i]
+				sampled[i] = t.vm.InitArrayObject([]Object{t.vm.InitStringObject(keys[i]), pairs[keys[i]]})
+			}
+
+			if count >= 0 {
+				return t.vm.InitArrayObject(sampled)
+			}
+
+			if n == 0 {
+				return NULL
+			}
+
+			return sampled[0]
+
+		},
+	},
 	{
 		// Returns json that is corresponding to the hash.
 		// Basically just like Hash#to_json in Rails but currently doesn't support options.
@@ -251,8 +745,7 @@ var builtinConcurrentHashInstanceMethods = []*BuiltinMethodObject{
 				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
 			}
 
-			r := receiver.(*ConcurrentHashObject)
-			return t.vm.InitStringObject(r.ToJSON(t))
+			return t.encodeJSON(receiver, sourceLine)
 
 		},
 	},
@@ -277,30 +770,129 @@ var builtinConcurrentHashInstanceMethods = []*BuiltinMethodObject{
 
 		},
 	},
+	{
+		// Returns a new concurrent hash with the keys replaced by the block's results, which must be Strings.
+		// The block is called with a snapshot of the keys in sorted order, so it can modify the hash.
+		// When several keys are mapped to the same key, the value of the last one wins.
+		//
+		// ```Ruby
+		// h = Concurrent::Hash.new({ a: 1, b: 2 })
+		// h.transform_keys do |k|
+		//   k.upcase
+		// end
+		// # => { A: 1, B: 2 }
+		// h # => { a: 1, b: 2 }
+		// ```
+		//
+		// @return [Concurrent::Hash]
+		Name: "transform_keys",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			pairs, err := t.transformKeys(receiver.(*ConcurrentHashObject), args, blockFrame, sourceLine)
+
+			if err != nil {
+				return err
+			}
+
+			return t.vm.newConcurrentHashObject(pairs)
+
+		},
+	},
+	{
+		// Replaces the keys with the block's results like `transform_keys`, and returns self.
+		// The hash isn't modified if the block raises an error or returns a key that isn't a String.
+		// Pairs stored by other threads while the keys are transformed may be dropped.
+		//
+		// ```Ruby
+		// h = Concurrent::Hash.new({ a: 1, b: 2 })
+		// h.transform_keys! do |k|
+		//   k.upcase
+		// end
+		// h # => { A: 1, B: 2 }
+		// ```
+		//
+		// @return [Concurrent::Hash]
+		Name: "transform_keys!",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			hash := receiver.(*ConcurrentHashObject)
+			pairs, err := t.transformKeys(hash, args, blockFrame, sourceLine)
+
+			if err != nil {
+				return err
+			}
+
+			hash.replace(pairs)
+			return hash
+
+		},
+	},
+	{
+		// Returns the keys and the values of the hash as two Arrays, `[keys, values]`, sorted by key,
+		// so the value of `keys[i]` is `values[i]`.
+		// Both are read in a single pass over the hash, unlike separate calls that could see the hash change in between.
+		//
+		// ```Ruby
+		// h = Concurrent::Hash.new({ b: 2, a: 1 })
+		// h.values_snapshot # => [["a", "b"], [1, 2]]
+		// ```
+		//
+		// @return [Array]
+		Name: "values_snapshot",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			pairs := receiver.(*ConcurrentHashObject).pairs()
+			keys := make([]string, 0, len(pairs))
+
+			for key := range pairs {
+				keys = append(keys, key)
+			}
+
+			sort.Strings(keys)
+
+			keyObjects := make([]Object, len(keys))
+			values := make([]Object, len(keys))
+
+			for i, key := range keys {
+				keyObjects[i] = t.vm.InitStringObject(key)
+				values[i] = pairs[key]
+			}
+
+			return t.vm.InitArrayObject([]Object{t.vm.InitArrayObject(keyObjects), t.vm.InitArrayObject(values)})
+
+		},
+	},
 }
 
 // Internal functions ===================================================
 
 // Functions for initialization -----------------------------------------
 
+// initConcurrentHashObject copies the given pairs, whose keys are already in the normalized (plain string) form
 func (vm *VM) initConcurrentHashObject(pairs map[string]Object) *ConcurrentHashObject {
-	var internalMap sync.Map
+	copied := make(map[string]Object, len(pairs))
 
 	for key, value := range pairs {
-		internalMap.Store(key, value)
+		copied[key] = value
 	}
 
-	concurrent := vm.loadConstant("Concurrent", true)
+	return vm.newConcurrentHashObject(copied)
+}
 
+// newConcurrentHashObject creates a Concurrent::Hash that takes over the pairs without copying them,
+// so they must not be used elsewhere afterwards. It's for the pairs built just for the new hash.
+func (vm *VM) newConcurrentHashObject(pairs map[string]Object) *ConcurrentHashObject {
 	return &ConcurrentHashObject{
-		BaseObj:     NewBaseObject(concurrent.getClassConstant(classes.HashClass)),
-		internalMap: &internalMap,
+		BaseObj:     NewBaseObject(vm.concurrentHashClass),
+		internalMap: pairs,
 	}
 }
 
 func initConcurrentHashClass(vm *VM) {
 	concurrent := vm.loadConstant("Concurrent", true)
 	hash := vm.initializeClass(classes.HashClass)
+	vm.concurrentHashClass = hash
 
 	hash.setBuiltinMethods(builtinConcurrentHashInstanceMethods, false)
 	hash.setBuiltinMethods(builtinConcurrentHashClassMethods, true)
@@ -308,11 +900,119 @@ func initConcurrentHashClass(vm *VM) {
 	concurrent.setClassConstant(hash)
 }
 
+// concurrentHashFromJSON creates a Concurrent::Hash from the decoded JSON, which should be an object
+func (t *Thread) concurrentHashFromJSON(decoded Object, sourceLine int) Object {
+	switch d := decoded.(type) {
+	case *Error:
+		return d
+	case *HashObject:
+		return t.vm.newConcurrentHashObject(d.Pairs)
+	default:
+		return t.vm.InitErrorObject(errors.TypeError, sourceLine, unexpectedJSON, "an object", jsonKind(decoded))
+	}
+}
+
 // Polymorphic helper functions -----------------------------------------
 
-// Value returns the object
+// sampleRandom returns the Intn `sample` picks pairs with: the one of a rand.Rand seeded by the `seed` option if it's given,
+// or the top-level one of math/rand, which is safe for concurrent use, otherwise.
+// The options are the argument after the count if there's one.
+func (t *Thread) sampleRandom(options Object, afterCount bool, sourceLine int) (func(n int) int, *Error) {
+	h, ok := options.(*HashObject)
+
+	if !ok {
+		if afterCount {
+			return nil, t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormatNum, 2, classes.HashClass, options.Class().Name)
+		}
+
+		return nil, t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, "Integer or Hash", options.Class().Name)
+	}
+
+	intn := rand.Intn
+
+	for _, key := range h.sortedKeys() {
+		if key != "seed" {
+			return nil, t.vm.InitErrorObject(errors.ArgumentError, sourceLine, unknownSampleOption, key)
+		}
+
+		seed, ok := h.Pairs[key].(*IntegerObject)
+
+		if !ok {
+			return nil, t.vm.InitErrorObject(errors.TypeError, sourceLine, invalidSampleOption, key, classes.IntegerClass, h.Pairs[key].Class().Name)
+		}
+
+		intn = rand.New(rand.NewSource(int64(seed.value))).Intn
+	}
+
+	return intn, nil
+}
+
+// pairs returns a snapshot of the hash's pairs
+func (h *ConcurrentHashObject) pairs() map[string]Object {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	pairs := make(map[string]Object, len(h.internalMap))
+
+	for key, value := range h.internalMap {
+		pairs[key] = value
+	}
+
+	return pairs
+}
+
+func (h *ConcurrentHashObject) load(key string) (Object, bool) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	value, ok := h.internalMap[key]
+	return value, ok
+}
+
+func (h *ConcurrentHashObject) store(key string, value Object) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.internalMap[key] = value
+}
+
+func (h *ConcurrentHashObject) storeAll(pairs map[string]Object) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	for key, value := range pairs {
+		h.internalMap[key] = value
+	}
+}
+
+// copyInto writes the pairs into dst while holding the read lock, without an intermediate snapshot
+func (h *ConcurrentHashObject) copyInto(dst map[string]Object) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	for key, value := range h.internalMap {
+		dst[key] = value
+	}
+}
+
+func (h *ConcurrentHashObject) delete(key string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	delete(h.internalMap, key)
+}
+
+// replace takes over the pairs without copying them, like newConcurrentHashObject
+func (h *ConcurrentHashObject) replace(pairs map[string]Object) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.internalMap = pairs
+}
+
+// Value returns a snapshot of the pairs
 func (h *ConcurrentHashObject) Value() interface{} {
-	return h.internalMap
+	return h.pairs()
 }
 
 // ToString returns the object's name as the string format
@@ -320,13 +1020,10 @@ func (h *ConcurrentHashObject) ToString() string {
 	var out bytes.Buffer
 	var pairs []string
 
-	iterator := func(key, value interface{}) bool {
-		pairs = append(pairs, fmt.Sprintf("%s: %s", key, value.(Object).Inspect()))
-		return true
+	for key, value := range h.pairs() {
+		pairs = append(pairs, fmt.Sprintf("%s: %s", key, value.Inspect()))
 	}
 
-	h.internalMap.Range(iterator)
-
 	out.WriteString("{ ")
 	out.WriteString(strings.Join(pairs, ", "))
 	out.WriteString(" }")
@@ -341,19 +1038,136 @@ func (h *ConcurrentHashObject) Inspect() string {
 
 // ToJSON returns the object's name as the JSON string format
 func (h *ConcurrentHashObject) ToJSON(t *Thread) string {
-	var out bytes.Buffer
-	var values []string
-	out.WriteString("{")
+	e := &jsonEncoder{t: t}
+	return e.encodePairs(h.pairs(), jsonRootPath)
+}
 
-	iterator := func(key, value interface{}) bool {
-		values = append(values, generateJSONFromPair(key.(string), value.(Object), t))
+// Other helper functions -----------------------------------------------
+
+// hashPairsOf returns the pairs of a Hash, or a snapshot of the pairs of a Concurrent::Hash
+func hashPairsOf(obj Object) (map[string]Object, bool) {
+	switch h := obj.(type) {
+	case *HashObject:
+		return h.Pairs, true
+	case *ConcurrentHashObject:
+		return h.pairs(), true
+	default:
+		return nil, false
+	}
+}
 
-		return true
+// mergeHashArgs writes the pairs of each Hash or Concurrent::Hash argument into dst in a single pass, so later arguments win.
+// All the arguments are type checked first, so dst isn't touched when one of them is invalid.
+func (t *Thread) mergeHashArgs(dst map[string]Object, args []Object, sourceLine int) (map[string]Object, *Error) {
+	for _, arg := range args {
+		switch arg.(type) {
+		case *HashObject, *ConcurrentHashObject:
+		default:
+			return nil, t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.HashClass, arg.Class().Name)
+		}
 	}
 
-	h.internalMap.Range(iterator)
+	for _, arg := range args {
+		switch h := arg.(type) {
+		case *HashObject:
+			for key, value := range h.Pairs {
+				dst[key] = value
+			}
+		case *ConcurrentHashObject:
+			h.copyInto(dst)
+		}
+	}
 
-	out.WriteString(strings.Join(values, ","))
-	out.WriteString("}")
-	return out.String()
+	return dst, nil
+}
+
+const transformedKeyNotString = "Expect the block to return a String key. got: %s"
+
+// transformKeys returns a snapshot of the hash's pairs with the keys replaced by the block's results, for `transform_keys`
+func (t *Thread) transformKeys(hash *ConcurrentHashObject, args []Object, blockFrame *normalCallFrame, sourceLine int) (map[string]Object, *Error) {
+	if len(args) != 0 {
+		return nil, t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+	}
+
+	if blockFrame == nil {
+		return nil, t.vm.InitErrorObject(errors.InternalError, sourceLine, errors.CantYieldWithoutBlockFormat)
+	}
+
+	pairs := hash.pairs()
+	keys := make([]string, 0, len(pairs))
+
+	for key := range pairs {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	result := make(map[string]Object, len(pairs))
+
+	for _, key := range keys {
+		newKey, erred := t.builtinMethodYield(blockFrame, t.vm.InitStringObject(key))
+
+		if erred {
+			return nil, newKey.(*Error)
+		}
+
+		str, ok := newKey.(*StringObject)
+
+		if !ok {
+			return nil, t.vm.InitErrorObject(errors.TypeError, sourceLine, transformedKeyNotString, newKey.Class().Name)
+		}
+
+		result[str.value] = pairs[key]
+	}
+
+	return result, nil
+}
+
+// deepMergePairs returns a copy of pairs with other merged into it, merging the values that are both hashes recursively.
+// A merged hash keeps the type of the value in pairs. Other conflicts are settled by resolve if it's not nil,
+// in the order of the keys, or by taking the value in other.
+func deepMergePairs(vm *VM, pairs, other map[string]Object, resolve func(key string, oldValue, newValue Object) Object) map[string]Object {
+	result := make(map[string]Object, len(pairs))
+
+	for key, value := range pairs {
+		result[key] = value
+	}
+
+	keys := make([]string, 0, len(other))
+
+	for key := range other {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		newValue := other[key]
+		oldValue, exists := result[key]
+
+		if !exists {
+			result[key] = newValue
+			continue
+		}
+
+		oldPairs, oldIsHash := hashPairsOf(oldValue)
+		newPairs, newIsHash := hashPairsOf(newValue)
+
+		switch {
+		case oldIsHash && newIsHash:
+			merged := deepMergePairs(vm, oldPairs, newPairs, resolve)
+
+			if _, ok := oldValue.(*ConcurrentHashObject); ok {
+				result[key] = vm.newConcurrentHashObject(merged)
+			} else {
+				result[key] = vm.InitHashObject(merged)
+			}
+		case resolve != nil:
+			result[key] = resolve(key, oldValue, newValue)
+		default:
+			result[key] = newValue
+		}
+	}
+
+	return result
 }
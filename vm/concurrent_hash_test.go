@@ -1,6 +1,10 @@
 package vm
 
 import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -41,6 +45,18 @@ func TestConcurrentHashInitialization(t *testing.T) {
 		require 'concurrent/hash'
 		Concurrent::Hash.new({a: 1, b: 2})
 		`, map[string]interface{}{"a": 1, "b": 2}},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new([])
+		`, map[string]interface{}{}},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new([[:a, 1], ["b", 2], [:a, 3]])
+		`, map[string]interface{}{"a": 3, "b": 2}},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: 1, b: 2 }.to_a)
+		`, map[string]interface{}{"a": 1, "b": 2}},
 	}
 
 	for i, tt := range tests {
@@ -57,7 +73,23 @@ func TestConcurrentHashInitializationFail(t *testing.T) {
 		{`
 		require 'concurrent/hash'
 		Concurrent::Hash.new(true)
-		`, "TypeError: Expect argument to be Hash. got: Boolean", 3},
+		`, "TypeError: Expect argument to be Hash or Array. got: Boolean", 3},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new([[:a, 1], [:b]])
+		`, "ArgumentError: Expect element #1 to be a [key, value] pair. got: [\"b\"]", 3},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new([[:a, 1, 2]])
+		`, "ArgumentError: Expect element #0 to be a [key, value] pair. got: [\"a\", 1, 2]", 3},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new([:a, 1])
+		`, "ArgumentError: Expect element #0 to be a [key, value] pair. got: \"a\"", 3},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new([[1, 2]])
+		`, "TypeError: Expect the key in the Array's element #0 to be String. got: Integer", 3},
 		{`
 		require 'concurrent/hash'
 		Concurrent::Hash.new(1, 2)
@@ -87,21 +119,17 @@ func TestEvalConcurrentHashExpression(t *testing.T) {
 		t.Fatalf("Expect evaluated value to be a concurrent hash. got: %T", evaluated)
 	}
 
-	iterator := func(key, value interface{}) bool {
+	for key, value := range h.pairs() {
 		switch key {
 		case "foo":
-			verifyIntegerObject(t, 0, value.(Object), 123)
+			verifyIntegerObject(t, 0, value, 123)
 		case "bar":
-			verifyStringObject(t, 0, value.(Object), "test")
+			verifyStringObject(t, 0, value, "test")
 		case "Baz":
-			verifyBooleanObject(t, 0, value.(Object), true)
+			verifyBooleanObject(t, 0, value, true)
 		}
-
-		return true
 	}
 
-	h.internalMap.Range(iterator)
-
 	v.checkCFP(t, 0, 0)
 	v.checkSP(t, 0, 1)
 }
@@ -217,6 +245,186 @@ func TestConcurrentHashAccessOperationFail(t *testing.T) {
 	}
 }
 
+func TestConcurrentHashKeyNormalization(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require 'concurrent/hash'
+		h = Concurrent::Hash.new({ a: 1, b: 2 })
+		h["a"]
+		`, 1},
+		{`
+		require 'concurrent/hash'
+		h = Concurrent::Hash.new({ a: 1, b: 2 })
+		h[:b]
+		`, 2},
+		{`
+		require 'concurrent/hash'
+		h = {}
+		h["a"] = 1
+		Concurrent::Hash.new(h)[:a]
+		`, 1},
+		{`
+		require 'concurrent/hash'
+		h = Concurrent::Hash.new
+		h[:a] = 1
+		h["a"] = 2
+		h[:a]
+		`, 2},
+		{`
+		require 'concurrent/hash'
+		h = Concurrent::Hash.new({ a: 1 })
+		h.has_key?("a") && h.has_key?(:a)
+		`, true},
+		{`
+		require 'concurrent/hash'
+		h = Concurrent::Hash.new({ a: 1, b: 2 })
+		h.delete(:a)
+		h["a"]
+		`, nil},
+		{`
+		require 'concurrent/hash'
+		h = { a: 1 }
+		c = Concurrent::Hash.new(h)
+		h[:a] = 2
+		c[:a]
+		`, 1},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentHashDeepMergeMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected map[string]interface{}
+	}{
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: 1, b: 2 }).deep_merge({ b: 3, c: 4 })
+		`, map[string]interface{}{"a": 1, "b": 3, "c": 4}},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: 1, b: 2 }).deep_merge(Concurrent::Hash.new({ b: 3 }))
+		`, map[string]interface{}{"a": 1, "b": 3}},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: 1, b: 2 }).deep_merge({ a: 10, b: 20, c: 30 }) do |key, old, new|
+		  old + new
+		end
+		`, map[string]interface{}{"a": 11, "b": 22, "c": 30}},
+		// the block isn't called without conflicts
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: 1 }).deep_merge({ b: 2 }) do |key, old, new|
+		  0
+		end
+		`, map[string]interface{}{"a": 1, "b": 2}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		verifyConcurrentHashObject(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+
+	tests2 := []struct {
+		input    string
+		expected interface{}
+	}{
+		// nested merges
+		{`
+		require 'concurrent/hash'
+		h = Concurrent::Hash.new({ a: { x: 1 } }).deep_merge({ a: { y: 2 } })
+		h[:a].to_s
+		`, "{ x: 1, y: 2 }"},
+		{`
+		require 'concurrent/hash'
+		h = Concurrent::Hash.new({ a: { x: { m: 1, n: 2 } } }).deep_merge({ a: { x: { n: 3 }, y: 4 } })
+		h[:a].to_s
+		`, "{ x: { m: 1, n: 3 }, y: 4 }"},
+		{`
+		require 'concurrent/hash'
+		h = Concurrent::Hash.new({ a: Concurrent::Hash.new({ x: 1 }) }).deep_merge({ a: { y: 2 } })
+		[h[:a].class.name, h[:a][:x], h[:a][:y]]
+		`, []interface{}{"Hash", 1, 2}},
+		// leaf conflicts in nested hashes go to the block
+		{`
+		require 'concurrent/hash'
+		h = Concurrent::Hash.new({ a: { x: 1 } }).deep_merge({ a: { x: 2 } }) do |key, old, new|
+		  key + ":" + (old + new).to_s
+		end
+		h[:a][:x]
+		`, "x:3"},
+		// type mismatches between nested levels
+		{`
+		require 'concurrent/hash'
+		h = Concurrent::Hash.new({ a: { x: 1 } }).deep_merge({ a: 1 })
+		h[:a]
+		`, 1},
+		{`
+		require 'concurrent/hash'
+		h = Concurrent::Hash.new({ a: 1 }).deep_merge({ a: { x: 1 } })
+		h[:a].to_s
+		`, "{ x: 1 }"},
+		{`
+		require 'concurrent/hash'
+		h = Concurrent::Hash.new({ a: { x: 1 } }).deep_merge({ a: [1] }) do |key, old, new|
+		  old.class.name + "/" + new.class.name
+		end
+		h[:a]
+		`, "Hash/Array"},
+		// neither the receiver nor the argument is modified
+		{`
+		require 'concurrent/hash'
+		h = Concurrent::Hash.new({ a: { x: 1 } })
+		other = { a: { y: 2 } }
+		h.deep_merge(other)
+		[h[:a].to_s, other[:a].to_s]
+		`, []interface{}{"{ x: 1 }", "{ y: 2 }"}},
+	}
+
+	for i, tt := range tests2 {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentHashDeepMergeMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: 1 }).deep_merge`, "ArgumentError: Expect 1 argument(s). got: 0", 1},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: 1 }).deep_merge({ a: 1 }, { b: 2 })`, "ArgumentError: Expect 1 argument(s). got: 2", 1},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: 1 }).deep_merge([1])`, "TypeError: Expect argument to be Hash. got: Array", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestConcurrentHashDeleteMethod(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -381,6 +589,83 @@ func TestConcurrentHashEachMethodFail(t *testing.T) {
 	}
 }
 
+func TestConcurrentHashFlatMapMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require 'concurrent/hash'
+		h = Concurrent::Hash.new({ c: 3, a: 1, b: 2 })
+		h.flat_map do |k, v|
+		  if v > 1
+		    [k, v]
+		  else
+		    k
+		  end
+		end
+		`, []interface{}{"a", "b", 2, "c", 3}},
+		// arrays are flattened only one level
+		{`
+		require 'concurrent/hash'
+		h = Concurrent::Hash.new({ a: 1, b: 2 })
+		h.flat_map do |k, v|
+		  [[k, v]]
+		end
+		`, []interface{}{[]interface{}{"a", 1}, []interface{}{"b", 2}}},
+		{`
+		require 'concurrent/hash'
+		h = Concurrent::Hash.new({ a: 1, b: 2 })
+		h.flat_map do |k, v|
+		  []
+		end
+		`, []interface{}{}},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({}).flat_map do |k, v|
+		  [k, v]
+		end
+		`, []interface{}{}},
+		// the block can modify the hash
+		{`
+		require 'concurrent/hash'
+		h = Concurrent::Hash.new({ a: 1 })
+		r = h.flat_map do |k, v|
+		  h["b"] = 2
+		  [v]
+		end
+		[r, h["b"]]
+		`, []interface{}{[]interface{}{1}, 2}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentHashFlatMapMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: 1 }).flat_map(1) do end`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: 1 }).flat_map`, "InternalError: Can't yield without a block", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestConcurrentHashHasKeyMethod(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -428,92 +713,411 @@ func TestConcurrentHashHasKeyMethodFail(t *testing.T) {
 	}
 }
 
-func TestConcurrentHashToJSONMethodWithArray(t *testing.T) {
+func TestConcurrentHashMergeMethod(t *testing.T) {
 	tests := []struct {
 		input    string
-		expected interface{}
+		expected map[string]interface{}
 	}{
 		{`
 		require 'concurrent/hash'
-		Concurrent::Hash.new({ a: 1, b: [1, "2", true]}).to_json
-		`, struct {
-			A int           `json:"a"`
-			B []interface{} `json:"b"`
-		}{
-			A: 1,
-			B: []interface{}{1, "2", true},
-		}},
+		Concurrent::Hash.new({ a: 1, b: 2 }).merge({ b: 3, c: 4 })
+		`, map[string]interface{}{"a": 1, "b": 3, "c": 4}},
 		{`
 		require 'concurrent/hash'
-		Concurrent::Hash.new({ a: 1, b: [1, "2", [4, 5, nil], { foo: "bar" }]}).to_json
-		`, struct {
-			A int           `json:"a"`
-			B []interface{} `json:"b"`
-		}{
-			A: 1,
-			B: []interface{}{
-				1, "2", []interface{}{4, 5, nil}, struct {
-					Foo string `json:"foo"`
-				}{
-					"bar",
-				},
-			},
-		}},
+		Concurrent::Hash.new({ a: 1 }).merge({ a: 2, b: 2 }, Concurrent::Hash.new({ b: 3 }), { a: 4 })
+		`, map[string]interface{}{"a": 4, "b": 3}},
+		{`
+		require 'concurrent/hash'
+		h = Concurrent::Hash.new({ a: 1 })
+		h.merge({ b: 2 })
+		h
+		`, map[string]interface{}{"a": 1}},
+		{`
+		require 'concurrent/hash'
+		h = Concurrent::Hash.new({ a: 1, b: 2 })
+		h.merge!({ b: 3 }, { c: 4 }, { c: 5 })
+		h
+		`, map[string]interface{}{"a": 1, "b": 3, "c": 5}},
+		{`
+		require 'concurrent/hash'
+		h = Concurrent::Hash.new({ a: 1 })
+		h.merge!(h, { b: 2 })
+		`, map[string]interface{}{"a": 1, "b": 2}},
 	}
 
 	for i, tt := range tests {
 		v := initTestVM()
 		evaluated := v.testEval(t, tt.input, getFilename())
-		compareJSONResult(t, evaluated, tt.expected)
+		verifyConcurrentHashObject(t, i, evaluated, tt.expected)
 		v.checkCFP(t, i, 0)
 		v.checkSP(t, i, 1)
 	}
-}
 
-func TestConcurrentHashToJSONMethodWithNestedHash(t *testing.T) {
-	tests := []struct {
+	tests2 := []struct {
 		input    string
 		expected interface{}
 	}{
+		// merging several hashes at once gives the same result as merging them one by one
 		{`
 		require 'concurrent/hash'
-		Concurrent::Hash.new({ a: 1, b: { c: 2 }}).to_json
-		`, struct {
-			A int `json:"a"`
-			B struct {
-				C int `json:"c"`
-			} `json:"b"`
-		}{
-			1,
-			struct {
-				C int `json:"c"`
-			}{C: 2},
-		}},
+		h = Concurrent::Hash.new({ a: 1, b: 1, c: 1 })
+		x = { a: 2, d: 2 }
+		y = Concurrent::Hash.new({ b: 3, d: 3 })
+		z = { d: 4, e: 4 }
+		h.merge(x, y, z).snapshot == h.merge(x).merge(y).merge(z).snapshot
+		`, true},
 		{`
 		require 'concurrent/hash'
-		Concurrent::Hash.new({ a: 1, b: { c: 2, d: { e: "foo" }}}).to_json
-		`, struct {
-			A int `json:"a"`
-			B struct {
-				C int `json:"c"`
-				D struct {
-					E string `json:"e"`
-				} `json:"d"`
-			} `json:"b"`
-		}{
-			1,
-			struct {
-				C int `json:"c"`
-				D struct {
-					E string `json:"e"`
-				} `json:"d"`
-			}{C: 2, D: struct {
-				E string `json:"e"`
-			}{E: "foo"}},
-		}},
+		h1 = Concurrent::Hash.new({ a: 1, b: 1 })
+		h2 = Concurrent::Hash.new({ a: 1, b: 1 })
+		h1.merge!({ a: 2, c: 2 }, { c: 3 })
+		h2.merge!({ a: 2, c: 2 })
+		h2.merge!({ c: 3 })
+		h1.snapshot == h2.snapshot
+		`, true},
+		{`
+		require 'concurrent/hash'
+		h = Concurrent::Hash.new({ a: 1 })
+		h.merge!({ b: 2 }).object_id == h.object_id
+		`, true},
 	}
 
-	for i, tt := range tests {
+	for i, tt := range tests2 {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentHashMergeMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: 1 }).merge`, "ArgumentError: Expect 1 or more argument(s). got: 0", 1},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: 1 }).merge({ b: 1 }, [1])`, "TypeError: Expect argument to be Hash. got: Array", 1},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: 1 }).merge!`, "ArgumentError: Expect 1 or more argument(s). got: 0", 1},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: 1 }).merge!(1)`, "TypeError: Expect argument to be Hash. got: Integer", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentHashRejectMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require 'concurrent/hash'
+		h = Concurrent::Hash.new({ a: 1, b: 2, c: 3, d: 4 })
+		r = h.reject do |k, v|
+		  v > 2
+		end
+		[r["a"], r["b"], r.has_key?("c"), r.has_key?("d"), h["c"], h["d"], r.class == h.class]
+		`, []interface{}{1, 2, false, false, 3, 4, true}},
+		{`
+		require 'concurrent/hash'
+		h = Concurrent::Hash.new({ a: 1, b: nil, c: false })
+		r = h.reject do |k, v|
+		  v
+		end
+		[r.has_key?("a"), r.has_key?("b"), r.has_key?("c")]
+		`, []interface{}{false, true, true}},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: 1 }).reject do |k, v|
+		  k == "a"
+		end.to_s
+		`, `{  }`},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({}).reject do |k, v|
+		  true
+		end.to_s
+		`, `{  }`},
+		// the block can modify the hash
+		{`
+		require 'concurrent/hash'
+		h = Concurrent::Hash.new({ a: 1 })
+		r = h.reject do |k, v|
+		  h["b"] = 2
+		  false
+		end
+		[r.to_s, h["b"]]
+		`, []interface{}{`{ a: 1 }`, 2}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentHashRejectMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: 1 }).reject(1) do end`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: 1 }).reject`, "InternalError: Can't yield without a block", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentHashSampleMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require 'concurrent/hash'
+		h = Concurrent::Hash.new({ a: 1, b: 2, c: 3 })
+		k, v = h.sample
+		h[k] == v
+		`, true},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: 1 }).sample
+		`, []interface{}{"a", 1}},
+		// sample(n) returns distinct pairs
+		{`
+		require 'concurrent/hash'
+		h = Concurrent::Hash.new({ a: 1, b: 2, c: 3, d: 4, e: 5 })
+		s = h.sample(3)
+		keys = {}
+		s.each do |pair|
+		  keys[pair[0]] = true
+		end
+		invalid = s.any? do |pair|
+		  h[pair[0]] != pair[1]
+		end
+		[s.length, keys.length, invalid]
+		`, []interface{}{3, 3, false}},
+		{`
+		require 'concurrent/hash'
+		h = Concurrent::Hash.new({ a: 1, b: 2, c: 3 })
+		h.sample(10).map do |pair| pair[0] end.sort
+		`, []interface{}{"a", "b", "c"}},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: 1 }).sample(0)
+		`, []interface{}{}},
+		// a seeded sample is the same every time
+		{`
+		require 'concurrent/hash'
+		h = Concurrent::Hash.new({ a: 1, b: 2, c: 3, d: 4, e: 5, f: 6, g: 7, h: 8 })
+		[h.sample({ seed: 42 }) == h.sample({ seed: 42 }), h.sample(4, { seed: 7 }) == h.sample(4, { seed: 7 })]
+		`, []interface{}{true, true}},
+		// and picks every pair with some seed
+		{`
+		require 'concurrent/hash'
+		h = Concurrent::Hash.new({ a: 1, b: 2, c: 3 })
+		keys = {}
+		50.times do |i|
+		  keys[h.sample({ seed: i })[0]] = true
+		end
+		keys.keys.sort
+		`, []interface{}{"a", "b", "c"}},
+		// empty hashes
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new.sample
+		`, nil},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new.sample(2, { seed: 1 })
+		`, []interface{}{}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentHashSampleIsDeterministicWithSeed(t *testing.T) {
+	input := `
+	require 'concurrent/hash'
+	h = Concurrent::Hash.new
+	100.times do |i|
+	  h["k" + i.to_s] = i
+	end
+	[h.sample({ seed: 1749 }), h.sample(5, { seed: 1749 })]
+	`
+
+	// the map's iteration order differs between runs, which the seeded result mustn't depend on
+	var results []string
+
+	for i := 0; i < 5; i++ {
+		v := initTestVM()
+		evaluated := v.testEval(t, input, getFilename())
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+		results = append(results, evaluated.Inspect())
+	}
+
+	for i, result := range results {
+		if result != results[0] {
+			t.Errorf("At run %d: Expect the seeded sample to be %s. got: %s", i, results[0], result)
+		}
+	}
+}
+
+func TestConcurrentHashSampleMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: 1 }).sample(-1)
+		`, "ArgumentError: Expect argument to be positive value. got: -1", 1},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: 1 }).sample("1")
+		`, "TypeError: Expect argument to be Integer or Hash. got: String", 1},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: 1 }).sample(1, 2)
+		`, "TypeError: Expect argument #2 to be Hash. got: Integer", 1},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: 1 }).sample("1", {})
+		`, "TypeError: Expect argument #1 to be Integer. got: String", 1},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: 1 }).sample({ random: 1 })
+		`, "ArgumentError: Unknown option random for Concurrent::Hash#sample", 1},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: 1 }).sample({ seed: "1" })
+		`, "TypeError: Expect option seed to be Integer. got: String", 1},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: 1 }).sample(1, {}, 2)
+		`, "ArgumentError: Expect 2 or less argument(s). got: 3", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentHashToJSONMethodWithArray(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: 1, b: [1, "2", true]}).to_json
+		`, struct {
+			A int           `json:"a"`
+			B []interface{} `json:"b"`
+		}{
+			A: 1,
+			B: []interface{}{1, "2", true},
+		}},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: 1, b: [1, "2", [4, 5, nil], { foo: "bar" }]}).to_json
+		`, struct {
+			A int           `json:"a"`
+			B []interface{} `json:"b"`
+		}{
+			A: 1,
+			B: []interface{}{
+				1, "2", []interface{}{4, 5, nil}, struct {
+					Foo string `json:"foo"`
+				}{
+					"bar",
+				},
+			},
+		}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		compareJSONResult(t, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentHashToJSONMethodWithNestedHash(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: 1, b: { c: 2 }}).to_json
+		`, struct {
+			A int `json:"a"`
+			B struct {
+				C int `json:"c"`
+			} `json:"b"`
+		}{
+			1,
+			struct {
+				C int `json:"c"`
+			}{C: 2},
+		}},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: 1, b: { c: 2, d: { e: "foo" }}}).to_json
+		`, struct {
+			A int `json:"a"`
+			B struct {
+				C int `json:"c"`
+				D struct {
+					E string `json:"e"`
+				} `json:"d"`
+			} `json:"b"`
+		}{
+			1,
+			struct {
+				C int `json:"c"`
+				D struct {
+					E string `json:"e"`
+				} `json:"d"`
+			}{C: 2, D: struct {
+				E string `json:"e"`
+			}{E: "foo"}},
+		}},
+	}
+
+	for i, tt := range tests {
 		v := initTestVM()
 		evaluated := v.testEval(t, tt.input, getFilename())
 		compareJSONResult(t, evaluated, tt.expected)
@@ -647,3 +1251,393 @@ func TestConcurrentHashToStringMethodFail(t *testing.T) {
 		v.checkSP(t, i, 1)
 	}
 }
+
+func TestConcurrentHashSnapshotMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new.snapshot
+		`, canonical(`{}`)},
+		{`
+		require 'concurrent/hash'
+		require 'concurrent/array'
+		h = Concurrent::Hash.new({ a: [1, { b: "c" }], d: Concurrent::Array.new([2.5]), e: Concurrent::Hash.new({ f: nil }) })
+		h.snapshot
+		`, canonical(`{"a": [1, {"b": "c"}], "d": [2.5], "e": {"f": nil}}`)},
+		// the snapshot doesn't share anything with the receiver
+		{`
+		require 'concurrent/hash'
+		h = Concurrent::Hash.new({ a: [1], s: "x" })
+		s = h.snapshot
+		h["a"].push(2)
+		h["s"].concat("y")
+		h["b"] = 3
+		s
+		`, canonical(`{"a": [1], "s": "x"}`)},
+		// each value is copied at a single point in time, while another thread keeps replacing them
+		{`
+		require 'concurrent/hash'
+		h = Concurrent::Hash.new({ a: [0, 0], b: [0, 0] })
+		c = Channel.new
+
+		thread do
+		  i = 1
+		  while i <= 200 do
+		    h[:a] = [i, i * 2]
+		    h[:b] = [i, i * 2]
+		    i += 1
+		  end
+		  c.deliver(nil)
+		end
+
+		consistent = true
+		j = 0
+		while j < 200 do
+		  h.snapshot.each do |k, v|
+		    if v[1] != v[0] * 2
+		      consistent = false
+		    end
+		  end
+		  j += 1
+		end
+
+		c.receive
+		consistent
+		`, true},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentHashSnapshotMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: 1 }).snapshot(1)`, "ArgumentError: Expect 0 argument(s). got: 1", 3},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: [1, { b: Channel.new }] }).snapshot`, "TypeError: Can't snapshot Channel at [\"a\"][1][\"b\"]: only nil, Boolean, Integer, Float, String, Array and Hash values are supported", 3},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 1)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentHashFromJSONMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected map[string]interface{}
+	}{
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.from_json('{}')
+		`, map[string]interface{}{}},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.from_json('{"a": 1, "b": 1.5, "c": "d", "e": true, "f": null}')
+		`, map[string]interface{}{"a": 1, "b": 1.5, "c": "d", "e": true, "f": nil}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		verifyConcurrentHashObject(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+
+	input := `
+	require 'concurrent/hash'
+	h = Concurrent::Hash.from_json('{"a": [1, {"b": [2]}]}')
+	h["a"]
+	`
+	v := initTestVM()
+	evaluated := v.testEval(t, input, getFilename())
+	VerifyExpected(t, 0, evaluated, canonical(`[1, {"b": [2]}]`))
+}
+
+func TestConcurrentHashFromJSONMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.from_json('[1]')`, "TypeError: Expect the JSON to be an object. got: an array", 3},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.from_json('1')`, "TypeError: Expect the JSON to be an object. got: Integer", 3},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.from_json('{"a": ')`, "ArgumentError: Can't parse string `{\"a\": ` as json: unexpected EOF", 3},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.from_json('{} {}')`, "ArgumentError: Can't parse string `{} {}` as json: unexpected data after the top-level value", 3},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.from_json(1)`, "TypeError: Expect argument to be String. got: Integer", 3},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.from_json`, "ArgumentError: Expect 1 argument(s). got: 0", 3},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 1)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentHashPersistAndLoadMethods(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goby_concurrent_hash")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "state.json")
+
+	input := fmt.Sprintf(`
+	require 'concurrent/hash'
+	h = Concurrent::Hash.new({ a: [1, 2], b: { c: "d" } })
+	h.persist("%[1]s")
+	h["a"] = 0
+	h.persist("%[1]s")
+	h["a"] = 3
+	Concurrent::Hash.load("%[1]s")
+	`, path)
+
+	v := initTestVM()
+	evaluated := v.testEval(t, input, getFilename())
+	VerifyExpected(t, 0, evaluated, canonical(`Concurrent::Hash{"a": 0, "b": {"c": "d"}}`))
+	v.checkCFP(t, 0, 0)
+	v.checkSP(t, 0, 1)
+
+	// the temporary files are renamed over the path
+	files, err := ioutil.ReadDir(dir)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 1 || files[0].Name() != "state.json" {
+		t.Errorf("Expect only state.json to be in the directory. got: %d files", len(files))
+	}
+}
+
+func TestConcurrentHashPersistAndLoadMethodsFail(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goby_concurrent_hash")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "state.json")
+
+	testsFail := []errorTestCase{
+		{fmt.Sprintf(`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: Channel.new }).persist("%s")`, path), "TypeError: Can't snapshot Channel at [\"a\"]: only nil, Boolean, Integer, Float, String, Array and Hash values are supported", 3},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new.persist(1)`, "TypeError: Expect argument to be String. got: Integer", 3},
+		{fmt.Sprintf(`
+		require 'concurrent/hash'
+		Concurrent::Hash.load("%s")`, filepath.Join(dir, "missing.json")), fmt.Sprintf("IOError: open %s: no such file or directory", filepath.Join(dir, "missing.json")), 3},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 1)
+		v.checkSP(t, i, 1)
+	}
+
+	// a failed snapshot doesn't touch the file
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expect %s not to be written", path)
+	}
+}
+
+func TestConcurrentHashTransformKeysMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require 'concurrent/hash'
+		h = Concurrent::Hash.new({ a: 1, b: 2 })
+		r = h.transform_keys do |k|
+		  k.upcase
+		end
+		[r["A"], r["B"], r.has_key?("a"), h["a"], h.has_key?("A"), r.class == h.class]
+		`, []interface{}{1, 2, false, 1, false, true}},
+		// colliding keys take the value of the last key in sorted order
+		{`
+		require 'concurrent/hash'
+		h = Concurrent::Hash.new({ a: 1, A: 2, b: 3 })
+		r = h.transform_keys do |k|
+		  k.upcase
+		end
+		[r["A"], r["B"], r.has_key?("a")]
+		`, []interface{}{1, 3, false}},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({}).transform_keys do |k|
+		  k.upcase
+		end.to_s
+		`, `{  }`},
+		{`
+		require 'concurrent/hash'
+		h = Concurrent::Hash.new({ a: 1, b: 2 })
+		r = h.transform_keys! do |k|
+		  k.upcase
+		end
+		[h["A"], h["B"], h.has_key?("a"), h.has_key?("b"), r.object_id == h.object_id]
+		`, []interface{}{1, 2, false, false, true}},
+		{`
+		require 'concurrent/hash'
+		h = Concurrent::Hash.new({ a: 1, A: 2 })
+		h.transform_keys! do |k|
+		  k.upcase
+		end
+		h.to_s
+		`, `{ A: 1 }`},
+		// a key mapped to itself is kept
+		{`
+		require 'concurrent/hash'
+		h = Concurrent::Hash.new({ a: 1, b: 2 })
+		h.transform_keys! do |k|
+		  if k == "a"
+		    "b"
+		  else
+		    k
+		  end
+		end
+		h.to_s
+		`, `{ b: 2 }`},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentHashTransformKeysMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: 1 }).transform_keys(1) do end`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: 1 }).transform_keys`, "InternalError: Can't yield without a block", 1},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: 1 }).transform_keys! do |k|
+		  1
+		end`, "TypeError: Expect the block to return a String key. got: Integer", 1},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: 1 }).transform_keys! do |k|
+		  k.foo
+		end`, "NoMethodError: Undefined Method 'foo' for \"a\"", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentHashValuesSnapshotMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ c: 3, a: 1, b: 2 }).values_snapshot
+		`, []interface{}{[]interface{}{"a", "b", "c"}, []interface{}{1, 2, 3}}},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({}).values_snapshot
+		`, []interface{}{[]interface{}{}, []interface{}{}}},
+		// the keys and the values stay aligned while another thread changes the hash
+		{`
+		require 'concurrent/hash'
+		h = Concurrent::Hash.new({})
+		c = Channel.new
+
+		thread do
+		  i = 0
+		  while i < 500 do
+		    key = "k" + (i % 20).to_s
+		    h[key] = key
+		    h.delete("k" + ((i + 7) % 20).to_s)
+		    i += 1
+		  end
+		  c.deliver(true)
+		end
+
+		aligned = true
+		j = 0
+		while j < 500 do
+		  keys, values = h.values_snapshot
+		  aligned = aligned && keys == values && keys == keys.sort
+		  j += 1
+		end
+
+		c.receive
+		aligned
+		`, true},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentHashValuesSnapshotMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: 1 }).values_snapshot(1)`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
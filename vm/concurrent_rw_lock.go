@@ -170,7 +170,8 @@ var builtinConcurrentRWLockInstanceMethods = []*BuiltinMethodObject{
 
 			lockObject.mutex.RLock()
 
-			blockReturnValue := t.builtinMethodYield(blockFrame)
+			// An error raised in the block is returned after the lock is released
+			blockReturnValue, _ := t.builtinMethodYield(blockFrame)
 
 			lockObject.mutex.RUnlock()
 
@@ -204,7 +205,8 @@ var builtinConcurrentRWLockInstanceMethods = []*BuiltinMethodObject{
 
 			lockObject.mutex.Lock()
 
-			blockReturnValue := t.builtinMethodYield(blockFrame)
+			// An error raised in the block is returned after the lock is released
+			blockReturnValue, _ := t.builtinMethodYield(blockFrame)
 
 			lockObject.mutex.Unlock()
 
@@ -257,5 +259,5 @@ func (lock *ConcurrentRWLockObject) Inspect() string {
 
 // ToJSON just delegates to ToString
 func (lock *ConcurrentRWLockObject) ToJSON(t *Thread) string {
-	return lock.ToString()
+	return unsupportedJSON(lock)
 }
@@ -0,0 +1,197 @@
+package vm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/goby-lang/goby/vm/errors"
+)
+
+// Helpers shared by the concurrent collections' `snapshot`, `persist`, `from_json` and `load` methods.
+
+const (
+	cantSnapshotValue = "Can't snapshot %s at %s: only nil, Boolean, Integer, Float, String, Array and Hash values are supported"
+	cantParseJSON     = "Can't parse string `%s` as json: %s"
+	cantPersist       = "Can't persist to %s: %s"
+	unexpectedJSON    = "Expect the JSON to be %s. got: %s"
+)
+
+// snapshotValue returns a deep copy of a value stored in a concurrent collection, made of plain Arrays and Hashes,
+// so it can be read or persisted as JSON without racing with later writes.
+// Nested concurrent collections are copied under their own locks.
+// Only JSON-compatible values can be copied, and the path locates the offending value in the error otherwise.
+func (t *Thread) snapshotValue(obj Object, path string, sourceLine int) Object {
+	switch o := obj.(type) {
+	case *IntegerObject, *FloatObject, *BooleanObject, *NullObject:
+		return o
+	case *StringObject:
+		return t.vm.InitStringObject(o.value)
+	case *ArrayObject:
+		return t.snapshotElements(o.Elements, path, sourceLine)
+	case *ConcurrentArrayObject:
+		return t.snapshotElements(o.snapshot(), path, sourceLine)
+	case *HashObject:
+		return t.snapshotPairs(o.Pairs, path, sourceLine)
+	case *ConcurrentHashObject:
+		return t.snapshotPairs(o.pairs(), path, sourceLine)
+	default:
+		return t.vm.InitErrorObject(errors.TypeError, sourceLine, cantSnapshotValue, obj.Class().Name, path)
+	}
+}
+
+func (t *Thread) snapshotElements(elems []Object, path string, sourceLine int) Object {
+	copied := make([]Object, len(elems))
+
+	for i, elem := range elems {
+		copied[i] = t.snapshotValue(elem, fmt.Sprintf("%s[%d]", path, i), sourceLine)
+
+		if err, ok := copied[i].(*Error); ok {
+			return err
+		}
+	}
+
+	return t.vm.InitArrayObject(copied)
+}
+
+func (t *Thread) snapshotPairs(pairs map[string]Object, path string, sourceLine int) Object {
+	copied := make(map[string]Object, len(pairs))
+
+	for key, value := range pairs {
+		copied[key] = t.snapshotValue(value, fmt.Sprintf("%s[%q]", path, key), sourceLine)
+
+		if err, ok := copied[key].(*Error); ok {
+			return err
+		}
+	}
+
+	return t.vm.InitHashObject(copied)
+}
+
+// persistSnapshot writes the snapshot as JSON to the path atomically
+func (t *Thread) persistSnapshot(snapshot Object, path string, sourceLine int) *Error {
+	err := writeFileAtomically(path, []byte(t.toJSON(snapshot)))
+
+	// The temporary file's name is random, so only the cause is reported
+	if pathErr, ok := err.(*os.PathError); ok {
+		err = pathErr.Err
+	}
+
+	if err != nil {
+		return t.vm.InitErrorObject(errors.IOError, sourceLine, cantPersist, path, err.Error())
+	}
+
+	return nil
+}
+
+// writeFileAtomically writes the data to a temporary file in the same directory, then renames it over the path,
+// so readers see either the old content or the new one, never a partial write.
+func writeFileAtomically(path string, data []byte) (err error) {
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			os.Remove(f.Name())
+		}
+	}()
+
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err = f.Chmod(0644); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err = f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err = f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// readJSONFile reads the file at the path and decodes its content with decodeJSON
+func (t *Thread) readJSONFile(path string, sourceLine int) Object {
+	content, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return t.vm.InitErrorObject(errors.IOError, sourceLine, err.Error())
+	}
+
+	return t.decodeJSON(string(content), sourceLine)
+}
+
+// decodeJSON parses any JSON value into plain Goby objects.
+// Unlike `JSON.parse`, any value can be at the top level, and numbers without a fraction or an exponent become Integers.
+func (t *Thread) decodeJSON(text string, sourceLine int) Object {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+
+	var value interface{}
+
+	if err := decoder.Decode(&value); err != nil {
+		return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, cantParseJSON, text, err.Error())
+	}
+
+	if decoder.More() {
+		return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, cantParseJSON, text, "unexpected data after the top-level value")
+	}
+
+	return t.vm.initObjectFromJSON(value)
+}
+
+func (vm *VM) initObjectFromJSON(value interface{}) Object {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		pairs := make(map[string]Object, len(v))
+
+		for key, elem := range v {
+			pairs[key] = vm.initObjectFromJSON(elem)
+		}
+
+		return vm.InitHashObject(pairs)
+	case []interface{}:
+		elems := make([]Object, len(v))
+
+		for i, elem := range v {
+			elems[i] = vm.initObjectFromJSON(elem)
+		}
+
+		return vm.InitArrayObject(elems)
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return vm.InitIntegerObject(int(i))
+		}
+
+		f, _ := v.Float64()
+		return vm.initFloatObject(f)
+	default:
+		return vm.InitObjectFromGoType(v)
+	}
+}
+
+// jsonKind names the kind of a decoded JSON value for error messages
+func jsonKind(obj Object) string {
+	switch obj.(type) {
+	case *HashObject:
+		return "an object"
+	case *ArrayObject:
+		return "an array"
+	default:
+		return obj.Class().Name
+	}
+}
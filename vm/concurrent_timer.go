@@ -0,0 +1,506 @@
+package vm
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/goby-lang/goby/vm/classes"
+	"github.com/goby-lang/goby/vm/errors"
+)
+
+// ConcurrentTimerObject runs a block later, once or periodically.
+//
+// `Timer.after` and `Timer.at` run the block once, and `Timer.every` runs it at a fixed interval until the timer
+// is cancelled. Every run happens on a separate thread, so the caller is never blocked.
+//
+// Periodic runs are scheduled from the time the timer started on the monotonic clock,
+// so a slow run doesn't push the next ones back. Ticks missed while the process was busy are skipped.
+// By default a run can start while the previous one is still executing; the `skip_overlap` option skips such runs instead.
+//
+// An error raised in the block is written to the vm's warning output, and doesn't stop a periodic timer.
+// All the timers of a vm are stopped by `VM.Shutdown`.
+//
+// ```ruby
+// require 'concurrent/timer'
+//
+// c = Channel.new
+// Concurrent::Timer.after(0.5) do
+//   c.deliver("done")
+// end
+// c.receive # => "done"
+//
+// t = Concurrent::Timer.every(1) do
+//   puts("tick")
+// end
+// t.cancel
+// ```
+//
+type ConcurrentTimerObject struct {
+	*BaseObj
+	block *BlockObject
+	// interval is 0 for timers that run once
+	interval    time.Duration
+	skipOverlap bool
+	stop        chan struct{}
+	// state is one of the timer* constants, and is accessed atomically
+	state int32
+	// runs and running count the started and the unfinished runs, and are accessed atomically
+	runs    int64
+	running int32
+}
+
+const (
+	timerPending int32 = iota
+	timerFired
+	timerCancelled
+)
+
+const (
+	negativeTimerDelay       = "Expect delay to be zero or positive. got: %s"
+	nonPositiveTimerInterval = "Expect interval to be positive. got: %s"
+	invalidTimerOption       = "Expect option %s to be %s. got: %s"
+	unknownTimerOption       = "Unknown option %s for Timer.every"
+)
+
+// timerRegistry keeps the timers that haven't stopped yet, so VM.Shutdown can stop them
+type timerRegistry struct {
+	sync.Mutex
+	timers map[*ConcurrentTimerObject]struct{}
+	// closed is set by VM.Shutdown, after which new timers are cancelled right away
+	closed bool
+	// loops tracks the scheduling goroutines, so Shutdown returns only once no run can start anymore
+	loops sync.WaitGroup
+	// warnLock keeps the warnings of overlapping runs from being written at the same time
+	warnLock sync.Mutex
+}
+
+// Class methods --------------------------------------------------------
+var builtinConcurrentTimerClassMethods = []*BuiltinMethodObject{
+	{
+		// Runs the block once on a separate thread after the given number of seconds, and returns the timer.
+		//
+		// ```ruby
+		// t = Concurrent::Timer.after(0.1) do
+		//   puts("later")
+		// end
+		// t.fired? # => false
+		// sleep(0.2)
+		// t.fired? # => true
+		// ```
+		//
+		// @param seconds [Numeric]
+		// @param block literal
+		// @return [Timer]
+		Name: "after",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			if blockFrame == nil {
+				return t.vm.InitErrorObject(errors.InternalError, sourceLine, errors.CantYieldWithoutBlockFormat)
+			}
+
+			delay, err := t.secondsOf(args[0], sourceLine)
+
+			if err != nil {
+				return err
+			}
+
+			if delay < 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, negativeTimerDelay, args[0].ToString())
+			}
+
+			tm := t.vm.initConcurrentTimerObject(blockFrame, 0, false)
+			t.vm.startTimer(tm, time.Now().Add(delay))
+
+			return tm
+
+		},
+	},
+	{
+		// Runs the block once on a separate thread at the given time, and returns the timer.
+		// A time in the past runs the block right away.
+		//
+		// ```ruby
+		// Concurrent::Timer.at(Time.now + 60) do
+		//   puts("a minute later")
+		// end
+		// ```
+		//
+		// @param time [Time]
+		// @param block literal
+		// @return [Timer]
+		Name: "at",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			if blockFrame == nil {
+				return t.vm.InitErrorObject(errors.InternalError, sourceLine, errors.CantYieldWithoutBlockFormat)
+			}
+
+			at, ok := args[0].(*TimeObject)
+
+			if !ok {
+				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.TimeClass, args[0].Class().Name)
+			}
+
+			tm := t.vm.initConcurrentTimerObject(blockFrame, 0, false)
+			// Times created by Goby have no monotonic reading, so the wait is based on the wall clock
+			t.vm.startTimer(tm, time.Now().Add(time.Until(at.value)))
+
+			return tm
+
+		},
+	},
+	{
+		// Runs the block on a separate thread every given number of seconds until the timer is cancelled,
+		// and returns the timer. The first run happens one interval after the call.
+		//
+		// The only option is `skip_overlap`: when it's true, a run is skipped if the previous one is still executing.
+		//
+		// ```ruby
+		// t = Concurrent::Timer.every(0.5, { skip_overlap: true }) do
+		//   puts("tick")
+		// end
+		// sleep(2)
+		// t.cancel
+		// ```
+		//
+		// @param seconds [Numeric]
+		// @param options [Hash]
+		// @param block literal
+		// @return [Timer]
+		Name: "every",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			aLen := len(args)
+
+			if aLen < 1 || aLen > 2 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgumentRange, 1, 2, aLen)
+			}
+
+			if blockFrame == nil {
+				return t.vm.InitErrorObject(errors.InternalError, sourceLine, errors.CantYieldWithoutBlockFormat)
+			}
+
+			interval, err := t.secondsOf(args[0], sourceLine)
+
+			if err != nil {
+				return err
+			}
+
+			if interval <= 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, nonPositiveTimerInterval, args[0].ToString())
+			}
+
+			skipOverlap := false
+
+			if aLen == 2 {
+				options, ok := args[1].(*HashObject)
+
+				if !ok {
+					return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormatNum, 2, classes.HashClass, args[1].Class().Name)
+				}
+
+				for _, key := range options.sortedKeys() {
+					if key != "skip_overlap" {
+						return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, unknownTimerOption, key)
+					}
+
+					value, ok := options.Pairs[key].(*BooleanObject)
+
+					if !ok {
+						return t.vm.InitErrorObject(errors.TypeError, sourceLine, invalidTimerOption, key, classes.BooleanClass, options.Pairs[key].Class().Name)
+					}
+
+					skipOverlap = value == TRUE
+				}
+			}
+
+			tm := t.vm.initConcurrentTimerObject(blockFrame, interval, skipOverlap)
+			t.vm.startTimer(tm, time.Now())
+
+			return tm
+
+		},
+	},
+}
+
+// Instance methods -----------------------------------------------------
+var builtinConcurrentTimerInstanceMethods = []*BuiltinMethodObject{
+	{
+		// Stops the timer, so the block isn't run anymore. Runs already started aren't interrupted.
+		// Returns true if the timer was stopped by the call, or false if it had already fired once or been cancelled.
+		//
+		// ```ruby
+		// t = Concurrent::Timer.after(1) do
+		//   puts("never")
+		// end
+		// t.cancel # => true
+		// t.cancel # => false
+		// ```
+		//
+		// @return [Boolean]
+		Name: "cancel",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			return toBooleanObject(receiver.(*ConcurrentTimerObject).cancel())
+
+		},
+	},
+	{
+		// Returns true if the timer has been cancelled.
+		//
+		// ```ruby
+		// t = Concurrent::Timer.every(1) do
+		//   puts("tick")
+		// end
+		// t.cancel
+		// t.cancelled? # => true
+		// ```
+		//
+		// @return [Boolean]
+		Name: "cancelled?",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			return toBooleanObject(atomic.LoadInt32(&receiver.(*ConcurrentTimerObject).state) == timerCancelled)
+
+		},
+	},
+	{
+		// Returns true once the block has started running at least once.
+		//
+		// ```ruby
+		// t = Concurrent::Timer.after(0.1) do
+		//   1
+		// end
+		// t.fired? # => false
+		// sleep(0.2)
+		// t.fired? # => true
+		// ```
+		//
+		// @return [Boolean]
+		Name: "fired?",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			return toBooleanObject(atomic.LoadInt64(&receiver.(*ConcurrentTimerObject).runs) > 0)
+
+		},
+	},
+	{
+		// Returns how many times the block has started running. Runs skipped because of `skip_overlap` aren't counted.
+		//
+		// ```ruby
+		// t = Concurrent::Timer.every(0.1) do
+		//   1
+		// end
+		// sleep(0.35)
+		// t.cancel
+		// t.runs # => 3
+		// ```
+		//
+		// @return [Integer]
+		Name: "runs",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			return t.vm.InitIntegerObject(int(atomic.LoadInt64(&receiver.(*ConcurrentTimerObject).runs)))
+
+		},
+	},
+}
+
+// Internal functions ===================================================
+
+// Functions for initialization -----------------------------------------
+
+func (vm *VM) initConcurrentTimerObject(blockFrame *normalCallFrame, interval time.Duration, skipOverlap bool) *ConcurrentTimerObject {
+	concurrentModule := vm.loadConstant("Concurrent", true)
+	timerClass := concurrentModule.getClassConstant("Timer")
+
+	return &ConcurrentTimerObject{
+		BaseObj:     NewBaseObject(timerClass),
+		block:       vm.initBlockObject(blockFrame.instructionSet, blockFrame.ep, blockFrame.self, blockFrame.sourceLine),
+		interval:    interval,
+		skipOverlap: skipOverlap,
+		stop:        make(chan struct{}),
+	}
+}
+
+func initConcurrentTimerClass(vm *VM) {
+	concurrentModule := vm.loadConstant("Concurrent", true)
+	timerClass := vm.initializeClass("Timer")
+
+	timerClass.setBuiltinMethods(builtinConcurrentTimerInstanceMethods, false)
+	timerClass.setBuiltinMethods(builtinConcurrentTimerClassMethods, true)
+
+	concurrentModule.setClassConstant(timerClass)
+}
+
+// Shutdown stops all the timers created by `Concurrent::Timer`, and the ones created afterwards are cancelled right away.
+// Once it returns, no timer starts a new run; runs that already started aren't waited for.
+func (vm *VM) Shutdown() {
+	r := &vm.timerRegistry
+
+	r.Lock()
+	r.closed = true
+	timers := make([]*ConcurrentTimerObject, 0, len(r.timers))
+
+	for tm := range r.timers {
+		timers = append(timers, tm)
+	}
+	r.Unlock()
+
+	for _, tm := range timers {
+		tm.cancel()
+	}
+
+	r.loops.Wait()
+}
+
+// Polymorphic helper functions -----------------------------------------
+
+// Value returns the number of started runs
+func (tm *ConcurrentTimerObject) Value() interface{} {
+	return int(atomic.LoadInt64(&tm.runs))
+}
+
+// ToString returns the object's name as the string format
+func (tm *ConcurrentTimerObject) ToString() string {
+	return "#<" + tm.class.Name + " >"
+}
+
+// Inspect delegates to ToString
+func (tm *ConcurrentTimerObject) Inspect() string {
+	return tm.ToString()
+}
+
+// ToJSON just delegates to ToString
+func (tm *ConcurrentTimerObject) ToJSON(t *Thread) string {
+	return unsupportedJSON(tm)
+}
+
+// Other helper functions -----------------------------------------------
+
+// startTimer registers the timer and starts scheduling its runs from the given time.
+// A timer that runs once fires at start; a periodic one fires at start plus every multiple of its interval.
+func (vm *VM) startTimer(tm *ConcurrentTimerObject, start time.Time) {
+	r := &vm.timerRegistry
+
+	r.Lock()
+	defer r.Unlock()
+
+	if r.closed {
+		tm.cancel()
+		return
+	}
+
+	if r.timers == nil {
+		r.timers = make(map[*ConcurrentTimerObject]struct{})
+	}
+
+	r.timers[tm] = struct{}{}
+	r.loops.Add(1)
+
+	go func() {
+		defer func() {
+			r.Lock()
+			delete(r.timers, tm)
+			r.Unlock()
+			r.loops.Done()
+		}()
+
+		tm.schedule(vm, start)
+	}()
+}
+
+// schedule waits for each tick and fires the timer, until the timer is cancelled or has run once
+func (tm *ConcurrentTimerObject) schedule(vm *VM, start time.Time) {
+	next := start
+
+	if tm.interval > 0 {
+		next = start.Add(tm.interval)
+	}
+
+	for {
+		wait := time.NewTimer(time.Until(next))
+
+		select {
+		case <-tm.stop:
+			wait.Stop()
+			return
+		case <-wait.C:
+		}
+
+		if tm.interval == 0 {
+			if atomic.CompareAndSwapInt32(&tm.state, timerPending, timerFired) {
+				tm.fire(vm)
+			}
+
+			return
+		}
+
+		if atomic.LoadInt32(&tm.state) == timerCancelled {
+			return
+		}
+
+		if !tm.skipOverlap || atomic.LoadInt32(&tm.running) == 0 {
+			tm.fire(vm)
+		}
+
+		// The next tick is computed from the start instead of the previous tick, so delays don't add up
+		n := time.Since(start)/tm.interval + 1
+		next = start.Add(n * tm.interval)
+	}
+}
+
+// fire runs the block on a new thread. Errors are reported as warnings, so they don't stop a periodic timer.
+func (tm *ConcurrentTimerObject) fire(vm *VM) {
+	atomic.AddInt64(&tm.runs, 1)
+	atomic.AddInt32(&tm.running, 1)
+
+	go func() {
+		defer atomic.AddInt32(&tm.running, -1)
+
+		newT := vm.acquireThread()
+
+		c := newNormalCallFrame(tm.block.instructionSet, tm.block.instructionSet.filename, tm.block.sourceLine)
+		c.ep = tm.block.ep
+		c.self = tm.block.self
+		c.isBlock = true
+
+		result, erred := newT.builtinMethodYield(c)
+		vm.releaseThread(newT)
+
+		if erred {
+			vm.timerRegistry.warnLock.Lock()
+			fmt.Fprintf(vm.stderr, "warning: error in %s: %s\n", tm.class.Name, result.(*Error).message)
+			vm.timerRegistry.warnLock.Unlock()
+		}
+	}()
+}
+
+// cancel stops the timer and returns true, or returns false if it had already fired once or been cancelled
+func (tm *ConcurrentTimerObject) cancel() bool {
+	if !atomic.CompareAndSwapInt32(&tm.state, timerPending, timerCancelled) {
+		return false
+	}
+
+	close(tm.stop)
+	return true
+}
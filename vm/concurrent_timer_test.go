@@ -0,0 +1,342 @@
+package vm
+
+import (
+	"bytes"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConcurrentTimerAfter(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require 'concurrent/timer'
+		c = Channel.new
+		Concurrent::Timer.after(0.01) do
+		  c.deliver(42)
+		end
+		c.receive
+		`, 42},
+		{`
+		require 'concurrent/timer'
+		c = Channel.new
+		t = Concurrent::Timer.after(0) do
+		  c.deliver(1)
+		end
+		c.receive
+		[t.fired?, t.runs, t.cancel, t.cancelled?]
+		`, []interface{}{true, 1, false, false}},
+		// cancelling prevents the block from running
+		{`
+		require 'concurrent/timer'
+		t = Concurrent::Timer.after(0.05) do
+		  1
+		end
+		r = t.cancel
+		sleep(0.2)
+		[r, t.fired?, t.cancelled?, t.cancel]
+		`, []interface{}{true, false, true, false}},
+		{`
+		require 'concurrent/timer'
+		t = Concurrent::Timer.after(10) do
+		  1
+		end
+		r = t.fired?
+		t.cancel
+		r
+		`, false},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentTimerAt(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require 'concurrent/timer'
+		c = Channel.new
+		Concurrent::Timer.at(Time.now + 0.01) do
+		  c.deliver("at")
+		end
+		c.receive
+		`, "at"},
+		// a time in the past fires right away
+		{`
+		require 'concurrent/timer'
+		c = Channel.new
+		Concurrent::Timer.at(Time.at(0)) do
+		  c.deliver("past")
+		end
+		c.receive
+		`, "past"},
+		{`
+		require 'concurrent/timer'
+		t = Concurrent::Timer.at(Time.now + 0.05) do
+		  1
+		end
+		t.cancel
+		sleep(0.2)
+		t.fired?
+		`, false},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentTimerEvery(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require 'concurrent/timer'
+		c = Channel.new
+		t = Concurrent::Timer.every(0.01) do
+		  c.deliver(1)
+		end
+		r = c.receive + c.receive + c.receive
+		t.cancel
+		[r, t.runs >= 3, t.cancelled?]
+		`, []interface{}{3, true, true}},
+		// no run starts once the timer is cancelled
+		{`
+		require 'concurrent/timer'
+		t = Concurrent::Timer.every(0.01) do
+		  1
+		end
+		sleep(0.05)
+		t.cancel
+		r = t.runs
+		sleep(0.1)
+		t.runs == r
+		`, true},
+		// runs overlap by default
+		{`
+		require 'concurrent/timer'
+		t = Concurrent::Timer.every(0.02) do
+		  sleep(0.2)
+		end
+		sleep(0.3)
+		t.cancel
+		t.runs >= 5
+		`, true},
+		// and are skipped while the previous one is executing with skip_overlap
+		{`
+		require 'concurrent/timer'
+		t = Concurrent::Timer.every(0.02, { skip_overlap: true }) do
+		  sleep(0.2)
+		end
+		sleep(0.3)
+		t.cancel
+		t.runs
+		`, 2},
+		{`
+		require 'concurrent/timer'
+		t = Concurrent::Timer.every(0.02, { skip_overlap: false }) do
+		  sleep(0.2)
+		end
+		sleep(0.3)
+		t.cancel
+		t.runs >= 5
+		`, true},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestConcurrentTimerEveryWithoutDrift(t *testing.T) {
+	v := initTestVM()
+	evaluated := v.testEval(t, `
+	require 'concurrent/timer'
+	Concurrent::Timer.every(0.02, { skip_overlap: true }) do
+	  sleep(0.015)
+	end
+	`, getFilename())
+	tm := evaluated.(*ConcurrentTimerObject)
+
+	time.Sleep(500 * time.Millisecond)
+	tm.cancel()
+
+	// A timer waiting a full interval after each run would only fire about 14 times
+	if runs := atomic.LoadInt64(&tm.runs); runs < 18 || runs > 26 {
+		t.Errorf("Expect the timer to run about 25 times. got: %d", runs)
+	}
+}
+
+func TestConcurrentTimerErrorsAreWarnings(t *testing.T) {
+	v := initTestVM()
+	var stderr bytes.Buffer
+	v.SetStderr(&stderr)
+
+	evaluated := v.testEval(t, `
+	require 'concurrent/timer'
+	Concurrent::Timer.every(0.01, { skip_overlap: true }) do
+	  raise ArgumentError, "boom"
+	end
+	`, getFilename())
+	tm := evaluated.(*ConcurrentTimerObject)
+
+	// the timer keeps running after errors
+	for deadline := time.Now().Add(5 * time.Second); atomic.LoadInt64(&tm.runs) < 3; {
+		if time.Now().After(deadline) {
+			t.Fatal("Expect the timer to keep running after an error")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	v.Shutdown()
+
+	for atomic.LoadInt32(&tm.running) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	warning := "warning: error in Timer: ArgumentError: \"boom\"\n"
+
+	if !strings.HasPrefix(stderr.String(), warning+warning) {
+		t.Errorf("Expect the errors to be written as warnings. got: %q", stderr.String())
+	}
+}
+
+func TestConcurrentTimerShutdown(t *testing.T) {
+	v := initTestVM()
+	evaluated := v.testEval(t, `
+	require 'concurrent/timer'
+	periodic = Concurrent::Timer.every(0.01) do
+	  1
+	end
+	once = Concurrent::Timer.after(10) do
+	  1
+	end
+	[periodic, once]
+	`, getFilename())
+	timers := evaluated.(*ArrayObject).Elements
+
+	time.Sleep(50 * time.Millisecond)
+	v.Shutdown()
+
+	periodic := timers[0].(*ConcurrentTimerObject)
+	runs := atomic.LoadInt64(&periodic.runs)
+	time.Sleep(50 * time.Millisecond)
+
+	if atomic.LoadInt64(&periodic.runs) != runs {
+		t.Error("Expect the periodic timer to stop running after Shutdown")
+	}
+
+	for i, tm := range timers {
+		if atomic.LoadInt32(&tm.(*ConcurrentTimerObject).state) != timerCancelled {
+			t.Errorf("Expect timer %d to be cancelled by Shutdown", i)
+		}
+	}
+
+	if len(v.timerRegistry.timers) != 0 {
+		t.Errorf("Expect no timer to be left after Shutdown. got: %d", len(v.timerRegistry.timers))
+	}
+
+	// timers created after Shutdown never run
+	evaluated = v.testEval(t, `
+	require 'concurrent/timer'
+	Concurrent::Timer.after(0) do
+	  1
+	end
+	`, getFilename())
+	time.Sleep(20 * time.Millisecond)
+
+	if tm := evaluated.(*ConcurrentTimerObject); atomic.LoadInt64(&tm.runs) != 0 || atomic.LoadInt32(&tm.state) != timerCancelled {
+		t.Error("Expect a timer created after Shutdown to be cancelled")
+	}
+}
+
+func TestConcurrentTimerMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`
+		require 'concurrent/timer'
+		Concurrent::Timer.after(1)
+		`, "InternalError: Can't yield without a block", 1},
+		{`
+		require 'concurrent/timer'
+		Concurrent::Timer.after do
+		end
+		`, "ArgumentError: Expect 1 argument(s). got: 0", 1},
+		{`
+		require 'concurrent/timer'
+		Concurrent::Timer.after("1") do
+		end
+		`, "TypeError: Expect argument to be Numeric. got: String", 1},
+		{`
+		require 'concurrent/timer'
+		Concurrent::Timer.after(-1) do
+		end
+		`, "ArgumentError: Expect delay to be zero or positive. got: -1", 1},
+		{`
+		require 'concurrent/timer'
+		Concurrent::Timer.at(1) do
+		end
+		`, "TypeError: Expect argument to be Time. got: Integer", 1},
+		{`
+		require 'concurrent/timer'
+		Concurrent::Timer.every(0) do
+		end
+		`, "ArgumentError: Expect interval to be positive. got: 0", 1},
+		{`
+		require 'concurrent/timer'
+		Concurrent::Timer.every(1, 2) do
+		end
+		`, "TypeError: Expect argument #2 to be Hash. got: Integer", 1},
+		{`
+		require 'concurrent/timer'
+		Concurrent::Timer.every(1, { overlap: true }) do
+		end
+		`, "ArgumentError: Unknown option overlap for Timer.every", 1},
+		{`
+		require 'concurrent/timer'
+		Concurrent::Timer.every(1, { skip_overlap: 1 }) do
+		end
+		`, "TypeError: Expect option skip_overlap to be Boolean. got: Integer", 1},
+		{`
+		require 'concurrent/timer'
+		Concurrent::Timer.every(1, {}, 2) do
+		end
+		`, "ArgumentError: Expect 1 to 2 argument(s). got: 3", 1},
+		{`
+		require 'concurrent/timer'
+		t = Concurrent::Timer.after(10) do
+		end
+		t.cancel
+		t.cancel(1)
+		`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
@@ -0,0 +1,189 @@
+package vm
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+
+	"github.com/goby-lang/goby/vm/classes"
+	"github.com/goby-lang/goby/vm/errors"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	defaultBcryptCost  = 10
+	invalidBcryptCost  = "Expect bcrypt cost to be between %d and %d. got: %d"
+	invalidBcryptHash  = "Invalid bcrypt hash: %s"
+	unsupportedDigest  = "Unsupported digest: %s"
+	nonPositiveKDFArgs = "Expect %s to be positive. got: %d"
+)
+
+// pbkdf2Digests are the digests `Crypto.pbkdf2` accepts by name
+var pbkdf2Digests = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha224": sha256.New224,
+	"sha256": sha256.New,
+	"sha384": sha512.New384,
+	"sha512": sha512.New,
+}
+
+// Class methods --------------------------------------------------------
+var builtinCryptoClassMethods = []*BuiltinMethodObject{
+	{
+		// Hashes the password with bcrypt and a random salt, and returns the encoded hash,
+		// which contains the cost and the salt. The cost is 10 by default, and can be 4 to 31.
+		//
+		// ```ruby
+		// require "crypto"
+		//
+		// Crypto.bcrypt("secret")           # => "$2a$10$..."
+		// Crypto.bcrypt("secret", cost: 12) # => "$2a$12$..."
+		// ```
+		//
+		// @param password [String], cost [Integer]
+		// @return [String]
+		Name: "bcrypt",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) < 1 || len(args) > 2 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgumentRange, 1, 2, len(args))
+			}
+
+			password, ok := args[0].(*StringObject)
+
+			if !ok {
+				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormatNum, 1, classes.StringClass, args[0].Class().Name)
+			}
+
+			cost := defaultBcryptCost
+
+			if len(args) == 2 {
+				c, ok := args[1].(*IntegerObject)
+
+				if !ok {
+					return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormatNum, 2, classes.IntegerClass, args[1].Class().Name)
+				}
+
+				cost = c.value
+			}
+
+			if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, invalidBcryptCost, bcrypt.MinCost, bcrypt.MaxCost, cost)
+			}
+
+			hashed, err := bcrypt.GenerateFromPassword([]byte(password.value), cost)
+
+			if err != nil {
+				return t.vm.InitErrorObject(errors.InternalError, sourceLine, err.Error())
+			}
+
+			return t.vm.InitStringObject(string(hashed))
+
+		},
+	},
+	{
+		// Returns true if the password matches the hash returned by `Crypto.bcrypt`, false otherwise.
+		// The comparison takes the same time however much of the hash matches.
+		// It's an ArgumentError if the hash isn't a bcrypt hash.
+		//
+		// ```ruby
+		// require "crypto"
+		//
+		// hash = Crypto.bcrypt("secret")
+		// Crypto.bcrypt_verify("secret", hash) # => true
+		// Crypto.bcrypt_verify("guess", hash)  # => false
+		// ```
+		//
+		// @param password [String], hash [String]
+		// @return [Boolean]
+		Name: "bcrypt_verify",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 2 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 2, len(args))
+			}
+
+			typeErr := t.vm.checkArgTypes(args, sourceLine, classes.StringClass, classes.StringClass)
+
+			if typeErr != nil {
+				return typeErr
+			}
+
+			password, hashed := args[0].(*StringObject).value, args[1].(*StringObject).value
+
+			switch err := bcrypt.CompareHashAndPassword([]byte(hashed), []byte(password)); err {
+			case nil:
+				return TRUE
+			case bcrypt.ErrMismatchedHashAndPassword:
+				return FALSE
+			default:
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, invalidBcryptHash, err.Error())
+			}
+
+		},
+	},
+	{
+		// Derives a key of the given length in bytes from the password and the salt with PBKDF2,
+		// and returns it as a String of raw bytes.
+		// The digest is one of "md5", "sha1", "sha224", "sha256", "sha384" and "sha512".
+		//
+		// ```ruby
+		// require "crypto"
+		//
+		// Crypto.pbkdf2("secret", "salt", 10000, 32, "sha256") # => the 32 bytes of the key
+		// ```
+		//
+		// @param password [String], salt [String], iterations [Integer], key length [Integer], digest [String]
+		// @return [String]
+		Name: "pbkdf2",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 5 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 5, len(args))
+			}
+
+			typeErr := t.vm.checkArgTypes(args, sourceLine, classes.StringClass, classes.StringClass, classes.IntegerClass, classes.IntegerClass, classes.StringClass)
+
+			if typeErr != nil {
+				return typeErr
+			}
+
+			iterations, keyLen := args[2].(*IntegerObject).value, args[3].(*IntegerObject).value
+
+			if iterations <= 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, nonPositiveKDFArgs, "iterations", iterations)
+			}
+
+			if keyLen <= 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, nonPositiveKDFArgs, "key length", keyLen)
+			}
+
+			digest := args[4].(*StringObject).value
+			newHash, ok := pbkdf2Digests[digest]
+
+			if !ok {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, unsupportedDigest, digest)
+			}
+
+			if err := t.reserveMemory(keyLen, "Crypto.pbkdf2", sourceLine); err != nil {
+				return err
+			}
+
+			password, salt := args[0].(*StringObject).value, args[1].(*StringObject).value
+			key := pbkdf2.Key([]byte(password), []byte(salt), iterations, keyLen, newHash)
+			return t.vm.InitStringObject(string(key))
+
+		},
+	},
+}
+
+// Internal functions ===================================================
+
+// Functions for initialization -----------------------------------------
+
+func initCryptoClass(vm *VM) {
+	class := vm.initializeClass("Crypto")
+	class.setBuiltinMethods(builtinCryptoClassMethods, true)
+	vm.objectClass.setClassConstant(class)
+}
@@ -0,0 +1,132 @@
+package vm
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestCryptoBcrypt(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require "crypto"
+
+		hash = Crypto.bcrypt("secret", 4)
+		[Crypto.bcrypt_verify("secret", hash), Crypto.bcrypt_verify("Secret", hash), Crypto.bcrypt_verify("", hash)]
+		`, []interface{}{true, false, false}},
+		{`
+		require "crypto"
+
+		Crypto.bcrypt("secret", cost: 5)[0, 7]
+		`, "$2a$05$"},
+		{`
+		require "crypto"
+
+		Crypto.bcrypt("secret")[0, 7]
+		`, "$2a$10$"},
+		// the salt is random, so the same password gives different hashes
+		{`
+		require "crypto"
+
+		Crypto.bcrypt("secret", cost: 4) == Crypto.bcrypt("secret", cost: 4)
+		`, false},
+		{`
+		require "crypto"
+
+		Crypto.bcrypt_verify("U*U", "$2a$05$CCCCCCCCCCCCCCCCCCCCC.E5YPO9kmyuRGyh0XouQYb4YMJKvyOeW")
+		`, true},
+		{`
+		require "crypto"
+
+		Crypto.bcrypt_verify("U*V", "$2a$05$CCCCCCCCCCCCCCCCCCCCC.E5YPO9kmyuRGyh0XouQYb4YMJKvyOeW")
+		`, false},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestCryptoBcryptFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`require "crypto"
+		Crypto.bcrypt("secret", cost: 3)`, "ArgumentError: Expect bcrypt cost to be between 4 and 31. got: 3", 1},
+		{`require "crypto"
+		Crypto.bcrypt("secret", cost: 32)`, "ArgumentError: Expect bcrypt cost to be between 4 and 31. got: 32", 1},
+		{`require "crypto"
+		Crypto.bcrypt("secret", cost: "10")`, "TypeError: Expect argument #2 to be Integer. got: String", 1},
+		{`require "crypto"
+		Crypto.bcrypt(1)`, "TypeError: Expect argument #1 to be String. got: Integer", 1},
+		{`require "crypto"
+		Crypto.bcrypt`, "ArgumentError: Expect 1 to 2 argument(s). got: 0", 1},
+		{`require "crypto"
+		Crypto.bcrypt_verify("secret")`, "ArgumentError: Expect 2 argument(s). got: 1", 1},
+		{`require "crypto"
+		Crypto.bcrypt_verify("secret", 1)`, "TypeError: Expect argument to be String. got: Integer", 1},
+		{`require "crypto"
+		Crypto.bcrypt_verify("secret", "plain")`, "ArgumentError: Invalid bcrypt hash: crypto/bcrypt: hashedSecret too short to be a bcrypted password", 1},
+		{`require "crypto"
+		Crypto.bcrypt_verify("secret", "$9a$05$CCCCCCCCCCCCCCCCCCCCC.E5YPO9kmyuRGyh0XouQYb4YMJKvyOeW")`, "ArgumentError: Invalid bcrypt hash: crypto/bcrypt: bcrypt algorithm version '9' requested is newer than current version '2'", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestCryptoPBKDF2(t *testing.T) {
+	// The vectors are from RFC 6070 and RFC 7914
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`Crypto.pbkdf2("password", "salt", 1, 20, "sha1")`, "0c60c80f961f0e71f3a9b524af6012062fe037a6"},
+		{`Crypto.pbkdf2("password", "salt", 2, 20, "sha1")`, "ea6c014dc72d6f8ccd1ed92ace1d41f0d8de8957"},
+		{`Crypto.pbkdf2("password", "salt", 4096, 20, "sha1")`, "4b007901b765489abead49d926f721d065a429c1"},
+		{`Crypto.pbkdf2("passwd", "salt", 1, 64, "sha256")`, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"},
+		{`Crypto.pbkdf2("password", "salt", 4096, 32, "sha256")`, "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a"},
+	}
+
+	for i, tt := range tests {
+		expected, _ := hex.DecodeString(tt.expected)
+		v := initTestVM()
+		evaluated := v.testEval(t, `require "crypto"
+		`+tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, string(expected))
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestCryptoPBKDF2Fail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`require "crypto"
+		Crypto.pbkdf2("password", "salt", 0, 20, "sha1")`, "ArgumentError: Expect iterations to be positive. got: 0", 1},
+		{`require "crypto"
+		Crypto.pbkdf2("password", "salt", 1, -1, "sha1")`, "ArgumentError: Expect key length to be positive. got: -1", 1},
+		{`require "crypto"
+		Crypto.pbkdf2("password", "salt", 1, 20, "sha3")`, "ArgumentError: Unsupported digest: sha3", 1},
+		{`require "crypto"
+		Crypto.pbkdf2("password", 1, 1, 20, "sha1")`, "TypeError: Expect argument to be String. got: Integer", 1},
+		{`require "crypto"
+		Crypto.pbkdf2("password", "salt", 1, 20)`, "ArgumentError: Expect 5 argument(s). got: 4", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
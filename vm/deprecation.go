@@ -0,0 +1,101 @@
+package vm
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/goby-lang/goby/vm/errors"
+)
+
+const deprecatedMethod = "%s is deprecated since %s, use %s instead (called at %s:%d)"
+
+// Deprecation describes a call site of a deprecated builtin method, see Deprecated
+type Deprecation struct {
+	// Method is the deprecated method, like `String#start_with` or `Foo.bar` for a class method
+	Method      string
+	Since       string
+	Replacement string
+	File        string
+	// Line is the line of the call, starting from 1
+	Line int
+}
+
+// deprecationRegistry remembers the call sites of deprecated methods, so each of them is warned about once
+type deprecationRegistry struct {
+	sync.Mutex
+	seen  map[Deprecation]struct{}
+	fired []Deprecation
+}
+
+// Deprecated wraps a builtin method, so calling it warns that it's deprecated since the given version,
+// and that the replacement should be called instead. The warning is written once for each call site,
+// and says where the method was called from.
+//
+// When the vm is in strict mode, see SetStrictDeprecations, calling the method raises a DeprecationError instead.
+// Either way, the call sites are listed by `VM.deprecations` and VM.Deprecations. For example:
+//
+//	var builtinFooClassMethods = []*BuiltinMethodObject{
+//		{Name: "bar?", Fn: fooBar},
+//		Deprecated(&BuiltinMethodObject{Name: "bar", Fn: fooBar}, "0.1.14", "bar?"),
+//	}
+func Deprecated(method *BuiltinMethodObject, since, replacement string) *BuiltinMethodObject {
+	fn := method.Fn
+
+	return &BuiltinMethodObject{
+		Name: method.Name,
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			name := receiver.Class().Name + "#" + method.Name
+
+			if c, ok := receiver.(*RClass); ok {
+				name = c.Name + "." + method.Name
+			}
+
+			// The top frame is the method's own frame, which holds the file of the call
+			d := Deprecation{Method: name, Since: since, Replacement: replacement, File: t.callFrameStack.top().FileName(), Line: sourceLine}
+			strict := t.vm.strictDeprecations
+			t.vm.recordDeprecation(d, !strict)
+
+			if strict {
+				return t.vm.InitErrorObject(errors.DeprecationError, sourceLine, deprecatedMethod, d.Method, d.Since, d.Replacement, d.File, d.Line)
+			}
+
+			return fn(receiver, sourceLine, t, args, blockFrame)
+		},
+	}
+}
+
+// SetStrictDeprecations makes calling a deprecated builtin method raise a DeprecationError
+// instead of writing a warning, so scripts still using them can be caught by their tests.
+func (vm *VM) SetStrictDeprecations(strict bool) {
+	vm.strictDeprecations = strict
+}
+
+// Deprecations returns the call sites of deprecated builtin methods the vm has run so far, in the order they were first called
+func (vm *VM) Deprecations() []Deprecation {
+	vm.deprecations.Lock()
+	defer vm.deprecations.Unlock()
+
+	return append([]Deprecation(nil), vm.deprecations.fired...)
+}
+
+// recordDeprecation adds the call site to the registry, and writes the warning if it's the first time it's called.
+// The warning is written under the lock, so the warnings of different threads aren't interleaved.
+func (vm *VM) recordDeprecation(d Deprecation, warn bool) {
+	vm.deprecations.Lock()
+	defer vm.deprecations.Unlock()
+
+	if _, ok := vm.deprecations.seen[d]; ok {
+		return
+	}
+
+	if vm.deprecations.seen == nil {
+		vm.deprecations.seen = map[Deprecation]struct{}{}
+	}
+
+	vm.deprecations.seen[d] = struct{}{}
+	vm.deprecations.fired = append(vm.deprecations.fired, d)
+
+	if warn {
+		fmt.Fprintf(vm.stderr, "warning: "+deprecatedMethod+"\n", d.Method, d.Since, d.Replacement, d.File, d.Line)
+	}
+}
@@ -0,0 +1,135 @@
+package vm
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestDeprecatedMethodWarnsOncePerCallSite(t *testing.T) {
+	v := initTestVM()
+	var stderr bytes.Buffer
+	v.SetStderr(&stderr)
+	file := getFilename()
+
+	evaluated := v.testEval(t, `
+	r = []
+	3.times do
+	  r.push("Goby".start_with("Go"))
+	end
+	r.push("Goby".start_with("by"))
+	r.push("Goby".start_with?("Go"))
+	r
+	`, file)
+	VerifyExpected(t, 0, evaluated, []interface{}{true, true, true, false, true})
+	v.checkCFP(t, 0, 0)
+	v.checkSP(t, 0, 1)
+
+	expected := fmt.Sprintf("warning: String#start_with is deprecated since 0.1.14, use start_with? instead (called at %s:4)\n", file) +
+		fmt.Sprintf("warning: String#start_with is deprecated since 0.1.14, use start_with? instead (called at %s:6)\n", file)
+
+	if stderr.String() != expected {
+		t.Errorf("Expect the warnings to be:\n%s\ngot:\n%s", expected, stderr.String())
+	}
+
+	deprecations := []Deprecation{
+		{Method: "String#start_with", Since: "0.1.14", Replacement: "start_with?", File: file, Line: 4},
+		{Method: "String#start_with", Since: "0.1.14", Replacement: "start_with?", File: file, Line: 6},
+	}
+
+	if !reflect.DeepEqual(v.Deprecations(), deprecations) {
+		t.Errorf("Expect the deprecations to be %+v. got: %+v", deprecations, v.Deprecations())
+	}
+}
+
+func TestDeprecatedClassMethod(t *testing.T) {
+	v := initTestVM()
+	var stderr bytes.Buffer
+	v.SetStderr(&stderr)
+
+	old := &BuiltinMethodObject{
+		Name: "old",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return t.vm.InitIntegerObject(len(args))
+		},
+	}
+	v.TopLevelClass("String").setBuiltinMethods([]*BuiltinMethodObject{Deprecated(old, "0.1.0", "new")}, true)
+
+	evaluated := v.testEval(t, `String.old(1, 2)`, "script.gb")
+	VerifyExpected(t, 0, evaluated, 2)
+	v.checkCFP(t, 0, 0)
+	v.checkSP(t, 0, 1)
+
+	expected := "warning: String.old is deprecated since 0.1.0, use new instead (called at script.gb:1)\n"
+
+	if stderr.String() != expected {
+		t.Errorf("Expect the warning to be %q. got: %q", expected, stderr.String())
+	}
+}
+
+func TestVMDeprecations(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`VM.deprecations`, []interface{}{}},
+		{`
+		"Goby".start_with?("Go")
+		VM.deprecations
+		`, []interface{}{}},
+		{`
+		2.times do
+		  "Goby".start_with("Go")
+		end
+		d = VM.deprecations
+		[d.length, d[0]["method"], d[0]["since"], d[0]["replacement"], d[0]["file"], d[0]["line"]]
+		`, []interface{}{1, "String#start_with", "0.1.14", "start_with?", "script.gb", 3}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		v.SetStderr(&bytes.Buffer{})
+		evaluated := v.testEval(t, tt.input, "script.gb")
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestStrictDeprecations(t *testing.T) {
+	v := initTestVM()
+	var stderr bytes.Buffer
+	v.SetStderr(&stderr)
+	v.SetStrictDeprecations(true)
+
+	evaluated := v.testEval(t, `
+	"Goby".start_with?("Go")
+	"Goby".start_with("Go")
+	`, "script.gb")
+	checkErrorMsg(t, 0, evaluated, "DeprecationError: String#start_with is deprecated since 0.1.14, use start_with? instead (called at script.gb:3)")
+	v.checkCFP(t, 0, 1)
+	v.checkSP(t, 0, 1)
+
+	if stderr.Len() != 0 {
+		t.Errorf("Expect no warning in strict mode. got: %q", stderr.String())
+	}
+
+	if d := v.Deprecations(); len(d) != 1 || d[0].Line != 3 {
+		t.Errorf("Expect the call to be listed in the deprecations. got: %+v", d)
+	}
+}
+
+func TestVMDeprecationsFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`VM.deprecations(1)`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
@@ -0,0 +1,334 @@
+package vm
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/goby-lang/goby/vm/classes"
+	"github.com/goby-lang/goby/vm/errors"
+)
+
+const (
+	defaultDNSTimeout = 5 * time.Second
+	couldNotResolve   = "Could not resolve %s: %s"
+	invalidIPAddress  = "Invalid IP address: %s"
+	nonPositiveTime   = "Expect timeout to be positive. got: %s"
+)
+
+// Class methods --------------------------------------------------------
+var builtinDNSClassMethods = []*BuiltinMethodObject{
+	{
+		// Returns the IP addresses of the host as Strings.
+		// It's a ResolutionError if the host can't be resolved in time, see `Net::DNS.timeout`.
+		//
+		// ```ruby
+		// require "net/dns"
+		//
+		// Net::DNS.resolve("localhost") # => ["127.0.0.1", "::1"]
+		// ```
+		//
+		// @param host [String]
+		// @return [Array]
+		Name: "resolve",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			host, err := t.dnsHostArg(args, sourceLine)
+
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := t.vm.dnsContext()
+			defer cancel()
+
+			addrs, lookupErr := net.DefaultResolver.LookupIPAddr(ctx, host)
+
+			if lookupErr != nil {
+				return t.vm.resolutionError(host, lookupErr, sourceLine)
+			}
+
+			ips := make([]Object, len(addrs))
+
+			for i, addr := range addrs {
+				ips[i] = t.vm.InitStringObject(addr.String())
+			}
+
+			return t.vm.InitArrayObject(ips)
+
+		},
+	},
+	{
+		// Returns the MX records of the domain as `[priority, host]` pairs, in order of priority.
+		//
+		// ```ruby
+		// require "net/dns"
+		//
+		// Net::DNS.resolve_mx("example.com") # => [[10, "mail.example.com"]]
+		// ```
+		//
+		// @param domain [String]
+		// @return [Array]
+		Name: "resolve_mx",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			host, err := t.dnsHostArg(args, sourceLine)
+
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := t.vm.dnsContext()
+			defer cancel()
+
+			records, lookupErr := net.DefaultResolver.LookupMX(ctx, host)
+
+			if lookupErr != nil {
+				return t.vm.resolutionError(host, lookupErr, sourceLine)
+			}
+
+			pairs := make([]Object, len(records))
+
+			for i, mx := range records {
+				pairs[i] = t.vm.InitArrayObject([]Object{
+					t.vm.InitIntegerObject(int(mx.Pref)),
+					t.vm.InitStringObject(strings.TrimSuffix(mx.Host, ".")),
+				})
+			}
+
+			return t.vm.InitArrayObject(pairs)
+
+		},
+	},
+	{
+		// Returns the TXT records of the domain as Strings.
+		//
+		// ```ruby
+		// require "net/dns"
+		//
+		// Net::DNS.resolve_txt("example.com") # => ["v=spf1 -all"]
+		// ```
+		//
+		// @param domain [String]
+		// @return [Array]
+		Name: "resolve_txt",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			host, err := t.dnsHostArg(args, sourceLine)
+
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := t.vm.dnsContext()
+			defer cancel()
+
+			records, lookupErr := net.DefaultResolver.LookupTXT(ctx, host)
+
+			if lookupErr != nil {
+				return t.vm.resolutionError(host, lookupErr, sourceLine)
+			}
+
+			txts := make([]Object, len(records))
+
+			for i, txt := range records {
+				txts[i] = t.vm.InitStringObject(txt)
+			}
+
+			return t.vm.InitArrayObject(txts)
+
+		},
+	},
+	{
+		// Returns the host names of the IP address.
+		// It's an ArgumentError if the address isn't an IP address.
+		//
+		// ```ruby
+		// require "net/dns"
+		//
+		// Net::DNS.reverse("127.0.0.1") # => ["localhost"]
+		// ```
+		//
+		// @param address [String]
+		// @return [Array]
+		Name: "reverse",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			addr, err := t.dnsHostArg(args, sourceLine)
+
+			if err != nil {
+				return err
+			}
+
+			if net.ParseIP(addr) == nil {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, invalidIPAddress, addr)
+			}
+
+			ctx, cancel := t.vm.dnsContext()
+			defer cancel()
+
+			names, lookupErr := net.DefaultResolver.LookupAddr(ctx, addr)
+
+			if lookupErr != nil {
+				return t.vm.resolutionError(addr, lookupErr, sourceLine)
+			}
+
+			hosts := make([]Object, len(names))
+
+			for i, name := range names {
+				hosts[i] = t.vm.InitStringObject(strings.TrimSuffix(name, "."))
+			}
+
+			return t.vm.InitArrayObject(hosts)
+
+		},
+	},
+	{
+		// Returns how long a lookup can take in seconds before it's a ResolutionError. It's 5 by default.
+		//
+		// ```ruby
+		// require "net/dns"
+		//
+		// Net::DNS.timeout # => 5.0
+		// ```
+		//
+		// @return [Float]
+		Name: "timeout",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			return t.vm.initFloatObject(t.vm.dnsLookupTimeout().Seconds())
+
+		},
+	},
+	{
+		// Sets how long a lookup can take in seconds, for every thread.
+		//
+		// ```ruby
+		// require "net/dns"
+		//
+		// Net::DNS.timeout = 0.5
+		// ```
+		//
+		// @param seconds [Numeric]
+		// @return [Numeric]
+		Name: "timeout=",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			seconds, ok := args[0].(Numeric)
+
+			if !ok {
+				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, "Numeric", args[0].Class().Name)
+			}
+
+			timeout := time.Duration(seconds.floatValue() * float64(time.Second))
+
+			if timeout <= 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, nonPositiveTime, args[0].ToString())
+			}
+
+			atomic.StoreInt64(&t.vm.dnsTimeout, int64(timeout))
+
+			return args[0]
+
+		},
+	},
+}
+
+var builtinNetClassMethods = []*BuiltinMethodObject{
+	{
+		// Returns the IP addresses of the machine's network interfaces as Strings.
+		//
+		// ```ruby
+		// require "net/dns"
+		//
+		// Net.local_addresses # => ["127.0.0.1", "192.168.1.2", "::1"]
+		// ```
+		//
+		// @return [Array]
+		Name: "local_addresses",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			addrs, err := net.InterfaceAddrs()
+
+			if err != nil {
+				return t.vm.InitErrorObject(errors.IOError, sourceLine, err.Error())
+			}
+
+			ips := []Object{}
+
+			for _, addr := range addrs {
+				switch a := addr.(type) {
+				case *net.IPNet:
+					ips = append(ips, t.vm.InitStringObject(a.IP.String()))
+				case *net.IPAddr:
+					ips = append(ips, t.vm.InitStringObject(a.IP.String()))
+				}
+			}
+
+			return t.vm.InitArrayObject(ips)
+
+		},
+	},
+}
+
+// Internal functions ===================================================
+
+// Functions for initialization -----------------------------------------
+
+func initDNSModule(vm *VM) {
+	netModule := vm.loadConstant("Net", true)
+	netModule.setBuiltinMethods(builtinNetClassMethods, true)
+
+	dns := vm.initializeModule("DNS")
+	dns.setBuiltinMethods(builtinDNSClassMethods, true)
+	netModule.setClassConstant(dns)
+
+	initIPClass(vm, netModule)
+}
+
+// Other helper functions -----------------------------------------------
+
+// dnsHostArg returns the only argument of a lookup, which should be a String
+func (t *Thread) dnsHostArg(args []Object, sourceLine int) (string, *Error) {
+	if len(args) != 1 {
+		return "", t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+	}
+
+	if err := t.vm.checkArgTypes(args, sourceLine, classes.StringClass); err != nil {
+		return "", err
+	}
+
+	return args[0].(*StringObject).value, nil
+}
+
+func (vm *VM) dnsLookupTimeout() time.Duration {
+	if timeout := atomic.LoadInt64(&vm.dnsTimeout); timeout > 0 {
+		return time.Duration(timeout)
+	}
+
+	return defaultDNSTimeout
+}
+
+// dnsContext returns the context a lookup is done in, which is canceled after the timeout
+func (vm *VM) dnsContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), vm.dnsLookupTimeout())
+}
+
+// resolutionError returns a ResolutionError for the failed lookup of the host, with the resolver's reason
+// without the host and the server it already mentions
+func (vm *VM) resolutionError(host string, err error, sourceLine int) *Error {
+	reason := err.Error()
+
+	if dnsErr, ok := err.(*net.DNSError); ok {
+		reason = dnsErr.Err
+	}
+
+	return vm.InitErrorObject(errors.ResolutionError, sourceLine, couldNotResolve, host, reason)
+}
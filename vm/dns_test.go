@@ -0,0 +1,128 @@
+package vm
+
+import (
+	"net"
+	"strings"
+	"testing"
+)
+
+// skipWithoutResolver skips the test if the system resolver can't resolve localhost, like in a sandbox without /etc/hosts
+func skipWithoutResolver(t *testing.T) {
+	t.Helper()
+
+	if _, err := net.LookupHost("localhost"); err != nil {
+		t.Skipf("Can't resolve localhost: %s", err)
+	}
+}
+
+func TestDNSResolveMethods(t *testing.T) {
+	skipWithoutResolver(t)
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require "net/dns"
+		ips = Net::DNS.resolve("localhost")
+		ips.any? do |ip|
+		  ip == "127.0.0.1" || ip == "::1"
+		end
+		`, true},
+		{`
+		require "net/dns"
+		Net::DNS.reverse("127.0.0.1").any? do |host|
+		  host == "localhost"
+		end
+		`, true},
+		{`
+		require "net/dns"
+		Net::DNS.timeout
+		`, 5.0},
+		{`
+		require "net/dns"
+		Net::DNS.timeout = 2
+		Net::DNS.timeout
+		`, 2.0},
+		{`
+		require "net/dns"
+		Net::DNS.timeout = 0.5
+		Net::DNS.resolve("localhost").length > 0
+		`, true},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestDNSResolveMethodsFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`require "net/dns"
+		Net::DNS.resolve`, "ArgumentError: Expect 1 argument(s). got: 0", 1},
+		{`require "net/dns"
+		Net::DNS.resolve_mx(1)`, "TypeError: Expect argument to be String. got: Integer", 1},
+		{`require "net/dns"
+		Net::DNS.resolve_txt("a", "b")`, "ArgumentError: Expect 1 argument(s). got: 2", 1},
+		{`require "net/dns"
+		Net::DNS.reverse("localhost")`, "ArgumentError: Invalid IP address: localhost", 1},
+		{`require "net/dns"
+		Net::DNS.timeout = 0`, "ArgumentError: Expect timeout to be positive. got: 0", 1},
+		{`require "net/dns"
+		Net::DNS.timeout = "1"`, "TypeError: Expect argument to be Numeric. got: String", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+// The reason depends on whether there's a DNS server to ask, so only the beginning of the message is checked
+func TestDNSResolveInvalidHost(t *testing.T) {
+	tests := []string{
+		`Net::DNS.resolve("goby.invalid")`,
+		`Net::DNS.resolve_mx("goby.invalid")`,
+		`Net::DNS.resolve_txt("goby.invalid")`,
+	}
+
+	for i, input := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, "require \"net/dns\"\nNet::DNS.timeout = 2\n"+input, getFilename())
+		err, ok := evaluated.(*Error)
+
+		if !ok || !strings.HasPrefix(err.Message(), "ResolutionError: Could not resolve goby.invalid: ") {
+			t.Fatalf("At test case %d: expect a ResolutionError for goby.invalid. got: %s", i, evaluated.Inspect())
+		}
+
+		v.checkCFP(t, i, 1)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestNetLocalAddressesMethod(t *testing.T) {
+	addrs, err := net.InterfaceAddrs()
+
+	if err != nil || len(addrs) == 0 {
+		t.Skip("There's no network interface address")
+	}
+
+	// every address can be parsed as an IP address
+	v := initTestVM()
+	evaluated := v.testEval(t, `
+	require "net/dns"
+	Net.local_addresses.select do |addr|
+	  Net::IP.parse(addr).version > 0
+	end.length
+	`, getFilename())
+	VerifyExpected(t, 0, evaluated, len(addrs))
+	v.checkCFP(t, 0, 0)
+	v.checkSP(t, 0, 1)
+}
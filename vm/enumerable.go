@@ -0,0 +1,37 @@
+package vm
+
+import (
+	"github.com/goby-lang/goby/vm/classes"
+)
+
+// Enumerable is a module that derives collection methods from the `each` method of the class that includes it.
+// `each` should yield every element to the given block. The methods are written in Goby, see `lib/enumerable.gb`.
+//
+// ```ruby
+// class NumberList
+//   include(Enumerable)
+//
+//   def each
+//     yield(3)
+//     yield(1)
+//     yield(2)
+//   end
+// end
+//
+// list = NumberList.new
+// list.map do |i| i * 2 end        # => [6, 2, 4]
+// list.select do |i| i > 1 end     # => [3, 2]
+// list.reduce(0) do |a, i| a + i end # => 6
+// list.sort_by do |i| -i end       # => [3, 2, 1]
+// list.include?(2)                 # => true
+// ```
+
+// Internal functions ===================================================
+
+// Functions for initialization -----------------------------------------
+
+func (vm *VM) initEnumerableModule() *RClass {
+	module := vm.initializeModule(classes.EnumerableModule)
+	vm.libFiles = append(vm.libFiles, "enumerable.gb")
+	return module
+}
@@ -0,0 +1,106 @@
+package vm
+
+import (
+	"testing"
+)
+
+const enumerableListClass = `
+class NumberList
+  include(Enumerable)
+
+  def each
+    yield(3)
+    yield(1)
+    yield(2)
+  end
+end
+
+list = NumberList.new
+`
+
+func TestEnumerableModule(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`list.to_a`, []interface{}{3, 1, 2}},
+		{`list.map do |i| i * 2 end`, []interface{}{6, 2, 4}},
+		{`list.select do |i| i > 1 end`, []interface{}{3, 2}},
+		{`list.reject do |i| i > 1 end`, []interface{}{1}},
+		{`list.reduce(10) do |acc, i| acc + i end`, 16},
+		{`list.reduce do |acc, i| acc * i end`, 6},
+		{`list.include?(2)`, true},
+		{`list.include?(4)`, false},
+		{`list.count`, 3},
+		{`list.count(1)`, 1},
+		{`list.count do |i| i.odd? end`, 2},
+		{`list.find do |i| i < 3 end`, 1},
+		{`list.find do |i| i > 3 end`, nil},
+		{`list.min`, 1},
+		{`list.max`, 3},
+		{`list.sum`, 6},
+		{`list.first`, 3},
+		{`list.first(2)`, []interface{}{3, 1}},
+		{`list.sort_by do |i| i end`, []interface{}{1, 2, 3}},
+		{`list.sort_by do |i| -i end`, []interface{}{3, 2, 1}},
+		{`
+		result = []
+		list.each_with_index do |elem, i|
+		  result.push(elem * i)
+		end
+		result
+		`, []interface{}{0, 1, 4}},
+		{`NumberList.ancestors.to_s`, "[NumberList, Enumerable, Object]"},
+		{`list.is_a?(Enumerable)`, true},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, enumerableListClass+tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestEnumerableModuleWithEmptyEach(t *testing.T) {
+	input := `
+	class EmptyList
+	  include(Enumerable)
+
+	  def each; end
+	end
+
+	list = EmptyList.new
+	[list.to_a, list.min, list.first, list.count, list.sum, list.sort_by do |i| i end]
+	`
+
+	v := initTestVM()
+	evaluated := v.testEval(t, input, getFilename())
+	VerifyExpected(t, 0, evaluated, []interface{}{[]interface{}{}, nil, nil, 0, 0, []interface{}{}})
+	v.checkCFP(t, 0, 0)
+	v.checkSP(t, 0, 1)
+}
+
+func TestEnumerableModuleWithStableSortBy(t *testing.T) {
+	input := `
+	class WordList
+	  include(Enumerable)
+
+	  def each
+	    yield("bb")
+	    yield("a")
+	    yield("cc")
+	    yield("d")
+	  end
+	end
+
+	WordList.new.sort_by do |w| w.length end
+	`
+
+	v := initTestVM()
+	evaluated := v.testEval(t, input, getFilename())
+	VerifyExpected(t, 0, evaluated, []interface{}{"a", "d", "bb", "cc"})
+	v.checkCFP(t, 0, 0)
+	v.checkSP(t, 0, 1)
+}
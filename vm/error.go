@@ -23,6 +23,10 @@ type Error struct {
 	stackTraces  []string
 	storedTraces bool
 	Type         string
+	// sourceLine is the line the error is raised on, which the thread raising it adds its file to
+	sourceLine int
+	// GoStack is the Go stack trace of the panic an InternalError is converted from, for debugging the builtin method that panicked
+	GoStack string
 }
 
 // Internal functions ===================================================
@@ -34,33 +38,41 @@ func (vm *VM) InitNoMethodError(sourceLine int, methodName string, receiver Obje
 	return vm.InitErrorObject(errors.NoMethodError, sourceLine, errors.UndefinedMethod, methodName, receiver.Inspect())
 }
 
-// InitErrorObject initializes and returns Error object
+// InitErrorObject initializes and returns Error object.
+// It doesn't depend on any thread, so it can be called from any of them:
+// the file the error is raised from is added by the thread which raises it.
 func (vm *VM) InitErrorObject(errorType string, sourceLine int, format string, args ...interface{}) *Error {
 	errClass := vm.objectClass.getClassConstant(errorType)
 
-	t := &vm.mainThread
+	return &Error{
+		BaseObj:    NewBaseObject(errClass),
+		message:    fmt.Sprintf(errorType+": "+format, args...),
+		Type:       errorType,
+		sourceLine: sourceLine,
+	}
+}
+
+// initErrorObject initializes an Error object raised from the thread's current call frame
+func (t *Thread) initErrorObject(errorType string, sourceLine int, format string, args ...interface{}) *Error {
+	err := t.vm.InitErrorObject(errorType, sourceLine, format, args...)
 	cf := t.callFrameStack.top()
 
-	switch cf := cf.(type) {
-	case *normalCallFrame:
-		// If program counter is 0 means we need to trace back to previous call frame
-		if cf.pc == 0 {
-			t.callFrameStack.pop()
-			cf = t.callFrameStack.top().(*normalCallFrame)
-		}
+	if cf == nil {
+		return err
 	}
 
-	return &Error{
-		BaseObj: NewBaseObject(errClass),
-		// Add 1 to source line because it's zero indexed
-		message:     fmt.Sprintf(errorType+": "+format, args...),
-		stackTraces: []string{fmt.Sprintf("from %s:%d", cf.FileName(), sourceLine)},
-		Type:        errorType,
+	// If program counter is 0 means we need to trace back to previous call frame
+	if c, ok := cf.(*normalCallFrame); ok && c.pc == 0 {
+		t.callFrameStack.pop()
 	}
+
+	err.raisedFrom(cf.FileName())
+
+	return err
 }
 
 func (vm *VM) initErrorClasses() {
-	errTypes := []string{errors.InternalError, errors.IOError, errors.ArgumentError, errors.NameError, errors.StopIteration, errors.TypeError, errors.NoMethodError, errors.ConstantAlreadyInitializedError, errors.HTTPError, errors.ZeroDivisionError, errors.ChannelCloseError, errors.NotImplementedError}
+	errTypes := []string{errors.InternalError, errors.IOError, errors.ArgumentError, errors.NameError, errors.StopIteration, errors.TypeError, errors.NoMethodError, errors.ConstantAlreadyInitializedError, errors.HTTPError, errors.ZeroDivisionError, errors.ChannelCloseError, errors.NotImplementedError, errors.FrozenError, errors.OverflowError, errors.IndexError, errors.UncaughtThrowError, errors.MemoryLimitError, errors.ResolutionError, errors.DeprecationError}
 
 	for _, errType := range errTypes {
 		c := vm.initializeClass(errType)
@@ -82,7 +94,7 @@ func (e *Error) Inspect() string {
 
 // ToJSON just delegates to `ToString`
 func (e *Error) ToJSON(t *Thread) string {
-	return e.ToString()
+	return quoteJSON(e.ToString())
 }
 
 // Value is equivalent to ToString
@@ -90,6 +102,13 @@ func (e *Error) Value() interface{} {
 	return e.message
 }
 
+// raisedFrom adds the file the error is raised from to its stack traces, unless the error has been raised before
+func (e *Error) raisedFrom(fileName string) {
+	if len(e.stackTraces) == 0 {
+		e.stackTraces = []string{fmt.Sprintf("from %s:%d", fileName, e.sourceLine)}
+	}
+}
+
 // Message prints the error's message and its stack traces
 func (e *Error) Message() string {
 	return e.message + "\n" + strings.Join(e.stackTraces, "\n")
@@ -80,8 +80,8 @@ func TestStackTraces(t *testing.T) {
 				fmt.Sprintf("from %s:6", getFilename()),
 				fmt.Sprintf("from %s:5", getFilename()),
 			},
-			4,
-			2,
+			1,
+			1,
 		},
 		/*
 			TODO: This case should have these stack traces:
@@ -142,8 +142,20 @@ func TestStackTraces(t *testing.T) {
 				fmt.Sprintf("from %s:6", getFilename()),
 				fmt.Sprintf("from %s:5", getFilename()),
 			},
-			4,
-			2,
+			1,
+			1,
+		},
+		{`loop do
+		  raise ArgumentError, "boom"
+		end
+		`,
+			"ArgumentError: \"boom\"",
+			[]string{
+				fmt.Sprintf("from %s:2", getFilename()),
+				fmt.Sprintf("from %s:1", getFilename()),
+			},
+			1,
+			1,
 		},
 	}
 
@@ -388,6 +400,20 @@ func TestKeywordArgumentError(t *testing.T) {
 		foo(y: 1, x: 100)
 		`,
 			"ArgumentError: Expect at most 1 args for method 'foo'. got: 2", 1},
+		{`def foo(x:, **opts)
+		  x
+		end
+
+		foo(y: 1)
+		`,
+			"ArgumentError: Method foo requires key argument x", 1},
+		{`def foo(a, **opts)
+		  a
+		end
+
+		foo(1, 2, y: 1)
+		`,
+			"ArgumentError: Expect at most 1 args for method 'foo'. got: 2", 1},
 	}
 
 	for i, tt := range tests {
@@ -463,7 +489,7 @@ func checkFuzzifiedErrorMsg(t *testing.T, index int, evaluated Object, expectedE
 }
 
 func fuzzifyMessage(message string) string {
-	re, _ := regexp2.Compile("(?<=#<[a-zA-Z0-9_]+:)[0-9]{12}(?=[ ]>?)", 0)
+	re, _ := regexp2.Compile("(?<=#<[a-zA-Z0-9_]+:)[0-9]+(?=[ ]>?)", 0)
 	fuzMsg, _ := re.Replace(message, "##OBJECTID##", 0, -1)
 	return fuzMsg
 }
@@ -25,6 +25,20 @@ const (
 	ChannelCloseError = "ChannelCloseError"
 	// NotImplementedError means the method is missing
 	NotImplementedError = "NotImplementedError"
+	// FrozenError is for modifying a frozen object
+	FrozenError = "FrozenError"
+	// OverflowError is for Integer arithmetic whose result doesn't fit in an Integer
+	OverflowError = "OverflowError"
+	// IndexError is for an index that can't be used to access or assign a sequence
+	IndexError = "IndexError"
+	// UncaughtThrowError is for a `throw` without a matching `catch`
+	UncaughtThrowError = "UncaughtThrowError"
+	// MemoryLimitError is for a script that uses more memory than the vm's limit
+	MemoryLimitError = "MemoryLimitError"
+	// ResolutionError is for a host name or an address that can't be resolved
+	ResolutionError = "ResolutionError"
+	// DeprecationError is for calling a deprecated method when the vm is strict about deprecations
+	DeprecationError = "DeprecationError"
 )
 
 /*
@@ -53,4 +67,14 @@ const (
 	NegativeSecondValue             = "Expect second argument to be positive value. got: %d"
 	NativeNotImplementedErrorFormat = "'%s' should be implemented on %s but haven't be done yet. Looking forward to see your PR for it ;-)"
 	UndefinedMethod                 = "Undefined Method '%+v' for %+v"
+	PrivateMethodCalled             = "private method '%s' called for %s"
+	ProtectedMethodCalled           = "protected method '%s' called for %s"
+	UndefinedMethodForClass         = "undefined method '%s' for class '%s'"
+	MethodNotDefinedIn              = "method '%s' not defined in %s"
+	CantModifyFrozenObject          = "Can't modify frozen %s: %s"
+	IntegerOverflow                 = "Integer overflow: %d %s %d"
+	ExponentTooLarge                = "Exponent is too large: %s"
+	InvalidHashKey                  = "Expect hash key to be String. got: %s"
+	InvalidClassVariableName        = "'%s' is not allowed as a class variable name"
+	BuiltinMethodPanicked           = "Builtin method '%s' panicked: %v"
 )
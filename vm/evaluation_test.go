@@ -1466,6 +1466,51 @@ func TestMethodCallWithSplatArgument(t *testing.T) {
 
 		foo
 		`, []interface{}{}},
+		{`
+		def foo(a, *rest)
+		  [a, rest]
+		end
+
+		arr = [1, 2]
+		foo(*arr, 3)
+		`, []interface{}{1, []interface{}{2, 3}}},
+		{`
+		def foo(*rest)
+		  rest
+		end
+
+		foo(0, *[1, 2], 3, *[], *[4])
+		`, []interface{}{0, 1, 2, 3, 4}},
+		{`
+		def foo(a, k: 0, *rest)
+		  [a, k, rest]
+		end
+
+		foo(*[1, 2, 3], k: 4)
+		`, []interface{}{1, 4, []interface{}{2, 3}}},
+		// the splatted array itself is passed as one argument afterwards
+		{`
+		def foo(*rest)
+		  rest
+		end
+
+		arr = [1, 2]
+		foo(*arr)
+		foo(arr)
+		`, []interface{}{[]interface{}{1, 2}}},
+		{`
+		def foo
+		  yield(*[1, 2], 3)
+		end
+
+		foo do |a, b, c|
+		  [a, b, c]
+		end
+		`, []interface{}{1, 2, 3}},
+		{`
+		arr = [1]
+		arr.push(*[2, 3], 4)
+		`, []interface{}{1, 2, 3, 4}},
 	}
 
 	for i, tt := range tests {
@@ -1777,6 +1822,107 @@ func TestMethodCallWithNestedBlock(t *testing.T) {
 	}
 }
 
+func TestBlockParameterDestructuring(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		// an Array yielded to a block with several parameters is spread into them
+		{`
+		r = []
+		[[1, 2], [3, 4]].each do |a, b|
+		  r.push(a + b)
+		end
+		r
+		`, []interface{}{3, 7}},
+		{`
+		def foo
+		  yield([1, 2])
+		end
+
+		foo do |a, b|
+		  [a, b]
+		end
+		`, []interface{}{1, 2}},
+		{`
+		def foo
+		  yield([1, 2])
+		end
+
+		foo do |a|
+		  a
+		end
+		`, []interface{}{1, 2}},
+		{`
+		r = []
+		{ a: 1 }.each do |(k, v)|
+		  r.push(k)
+		  r.push(v)
+		end
+		r
+		`, []interface{}{"a", 1}},
+		{`
+		r = []
+		[[1, [2, 3]], [4, [5, 6]]].each do |a, (b, c)|
+		  r.push(a + b + c)
+		end
+		r
+		`, []interface{}{6, 15}},
+		{`
+		r = nil
+		[[1, [2, [3, 4]]]].each do |(a, (b, (c, d)))|
+		  r = [a, b, c, d]
+		end
+		r
+		`, []interface{}{1, 2, 3, 4}},
+		// missing elements are nil and extra elements are ignored
+		{`
+		r = []
+		[[1, [2]], [3, [4, 5, 6]]].each do |a, (b, c)|
+		  r.push([a, b, c])
+		end
+		r
+		`, []interface{}{[]interface{}{1, 2, nil}, []interface{}{3, 4, 5}}},
+		{`
+		r = []
+		[[1, 2, 3], [4]].each do |a, b|
+		  r.push([a, b])
+		end
+		r
+		`, []interface{}{[]interface{}{1, 2}, []interface{}{4, nil}}},
+		// a non-array value is assigned to the first destructured parameter
+		{`
+		r = nil
+		[[1, 2]].each do |a, (b, c)|
+		  r = [a, b, c]
+		end
+		r
+		`, []interface{}{1, 2, nil}},
+		// destructured parameters shadow the outer locals
+		{`
+		b = 10
+		[[1, [2, 3]]].each do |a, (b, c)|
+		  b
+		end
+		b
+		`, 10},
+		{`
+		p = Block.new do |(a, b), c|
+		  [a, b, c]
+		end
+		p.call([1, 2], 3)
+		`, []interface{}{1, 2, 3}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestMethodCallWithoutParens(t *testing.T) {
 	tests := []struct {
 		input    string
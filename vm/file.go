@@ -2,10 +2,12 @@ package vm
 
 import (
 	"bufio"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"syscall"
+	"time"
 
 	"github.com/goby-lang/goby/vm/classes"
 	"github.com/goby-lang/goby/vm/errors"
@@ -35,6 +37,40 @@ var fileModeTable = map[string]int{
 
 // Class methods --------------------------------------------------------
 var builtinFileClassMethods = []*BuiltinMethodObject{
+	{
+		// Writes the file atomically: the block is called with a temporary file in the same directory,
+		// which replaces the file only if the block succeeds, so readers see either the old or the new contents.
+		// The permissions of the file are kept if it exists, and are 0644 otherwise.
+		// If the block raises an error, the file is left untouched and the temporary file is removed.
+		// The temporary file is closed after the block, so the block shouldn't close it.
+		//
+		// ```ruby
+		// File.atomic_write("/tmp/goby/config.json") do |f|
+		//   f.write(config.to_json)
+		// end
+		// ```
+		// @param filePath [String]
+		// @return [Object] the result of the block
+		Name: "atomic_write",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			err := t.vm.checkArgTypes(args, sourceLine, classes.StringClass)
+
+			if err != nil {
+				return err
+			}
+
+			if blockFrame == nil {
+				return t.vm.InitErrorObject(errors.InternalError, sourceLine, errors.CantYieldWithoutBlockFormat)
+			}
+
+			return t.atomicWrite(args[0].Value().(string), blockFrame, sourceLine)
+
+		},
+	},
 	{
 		// Returns the last element from path.
 		//
@@ -325,6 +361,69 @@ var builtinFileClassMethods = []*BuiltinMethodObject{
 
 		},
 	},
+	{
+		// Opens the file, creating it if it doesn't exist, and calls the block with it while holding a lock on it.
+		// The lock is either "exclusive" or "shared", and is released when the block returns or raises an error.
+		// Taking the lock blocks until the other holders release it: an exclusive lock waits for every lock,
+		// and a shared lock only for an exclusive one.
+		// The lock is advisory, so it only keeps out the scripts that take it too.
+		// It's taken with flock(2), and isn't taken at all on the platforms without it, such as Windows.
+		//
+		// ```ruby
+		// File.flock("/tmp/goby/counter", :exclusive) do |f|
+		//   n = f.read.to_i
+		//   File.new("/tmp/goby/counter", "w").write((n + 1).to_s)
+		// end
+		// ```
+		// @param filePath [String], mode [String]
+		// @return [Object] the result of the block
+		Name: "flock",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 2 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 2, len(args))
+			}
+
+			typeErr := t.vm.checkArgTypes(args, sourceLine, classes.StringClass, classes.StringClass)
+
+			if typeErr != nil {
+				return typeErr
+			}
+
+			if blockFrame == nil {
+				return t.vm.InitErrorObject(errors.InternalError, sourceLine, errors.CantYieldWithoutBlockFormat)
+			}
+
+			var exclusive bool
+			flag := os.O_RDONLY | os.O_CREATE
+
+			switch mode := args[1].Value().(string); mode {
+			case "exclusive":
+				exclusive = true
+				flag = os.O_RDWR | os.O_CREATE
+			case "shared":
+			default:
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, "Unknown lock mode: %s", mode)
+			}
+
+			f, err := os.OpenFile(args[0].Value().(string), flag, 0644)
+
+			if err != nil {
+				return t.vm.InitErrorObject(errors.IOError, sourceLine, err.Error())
+			}
+
+			defer f.Close()
+
+			if err := lockFile(f, exclusive); err != nil {
+				return t.vm.InitErrorObject(errors.IOError, sourceLine, err.Error())
+			}
+
+			defer unlockFile(f)
+
+			result, _ := t.builtinMethodYield(blockFrame, t.vm.initFileObject(f))
+			return result
+
+		},
+	},
 	{
 		// Returns array of path and file.
 		//
@@ -352,6 +451,49 @@ var builtinFileClassMethods = []*BuiltinMethodObject{
 
 		},
 	},
+	{
+		// Sets the access and modification times of the file to now, creating an empty file if it doesn't exist.
+		//
+		// ```ruby
+		// File.touch("/tmp/goby/done")
+		// File.exist?("/tmp/goby/done") # => true
+		// ```
+		//
+		// @param filePath [String]
+		// @return [Null]
+		Name: "touch",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			typeErr := t.vm.checkArgTypes(args, sourceLine, classes.StringClass)
+
+			if typeErr != nil {
+				return typeErr
+			}
+
+			fn := args[0].Value().(string)
+			now := time.Now()
+			err := os.Chtimes(fn, now, now)
+
+			if os.IsNotExist(err) {
+				var f *os.File
+				f, err = os.OpenFile(fn, os.O_WRONLY|os.O_CREATE, 0644)
+
+				if err == nil {
+					err = f.Close()
+				}
+			}
+
+			if err != nil {
+				return t.vm.InitErrorObject(errors.IOError, sourceLine, err.Error())
+			}
+
+			return NULL
+
+		},
+	},
 }
 
 // Instance methods -----------------------------------------------------
@@ -397,14 +539,23 @@ var builtinFileInstanceMethods = []*BuiltinMethodObject{
 		},
 	},
 	// Returns the contents of the specified file.
+	// If a length is given, it reads at most that many bytes from the current position instead,
+	// and returns `nil` at the end of the file.
 	//
 	// ```ruby
 	// File.open("/tmp/goby/out.txt", "w", 0755) do |f|
 	//   f.write("Hello, Goby!")
 	//   puts f.read      #=> "Hello, Goby!"
 	// end
+	//
+	// File.open("/tmp/goby/out.txt", "r", 0755) do |f|
+	//   f.read(5) #=> "Hello"
+	//   f.read(9) #=> ", Goby!"
+	//   f.read(5) #=> nil
+	// end
 	// ```
 	//
+	// @param length [Integer]
 	// @return [String]
 	{
 		Name: "read",
@@ -415,6 +566,34 @@ var builtinFileInstanceMethods = []*BuiltinMethodObject{
 
 			file := receiver.(*FileObject).File
 
+			if len(args) > 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgumentLess, 1, len(args))
+			}
+
+			if len(args) == 1 {
+				length, ok := args[0].(*IntegerObject)
+
+				if !ok {
+					return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.IntegerClass, args[0].Class().Name)
+				}
+
+				if length.value < 0 {
+					return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.NegativeValue, length.value)
+				}
+
+				buf := make([]byte, length.value)
+				n, err := io.ReadFull(file, buf)
+
+				switch {
+				case err == io.EOF && length.value > 0:
+					return NULL
+				case err != nil && err != io.EOF && err != io.ErrUnexpectedEOF:
+					return t.vm.InitErrorObject(errors.IOError, sourceLine, err.Error())
+				}
+
+				return t.vm.InitStringObject(string(buf[:n]))
+			}
+
 			if file.Name() == "/dev/stdin" {
 				reader := bufio.NewReader(os.Stdin)
 				result, err = reader.ReadString('\n')
@@ -471,6 +650,58 @@ var builtinFileInstanceMethods = []*BuiltinMethodObject{
 
 // Internal functions ===================================================
 
+// atomicWrite calls the block with a temporary file next to the path, and renames it to the path if the block succeeds.
+// The temporary file is removed otherwise.
+func (t *Thread) atomicWrite(path string, blockFrame *normalCallFrame, sourceLine int) Object {
+	perm := os.FileMode(0644)
+
+	if fs, err := os.Stat(path); err == nil {
+		perm = fs.Mode().Perm()
+	} else if !os.IsNotExist(err) {
+		return t.vm.InitErrorObject(errors.IOError, sourceLine, err.Error())
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+
+	if err != nil {
+		return t.vm.InitErrorObject(errors.IOError, sourceLine, err.Error())
+	}
+
+	renamed := false
+
+	defer func() {
+		if !renamed {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if err := tmp.Chmod(perm); err != nil {
+		return t.vm.InitErrorObject(errors.IOError, sourceLine, err.Error())
+	}
+
+	result, erred := t.builtinMethodYield(blockFrame, t.vm.initFileObject(tmp))
+
+	if erred {
+		return result
+	}
+
+	if err := tmp.Sync(); err != nil {
+		return t.vm.InitErrorObject(errors.IOError, sourceLine, err.Error())
+	}
+
+	if err := tmp.Close(); err != nil {
+		return t.vm.InitErrorObject(errors.IOError, sourceLine, err.Error())
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return t.vm.InitErrorObject(errors.IOError, sourceLine, err.Error())
+	}
+
+	renamed = true
+	return result
+}
+
 // Functions for initialization -----------------------------------------
 
 func (vm *VM) initFileObject(f *os.File) *FileObject {
@@ -504,7 +735,7 @@ func (f *FileObject) Inspect() string {
 
 // ToJSON just delegates to `ToString`
 func (f *FileObject) ToJSON(t *Thread) string {
-	return f.ToString()
+	return unsupportedJSON(f)
 }
 
 // Value returns file object's string format
@@ -0,0 +1,18 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package vm
+
+import (
+	"os"
+)
+
+// lockFile doesn't lock anything on the platforms without flock(2), Windows included,
+// so `File.flock` just runs its block there. Scripts that need the lock must not run concurrently on them.
+func lockFile(f *os.File, exclusive bool) error {
+	return nil
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
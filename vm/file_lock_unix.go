@@ -0,0 +1,31 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package vm
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile takes an advisory lock on the file with flock(2), blocking until it's available.
+// The lock belongs to the open file, so opening the same path again and locking it blocks even in the same process.
+func lockFile(f *os.File, exclusive bool) error {
+	how := syscall.LOCK_SH
+
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+
+	for {
+		err := syscall.Flock(int(f.Fd()), how)
+
+		if err != syscall.EINTR {
+			return err
+		}
+	}
+}
+
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
@@ -0,0 +1,110 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package vm
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestFileFlockMethodWaitsForLock(t *testing.T) {
+	setup()
+	defer teardown()
+
+	tests := []struct {
+		heldExclusive bool
+		mode          string
+		blocked       bool
+	}{
+		{true, "exclusive", true},
+		{true, "shared", true},
+		{false, "shared", false},
+		{false, "exclusive", true},
+	}
+
+	for i, tt := range tests {
+		path := "/tmp/goby/flock_wait"
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := lockFile(f, tt.heldExclusive); err != nil {
+			t.Fatal(err)
+		}
+
+		done := make(chan Object)
+
+		go func() {
+			v := initTestVM()
+			done <- v.testEval(t, `
+			File.flock("/tmp/goby/flock_wait", :`+tt.mode+`) do |f|
+			  "locked"
+			end
+			`, getFilename())
+		}()
+
+		select {
+		case <-done:
+			if tt.blocked {
+				t.Fatalf("At test case %d: expect the %s lock to wait for the lock held", i, tt.mode)
+			}
+		case <-time.After(100 * time.Millisecond):
+			if !tt.blocked {
+				t.Fatalf("At test case %d: expect the %s lock not to wait for the lock held", i, tt.mode)
+			}
+		}
+
+		unlockFile(f)
+
+		if tt.blocked {
+			select {
+			case evaluated := <-done:
+				VerifyExpected(t, i, evaluated, "locked")
+			case <-time.After(5 * time.Second):
+				t.Fatalf("At test case %d: expect the %s lock to be taken after the lock held is released", i, tt.mode)
+			}
+		}
+
+		f.Close()
+	}
+}
+
+func TestFileFlockMethodReleasesLock(t *testing.T) {
+	setup()
+	defer teardown()
+
+	tests := []string{
+		`
+		File.flock("/tmp/goby/flock_release", :exclusive) do |f|
+		  f.write("1")
+		end
+		`,
+		`
+		File.flock("/tmp/goby/flock_release", :exclusive) do |f|
+		  raise "Boom"
+		end
+		`,
+	}
+
+	for i, input := range tests {
+		v := initTestVM()
+		v.testEval(t, input, getFilename())
+
+		f, err := os.Open("/tmp/goby/flock_release")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+			t.Fatalf("At test case %d: expect the lock to be released. got: %s", i, err)
+		}
+
+		f.Close()
+	}
+}
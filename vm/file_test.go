@@ -1,8 +1,13 @@
 package vm
 
 import (
+	"io/ioutil"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestFileObject(t *testing.T) {
@@ -56,6 +61,10 @@ func TestFileObjectFail(t *testing.T) {
 			`IOError: open fictitious.gb: no such file or directory`, 1},
 		{`f = File.new("fictitious/")`,
 			`IOError: open fictitious/: no such file or directory`, 1},
+		{`File.new("../test_fixtures/file_test/size.gb").read("1")`,
+			`TypeError: Expect argument to be Integer. got: String`, 1},
+		{`File.new("../test_fixtures/file_test/size.gb").read(-1)`,
+			`ArgumentError: Expect argument to be positive value. got: -1`, 1},
 	}
 
 	for i, tt := range testsFail {
@@ -68,6 +77,136 @@ func TestFileObjectFail(t *testing.T) {
 }
 
 // Tests for class methods
+func TestFileAtomicWriteMethod(t *testing.T) {
+	setup()
+	defer teardown()
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		r = File.atomic_write("/tmp/goby/atomic_new.txt") do |f|
+		  f.write("Hello")
+		end
+		[r, File.new("/tmp/goby/atomic_new.txt").read]
+		`, []interface{}{5, "Hello"}},
+		{`
+		File.open("/tmp/goby/atomic_old.txt", "w", 0755) do |f|
+		  f.write("old contents")
+		end
+		File.atomic_write("/tmp/goby/atomic_old.txt") do |f|
+		  f.write("new")
+		end
+		File.new("/tmp/goby/atomic_old.txt").read
+		`, "new"},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestFileAtomicWriteMethodFail(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testsFail := []errorTestCase{
+		{`File.atomic_write do end`,
+			`ArgumentError: Expect 1 argument(s). got: 0`, 1},
+		{`File.atomic_write(1) do end`,
+			`TypeError: Expect argument to be String. got: Integer`, 1},
+		{`File.atomic_write("/tmp/goby/atomic.txt")`,
+			`InternalError: Can't yield without a block`, 1},
+		{`File.atomic_write("/tmp/goby/fictitious/atomic.txt") do end`,
+			`IOError: open /tmp/goby/fictitious/.atomic.txt.`, 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		err, ok := evaluated.(*Error)
+
+		if !ok || !strings.HasPrefix(err.Message(), tt.expected) {
+			t.Fatalf("At test case %d: expect error message to start with %q. got: %s", i, tt.expected, evaluated.Inspect())
+		}
+
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestFileAtomicWriteMethodWithErrorInBlock(t *testing.T) {
+	setup()
+	defer teardown()
+
+	path := "/tmp/goby/atomic_error.txt"
+
+	if err := ioutil.WriteFile(path, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	v := initTestVM()
+	evaluated := v.testEval(t, `
+	File.atomic_write("/tmp/goby/atomic_error.txt") do |f|
+	  f.write("new")
+	  raise "Boom"
+	end
+	`, getFilename())
+	checkErrorMsg(t, 0, evaluated, `InternalError: "Boom"`)
+
+	contents, err := ioutil.ReadFile(path)
+
+	if err != nil || string(contents) != "old" {
+		t.Fatalf("Expect the file to be untouched. got: %q, %v", contents, err)
+	}
+
+	leftovers, _ := filepath.Glob("/tmp/goby/.atomic_error.txt.*")
+
+	if len(leftovers) != 0 {
+		t.Fatalf("Expect the temporary file to be removed. got: %v", leftovers)
+	}
+}
+
+func TestFileAtomicWriteMethodKeepsPermissions(t *testing.T) {
+	setup()
+	defer teardown()
+
+	path := "/tmp/goby/atomic_perm.txt"
+
+	if err := ioutil.WriteFile(path, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// WriteFile doesn't change the permissions of an existing file
+	if err := os.Chmod(path, 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	v := initTestVM()
+	evaluated := v.testEval(t, `
+	File.atomic_write("/tmp/goby/atomic_perm.txt") do |f|
+	  f.write("new")
+	end
+	File.new("/tmp/goby/atomic_perm.txt").read
+	`, getFilename())
+	VerifyExpected(t, 0, evaluated, "new")
+
+	fs, err := os.Stat(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fs.Mode().Perm() != 0640 {
+		t.Fatalf("Expect the permissions to be kept. got: %o", fs.Mode().Perm())
+	}
+}
+
 func TestFileBasenameMethod(t *testing.T) {
 	setup()
 	defer teardown()
@@ -326,6 +465,75 @@ func TestFileExtnameMethodFail(t *testing.T) {
 	}
 }
 
+func TestFileFlockMethod(t *testing.T) {
+	setup()
+	defer teardown()
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		File.flock("/tmp/goby/lock_new", :exclusive) do |f|
+		  f.name
+		end
+		`, "/tmp/goby/lock_new"},
+		{`
+		File.flock("/tmp/goby/lock_shared", :shared) do |f|
+		  10
+		end
+		`, 10},
+		// the lock is released after the block, so it can be taken again
+		{`
+		File.flock("/tmp/goby/lock_twice", :exclusive) do |f|
+		  f.write("1")
+		end
+		File.flock("/tmp/goby/lock_twice", :exclusive) do |f|
+		  f.read
+		end
+		`, "1"},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestFileFlockMethodFail(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testsFail := []errorTestCase{
+		{`File.flock("/tmp/goby/lock") do end`,
+			`ArgumentError: Expect 2 argument(s). got: 1`, 1},
+		{`File.flock("/tmp/goby/lock", 1) do end`,
+			`TypeError: Expect argument to be String. got: Integer`, 1},
+		{`File.flock("/tmp/goby/lock", :exclusive)`,
+			`InternalError: Can't yield without a block`, 1},
+		{`File.flock("/tmp/goby/lock", :forever) do end`,
+			`ArgumentError: Unknown lock mode: forever`, 1},
+		{`File.flock("/tmp/goby/fictitious/lock", :exclusive) do end`,
+			`IOError: open /tmp/goby/fictitious/lock: no such file or directory`, 1},
+		{`
+		File.flock("/tmp/goby/lock", :exclusive) do |f|
+		  raise "Boom"
+		end
+		`, `InternalError: "Boom"`, 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestFileJoinMethod(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -520,6 +728,88 @@ func TestFileSplitMethodFail(t *testing.T) {
 
 // Tests for instance methods
 
+func TestFileTouchMethod(t *testing.T) {
+	setup()
+	defer teardown()
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		File.touch("/tmp/goby/touched")
+		[File.exist?("/tmp/goby/touched"), File.size("/tmp/goby/touched")]
+		`, []interface{}{true, 0}},
+		// the contents of an existing file are kept
+		{`
+		File.open("/tmp/goby/touched_old", "w", 0755) do |f|
+		  f.write("Hello")
+		end
+		File.touch("/tmp/goby/touched_old")
+		File.new("/tmp/goby/touched_old").read
+		`, "Hello"},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestFileTouchMethodModifiesTime(t *testing.T) {
+	setup()
+	defer teardown()
+
+	path := "/tmp/goby/touched_time"
+	old := time.Now().Add(-time.Hour)
+
+	if err := ioutil.WriteFile(path, []byte{}, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	v := initTestVM()
+	v.testEval(t, `File.touch("/tmp/goby/touched_time")`, getFilename())
+
+	fs, err := os.Stat(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !fs.ModTime().After(old.Add(time.Minute)) {
+		t.Fatalf("Expect the modification time to be updated. got: %s", fs.ModTime())
+	}
+}
+
+func TestFileTouchMethodFail(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testsFail := []errorTestCase{
+		{`File.touch`,
+			`ArgumentError: Expect 1 argument(s). got: 0`, 1},
+		{`File.touch(1)`,
+			`TypeError: Expect argument to be String. got: Integer`, 1},
+		{`File.touch("/tmp/goby/fictitious/touched")`,
+			`IOError: open /tmp/goby/fictitious/touched: no such file or directory`, 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestFileCloseMethod(t *testing.T) {
 	setup()
 	defer teardown()
@@ -576,7 +866,7 @@ func TestFileReadMethod(t *testing.T) {
 
 	tests := []struct {
 		input    string
-		expected string
+		expected interface{}
 	}{
 		{`
 		l = ""
@@ -586,6 +876,10 @@ func TestFileReadMethod(t *testing.T) {
 		end
 		l
 		`, "Hello, Goby!"},
+		{`
+		f = File.new("../test_fixtures/file_test/size.gb")
+		[f.read(4), f.read(0), f.read(20), f.read(20)]
+		`, []interface{}{"this", "", " file's size is\n22", nil}},
 	}
 
 	for i, tt := range tests {
@@ -51,22 +51,26 @@ var builtinFloatInstanceMethods = []*BuiltinMethodObject{
 				return leftValue + rightValue
 			}
 
-			return receiver.(*FloatObject).arithmeticOperation(t, args[0], operation, sourceLine, false)
+			return receiver.(*FloatObject).arithmeticOperation(t, args[0], "+", operation, sourceLine)
 
 		},
 	},
 	{
-		// Returns the modulo between self and a Numeric.
+		// Returns the modulo between self and a Numeric. Like Integer's, it has the sign of the divisor,
+		// and it's NaN if the divisor is 0.
 		//
 		// ```Ruby
-		// 5.5 % 2 # => 1.5
+		// 5.5 % 2   # => 1.5
+		// -5.5 % 2  # => 0.5
+		// 5.5 % -2  # => -0.5
+		// 5.5 % 0   # => NaN
 		// ```
 		//
 		// @return [Float]
 		Name: "%",
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
-			operation := math.Mod
-			return receiver.(*FloatObject).arithmeticOperation(t, args[0], operation, sourceLine, true)
+			operation := floorModFloat
+			return receiver.(*FloatObject).arithmeticOperation(t, args[0], "%", operation, sourceLine)
 
 		},
 	},
@@ -84,7 +88,7 @@ var builtinFloatInstanceMethods = []*BuiltinMethodObject{
 				return leftValue - rightValue
 			}
 
-			return receiver.(*FloatObject).arithmeticOperation(t, args[0], operation, sourceLine, false)
+			return receiver.(*FloatObject).arithmeticOperation(t, args[0], "-", operation, sourceLine)
 
 		},
 	},
@@ -102,7 +106,7 @@ var builtinFloatInstanceMethods = []*BuiltinMethodObject{
 				return leftValue * rightValue
 			}
 
-			return receiver.(*FloatObject).arithmeticOperation(t, args[0], operation, sourceLine, false)
+			return receiver.(*FloatObject).arithmeticOperation(t, args[0], "*", operation, sourceLine)
 
 		},
 	},
@@ -117,15 +121,18 @@ var builtinFloatInstanceMethods = []*BuiltinMethodObject{
 		Name: "**",
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
 			operation := math.Pow
-			return receiver.(*FloatObject).arithmeticOperation(t, args[0], operation, sourceLine, false)
+			return receiver.(*FloatObject).arithmeticOperation(t, args[0], "**", operation, sourceLine)
 
 		},
 	},
 	{
-		// Returns self divided by a Numeric.
+		// Returns self divided by a Numeric. It follows IEEE 754, so dividing by 0 results in Infinity, -Infinity or NaN.
 		//
 		// ```Ruby
-		// 7.5 / 3 # => 2.5
+		// 7.5 / 3    # => 2.5
+		// 7.5 / 0    # => Infinity
+		// -7.5 / 0   # => -Infinity
+		// 0.0 / 0    # => NaN
 		// ```
 		//
 		// @return [Float]
@@ -135,7 +142,7 @@ var builtinFloatInstanceMethods = []*BuiltinMethodObject{
 				return leftValue / rightValue
 			}
 
-			return receiver.(*FloatObject).arithmeticOperation(t, args[0], operation, sourceLine, true)
+			return receiver.(*FloatObject).arithmeticOperation(t, args[0], "/", operation, sourceLine)
 
 		},
 	},
@@ -383,6 +390,60 @@ var builtinFloatInstanceMethods = []*BuiltinMethodObject{
 			return newInt
 		},
 	},
+	{
+		// Returns `[q, r]`, where q is `(self / divisor).floor` as an Integer and r is `self % divisor`.
+		// Raises a ZeroDivisionError if the divisor is 0.
+		//
+		// ```Ruby
+		// 7.5.divmod(2)   # => [3, 1.5]
+		// -7.5.divmod(2)  # => [-4, 0.5]
+		// 7.5.divmod(-2)  # => [-4, -0.5]
+		// ```
+		// @param divisor [Numeric]
+		// @return [Array]
+		Name: "divmod",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			f := receiver.(*FloatObject)
+			divisor, ok := args[0].(Numeric)
+
+			if !ok {
+				return t.coerceOperation(f, args[0], "divmod", sourceLine)
+			}
+
+			return t.floatDivmod(f.value, divisor.floatValue(), sourceLine)
+
+		},
+	},
+	{
+		// Returns self divided by a Numeric, like `/` does.
+		//
+		// ```Ruby
+		// 7.5.fdiv(2) # => 3.75
+		// 7.5.fdiv(0) # => Infinity
+		// ```
+		// @param divisor [Numeric]
+		// @return [Float]
+		Name: "fdiv",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			f := receiver.(*FloatObject)
+			divisor, ok := args[0].(Numeric)
+
+			if !ok {
+				return t.coerceOperation(f, args[0], "fdiv", sourceLine)
+			}
+
+			return t.vm.initFloatObject(f.value / divisor.floatValue())
+
+		},
+	},
 	{
 		// Returns true if Float is equal to 0.0
 		//
@@ -504,20 +565,17 @@ func (f *FloatObject) floatValue() float64 {
 
 // TODO: Remove instruction argument
 // Apply the passed arithmetic operation, while performing type conversion.
-func (f *FloatObject) arithmeticOperation(t *Thread, rightObject Object, operation func(leftValue float64, rightValue float64) float64, sourceLine int, division bool) Object {
+// Objects that aren't numbers are coerced with `coerce`.
+func (f *FloatObject) arithmeticOperation(t *Thread, rightObject Object, operator string, operation func(leftValue float64, rightValue float64) float64, sourceLine int) Object {
 	rightNumeric, ok := rightObject.(Numeric)
 
 	if !ok {
-		return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, "Numeric", rightObject.Class().Name)
+		return t.coerceOperation(f, rightObject, operator, sourceLine)
 	}
 
 	leftValue := f.value
 	rightValue := rightNumeric.floatValue()
 
-	if division && rightValue == 0 {
-		return t.vm.InitErrorObject(errors.ZeroDivisionError, sourceLine, errors.DividedByZero)
-	}
-
 	result := operation(leftValue, rightValue)
 
 	return t.vm.initFloatObject(result)
@@ -556,7 +614,17 @@ func (f *FloatObject) lessThan(arg Object) bool {
 
 // ToString returns the object's value as the string format, in non
 // exponential format (straight number, without exponent `E<exp>`).
+// Infinities and NaN are written like Ruby does: `Infinity`, `-Infinity` and `NaN`.
 func (f *FloatObject) ToString() string {
+	switch {
+	case math.IsInf(f.value, 1):
+		return "Infinity"
+	case math.IsInf(f.value, -1):
+		return "-Infinity"
+	case math.IsNaN(f.value):
+		return "NaN"
+	}
+
 	s := strconv.FormatFloat(f.value, 'f', -1, 64)
 	// Add ".0" to represent a float number
 	if !strings.Contains(s, ".") {
@@ -572,6 +640,11 @@ func (f *FloatObject) Inspect() string {
 
 // ToJSON just delegates to ToString
 func (f *FloatObject) ToJSON(t *Thread) string {
+	// JSON has no infinities or NaN
+	if math.IsInf(f.value, 0) || math.IsNaN(f.value) {
+		return quoteJSON(f.ToString())
+	}
+
 	return f.ToString()
 }
 
@@ -246,16 +246,84 @@ func TestFloatEdgeCases(t *testing.T) {
 	}
 }
 
-func TestFloatZeroDivisionFail(t *testing.T) {
+func TestFloatZeroDivision(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`(6.0 / 0).to_s`, "Infinity"},
+		{`(6.0 / -0).to_s`, "Infinity"},
+		{`(6.0 / 0.0).to_s`, "Infinity"},
+		{`(6.0 / -0.0).to_s`, "-Infinity"},
+		{`(-6.0 / 0.0).to_s`, "-Infinity"},
+		{`(-6.0 / -0.0).to_s`, "Infinity"},
+		{`(0.0 / 0.0).to_s`, "NaN"},
+		{`(6.0 % 0).to_s`, "NaN"},
+		{`(6.0 % -0).to_s`, "NaN"},
+		{`(6.0 % 0.0).to_s`, "NaN"},
+		{`(6.0 % -0.0).to_s`, "NaN"},
+		{`6.0.fdiv(0).to_s`, "Infinity"},
+		{`(6.0 / 0.0).to_json`, `"Infinity"`},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestFloatDivisionBySignCombination(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`7.5 / 2`, 3.75},
+		{`-7.5 / 2`, -3.75},
+		{`7.5 / -2`, -3.75},
+		{`-7.5 / -2`, 3.75},
+		{`7.5 % 2`, 1.5},
+		{`-7.5 % 2`, 0.5},
+		{`7.5 % -2`, -0.5},
+		{`-7.5 % -2`, -1.5},
+		{`7.5 % 2.5`, 0.0},
+		{`-5.5 % 2.5`, 2.0},
+		{`7.5.divmod(2)`, []interface{}{3, 1.5}},
+		{`-7.5.divmod(2)`, []interface{}{-4, 0.5}},
+		{`7.5.divmod(-2)`, []interface{}{-4, -0.5}},
+		{`-7.5.divmod(-2)`, []interface{}{3, -1.5}},
+		{`7.5.divmod(2.5)`, []interface{}{3, 0.0}},
+		{`7.5 / Rational(-2, 1)`, -3.75},
+		{`7.5 % Rational(2, 1)`, 1.5},
+		{`-7.5 % Rational(2, 1)`, 0.5},
+		{`7.5 % Rational(-2, 1)`, -0.5},
+		{`-7.5 % Rational(-2, 1)`, -1.5},
+		{`-7.5.divmod(Rational(2, 1))`, []interface{}{-4, 0.5}},
+		{`7.5.fdiv(2)`, 3.75},
+		{`-7.5.fdiv(2.5)`, -3.0},
+		{`(1.0 + 1).class.name`, "Float"},
+		{`(1.0 * 9223372036854775807).class.name`, "Float"},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestFloatDivisionFail(t *testing.T) {
 	testsFail := []errorTestCase{
-		{`6.0 / 0`, "ZeroDivisionError: Divided by 0", 1},
-		{`6.0 / -0`, "ZeroDivisionError: Divided by 0", 1},
-		{`6.0 / 0.0`, "ZeroDivisionError: Divided by 0", 1},
-		{`6.0 / -0.0`, "ZeroDivisionError: Divided by 0", 1},
-		{`6.0 % 0`, "ZeroDivisionError: Divided by 0", 1},
-		{`6.0 % -0`, "ZeroDivisionError: Divided by 0", 1},
-		{`6.0 % 0.0`, "ZeroDivisionError: Divided by 0", 1},
-		{`6.0 % -0.0`, "ZeroDivisionError: Divided by 0", 1},
+		{`6.0.divmod(0)`, "ZeroDivisionError: Divided by 0", 1},
+		{`6.0.divmod(0.0)`, "ZeroDivisionError: Divided by 0", 1},
+		{`(1.0 / 0).divmod(2)`, "ArgumentError: Can't divmod Infinity by 2.0", 1},
+		{`6.0.divmod`, "ArgumentError: Expect 1 argument(s). got: 0", 1},
+		{`6.0.fdiv("1")`, "TypeError: Expect argument to be Numeric. got: String", 1},
+		{`6.0 * "1"`, "TypeError: Expect argument to be Numeric. got: String", 1},
 	}
 
 	for i, tt := range testsFail {
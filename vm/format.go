@@ -0,0 +1,245 @@
+package vm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/goby-lang/goby/vm/classes"
+	"github.com/goby-lang/goby/vm/errors"
+)
+
+// Helpers for `format`, `sprintf` and `String#%`, which format their arguments like Ruby's `sprintf`.
+//
+// A directive is written as `%[flags][width][.precision]type`, with the flags `-`, `+`, ` `, `0` and `#`.
+// The supported types are:
+//
+// - `d`, `i` and `u` for Integers. Floats are truncated.
+// - `f`, `e`, `E`, `g` and `G` for Floats and Integers.
+// - `x`, `X`, `o`, `b` and `B` for Integers in hexadecimal, octal and binary.
+//   Negative numbers are written with a minus sign, like `-ff`, instead of Ruby's two's complement form.
+// - `s` for any object's `to_s`, and `p` for its `inspect`.
+// - `c` for a character, given as an Integer code point or a String.
+// - `%%` for a literal `%`.
+
+const (
+	malformedFormat        = "malformed format string - %s"
+	formatArgCountMismatch = "Expect %d argument(s) for the format string. got: %d"
+)
+
+// formatDirective is a parsed directive, or a literal text if the verb is 0
+type formatDirective struct {
+	text      string
+	flags     string
+	width     string
+	precision string
+	verb      byte
+}
+
+// parseFormat splits the format string into literal texts and directives
+func parseFormat(format string) ([]formatDirective, error) {
+	var directives []formatDirective
+	var literal strings.Builder
+
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			literal.WriteByte(format[i])
+			continue
+		}
+
+		start := i
+		i++
+
+		if i < len(format) && format[i] == '%' {
+			literal.WriteByte('%')
+			continue
+		}
+
+		d := formatDirective{}
+
+		for i < len(format) && strings.IndexByte("-+ 0#", format[i]) >= 0 {
+			d.flags += string(format[i])
+			i++
+		}
+
+		for i < len(format) && isDigit(format[i]) {
+			d.width += string(format[i])
+			i++
+		}
+
+		if i < len(format) && format[i] == '.' {
+			d.precision = "."
+			i++
+
+			for i < len(format) && isDigit(format[i]) {
+				d.precision += string(format[i])
+				i++
+			}
+		}
+
+		if i >= len(format) {
+			return nil, fmt.Errorf("%s", format[start:])
+		}
+
+		if strings.IndexByte("diufeEgGxXobBspc", format[i]) < 0 {
+			return nil, fmt.Errorf("%s", format[start:i+1])
+		}
+
+		d.verb = format[i]
+
+		if literal.Len() > 0 {
+			directives = append(directives, formatDirective{text: literal.String()})
+			literal.Reset()
+		}
+
+		directives = append(directives, d)
+	}
+
+	if literal.Len() > 0 {
+		directives = append(directives, formatDirective{text: literal.String()})
+	}
+
+	return directives, nil
+}
+
+func isDigit(b byte) bool {
+	return '0' <= b && b <= '9'
+}
+
+// formatObjects formats the arguments with the format string.
+// It's an ArgumentError if the number of the arguments doesn't match the directives.
+func (t *Thread) formatObjects(format string, args []Object, sourceLine int) Object {
+	directives, err := parseFormat(format)
+
+	if err != nil {
+		return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, malformedFormat, err.Error())
+	}
+
+	count := 0
+
+	for _, d := range directives {
+		if d.verb != 0 {
+			count++
+		}
+	}
+
+	if count != len(args) {
+		return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, formatArgCountMismatch, count, len(args))
+	}
+
+	var out strings.Builder
+	n := 0
+
+	for _, d := range directives {
+		if d.verb == 0 {
+			out.WriteString(d.text)
+			continue
+		}
+
+		formatted, err := t.formatDirective(d, args[n], n, sourceLine)
+
+		if err != nil {
+			return err
+		}
+
+		out.WriteString(formatted)
+		n++
+	}
+
+	return t.vm.InitStringObject(out.String())
+}
+
+// formatDirective formats the nth argument with the directive, mapping it onto Go's fmt verbs
+func (t *Thread) formatDirective(d formatDirective, arg Object, n int, sourceLine int) (string, *Error) {
+	flags, verb := d.flags, d.verb
+	var value interface{}
+
+	switch verb {
+	case 'd', 'i', 'u':
+		i, ok := integerForFormat(arg)
+
+		if !ok {
+			return "", t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormatNum, n+1, classes.IntegerClass, arg.Class().Name)
+		}
+
+		verb, value = 'd', i
+	case 'f', 'e', 'E', 'g', 'G':
+		switch a := arg.(type) {
+		case *FloatObject:
+			value = a.value
+		case *IntegerObject:
+			value = float64(a.value)
+		default:
+			return "", t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormatNum, n+1, classes.FloatClass, arg.Class().Name)
+		}
+
+		// Go writes the shortest representation by default, while Ruby uses 6 significant digits
+		if (verb == 'g' || verb == 'G') && d.precision == "" {
+			d.precision = ".6"
+		}
+	case 'x', 'X', 'o', 'b', 'B':
+		i, ok := arg.(*IntegerObject)
+
+		if !ok {
+			return "", t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormatNum, n+1, classes.IntegerClass, arg.Class().Name)
+		}
+
+		value = i.value
+	case 'c':
+		switch a := arg.(type) {
+		case *IntegerObject:
+			value = rune(a.value)
+		case *StringObject:
+			if a.value == "" {
+				return "", t.vm.InitErrorObject(errors.ArgumentError, sourceLine, "%%c requires a character")
+			}
+
+			value = []rune(a.value)[0]
+		default:
+			return "", t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormatNum, n+1, classes.StringClass, arg.Class().Name)
+		}
+	case 's':
+		value = arg.ToString()
+	case 'p':
+		verb, value = 's', arg.Inspect()
+	}
+
+	// Ruby pads strings and characters with spaces even with the `0` flag
+	if verb == 's' || verb == 'c' {
+		flags = strings.Replace(flags, "0", "", -1)
+	}
+
+	// Go has no `%B`, so the `0b` prefix of `%#b` is upcased instead
+	if verb == 'B' {
+		formatted := fmt.Sprintf("%"+flags+d.width+d.precision+"b", value)
+		return strings.Replace(formatted, "0b", "0B", 1), nil
+	}
+
+	return fmt.Sprintf("%"+flags+d.width+d.precision+string(verb), value), nil
+}
+
+// integerForFormat converts an Integer or a Float, which is truncated, for the integer directives
+func integerForFormat(arg Object) (int, bool) {
+	switch a := arg.(type) {
+	case *IntegerObject:
+		return a.value, true
+	case *FloatObject:
+		return int(a.value), true
+	default:
+		return 0, false
+	}
+}
+
+// formatMethod implements `format` and `sprintf`, which take the format string and its arguments
+func formatMethod(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+	if len(args) < 1 {
+		return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgumentMore, 1, len(args))
+	}
+
+	format, ok := args[0].(*StringObject)
+
+	if !ok {
+		return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.StringClass, args[0].Class().Name)
+	}
+
+	return t.formatObjects(format.value, args[1:], sourceLine)
+}
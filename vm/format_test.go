@@ -0,0 +1,74 @@
+package vm
+
+import (
+	"testing"
+)
+
+func TestFormatMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		// integer directives
+		{`format("%d", 42)`, "42"},
+		{`format("%5d|%-5d|%05d", 42, 42, 42)`, "   42|42   |00042"},
+		{`format("%+d % d %i %u", 3, 4, -5, 6)`, "+3  4 -5 6"},
+		{`format("%d", 3.99)`, "3"},
+		// float precision
+		{`format("%f", 1.5)`, "1.500000"},
+		{`format("%.2f", 3.14159)`, "3.14"},
+		{`format("%0.2f", 3.14159)`, "3.14"},
+		{`format("%08.3f", -3.14159)`, "-003.142"},
+		{`format("%.1f", 2)`, "2.0"},
+		{`format("%e %g %g %G", 1.5, 1234567.0, 0.1, 0.00001)`, "1.500000e+00 1.23457e+06 0.1 1E-05"},
+		// strings
+		{`format("Hello, %s!", "Goby")`, "Hello, Goby!"},
+		{`format("%-6s|%6s|", "ab", "cd")`, "ab    |    cd|"},
+		{`format("%05s", "ab")`, "   ab"},
+		{`format("%.3s", "abcdef")`, "abc"},
+		{`format("%s %s %s", 1, nil, [1, "a"])`, `1  [1, "a"]`},
+		{`format("%p", "a")`, `"a"`},
+		{`format("%c%c", 71, "oby")`, "Go"},
+		// hex, octal and binary
+		{`format("%x %X %o %b", 255, 255, 8, 5)`, "ff FF 10 101"},
+		{`format("%#x %#X %#o %#b %#B", 255, 255, 8, 5, 5)`, "0xff 0XFF 010 0b101 0B101"},
+		{`format("%08b", 10)`, "00001010"},
+		{`format("%x", -255)`, "-ff"},
+		{`format("100%%")`, "100%"},
+		{`sprintf("%d-%s", 1, "a")`, "1-a"},
+		{`"%05.2f" % 3.14159`, "03.14"},
+		{`"%s: %x" % ["hex", 255]`, "hex: ff"},
+		{`"%s" % nil`, ""},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestFormatMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`format("%s and %s", "Goby")`, "ArgumentError: Expect 2 argument(s) for the format string. got: 1", 1},
+		{`format("%s", "Goby", "Ruby")`, "ArgumentError: Expect 1 argument(s) for the format string. got: 2", 1},
+		{`"%d %d" % [1]`, "ArgumentError: Expect 2 argument(s) for the format string. got: 1", 1},
+		{`format("%y", 1)`, "ArgumentError: malformed format string - %y", 1},
+		{`format("%5", 1)`, "ArgumentError: malformed format string - %5", 1},
+		{`format("%d", "1")`, "TypeError: Expect argument #1 to be Integer. got: String", 1},
+		{`format("%s %f", "a", "1")`, "TypeError: Expect argument #2 to be Float. got: String", 1},
+		{`format("%c", "")`, "ArgumentError: %c requires a character", 1},
+		{`format`, "ArgumentError: Expect 1 or more argument(s). got: 0", 1},
+		{`format(1)`, "TypeError: Expect argument to be String. got: Integer", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
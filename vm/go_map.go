@@ -154,5 +154,5 @@ func (m *GoMap) Inspect() string {
 
 // ToJSON just delegates to ToString
 func (m *GoMap) ToJSON(t *Thread) string {
-	return m.ToString()
+	return unsupportedJSON(m)
 }
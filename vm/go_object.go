@@ -92,7 +92,7 @@ func (s *GoObject) Inspect() string {
 
 // ToJSON just delegates to ToString
 func (s *GoObject) ToJSON(t *Thread) string {
-	return s.ToString()
+	return unsupportedJSON(s)
 }
 
 // Other helper functions -----------------------------------------------
@@ -1,10 +1,8 @@
 package vm
 
 import (
-	"bytes"
-	"fmt"
+	"hash/maphash"
 	"sort"
-	"strings"
 
 	"github.com/goby-lang/goby/vm/classes"
 	"github.com/goby-lang/goby/vm/errors"
@@ -148,6 +146,64 @@ var builtinHashInstanceMethods = []*BuiltinMethodObject{
 
 		},
 	},
+	{
+		// Passes each (key, value) pair of the collection to the given block.
+		// The method returns true if all of the results by the block are true,
+		// and stops at the first pair the block returns a falsy value for.
+		// It returns true for an empty hash.
+		//
+		// ```ruby
+		// a = { a: 1, b: 2 }
+		//
+		// a.all? do |k, v|
+		//   v > 0
+		// end            # => true
+		// a.all? do |k, v|
+		//   v == 2
+		// end            # => false
+		//
+		// {}.all? do |k, v|
+		//   false
+		// end            # => true
+		// ```
+		//
+		// @return [Boolean]
+		Name: "all?",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			if blockFrame == nil {
+				return t.vm.InitErrorObject(errors.InternalError, sourceLine, errors.CantYieldWithoutBlockFormat)
+			}
+
+			hash := receiver.(*HashObject)
+			if blockIsEmpty(blockFrame) {
+				return toBooleanObject(len(hash.Pairs) == 0)
+			}
+
+			for stringKey, value := range hash.Pairs {
+				objectKey := t.vm.InitStringObject(stringKey)
+				result, erred := t.builtinMethodYield(blockFrame, objectKey, value)
+
+				if erred {
+					return result
+				}
+
+				if blockFrame.IsRemoved() {
+					return NULL
+				}
+
+				if !result.isTruthy() {
+					return FALSE
+				}
+			}
+
+			return TRUE
+
+		},
+	},
 	{
 		// Passes each (key, value) pair of the collection to the given block.
 		// The method returns true if any of the results by the block is true.
@@ -191,13 +247,13 @@ var builtinHashInstanceMethods = []*BuiltinMethodObject{
 				return FALSE
 			}
 
-			if len(hash.Pairs) == 0 {
-				t.callFrameStack.pop()
-			}
-
 			for stringKey, value := range hash.Pairs {
 				objectKey := t.vm.InitStringObject(stringKey)
-				result := t.builtinMethodYield(blockFrame, objectKey, value)
+				result, erred := t.builtinMethodYield(blockFrame, objectKey, value)
+
+				if erred {
+					return result
+				}
 
 				/*
 					TODO: Discuss this behavior
@@ -248,6 +304,59 @@ var builtinHashInstanceMethods = []*BuiltinMethodObject{
 
 		},
 	},
+	{
+		// If no block is given, just returns the number of the key-value pairs, like `length`.
+		// If a block is given, passes each (key, value) pair to the block,
+		// and returns the number of pairs the block returns a truthy value for.
+		//
+		// ```ruby
+		// h = { a: 1, b: 2, c: 3 }
+		//
+		// h.count # => 3
+		// h.count do |k, v|
+		//   v.odd?
+		// end     # => 2
+		// ```
+		//
+		// @param block [Block]
+		// @return [Integer]
+		Name: "count",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			hash := receiver.(*HashObject)
+			if blockFrame == nil {
+				return t.vm.InitIntegerObject(len(hash.Pairs))
+			}
+
+			if blockIsEmpty(blockFrame) {
+				return t.vm.InitIntegerObject(0)
+			}
+
+			var count int
+			for stringKey, value := range hash.Pairs {
+				objectKey := t.vm.InitStringObject(stringKey)
+				result, erred := t.builtinMethodYield(blockFrame, objectKey, value)
+
+				if erred {
+					return result
+				}
+
+				if blockFrame.IsRemoved() {
+					return NULL
+				}
+
+				if result.isTruthy() {
+					count++
+				}
+			}
+
+			return t.vm.InitIntegerObject(count)
+
+		},
+	},
 	{
 		// Returns the configured default value of the Hash.
 		// If no default value has been specified, nil is returned.
@@ -374,15 +483,15 @@ var builtinHashInstanceMethods = []*BuiltinMethodObject{
 				return hash
 			}
 
-			if len(hash.Pairs) == 0 {
-				t.callFrameStack.pop()
-			}
-
 			// Note that from the Go specification, https://golang.org/ref/spec#For_statements,
 			// it's safe to delete elements from a Map, while iterating it.
 			for stringKey, value := range hash.Pairs {
 				objectKey := t.vm.InitStringObject(stringKey)
-				result := t.builtinMethodYield(blockFrame, objectKey, value)
+				result, erred := t.builtinMethodYield(blockFrame, objectKey, value)
+
+				if erred {
+					return result
+				}
 
 				booleanResult, isResultBoolean := result.(*BooleanObject)
 
@@ -407,6 +516,8 @@ var builtinHashInstanceMethods = []*BuiltinMethodObject{
 		// { a: 1 , b: 2 }.dig(:a)         # => 1
 		// { a: {}, b: 2 }.dig(:a, :b)     # => nil
 		// { a: {}, b: 2 }.dig(:a, :b, :c) # => nil
+		// { a: nil }.dig(:a, :b)          # => nil
+		// { a: [1, 2] }.dig(:a, -1)       # => 2
 		// { a: 1, b: 2 }.dig(:a, :b)      # => TypeError: Expect target to be Diggable
 		// ```
 		//
@@ -497,16 +608,14 @@ var builtinHashInstanceMethods = []*BuiltinMethodObject{
 
 			h := receiver.(*HashObject)
 
-			if len(h.Pairs) == 0 {
-				t.callFrameStack.pop()
-			} else {
-				keys := h.sortedKeys()
+			keys := h.sortedKeys()
 
-				for _, k := range keys {
-					v := h.Pairs[k]
-					strK := t.vm.InitStringObject(k)
+			for _, k := range keys {
+				v := h.Pairs[k]
+				strK := t.vm.InitStringObject(k)
 
-					t.builtinMethodYield(blockFrame, strK, v)
+				if result, erred := t.builtinMethodYield(blockFrame, strK, v); erred {
+					return result
 				}
 			}
 
@@ -543,17 +652,16 @@ var builtinHashInstanceMethods = []*BuiltinMethodObject{
 
 			h := receiver.(*HashObject)
 
-			if len(h.Pairs) == 0 {
-				t.callFrameStack.pop()
-			}
-
 			keys := h.sortedKeys()
 			var arrOfKeys []Object
 
 			for _, k := range keys {
 				obj := t.vm.InitStringObject(k)
 				arrOfKeys = append(arrOfKeys, obj)
-				t.builtinMethodYield(blockFrame, obj)
+
+				if result, erred := t.builtinMethodYield(blockFrame, obj); erred {
+					return result
+				}
 			}
 
 			return t.vm.InitArrayObject(arrOfKeys)
@@ -589,17 +697,16 @@ var builtinHashInstanceMethods = []*BuiltinMethodObject{
 
 			h := receiver.(*HashObject)
 
-			if len(h.Pairs) == 0 {
-				t.callFrameStack.pop()
-			}
-
 			keys := h.sortedKeys()
 			var arrOfValues []Object
 
 			for _, k := range keys {
 				value := h.Pairs[k]
 				arrOfValues = append(arrOfValues, value)
-				t.builtinMethodYield(blockFrame, value)
+
+				if result, erred := t.builtinMethodYield(blockFrame, value); erred {
+					return result
+				}
 			}
 
 			return t.vm.InitArrayObject(arrOfValues)
@@ -683,7 +790,7 @@ var builtinHashInstanceMethods = []*BuiltinMethodObject{
 
 			key, ok := args[0].(*StringObject)
 			if !ok {
-				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.StringClass, key.Class().Name)
+				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.StringClass, args[0].Class().Name)
 			}
 
 			if aLen == 2 {
@@ -697,14 +804,12 @@ var builtinHashInstanceMethods = []*BuiltinMethodObject{
 			value, ok := hash.Pairs[key.value]
 
 			if ok {
-				if blockFrame != nil {
-					t.callFrameStack.pop()
-				}
 				return value
 			}
 
 			if blockFrame != nil {
-				return t.builtinMethodYield(blockFrame, key)
+				result, _ := t.builtinMethodYield(blockFrame, key)
+				return result
 			}
 			return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, "The value was not found, and no block has been provided")
 		},
@@ -733,7 +838,6 @@ var builtinHashInstanceMethods = []*BuiltinMethodObject{
 			values := make([]Object, aLen)
 
 			hash := receiver.(*HashObject)
-			blockFramePopped := false
 
 			for index, objectKey := range args {
 				stringKey, ok := objectKey.(*StringObject)
@@ -746,8 +850,12 @@ var builtinHashInstanceMethods = []*BuiltinMethodObject{
 
 				if !ok {
 					if blockFrame != nil {
-						value = t.builtinMethodYield(blockFrame, objectKey)
-						blockFramePopped = true
+						var erred bool
+
+						if value, erred = t.builtinMethodYield(blockFrame, objectKey); erred {
+							return value
+						}
+
 					} else {
 						return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, "There is no value for the key `%s`, and no block has been provided", stringKey.value)
 					}
@@ -756,10 +864,6 @@ var builtinHashInstanceMethods = []*BuiltinMethodObject{
 				values[index] = value
 			}
 
-			if blockFrame != nil && !blockFramePopped {
-				t.callFrameStack.pop()
-			}
-
 			return t.vm.InitArrayObject(values)
 
 		},
@@ -829,6 +933,44 @@ var builtinHashInstanceMethods = []*BuiltinMethodObject{
 
 		},
 	},
+	{
+		// Returns a new hash whose keys are the values of the receiver, and whose values are the keys.
+		// Since hash keys must be Strings, all the values must be Strings.
+		//
+		// If some keys share the same value, only one of them is kept in the result:
+		// the one that comes last in the order of `sorted_keys`.
+		//
+		// ```Ruby
+		// { a: "x", b: "y" }.invert          # => { x: "a", y: "b" }
+		// { a: "x", b: "x", c: "y" }.invert  # => { x: "b", y: "c" }
+		// { a: 1 }.invert                    # => TypeError
+		// ```
+		//
+		// @return [Hash]
+		Name: "invert",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			h := receiver.(*HashObject)
+			result := make(map[string]Object)
+
+			// Keys are visited in sorted order, so the last one wins deterministically on duplicate values
+			for _, k := range h.sortedKeys() {
+				v, ok := h.Pairs[k].(*StringObject)
+
+				if !ok {
+					return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.InvalidHashKey, h.Pairs[k].Class().Name)
+				}
+
+				result[v.value] = t.vm.InitStringObject(k)
+			}
+
+			return t.vm.InitHashObject(result)
+
+		},
+	},
 	{
 		// Returns an array of keys (in arbitrary order)
 		//
@@ -905,12 +1047,14 @@ var builtinHashInstanceMethods = []*BuiltinMethodObject{
 
 			result := make(map[string]Object)
 
-			if len(h.Pairs) == 0 {
-				t.callFrameStack.pop()
-			}
-
 			for k, v := range h.Pairs {
-				result[k] = t.builtinMethodYield(blockFrame, v)
+				value, erred := t.builtinMethodYield(blockFrame, v)
+
+				if erred {
+					return value
+				}
+
+				result[k] = value
 			}
 			return t.vm.InitHashObject(result)
 
@@ -957,6 +1101,64 @@ var builtinHashInstanceMethods = []*BuiltinMethodObject{
 
 		},
 	},
+	{
+		// Passes each (key, value) pair of the collection to the given block.
+		// The method returns true if none of the results by the block is true,
+		// and stops at the first pair the block returns a truthy value for.
+		// It returns true for an empty hash.
+		//
+		// ```ruby
+		// a = { a: 1, b: 2 }
+		//
+		// a.none? do |k, v|
+		//   v > 2
+		// end            # => true
+		// a.none? do |k, v|
+		//   v == 2
+		// end            # => false
+		//
+		// {}.none? do |k, v|
+		//   true
+		// end            # => true
+		// ```
+		//
+		// @return [Boolean]
+		Name: "none?",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			if blockFrame == nil {
+				return t.vm.InitErrorObject(errors.InternalError, sourceLine, errors.CantYieldWithoutBlockFormat)
+			}
+
+			hash := receiver.(*HashObject)
+			if blockIsEmpty(blockFrame) {
+				return TRUE
+			}
+
+			for stringKey, value := range hash.Pairs {
+				objectKey := t.vm.InitStringObject(stringKey)
+				result, erred := t.builtinMethodYield(blockFrame, objectKey, value)
+
+				if erred {
+					return result
+				}
+
+				if blockFrame.IsRemoved() {
+					return NULL
+				}
+
+				if result.isTruthy() {
+					return FALSE
+				}
+			}
+
+			return TRUE
+
+		},
+	},
 	{
 		// Returns a new hash consisting of entries for which the block does not return false
 		// or nil.
@@ -997,13 +1199,13 @@ var builtinHashInstanceMethods = []*BuiltinMethodObject{
 
 			sourceHash := receiver.(*HashObject)
 
-			if len(sourceHash.Pairs) == 0 {
-				t.callFrameStack.pop()
-			}
-
 			for stringKey, value := range sourceHash.Pairs {
 				objectKey := t.vm.InitStringObject(stringKey)
-				result := t.builtinMethodYield(blockFrame, objectKey, value)
+				result, erred := t.builtinMethodYield(blockFrame, objectKey, value)
+
+				if erred {
+					return result
+				}
 
 				if result.isTruthy() {
 					destinationPairs[stringKey] = value
@@ -1119,8 +1321,7 @@ var builtinHashInstanceMethods = []*BuiltinMethodObject{
 				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
 			}
 
-			r := receiver.(*HashObject)
-			return t.vm.InitStringObject(r.ToJSON(t))
+			return t.encodeJSON(receiver, sourceLine)
 
 		},
 	},
@@ -1173,13 +1374,15 @@ var builtinHashInstanceMethods = []*BuiltinMethodObject{
 
 			h := receiver.(*HashObject)
 
-			if len(h.Pairs) == 0 {
-				t.callFrameStack.pop()
-			}
-
 			resultHash := make(map[string]Object)
 			for k, v := range h.Pairs {
-				resultHash[k] = t.builtinMethodYield(blockFrame, v)
+				value, erred := t.builtinMethodYield(blockFrame, v)
+
+				if erred {
+					return value
+				}
+
+				resultHash[k] = value
 			}
 			return t.vm.InitHashObject(resultHash)
 
@@ -1274,18 +1477,7 @@ func (h *HashObject) Value() interface{} {
 
 // ToString returns the object's name as the string format
 func (h *HashObject) ToString() string {
-	var out bytes.Buffer
-	var pairs []string
-
-	for _, key := range h.sortedKeys() {
-		pairs = append(pairs, fmt.Sprintf("%s: %s", key, h.Pairs[key].Inspect()))
-	}
-
-	out.WriteString("{ ")
-	out.WriteString(strings.Join(pairs, ", "))
-	out.WriteString(" }")
-
-	return out.String()
+	return inspectWithDepth(h, 0)
 }
 
 // Inspect delegates to ToString
@@ -1295,18 +1487,8 @@ func (h *HashObject) Inspect() string {
 
 // ToJSON returns the object's name as the JSON string format
 func (h *HashObject) ToJSON(t *Thread) string {
-	var out bytes.Buffer
-	var values []string
-	pairs := h.Pairs
-	out.WriteString("{")
-
-	for key, value := range pairs {
-		values = append(values, generateJSONFromPair(key, value, t))
-	}
-
-	out.WriteString(strings.Join(values, ","))
-	out.WriteString("}")
-	return out.String()
+	e := &jsonEncoder{t: t}
+	return e.encodePairs(h.Pairs, jsonRootPath)
 }
 
 // Returns the length of the hash
@@ -1355,7 +1537,7 @@ func (h *HashObject) dig(t *Thread, keys []Object, sourceLine int) Object {
 		return NULL
 	}
 
-	if len(nextKeys) == 0 {
+	if len(nextKeys) == 0 || currentValue == NULL {
 		return currentValue
 	}
 
@@ -1368,6 +1550,13 @@ func (h *HashObject) dig(t *Thread, keys []Object, sourceLine int) Object {
 	return diggableCurrentValue.dig(t, nextKeys, sourceLine)
 }
 
+// hashKey hashes a key with the vm's seed. Hash keys are strings stored in Go maps, whose hashing Go randomizes already,
+// but any hashing scheme Goby implements itself, like bucketing keys that aren't strings, must use it:
+// keys from untrusted input can't then be crafted to collide, as the seed differs from one vm to another.
+func (vm *VM) hashKey(key string) uint64 {
+	return maphash.String(vm.hashSeed, key)
+}
+
 func (h *HashObject) equalTo(with Object) bool {
 	w, ok := with.(*HashObject)
 
@@ -1387,18 +1576,3 @@ func (h *HashObject) equalTo(with Object) bool {
 
 	return true
 }
-
-// Other helper functions ----------------------------------------------
-
-// Return the JSON style strings of the Hash object
-func generateJSONFromPair(key string, v Object, t *Thread) string {
-	var data string
-	var out bytes.Buffer
-
-	out.WriteString(data)
-	out.WriteString("\"" + key + "\"")
-	out.WriteString(":")
-	out.WriteString(v.ToJSON(t))
-
-	return out.String()
-}
@@ -2,6 +2,7 @@ package vm
 
 import (
 	"encoding/json"
+	"fmt"
 	"reflect"
 	"sort"
 	"testing"
@@ -243,6 +244,78 @@ func TestHashComparisonOperation(t *testing.T) {
 
 // Method test
 
+func TestHashAllMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+      { a: 1, b: 2 }.all? do |k, v|
+        v > 0
+      end
+		`, true},
+		{`
+      { a: 1, b: 2 }.all? do |k, v|
+        v == 2
+      end
+		`, false},
+		{`
+      { a: 1, b: nil }.all? do |k, v|
+        v
+      end
+		`, false},
+		{`
+      { }.all? do |k, v|
+        false
+      end
+		`, true},
+		// stops at the first falsy result
+		{`
+      n = 0
+      { a: 1, b: 2, c: 3 }.all? do |k, v|
+        n += 1
+        false
+      end
+      n
+		`, 1},
+		// cases for providing an empty block
+		{`
+      { a: 1, b: 2 }.all? do; end
+		`, false},
+		{`
+      {}.all? do; end
+		`, true},
+		{`
+	  { key: "foo", bar: "baz" }.all? do |k, v|
+	    break
+	  end
+		`, nil},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestHashAllMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`{  }.all?(123) do end`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+		{`{  }.all?`, "InternalError: Can't yield without a block", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestHashAnyMethod(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -273,6 +346,15 @@ func TestHashAnyMethod(t *testing.T) {
         true
       end
 		`, false},
+		// stops at the first truthy result
+		{`
+      n = 0
+      { a: 1, b: 2, c: 3 }.any? do |k, v|
+        n += 1
+        true
+      end
+      n
+		`, 1},
 		// cases for providing an empty block
 		{`
       { a: 1, b: 2 }.any? do; end
@@ -360,6 +442,75 @@ func TestHashClearMethodFail(t *testing.T) {
 	}
 }
 
+func TestHashCountMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+      { a: 1, b: 2, c: 3 }.count
+		`, 3},
+		{`
+      { }.count
+		`, 0},
+		{`
+      { a: 1, b: 2, c: 3 }.count do |k, v|
+        v.odd?
+      end
+		`, 2},
+		{`
+      { a: 1, b: 2, c: 3 }.count do |k, v|
+        k == "b" || v == 3
+      end
+		`, 2},
+		{`
+      { a: 1, b: 2 }.count do |k, v|
+        v > 5
+      end
+		`, 0},
+		{`
+      { }.count do |k, v|
+        true
+      end
+		`, 0},
+		// cases for providing an empty block
+		{`
+      { a: 1, b: 2 }.count do; end
+		`, 0},
+		{`
+      {}.count do; end
+		`, 0},
+		{`
+	  { key: "foo", bar: "baz" }.count do |k, v|
+	    break
+	  end
+		`, nil},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestHashCountMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`{ a: 1 }.count(1)`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+		{`{ a: 1 }.count(1) do end`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestHashDefaultOperation(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -551,6 +702,12 @@ func TestHashDigMethod(t *testing.T) {
 		{`
 			{ a: {}, b: 2 }.dig(:a, :b, :c)
 		`, nil},
+		{`
+			{ a: nil }.dig(:a, :b)
+		`, nil},
+		{`
+			{ a: [1, { b: 2 }] }.dig(:a, -1, :b)
+		`, 2},
 	}
 
 	for i, tt := range tests {
@@ -912,6 +1069,7 @@ func TestHashFetchMethodFail(t *testing.T) {
 		{`{ spaghetti: "eat" }.fetch("a", "b", "c")`, "ArgumentError: Expect 1 to 2 argument(s). got: 3", 1},
 		{`{ spaghetti: "eat" }.fetch("a", "b") do end`, "ArgumentError: The default argument can't be passed along with a block", 1},
 		{`{ spaghetti: "eat" }.fetch("pizza")`, "ArgumentError: The value was not found, and no block has been provided", 1},
+		{`{ spaghetti: "eat" }.fetch(nil)`, "TypeError: Expect argument to be String. got: Null", 1},
 	}
 
 	for i, tt := range testsFail {
@@ -1036,6 +1194,46 @@ func TestHashHasValueMethodFail(t *testing.T) {
 	}
 }
 
+func TestHashInvertMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected map[string]interface{}
+	}{
+		{`{ a: "x", b: "y", c: "z" }.invert`, map[string]interface{}{"x": "a", "y": "b", "z": "c"}},
+		{`{ a: "x", b: "x", c: "y" }.invert`, map[string]interface{}{"x": "b", "y": "c"}},
+		{`{ b: "x", a: "x", c: "x" }.invert`, map[string]interface{}{"x": "c"}},
+		{`{}.invert`, map[string]interface{}{}},
+		{`
+		h = { a: "x" }
+		h.invert
+		h
+		`, map[string]interface{}{"a": "x"}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		verifyHashObject(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestHashInvertMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`{ a: "x" }.invert(1)`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+		{`{ a: "x", b: 1 }.invert`, "TypeError: Expect hash key to be String. got: Integer", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestHashKeysMethod(t *testing.T) {
 	input := `
 	{ foo: 123, bar: "test", baz: true }.keys
@@ -1256,6 +1454,78 @@ func TestHashMergeMethodFail(t *testing.T) {
 	}
 }
 
+func TestHashNoneMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+      { a: 1, b: 2 }.none? do |k, v|
+        v > 2
+      end
+		`, true},
+		{`
+      { a: 1, b: 2 }.none? do |k, v|
+        v == 2
+      end
+		`, false},
+		{`
+      { a: nil, b: false }.none? do |k, v|
+        v
+      end
+		`, true},
+		{`
+      { }.none? do |k, v|
+        true
+      end
+		`, true},
+		// stops at the first truthy result
+		{`
+      n = 0
+      { a: 1, b: 2, c: 3 }.none? do |k, v|
+        n += 1
+        true
+      end
+      n
+		`, 1},
+		// cases for providing an empty block
+		{`
+      { a: 1, b: 2 }.none? do; end
+		`, true},
+		{`
+      {}.none? do; end
+		`, true},
+		{`
+	  { key: "foo", bar: "baz" }.none? do |k, v|
+	    break
+	  end
+		`, nil},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestHashNoneMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`{  }.none?(123) do end`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+		{`{  }.none?`, "InternalError: Can't yield without a block", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestHashSelectMethod(t *testing.T) {
 	testsSortedArray := []struct {
 		input    string
@@ -1951,6 +2221,36 @@ func TestHashInspectCallsChildElementToString(t *testing.T) {
 	vm.checkSP(t, i, 1)
 }
 
+func TestHashKeySeedDiffersBetweenVMs(t *testing.T) {
+	const buckets = 16
+	keys := make([]string, 64)
+
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key%d", i)
+	}
+
+	distribution := func(v *VM) []uint64 {
+		indexes := make([]uint64, len(keys))
+
+		for i, key := range keys {
+			indexes[i] = v.hashKey(key) % buckets
+		}
+
+		return indexes
+	}
+
+	v1 := initTestVM()
+	v2 := initTestVM()
+
+	if !reflect.DeepEqual(distribution(v1), distribution(v1)) {
+		t.Fatal("Expect a vm to hash the same keys into the same buckets")
+	}
+
+	if reflect.DeepEqual(distribution(v1), distribution(v2)) {
+		t.Fatal("Expect vms to hash the same keys into different buckets")
+	}
+}
+
 func TestHashDupMethod(t *testing.T) {
 	tests := []struct {
 		input    string
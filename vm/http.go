@@ -7,6 +7,7 @@ import (
 	"path"
 	"strings"
 
+	"github.com/goby-lang/goby/vm/classes"
 	"github.com/goby-lang/goby/vm/errors"
 )
 
@@ -14,12 +15,11 @@ const (
 	invalidSplatArgument    = "Splat arguments must be a string, got: %s on argument #%d"
 	couldNotCompleteRequest = "Could not complete request, %s"
 	non200Response          = "Non-200 response, %s (%d)"
-)
-
-var (
-	httpRequestClass  *RClass
-	httpResponseClass *RClass
-	httpClientClass   *RClass
+	invalidHeaderValue      = "Expect the value of header %s to be String. got: %s"
+	invalidClientOption     = "Expect option %s to be %s. got: %s"
+	unknownClientOption     = "Unknown option: %s"
+	unknownRequestAttribute = "Unknown attribute %s for Request#with"
+	invalidRequestURL       = "Invalid url %s: %s"
 )
 
 // Class methods --------------------------------------------------------
@@ -60,12 +60,13 @@ var builtinHTTPClassMethods = []*BuiltinMethodObject{
 			if err != nil {
 				return t.vm.InitErrorObject(errors.HTTPError, sourceLine, couldNotCompleteRequest, err)
 			}
+			defer discardResponse(resp)
+
 			if resp.StatusCode != http.StatusOK {
 				return t.vm.InitErrorObject(errors.HTTPError, sourceLine, non200Response, resp.Status, resp.StatusCode)
 			}
 
 			content, err := ioutil.ReadAll(resp.Body)
-			resp.Body.Close()
 
 			if err != nil {
 				return t.vm.InitErrorObject(errors.InternalError, sourceLine, err.Error())
@@ -104,12 +105,13 @@ var builtinHTTPClassMethods = []*BuiltinMethodObject{
 			if err != nil {
 				return t.vm.InitErrorObject(errors.HTTPError, sourceLine, couldNotCompleteRequest, err)
 			}
+			defer discardResponse(resp)
+
 			if resp.StatusCode != http.StatusOK {
 				return t.vm.InitErrorObject(errors.HTTPError, sourceLine, non200Response, resp.Status, resp.StatusCode)
 			}
 
 			content, err := ioutil.ReadAll(resp.Body)
-			resp.Body.Close()
 
 			if err != nil {
 				return t.vm.InitErrorObject(errors.InternalError, sourceLine, err.Error())
@@ -144,15 +146,223 @@ var builtinHTTPClassMethods = []*BuiltinMethodObject{
 				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
 			}
 
-			gobyClient := httpClientClass.initializeInstance()
+			gobyClient := t.vm.httpClientClass.initializeInstance()
 
-			result := t.builtinMethodYield(blockFrame, gobyClient)
+			result, _ := t.builtinMethodYield(blockFrame, gobyClient)
+			return result
 
-			if err, ok := result.(*Error); ok {
-				return err //an Error object
+		},
+	}, {
+		// Expands a URI template with the values of the given hash, as described in RFC 6570 up to level 3.
+		// Besides `{name}`, the expressions can start with an operator: `+` and `#` keep reserved characters,
+		// `.`, `/` and `;` add labels, path segments and parameters, and `?` and `&` add a query or continue it.
+		// Missing and nil values are left out. Values can be Strings or Numerics.
+		//
+		// ```ruby
+		// Net::HTTP.expand("/users/{id}/posts{?page,limit}", { id: 42, page: 2 })  # => "/users/42/posts?page=2"
+		// Net::HTTP.expand("/search{?q}{&lang}", { q: "Hello World!", lang: "en" }) # => "/search?q=Hello%20World%21&lang=en"
+		// Net::HTTP.expand("{+base}/items{/id}", { base: "http://example.com" })    # => "http://example.com/items"
+		// ```
+		//
+		// @param template [String]
+		// @param values [Hash]
+		// @return [String]
+		Name: "expand",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 2 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 2, len(args))
 			}
 
-			return result
+			template, ok := args[0].(*StringObject)
+
+			if !ok {
+				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormatNum, 1, classes.StringClass, args[0].Class().Name)
+			}
+
+			values, ok := args[1].(*HashObject)
+
+			if !ok {
+				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormatNum, 2, classes.HashClass, args[1].Class().Name)
+			}
+
+			expanded, err := expandURITemplate(template.value, values.Pairs)
+
+			if err != nil {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, err.Error())
+			}
+
+			return t.vm.InitStringObject(expanded)
+
+		},
+	},
+}
+
+// Instance methods -----------------------------------------------------
+var builtinHTTPRequestInstanceMethods = []*BuiltinMethodObject{
+	{
+		// Returns a copy of the request that can be changed without affecting the receiver.
+		// The headers and the params are copied, and so is a String body. A streamed body is shared.
+		//
+		// ```ruby
+		// r = Net::HTTP::Request.new({ Accept: "text/plain" })
+		// r2 = r.dup
+		// r2.set_header("Accept", "application/json")
+		// r.get_header("Accept") # => "text/plain"
+		// ```
+		//
+		// @return [Request]
+		Name: "dup",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			return t.dupHTTPRequest(receiver)
+
+		},
+	},
+	{
+		// Replaces the path of the request's URL, keeping its scheme, host and query, and returns the path.
+		//
+		// ```ruby
+		// r = Net::HTTP::Request.new
+		// r.url = "http://example.com/users?page=2"
+		// r.path = "/posts"
+		// r.url # => "http://example.com/posts?page=2"
+		// ```
+		//
+		// @param path [String]
+		// @return [String]
+		Name: "path=",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			p, ok := args[0].(*StringObject)
+
+			if !ok {
+				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.StringClass, args[0].Class().Name)
+			}
+
+			err := t.updateRequestURL(receiver, sourceLine, func(u *url.URL) {
+				u.Path = p.value
+				u.RawPath = ""
+			})
+
+			if err != nil {
+				return err
+			}
+
+			receiver.InstanceVariableSet("@path", t.vm.InitStringObject(p.value))
+
+			return p
+
+		},
+	},
+	{
+		// Replaces the query of the request's URL and returns it. A Hash is encoded with its keys sorted,
+		// a String is used as it is, and nil removes the query.
+		//
+		// ```ruby
+		// r = Net::HTTP::Request.new
+		// r.url = "http://example.com/search?q=old"
+		// r.query = { q: "goby lang", page: 2 }
+		// r.url # => "http://example.com/search?page=2&q=goby+lang"
+		// ```
+		//
+		// @param query [Hash]
+		// @return [Hash]
+		Name: "query=",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			var query string
+
+			switch q := args[0].(type) {
+			case *HashObject:
+				values := url.Values{}
+
+				for key, value := range q.Pairs {
+					values.Set(key, value.ToString())
+				}
+
+				query = values.Encode()
+			case *StringObject:
+				query = strings.TrimPrefix(q.value, "?")
+			case *NullObject:
+			default:
+				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.HashClass, args[0].Class().Name)
+			}
+
+			err := t.updateRequestURL(receiver, sourceLine, func(u *url.URL) {
+				u.RawQuery = query
+			})
+
+			if err != nil {
+				return err
+			}
+
+			return args[0]
+
+		},
+	},
+	{
+		// Returns a copy of the request with the given attributes changed, leaving the receiver untouched.
+		// Each key is set with its setter, like `method=` or `path=`, on a copy made by `dup`.
+		//
+		// ```ruby
+		// get = Net::HTTP::Request.new
+		// get.method = "GET"
+		// get.url = "http://example.com/users/1"
+		// put = get.with({ method: "PUT", body: "name=goby" })
+		// get.method # => "GET"
+		// put.method # => "PUT"
+		// ```
+		//
+		// @param attributes [Hash]
+		// @return [Request]
+		Name: "with",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			changes, ok := args[0].(*HashObject)
+
+			if !ok {
+				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.HashClass, args[0].Class().Name)
+			}
+
+			copied := t.dupHTTPRequest(receiver)
+
+			for _, key := range changes.sortedKeys() {
+				// headers only has a reader, so they're replaced with a copy of the given hash
+				if key == "headers" {
+					headers, ok := changes.Pairs[key].(*HashObject)
+
+					if !ok {
+						return t.vm.InitErrorObject(errors.TypeError, sourceLine, invalidClientOption, key, classes.HashClass, changes.Pairs[key].Class().Name)
+					}
+
+					copied.InstanceVariableSet("@headers", t.vm.InitHashObject(copyPairs(headers.Pairs)))
+					continue
+				}
+
+				if copied.findMethod(key+"=") == nil {
+					return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, unknownRequestAttribute, key)
+				}
+
+				result := t.callMethod(copied, key+"=", sourceLine, changes.Pairs[key])
+
+				if err, ok := result.(*Error); ok {
+					return err
+				}
+			}
+
+			return copied
 
 		},
 	},
@@ -196,6 +406,8 @@ func httpMethodWithoutBody(method string, receiver Object, sourceLine int, t *Th
 	if err != nil {
 		return t.vm.InitErrorObject(errors.HTTPError, sourceLine, couldNotCompleteRequest, err)
 	}
+	defer discardResponse(resp)
+
 	if resp.StatusCode != http.StatusOK {
 		return t.vm.InitErrorObject(errors.HTTPError, sourceLine, non200Response, resp.Status, resp.StatusCode)
 	}
@@ -209,6 +421,60 @@ func httpMethodWithoutBody(method string, receiver Object, sourceLine int, t *Th
 	return ret
 }
 
+// dupHTTPRequest copies the request's instance variables, along with its headers, params and String body
+func (t *Thread) dupHTTPRequest(receiver Object) Object {
+	copied := receiver.Class().initializeInstance()
+	ivars := receiver.instanceVariables().copy()
+
+	for _, name := range []string{"@headers", "@params"} {
+		if h, ok := ivars.get(name); ok {
+			if hash, ok := h.(*HashObject); ok {
+				ivars.set(name, t.vm.InitHashObject(copyPairs(hash.Pairs)))
+			}
+		}
+	}
+
+	if b, ok := ivars.get("@body"); ok {
+		if body, ok := b.(*StringObject); ok {
+			ivars.set("@body", t.vm.InitStringObject(body.value))
+		}
+	}
+
+	copied.setInstanceVariables(ivars)
+	return copied
+}
+
+// updateRequestURL parses the request's url, lets change update it, and stores it back
+func (t *Thread) updateRequestURL(receiver Object, sourceLine int, change func(u *url.URL)) *Error {
+	var raw string
+
+	if u, ok := receiver.InstanceVariableGet("@url"); ok {
+		if s, ok := u.(*StringObject); ok {
+			raw = s.value
+		}
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, invalidRequestURL, raw, err)
+	}
+
+	change(u)
+	receiver.InstanceVariableSet("@url", t.vm.InitStringObject(u.String()))
+
+	return nil
+}
+
+func copyPairs(pairs map[string]Object) map[string]Object {
+	copied := make(map[string]Object, len(pairs))
+
+	for key, value := range pairs {
+		copied[key] = value
+	}
+
+	return copied
+}
+
 // Functions for initialization -----------------------------------------
 
 func initHTTPClass(vm *VM) {
@@ -222,18 +488,17 @@ func initHTTPClass(vm *VM) {
 	net.setClassConstant(http)
 
 	// Use Goby code to extend request and response classes.
-	vm.mainThread.execGobyLib("net/http/response.gb")
-	vm.mainThread.execGobyLib("net/http/request.gb")
+	vm.execGobyLibInNewThread("net/http/response.gb")
+	vm.execGobyLibInNewThread("net/http/request.gb")
 }
 
 func initRequestClass(vm *VM, hc *RClass) *RClass {
 	requestClass := vm.initializeClass("Request")
 	hc.setClassConstant(requestClass)
-	builtinHTTPRequestInstanceMethods := []*BuiltinMethodObject{}
 
 	requestClass.setBuiltinMethods(builtinHTTPRequestInstanceMethods, false)
 
-	httpRequestClass = requestClass
+	vm.httpRequestClass = requestClass
 	return requestClass
 }
 
@@ -244,6 +509,6 @@ func initResponseClass(vm *VM, hc *RClass) *RClass {
 
 	responseClass.setBuiltinMethods(builtinHTTPResponseInstanceMethods, false)
 
-	httpResponseClass = responseClass
+	vm.httpResponseClass = responseClass
 	return responseClass
 }
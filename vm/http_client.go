@@ -1,21 +1,150 @@
 package vm
 
 import (
+	"bytes"
+	"crypto/tls"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/goby-lang/goby/vm/classes"
 	"github.com/goby-lang/goby/vm/errors"
 )
 
+// HTTPClientObject is a `Net::HTTP::Client` created by `Net::HTTP::Client.new`, which sends its requests with its own Go client
+// configured by the options given to `new`. Other clients, like the ones `Net::HTTP.start` yields, share Go's default client.
+type HTTPClientObject struct {
+	*RObject
+	client *http.Client
+}
+
+// httpClientOption validates an option of `Net::HTTP::Client.new`, and applies it to the Go client and its transport.
+// The transport is nil if the option doesn't need one.
+type httpClientOption struct {
+	class string
+	apply func(value Object, c *http.Client, tr *http.Transport) error
+}
+
+// httpClientOptions are the options `Net::HTTP::Client.new` accepts, besides the ones that have setters and `strict`
+var httpClientOptions = map[string]httpClientOption{
+	"timeout": {"Numeric", func(value Object, c *http.Client, tr *http.Transport) error {
+		timeout, err := positiveDuration(value)
+		c.Timeout = timeout
+		return err
+	}},
+	"proxy": {classes.StringClass, func(value Object, c *http.Client, tr *http.Transport) error {
+		u, err := url.Parse(value.(*StringObject).value)
+
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("Invalid proxy URL: %s", value.(*StringObject).value)
+		}
+
+		tr.Proxy = http.ProxyURL(u)
+		return nil
+	}},
+	"verify_tls": {classes.BooleanClass, func(value Object, c *http.Client, tr *http.Transport) error {
+		tr.TLSClientConfig = &tls.Config{InsecureSkipVerify: value == FALSE}
+		return nil
+	}},
+	"max_idle": {classes.IntegerClass, func(value Object, c *http.Client, tr *http.Transport) error {
+		n := value.(*IntegerObject).value
+
+		if n < 0 {
+			return fmt.Errorf(errors.NegativeValue, n)
+		}
+
+		// 0 means no limit to the transport, but no idle connection at all here
+		if n == 0 {
+			tr.DisableKeepAlives = true
+		}
+
+		tr.MaxIdleConns = n
+		tr.MaxIdleConnsPerHost = n
+		return nil
+	}},
+	"idle_timeout": {"Numeric", func(value Object, c *http.Client, tr *http.Transport) error {
+		timeout, err := positiveDuration(value)
+		tr.IdleConnTimeout = timeout
+		return err
+	}},
+	"compression": {classes.BooleanClass, func(value Object, c *http.Client, tr *http.Transport) error {
+		tr.DisableCompression = value == FALSE
+		return nil
+	}},
+}
+
+// Class methods --------------------------------------------------------
+var builtinHTTPClientClassMethods = []*BuiltinMethodObject{
+	{
+		// Returns a new client configured by the options Hash, which can have:
+		//
+		// - `timeout`: how long a request can take in seconds, including reading the response body.
+		// - `proxy`: the URL of the proxy to send the requests through, instead of the one in `HTTP_PROXY` and `HTTPS_PROXY`.
+		// - `verify_tls`: false to accept any certificate of an HTTPS server. It's true by default.
+		// - `max_idle`: how many idle connections are kept for reuse for each host, or 0 not to reuse connections.
+		// - `idle_timeout`: how long an idle connection is kept in seconds.
+		// - `compression`: false not to ask for gzipped responses. It's true by default.
+		// - `default_headers`, `accept`, `content_type` and `expect_continue`: the same as setting them with
+		//   `default_headers=`, `accept=`, `content_type=` and `expect_continue=`.
+		//
+		// An option of a wrong type is a TypeError. An unknown option is ignored with a warning,
+		// or is an ArgumentError if `strict` is true.
+		//
+		// ```ruby
+		// require "net/http"
+		//
+		// client = Net::HTTP::Client.new({ timeout: 5, proxy: "http://proxy.local:8080", verify_tls: false, max_idle: 10 })
+		// client.get("https://example.com")
+		// ```
+		//
+		// @param options [Hash]
+		// @return [Client]
+		Name: "new",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) > 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgumentLess, 1, len(args))
+			}
+
+			options := t.vm.InitHashObject(map[string]Object{})
+
+			if len(args) == 1 {
+				typeErr := t.vm.checkArgTypes(args, sourceLine, classes.HashClass)
+
+				if typeErr != nil {
+					return typeErr
+				}
+
+				options = args[0].(*HashObject)
+			}
+
+			class, ok := receiver.(*RClass)
+
+			if !ok {
+				return t.vm.InitNoMethodError(sourceLine, "new", receiver)
+			}
+
+			client := &HTTPClientObject{RObject: class.initializeInstance()}
+
+			if err := t.configureHTTPClient(client, options, sourceLine); err != nil {
+				return err
+			}
+
+			return client
+
+		},
+	},
+}
+
 // Instance methods --------------------------------------------------------
 
 func builtinHTTPClientInstanceMethods() []*BuiltinMethodObject {
-	//TODO: cookie jar and mutable client
-	goClient := http.DefaultClient
-
+	//TODO: cookie jar
 	return []*BuiltinMethodObject{
 		{
 			// Sends a GET request to the target and returns a `Net::HTTP::Response` object.
@@ -31,7 +160,16 @@ func builtinHTTPClientInstanceMethods() []*BuiltinMethodObject {
 					return typeErr
 				}
 
-				resp, err := goClient.Get(args[0].Value().(string))
+				u := args[0].Value().(string)
+
+				newReq, errObj := t.interceptedRequest(receiver, sourceLine, "GET", u, "", "", func() (*http.Request, error) {
+					return http.NewRequest("GET", u, nil)
+				})
+				if errObj != nil {
+					return errObj
+				}
+
+				resp, err := sendWithRetry(goClientOf(receiver), receiver, newReq)
 				if err != nil {
 					return t.vm.InitErrorObject(errors.HTTPError, sourceLine, couldNotCompleteRequest, err)
 				}
@@ -41,7 +179,7 @@ func builtinHTTPClientInstanceMethods() []*BuiltinMethodObject {
 					return t.vm.InitErrorObject(errors.InternalError, sourceLine, err.Error())
 				}
 
-				return gobyResp
+				return t.interceptResponse(receiver, gobyResp)
 
 			},
 		}, {
@@ -58,9 +196,23 @@ func builtinHTTPClientInstanceMethods() []*BuiltinMethodObject {
 					return typeErr
 				}
 
-				bodyR := strings.NewReader(args[2].Value().(string))
+				u, contentType, body := args[0].Value().(string), args[1].Value().(string), args[2].Value().(string)
+
+				// The body reader is consumed by each attempt, so a new one is built every time
+				newReq, errObj := t.interceptedRequest(receiver, sourceLine, "POST", u, contentType, body, func() (*http.Request, error) {
+					req, err := http.NewRequest("POST", u, strings.NewReader(body))
+					if err != nil {
+						return nil, err
+					}
 
-				resp, err := goClient.Post(args[0].Value().(string), args[1].Value().(string), bodyR)
+					req.Header.Set("Content-Type", contentType)
+					return req, nil
+				})
+				if errObj != nil {
+					return errObj
+				}
+
+				resp, err := sendWithRetry(goClientOf(receiver), receiver, newReq)
 				if err != nil {
 					return t.vm.InitErrorObject(errors.HTTPError, sourceLine, "Could not complete request, %s", err)
 				}
@@ -70,7 +222,7 @@ func builtinHTTPClientInstanceMethods() []*BuiltinMethodObject {
 					return t.vm.InitErrorObject(errors.InternalError, sourceLine, err.Error())
 				}
 
-				return gobyResp
+				return t.interceptResponse(receiver, gobyResp)
 
 			},
 		}, {
@@ -87,7 +239,16 @@ func builtinHTTPClientInstanceMethods() []*BuiltinMethodObject {
 					return typeErr
 				}
 
-				resp, err := goClient.Head(args[0].Value().(string))
+				u := args[0].Value().(string)
+
+				newReq, errObj := t.interceptedRequest(receiver, sourceLine, "HEAD", u, "", "", func() (*http.Request, error) {
+					return http.NewRequest("HEAD", u, nil)
+				})
+				if errObj != nil {
+					return errObj
+				}
+
+				resp, err := sendWithRetry(goClientOf(receiver), receiver, newReq)
 				if err != nil {
 					return t.vm.InitErrorObject(errors.HTTPError, sourceLine, couldNotCompleteRequest, err)
 				}
@@ -97,36 +258,60 @@ func builtinHTTPClientInstanceMethods() []*BuiltinMethodObject {
 					return t.vm.InitErrorObject(errors.InternalError, sourceLine, err.Error())
 				}
 
-				return gobyResp
+				return t.interceptResponse(receiver, gobyResp)
 
 			},
 		}, {
 			// Returns a blank `Net::HTTP::Request` object to be sent with the`exec` method
 			Name: "request",
 			Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
-				return httpRequestClass.initializeInstance()
+				return t.vm.httpRequestClass.initializeInstance()
 
 			},
 		}, {
-			// Sends a passed `Net::HTTP::Request` object and returns a `Net::HTTP::Response` object
+			// Sends a passed `Net::HTTP::Request` object and returns a `Net::HTTP::Response` object.
+			// The request's body can be a String, or an object responding to `read` such as a `File`.
+			// The latter is streamed: `read` is called with the number of bytes wanted until it returns `nil`,
+			// so the body doesn't have to be sent in a single piece.
+			// What's read from a streamed body is kept in memory as well, so the body can be sent again
+			// when the request is redirected with a 307 or 308 status, or retried because of `retry_on`.
+			// Setting the request's `buffer_body` to false saves the memory of large bodies: the body is then sent once,
+			// so a 307 or 308 response is returned as is instead of being followed, and a retried request sends whatever is left of it.
+			//
+			// ```ruby
+			// Net::HTTP.start do |client|
+			//   r = client.request()
+			//   r.url = "http://example.com/upload"
+			//   r.method = "POST"
+			//   r.body = File.new("large.txt")
+			//   client.exec(r)
+			// end
+			// ```
 			Name: "exec",
 			Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
 				if len(args) != 1 {
 					return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
 				}
 
-				typeErr := t.vm.checkArgTypes(args, sourceLine, httpRequestClass.Name)
+				typeErr := t.vm.checkArgTypes(args, sourceLine, t.vm.httpRequestClass.Name)
 
 				if typeErr != nil {
 					return typeErr
 				}
 
-				goReq, err := requestGobyToGo(args[0])
+				gobyReq, errObj := t.interceptRequest(receiver, args[0])
+
+				if errObj != nil {
+					return errObj
+				}
+
+				req, err := requestGobyToGo(t, gobyReq)
+
 				if err != nil {
 					return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, err.Error())
 				}
 
-				goResp, err := goClient.Do(goReq)
+				goResp, err := sendWithRetry(goClientOf(receiver), receiver, replayRequest(t, gobyReq, req))
 				if err != nil {
 					return t.vm.InitErrorObject(errors.HTTPError, sourceLine, couldNotCompleteRequest, err)
 				}
@@ -137,7 +322,446 @@ func builtinHTTPClientInstanceMethods() []*BuiltinMethodObject {
 					return t.vm.InitErrorObject(errors.InternalError, sourceLine, err.Error())
 				}
 
-				return gobyResp
+				return t.interceptResponse(receiver, gobyResp)
+
+			},
+		}, {
+			// Registers a block that's called with every `Net::HTTP::Request` the client sends by `get`, `post`, `head` and `exec`,
+			// before it's sent, and returns the client. The block can change the request, like adding a header,
+			// or return another request to be sent instead, like one made by `with`. Other return values are ignored.
+			// The blocks are called in the order they were registered, and an error raised in one stops the request.
+			//
+			// `get`, `post` and `head` pass a new request with the method, the url, and for `post` the `Content-Type` header
+			// and the body. `exec` passes the request it was given, so changes made by the block stay on it.
+			//
+			// ```ruby
+			// Net::HTTP.start do |client|
+			//   client.before_request do |req|
+			//     req.set_header("Authorization", "Bearer token")
+			//   end
+			//   client.get("http://example.com")
+			// end
+			// ```
+			//
+			// @param block literal
+			// @return [Client] self
+			Name: "before_request",
+			Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+				return t.addHTTPInterceptor(receiver, "@request_interceptors", sourceLine, args, blockFrame)
+
+			},
+		}, {
+			// Registers a block that's called with every `Net::HTTP::Response` the client gets by `get`, `post`, `head` and `exec`,
+			// and returns the client. The block can inspect or change the response, or return another response to be returned instead.
+			// Other return values are ignored, so a block logging the response doesn't have to return it.
+			// The blocks are called in the order they were registered, and an error raised in one is raised by the request method.
+			//
+			// ```ruby
+			// Net::HTTP.start do |client|
+			//   codes = []
+			//   client.after_response do |res|
+			//     codes.push(res.status_code)
+			//   end
+			//   client.get("http://example.com")
+			//   codes # => [200]
+			// end
+			// ```
+			//
+			// @param block literal
+			// @return [Client] self
+			Name: "after_response",
+			Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+				return t.addHTTPInterceptor(receiver, "@response_interceptors", sourceLine, args, blockFrame)
+
+			},
+		}, {
+			// Sends a GET request for a stream of server-sent events, and yields each event to the block as it arrives,
+			// as a Hash with the `event` name ("message" unless the server names it), the `data`, and the last `id` the server sent or nil.
+			// The connection is kept open until the server closes it or the block breaks, and then it returns nil or the break value.
+			//
+			// With `reconnect: true`, the client connects again whenever the stream ends, sending the last id as `Last-Event-ID`,
+			// after the delay the server set with a `retry` field, or 3 seconds by default; only a break stops it then.
+			// If the connection breaks in the middle of the stream without `reconnect`, or a connection attempt fails
+			// or isn't answered with 200, an HTTPError is raised.
+			//
+			// ```ruby
+			// Net::HTTP.start do |client|
+			//   client.get_sse("http://example.com/events", { Authorization: "Bearer token" }) do |event|
+			//     puts(event["data"])
+			//
+			//     if event["event"] == "done"
+			//       break
+			//     end
+			//   end
+			// end
+			// ```
+			//
+			// @param url [String], headers [Hash], reconnect [Boolean], block literal
+			// @return [Object]
+			Name: "get_sse",
+			Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+				if len(args) < 1 || len(args) > 3 {
+					return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgumentRange, 1, 3, len(args))
+				}
+
+				if blockFrame == nil {
+					return t.vm.InitErrorObject(errors.InternalError, sourceLine, errors.CantYieldWithoutBlockFormat)
+				}
+
+				// The headers can be left out when reconnect is given
+				types := []string{classes.StringClass, classes.HashClass, classes.BooleanClass}
+
+				if len(args) == 2 && args[1].Class().Name == classes.BooleanClass {
+					types = []string{classes.StringClass, classes.BooleanClass}
+				}
+
+				typeErr := t.vm.checkArgTypes(args, sourceLine, types[:len(args)]...)
+
+				if typeErr != nil {
+					return typeErr
+				}
+
+				u := args[0].(*StringObject)
+				headers := map[string]Object{}
+				reconnect := false
+
+				for _, arg := range args[1:] {
+					switch arg := arg.(type) {
+					case *HashObject:
+						headers = arg.Pairs
+					case *BooleanObject:
+						reconnect = arg.value
+					}
+				}
+
+				for key, value := range headers {
+					if _, ok := value.(*StringObject); !ok {
+						return t.vm.InitErrorObject(errors.TypeError, sourceLine, invalidHeaderValue, key, value.Class().Name)
+					}
+				}
+
+				// The stream is kept open for as long as it goes, so the client's timeout doesn't apply
+				sseClient := *goClientOf(receiver)
+				sseClient.Timeout = 0
+
+				stream := newSSEReader(nil, "", defaultSSERetry)
+
+				for {
+					req, err := http.NewRequest("GET", u.value, nil)
+					if err != nil {
+						return t.vm.InitErrorObject(errors.HTTPError, sourceLine, couldNotCompleteRequest, err)
+					}
+
+					for key, value := range headers {
+						req.Header.Set(key, value.(*StringObject).value)
+					}
+
+					req.Header.Set("Accept", "text/event-stream")
+					req.Header.Set("Cache-Control", "no-cache")
+
+					if stream.lastID != "" {
+						req.Header.Set("Last-Event-ID", stream.lastID)
+					}
+
+					setDefaultHeaders(receiver, req)
+
+					resp, err := sseClient.Do(req)
+					if err != nil {
+						return t.vm.InitErrorObject(errors.HTTPError, sourceLine, couldNotCompleteRequest, err)
+					}
+
+					if resp.StatusCode != http.StatusOK {
+						discardResponse(resp)
+						return t.vm.InitErrorObject(errors.HTTPError, sourceLine, non200Response, resp.Status, resp.StatusCode)
+					}
+
+					stream = newSSEReader(resp.Body, stream.lastID, stream.retry)
+
+					for {
+						event, err := stream.next()
+
+						if err == io.EOF {
+							break
+						}
+
+						if err != nil {
+							resp.Body.Close()
+
+							if reconnect {
+								break
+							}
+
+							return t.vm.InitErrorObject(errors.HTTPError, sourceLine, couldNotCompleteRequest, err)
+						}
+
+						result, erred := t.builtinMethodYield(blockFrame, event.toGobyHash(t))
+
+						if erred {
+							resp.Body.Close()
+							return result
+						}
+
+						// The block broke, so the connection is dropped without waiting for the stream to end
+						if blockFrame.IsRemoved() {
+							resp.Body.Close()
+
+							if blockFrame.breakValue != nil {
+								return blockFrame.breakValue
+							}
+
+							return NULL
+						}
+					}
+
+					resp.Body.Close()
+
+					if !reconnect {
+						break
+					}
+
+					time.Sleep(stream.retry)
+				}
+
+				return NULL
+
+			},
+		}, {
+			// Makes the client retry requests that are answered with one of the given status codes,
+			// up to `max_attempts` attempts in total (including the first one).
+			// A `Retry-After` header on the response is honored before the next attempt, waiting 30 seconds at most.
+			// When the attempts are exhausted, the last response is returned as usual.
+			// Errors on the transport level are not retried.
+			//
+			// ```ruby
+			// Net::HTTP.start do |client|
+			//   client.retry_on([429, 503], 3)
+			//   client.get("http://example.com")
+			// end
+			// ```
+			//
+			// @param status codes [Array], max attempts [Integer]
+			// @return [Client] self
+			Name: "retry_on",
+			Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+				if len(args) != 2 {
+					return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 2, len(args))
+				}
+
+				typeErr := t.vm.checkArgTypes(args, sourceLine, classes.ArrayClass, classes.IntegerClass)
+
+				if typeErr != nil {
+					return typeErr
+				}
+
+				maxAttempts := args[1].(*IntegerObject).value
+				if maxAttempts < 1 {
+					return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.NegativeValue, maxAttempts)
+				}
+
+				// The codes are copied, so changing the Array later doesn't change the policy
+				policy := &httpRetryPolicy{maxAttempts: maxAttempts, statusCodes: map[int]bool{}}
+
+				for _, code := range args[0].(*ArrayObject).Elements {
+					c, ok := code.(*IntegerObject)
+
+					if !ok {
+						return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.IntegerClass, code.Class().Name)
+					}
+
+					policy.statusCodes[c.value] = true
+				}
+
+				receiver.InstanceVariableSet("@retry_policy", t.vm.initGoObject(policy))
+
+				return receiver
+
+			},
+		}, {
+			// Returns the headers set with `default_headers=`, or an empty Hash if none is set.
+			//
+			// @return [Hash]
+			Name: "default_headers",
+			Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+				if len(args) != 0 {
+					return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+				}
+
+				if headers, ok := receiver.InstanceVariableGet("@default_headers"); ok {
+					return headers
+				}
+
+				return t.vm.InitHashObject(map[string]Object{})
+
+			},
+		}, {
+			// Sets the headers sent with every request of the client, by `get`, `post`, `head` and `exec`.
+			// A header the request sets itself, like the `Content-Type` of `post` or one set with `set_header`, takes precedence.
+			// The header values must be Strings. The Hash is copied, so changing it later doesn't affect the client.
+			//
+			// ```ruby
+			// Net::HTTP.start do |client|
+			//   client.default_headers = { Authorization: "Bearer token", Accept: "application/json" }
+			//   client.get("http://example.com/a")
+			//   r = client.request()
+			//   r.url = "http://example.com/b"
+			//   r.method = "GET"
+			//   r.set_header("Accept", "text/plain") # sent instead of the default
+			//   client.exec(r)
+			// end
+			// ```
+			//
+			// @param headers [Hash]
+			// @return [Hash]
+			Name: "default_headers=",
+			Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+				if len(args) != 1 {
+					return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+				}
+
+				headers, ok := args[0].(*HashObject)
+
+				if !ok {
+					return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.HashClass, args[0].Class().Name)
+				}
+
+				for _, key := range headers.sortedKeys() {
+					if _, ok := headers.Pairs[key].(*StringObject); !ok {
+						return t.vm.InitErrorObject(errors.TypeError, sourceLine, invalidHeaderValue, key, headers.Pairs[key].Class().Name)
+					}
+				}
+
+				receiver.InstanceVariableSet("@default_headers", headers.copy())
+
+				return args[0]
+
+			},
+		}, {
+			// Returns the `Accept` header set with `accept=`, or nil if none is set.
+			//
+			// @return [String]
+			Name: "accept",
+			Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+				if len(args) != 0 {
+					return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+				}
+
+				if accept, ok := receiver.InstanceVariableGet("@accept"); ok {
+					return accept
+				}
+
+				return NULL
+
+			},
+		}, {
+			// Sets the `Accept` header sent with every request of the client, by `get`, `post`, `head` and `exec`,
+			// or stops sending it if the value is nil.
+			// It takes precedence over an `Accept` header in `default_headers=`, and a request setting the header itself overrides it.
+			//
+			// ```ruby
+			// Net::HTTP.start do |client|
+			//   client.accept = "application/json"
+			//   client.get("http://example.com/users") # sent with "Accept: application/json"
+			//   r = client.request()
+			//   r.url = "http://example.com/users.csv"
+			//   r.method = "GET"
+			//   r.set_header("Accept", "text/csv") # sent instead of the client's
+			//   client.exec(r)
+			// end
+			// ```
+			//
+			// @param accept [String]
+			// @return [String]
+			Name: "accept=",
+			Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+				return t.setHTTPClientHeader(receiver, "@accept", sourceLine, args)
+
+			},
+		}, {
+			// Returns the `Content-Type` header set with `content_type=`, or nil if none is set.
+			//
+			// @return [String]
+			Name: "content_type",
+			Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+				if len(args) != 0 {
+					return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+				}
+
+				if contentType, ok := receiver.InstanceVariableGet("@content_type"); ok {
+					return contentType
+				}
+
+				return NULL
+
+			},
+		}, {
+			// Sets the `Content-Type` header sent with the requests of the client that have a body, by `exec`,
+			// or stops sending it if the value is nil. Requests without a body are sent without it.
+			// It takes precedence over a `Content-Type` header in `default_headers=`, and a request setting the header itself,
+			// like `post` or one with `set_header`, overrides it.
+			//
+			// ```ruby
+			// Net::HTTP.start do |client|
+			//   client.content_type = "application/json"
+			//   r = client.request()
+			//   r.url = "http://example.com/users"
+			//   r.method = "POST"
+			//   r.body = { name: "Stan" }.to_json # sent with "Content-Type: application/json"
+			//   client.exec(r)
+			// end
+			// ```
+			//
+			// @param content_type [String]
+			// @return [String]
+			Name: "content_type=",
+			Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+				return t.setHTTPClientHeader(receiver, "@content_type", sourceLine, args)
+
+			},
+		}, {
+			// Returns true if the client sends requests with a body with `Expect: 100-continue`, see `expect_continue=`.
+			//
+			// @return [Boolean]
+			Name: "expect_continue",
+			Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+				if len(args) != 0 {
+					return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+				}
+
+				return toBooleanObject(expectsContinue(receiver))
+
+			},
+		}, {
+			// Makes the client send requests with a body, like large uploads, with the `Expect: 100-continue` header.
+			// The body is only sent after the server answers with `100 Continue`, so it's not sent at all
+			// if the server rejects the request right away, like with `413 Payload Too Large`.
+			// If the server doesn't answer within a second, the body is sent anyway.
+			//
+			// ```ruby
+			// Net::HTTP.start do |client|
+			//   client.expect_continue = true
+			//   r = client.request()
+			//   r.url = "http://example.com/upload"
+			//   r.method = "POST"
+			//   r.body = File.new("large.txt")
+			//   client.exec(r)
+			// end
+			// ```
+			//
+			// @param enabled [Boolean]
+			// @return [Boolean]
+			Name: "expect_continue=",
+			Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+				if len(args) != 1 {
+					return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+				}
+
+				if _, ok := args[0].(*BooleanObject); !ok {
+					return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.BooleanClass, args[0].Class().Name)
+				}
+
+				receiver.InstanceVariableSet("@expect_continue", args[0])
+
+				return args[0]
 
 			},
 		},
@@ -153,45 +777,522 @@ func initClientClass(vm *VM, hc *RClass) *RClass {
 	hc.setClassConstant(clientClass)
 
 	clientClass.setBuiltinMethods(builtinHTTPClientInstanceMethods(), false)
+	clientClass.setBuiltinMethods(builtinHTTPClientClassMethods, true)
 
-	httpClientClass = clientClass
+	vm.httpClientClass = clientClass
 	return clientClass
 }
 
 // Other helper functions -----------------------------------------------
 
-func requestGobyToGo(gobyReq Object) (*http.Request, error) {
+// goClientOf returns the Go client the client sends requests with
+func goClientOf(client Object) *http.Client {
+	if c, ok := client.(*HTTPClientObject); ok {
+		return c.client
+	}
+
+	return http.DefaultClient
+}
+
+// configureHTTPClient builds the Go client of a client created by `Net::HTTP::Client.new` from the options,
+// and sets the options that have setters with them
+func (t *Thread) configureHTTPClient(client *HTTPClientObject, options *HashObject, sourceLine int) *Error {
+	strict := false
+
+	if value, ok := options.Pairs["strict"]; ok {
+		if _, ok := value.(*BooleanObject); !ok {
+			return t.vm.InitErrorObject(errors.TypeError, sourceLine, invalidClientOption, "strict", classes.BooleanClass, value.Class().Name)
+		}
+
+		strict = value == TRUE
+	}
+
+	goClient := &http.Client{}
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	customTransport := false
+	setters := map[string]string{"default_headers": "default_headers=", "accept": "accept=", "content_type": "content_type=", "expect_continue": "expect_continue="}
+
+	for _, key := range options.sortedKeys() {
+		value := options.Pairs[key]
+
+		if _, ok := setters[key]; ok || key == "strict" {
+			continue
+		}
+
+		option, ok := httpClientOptions[key]
+
+		if !ok {
+			if strict {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, unknownClientOption, key)
+			}
+
+			fmt.Fprintf(t.vm.stderr, "warning: unknown option %s for %s is ignored\n", key, client.Class().Name)
+			continue
+		}
+
+		if _, isNumeric := value.(Numeric); value.Class().Name != option.class && !(option.class == "Numeric" && isNumeric) {
+			return t.vm.InitErrorObject(errors.TypeError, sourceLine, invalidClientOption, key, option.class, value.Class().Name)
+		}
+
+		if err := option.apply(value, goClient, tr); err != nil {
+			return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, err.Error())
+		}
+
+		customTransport = customTransport || key != "timeout"
+	}
+
+	// The clients without transport options share the default transport's connections
+	if customTransport {
+		goClient.Transport = tr
+	}
+
+	for _, key := range []string{"default_headers", "accept", "content_type", "expect_continue"} {
+		if value, ok := options.Pairs[key]; ok {
+			if err, ok := t.callMethod(client, setters[key], sourceLine, value).(*Error); ok {
+				return err
+			}
+		}
+	}
+
+	client.client = goClient
+	return nil
+}
+
+// positiveDuration converts a number of seconds to a duration, which must be positive
+func positiveDuration(seconds Object) (time.Duration, error) {
+	d := time.Duration(seconds.(Numeric).floatValue() * float64(time.Second))
+
+	if d <= 0 {
+		return 0, fmt.Errorf("Expect a positive number of seconds. got: %s", seconds.ToString())
+	}
+
+	return d, nil
+}
+
+func requestGobyToGo(t *Thread, gobyReq Object) (*http.Request, error) {
 	//:method, :protocol, :body, :content_length, :transfer_encoding, :host, :path, :url, :params
 	uObj, ok := gobyReq.InstanceVariableGet("@url")
 	if !ok {
 		return nil, fmt.Errorf("could not get url")
 	}
 
-	u := uObj.(*StringObject).value
+	u, ok := uObj.(*StringObject)
+	if !ok {
+		return nil, fmt.Errorf("url must be a String. got: %s", uObj.Class().Name)
+	}
 
 	methodObj, ok := gobyReq.InstanceVariableGet("@method")
 	if !ok {
 		return nil, fmt.Errorf("could not get method")
 	}
 
-	method := methodObj.(*StringObject).value
+	m, ok := methodObj.(*StringObject)
+	if !ok {
+		return nil, fmt.Errorf("method must be a String. got: %s", methodObj.Class().Name)
+	}
+
+	method := m.value
 
-	var body string
+	// http.NewRequest sets GetBody and ContentLength for a String body, so it can be sent again on redirects.
+	// A streamed body gets a GetBody replaying what was read, unless the request's `buffer_body` is false.
+	var body io.Reader = strings.NewReader("")
+	var getBody func() (io.ReadCloser, error)
 	if !(method == "GET" || method == "HEAD") {
 		bodyObj, ok := gobyReq.InstanceVariableGet("@body")
 		if !ok {
 			return nil, fmt.Errorf("could not get body")
 		}
 
-		body = bodyObj.(*StringObject).value
+		switch b := bodyObj.(type) {
+		case *StringObject:
+			body = strings.NewReader(b.value)
+		default:
+			if b.findMethod("read") == nil {
+				return nil, fmt.Errorf("body must be a String or respond to read. got: %s", b.Class().Name)
+			}
+
+			// The transport reads the body in its own goroutine, so `read` is called on another thread
+			readerThread := t.vm.newThread()
+			body = &gobyReader{t: &readerThread, source: b}
+
+			if buffer, ok := gobyReq.InstanceVariableGet("@buffer_body"); !ok || buffer != FALSE {
+				replayable := &replayableBody{source: body}
+				body = replayable
+				getBody = replayable.getBody
+			}
+		}
+	}
+
+	req, err := http.NewRequest(method, u.value, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if getBody != nil {
+		req.GetBody = getBody
+	}
+
+	if headersObj, ok := gobyReq.InstanceVariableGet("@headers"); ok {
+		headers, ok := headersObj.(*HashObject)
+		if !ok {
+			return nil, fmt.Errorf("headers must be a Hash. got: %s", headersObj.Class().Name)
+		}
+
+		for key, value := range headers.Pairs {
+			v, ok := value.(*StringObject)
+			if !ok {
+				return nil, fmt.Errorf(invalidHeaderValue, key, value.Class().Name)
+			}
+
+			req.Header.Set(key, v.value)
+		}
+	}
+
+	return req, nil
+
+}
+
+// gobyReader adapts a Goby object responding to `read` to io.Reader, so a request body can be streamed from it.
+// `read` is called with the number of bytes wanted, and it should return a String, or nil at the end of the stream.
+type gobyReader struct {
+	t       *Thread
+	source  Object
+	pending []byte
+}
+
+func (r *gobyReader) Read(p []byte) (int, error) {
+	if len(r.pending) == 0 {
+		chunk := r.t.callMethod(r.source, "read", 0, r.t.vm.InitIntegerObject(len(p)))
+
+		switch chunk := chunk.(type) {
+		case *StringObject:
+			r.pending = []byte(chunk.value)
+		case *NullObject:
+			return 0, io.EOF
+		case *Error:
+			return 0, fmt.Errorf("%s", chunk.Message())
+		default:
+			return 0, fmt.Errorf("read must return a String or nil. got: %s", chunk.Class().Name)
+		}
+
+		if len(r.pending) == 0 {
+			return 0, io.EOF
+		}
+	}
+
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+// replayableBody keeps what's read from a streamed request body, so the body can be sent again with GetBody.
+// The lock serializes the reads, since the transport may still be reading the body of the previous attempt.
+type replayableBody struct {
+	lock   sync.Mutex
+	source io.Reader
+	read   []byte
+}
+
+func (b *replayableBody) Read(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	n, err := b.source.Read(p)
+	b.read = append(b.read, p[:n]...)
+	return n, err
+}
+
+// getBody reads the rest of the source and returns the whole body.
+// The previous attempt gets EOF from then on, which is fine since it's been answered already.
+func (b *replayableBody) getBody() (io.ReadCloser, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	rest, err := ioutil.ReadAll(b.source)
+	b.read = append(b.read, rest...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(b.read)), nil
+}
+
+// replayRequest returns a newReq function for sendWithRetry, which gives req for the first attempt,
+// and copies of it with the body from GetBody for the next ones.
+// A request without GetBody is built again from the Goby request instead.
+func replayRequest(t *Thread, gobyReq Object, req *http.Request) func() (*http.Request, error) {
+	sent := false
+
+	return func() (*http.Request, error) {
+		if !sent {
+			sent = true
+			return req, nil
+		}
+
+		if req.GetBody == nil {
+			return requestGobyToGo(t, gobyReq)
+		}
+
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+
+		retried := req.Clone(req.Context())
+		retried.Body = body
+		return retried, nil
+	}
+}
+
+// httpRetryPolicy tells which responses are worth another attempt, and how many attempts a request gets in total
+type httpRetryPolicy struct {
+	maxAttempts int
+	statusCodes map[int]bool
+}
+
+// retryPolicyOf reads the policy set with `retry_on` from the client. By default nothing is retried.
+func retryPolicyOf(client Object) *httpRetryPolicy {
+	if obj, ok := client.InstanceVariableGet("@retry_policy"); ok {
+		if g, ok := obj.(*GoObject); ok {
+			if policy, ok := g.data.(*httpRetryPolicy); ok {
+				return policy
+			}
+		}
+	}
+
+	return &httpRetryPolicy{maxAttempts: 1, statusCodes: map[int]bool{}}
+}
+
+// addHTTPInterceptor stores the block in the client's interceptors of the given kind
+func (t *Thread) addHTTPInterceptor(client Object, kind string, sourceLine int, args []Object, blockFrame *normalCallFrame) Object {
+	if len(args) != 0 {
+		return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+	}
+
+	if blockFrame == nil {
+		return t.vm.InitErrorObject(errors.InternalError, sourceLine, errors.CantYieldWithoutBlockFormat)
+	}
+
+	block := t.vm.initBlockObject(blockFrame.instructionSet, blockFrame.ep, blockFrame.self, blockFrame.sourceLine)
+
+	interceptors, ok := client.InstanceVariableGet(kind)
+	if !ok {
+		interceptors = t.vm.InitArrayObject([]Object{})
+		client.InstanceVariableSet(kind, interceptors)
+	}
+
+	interceptors.(*ArrayObject).Elements = append(interceptors.(*ArrayObject).Elements, block)
+
+	return client
+}
+
+// runHTTPInterceptors calls the client's interceptors of the given kind in order, each with the result of the previous one.
+// A block returning something that's not of the class of obj leaves obj as it is.
+func (t *Thread) runHTTPInterceptors(client Object, kind string, obj Object) (Object, *Error) {
+	interceptors, ok := client.InstanceVariableGet(kind)
+	if !ok {
+		return obj, nil
+	}
+
+	for _, b := range interceptors.(*ArrayObject).Elements {
+		block := b.(*BlockObject)
+		c := newNormalCallFrame(block.instructionSet, block.instructionSet.filename, block.sourceLine)
+		c.ep = block.ep
+		c.self = block.self
+		c.isBlock = true
+
+		result, erred := t.builtinMethodYield(c, obj)
+
+		if erred {
+			return nil, result.(*Error)
+		}
+
+		if result.Class() == obj.Class() {
+			obj = result
+		}
+	}
+
+	return obj, nil
+}
+
+// interceptRequest passes the request to the client's `before_request` blocks, and returns the request to send
+func (t *Thread) interceptRequest(client Object, gobyReq Object) (Object, *Error) {
+	return t.runHTTPInterceptors(client, "@request_interceptors", gobyReq)
+}
+
+// interceptResponse passes the response to the client's `after_response` blocks, and returns the response to return,
+// or the error raised by a block
+func (t *Thread) interceptResponse(client Object, gobyResp Object) Object {
+	resp, err := t.runHTTPInterceptors(client, "@response_interceptors", gobyResp)
+	if err != nil {
+		return err
+	}
+
+	return resp
+}
+
+// interceptedRequest returns newReq as is if the client has no `before_request` block. Otherwise the request is built
+// as a `Net::HTTP::Request` passed to the blocks, and the newReq function returned sends what they leave of it.
+func (t *Thread) interceptedRequest(client Object, sourceLine int, method, u, contentType, body string, newReq func() (*http.Request, error)) (func() (*http.Request, error), *Error) {
+	if _, ok := client.InstanceVariableGet("@request_interceptors"); !ok {
+		return newReq, nil
+	}
+
+	headers := map[string]Object{}
+	if contentType != "" {
+		headers["Content-Type"] = t.vm.InitStringObject(contentType)
+	}
+
+	gobyReq := t.vm.httpRequestClass.initializeInstance()
+	gobyReq.InstanceVariableSet("@method", t.vm.InitStringObject(method))
+	gobyReq.InstanceVariableSet("@url", t.vm.InitStringObject(u))
+	gobyReq.InstanceVariableSet("@headers", t.vm.InitHashObject(headers))
+	gobyReq.InstanceVariableSet("@body", t.vm.InitStringObject(body))
+
+	intercepted, errObj := t.interceptRequest(client, gobyReq)
+	if errObj != nil {
+		return nil, errObj
+	}
+
+	req, err := requestGobyToGo(t, intercepted)
+	if err != nil {
+		return nil, t.vm.InitErrorObject(errors.HTTPError, sourceLine, couldNotCompleteRequest, err)
+	}
+
+	return replayRequest(t, intercepted, req), nil
+}
+
+// setHTTPClientHeader implements `accept=` and `content_type=`, which keep the header value in the given instance variable
+func (t *Thread) setHTTPClientHeader(client Object, ivar string, sourceLine int, args []Object) Object {
+	if len(args) != 1 {
+		return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+	}
+
+	switch args[0].(type) {
+	case *StringObject, *NullObject:
+	default:
+		return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.StringClass, args[0].Class().Name)
+	}
+
+	client.InstanceVariableSet(ivar, args[0])
+
+	return args[0]
+}
+
+// setDefaultHeaders adds the headers set with `accept=`, `content_type=` and `default_headers=` on the client to the request,
+// except the ones the request already has. `Content-Type` is only added to a request with a body.
+func setDefaultHeaders(client Object, req *http.Request) {
+	if accept, ok := client.InstanceVariableGet("@accept"); ok && accept != NULL && req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", accept.(*StringObject).value)
+	}
+
+	hasBody := req.Body != nil && req.Body != http.NoBody
+	if contentType, ok := client.InstanceVariableGet("@content_type"); ok && contentType != NULL && hasBody && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", contentType.(*StringObject).value)
+	}
+
+	headers, ok := client.InstanceVariableGet("@default_headers")
+	if !ok {
+		return
+	}
+
+	for key, value := range headers.(*HashObject).Pairs {
+		if _, ok := req.Header[http.CanonicalHeaderKey(key)]; !ok {
+			req.Header.Set(key, value.(*StringObject).value)
+		}
+	}
+}
+
+// expectsContinue returns whether `expect_continue=` is enabled on the client
+func expectsContinue(client Object) bool {
+	enabled, ok := client.InstanceVariableGet("@expect_continue")
+	return ok && enabled == TRUE
+}
+
+// setExpectContinue makes a request with a body wait for the server's `100 Continue` before sending it, if the client expects it.
+// The transport does the waiting when the header is set.
+func setExpectContinue(client Object, req *http.Request) {
+	if !expectsContinue(client) || req.Body == nil || req.Body == http.NoBody {
+		return
+	}
+
+	req.Header.Set("Expect", "100-continue")
+}
+
+// sendWithRetry sends the request built by newReq, and sends a freshly built one again while the client's retry policy asks for it.
+// Requests are rebuilt for each attempt because the body reader can only be consumed once.
+// The client's default headers and `Expect: 100-continue` are added to every request.
+func sendWithRetry(goClient *http.Client, client Object, newReq func() (*http.Request, error)) (*http.Response, error) {
+	policy := retryPolicyOf(client)
+
+	for attempt := 1; ; attempt++ {
+		req, err := newReq()
+		if err != nil {
+			return nil, err
+		}
+
+		setDefaultHeaders(client, req)
+		setExpectContinue(client, req)
+
+		resp, err := goClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if attempt >= policy.maxAttempts || !policy.statusCodes[resp.StatusCode] {
+			return resp, nil
+		}
+
+		// Drain the body so the connection can be reused by the next attempt
+		discardResponse(resp)
+
+		time.Sleep(retryAfter(resp.Header.Get("Retry-After")))
 	}
+}
 
-	return http.NewRequest(method, u, strings.NewReader(body))
+// maxRetryAfter is the longest a `Retry-After` header can make the client wait before the next attempt
+const maxRetryAfter = 30 * time.Second
 
+// retryAfter parses a `Retry-After` header value, which is either a number of seconds or an HTTP date.
+// The wait is capped at maxRetryAfter, so a server can't stall the client indefinitely.
+func retryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		// Compared in seconds, so multiplying a huge value can't overflow
+		if seconds > int(maxRetryAfter/time.Second) {
+			return maxRetryAfter
+		}
+
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > maxRetryAfter {
+			return maxRetryAfter
+		} else if wait > 0 {
+			return wait
+		}
+	}
+
+	return 0
 }
 
+// discardResponse drains and closes the response's body, so the client can reuse the connection
+// whether the body is read or not. It's safe to call after the body is read.
+func discardResponse(resp *http.Response) {
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+}
+
+// responseGoToGoby reads the response into a `Net::HTTP::Response` object, and closes its body even if reading fails
 func responseGoToGoby(t *Thread, goResp *http.Response) (Object, error) {
-	gobyResp := httpResponseClass.initializeInstance()
+	defer discardResponse(goResp)
+
+	gobyResp := t.vm.httpResponseClass.initializeInstance()
 
 	//attr_accessor :body, :status, :status_code, :protocol, :transfer_encoding, :http_version, :request_http_version, :request
 	//attr_reader :headers
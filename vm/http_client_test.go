@@ -1,6 +1,18 @@
 package vm
 
-import "testing"
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
 
 func TestHTTPClientObject(t *testing.T) {
 
@@ -89,7 +101,1612 @@ func TestHTTPClientObjectFail(t *testing.T) {
 		end
 
 		res
-		`, "HTTPError: Could not complete request, Get \"http://127.0.0.1:3001\": dial tcp 127.0.0.1:3001: connect: connection refused", 4},
+		`, "HTTPError: Could not complete request, Get \"http://127.0.0.1:3001\": dial tcp 127.0.0.1:3001: connect: connection refused", 1},
+		{`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+		  r = client.request
+		  r.url = 3001
+		  r.method = "GET"
+		  client.exec(r)
+		end
+		`, "ArgumentError: url must be a String. got: Integer", 1},
+		{`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+		  r = client.request
+		  r.url = "http://127.0.0.1:3001"
+		  r.method = nil
+		  client.exec(r)
+		end
+		`, "ArgumentError: method must be a String. got: Null", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestHTTPClientRetryOnStatusCode(t *testing.T) {
+	var hits int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s %s", r.Method, body)
+	}))
+
+	defer ts.Close()
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{fmt.Sprintf(`
+		require "net/http"
+
+		res = Net::HTTP.start do |client|
+			client.retry_on([429, 503], 3)
+			client.get("%s")
+		end
+
+		[res.status_code, res.body]
+		`, ts.URL), []interface{}{200, "GET "}},
+		// the body is sent again with the retried request
+		{fmt.Sprintf(`
+		require "net/http"
+
+		res = Net::HTTP.start do |client|
+			client.retry_on([503], 2)
+			client.post("%s", "text/plain", "Hi Again")
+		end
+
+		[res.status_code, res.body]
+		`, ts.URL), []interface{}{200, "POST Hi Again"}},
+		{fmt.Sprintf(`
+		require "net/http"
+
+		res = Net::HTTP.start do |client|
+			client.retry_on([503], 2)
+			r = client.request()
+			r.url = "%s"
+			r.method = "POST"
+			r.body = "Another way of doing it"
+			client.exec(r)
+		end
+
+		[res.status_code, res.body]
+		`, ts.URL), []interface{}{200, "POST Another way of doing it"}},
+		// the codes are copied, so changing the Array afterwards doesn't affect the policy
+		{fmt.Sprintf(`
+		require "net/http"
+
+		res = Net::HTTP.start do |client|
+			codes = [503]
+			client.retry_on(codes, 2)
+			codes.push("x")
+			codes.clear
+			client.get("%s")
+		end
+
+		[res.status_code, res.body]
+		`, ts.URL), []interface{}{200, "GET "}},
+	}
+
+	for i, tt := range tests {
+		atomic.StoreInt32(&hits, 0)
+
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+
+		if n := atomic.LoadInt32(&hits); n != 2 {
+			t.Errorf("At test case %d: expect 2 requests to be sent. got: %d", i, n)
+		}
+	}
+}
+
+func TestHTTPClientRetryExhausted(t *testing.T) {
+	var hits int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+
+	defer ts.Close()
+
+	tests := []struct {
+		input    string
+		expected interface{}
+		hits     int32
+	}{
+		{fmt.Sprintf(`
+		require "net/http"
+
+		res = Net::HTTP.start do |client|
+			client.retry_on([503], 3)
+			client.get("%s")
+		end
+
+		res.status_code
+		`, ts.URL), 503, 3},
+		// statuses not in the list aren't retried
+		{fmt.Sprintf(`
+		require "net/http"
+
+		res = Net::HTTP.start do |client|
+			client.retry_on([429], 3)
+			client.get("%s")
+		end
+
+		res.status_code
+		`, ts.URL), 503, 1},
+		// nothing is retried by default
+		{fmt.Sprintf(`
+		require "net/http"
+
+		res = Net::HTTP.start do |client|
+			client.get("%s")
+		end
+
+		res.status_code
+		`, ts.URL), 503, 1},
+	}
+
+	for i, tt := range tests {
+		atomic.StoreInt32(&hits, 0)
+
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+
+		if n := atomic.LoadInt32(&hits); n != tt.hits {
+			t.Errorf("At test case %d: expect %d requests to be sent. got: %d", i, tt.hits, n)
+		}
+	}
+}
+
+func TestHTTPClientRetryOnFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			client.retry_on([503])
+		end
+		`, "ArgumentError: Expect 2 argument(s). got: 1", 1},
+		{`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			client.retry_on(503, 3)
+		end
+		`, "TypeError: Expect argument to be Array. got: Integer", 1},
+		{`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			client.retry_on(["503"], 3)
+		end
+		`, "TypeError: Expect argument to be Integer. got: String", 1},
+		{`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			client.retry_on([503], 0)
+		end
+		`, "ArgumentError: Expect argument to be positive value. got: 0", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestHTTPClientStreamedBody(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s %s", r.Method, body)
+	}))
+
+	defer ts.Close()
+
+	// Larger than a single read of the transport, so the file is read in several chunks
+	content := strings.Repeat("Hello, Goby!\n", 10000)
+
+	file, err := ioutil.TempFile("", "goby_http_body")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Remove(file.Name())
+	file.WriteString(content)
+	file.Close()
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{fmt.Sprintf(`
+		require "net/http"
+
+		res = Net::HTTP.start do |client|
+			r = client.request()
+			r.url = "%s"
+			r.method = "POST"
+			r.body = File.new("%s")
+			client.exec(r)
+		end
+
+		res.body
+		`, ts.URL, file.Name()), "POST " + content},
+		{fmt.Sprintf(`
+		require "net/http"
+
+		class Chunks
+		  def initialize(chunks)
+		    @chunks = chunks
+		  end
+
+		  def read(length)
+		    @chunks.shift
+		  end
+		end
+
+		res = Net::HTTP.start do |client|
+			r = client.request()
+			r.url = "%s"
+			r.method = "PUT"
+			r.body = Chunks.new(["Hello", ", ", "Goby!"])
+			client.exec(r)
+		end
+
+		res.body
+		`, ts.URL), "PUT Hello, Goby!"},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+const chunksClass = `
+		class Chunks
+		  def initialize(chunks)
+		    @chunks = chunks
+		  end
+
+		  def read(length)
+		    @chunks.shift
+		  end
+		end
+`
+
+func TestHTTPClientBodyAfterRedirect(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/temporary", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/final", http.StatusTemporaryRedirect)
+	})
+	mux.HandleFunc("/permanent", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/temporary", http.StatusPermanentRedirect)
+	})
+	mux.HandleFunc("/final", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s %s", r.Method, body)
+	})
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	content := strings.Repeat("Hello, Goby!\n", 10000)
+
+	file, err := ioutil.TempFile("", "goby_http_body")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Remove(file.Name())
+	file.WriteString(content)
+	file.Close()
+
+	exec := `
+		require "net/http"
+` + chunksClass + `
+		res = Net::HTTP.start do |client|
+			r = client.request()
+			r.url = "%s"
+			r.method = "%s"
+			r.body = %s
+			%s
+			client.exec(r)
+		end
+
+		[res.status_code, res.body]
+		`
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{fmt.Sprintf(exec, ts.URL+"/temporary", "POST", `"Hello, Goby!"`, ""), []interface{}{200, "POST Hello, Goby!"}},
+		{fmt.Sprintf(exec, ts.URL+"/permanent", "PUT", `"Hello, Goby!"`, ""), []interface{}{200, "PUT Hello, Goby!"}},
+		{fmt.Sprintf(exec, ts.URL+"/temporary", "POST", `Chunks.new(["Hello", ", ", "Goby!"])`, ""), []interface{}{200, "POST Hello, Goby!"}},
+		{fmt.Sprintf(exec, ts.URL+"/permanent", "PATCH", `Chunks.new(["Hello", ", ", "Goby!"])`, ""), []interface{}{200, "PATCH Hello, Goby!"}},
+		{fmt.Sprintf(exec, ts.URL+"/temporary", "POST", fmt.Sprintf(`File.new("%s")`, file.Name()), ""), []interface{}{200, "POST " + content}},
+		// a body that isn't buffered can't be sent again, so the redirect isn't followed
+		{fmt.Sprintf(exec, ts.URL+"/temporary", "POST", `Chunks.new(["Hello", ", ", "Goby!"])`, "r.buffer_body = false"), []interface{}{307, ""}},
+		{fmt.Sprintf(exec, ts.URL+"/final", "POST", `Chunks.new(["Hello", ", ", "Goby!"])`, "r.buffer_body = false"), []interface{}{200, "POST Hello, Goby!"}},
+		{fmt.Sprintf(exec, ts.URL+"/temporary", "POST", `"Hello, Goby!"`, "r.buffer_body = false"), []interface{}{200, "POST Hello, Goby!"}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestHTTPClientRetryAfterIsCapped(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"", 0},
+		{"0", 0},
+		{"-5", 0},
+		{"soon", 0},
+		{"2", 2 * time.Second},
+		{"30", maxRetryAfter},
+		{"31", maxRetryAfter},
+		{"86400", maxRetryAfter},
+		// a value this large overflows a time.Duration if it's converted as it is
+		{"9223372036854775807", maxRetryAfter},
+		{time.Now().Add(24 * time.Hour).UTC().Format(http.TimeFormat), maxRetryAfter},
+		{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0},
+	}
+
+	for i, tt := range tests {
+		if wait := retryAfter(tt.value); wait != tt.expected {
+			t.Errorf("At test case %d: expect Retry-After %q to wait %s. got: %s", i, tt.value, tt.expected, wait)
+		}
+	}
+}
+
+func TestHTTPClientStreamedBodyRetry(t *testing.T) {
+	var hits int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+
+		if atomic.AddInt32(&hits, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		fmt.Fprintf(w, "%s %s", r.Method, body)
+	}))
+
+	defer ts.Close()
+
+	exec := `
+		require "net/http"
+` + chunksClass + `
+		res = Net::HTTP.start do |client|
+			client.retry_on([503], 2)
+			r = client.request()
+			r.url = "%s"
+			r.method = "POST"
+			r.body = Chunks.new(["Hello", ", ", "Goby!"])
+			%s
+			client.exec(r)
+		end
+
+		[res.status_code, res.body]
+		`
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{fmt.Sprintf(exec, ts.URL, ""), []interface{}{200, "POST Hello, Goby!"}},
+		// the body was consumed by the first attempt
+		{fmt.Sprintf(exec, ts.URL, "r.buffer_body = false"), []interface{}{200, "POST "}},
+	}
+
+	for i, tt := range tests {
+		atomic.StoreInt32(&hits, 0)
+
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestHTTPClientStreamedBodyFail(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+	}))
+
+	defer ts.Close()
+
+	testsFail := []errorTestCase{
+		{fmt.Sprintf(`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			r = client.request()
+			r.url = "%s"
+			r.method = "POST"
+			r.body = 1
+			client.exec(r)
+		end
+		`, ts.URL), "ArgumentError: body must be a String or respond to read. got: Integer", 1},
+		{fmt.Sprintf(`
+		require "net/http"
+
+		class BrokenBody
+		  def read(length)
+		    1
+		  end
+		end
+
+		Net::HTTP.start do |client|
+			r = client.request()
+			r.url = "%s"
+			r.method = "POST"
+			r.body = BrokenBody.new
+			client.exec(r)
+		end
+		`, ts.URL), fmt.Sprintf("HTTPError: Could not complete request, Post \"%s\": read must return a String or nil. got: Integer", ts.URL), 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestHTTPClientExecDerivedRequests(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s %s %s", r.Method, r.URL.RequestURI(), body)
+	}))
+
+	defer ts.Close()
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{fmt.Sprintf(`
+		require "net/http"
+
+		res = Net::HTTP.start do |client|
+			r = client.request
+			r.method = "GET"
+			r.url = "%s/users?page=1"
+			r.path = Net::HTTP.expand("/users/{id}/posts", { id: 42 })
+			r.query = { page: 2 }
+			client.exec(r)
+		end
+
+		res.body
+		`, ts.URL), "GET /users/42/posts?page=2 "},
+		{fmt.Sprintf(`
+		require "net/http"
+
+		bodies = Net::HTTP.start do |client|
+			get = client.request
+			get.method = "GET"
+			get.url = "%s/users/1"
+			put = get.with({ method: "PUT", body: "name=goby" })
+			[client.exec(put).body, client.exec(get).body]
+		end
+
+		bodies
+		`, ts.URL), []interface{}{"PUT /users/1 name=goby", "GET /users/1 "}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestHTTPClientInterceptors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s %s %s %s", r.Method, r.URL.Path, r.Header.Get("Authorization"), body)
+	}))
+
+	defer ts.Close()
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		// a request interceptor adds a header to every request
+		{fmt.Sprintf(`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			client.before_request do |req|
+				req.set_header("Authorization", "Bearer " + req.method)
+			end
+
+			r = client.request
+			r.method = "PUT"
+			r.url = "%[1]s/exec"
+			r.body = "x"
+
+			[client.get("%[1]s/get").body, client.post("%[1]s/post", "text/plain", "hi").body, client.exec(r).body, r.get_header("Authorization")]
+		end
+		`, ts.URL), []interface{}{"GET /get Bearer GET ", "POST /post Bearer POST hi", "PUT /exec Bearer PUT x", "Bearer PUT"}},
+		// a response interceptor records the status codes
+		{fmt.Sprintf(`
+		require "net/http"
+
+		codes = []
+		Net::HTTP.start do |client|
+			client.after_response do |res|
+				codes.push(res.status_code)
+			end
+
+			client.get("%[1]s/a")
+			client.head("%[1]s/missing")
+			client.post("%[1]s/b", "text/plain", "")
+		end
+
+		codes
+		`, ts.URL), []interface{}{200, 404, 200}},
+		// interceptors run in order, and the requests or responses they return replace the ones they're given
+		{fmt.Sprintf(`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			client.before_request do |req|
+				req.with({ path: "/replaced" })
+			end
+			client.before_request do |req|
+				req.set_header("Authorization", req.path)
+			end
+			client.after_response do |res|
+				res.body = res.body + "!"
+			end
+			client.after_response do |res|
+				r = Net::HTTP::Response.new
+				r.body = res.body.upcase
+				r
+			end
+
+			client.get("%[1]s/original").body
+		end
+		`, ts.URL), "GET /REPLACED /REPLACED !"},
+		// the exec'd request is left as it is when the interceptor returns a copy
+		{fmt.Sprintf(`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			client.before_request do |req|
+				req.with({ method: "POST", body: "x" })
+			end
+
+			r = client.request
+			r.method = "GET"
+			r.url = "%[1]s/exec"
+			[client.exec(r).body, r.method]
+		end
+		`, ts.URL), []interface{}{"POST /exec  x", "GET"}},
+		{`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			client.before_request do |req|
+			end.after_response do |res|
+			end.class.name
+		end
+		`, "Client"},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestHTTPClientInterceptorsFail(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	}))
+
+	defer ts.Close()
+
+	testsFail := []errorTestCase{
+		{`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			client.before_request
+		end
+		`, "InternalError: Can't yield without a block", 1},
+		{`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			client.after_response(1) do |res|
+			end
+		end
+		`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+		{fmt.Sprintf(`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			client.before_request do |req|
+				raise ArgumentError, "no token"
+			end
+			client.get("%s")
+		end
+		`, ts.URL), "ArgumentError: \"no token\"", 1},
+		{fmt.Sprintf(`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			client.after_response do |res|
+				raise ArgumentError, "bad response"
+			end
+			client.get("%s")
+		end
+		`, ts.URL), "ArgumentError: \"bad response\"", 1},
+		{fmt.Sprintf(`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			client.before_request do |req|
+				req.url = 1
+			end
+			client.get("%s")
+		end
+		`, ts.URL), "HTTPError: Could not complete request, url must be a String. got: Integer", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestHTTPClientDefaultHeaders(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s|%s|%s", r.Header.Get("Authorization"), r.Header.Get("Accept"), r.Header.Get("Content-Type"))
+	}))
+
+	defer ts.Close()
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		// the default headers are sent with every request
+		{fmt.Sprintf(`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			client.default_headers = { Authorization: "Bearer abc", Accept: "application/json" }
+			r = client.request()
+			r.url = "%[1]s"
+			r.method = "GET"
+			[client.get("%[1]s").body, client.head("%[1]s").status_code, client.post("%[1]s", "text/plain", "").body, client.exec(r).body]
+		end
+		`, ts.URL), []interface{}{"Bearer abc|application/json|", 200, "Bearer abc|application/json|text/plain", "Bearer abc|application/json|"}},
+		// the request's own headers take precedence
+		{fmt.Sprintf(`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			headers = { Accept: "application/json" }
+			headers["Content-Type"] = "application/json"
+			client.default_headers = headers
+			r = client.request()
+			r.url = "%[1]s"
+			r.method = "GET"
+			r.set_header("accept", "text/plain")
+			[client.exec(r).body, client.post("%[1]s", "text/csv", "").body]
+		end
+		`, ts.URL), []interface{}{"|text/plain|application/json", "|application/json|text/csv"}},
+		// the hash is copied
+		{`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			h = { Accept: "application/json" }
+			client.default_headers = h
+			h[:Accept] = "text/plain"
+			client.default_headers[:Accept]
+		end
+		`, "application/json"},
+		{`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			client.default_headers
+		end
+		`, canonical(`{}`)},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestHTTPClientDefaultHeadersFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			client.default_headers = "Accept: text/plain"
+		end
+		`, "TypeError: Expect argument to be Hash. got: String", 1},
+		{`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			headers = { Accept: "text/plain" }
+			headers["X-Retries"] = 3
+			client.default_headers = headers
+		end
+		`, "TypeError: Expect the value of header X-Retries to be String. got: Integer", 1},
+		{`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			r = client.request()
+			r.url = "http://127.0.0.1:3000/index"
+			r.method = "GET"
+			r.set_header("X-Retries", 3)
+			client.exec(r)
+		end
+		`, "ArgumentError: Expect the value of header X-Retries to be String. got: Integer", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestHTTPClientAcceptAndContentType(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s|%s", r.Header.Get("Accept"), r.Header.Get("Content-Type"))
+	}))
+
+	defer ts.Close()
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		// the Accept header is sent with every request, and Content-Type with the ones that have a body
+		{fmt.Sprintf(`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			client.accept = "application/json"
+			client.content_type = "application/json"
+			r = client.request()
+			r.url = "%[1]s"
+			r.method = "PUT"
+			r.body = "{}"
+			[client.get("%[1]s").body, client.head("%[1]s").status_code, client.exec(r).body]
+		end
+		`, ts.URL), []interface{}{"application/json|", 200, "application/json|application/json"}},
+		// and can be overridden by the request
+		{fmt.Sprintf(`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			client.accept = "application/json"
+			client.content_type = "application/json"
+			r = client.request()
+			r.url = "%[1]s"
+			r.method = "POST"
+			r.body = "a,b"
+			r.set_header("Accept", "text/csv")
+			r.set_header("Content-Type", "text/csv")
+			[client.exec(r).body, client.post("%[1]s", "text/plain", "abc").body]
+		end
+		`, ts.URL), []interface{}{"text/csv|text/csv", "application/json|text/plain"}},
+		// they take precedence over the default headers
+		{fmt.Sprintf(`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			headers = { Accept: "text/plain" }
+			headers["Content-Type"] = "text/plain"
+			client.default_headers = headers
+			client.accept = "application/json"
+			client.content_type = "application/json"
+			r = client.request()
+			r.url = "%[1]s"
+			r.method = "POST"
+			r.body = "{}"
+			client.exec(r).body
+		end
+		`, ts.URL), "application/json|application/json"},
+		// nil stops sending them
+		{fmt.Sprintf(`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			client.accept = "application/json"
+			client.accept = nil
+			client.content_type = nil
+			[client.get("%[1]s").body, client.accept, client.content_type]
+		end
+		`, ts.URL), []interface{}{"|", nil, nil}},
+		{fmt.Sprintf(`
+		require "net/http"
+
+		client = Net::HTTP::Client.new({ accept: "application/json", content_type: "application/xml" })
+		r = client.request()
+		r.url = "%[1]s"
+		r.method = "POST"
+		r.body = "<a/>"
+		[client.accept, client.content_type, client.get("%[1]s").body, client.exec(r).body]
+		`, ts.URL), []interface{}{"application/json", "application/xml", "application/json|", "application/json|application/xml"}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestHTTPClientAcceptAndContentTypeFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			client.accept = ["application/json"]
+		end
+		`, "TypeError: Expect argument to be String. got: Array", 1},
+		{`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			client.content_type = 1
+		end
+		`, "TypeError: Expect argument to be String. got: Integer", 1},
+		{`
+		require "net/http"
+
+		Net::HTTP::Client.new({ accept: 1.5 })
+		`, "TypeError: Expect argument to be String. got: Float", 1},
+		{`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			client.accept(1)
+		end
+		`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestHTTPClientExpectContinue(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The server answers `100 Continue` when the handler reads the body, so rejecting without reading it
+		// makes the client skip sending it
+		if r.URL.Path == "/reject" {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s|%s", r.Header.Get("Expect"), body)
+	}))
+
+	defer ts.Close()
+
+	// Chunks records its reads, so the tests can tell whether the body was sent
+	chunks := `
+		class Chunks
+		  attr_reader :reads
+
+		  def initialize(chunks)
+		    @chunks = chunks
+		    @reads = 0
+		  end
+
+		  def read(length)
+		    @reads += 1
+		    @chunks.shift
+		  end
+		end
+	`
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{fmt.Sprintf(`
+		require "net/http"
+		%s
+		body = Chunks.new(["Hello, ", "Goby!"])
+
+		Net::HTTP.start do |client|
+			client.expect_continue = true
+			r = client.request()
+			r.url = "%s/accept"
+			r.method = "POST"
+			r.body = body
+			[client.exec(r).body, body.reads > 0]
+		end
+		`, chunks, ts.URL), []interface{}{"100-continue|Hello, Goby!", true}},
+		{fmt.Sprintf(`
+		require "net/http"
+		%s
+		body = Chunks.new(["Hello, ", "Goby!"])
+
+		Net::HTTP.start do |client|
+			client.expect_continue = true
+			r = client.request()
+			r.url = "%s/reject"
+			r.method = "POST"
+			r.body = body
+			[client.exec(r).status_code, body.reads]
+		end
+		`, chunks, ts.URL), []interface{}{413, 0}},
+		{fmt.Sprintf(`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			client.expect_continue = true
+			client.post("%s/accept", "text/plain", "Hi").body
+		end
+		`, ts.URL), "100-continue|Hi"},
+		// a request without a body doesn't need the server's go-ahead
+		{fmt.Sprintf(`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			client.expect_continue = true
+			client.get("%s/accept").body
+		end
+		`, ts.URL), "|"},
+		// it's disabled by default
+		{fmt.Sprintf(`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			[client.expect_continue, client.post("%s/accept", "text/plain", "Hi").body]
+		end
+		`, ts.URL), []interface{}{false, "|Hi"}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestHTTPClientExpectContinueFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			client.expect_continue = "yes"
+		end
+		`, "TypeError: Expect argument to be Boolean. got: String", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestHTTPClientReusesConnectionsAfterErrors(t *testing.T) {
+	var conns int32
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/error":
+			http.Error(w, strings.Repeat("error ", 1000), http.StatusInternalServerError)
+		case "/truncated":
+			// The body is shorter than the promised length, so reading it fails
+			w.Header().Set("Content-Length", "100")
+			fmt.Fprint(w, "short")
+		default:
+			fmt.Fprint(w, "ok")
+		}
+	}))
+	ts.Config.ConnState = func(c net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	ts.Start()
+
+	defer ts.Close()
+
+	testsFail := []errorTestCase{
+		{fmt.Sprintf(`
+		require "net/http"
+
+		Net::HTTP.get("%s/error")
+		`, ts.URL), "HTTPError: Non-200 response, 500 Internal Server Error (500)", 1},
+		{fmt.Sprintf(`
+		require "net/http"
+
+		Net::HTTP.post("%s/error", "text/plain", "Hi")
+		`, ts.URL), "HTTPError: Non-200 response, 500 Internal Server Error (500)", 1},
+		{fmt.Sprintf(`
+		require "net/http"
+
+		Net::HTTP.head("%s/error")
+		`, ts.URL), "HTTPError: Non-200 response, 500 Internal Server Error (500)", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+
+	input := fmt.Sprintf(`
+	require "net/http"
+
+	Net::HTTP.start do |client|
+		[client.get("%s/error").status_code, client.get("%s").body, client.get("%s/error").status_code]
+	end
+	`, ts.URL, ts.URL, ts.URL)
+
+	v := initTestVM()
+	evaluated := v.testEval(t, input, getFilename())
+	VerifyExpected(t, 0, evaluated, []interface{}{500, "ok", 500})
+	v.checkCFP(t, 0, 0)
+	v.checkSP(t, 0, 1)
+
+	if n := atomic.LoadInt32(&conns); n != 1 {
+		t.Fatalf("Expect all the requests to share 1 connection. got: %d", n)
+	}
+
+	// A body that fails in the middle of reading is closed too, so the next request gets a new connection
+	input = fmt.Sprintf(`
+	require "net/http"
+
+	Net::HTTP.start do |client|
+		client.get("%s/truncated")
+	end
+	`, ts.URL)
+
+	v = initTestVM()
+	evaluated = v.testEval(t, input, getFilename())
+	checkErrorMsg(t, 0, evaluated, "InternalError: unexpected EOF")
+
+	v = initTestVM()
+	evaluated = v.testEval(t, fmt.Sprintf(`
+	require "net/http"
+
+	Net::HTTP.get("%s")
+	`, ts.URL), getFilename())
+	VerifyExpected(t, 0, evaluated, "ok")
+
+	if n := atomic.LoadInt32(&conns); n != 2 {
+		t.Fatalf("Expect 2 connections after the failed read. got: %d", n)
+	}
+}
+
+func TestHTTPClientNewWithOptions(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(500 * time.Millisecond)
+		}
+
+		fmt.Fprintf(w, "%s|%s", r.Header.Get("Accept-Encoding"), r.Header.Get("Authorization"))
+	}))
+
+	defer ts.Close()
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "proxied %s", r.URL)
+	}))
+
+	defer proxy.Close()
+
+	tlsServer := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "secure")
+	}))
+	// The client rejecting the certificate is expected
+	tlsServer.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+	tlsServer.StartTLS()
+
+	defer tlsServer.Close()
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{fmt.Sprintf(`
+		require "net/http"
+
+		client = Net::HTTP::Client.new({ timeout: 5, default_headers: { Authorization: "Bearer abc" } })
+		[client.get("%s").body, client.default_headers[:Authorization], client.class.name]
+		`, ts.URL), []interface{}{"gzip|Bearer abc", "Bearer abc", "Client"}},
+		{fmt.Sprintf(`
+		require "net/http"
+
+		Net::HTTP::Client.new({ compression: false }).get("%s").body
+		`, ts.URL), "|"},
+		{fmt.Sprintf(`
+		require "net/http"
+
+		Net::HTTP::Client.new({ proxy: "%s" }).get("http://goby.invalid/index").body
+		`, proxy.URL), "proxied http://goby.invalid/index"},
+		{fmt.Sprintf(`
+		require "net/http"
+
+		Net::HTTP::Client.new({ verify_tls: false, max_idle: 2, idle_timeout: 30 }).get("%s").body
+		`, tlsServer.URL), "secure"},
+		{`
+		require "net/http"
+
+		client = Net::HTTP::Client.new({ expect_continue: true })
+		client.expect_continue
+		`, true},
+		{`
+		require "net/http"
+
+		Net::HTTP::Client.new.expect_continue
+		`, false},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+
+	// The errors depend on Go's messages, so only their beginnings are checked
+	testsFail := []errorTestCase{
+		{fmt.Sprintf(`
+		require "net/http"
+
+		Net::HTTP::Client.new({ timeout: 0.1 }).get("%s/slow")
+		`, ts.URL), "HTTPError: Could not complete request, Get \"" + ts.URL + "/slow\": context deadline exceeded (Client.Timeout exceeded", 1},
+		{fmt.Sprintf(`
+		require "net/http"
+
+		Net::HTTP::Client.new.get("%s")
+		`, tlsServer.URL), "HTTPError: Could not complete request, Get \"" + tlsServer.URL + "\": tls: failed to verify certificate", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		err, ok := evaluated.(*Error)
+
+		if !ok || !strings.HasPrefix(err.Message(), tt.expected) {
+			t.Fatalf("At test case %d: expect error message to start with %q. got: %s", i, tt.expected, evaluated.Inspect())
+		}
+
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+// The options are set on the Go client and its transport
+func TestHTTPClientNewOptionsTakeEffect(t *testing.T) {
+	v := initTestVM()
+	evaluated := v.testEval(t, `
+	require "net/http"
+
+	Net::HTTP::Client.new({ timeout: 2.5, proxy: "http://proxy.local:8080", verify_tls: false, max_idle: 10, idle_timeout: 60, compression: false })
+	`, getFilename())
+
+	client, ok := evaluated.(*HTTPClientObject)
+
+	if !ok {
+		t.Fatalf("Expect a client. got: %s", evaluated.Inspect())
+	}
+
+	if client.client.Timeout != 2500*time.Millisecond {
+		t.Errorf("Expect the timeout to be 2.5s. got: %s", client.client.Timeout)
+	}
+
+	tr, ok := client.client.Transport.(*http.Transport)
+
+	if !ok {
+		t.Fatalf("Expect the client to have its own transport. got: %T", client.client.Transport)
+	}
+
+	req, _ := http.NewRequest("GET", "http://example.com", nil)
+
+	if proxyURL, err := tr.Proxy(req); err != nil || proxyURL.String() != "http://proxy.local:8080" {
+		t.Errorf("Expect the proxy to be http://proxy.local:8080. got: %v, %v", proxyURL, err)
+	}
+
+	if tr.TLSClientConfig == nil || !tr.TLSClientConfig.InsecureSkipVerify {
+		t.Error("Expect the TLS certificates not to be verified")
+	}
+
+	if tr.MaxIdleConns != 10 || tr.MaxIdleConnsPerHost != 10 || tr.DisableKeepAlives {
+		t.Errorf("Expect 10 idle connections to be kept. got: %d, %d", tr.MaxIdleConns, tr.MaxIdleConnsPerHost)
+	}
+
+	if tr.IdleConnTimeout != time.Minute {
+		t.Errorf("Expect the idle timeout to be 1m. got: %s", tr.IdleConnTimeout)
+	}
+
+	if !tr.DisableCompression {
+		t.Error("Expect compression to be disabled")
+	}
+
+	// A client without transport options shares the default transport, and a client of Net::HTTP.start the default client
+	evaluated = v.testEval(t, `Net::HTTP::Client.new({ timeout: 1, max_idle: 0 })`, getFilename())
+
+	if tr := evaluated.(*HTTPClientObject).client.Transport.(*http.Transport); !tr.DisableKeepAlives {
+		t.Error("Expect connections not to be reused with max_idle 0")
+	}
+
+	evaluated = v.testEval(t, `Net::HTTP::Client.new({ timeout: 1 })`, getFilename())
+
+	if tr := evaluated.(*HTTPClientObject).client.Transport; tr != nil {
+		t.Errorf("Expect the default transport. got: %T", tr)
+	}
+
+	evaluated = v.testEval(t, `Net::HTTP.start do |client| client end`, getFilename())
+
+	if goClientOf(evaluated) != http.DefaultClient {
+		t.Error("Expect the client of Net::HTTP.start to use the default client")
+	}
+}
+
+func TestHTTPClientNewUnknownOptions(t *testing.T) {
+	var stderr strings.Builder
+
+	v := initTestVM()
+	v.SetStderr(&stderr)
+	evaluated := v.testEval(t, `
+	require "net/http"
+
+	Net::HTTP::Client.new({ timeout: 1, retries: 3, strict: false }).class.name
+	`, getFilename())
+	VerifyExpected(t, 0, evaluated, "Client")
+	VerifyExpected(t, 1, v.InitStringObject(stderr.String()), "warning: unknown option retries for Client is ignored\n")
+}
+
+func TestHTTPClientNewWithOptionsFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`
+		require "net/http"
+		Net::HTTP::Client.new({ timeout: "5" })`, "TypeError: Expect option timeout to be Numeric. got: String", 1},
+		{`
+		require "net/http"
+		Net::HTTP::Client.new({ timeout: -1 })`, "ArgumentError: Expect a positive number of seconds. got: -1", 1},
+		{`
+		require "net/http"
+		Net::HTTP::Client.new({ proxy: 8080 })`, "TypeError: Expect option proxy to be String. got: Integer", 1},
+		{`
+		require "net/http"
+		Net::HTTP::Client.new({ proxy: "proxy.local" })`, "ArgumentError: Invalid proxy URL: proxy.local", 1},
+		{`
+		require "net/http"
+		Net::HTTP::Client.new({ verify_tls: "no" })`, "TypeError: Expect option verify_tls to be Boolean. got: String", 1},
+		{`
+		require "net/http"
+		Net::HTTP::Client.new({ max_idle: 1.5 })`, "TypeError: Expect option max_idle to be Integer. got: Float", 1},
+		{`
+		require "net/http"
+		Net::HTTP::Client.new({ max_idle: -1 })`, "ArgumentError: Expect argument to be positive value. got: -1", 1},
+		{`
+		require "net/http"
+		Net::HTTP::Client.new({ compression: nil })`, "TypeError: Expect option compression to be Boolean. got: Null", 1},
+		{`
+		require "net/http"
+		Net::HTTP::Client.new({ default_headers: { Accept: 1 } })`, "TypeError: Expect the value of header Accept to be String. got: Integer", 1},
+		{`
+		require "net/http"
+		Net::HTTP::Client.new({ expect_continue: 1 })`, "TypeError: Expect argument to be Boolean. got: Integer", 1},
+		{`
+		require "net/http"
+		Net::HTTP::Client.new({ timeout: 1, retries: 3, strict: true })`, "ArgumentError: Unknown option: retries", 1},
+		{`
+		require "net/http"
+		Net::HTTP::Client.new({ strict: 1 })`, "TypeError: Expect option strict to be Boolean. got: Integer", 1},
+		{`
+		require "net/http"
+		Net::HTTP::Client.new("timeout: 1")`, "TypeError: Expect argument to be Hash. got: String", 1},
+		{`
+		require "net/http"
+		Net::HTTP::Client.new({}, {})`, "ArgumentError: Expect 1 or less argument(s). got: 2", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestHTTPClientGetSSE(t *testing.T) {
+	var reconnects int32
+	disconnected := make(chan bool, 1)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != "text/event-stream" {
+			http.Error(w, "not a stream request", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+
+		switch r.URL.Path {
+		case "/events":
+			for _, frame := range []string{
+				": keep alive\n\n",
+				"data: first\n\n",
+				"event: update\nid: 1\ndata: line one\n",
+				"data: line two\n\n",
+				"data\n\n",
+				"id: 2\r\ndata:no space\r\n\r\n",
+				"event: ignored\n\n",
+				"data: unterminated",
+			} {
+				fmt.Fprint(w, frame)
+				flusher.Flush()
+				time.Sleep(5 * time.Millisecond)
+			}
+		case "/auth":
+			fmt.Fprintf(w, "data: %s\n\n", r.Header.Get("Authorization"))
+		case "/endless":
+			fmt.Fprint(w, "data: 1\n\n")
+			flusher.Flush()
+
+			// The client should drop the connection as soon as the block breaks
+			select {
+			case <-r.Context().Done():
+				disconnected <- true
+			case <-time.After(5 * time.Second):
+				disconnected <- false
+			}
+		case "/reconnect":
+			n := atomic.AddInt32(&reconnects, 1)
+			fmt.Fprintf(w, "retry: 10\nid: %d\ndata: last=%s\n\n", n, r.Header.Get("Last-Event-ID"))
+		case "/truncated":
+			w.Header().Set("Content-Length", "100")
+			fmt.Fprint(w, "retry: 10\ndata: a\n\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	defer ts.Close()
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{fmt.Sprintf(`
+		require "net/http"
+
+		events = []
+		Net::HTTP.start do |client|
+			client.get_sse("%s/events") do |e|
+				events.push([e["event"], e["data"], e["id"]])
+			end
+		end
+		events
+		`, ts.URL), []interface{}{
+			[]interface{}{"message", "first", nil},
+			[]interface{}{"update", "line one\nline two", "1"},
+			[]interface{}{"message", "", "1"},
+			[]interface{}{"message", "no space", "2"},
+		}},
+		{fmt.Sprintf(`
+		require "net/http"
+
+		data = nil
+		Net::HTTP.start do |client|
+			client.get_sse("%s/auth", { Authorization: "Bearer token" }) do |e|
+				data = e["data"]
+			end
+		end
+		data
+		`, ts.URL), "Bearer token"},
+		{fmt.Sprintf(`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			client.get_sse("%s/endless") do |e|
+				break e["data"]
+			end
+		end
+		`, ts.URL), "1"},
+		{fmt.Sprintf(`
+		require "net/http"
+
+		events = []
+		Net::HTTP.start do |client|
+			client.get_sse("%s/reconnect", true) do |e|
+				events.push(e["data"])
+				if events.length == 3
+					break
+				end
+			end
+		end
+		events
+		`, ts.URL), []interface{}{"last=", "last=1", "last=2"}},
+		// a broken stream is reconnected too
+		{fmt.Sprintf(`
+		require "net/http"
+
+		events = []
+		Net::HTTP.start do |client|
+			client.get_sse("%s/truncated", {}, true) do |e|
+				events.push(e["data"])
+				if events.length == 2
+					break
+				end
+			end
+		end
+		events
+		`, ts.URL), []interface{}{"a", "a"}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+
+	if !<-disconnected {
+		t.Fatal("Expect the connection to be closed when the block breaks")
+	}
+
+	testsFail := []errorTestCase{
+		{fmt.Sprintf(`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			client.get_sse("%s/truncated") do |e|
+				e
+			end
+		end
+		`, ts.URL), "HTTPError: Could not complete request, unexpected EOF", 1},
+		{`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			client.get_sse("http://127.0.0.1:3001") do |e|
+				e
+			end
+		end
+		`, "HTTPError: Could not complete request, Get \"http://127.0.0.1:3001\": dial tcp 127.0.0.1:3001: connect: connection refused", 1},
+		{fmt.Sprintf(`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			client.get_sse("%s/missing") do |e|
+				e
+			end
+		end
+		`, ts.URL), "HTTPError: Non-200 response, 404 Not Found (404)", 1},
+		{fmt.Sprintf(`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			client.get_sse("%s/auth")
+		end
+		`, ts.URL), "InternalError: Can't yield without a block", 1},
+		{`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			client.get_sse(1) do |e|
+				e
+			end
+		end
+		`, "TypeError: Expect argument to be String. got: Integer", 1},
+		{`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			client.get_sse("http://127.0.0.1:3001", 1) do |e|
+				e
+			end
+		end
+		`, "TypeError: Expect argument to be Hash. got: Integer", 1},
+		{`
+		require "net/http"
+
+		Net::HTTP.start do |client|
+			client.get_sse("http://127.0.0.1:3001", { Authorization: 1 }) do |e|
+				e
+			end
+		end
+		`, "TypeError: Expect the value of header Authorization to be String. got: Integer", 1},
 	}
 
 	for i, tt := range testsFail {
@@ -97,6 +1714,6 @@ func TestHTTPClientObjectFail(t *testing.T) {
 		evaluated := v.testEval(t, tt.input, getFilename())
 		checkErrorMsg(t, i, evaluated, tt.expected)
 		v.checkCFP(t, i, tt.expectedCFP)
-		v.checkSP(t, i, 2)
+		v.checkSP(t, i, 1)
 	}
 }
@@ -38,3 +38,219 @@ func TestHTTPRequestObject(t *testing.T) {
 		v.checkSP(t, i, 1)
 	}
 }
+
+func TestHTTPRequestDup(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require "net/http"
+
+		req = Net::HTTP::Request.new({ Accept: "text/plain" })
+		req.method = "POST"
+		req.url = "http://example.com/users"
+		req.body = "name=goby"
+		copy = req.dup
+
+		[copy.method, copy.url, copy.body, copy.get_header("Accept"), copy.class.name]
+		`, []interface{}{"POST", "http://example.com/users", "name=goby", "text/plain", "Request"}},
+		// changing the copy leaves the original untouched
+		{`
+		require "net/http"
+
+		req = Net::HTTP::Request.new({ Accept: "text/plain" })
+		req.params = { page: "1" }
+		req.body = "name=goby"
+		copy = req.dup
+		copy.set_header("Accept", "application/json")
+		copy.set_header("X-Id", "1")
+		copy.params[:page] = "2"
+		copy.body.concat("&lang=en")
+		copy.method = "PUT"
+
+		[req.get_header("Accept"), req.headers.length, req.params[:page], req.body, req.method]
+		`, []interface{}{"text/plain", 1, "1", "name=goby", nil}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestHTTPRequestPathAndQuery(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require "net/http"
+
+		req = Net::HTTP::Request.new
+		req.url = "http://example.com/users?page=2"
+		r = req.path = "/posts"
+
+		[r, req.path, req.url]
+		`, []interface{}{"/posts", "/posts", "http://example.com/posts?page=2"}},
+		{`
+		require "net/http"
+
+		req = Net::HTTP::Request.new
+		req.url = "http://example.com/search?q=old#top"
+		req.query = { q: "goby lang", page: 2 }
+
+		req.url
+		`, "http://example.com/search?page=2&q=goby+lang#top"},
+		{`
+		require "net/http"
+
+		req = Net::HTTP::Request.new
+		req.url = "http://example.com/search"
+		req.query = "?q=goby"
+
+		req.url
+		`, "http://example.com/search?q=goby"},
+		{`
+		require "net/http"
+
+		req = Net::HTTP::Request.new
+		req.url = "http://example.com/search?q=goby"
+		req.query = nil
+
+		req.url
+		`, "http://example.com/search"},
+		// without a url, only the path and the query are set
+		{`
+		require "net/http"
+
+		req = Net::HTTP::Request.new
+		req.path = "/users"
+		req.query = { id: 1 }
+
+		req.url
+		`, "/users?id=1"},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestHTTPRequestWith(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require "net/http"
+
+		get = Net::HTTP::Request.new({ Accept: "text/plain" })
+		get.method = "GET"
+		get.url = "http://example.com/users/1"
+		put = get.with({ method: "PUT", body: "name=goby" })
+
+		[put.method, put.body, put.url, put.get_header("Accept")]
+		`, []interface{}{"PUT", "name=goby", "http://example.com/users/1", "text/plain"}},
+		// the receiver isn't changed
+		{`
+		require "net/http"
+
+		get = Net::HTTP::Request.new({ Accept: "text/plain" })
+		get.method = "GET"
+		get.url = "http://example.com/users/1?page=2"
+		get.with({ method: "PUT", body: "name=goby", path: "/posts", query: nil, headers: { Accept: "application/json" } })
+
+		[get.method, get.body, get.url, get.path, get.get_header("Accept")]
+		`, []interface{}{"GET", nil, "http://example.com/users/1?page=2", nil, "text/plain"}},
+		{`
+		require "net/http"
+
+		get = Net::HTTP::Request.new({ Accept: "text/plain" })
+		get.url = "http://example.com/users/1?page=2"
+		r = get.with({ path: "/posts", query: nil, headers: { Accept: "application/json" } })
+
+		[r.url, r.path, r.get_header("Accept")]
+		`, []interface{}{"http://example.com/posts", "/posts", "application/json"}},
+		// headers given to with are copied
+		{`
+		require "net/http"
+
+		h = { Accept: "text/plain" }
+		r = Net::HTTP::Request.new.with({ headers: h })
+		r.set_header("X-Id", "1")
+
+		h.length
+		`, 1},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestHTTPRequestMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`
+		require "net/http"
+
+		Net::HTTP::Request.new.dup(1)
+		`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+		{`
+		require "net/http"
+
+		Net::HTTP::Request.new.path = 1
+		`, "TypeError: Expect argument to be String. got: Integer", 1},
+		{`
+		require "net/http"
+
+		Net::HTTP::Request.new.query = 1
+		`, "TypeError: Expect argument to be Hash. got: Integer", 1},
+		{`
+		require "net/http"
+
+		req = Net::HTTP::Request.new
+		req.url = "http://[::1"
+		req.path = "/users"
+		`, "ArgumentError: Invalid url http://[::1: parse \"http://[::1\": missing ']' in host", 1},
+		{`
+		require "net/http"
+
+		Net::HTTP::Request.new.with(1)
+		`, "TypeError: Expect argument to be Hash. got: Integer", 1},
+		{`
+		require "net/http"
+
+		Net::HTTP::Request.new.with({ verb: "PUT" })
+		`, "ArgumentError: Unknown attribute verb for Request#with", 1},
+		{`
+		require "net/http"
+
+		Net::HTTP::Request.new.with({ headers: 1 })
+		`, "TypeError: Expect option headers to be Hash. got: Integer", 1},
+		{`
+		require "net/http"
+
+		Net::HTTP::Request.new.with({ path: 1 })
+		`, "TypeError: Expect argument to be String. got: Integer", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
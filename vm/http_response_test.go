@@ -26,6 +26,69 @@ func TestHTTPResponseObject(t *testing.T) {
 	v.checkSP(t, 0, 1)
 }
 
+func TestHTTPResponseStatusPredicates(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var code int
+		fmt.Sscanf(r.URL.Path, "/%d", &code)
+		w.WriteHeader(code)
+	}))
+
+	defer ts.Close()
+
+	tests := []struct {
+		code     int
+		expected []interface{}
+	}{
+		{200, []interface{}{true, false, false, false}},
+		{204, []interface{}{true, false, false, false}},
+		{299, []interface{}{true, false, false, false}},
+		{301, []interface{}{false, true, false, false}},
+		{304, []interface{}{false, true, false, false}},
+		{400, []interface{}{false, false, true, false}},
+		{404, []interface{}{false, false, true, false}},
+		{499, []interface{}{false, false, true, false}},
+		{500, []interface{}{false, false, false, true}},
+		{503, []interface{}{false, false, false, true}},
+		{101, []interface{}{false, false, false, false}},
+	}
+
+	for i, tt := range tests {
+		input := fmt.Sprintf(`
+		require "net/http"
+
+		res = Net::HTTP.start do |client|
+		  r = client.request
+		  r.url = "%s/%d"
+		  r.method = "GET"
+		  client.exec(r)
+		end
+
+		[res.ok?, res.redirect?, res.client_error?, res.server_error?]
+		`, ts.URL, tt.code)
+
+		v := initTestVM()
+		evaluated := v.testEval(t, input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestHTTPResponseStatusPredicatesWithoutStatusCode(t *testing.T) {
+	input := `
+	require "net/http"
+
+	res = Net::HTTP::Response.new
+	[res.ok?, res.redirect?, res.client_error?, res.server_error?]
+	`
+
+	v := initTestVM()
+	evaluated := v.testEval(t, input, getFilename())
+	VerifyExpected(t, 0, evaluated, []interface{}{false, false, false, false})
+	v.checkCFP(t, 0, 0)
+	v.checkSP(t, 0, 1)
+}
+
 func TestNormalGetResponse(t *testing.T) {
 	expected := "Hello, client"
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,102 @@
+package vm
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultSSERetry is how long `get_sse` waits before reconnecting, until the server sets it with a `retry` field
+const defaultSSERetry = 3 * time.Second
+
+// sseEvent is an event parsed from a `text/event-stream` body
+type sseEvent struct {
+	name string
+	data string
+	id   string
+}
+
+// sseReader parses the events of a `text/event-stream` body as they arrive.
+// The last event id and the reconnection delay outlive a connection, so they're kept on the reader and carried over when reconnecting.
+type sseReader struct {
+	r      *bufio.Reader
+	lastID string
+	retry  time.Duration
+}
+
+func newSSEReader(body io.Reader, lastID string, retry time.Duration) *sseReader {
+	return &sseReader{r: bufio.NewReader(body), lastID: lastID, retry: retry}
+}
+
+// next reads lines until an event is complete, and returns it.
+// A blank line completes an event; an event without data is dropped as the spec says.
+// It returns io.EOF when the stream ends, discarding the event being read, or the error that broke the stream.
+func (s *sseReader) next() (*sseEvent, error) {
+	var name string
+	var data []string
+
+	for {
+		line, err := s.r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+
+		if line == "" {
+			if data == nil {
+				name = ""
+				continue
+			}
+
+			if name == "" {
+				name = "message"
+			}
+
+			return &sseEvent{name: name, data: strings.Join(data, "\n"), id: s.lastID}, nil
+		}
+
+		// Lines starting with a colon are comments, usually sent to keep the connection alive
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value := line, ""
+
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+
+		switch field {
+		case "event":
+			name = value
+		case "data":
+			data = append(data, value)
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				s.lastID = value
+			}
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				s.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+}
+
+// toGobyHash returns the event as the Hash `get_sse` yields, with `id` being nil if the server hasn't sent one yet
+func (e *sseEvent) toGobyHash(t *Thread) *HashObject {
+	var id Object = NULL
+
+	if e.id != "" {
+		id = t.vm.InitStringObject(e.id)
+	}
+
+	return t.vm.InitHashObject(map[string]Object{
+		"event": t.vm.InitStringObject(e.name),
+		"data":  t.vm.InitStringObject(e.data),
+		"id":    id,
+	})
+}
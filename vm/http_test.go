@@ -64,6 +64,148 @@ func TestHTTPRequest(t *testing.T) {
 	}
 }
 
+func TestHTTPExpand(t *testing.T) {
+	// The examples of RFC 6570, levels 1 to 3
+	rfcExamples := []struct {
+		template string
+		expected string
+	}{
+		{"{var}", "value"},
+		{"{hello}", "Hello%20World%21"},
+		{"{+hello}", "Hello%20World!"},
+		{"{+path}/here", "/foo/bar/here"},
+		{"X{#hello}", "X#Hello%20World!"},
+		{"map?{x,y}", "map?1024,768"},
+		{"{x,hello,y}", "1024,Hello%20World%21,768"},
+		{"{+path,x}/here", "/foo/bar,1024/here"},
+		{"{#path,x}/here", "#/foo/bar,1024/here"},
+		{"X{.x,y}", "X.1024.768"},
+		{"{/var,x}/here", "/value/1024/here"},
+		{"{;x,y,empty}", ";x=1024;y=768;empty"},
+		{"{?x,y,empty}", "?x=1024&y=768&empty="},
+		{"?fixed=yes{&x}", "?fixed=yes&x=1024"},
+		{"{&x,y,empty}", "&x=1024&y=768&empty="},
+	}
+
+	for i, ex := range rfcExamples {
+		input := fmt.Sprintf(`
+		require "net/http"
+
+		Net::HTTP.expand("%s", { var: "value", hello: "Hello World!", empty: "", path: "/foo/bar", x: 1024, y: "768" })
+		`, ex.template)
+
+		v := initTestVM()
+		evaluated := v.testEval(t, input, getFilename())
+		VerifyExpected(t, i, evaluated, ex.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require "net/http"
+
+		Net::HTTP.expand("/users/{id}/posts{?page,limit}", { id: 42, page: 2, limit: 10 })
+		`, "/users/42/posts?page=2&limit=10"},
+		// missing and nil values are left out
+		{`
+		require "net/http"
+
+		Net::HTTP.expand("/users/{id}/posts{?page,limit}", { id: 42, limit: nil })
+		`, "/users/42/posts"},
+		{`
+		require "net/http"
+
+		Net::HTTP.expand("{+base}/items{/id}{#section}", { base: "http://example.com" })
+		`, "http://example.com/items"},
+		{`
+		require "net/http"
+
+		Net::HTTP.expand("/search{?q}", { q: "50% off/now" })
+		`, "/search?q=50%25%20off%2Fnow"},
+		// percent-encoded triplets are kept by reserved expansion
+		{`
+		require "net/http"
+
+		Net::HTTP.expand("{+q}", { q: "50%25 off" })
+		`, "50%25%20off"},
+		{`
+		require "net/http"
+
+		Net::HTTP.expand("{?ratio}", { ratio: 1.5 })
+		`, "?ratio=1.5"},
+		{`
+		require "net/http"
+
+		Net::HTTP.expand("/plain", {})
+		`, "/plain"},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestHTTPExpandFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`
+		require "net/http"
+
+		Net::HTTP.expand("/users/{id}")
+		`, "ArgumentError: Expect 2 argument(s). got: 1", 1},
+		{`
+		require "net/http"
+
+		Net::HTTP.expand(1, {})
+		`, "TypeError: Expect argument #1 to be String. got: Integer", 1},
+		{`
+		require "net/http"
+
+		Net::HTTP.expand("/users/{id}", [])
+		`, "TypeError: Expect argument #2 to be Hash. got: Array", 1},
+		{`
+		require "net/http"
+
+		Net::HTTP.expand("/users/{id", { id: 1 })
+		`, "ArgumentError: Invalid URI template \"/users/{id\": unclosed {", 1},
+		{`
+		require "net/http"
+
+		Net::HTTP.expand("/users/id}", { id: 1 })
+		`, "ArgumentError: Invalid URI template \"/users/id}\": unmatched }", 1},
+		{`
+		require "net/http"
+
+		Net::HTTP.expand("/users/{id,}", { id: 1 })
+		`, "ArgumentError: Invalid URI template \"/users/{id,}\": empty variable name", 1},
+		{`
+		require "net/http"
+
+		Net::HTTP.expand("/users/{id:3}", { id: 1 })
+		`, "ArgumentError: Invalid URI template \"/users/{id:3}\": modifiers of id:3 aren't supported", 1},
+		{`
+		require "net/http"
+
+		Net::HTTP.expand("/users/{id}", { id: [1, 2] })
+		`, "ArgumentError: Invalid URI template \"/users/{id}\": Expect the value of id to be a String or a Numeric. got: Array", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestHTTPRequestFail(t *testing.T) {
 	//blocking channel
 	c := make(chan bool, 1)
@@ -0,0 +1,162 @@
+package vm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Helpers for `Net::HTTP.expand`, which expands URI templates as described in RFC 6570, up to level 3.
+//
+// An expression is written as `{[operator]name[,name...]}`, and the supported operators are:
+//
+// - none for simple string expansion, like `{id}` or `{x,y}`;
+// - `+` and `#` for reserved expansion, which keeps reserved characters like `/` and `?`;
+// - `.`, `/` and `;` for labels, path segments and path-style parameters;
+// - `?` and `&` for form-style queries and their continuation.
+//
+// A variable that's missing or nil is left out, along with its separator.
+// Level 4 modifiers (`:` prefixes and `*` explosion) and list or map values aren't supported.
+
+const (
+	invalidURITemplate      = "Invalid URI template %q: %s"
+	invalidURITemplateValue = "Expect the value of %s to be a String or a Numeric. got: %s"
+)
+
+// uriTemplateOperator describes how the variables of an expression are expanded, see RFC 6570 appendix A
+type uriTemplateOperator struct {
+	first         string
+	sep           string
+	named         bool
+	ifEmpty       string
+	allowReserved bool
+}
+
+var uriTemplateOperators = map[byte]uriTemplateOperator{
+	0:   {first: "", sep: ","},
+	'+': {first: "", sep: ",", allowReserved: true},
+	'#': {first: "#", sep: ",", allowReserved: true},
+	'.': {first: ".", sep: "."},
+	'/': {first: "/", sep: "/"},
+	';': {first: ";", sep: ";", named: true},
+	'?': {first: "?", sep: "&", named: true, ifEmpty: "="},
+	'&': {first: "&", sep: "&", named: true, ifEmpty: "="},
+}
+
+// uriTemplateReserved are the reserved characters of RFC 3986 that `+` and `#` expansions leave unencoded
+const uriTemplateReserved = ":/?#[]@!$&'()*+,;="
+
+// expandURITemplate expands the expressions of the template with the given values, and copies the literals as they are
+func expandURITemplate(template string, values map[string]Object) (string, error) {
+	var out strings.Builder
+
+	for rest := template; rest != ""; {
+		open := strings.IndexAny(rest, "{}")
+
+		if open < 0 {
+			out.WriteString(rest)
+			break
+		}
+
+		if rest[open] == '}' {
+			return "", fmt.Errorf(invalidURITemplate, template, "unmatched }")
+		}
+
+		out.WriteString(rest[:open])
+		rest = rest[open+1:]
+		end := strings.IndexAny(rest, "{}")
+
+		if end < 0 || rest[end] == '{' {
+			return "", fmt.Errorf(invalidURITemplate, template, "unclosed {")
+		}
+
+		expanded, err := expandURITemplateExpression(rest[:end], values)
+
+		if err != nil {
+			return "", fmt.Errorf(invalidURITemplate, template, err.Error())
+		}
+
+		out.WriteString(expanded)
+		rest = rest[end+1:]
+	}
+
+	return out.String(), nil
+}
+
+func expandURITemplateExpression(expression string, values map[string]Object) (string, error) {
+	var opChar byte
+
+	if expression != "" && strings.IndexByte("+#./;?&", expression[0]) >= 0 {
+		opChar = expression[0]
+		expression = expression[1:]
+	}
+
+	op := uriTemplateOperators[opChar]
+	var parts []string
+
+	for _, name := range strings.Split(expression, ",") {
+		if name == "" {
+			return "", fmt.Errorf("empty variable name")
+		}
+
+		if strings.ContainsAny(name, ":*") {
+			return "", fmt.Errorf("modifiers of %s aren't supported", name)
+		}
+
+		value, ok := values[name]
+
+		if !ok || value == NULL {
+			continue
+		}
+
+		switch value.(type) {
+		case *StringObject, Numeric:
+		default:
+			return "", fmt.Errorf(invalidURITemplateValue, name, value.Class().Name)
+		}
+
+		s := encodeURITemplateValue(value.ToString(), op.allowReserved)
+
+		switch {
+		case !op.named:
+			parts = append(parts, s)
+		case s == "":
+			parts = append(parts, name+op.ifEmpty)
+		default:
+			parts = append(parts, name+"="+s)
+		}
+	}
+
+	if len(parts) == 0 {
+		return "", nil
+	}
+
+	return op.first + strings.Join(parts, op.sep), nil
+}
+
+// encodeURITemplateValue percent-encodes every byte but the unreserved characters,
+// and also the reserved characters and existing percent-encoded triplets if allowReserved is true
+func encodeURITemplateValue(s string, allowReserved bool) string {
+	var out strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', strings.IndexByte("-._~", c) >= 0:
+			out.WriteByte(c)
+		case allowReserved && strings.IndexByte(uriTemplateReserved, c) >= 0:
+			out.WriteByte(c)
+		case allowReserved && c == '%' && i+2 < len(s) && isHexDigit(s[i+1]) && isHexDigit(s[i+2]):
+			out.WriteString(s[i : i+3])
+			i += 2
+		default:
+			fmt.Fprintf(&out, "%%%02X", c)
+		}
+	}
+
+	return out.String()
+}
+
+func isHexDigit(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
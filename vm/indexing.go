@@ -0,0 +1,141 @@
+package vm
+
+import (
+	"github.com/goby-lang/goby/vm/classes"
+	"github.com/goby-lang/goby/vm/errors"
+)
+
+// indexSpan is a half-open span `[start, end)` of a sequence, resolved from the arguments
+// of an indexing method such as `[]`, `[]=` or `slice`.
+//
+// Array and String share the same indexing rules, which follow Ruby's.
+// `[index]` refers to a single element, and a negative index counts from the end.
+// `[start, length]` and `[range]` refer to a subsequence. The start may equal the size
+// of the sequence, which yields an empty subsequence, and the end is clamped to the size.
+type indexSpan struct {
+	start, end int
+	// single is true when the span was given by a lone Integer index
+	single bool
+}
+
+// resolveIndex resolves the arguments of a reading method against a sequence of the given size.
+// It returns false if the span is out of bounds, in which case the method should return `nil`.
+func resolveIndex(t *Thread, args []Object, size int, sourceLine int) (indexSpan, bool, *Error) {
+	start, count, single, err := parseIndexArgs(t, args, size, sourceLine)
+
+	if err != nil {
+		return indexSpan{}, false, err
+	}
+
+	if single {
+		if start < 0 || start >= size {
+			return indexSpan{}, false, nil
+		}
+
+		return indexSpan{start: start, end: start + 1, single: true}, true, nil
+	}
+
+	if start < 0 || start > size || count < 0 {
+		return indexSpan{}, false, nil
+	}
+
+	end := start + count
+	if end > size {
+		end = size
+	}
+
+	return indexSpan{start: start, end: end}, true, nil
+}
+
+// resolveAssignmentIndex resolves the arguments of an assigning method against a sequence of the given size.
+// A negative length raises an IndexError. The returned span may start beyond the size,
+// and it returns true if a negative start reaches before the first element;
+// how to handle these cases is up to the caller.
+func resolveAssignmentIndex(t *Thread, args []Object, size int, sourceLine int) (indexSpan, bool, *Error) {
+	start, count, single, err := parseIndexArgs(t, args, size, sourceLine)
+
+	if err != nil {
+		return indexSpan{}, false, err
+	}
+
+	if count < 0 {
+		return indexSpan{}, false, t.vm.InitErrorObject(errors.IndexError, sourceLine, errors.NegativeSecondValue, count)
+	}
+
+	if start < 0 {
+		return indexSpan{}, true, nil
+	}
+
+	end := start + count
+	if end > size {
+		end = size
+	}
+	if end < start {
+		end = start
+	}
+
+	return indexSpan{start: start, end: end, single: single}, false, nil
+}
+
+// indexStart returns the start position given by an Integer index or a Range, before it's normalized
+func indexStart(index Object) int {
+	if r, ok := index.(*RangeObject); ok {
+		return r.Start
+	}
+
+	return index.(*IntegerObject).value
+}
+
+// parseIndexArgs validates the index arguments, which are either an Integer index,
+// an Integer start with an Integer length, or a Range.
+// Negative positions are counted from the end of the sequence, but can remain negative if they are too small.
+func parseIndexArgs(t *Thread, args []Object, size int, sourceLine int) (start, count int, single bool, err *Error) {
+	aLen := len(args)
+	if aLen < 1 || aLen > 2 {
+		return 0, 0, false, t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgumentRange, 1, 2, aLen)
+	}
+
+	switch index := args[0].(type) {
+	case *IntegerObject:
+		start = index.value
+		if start < 0 {
+			start += size
+		}
+
+		if aLen == 1 {
+			return start, 1, true, nil
+		}
+
+		length, ok := args[1].(*IntegerObject)
+		if !ok {
+			return 0, 0, false, t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormatNum, 2, classes.IntegerClass, args[1].Class().Name)
+		}
+
+		return start, length.value, false, nil
+	case *RangeObject:
+		if aLen != 1 {
+			return 0, 0, false, t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, aLen)
+		}
+
+		start = index.Start
+		end := index.End
+		if start < 0 {
+			start += size
+		}
+		if end < 0 {
+			end += size
+		}
+
+		count = end - start
+		if !index.Exclusive {
+			count++
+		}
+		if count < 0 {
+			count = 0
+		}
+
+		return start, count, false, nil
+	default:
+		return 0, 0, false, t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, "Range or Integer", args[0].Class().Name)
+	}
+}
@@ -0,0 +1,222 @@
+package vm
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Array and String share the same indexing rules, so each case runs against both
+// `[0, 1, 2, 3, 4]` and `"01234"`. The expected values are written as the string's result.
+func TestIndexingSemantics(t *testing.T) {
+	tests := []struct {
+		index    string
+		expected interface{}
+	}{
+		// Single index
+		{`0`, "0"},
+		{`4`, "4"},
+		{`5`, nil},
+		{`100`, nil},
+		{`-1`, "4"},
+		{`-5`, "0"},
+		{`-6`, nil},
+		// Start and length
+		{`0, 0`, ""},
+		{`0, 2`, "01"},
+		{`3, 10`, "34"},
+		{`4, 1`, "4"},
+		{`5, 0`, ""},
+		{`5, 1`, ""},
+		{`6, 1`, nil},
+		{`-2, 2`, "34"},
+		{`-5, 1`, "0"},
+		{`-5, 10`, "01234"},
+		{`-6, 1`, nil},
+		{`1, -1`, nil},
+		{`-1, -1`, nil},
+		// Range
+		{`1..3`, "123"},
+		{`0..-1`, "01234"},
+		{`-3..-1`, "234"},
+		{`-2..10`, "34"},
+		{`3..1`, ""},
+		{`4..10`, "4"},
+		{`5..6`, ""},
+		{`6..7`, nil},
+		{`1..-10`, ""},
+		{`-6..2`, nil},
+		{`1...3`, "12"},
+		{`0...-1`, "0123"},
+		{`2...2`, ""},
+	}
+
+	for i, tt := range tests {
+		for _, method := range []string{"[%s]", ".slice(%s)"} {
+			index := fmt.Sprintf(method, tt.index)
+
+			v := initTestVM()
+			evaluated := v.testEval(t, `"01234"`+index, getFilename())
+			VerifyExpected(t, i, evaluated, tt.expected)
+			v.checkCFP(t, i, 0)
+			v.checkSP(t, i, 1)
+
+			v = initTestVM()
+			evaluated = v.testEval(t, `[0, 1, 2, 3, 4]`+index, getFilename())
+			VerifyExpected(t, i, evaluated, expectedArrayIndexing(tt.index, tt.expected))
+			v.checkCFP(t, i, 0)
+			v.checkSP(t, i, 1)
+		}
+	}
+}
+
+func TestArrayIndexAssignmentSemantics(t *testing.T) {
+	tests := []struct {
+		index    string
+		value    string
+		expected []interface{}
+	}{
+		// Single index
+		{`0`, `9`, []interface{}{9, 1, 2, 3, 4}},
+		{`-1`, `9`, []interface{}{0, 1, 2, 3, 9}},
+		{`-5`, `9`, []interface{}{9, 1, 2, 3, 4}},
+		{`5`, `9`, []interface{}{0, 1, 2, 3, 4, 9}},
+		{`7`, `9`, []interface{}{0, 1, 2, 3, 4, nil, nil, 9}},
+		{`1`, `[8, 9]`, []interface{}{0, []interface{}{8, 9}, 2, 3, 4}},
+		// Start and length
+		{`1, 2`, `9`, []interface{}{0, 9, 3, 4}},
+		{`1, 0`, `9`, []interface{}{0, 9, 1, 2, 3, 4}},
+		{`4, 10`, `9`, []interface{}{0, 1, 2, 3, 9}},
+		{`5, 1`, `9`, []interface{}{0, 1, 2, 3, 4, 9}},
+		{`7, 1`, `9`, []interface{}{0, 1, 2, 3, 4, nil, nil, 9}},
+		{`-2, 1`, `9`, []interface{}{0, 1, 2, 9, 4}},
+		{`-5, 5`, `9`, []interface{}{9}},
+		{`1, 2`, `[8, 9]`, []interface{}{0, 8, 9, 3, 4}},
+		{`1, 1`, `[]`, []interface{}{0, 2, 3, 4}},
+		// Range
+		{`1..2`, `9`, []interface{}{0, 9, 3, 4}},
+		{`1...3`, `9`, []interface{}{0, 9, 3, 4}},
+		{`-2..-1`, `9`, []interface{}{0, 1, 2, 9}},
+		{`3..1`, `9`, []interface{}{0, 1, 2, 9, 3, 4}},
+		{`3..10`, `9`, []interface{}{0, 1, 2, 9}},
+		{`7..8`, `9`, []interface{}{0, 1, 2, 3, 4, nil, nil, 9}},
+		{`1..2`, `[7, 8, 9]`, []interface{}{0, 7, 8, 9, 3, 4}},
+	}
+
+	for i, tt := range tests {
+		input := fmt.Sprintf(`
+		a = [0, 1, 2, 3, 4]
+		a[%s] = %s
+		a
+		`, tt.index, tt.value)
+
+		v := initTestVM()
+		evaluated := v.testEval(t, input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestArrayIndexAssignmentSemanticsFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`[0, 1, 2, 3, 4][-6] = 9`, "IndexError: Index value -6 too small for array. minimum: -5", 1},
+		{`[0, 1, 2, 3, 4][-6, 1] = 9`, "IndexError: Index value -6 too small for array. minimum: -5", 1},
+		{`[0, 1, 2, 3, 4][-6..1] = 9`, "IndexError: Index value -6 too small for array. minimum: -5", 1},
+		{`[0, 1, 2, 3, 4][1, -1] = 9`, "IndexError: Expect second argument to be positive value. got: -1", 1},
+		{`[0, 1, 2, 3, 4][7, -1] = 9`, "IndexError: Expect second argument to be positive value. got: -1", 1},
+		{`[0, 1, 2, 3, 4][1..2, 1] = 9`, "ArgumentError: Expect 1 argument(s). got: 2", 1},
+		{`[0, 1, 2, 3, 4]["1"] = 9`, "TypeError: Expect argument to be Range or Integer. got: String", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestStringIndexAssignmentSemantics(t *testing.T) {
+	tests := []struct {
+		index    string
+		expected string
+	}{
+		// Single index
+		{`0`, "x1234"},
+		{`-1`, "0123x"},
+		{`-5`, "x1234"},
+		{`5`, "01234x"},
+		// Start and length
+		{`1, 2`, "0x34"},
+		{`1, 0`, "0x1234"},
+		{`4, 10`, "0123x"},
+		{`5, 1`, "01234x"},
+		{`-2, 1`, "012x4"},
+		{`-5, 5`, "x"},
+		// Range
+		{`1..2`, "0x34"},
+		{`1...3`, "0x34"},
+		{`-2..-1`, "012x"},
+		{`3..1`, "012x34"},
+		{`3..10`, "012x"},
+		{`5..6`, "01234x"},
+	}
+
+	for i, tt := range tests {
+		input := fmt.Sprintf(`
+		s = "01234"
+		s[%s] = "x"
+		s
+		`, tt.index)
+
+		v := initTestVM()
+		evaluated := v.testEval(t, input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestStringIndexAssignmentSemanticsFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`"01234"[6] = "x"`, "IndexError: Index value out of range. got: 6", 1},
+		{`"01234"[-6] = "x"`, "IndexError: Index value out of range. got: -6", 1},
+		{`"01234"[6, 1] = "x"`, "IndexError: Index value out of range. got: 6", 1},
+		{`"01234"[-6, 1] = "x"`, "IndexError: Index value out of range. got: -6", 1},
+		{`"01234"[6..7] = "x"`, "IndexError: Index value out of range. got: (6..7)", 1},
+		{`"01234"[-6..1] = "x"`, "IndexError: Index value out of range. got: (-6..1)", 1},
+		{`"01234"[1, -1] = "x"`, "IndexError: Expect second argument to be positive value. got: -1", 1},
+		{`"01234"[1, 1] = 1`, "TypeError: Expect argument to be String. got: Integer", 1},
+		{`"01234"[1, 1, 1] = "x"`, "ArgumentError: Expect 2 to 3 argument(s). got: 4", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+// expectedArrayIndexing converts the expected result of indexing "01234" into that of indexing [0, 1, 2, 3, 4]
+func expectedArrayIndexing(index string, expected interface{}) interface{} {
+	str, ok := expected.(string)
+	if !ok {
+		return expected
+	}
+
+	elems := []interface{}{}
+	for _, r := range str {
+		elems = append(elems, int(r-'0'))
+	}
+
+	// A lone Integer index retrieves an element rather than a subarray
+	if !strings.Contains(index, ",") && !strings.Contains(index, "..") {
+		return elems[0]
+	}
+
+	return elems
+}
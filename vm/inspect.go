@@ -0,0 +1,122 @@
+package vm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// inspectWithDepth returns the object's inspect form. Arrays, hashes and objects nested deeper than the depth are
+// written as `...`, and a depth of 0 renders every level.
+// A collection or object that contains itself is written as `[...]`, `{...}` or `#<ClassName:id ...>` where it recurs.
+func inspectWithDepth(obj Object, depth int) string {
+	i := &inspector{depth: depth, visiting: make(map[Object]bool)}
+	return i.inspect(obj, 1)
+}
+
+type inspector struct {
+	depth int
+	// visiting holds the containers on the path from the root to the current object, for detecting cycles
+	visiting map[Object]bool
+}
+
+// inspect writes the object found at the given level, which is 1 for the root
+func (i *inspector) inspect(obj Object, level int) string {
+	switch o := obj.(type) {
+	case *ArrayObject:
+		return i.elements(o, o.Elements, level)
+	case *ConcurrentArrayObject:
+		return i.elements(o, o.snapshot(), level)
+	case *HashObject:
+		return i.pairs(o, o.Pairs, level)
+	case *ConcurrentHashObject:
+		return i.pairs(o, o.pairs(), level)
+	case *RObject:
+		return i.object(o, level)
+	default:
+		return obj.Inspect()
+	}
+}
+
+// tooDeep reports whether a container at the level is beyond the depth
+func (i *inspector) tooDeep(level int) bool {
+	return i.depth > 0 && level > i.depth
+}
+
+func (i *inspector) elements(container Object, elems []Object, level int) string {
+	if i.tooDeep(level) {
+		return "..."
+	}
+
+	if i.visiting[container] {
+		return "[...]"
+	}
+
+	i.visiting[container] = true
+	inspected := make([]string, len(elems))
+
+	for n, elem := range elems {
+		inspected[n] = i.inspect(elem, level+1)
+	}
+
+	delete(i.visiting, container)
+	return "[" + strings.Join(inspected, ", ") + "]"
+}
+
+func (i *inspector) pairs(container Object, pairs map[string]Object, level int) string {
+	if i.tooDeep(level) {
+		return "..."
+	}
+
+	if i.visiting[container] {
+		return "{...}"
+	}
+
+	keys := make([]string, 0, len(pairs))
+
+	for key := range pairs {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	i.visiting[container] = true
+	inspected := make([]string, len(keys))
+
+	for n, key := range keys {
+		inspected[n] = key + ": " + i.inspect(pairs[key], level+1)
+	}
+
+	delete(i.visiting, container)
+	return "{ " + strings.Join(inspected, ", ") + " }"
+}
+
+// object writes the instance variables like their `to_s`, except that nested collections are inspected.
+// So an object in an instance variable is written without its own instance variables.
+func (i *inspector) object(o *RObject, level int) string {
+	if i.tooDeep(level) {
+		return "..."
+	}
+
+	head := "#<" + o.class.Name + ":" + fmt.Sprint(o.ID()) + " "
+
+	if i.visiting[o] {
+		return head + "...>"
+	}
+
+	i.visiting[o] = true
+	var ivars string
+
+	for _, name := range o.InstanceVariables.names() {
+		v, _ := o.InstanceVariableGet(name)
+
+		switch v.(type) {
+		case *ArrayObject, *ConcurrentArrayObject, *HashObject, *ConcurrentHashObject:
+			ivars += name + "=" + i.inspect(v, level+1) + " "
+		default:
+			ivars += name + "=" + v.ToString() + " "
+		}
+	}
+
+	delete(i.visiting, o)
+	return head + ivars + ">"
+}
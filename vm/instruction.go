@@ -19,6 +19,8 @@ type instructionSet struct {
 	instructions []*bytecode.Instruction
 	filename     filename
 	paramTypes   *bytecode.ArgSet
+	// paramDefaults holds the source of optional parameters' default values by their index
+	paramDefaults map[int]string
 }
 
 var operations [bytecode.InstructionCount]operation
@@ -43,19 +45,18 @@ func init() {
 		},
 		bytecode.GetConstant: func(t *Thread, sourceLine int, cf *normalCallFrame, args ...interface{}) {
 			constName := args[0].(string)
-			c := t.vm.lookupConstant(cf, constName)
+			c := t.lookupConstant(cf, constName)
 
 			if c == nil {
 				t.pushErrorObject(errors.NameError, sourceLine, "uninitialized constant %s", constName)
 			}
 
-			c.isNamespace = args[1].(bool)
-
 			if t.Stack.top() != nil && t.Stack.top().isNamespace {
 				t.Stack.Pop()
 			}
 
-			t.Stack.Push(c)
+			// The constant's pointer is shared by threads, so it's copied to be marked as a namespace
+			t.Stack.Push(&Pointer{Target: c.Target, isNamespace: args[1].(bool)})
 		},
 		bytecode.GetLocal: func(t *Thread, sourceLine int, cf *normalCallFrame, args ...interface{}) {
 			depth := args[0].(int)
@@ -101,6 +102,20 @@ func init() {
 
 			t.Stack.Push(&Pointer{Target: obj})
 		},
+		bytecode.GetClassVariable: func(t *Thread, sourceLine int, cf *normalCallFrame, args ...interface{}) {
+			variableName := args[0].(string)
+			t.Stack.Push(&Pointer{Target: t.getClassVariable(cf.classVariableScope(), variableName)})
+		},
+		bytecode.SetClassVariable: func(t *Thread, sourceLine int, cf *normalCallFrame, args ...interface{}) {
+			variableName := args[0].(string)
+			p := t.Stack.Pop()
+			cf.classVariableScope().setClassVariable(variableName, p.Target)
+			t.Stack.Push(&Pointer{Target: p.Target})
+		},
+		bytecode.GetGlobalVariable: func(t *Thread, sourceLine int, cf *normalCallFrame, args ...interface{}) {
+			variableName := args[0].(string)
+			t.Stack.Push(&Pointer{Target: t.getGlobalVariable(variableName)})
+		},
 		bytecode.SetLocal: func(t *Thread, sourceLine int, cf *normalCallFrame, args ...interface{}) {
 			var optioned bool
 			p := t.Stack.Pop()
@@ -151,8 +166,9 @@ func init() {
 		bytecode.NewRange: func(t *Thread, sourceLine int, cf *normalCallFrame, args ...interface{}) {
 			rangeEnd := t.Stack.Pop().Target.(*IntegerObject).value
 			rangeStart := t.Stack.Pop().Target.(*IntegerObject).value
+			exclusive := args[0].(int) == 1
 
-			t.Stack.Push(&Pointer{Target: t.vm.initRangeObject(rangeStart, rangeEnd)})
+			t.Stack.Push(&Pointer{Target: t.vm.initRangeObject(rangeStart, rangeEnd, exclusive)})
 
 		},
 		bytecode.NewArray: func(t *Thread, sourceLine int, cf *normalCallFrame, args ...interface{}) {
@@ -170,7 +186,13 @@ func init() {
 		},
 		bytecode.ExpandArray: func(t *Thread, sourceLine int, cf *normalCallFrame, args ...interface{}) {
 			arrLength := args[0].(int)
-			arr, ok := t.Stack.Pop().Target.(*ArrayObject)
+			obj := t.Stack.Pop().Target
+			arr, ok := obj.(*ArrayObject)
+
+			// Destructuring block parameters treat a non-array value as a single element array
+			if !ok && len(args) > 1 && args[1].(bool) {
+				arr, ok = t.vm.InitArrayObject([]Object{obj}), true
+			}
 
 			if !ok {
 				t.pushErrorObject(errors.TypeError, sourceLine, "Expect stack top's value to be an Array when executing 'expandarray' instruction.")
@@ -202,7 +224,10 @@ func init() {
 				return
 			}
 
-			arr.splat = true
+			// Mark a copy, so the array itself is still passed as one argument elsewhere
+			splatted := t.vm.InitArrayObject(append([]Object{}, arr.Elements...))
+			splatted.splat = true
+			t.Stack.Set(t.Stack.pointer-1, &Pointer{Target: splatted})
 
 		},
 		bytecode.NewHash: func(t *Thread, sourceLine int, cf *normalCallFrame, args ...interface{}) {
@@ -273,6 +298,15 @@ func init() {
 				Normal frame. IS name: 0. is block: true. ep: 17. source line: 5 <- The block execution
 			*/
 
+			// `break` with a value, the value is stored in the block source frame and returned by the method call
+			if len(args) > 0 && args[0].(int) == 1 {
+				v := t.Stack.Pop()
+
+				if cf.IsBlock() && cf.blockFrame != nil {
+					cf.blockFrame.breakValue = v.Target
+				}
+			}
+
 			if cf.IsBlock() {
 				/*
 				  1. Remove block execution frame
@@ -315,7 +349,7 @@ func init() {
 				t.pushErrorObject(errors.InternalError, sourceLine, "Can't get method %s's instruction set.", methodName)
 			}
 
-			method := &MethodObject{Name: methodName, argc: argCount, instructionSet: is, BaseObj: NewBaseObject(t.vm.TopLevelClass(classes.MethodClass))}
+			method := &MethodObject{Name: methodName, argc: argCount, instructionSet: is, sourceLine: sourceLine, visibility: cf.defaultVisibility, BaseObj: NewBaseObject(t.vm.TopLevelClass(classes.MethodClass))}
 
 			t.vm.defineMethodOn(t.Stack.Pop().Target, method)
 		},
@@ -323,7 +357,7 @@ func init() {
 			argCount := args[0].(int)
 			methodName := t.Stack.Pop().Target.(*StringObject).value
 			is, _ := t.getMethodIS(methodName, cf.FileName())
-			method := &MethodObject{Name: methodName, argc: argCount, instructionSet: is, BaseObj: NewBaseObject(t.vm.TopLevelClass(classes.MethodClass))}
+			method := &MethodObject{Name: methodName, argc: argCount, instructionSet: is, sourceLine: sourceLine, BaseObj: NewBaseObject(t.vm.TopLevelClass(classes.MethodClass))}
 
 			t.vm.defineSingletonMethodOn(t.Stack.Pop().Target, method)
 		},
@@ -345,7 +379,7 @@ func init() {
 
 				if len(args) >= 2 {
 					superClassName := args[1].(string)
-					superClass := t.vm.lookupConstant(cf, superClassName)
+					superClass := t.lookupConstant(cf, superClassName)
 					inheritedClass, ok := superClass.Target.(*RClass)
 
 					if !ok {
@@ -383,17 +417,7 @@ func init() {
 			}
 
 			argSet := args[3].(*bytecode.ArgSet)
-
-			// Deal with splat arguments
-			if arr, ok := t.Stack.top().Target.(*ArrayObject); ok && arr.splat {
-				// Pop array
-				t.Stack.Pop()
-				// Can't count array itself, only the number of array elements
-				argCount = argCount - 1 + len(arr.Elements)
-				for _, elem := range arr.Elements {
-					t.Stack.Push(&Pointer{Target: elem})
-				}
-			}
+			argCount, argSet = t.expandSplatArguments(argCount, argSet)
 
 			argPr := t.Stack.pointer - argCount
 			receiverPr := argPr - 1
@@ -409,10 +433,13 @@ func init() {
 				t.callFrameStack.push(blockFrame)
 			}
 
-			t.findAndCallMethod(receiver, methodName, receiverPr, argSet, argCount, argPr, sourceLine, blockFrame, cf.fileName)
+			// The program counter has moved to the next instruction
+			receiverKind := cf.instructionSet.instructions[cf.pc-1].ReceiverKind()
+
+			t.findAndCallMethod(receiver, methodName, receiverPr, argSet, argCount, argPr, sourceLine, blockFrame, cf.fileName, receiverKind, cf.self)
 		},
 		bytecode.InvokeBlock: func(t *Thread, sourceLine int, cf *normalCallFrame, args ...interface{}) {
-			argCount := args[0].(int)
+			argCount, _ := t.expandSplatArguments(args[0].(int), nil)
 			argPr := t.Stack.pointer - argCount
 			receiverPr := argPr - 1
 			receiver := t.Stack.data[receiverPr].Target
@@ -455,8 +482,14 @@ func init() {
 			c.self = receiver
 			c.isBlock = true
 
+			blockArgs := make([]Object, argCount)
+
 			for i := 0; i < argCount; i++ {
-				c.locals[i] = t.Stack.data[argPr+i]
+				blockArgs[i] = t.Stack.data[argPr+i].Target
+			}
+
+			for i, arg := range t.vm.blockArguments(blockFrame.instructionSet, blockArgs) {
+				c.insertLCL(i, 0, arg)
 			}
 
 			t.callFrameStack.push(c)
@@ -477,7 +510,7 @@ func init() {
 				blockFrame = cf.blockFrame.ep.blockFrame
 			}
 
-			blockObject := t.vm.initBlockObject(blockFrame.instructionSet, blockFrame.ep, t.Stack.data[t.Stack.pointer-1].Target)
+			blockObject := t.vm.initBlockObject(blockFrame.instructionSet, blockFrame.ep, t.Stack.data[t.Stack.pointer-1].Target, blockFrame.sourceLine)
 
 			t.Stack.Push(&Pointer{Target: blockObject})
 
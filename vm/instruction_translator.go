@@ -46,6 +46,7 @@ func (it *instructionTranslator) transferInstructionSets(sets []*bytecode.Instru
 		is := &instructionSet{filename: it.filename}
 		is.instructions = set.Instructions
 		is.paramTypes = set.ArgTypes()
+		is.paramDefaults = set.ArgDefaults()
 		it.setMetadata(is, set)
 	}
 }
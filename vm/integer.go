@@ -2,6 +2,7 @@ package vm
 
 import (
 	"math"
+	"math/big"
 	"strconv"
 
 	"github.com/goby-lang/goby/vm/classes"
@@ -16,6 +17,12 @@ import (
 // ```
 //
 // - `Integer.new` is not supported.
+//
+// By default, `+`, `-`, `*` and `**` wrap around silently when the result doesn't fit in 64 bits.
+// Hosts can change this with `VM.SetIntegerOverflowPolicy`: the VM can either raise an `OverflowError`,
+// or promote the result to a `BigInteger`.
+//
+// Integers work with BigIntegers in arithmetic and comparisons just like with other Integers.
 type IntegerObject struct {
 	*BaseObj
 	value int
@@ -65,32 +72,34 @@ var builtinIntegerInstanceMethods = []*BuiltinMethodObject{
 		// @return [Numeric]
 		Name: "+",
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
-			intOperation := func(leftValue int, rightValue int) int {
-				return leftValue + rightValue
+			intOperation := func(leftValue int, rightValue int) (int, bool) {
+				result := leftValue + rightValue
+				return result, (result > leftValue) != (rightValue > 0)
 			}
 			floatOperation := func(leftValue float64, rightValue float64) float64 {
 				return leftValue + rightValue
 			}
 
-			return receiver.(*IntegerObject).arithmeticOperation(t, args[0], intOperation, floatOperation, sourceLine, false)
+			return receiver.(*IntegerObject).checkedArithmeticOperation(t, args[0], "+", intOperation, (*Int).Add, floatOperation, sourceLine)
 
 		},
 	},
 	{
-		// Divides left hand operand by right hand operand and returns remainder.
+		// Returns the modulo of self divided by another Numeric. It matches the floored division of `/`,
+		// so it has the sign of the divisor. Raises a ZeroDivisionError if the divisor is the Integer 0,
+		// while the modulo by a Float 0 is NaN.
 		//
 		// ```Ruby
-		// 5 % 2 # => 1
+		// 5 % 2    # => 1
+		// -5 % 2   # => 1
+		// 5 % -2   # => -1
+		// 5 % 2.5  # => 0.0
+		// 5 % 0    # => ZeroDivisionError
 		// ```
 		// @return [Numeric]
 		Name: "%",
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
-			intOperation := func(leftValue int, rightValue int) int {
-				return leftValue % rightValue
-			}
-			floatOperation := math.Mod
-
-			return receiver.(*IntegerObject).arithmeticOperation(t, args[0], intOperation, floatOperation, sourceLine, true)
+			return receiver.(*IntegerObject).arithmeticOperation(t, args[0], "%", floorMod, floorModBig, floorModFloat, sourceLine, true)
 
 		},
 	},
@@ -103,14 +112,15 @@ var builtinIntegerInstanceMethods = []*BuiltinMethodObject{
 		// @return [Numeric]
 		Name: "-",
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
-			intOperation := func(leftValue int, rightValue int) int {
-				return leftValue - rightValue
+			intOperation := func(leftValue int, rightValue int) (int, bool) {
+				result := leftValue - rightValue
+				return result, (result < leftValue) != (rightValue > 0)
 			}
 			floatOperation := func(leftValue float64, rightValue float64) float64 {
 				return leftValue - rightValue
 			}
 
-			return receiver.(*IntegerObject).arithmeticOperation(t, args[0], intOperation, floatOperation, sourceLine, false)
+			return receiver.(*IntegerObject).checkedArithmeticOperation(t, args[0], "-", intOperation, (*Int).Sub, floatOperation, sourceLine)
 
 		},
 	},
@@ -123,14 +133,20 @@ var builtinIntegerInstanceMethods = []*BuiltinMethodObject{
 		// @return [Numeric]
 		Name: "*",
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
-			intOperation := func(leftValue int, rightValue int) int {
-				return leftValue * rightValue
+			intOperation := func(leftValue int, rightValue int) (int, bool) {
+				if leftValue == 0 || rightValue == 0 {
+					return 0, false
+				}
+
+				result := leftValue * rightValue
+				// The only overflow the division can't catch is the smallest integer times -1, which stays negative
+				return result, result/rightValue != leftValue || (rightValue == -1 && leftValue < 0 && result < 0)
 			}
 			floatOperation := func(leftValue float64, rightValue float64) float64 {
 				return leftValue * rightValue
 			}
 
-			return receiver.(*IntegerObject).arithmeticOperation(t, args[0], intOperation, floatOperation, sourceLine, false)
+			return receiver.(*IntegerObject).checkedArithmeticOperation(t, args[0], "*", intOperation, (*Int).Mul, floatOperation, sourceLine)
 
 		},
 	},
@@ -143,33 +159,54 @@ var builtinIntegerInstanceMethods = []*BuiltinMethodObject{
 		// @return [Numeric]
 		Name: "**",
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
-			intOperation := func(leftValue int, rightValue int) int {
-				return int(math.Pow(float64(leftValue), float64(rightValue)))
+			intOperation := func(leftValue int, rightValue int) (int, bool) {
+				result := int(math.Pow(float64(leftValue), float64(rightValue)))
+
+				if rightValue < 0 || leftValue >= -1 && leftValue <= 1 {
+					return result, false
+				}
+
+				// |leftValue| is at least 2 here, so the power of 64 or more never fits
+				if rightValue >= 64 {
+					return result, true
+				}
+
+				return result, !new(Int).Exp(big.NewInt(int64(leftValue)), big.NewInt(int64(rightValue)), nil).IsInt64()
+			}
+			bigOperation := func(z *Int, x *Int, y *Int) *Int {
+				return z.Exp(x, y, nil)
 			}
 			floatOperation := math.Pow
 
-			return receiver.(*IntegerObject).arithmeticOperation(t, args[0], intOperation, floatOperation, sourceLine, false)
+			if _, ok := args[0].(*BigIntegerObject); ok {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.ExponentTooLarge, args[0].ToString())
+			}
+
+			return receiver.(*IntegerObject).checkedArithmeticOperation(t, args[0], "**", intOperation, bigOperation, floatOperation, sourceLine)
 
 		},
 	},
 	{
-		// Returns self divided by another Numeric.
+		// Returns self divided by another Numeric. The division of Integers is rounded toward negative infinity,
+		// and raises a ZeroDivisionError if the divisor is 0.
+		// The division by a Float follows IEEE 754, so dividing by 0.0 results in Infinity, -Infinity or NaN.
 		//
 		// ```Ruby
-		// 6 / 3 # => 2
+		// 6 / 3    # => 2
+		// 7 / 2    # => 3
+		// -7 / 2   # => -4
+		// 7 / 2.0  # => 3.5
+		// 7 / 0    # => ZeroDivisionError
+		// 7 / 0.0  # => Infinity
 		// ```
 		// @return [Numeric]
 		Name: "/",
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
-
-			intOperation := func(leftValue int, rightValue int) int {
-				return leftValue / rightValue
-			}
 			floatOperation := func(leftValue float64, rightValue float64) float64 {
 				return leftValue / rightValue
 			}
 
-			return receiver.(*IntegerObject).arithmeticOperation(t, args[0], intOperation, floatOperation, sourceLine, true)
+			return receiver.(*IntegerObject).arithmeticOperation(t, args[0], "/", floorDiv, floorDivBig, floatOperation, sourceLine, true)
 
 		},
 	},
@@ -191,7 +228,7 @@ var builtinIntegerInstanceMethods = []*BuiltinMethodObject{
 			}
 
 			switch arg := args[0].(type) {
-			case *IntegerObject, *FloatObject:
+			case *IntegerObject, *BigIntegerObject, *RationalObject, *FloatObject:
 				return toBooleanObject(receiver.(*IntegerObject).numericComparison(args[0], intComparison, floatComparison))
 			default:
 				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, "Numeric", arg.Class().Name)
@@ -216,7 +253,7 @@ var builtinIntegerInstanceMethods = []*BuiltinMethodObject{
 			}
 
 			switch arg := args[0].(type) {
-			case *IntegerObject, *FloatObject:
+			case *IntegerObject, *BigIntegerObject, *RationalObject, *FloatObject:
 				return toBooleanObject(receiver.(*IntegerObject).numericComparison(args[0], intComparison, floatComparison))
 			default:
 				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, "Numeric", arg.Class().Name)
@@ -242,7 +279,7 @@ var builtinIntegerInstanceMethods = []*BuiltinMethodObject{
 			}
 
 			switch arg := args[0].(type) {
-			case *IntegerObject, *FloatObject:
+			case *IntegerObject, *BigIntegerObject, *RationalObject, *FloatObject:
 				return toBooleanObject(receiver.(*IntegerObject).numericComparison(args[0], intComparison, floatComparison))
 			default:
 				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, "Numeric", arg.Class().Name)
@@ -268,7 +305,7 @@ var builtinIntegerInstanceMethods = []*BuiltinMethodObject{
 			}
 
 			switch arg := args[0].(type) {
-			case *IntegerObject, *FloatObject:
+			case *IntegerObject, *BigIntegerObject, *RationalObject, *FloatObject:
 				return toBooleanObject(receiver.(*IntegerObject).numericComparison(args[0], intComparison, floatComparison))
 			default:
 				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, "Numeric", arg.Class().Name)
@@ -302,6 +339,10 @@ var builtinIntegerInstanceMethods = []*BuiltinMethodObject{
 				}
 
 				return t.vm.InitIntegerObject(0)
+			case *BigIntegerObject:
+				return t.vm.InitIntegerObject(big.NewInt(int64(receiver.(*IntegerObject).value)).Cmp(rightObject.value))
+			case *RationalObject:
+				return t.vm.InitIntegerObject(new(big.Rat).SetInt64(int64(receiver.(*IntegerObject).value)).Cmp(rightObject.value))
 			case *FloatObject:
 				leftValue := float64(receiver.(*IntegerObject).value)
 				rightValue := rightObject.value
@@ -320,6 +361,158 @@ var builtinIntegerInstanceMethods = []*BuiltinMethodObject{
 
 		},
 	},
+	{
+		// Returns the absolute value of self.
+		//
+		// ```Ruby
+		// -12.abs # => 12
+		// 12.abs  # => 12
+		// ```
+		// @return [Integer]
+		Name: "abs",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			// The absolute value of the smallest Integer is a BigInteger
+			value := big.NewInt(int64(receiver.(*IntegerObject).value))
+			return t.vm.initIntegerObjectFromBig(value.Abs(value))
+
+		},
+	},
+	{
+		// Returns the smallest multiple of 10 ** -digits greater than or equal to self, if digits is negative.
+		// Otherwise, it's self.
+		//
+		// ```Ruby
+		// 1234.ceil      # => 1234
+		// 1234.ceil(-2)  # => 1300
+		// -1234.ceil(-2) # => -1200
+		// ```
+		// @param digits [Integer]
+		// @return [Integer]
+		Name: "ceil",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return t.roundIntegerToDigits(receiver.(*IntegerObject).value, args, ceilDivBig, sourceLine)
+
+		},
+	},
+	{
+		// Returns `[q, r]`, where q is `(self / divisor).floor` and r is `self % divisor`.
+		// q is always an Integer, and r is a Float if the divisor is a Float, or a Rational if it's a Rational.
+		// Raises a ZeroDivisionError if the divisor is 0, including 0.0.
+		//
+		// ```Ruby
+		// 7.divmod(2)    # => [3, 1]
+		// -7.divmod(2)   # => [-4, 1]
+		// 7.divmod(-2)   # => [-4, -1]
+		// 7.divmod(2.5)  # => [2, 2.0]
+		// 7.divmod(Rational(2, 3)) # => [10, (1/3)]
+		// ```
+		// @param divisor [Numeric]
+		// @return [Array]
+		Name: "divmod",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			i := receiver.(*IntegerObject)
+
+			switch divisor := args[0].(type) {
+			case *IntegerObject:
+				if divisor.value == 0 {
+					return t.vm.InitErrorObject(errors.ZeroDivisionError, sourceLine, errors.DividedByZero)
+				}
+
+				return t.vm.InitArrayObject([]Object{
+					t.vm.InitIntegerObject(floorDiv(i.value, divisor.value)),
+					t.vm.InitIntegerObject(floorMod(i.value, divisor.value)),
+				})
+			case *BigIntegerObject:
+				x := big.NewInt(int64(i.value))
+
+				return t.vm.InitArrayObject([]Object{
+					t.vm.initIntegerObjectFromBig(floorDivBig(new(Int), x, divisor.value)),
+					t.vm.initIntegerObjectFromBig(floorModBig(new(Int), x, divisor.value)),
+				})
+			case *FloatObject:
+				return t.floatDivmod(float64(i.value), divisor.value, sourceLine)
+			case *RationalObject:
+				return t.ratDivmod(new(big.Rat).SetInt64(int64(i.value)), divisor.value, sourceLine)
+			default:
+				return t.coerceOperation(i, divisor, "divmod", sourceLine)
+			}
+
+		},
+	},
+	{
+		// Returns the Float result of dividing self by a Numeric, unlike `/`, which rounds the division of Integers.
+		//
+		// ```Ruby
+		// 7.fdiv(2)  # => 3.5
+		// -7.fdiv(2) # => -3.5
+		// 7.fdiv(0)  # => Infinity
+		// ```
+		// @param divisor [Numeric]
+		// @return [Float]
+		Name: "fdiv",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			i := receiver.(*IntegerObject)
+			divisor, ok := args[0].(Numeric)
+
+			if !ok {
+				return t.coerceOperation(i, args[0], "fdiv", sourceLine)
+			}
+
+			return t.vm.initFloatObject(i.floatValue() / divisor.floatValue())
+
+		},
+	},
+	{
+		// Returns the largest multiple of 10 ** -digits less than or equal to self, if digits is negative.
+		// Otherwise, it's self.
+		//
+		// ```Ruby
+		// 1234.floor      # => 1234
+		// 1234.floor(-2)  # => 1200
+		// -1234.floor(-2) # => -1300
+		// ```
+		// @param digits [Integer]
+		// @return [Integer]
+		Name: "floor",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			floorDivision := func(x, y *Int) *Int {
+				return floorDivBig(new(Int), x, y)
+			}
+
+			return t.roundIntegerToDigits(receiver.(*IntegerObject).value, args, floorDivision, sourceLine)
+
+		},
+	},
+	{
+		// Returns the multiple of 10 ** -digits nearest to self, if digits is negative. Ties are rounded away from zero.
+		// Otherwise, it's self.
+		//
+		// ```Ruby
+		// 1234.round      # => 1234
+		// 1250.round(-2)  # => 1300
+		// 1249.round(-2)  # => 1200
+		// -1250.round(-2) # => -1300
+		// ```
+		// @param digits [Integer]
+		// @return [Integer]
+		Name: "round",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return t.roundIntegerToDigits(receiver.(*IntegerObject).value, args, roundHalfAwayFromZero, sourceLine)
+
+		},
+	},
 	{
 		// Returns if self is even.
 		//
@@ -709,6 +902,23 @@ func (vm *VM) InitIntegerObject(value int) *IntegerObject {
 	}
 }
 
+// IntegerOverflowPolicy decides what Integer arithmetic does when its result doesn't fit in an Integer
+type IntegerOverflowPolicy int
+
+const (
+	// OverflowWrap lets the result wrap around, like Go's integers do. It's the default policy.
+	OverflowWrap IntegerOverflowPolicy = iota
+	// OverflowRaise raises an OverflowError
+	OverflowRaise
+	// OverflowPromote returns the exact result as a BigInteger
+	OverflowPromote
+)
+
+// SetIntegerOverflowPolicy sets how `+`, `-` and `*` of Integers handle overflow
+func (vm *VM) SetIntegerOverflowPolicy(policy IntegerOverflowPolicy) {
+	vm.overflowPolicy = policy
+}
+
 func (vm *VM) initIntegerClass() *RClass {
 	ic := vm.initializeClass(classes.IntegerClass)
 	ic.setBuiltinMethods(builtinIntegerInstanceMethods, false)
@@ -731,10 +941,15 @@ func (i *IntegerObject) floatValue() float64 {
 
 // TODO: Remove instruction argument
 // Apply the passed arithmetic operation, while performing type conversion.
+// Any operation with a Float results in a Float, and other objects are coerced with `coerce`.
+// Operations with a Rational are exact, except for `**`, which results in a Float.
+// If it's a division, an Integer or Rational divisor can't be 0.
 func (i *IntegerObject) arithmeticOperation(
 	t *Thread,
 	rightObject Object,
+	operator string,
 	intOperation func(leftValue int, rightValue int) int,
+	bigOperation func(z *Int, x *Int, y *Int) *Int,
 	floatOperation func(leftValue float64, rightValue float64) float64,
 	sourceLine int,
 	division bool,
@@ -750,19 +965,66 @@ func (i *IntegerObject) arithmeticOperation(
 		result := intOperation(leftValue, rightValue)
 
 		return t.vm.InitIntegerObject(result)
+	case *BigIntegerObject:
+		// A BigInteger is never 0, so division needs no check. The result may fit in an Integer again
+		return t.vm.initIntegerObjectFromBig(bigOperation(new(Int), big.NewInt(int64(i.value)), rightObject.value))
 	case *FloatObject:
 		leftValue := float64(i.value)
 		rightValue := rightObject.value
 
-		if division && rightValue == 0 {
-			return t.vm.InitErrorObject(errors.ZeroDivisionError, sourceLine, errors.DividedByZero)
-		}
-
 		result := floatOperation(leftValue, rightValue)
 
 		return t.vm.initFloatObject(result)
+	case *RationalObject:
+		ratOperation, ok := ratOperations[operator]
+
+		if !ok {
+			return t.vm.initFloatObject(floatOperation(i.floatValue(), rightObject.floatValue()))
+		}
+
+		leftObject := t.vm.initRationalObject(new(big.Rat).SetInt64(int64(i.value)))
+
+		return leftObject.arithmeticOperation(t, rightObject, ratOperation, floatOperation, sourceLine, division)
 	default:
-		return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, "Numeric", rightObject.Class().Name)
+		return t.coerceOperation(i, rightObject, operator, sourceLine)
+	}
+}
+
+// Apply the passed arithmetic operation like arithmeticOperation does, and handle the overflow of Integer results
+// according to the VM's overflow policy. bigOperation computes the exact result in case it's promoted.
+func (i *IntegerObject) checkedArithmeticOperation(
+	t *Thread,
+	rightObject Object,
+	operator string,
+	intOperation func(leftValue int, rightValue int) (int, bool),
+	bigOperation func(z *Int, x *Int, y *Int) *Int,
+	floatOperation func(leftValue float64, rightValue float64) float64,
+	sourceLine int,
+) Object {
+	r, ok := rightObject.(*IntegerObject)
+
+	if !ok {
+		wrappedOperation := func(leftValue int, rightValue int) int {
+			result, _ := intOperation(leftValue, rightValue)
+			return result
+		}
+
+		return i.arithmeticOperation(t, rightObject, operator, wrappedOperation, bigOperation, floatOperation, sourceLine, false)
+	}
+
+	result, overflowed := intOperation(i.value, r.value)
+
+	if !overflowed {
+		return t.vm.InitIntegerObject(result)
+	}
+
+	switch t.vm.overflowPolicy {
+	case OverflowRaise:
+		return t.vm.InitErrorObject(errors.OverflowError, sourceLine, errors.IntegerOverflow, i.value, operator, r.value)
+	case OverflowPromote:
+		return t.vm.initBigIntegerObject(bigOperation(new(Int), big.NewInt(int64(i.value)), big.NewInt(int64(r.value))))
+	default:
+		return t.vm.InitIntegerObject(result)
 	}
 }
 
@@ -803,6 +1065,11 @@ func (i *IntegerObject) numericComparison(
 		result := intComparison(leftValue, rightValue)
 
 		return result
+	case *BigIntegerObject:
+		// Comparing the result of Cmp with 0 gives the same answer as comparing the values
+		return intComparison(big.NewInt(int64(i.value)).Cmp(rightObject.value), 0)
+	case *RationalObject:
+		return intComparison(new(big.Rat).SetInt64(int64(i.value)).Cmp(rightObject.value), 0)
 	case *FloatObject:
 		leftValue := i.floatValue()
 		rightValue := rightObject.value
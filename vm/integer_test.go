@@ -105,6 +105,90 @@ func TestIntegerArithmeticOperationFail(t *testing.T) {
 	}
 }
 
+func TestIntegerOverflowWrap(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`9223372036854775807 + 1`, -9223372036854775808},
+		{`-9223372036854775807 - 2`, 9223372036854775807},
+		{`4611686018427387904 * 2`, -9223372036854775808},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestIntegerOverflowRaise(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`9223372036854775806 + 1`, 9223372036854775807},
+		{`-9223372036854775807 - 1 + 1`, -9223372036854775807},
+		{`-4611686018427387904 * 2`, -9223372036854775808},
+		{`9223372036854775807 * -1`, -9223372036854775807},
+		{`9223372036854775807 + 1.0`, 9223372036854775807.0 + 1.0},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		v.SetIntegerOverflowPolicy(OverflowRaise)
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+
+	testsFail := []errorTestCase{
+		{`9223372036854775807 + 1`, "OverflowError: Integer overflow: 9223372036854775807 + 1", 1},
+		{`-9223372036854775807 - 2`, "OverflowError: Integer overflow: -9223372036854775807 - 2", 1},
+		{`4611686018427387904 * 2`, "OverflowError: Integer overflow: 4611686018427387904 * 2", 1},
+		{`a = -9223372036854775807 - 1
+		a * -1`, "OverflowError: Integer overflow: -9223372036854775808 * -1", 1},
+		{`a = -9223372036854775807 - 1
+		-1 * a`, "OverflowError: Integer overflow: -1 * -9223372036854775808", 1},
+		{`2 ** 64`, "OverflowError: Integer overflow: 2 ** 64", 1},
+		{`-3 ** 41`, "OverflowError: Integer overflow: -3 ** 41", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		v.SetIntegerOverflowPolicy(OverflowRaise)
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestIntegerOverflowPromote(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`(9223372036854775807 + 1).to_s`, "9223372036854775808"},
+		{`(-9223372036854775807 - 2).to_s`, "-9223372036854775809"},
+		{`(4611686018427387904 * 4).to_s`, "18446744073709551616"},
+		{`(9223372036854775807 + 1).class.name`, "BigInteger"},
+		{`9223372036854775806 + 1`, 9223372036854775807},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		v.SetIntegerOverflowPolicy(OverflowPromote)
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestIntegerComparisonWithInteger(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -330,6 +414,35 @@ func TestIntegerTimesMethod(t *testing.T) {
 			end
 			a
 			`, 3},
+		{`
+		r = []
+		3.times do |i|
+		  r.push(i)
+		end
+		r
+		`, []interface{}{0, 1, 2}},
+		{`
+		r = []
+		0.times do |i|
+		  r.push(i)
+		end
+		r
+		`, []interface{}{}},
+		{`3.times do end`, 3},
+		// returns an enumerator without a block
+		{`5.times.to_a`, []interface{}{0, 1, 2, 3, 4}},
+		{`5.times.map do |i| i * i end`, []interface{}{0, 1, 4, 9, 16}},
+		{`5.times.select do |i| i.even? end`, []interface{}{0, 2, 4}},
+		{`0.times.to_a`, []interface{}{}},
+		{`(0 - 3).times.to_a`, []interface{}{}},
+		{`
+		e = 3.times
+		r = []
+		while e.has_next? do
+		  r.push(e.next)
+		end
+		r
+		`, []interface{}{0, 1, 2}},
 	}
 
 	for i, tt := range tests {
@@ -345,12 +458,269 @@ func TestIntegerZeroDivisionFail(t *testing.T) {
 	testsFail := []errorTestCase{
 		{`6 / 0`, "ZeroDivisionError: Divided by 0", 1},
 		{`6 / -0`, "ZeroDivisionError: Divided by 0", 1},
-		{`6 / 0.0`, "ZeroDivisionError: Divided by 0", 1},
-		{`6 / -0.0`, "ZeroDivisionError: Divided by 0", 1},
 		{`6 % 0`, "ZeroDivisionError: Divided by 0", 1},
 		{`6 % -0`, "ZeroDivisionError: Divided by 0", 1},
-		{`6 % 0.0`, "ZeroDivisionError: Divided by 0", 1},
-		{`6 % -0.0`, "ZeroDivisionError: Divided by 0", 1},
+		{`6.divmod(0)`, "ZeroDivisionError: Divided by 0", 1},
+		{`6.divmod(0.0)`, "ZeroDivisionError: Divided by 0", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestIntegerDivisionBySignCombination(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`7 / 2`, 3},
+		{`-7 / 2`, -4},
+		{`7 / -2`, -4},
+		{`-7 / -2`, 3},
+		{`6 / -2`, -3},
+		{`7 % 2`, 1},
+		{`-7 % 2`, 1},
+		{`7 % -2`, -1},
+		{`-7 % -2`, -1},
+		{`6 % -2`, 0},
+		{`7 / 2.0`, 3.5},
+		{`-7 / 2.0`, -3.5},
+		{`7 % 2.5`, 2.0},
+		{`-7 % 2.5`, 0.5},
+		{`7 % -2.5`, -0.5},
+		{`-7 % -2.5`, -2.0},
+		{`(7 / 0.0).to_s`, "Infinity"},
+		{`(-7 / 0.0).to_s`, "-Infinity"},
+		{`(7 / -0.0).to_s`, "-Infinity"},
+		{`(0 / 0.0).to_s`, "NaN"},
+		{`(7 % 0.0).to_s`, "NaN"},
+		{`(7 / Rational(2, 1)).to_s`, "7/2"},
+		{`(-7 / Rational(2, 1)).to_s`, "-7/2"},
+		{`(7 % Rational(2, 1)).to_s`, "1/1"},
+		{`(-7 % Rational(2, 1)).to_s`, "1/1"},
+		{`(7 % Rational(-2, 1)).to_s`, "-1/1"},
+		{`(-7 % Rational(-2, 1)).to_s`, "-1/1"},
+		{`(Rational(7, 2) / 2).to_s`, "7/4"},
+		{`(Rational(7, 2) / -2).to_s`, "-7/4"},
+		{`(Rational(7, 2) % 2).to_s`, "3/2"},
+		{`(Rational(-7, 2) % 2).to_s`, "1/2"},
+		{`(Rational(7, 2) % -2).to_s`, "-1/2"},
+		{`(Rational(-7, 2) % -2).to_s`, "-3/2"},
+		{`(Rational(7, 2) % Rational(-2, 3)).to_s`, "-1/2"},
+		{`Rational(7, 2) % 2.5`, 1.0},
+		{`Rational(-7, 2) % 2.5`, 1.5},
+		{`Rational(7, 2) % -2.5`, -1.5},
+		{`(Rational(1, 2) / 0.0).to_s`, "Infinity"},
+		{`(Rational(-1, 2) / 0.0).to_s`, "-Infinity"},
+		{`(Rational(1, 2) / -0.0).to_s`, "-Infinity"},
+		{`(Rational(0, 1) / 0.0).to_s`, "NaN"},
+		{`(Rational(1, 2) % 0.0).to_s`, "NaN"},
+		{`(9223372036854775807 / 2.0).class.name`, "Float"},
+		{`(1 + 1.0).class.name`, "Float"},
+		{`(1 - 1.0).class.name`, "Float"},
+		{`(1 * 1.0).class.name`, "Float"},
+		{`(1 ** 1.0).class.name`, "Float"},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestIntegerDivmodMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`7.divmod(2)`, []interface{}{3, 1}},
+		{`-7.divmod(2)`, []interface{}{-4, 1}},
+		{`7.divmod(-2)`, []interface{}{-4, -1}},
+		{`-7.divmod(-2)`, []interface{}{3, -1}},
+		{`0.divmod(3)`, []interface{}{0, 0}},
+		{`7.divmod(2.5)`, []interface{}{2, 2.0}},
+		{`-7.divmod(2.5)`, []interface{}{-3, 0.5}},
+		{`7.divmod(-2.5)`, []interface{}{-3, -0.5}},
+		{`-7.divmod(-2.5)`, []interface{}{2, -2.0}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestIntegerFdivMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`7.fdiv(2)`, 3.5},
+		{`-7.fdiv(2)`, -3.5},
+		{`7.fdiv(-2)`, -3.5},
+		{`-7.fdiv(-2)`, 3.5},
+		{`7.fdiv(0.5)`, 14.0},
+		{`7.fdiv(0).to_s`, "Infinity"},
+		{`-7.fdiv(0).to_s`, "-Infinity"},
+		{`0.fdiv(0).to_s`, "NaN"},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestIntegerAbsMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`12.abs`, 12},
+		{`-12.abs`, 12},
+		{`0.abs`, 0},
+		{`(-9223372036854775807 - 1).abs.to_s`, "9223372036854775808"},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestIntegerRoundingMethods(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`1234.ceil`, 1234},
+		{`1234.ceil(2)`, 1234},
+		{`1234.ceil(-2)`, 1300},
+		{`-1234.ceil(-2)`, -1200},
+		{`1200.ceil(-2)`, 1200},
+		{`1234.ceil(-5)`, 100000},
+		{`-1234.ceil(-5)`, 0},
+		{`1234.floor`, 1234},
+		{`1234.floor(-2)`, 1200},
+		{`-1234.floor(-2)`, -1300},
+		{`-1200.floor(-2)`, -1200},
+		{`1234.floor(-5)`, 0},
+		{`-1234.floor(-5)`, -100000},
+		{`1234.round`, 1234},
+		{`1234.round(1)`, 1234},
+		{`1249.round(-2)`, 1200},
+		{`1250.round(-2)`, 1300},
+		{`-1249.round(-2)`, -1200},
+		{`-1250.round(-2)`, -1300},
+		{`1234.round(-5)`, 0},
+		{`1234.ceil(-20).to_s`, "100000000000000000000"},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestIntegerRoundingMethodsFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`1.ceil(1, 2)`, "ArgumentError: Expect 1 or less argument(s). got: 2", 1},
+		{`1.floor("1")`, "TypeError: Expect argument to be Integer. got: String", 1},
+		{`1.round(1.5)`, "TypeError: Expect argument to be Integer. got: Float", 1},
+		{`1.abs(1)`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+		{`1.divmod`, "ArgumentError: Expect 1 argument(s). got: 0", 1},
+		{`1.fdiv("1")`, "TypeError: Expect argument to be Numeric. got: String", 1},
+		{`1.divmod(nil)`, "TypeError: Expect argument to be Numeric. got: Null", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestIntegerCoerce(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		class Meter
+		  def initialize(value)
+		    @value = value
+		  end
+
+		  def coerce(other)
+		    [other, @value]
+		  end
+		end
+
+		[2 + Meter.new(3), 2 * Meter.new(3), 7 / Meter.new(2), 7.divmod(Meter.new(2)), 7.fdiv(Meter.new(2))]
+		`, []interface{}{5, 6, 3, []interface{}{3, 1}, 3.5}},
+		{`
+		class Meter
+		  def coerce(other)
+		    [1.0 * other, 2]
+		  end
+		end
+
+		7 - Meter.new
+		`, 5.0},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestIntegerCoerceFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`1 + "1"`, "TypeError: Expect argument to be Numeric. got: String", 1},
+		{`
+		class Meter
+		  def coerce(other)
+		    1
+		  end
+		end
+
+		1 + Meter.new
+		`, "TypeError: Expect Meter#coerce to return an Array of 2 elements. got: 1", 1},
+		{`
+		class Meter
+		  def coerce(other)
+		    [1, "a"]
+		  end
+		end
+
+		1 + Meter.new
+		`, "TypeError: Expect Meter#coerce to return an Array of 2 elements. got: [1, \"a\"]", 1},
 	}
 
 	for i, tt := range testsFail {
@@ -0,0 +1,234 @@
+package vm
+
+import (
+	"net/netip"
+
+	"github.com/goby-lang/goby/vm/classes"
+	"github.com/goby-lang/goby/vm/errors"
+)
+
+const invalidCIDR = "Invalid CIDR: %s"
+
+// IPObject is an IPv4 or IPv6 address, which is created by `Net::IP.parse` in the `net/dns` library.
+//
+// ```ruby
+// require "net/dns"
+//
+// ip = Net::IP.parse("10.1.2.3")
+// ip.version                  # => 4
+// ip.private?                 # => true
+// ip.in_subnet?("10.0.0.0/8") # => true
+// ```
+type IPObject struct {
+	*BaseObj
+	addr netip.Addr
+}
+
+// Class methods --------------------------------------------------------
+var builtinIPClassMethods = []*BuiltinMethodObject{
+	{
+		Name: "new",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return t.vm.InitNoMethodError(sourceLine, "new", receiver)
+
+		},
+	},
+	{
+		// Parses the String as an IPv4 or IPv6 address. It's an ArgumentError if it isn't one.
+		//
+		// ```ruby
+		// Net::IP.parse("192.168.0.1") # => 192.168.0.1
+		// Net::IP.parse("::1")         # => ::1
+		// Net::IP.parse("localhost")   # => ArgumentError: Invalid IP address: localhost
+		// ```
+		//
+		// @param address [String]
+		// @return [Net::IP]
+		Name: "parse",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			typeErr := t.vm.checkArgTypes(args, sourceLine, classes.StringClass)
+
+			if typeErr != nil {
+				return typeErr
+			}
+
+			s := args[0].(*StringObject).value
+			addr, err := netip.ParseAddr(s)
+
+			if err != nil {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, invalidIPAddress, s)
+			}
+
+			return &IPObject{BaseObj: NewBaseObject(receiver.(*RClass)), addr: addr}
+
+		},
+	},
+}
+
+// Instance methods -----------------------------------------------------
+var builtinIPInstanceMethods = []*BuiltinMethodObject{
+	{
+		// Returns true if the address is in the subnet written in the CIDR notation, false otherwise.
+		// An IPv4-mapped IPv6 address is in the IPv4 subnets its IPv4 address is in.
+		//
+		// ```ruby
+		// Net::IP.parse("10.1.2.3").in_subnet?("10.0.0.0/8")       # => true
+		// Net::IP.parse("10.1.2.3").in_subnet?("10.2.0.0/16")      # => false
+		// Net::IP.parse("::ffff:10.1.2.3").in_subnet?("10.0.0.0/8") # => true
+		// ```
+		//
+		// @param subnet [String]
+		// @return [Boolean]
+		Name: "in_subnet?",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			typeErr := t.vm.checkArgTypes(args, sourceLine, classes.StringClass)
+
+			if typeErr != nil {
+				return typeErr
+			}
+
+			s := args[0].(*StringObject).value
+			prefix, err := netip.ParsePrefix(s)
+
+			if err != nil {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, invalidCIDR, s)
+			}
+
+			addr := receiver.(*IPObject).addr
+
+			if prefix.Addr().Is4() {
+				addr = addr.Unmap()
+			}
+
+			return toBooleanObject(prefix.Contains(addr))
+
+		},
+	},
+	{
+		// Returns true if the address is a loopback address, like 127.0.0.1 or ::1.
+		//
+		// ```ruby
+		// Net::IP.parse("127.0.0.1").loopback? # => true
+		// Net::IP.parse("10.0.0.1").loopback?  # => false
+		// ```
+		//
+		// @return [Boolean]
+		Name: "loopback?",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			return toBooleanObject(receiver.(*IPObject).addr.Unmap().IsLoopback())
+
+		},
+	},
+	{
+		// Returns true if the address is in the private ranges of RFC 1918 for IPv4 or RFC 4193 for IPv6.
+		//
+		// ```ruby
+		// Net::IP.parse("192.168.0.1").private? # => true
+		// Net::IP.parse("fd00::1").private?     # => true
+		// Net::IP.parse("8.8.8.8").private?     # => false
+		// ```
+		//
+		// @return [Boolean]
+		Name: "private?",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			return toBooleanObject(receiver.(*IPObject).addr.Unmap().IsPrivate())
+
+		},
+	},
+	{
+		// Returns the address as a String.
+		//
+		// ```ruby
+		// Net::IP.parse("2001:DB8::1").to_s # => "2001:db8::1"
+		// ```
+		//
+		// @return [String]
+		Name: "to_s",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			return t.vm.InitStringObject(receiver.(*IPObject).ToString())
+
+		},
+	},
+	{
+		// Returns 4 for an IPv4 address, and 6 for an IPv6 address, including an IPv4-mapped one.
+		//
+		// ```ruby
+		// Net::IP.parse("127.0.0.1").version # => 4
+		// Net::IP.parse("::1").version       # => 6
+		// ```
+		//
+		// @return [Integer]
+		Name: "version",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			if receiver.(*IPObject).addr.Is4() {
+				return t.vm.InitIntegerObject(4)
+			}
+
+			return t.vm.InitIntegerObject(6)
+
+		},
+	},
+}
+
+// Internal functions ===================================================
+
+// Functions for initialization -----------------------------------------
+
+func initIPClass(vm *VM, netModule *RClass) {
+	ip := vm.initializeClass("IP")
+	ip.setBuiltinMethods(builtinIPClassMethods, true)
+	ip.setBuiltinMethods(builtinIPInstanceMethods, false)
+	netModule.setClassConstant(ip)
+}
+
+// Polymorphic helper functions -----------------------------------------
+
+// Value returns the address as a netip.Addr
+func (ip *IPObject) Value() interface{} {
+	return ip.addr
+}
+
+// ToString returns the address in its canonical form
+func (ip *IPObject) ToString() string {
+	return ip.addr.String()
+}
+
+// Inspect delegates to ToString
+func (ip *IPObject) Inspect() string {
+	return ip.ToString()
+}
+
+// ToJSON returns the address as a JSON string
+func (ip *IPObject) ToJSON(t *Thread) string {
+	return quoteJSON(ip.ToString())
+}
+
+// Addresses are equal if they're the same address
+func (ip *IPObject) equalTo(with Object) bool {
+	w, ok := with.(*IPObject)
+	return ok && ip.addr == w.addr
+}
@@ -0,0 +1,124 @@
+package vm
+
+import (
+	"testing"
+)
+
+func TestIPParseMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`Net::IP.parse("192.168.0.1").to_s`, "192.168.0.1"},
+		{`Net::IP.parse("2001:DB8::1").to_s`, "2001:db8::1"},
+		{`Net::IP.parse("10.0.0.1").class.name`, "IP"},
+		{`Net::IP.parse("10.0.0.1") == Net::IP.parse("10.0.0.1")`, true},
+		{`Net::IP.parse("10.0.0.1") == Net::IP.parse("10.0.0.2")`, false},
+		{`Net::IP.parse("10.0.0.1").to_json`, `"10.0.0.1"`},
+		{`Net::IP.parse("127.0.0.1").version`, 4},
+		{`Net::IP.parse("::1").version`, 6},
+		{`Net::IP.parse("::ffff:127.0.0.1").version`, 6},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, "require \"net/dns\"\n"+tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestIPParseMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`Net::IP.parse("localhost")`, "ArgumentError: Invalid IP address: localhost", 1},
+		{`Net::IP.parse("10.0.0.256")`, "ArgumentError: Invalid IP address: 10.0.0.256", 1},
+		{`Net::IP.parse("10.0.0.0/8")`, "ArgumentError: Invalid IP address: 10.0.0.0/8", 1},
+		{`Net::IP.parse(1)`, "TypeError: Expect argument to be String. got: Integer", 1},
+		{`Net::IP.parse`, "ArgumentError: Expect 1 argument(s). got: 0", 1},
+		{`Net::IP.new`, "NoMethodError: Undefined Method 'new' for IP", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, "require \"net/dns\"\n"+tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestIPPredicates(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`Net::IP.parse("10.1.2.3").private?`, true},
+		{`Net::IP.parse("172.16.0.1").private?`, true},
+		{`Net::IP.parse("172.32.0.1").private?`, false},
+		{`Net::IP.parse("192.168.10.1").private?`, true},
+		{`Net::IP.parse("8.8.8.8").private?`, false},
+		{`Net::IP.parse("fd00::1").private?`, true},
+		{`Net::IP.parse("2001:db8::1").private?`, false},
+		{`Net::IP.parse("::ffff:192.168.0.1").private?`, true},
+		{`Net::IP.parse("127.0.0.1").loopback?`, true},
+		{`Net::IP.parse("127.255.0.1").loopback?`, true},
+		{`Net::IP.parse("::1").loopback?`, true},
+		{`Net::IP.parse("10.0.0.1").loopback?`, false},
+		{`Net::IP.parse("::2").loopback?`, false},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, "require \"net/dns\"\n"+tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestIPInSubnetMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`Net::IP.parse("10.1.2.3").in_subnet?("10.0.0.0/8")`, true},
+		{`Net::IP.parse("10.1.2.3").in_subnet?("10.1.2.0/24")`, true},
+		{`Net::IP.parse("10.1.2.3").in_subnet?("10.1.3.0/24")`, false},
+		{`Net::IP.parse("10.1.2.3").in_subnet?("10.1.2.3/32")`, true},
+		{`Net::IP.parse("10.1.2.3").in_subnet?("0.0.0.0/0")`, true},
+		{`Net::IP.parse("192.168.1.1").in_subnet?("10.0.0.0/8")`, false},
+		{`Net::IP.parse("2001:db8::1").in_subnet?("2001:db8::/32")`, true},
+		{`Net::IP.parse("2001:db9::1").in_subnet?("2001:db8::/32")`, false},
+		// an address of one version is never in a subnet of the other, unless it's an IPv4-mapped address
+		{`Net::IP.parse("10.1.2.3").in_subnet?("::/0")`, false},
+		{`Net::IP.parse("::1").in_subnet?("0.0.0.0/0")`, false},
+		{`Net::IP.parse("::ffff:10.1.2.3").in_subnet?("10.0.0.0/8")`, true},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, "require \"net/dns\"\n"+tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestIPInSubnetMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`Net::IP.parse("10.1.2.3").in_subnet?("10.0.0.0")`, "ArgumentError: Invalid CIDR: 10.0.0.0", 1},
+		{`Net::IP.parse("10.1.2.3").in_subnet?("10.0.0.0/33")`, "ArgumentError: Invalid CIDR: 10.0.0.0/33", 1},
+		{`Net::IP.parse("10.1.2.3").in_subnet?(8)`, "TypeError: Expect argument to be String. got: Integer", 1},
+		{`Net::IP.parse("10.1.2.3").in_subnet?`, "ArgumentError: Expect 1 argument(s). got: 0", 1},
+		{`Net::IP.parse("10.1.2.3").private?(1)`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, "require \"net/dns\"\n"+tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
@@ -1,7 +1,12 @@
 package vm
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
 
 	"github.com/goby-lang/goby/vm/classes"
 	"github.com/goby-lang/goby/vm/errors"
@@ -9,6 +14,13 @@ import (
 
 type jsonObj map[string]interface{}
 
+const cantConvertToJSON = "Can't convert %s at %s to JSON"
+
+// jsonRootPath names the converted object in the paths of strict mode errors, like `obj.items[3].conn`
+const jsonRootPath = "obj"
+
+var jsonIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
 // Class methods --------------------------------------------------------
 var builtinJSONClassMethods = []*BuiltinMethodObject{
 	{
@@ -26,6 +38,10 @@ var builtinJSONClassMethods = []*BuiltinMethodObject{
 
 			jsonString := args[0].Value().(string)
 
+			if err := t.reserveMemory(multipliedSize(len(jsonString), jsonExpansion), "JSON.parse", sourceLine); err != nil {
+				return err
+			}
+
 			var obj jsonObj
 			var objs []jsonObj
 
@@ -148,3 +164,165 @@ func (v *VM) convertJSONToHashObj(j jsonObj) Object {
 
 	return v.InitHashObject(objectMap)
 }
+
+// JSONSerializer renders the given object as a JSON string.
+// Hosts can register one per class with `VM.RegisterJSONSerializer` to override the class's default JSON format.
+type JSONSerializer func(t *Thread, obj Object) string
+
+// RegisterJSONSerializer makes the VM render instances of the given class with the serializer
+// whenever they're converted to JSON, including when they're nested in an Array or a Hash.
+// Only instances of that exact class are affected; subclasses keep their own format.
+func (vm *VM) RegisterJSONSerializer(className string, serializer JSONSerializer) {
+	vm.jsonSerializers.Store(className, serializer)
+}
+
+// SetStrictJSON makes `to_json` raise a TypeError when the object contains a value JSON can't represent,
+// like a Channel, a GoObject or a Method, instead of writing a string describing it.
+// The error names the path to the value from the converted object, like `obj.items[3].conn`.
+func (vm *VM) SetStrictJSON(strict bool) {
+	vm.strictJSON = strict
+}
+
+// toJSON renders the object with the serializer registered for its class, or with its own ToJSON if there's none
+func (t *Thread) toJSON(obj Object) string {
+	e := &jsonEncoder{t: t}
+	return e.encode(obj, jsonRootPath)
+}
+
+// encodeJSON is toJSON for the `to_json` methods: it returns the JSON as a String,
+// or a TypeError if the VM is in strict mode and the object contains a value JSON can't represent
+func (t *Thread) encodeJSON(obj Object, sourceLine int) Object {
+	e := &jsonEncoder{t: t, sourceLine: sourceLine}
+	result := e.encode(obj, jsonRootPath)
+
+	if e.err != nil {
+		return e.err
+	}
+
+	return t.vm.InitStringObject(result)
+}
+
+// jsonEncoder renders nested objects as JSON, keeping track of the path to each value for strict mode errors
+type jsonEncoder struct {
+	t          *Thread
+	sourceLine int
+	// err is the error for the first value strict mode rejects
+	err *Error
+}
+
+func (e *jsonEncoder) encode(obj Object, path string) string {
+	if serializer, ok := e.t.vm.jsonSerializers.Load(obj.Class().Name); ok {
+		return serializer.(JSONSerializer)(e.t, obj)
+	}
+
+	switch o := obj.(type) {
+	case *ArrayObject:
+		return e.encodeElements(o.Elements, path)
+	case *ConcurrentArrayObject:
+		return e.encodeElements(o.snapshot(), path)
+	case *HashObject:
+		return e.encodePairs(o.Pairs, path)
+	case *ConcurrentHashObject:
+		return e.encodePairs(o.pairs(), path)
+	case *RObject:
+		if o.hasCustomToJSON() {
+			return o.ToJSON(e.t)
+		}
+
+		return e.encodeInstanceVariables(o, path)
+	case *FloatObject:
+		if math.IsInf(o.value, 0) || math.IsNaN(o.value) {
+			e.reject(obj, path)
+		}
+
+		return o.ToJSON(e.t)
+	case *ChannelObject, *GoObject, *GoMap, *MethodObject, *BuiltinMethodObject, *BlockObject, *FileObject,
+		*ConcurrentFutureObject, *ConcurrentRWLockObject, *Error, *RClass, *RangeObject:
+		e.reject(obj, path)
+		return o.ToJSON(e.t)
+	default:
+		return obj.ToJSON(e.t)
+	}
+}
+
+// reject records the error for a value JSON can't represent, if the VM is in strict mode
+func (e *jsonEncoder) reject(obj Object, path string) {
+	if e.t.vm.strictJSON && e.err == nil {
+		e.err = e.t.vm.InitErrorObject(errors.TypeError, e.sourceLine, cantConvertToJSON, obj.Class().Name, path)
+	}
+}
+
+func (e *jsonEncoder) encodeElements(elems []Object, path string) string {
+	var out bytes.Buffer
+	elements := []string{}
+
+	for i, elem := range elems {
+		elements = append(elements, e.encode(elem, fmt.Sprintf("%s[%d]", path, i)))
+	}
+
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+
+	return out.String()
+}
+
+func (e *jsonEncoder) encodePairs(pairs map[string]Object, path string) string {
+	var values []string
+
+	for key, value := range pairs {
+		values = append(values, e.encodePair(key, value, path))
+	}
+
+	return "{" + strings.Join(values, ",") + "}"
+}
+
+// encodeInstanceVariables renders the object's instance variables, without the `@` prefix.
+// Only the instance variables listed with `json_attributes` are included if the class declares them.
+func (e *jsonEncoder) encodeInstanceVariables(ro *RObject, path string) string {
+	names := ro.class.lookupJSONAttributes()
+
+	if names == nil {
+		names = ro.InstanceVariables.names()
+	}
+
+	values := []string{}
+
+	for _, name := range names {
+		var value Object = NULL
+
+		if v, ok := ro.InstanceVariableGet(name); ok {
+			value = v
+		}
+
+		values = append(values, e.encodePair(strings.TrimPrefix(name, "@"), value, path))
+	}
+
+	return "{" + strings.Join(values, ",") + "}"
+}
+
+func (e *jsonEncoder) encodePair(key string, value Object, path string) string {
+	if jsonIdentifier.MatchString(key) {
+		path += "." + key
+	} else {
+		path += fmt.Sprintf("[%q]", key)
+	}
+
+	return quoteJSON(key) + ":" + e.encode(value, path)
+}
+
+// quoteJSON returns the string as a JSON string literal
+func quoteJSON(s string) string {
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	// Encoding a string never fails
+	encoder.Encode(s)
+
+	return strings.TrimSuffix(out.String(), "\n")
+}
+
+// unsupportedJSON is the JSON of the objects JSON can't represent: a string like "#<Channel>"
+func unsupportedJSON(obj Object) string {
+	return quoteJSON("#<" + obj.Class().Name + ">")
+}
@@ -1,6 +1,10 @@
 package vm
 
-import "testing"
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
 
 func TestJSONValidateMethod(t *testing.T) {
 	tests := []struct {
@@ -160,3 +164,180 @@ func TestJSONObjectArrayParsing(t *testing.T) {
 		v.checkSP(t, i, 1)
 	}
 }
+
+func TestJSONCustomSerializer(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		{ a: [1, 2] }.to_json
+		`, `{"a":["int:1", "int:2"]}`},
+		{`
+		{ a: "1" }.to_json
+		`, `{"a":"1"}`},
+		{`
+		class Point
+		  def initialize(x, y)
+		    @x = x
+		    @y = y
+		  end
+		end
+
+		{ p: Point.new(1, 2) }.to_json
+		`, `{"p":"(1, 2)"}`},
+		{`
+		class Point
+		  def initialize(x, y)
+		    @x = x
+		    @y = y
+		  end
+		end
+
+		class Point3D < Point
+		  def to_json
+		    "{}"
+		  end
+		end
+
+		{ p: Point3D.new(1, 2) }.to_json
+		`, `{"p":{}}`},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		v.RegisterJSONSerializer("Integer", func(t *Thread, obj Object) string {
+			return `"int:` + obj.ToString() + `"`
+		})
+		v.RegisterJSONSerializer("Point", func(t *Thread, obj Object) string {
+			p := obj.(*RObject)
+			x, _ := p.InstanceVariableGet("@x")
+			y, _ := p.InstanceVariableGet("@y")
+			return `"(` + x.ToString() + `, ` + y.ToString() + `)"`
+		})
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestJSONUnsupportedValues(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		{ c: Channel.new, g: "a".to_bytes, m: GoMap.new, k: String, r: (1..3) }.to_json
+		`, map[string]interface{}{"c": "#<Channel>", "g": "#<GoObject>", "m": "#<GoMap>", "k": "String", "r": "(1..3)"}},
+		{`
+		[Block.new do
+		end, [Channel.new], { s: "a\"b" }, Regexp.new("\\d+")].to_json
+		`, []interface{}{"#<Block>", []interface{}{"#<Channel>"}, map[string]interface{}{"s": `a"b`}, `\d+`}},
+		{`
+		class Conn
+		  def initialize
+		    @chan = Channel.new
+		  end
+		end
+
+		h = { conn: Conn.new }
+		h["x-y"] = 'a1b'.match(Regexp.new('(a.)'))
+		h.to_json
+		`, map[string]interface{}{
+			"conn": map[string]interface{}{"chan": "#<Channel>"},
+			"x-y":  map[string]interface{}{"0": "a1", "1": "a1"},
+		}},
+		{`
+		require 'concurrent/array'
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: Concurrent::Array.new([Channel.new]) }).to_json
+		`, map[string]interface{}{"a": []interface{}{"#<Channel>"}}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+
+		var decoded interface{}
+
+		if err := json.Unmarshal([]byte(evaluated.ToString()), &decoded); err != nil {
+			t.Fatalf("At test case %d: can't parse %s: %s", i, evaluated.ToString(), err)
+		}
+
+		if !reflect.DeepEqual(decoded, tt.expected) {
+			t.Errorf("At test case %d: expect %#v. got: %#v", i, tt.expected, decoded)
+		}
+
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestJSONStrictMode(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`{ a: [1, "2", nil, true, 1.5, { b: "c" }] }.to_json`, `{"a":[1, "2", null, true, 1.5, {"b":"c"}]}`},
+		{`
+		class Point
+		  def initialize(x, y)
+		    @x = x
+		    @y = y
+		  end
+		end
+
+		Point.new(1, [2]).to_json
+		`, `{"x":1,"y":[2]}`},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		v.SetStrictJSON(true)
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestJSONStrictModeFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`Channel.new.to_json`, "TypeError: Can't convert Channel at obj to JSON", 1},
+		{`
+		class Item
+		  def initialize(conn)
+		    @conn = conn
+		  end
+		end
+
+		class Order
+		  def initialize
+		    @items = [Item.new(1), Item.new(2), Item.new(3), Item.new(Channel.new)]
+		  end
+		end
+
+		Order.new.to_json
+		`, "TypeError: Can't convert Channel at obj.items[3].conn to JSON", 1},
+		{`
+		h = { a: 1 }
+		h["x-y"] = [(1..2)]
+		h.to_json
+		`, "TypeError: Can't convert Range at obj[\"x-y\"][0] to JSON", 1},
+		{`
+		require 'concurrent/hash'
+		Concurrent::Hash.new({ a: { b: "a".to_bytes } }).to_json
+		`, "TypeError: Can't convert GoObject at obj.a.b to JSON", 1},
+		{`[String].to_json`, "TypeError: Can't convert Class at obj[0] to JSON", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		v.SetStrictJSON(true)
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
@@ -0,0 +1,561 @@
+package vm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/goby-lang/goby/vm/classes"
+	"github.com/goby-lang/goby/vm/errors"
+)
+
+const (
+	marshalVersion        = 1
+	cantDump              = "Can't dump %s"
+	marshalDataTooShort   = "Marshal data too short"
+	invalidMarshalData    = "Invalid marshal data: %s"
+	undefinedMarshalClass = "Undefined class %s in marshal data"
+)
+
+// marshalHeader starts every dump, so data of another format or version is rejected by `Marshal.load`
+var marshalHeader = []byte{'G', 'M', marshalVersion}
+
+// The tags marking the type of each value in a dump
+const (
+	marshalNil     = '0'
+	marshalTrue    = 'T'
+	marshalFalse   = 'F'
+	marshalInteger = 'i'
+	marshalFloat   = 'f'
+	marshalString  = 's'
+	marshalArray   = '['
+	marshalHash    = '{'
+	// marshalHashWithDefault is a hash followed by its default value
+	marshalHashWithDefault = '}'
+	marshalObject          = 'o'
+	// marshalLink refers to a String, Array, Hash or object dumped earlier, by the order it was dumped in
+	marshalLink = '@'
+)
+
+// Marshal converts objects to a compact binary String and back.
+// It supports `nil`, booleans, Integers, Floats, Strings, Arrays, Hashes and instances of classes defined in Goby.
+// An object appearing more than once is dumped once and referred to afterwards, so the loaded objects are shared the same way,
+// and cyclic structures can be dumped.
+//
+// ```ruby
+// data = Marshal.dump({ name: "goby", tags: ["lang", "vm"] })
+// Marshal.load(data) # => { name: "goby", tags: ["lang", "vm"] }
+//
+// a = [1]
+// a.push(a)
+// b = Marshal.load(Marshal.dump(a))
+// b[1].object_id == b.object_id # => true
+// ```
+//
+// Objects are loaded without calling `initialize`, the instance variables are set directly.
+// The class of an object is looked up by its name when loading, so it should be defined by then.
+//
+// ```ruby
+// class Point
+//   def initialize(x, y)
+//     @x = x
+//     @y = y
+//   end
+// end
+//
+// Marshal.load(Marshal.dump(Point.new(1, 2))) # => #<Point:... @x=1, @y=2>
+// ```
+
+// Class methods --------------------------------------------------------
+var builtinMarshalClassMethods = []*BuiltinMethodObject{
+	{
+		// Returns the object dumped as a binary String.
+		// It's a TypeError if the object, or an object it contains, can't be dumped, like a Channel or a Block.
+		//
+		// ```ruby
+		// Marshal.dump([1, "a", nil]).class # => String
+		// Marshal.dump(Channel.new)         # => TypeError: Can't dump Channel
+		// ```
+		//
+		// @param object [Object]
+		// @return [String]
+		Name: "dump",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			e := &marshalEncoder{links: map[Object]int{}}
+			e.out.Write(marshalHeader)
+
+			if unsupported := e.encode(args[0]); unsupported != nil {
+				return t.vm.InitErrorObject(errors.TypeError, sourceLine, cantDump, unsupported.Class().Name)
+			}
+
+			return t.vm.InitStringObject(e.out.String())
+
+		},
+	},
+	{
+		// Returns the object dumped in the String by `Marshal.dump`.
+		// It's an ArgumentError if the String isn't a dump, or if it refers to a class that isn't defined.
+		//
+		// ```ruby
+		// Marshal.load(Marshal.dump([1, 2.5, "a"])) # => [1, 2.5, "a"]
+		// Marshal.load("foo")                       # => ArgumentError: Invalid marshal data: unknown format
+		// ```
+		//
+		// @param data [String]
+		// @return [Object]
+		Name: "load",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			typeErr := t.vm.checkArgTypes(args, sourceLine, classes.StringClass)
+
+			if typeErr != nil {
+				return typeErr
+			}
+
+			data := args[0].(*StringObject).value
+
+			if len(data) < len(marshalHeader) {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, marshalDataTooShort)
+			}
+
+			if data[:len(marshalHeader)] != string(marshalHeader) {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, invalidMarshalData, "unknown format")
+			}
+
+			d := &marshalDecoder{t: t, sourceLine: sourceLine, data: data[len(marshalHeader):]}
+			obj := d.decode()
+
+			if d.err == nil && d.pos != len(d.data) {
+				d.fail(invalidMarshalData, "trailing bytes")
+			}
+
+			if d.err != nil {
+				return d.err
+			}
+
+			return obj
+
+		},
+	},
+}
+
+// Internal functions ===================================================
+
+// Functions for initialization -----------------------------------------
+
+func (vm *VM) initMarshalModule() *RClass {
+	module := vm.initializeModule(classes.MarshalModule)
+	module.setBuiltinMethods(builtinMarshalClassMethods, true)
+	return module
+}
+
+// Other helper functions -----------------------------------------------
+
+// marshalEncoder writes objects in the `Marshal` format, remembering the objects it has written so they're written once
+type marshalEncoder struct {
+	out   bytes.Buffer
+	links map[Object]int
+}
+
+// encode writes the object, and returns the first object it can't write, if any
+func (e *marshalEncoder) encode(obj Object) Object {
+	switch o := obj.(type) {
+	case *NullObject:
+		e.out.WriteByte(marshalNil)
+		return nil
+	case *BooleanObject:
+		if o.value {
+			e.out.WriteByte(marshalTrue)
+		} else {
+			e.out.WriteByte(marshalFalse)
+		}
+
+		return nil
+	case *IntegerObject:
+		e.out.WriteByte(marshalInteger)
+		e.writeVarint(int64(o.value))
+		return nil
+	case *FloatObject:
+		var buf [8]byte
+		binary.BigEndian.PutUint64(buf[:], math.Float64bits(o.value))
+		e.out.WriteByte(marshalFloat)
+		e.out.Write(buf[:])
+		return nil
+	}
+
+	if i, ok := e.links[obj]; ok {
+		e.out.WriteByte(marshalLink)
+		e.writeUvarint(uint64(i))
+		return nil
+	}
+
+	switch o := obj.(type) {
+	case *StringObject:
+		e.link(o)
+		e.out.WriteByte(marshalString)
+		e.writeString(o.value)
+	case *ArrayObject:
+		e.link(o)
+		e.out.WriteByte(marshalArray)
+		e.writeUvarint(uint64(len(o.Elements)))
+
+		for _, elem := range o.Elements {
+			if unsupported := e.encode(elem); unsupported != nil {
+				return unsupported
+			}
+		}
+	case *HashObject:
+		e.link(o)
+
+		if o.Default != nil {
+			e.out.WriteByte(marshalHashWithDefault)
+		} else {
+			e.out.WriteByte(marshalHash)
+		}
+
+		keys := make([]string, 0, len(o.Pairs))
+
+		for key := range o.Pairs {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+		e.writeUvarint(uint64(len(keys)))
+
+		for _, key := range keys {
+			e.writeString(key)
+
+			if unsupported := e.encode(o.Pairs[key]); unsupported != nil {
+				return unsupported
+			}
+		}
+
+		if o.Default != nil {
+			return e.encode(o.Default)
+		}
+	case *RObject:
+		path, ok := marshalClassPath(o.class)
+
+		if !ok {
+			return o
+		}
+
+		e.link(o)
+		e.out.WriteByte(marshalObject)
+		e.writeString(path)
+
+		names := o.InstanceVariables.names()
+		e.writeUvarint(uint64(len(names)))
+
+		for _, name := range names {
+			value, _ := o.InstanceVariableGet(name)
+			e.writeString(name)
+
+			if unsupported := e.encode(value); unsupported != nil {
+				return unsupported
+			}
+		}
+	default:
+		return obj
+	}
+
+	return nil
+}
+
+// link remembers the object, which is written next, so later appearances refer to it
+func (e *marshalEncoder) link(obj Object) {
+	e.links[obj] = len(e.links)
+}
+
+func (e *marshalEncoder) writeVarint(n int64) {
+	var buf [binary.MaxVarintLen64]byte
+	e.out.Write(buf[:binary.PutVarint(buf[:], n)])
+}
+
+func (e *marshalEncoder) writeUvarint(n uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	e.out.Write(buf[:binary.PutUvarint(buf[:], n)])
+}
+
+func (e *marshalEncoder) writeString(s string) {
+	e.writeUvarint(uint64(len(s)))
+	e.out.WriteString(s)
+}
+
+// marshalDecoder reads objects written by marshalEncoder, keeping the objects read so far to resolve links
+type marshalDecoder struct {
+	t          *Thread
+	sourceLine int
+	data       string
+	pos        int
+	links      []Object
+	// err is the error for the first malformed value
+	err *Error
+}
+
+// decode reads the next object. It returns NULL after an error, which is kept in d.err.
+func (d *marshalDecoder) decode() Object {
+	tag, ok := d.readByte()
+
+	if !ok {
+		return NULL
+	}
+
+	switch tag {
+	case marshalNil:
+		return NULL
+	case marshalTrue:
+		return TRUE
+	case marshalFalse:
+		return FALSE
+	case marshalInteger:
+		n, ok := d.readVarint()
+
+		if !ok {
+			return NULL
+		}
+
+		if n < math.MinInt || n > math.MaxInt {
+			d.fail(invalidMarshalData, "integer out of range")
+			return NULL
+		}
+
+		return d.t.vm.InitIntegerObject(int(n))
+	case marshalFloat:
+		if len(d.data)-d.pos < 8 {
+			d.fail(marshalDataTooShort)
+			return NULL
+		}
+
+		bits := binary.BigEndian.Uint64([]byte(d.data[d.pos : d.pos+8]))
+		d.pos += 8
+		return d.t.vm.initFloatObject(math.Float64frombits(bits))
+	case marshalString:
+		s, ok := d.readString()
+
+		if !ok {
+			return NULL
+		}
+
+		return d.link(d.t.vm.InitStringObject(s))
+	case marshalArray:
+		n, ok := d.readLength()
+
+		if !ok {
+			return NULL
+		}
+
+		arr := d.t.vm.InitArrayObject(make([]Object, 0, n))
+		d.link(arr)
+
+		for i := 0; i < n && d.err == nil; i++ {
+			arr.Elements = append(arr.Elements, d.decode())
+		}
+
+		return arr
+	case marshalHash, marshalHashWithDefault:
+		n, ok := d.readLength()
+
+		if !ok {
+			return NULL
+		}
+
+		hash := d.t.vm.InitHashObject(make(map[string]Object, n))
+		d.link(hash)
+
+		for i := 0; i < n && d.err == nil; i++ {
+			key, ok := d.readString()
+
+			if !ok {
+				return NULL
+			}
+
+			hash.Pairs[key] = d.decode()
+		}
+
+		if tag == marshalHashWithDefault && d.err == nil {
+			hash.Default = d.decode()
+		}
+
+		return hash
+	case marshalObject:
+		path, ok := d.readString()
+
+		if !ok {
+			return NULL
+		}
+
+		class := d.lookupClass(path)
+
+		if class == nil {
+			return NULL
+		}
+
+		obj := class.initializeInstance()
+		d.link(obj)
+
+		n, ok := d.readLength()
+
+		if !ok {
+			return NULL
+		}
+
+		for i := 0; i < n && d.err == nil; i++ {
+			name, ok := d.readString()
+
+			if !ok {
+				return NULL
+			}
+
+			obj.InstanceVariableSet(name, d.decode())
+		}
+
+		return obj
+	case marshalLink:
+		i, ok := d.readUvarint()
+
+		if !ok {
+			return NULL
+		}
+
+		if i >= uint64(len(d.links)) {
+			d.fail(invalidMarshalData, "bad link")
+			return NULL
+		}
+
+		return d.links[i]
+	default:
+		d.fail(invalidMarshalData, fmt.Sprintf("unknown type %q", tag))
+		return NULL
+	}
+}
+
+// lookupClass returns the class of the path like `Foo::Bar`, or nil after recording an error if there's no such class
+func (d *marshalDecoder) lookupClass(path string) *RClass {
+	scope := d.t.vm.objectClass
+
+	for _, name := range strings.Split(path, "::") {
+		ptr := scope.lookupConstantInCurrentScope(name)
+
+		if ptr == nil {
+			d.fail(undefinedMarshalClass, path)
+			return nil
+		}
+
+		class, ok := ptr.Target.(*RClass)
+
+		if !ok {
+			d.fail(undefinedMarshalClass, path)
+			return nil
+		}
+
+		scope = class
+	}
+
+	if scope.isModule {
+		d.fail(undefinedMarshalClass, path)
+		return nil
+	}
+
+	return scope
+}
+
+func (d *marshalDecoder) link(obj Object) Object {
+	d.links = append(d.links, obj)
+	return obj
+}
+
+func (d *marshalDecoder) fail(format string, args ...interface{}) {
+	if d.err == nil {
+		d.err = d.t.vm.InitErrorObject(errors.ArgumentError, d.sourceLine, format, args...)
+	}
+}
+
+func (d *marshalDecoder) readByte() (byte, bool) {
+	if d.pos >= len(d.data) {
+		d.fail(marshalDataTooShort)
+		return 0, false
+	}
+
+	b := d.data[d.pos]
+	d.pos++
+	return b, true
+}
+
+func (d *marshalDecoder) readVarint() (int64, bool) {
+	n, size := binary.Varint([]byte(d.data[d.pos:]))
+
+	if size <= 0 {
+		d.fail(marshalDataTooShort)
+		return 0, false
+	}
+
+	d.pos += size
+	return n, true
+}
+
+func (d *marshalDecoder) readUvarint() (uint64, bool) {
+	n, size := binary.Uvarint([]byte(d.data[d.pos:]))
+
+	if size <= 0 {
+		d.fail(marshalDataTooShort)
+		return 0, false
+	}
+
+	d.pos += size
+	return n, true
+}
+
+// readLength reads the size of a collection, which can't be more than the bytes left since every element takes one at least
+func (d *marshalDecoder) readLength() (int, bool) {
+	n, ok := d.readUvarint()
+
+	if !ok {
+		return 0, false
+	}
+
+	if n > uint64(len(d.data)-d.pos) {
+		d.fail(marshalDataTooShort)
+		return 0, false
+	}
+
+	return int(n), true
+}
+
+func (d *marshalDecoder) readString() (string, bool) {
+	n, ok := d.readLength()
+
+	if !ok {
+		return "", false
+	}
+
+	s := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return s, true
+}
+
+// marshalClassPath returns the name of the class with the names of the classes and modules it's defined in, like `Foo::Bar`.
+// It returns false for classes that can't be looked up by name.
+func marshalClassPath(c *RClass) (string, bool) {
+	if c.isSingleton || c.Name == "" {
+		return "", false
+	}
+
+	names := []string{c.Name}
+
+	for scope := c.scope; scope != nil && scope.Name != classes.ObjectClass; scope = scope.scope {
+		names = append([]string{scope.Name}, names...)
+	}
+
+	return strings.Join(names, "::"), true
+}
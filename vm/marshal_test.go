@@ -0,0 +1,160 @@
+package vm
+
+import (
+	"testing"
+)
+
+const marshalPointClass = `
+module Geometry
+  class Point
+    attr_reader :x, :y
+
+    def initialize(x, y)
+      @x = x
+      @y = y
+    end
+  end
+end
+`
+
+func TestMarshalRoundTrip(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`Marshal.load(Marshal.dump(nil))`, nil},
+		{`Marshal.load(Marshal.dump(true))`, true},
+		{`Marshal.load(Marshal.dump(false))`, false},
+		{`Marshal.load(Marshal.dump(0))`, 0},
+		{`Marshal.load(Marshal.dump(-123456789))`, -123456789},
+		{`Marshal.load(Marshal.dump(2.5))`, 2.5},
+		{`Marshal.load(Marshal.dump(-0.125))`, -0.125},
+		{`Marshal.load(Marshal.dump(""))`, ""},
+		{`Marshal.load(Marshal.dump("Hello, 世界"))`, "Hello, 世界"},
+		{`Marshal.dump([1, "a"]).class.name`, "String"},
+		{`Marshal.dump({ b: 1, a: 2 }) == Marshal.dump({ a: 2, b: 1 })`, true},
+		{`Marshal.load(Marshal.dump([1, [["a", nil], 2.5], { a: [] }])).to_s`, `[1, [["a", nil], 2.5], { a: [] }]`},
+		{`
+		h = Marshal.load(Marshal.dump({ name: "goby", tags: ["lang", "vm"], meta: { stars: 3, ok: true } }))
+		[h[:name], h[:tags], h[:meta][:stars], h[:meta][:ok]]
+		`, []interface{}{"goby", []interface{}{"lang", "vm"}, 3, true}},
+		// the default value of a hash is kept
+		{`
+		h = {}
+		h.default = 0
+		Marshal.load(Marshal.dump(h))[:missing]
+		`, 0},
+		// the loaded objects are new ones
+		{`
+		a = ["a"]
+		b = Marshal.load(Marshal.dump(a))
+		b[0].concat("b")
+		[a[0], b[0], a.object_id == b.object_id]
+		`, []interface{}{"a", "ab", false}},
+		// an object dumped twice is loaded as one object
+		{`
+		s = "shared"
+		a = Marshal.load(Marshal.dump([s, s, "shared"]))
+		[a[0].object_id == a[1].object_id, a[0].object_id == a[2].object_id]
+		`, []interface{}{true, false}},
+		// cyclic structures
+		{`
+		a = [1]
+		a.push(a)
+		b = Marshal.load(Marshal.dump(a))
+		[b[0], b[1].object_id == b.object_id]
+		`, []interface{}{1, true}},
+		{`
+		h = { name: "parent" }
+		child = { parent: h }
+		h[:children] = [child]
+		r = Marshal.load(Marshal.dump(h))
+		r[:children][0][:parent].object_id == r.object_id
+		`, true},
+		{marshalPointClass + `
+		p = Marshal.load(Marshal.dump(Geometry::Point.new(1, "b")))
+		[p.class.name, p.x, p.y]
+		`, []interface{}{"Point", 1, "b"}},
+		{marshalPointClass + `
+		p = Geometry::Point.new(nil, nil)
+		q = Geometry::Point.new(p, [p])
+		r = Marshal.load(Marshal.dump(q))
+		[r.x.is_a?(Geometry::Point), r.x.object_id == r.y[0].object_id]
+		`, []interface{}{true, true}},
+		{marshalPointClass + `
+		p = Geometry::Point.new(1, nil)
+		p.instance_variable_set("@y", p)
+		r = Marshal.load(Marshal.dump(p))
+		r.y.y.object_id == r.object_id
+		`, true},
+		// objects are loaded without calling initialize
+		{`
+		class Counter
+		  def initialize
+		    @count = 0
+		  end
+
+		  def count
+		    @count
+		  end
+		end
+
+		c = Counter.new
+		c.instance_variable_set("@count", 5)
+		Marshal.load(Marshal.dump(c)).count
+		`, 5},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestMarshalDumpMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`Marshal.dump`, "ArgumentError: Expect 1 argument(s). got: 0", 1},
+		{`Marshal.dump(1, 2)`, "ArgumentError: Expect 1 argument(s). got: 2", 1},
+		{`Marshal.dump(Channel.new)`, "TypeError: Can't dump Channel", 1},
+		{`Marshal.dump([1, { a: 1..2 }])`, "TypeError: Can't dump Range", 1},
+		{`Marshal.dump(String)`, "TypeError: Can't dump Class", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestMarshalLoadMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`Marshal.load`, "ArgumentError: Expect 1 argument(s). got: 0", 1},
+		{`Marshal.load(1)`, "TypeError: Expect argument to be String. got: Integer", 1},
+		{`Marshal.load("")`, "ArgumentError: Marshal data too short", 1},
+		{`Marshal.load("foo")`, "ArgumentError: Invalid marshal data: unknown format", 1},
+		{`Marshal.load(Marshal.dump([1, 2]).slice(0, 5))`, "ArgumentError: Marshal data too short", 1},
+		{`Marshal.load(Marshal.dump(1) + "0")`, "ArgumentError: Invalid marshal data: trailing bytes", 1},
+		{marshalPointClass + `
+		data = Marshal.dump(Geometry::Point.new(1, 2))
+		Marshal.load(data.replace("Geometry", "Geometrx"))
+		`, "ArgumentError: Undefined class Geometrx::Point in marshal data", 1},
+		{marshalPointClass + `
+		data = Marshal.dump(Geometry::Point.new(1, 2))
+		Marshal.load(data.replace("Geometry::Point", "Geometry::Pount"))
+		`, "ArgumentError: Undefined class Geometry::Pount in marshal data", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
@@ -2,6 +2,7 @@ package vm
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/dlclark/regexp2"
 	"github.com/goby-lang/goby/vm/classes"
@@ -197,17 +198,15 @@ func (m *MatchDataObject) Inspect() string {
 	return m.ToString()
 }
 
-// ToJSON returns a `{ captureNumber: captureValue }` JSON-encoded string
+// ToJSON returns a `{"captureNumber": "captureValue"}` JSON-encoded string
 func (m *MatchDataObject) ToJSON(t *Thread) string {
-	result := "{"
+	var captures []string
 
 	for _, c := range m.match.Groups() {
-		result += fmt.Sprintf(" %s:\"%s\"", c.Name, c.String())
+		captures = append(captures, quoteJSON(c.Name)+":"+quoteJSON(c.String()))
 	}
 
-	result += "}"
-
-	return result
+	return "{" + strings.Join(captures, ",") + "}"
 }
 
 // equal checks if the string values between receiver and argument are equal
@@ -0,0 +1,231 @@
+package vm
+
+import (
+	"sync"
+
+	"github.com/goby-lang/goby/compiler/bytecode"
+	"github.com/goby-lang/goby/vm/classes"
+	"github.com/goby-lang/goby/vm/errors"
+)
+
+const (
+	unmemoizedPrefix  = "_unmemoized_"
+	cantMemoizeBlock  = "Can't memoize method %s, which takes a block"
+	cantCallMemoized  = "Can't pass a block to memoized method %s"
+	clearMemoizedName = "clear_memoized"
+)
+
+// memoTable holds an object's memoized results by method name and arguments.
+// The lock is held only to look up or add an entry, never while a result is computed,
+// so a memoized method can call other memoized methods of the same object.
+type memoTable struct {
+	sync.Mutex
+	methods map[string]map[string]*memoEntry
+}
+
+// memoEntry is a result that's computed once; the callers that find it while it's being computed wait for it
+type memoEntry struct {
+	done  chan struct{}
+	value Object
+	// thread is the one computing the result, which can't wait for itself
+	thread *Thread
+	failed bool
+}
+
+// fetch returns the cached result of the method for the arguments' key, or computes and caches it.
+// If the computation raises an error, nothing is cached and the callers waiting for it compute it again.
+func (m *memoTable) fetch(t *Thread, method, key string, compute func() Object) Object {
+	for {
+		m.Lock()
+
+		if m.methods == nil {
+			m.methods = map[string]map[string]*memoEntry{}
+		}
+
+		results, ok := m.methods[method]
+
+		if !ok {
+			results = map[string]*memoEntry{}
+			m.methods[method] = results
+		}
+
+		e, ok := results[key]
+
+		if !ok {
+			e = &memoEntry{done: make(chan struct{}), thread: t}
+			results[key] = e
+			m.Unlock()
+
+			return e.compute(m, method, key, compute)
+		}
+
+		m.Unlock()
+
+		select {
+		case <-e.done:
+		default:
+			// A method calling itself with the same arguments would wait forever
+			if e.thread == t {
+				return compute()
+			}
+
+			<-e.done
+		}
+
+		if !e.failed {
+			return e.value
+		}
+	}
+}
+
+func (e *memoEntry) compute(m *memoTable, method, key string, compute func() Object) Object {
+	defer close(e.done)
+
+	e.value = compute()
+
+	if _, ok := e.value.(*Error); ok {
+		e.failed = true
+
+		m.Lock()
+		if m.methods[method][key] == e {
+			delete(m.methods[method], key)
+		}
+		m.Unlock()
+	}
+
+	return e.value
+}
+
+// clear forgets the results of the given methods, or all of them if none is given
+func (m *memoTable) clear(methods ...string) {
+	m.Lock()
+	defer m.Unlock()
+
+	if len(methods) == 0 {
+		m.methods = nil
+		return
+	}
+
+	for _, method := range methods {
+		delete(m.methods, method)
+	}
+}
+
+// memoize replaces the class's method with one that caches its results, and keeps the original under a prefixed name for it to call
+func (t *Thread) memoize(c *RClass, name string, sourceLine int) *Error {
+	original := c.lookupMethod(name)
+
+	if original == nil {
+		return t.vm.InitErrorObject(errors.NameError, sourceLine, errors.UndefinedMethodForClass, name, c.Name)
+	}
+
+	// Memoizing a method again would cache the cached method
+	if c.lookupMethod(unmemoizedPrefix+name) != nil {
+		return nil
+	}
+
+	if takesBlock(original) {
+		return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, cantMemoizeBlock, name)
+	}
+
+	c.Methods.set(unmemoizedPrefix+name, original)
+	c.Methods.set(name, generateMemoizedMethod(c, name, methodVisibility(original)))
+
+	if c.lookupMethod(clearMemoizedName) == nil {
+		c.Methods.set(clearMemoizedName, clearMemoizedMethod.ownedBy(c))
+	}
+
+	return nil
+}
+
+// takesBlock returns true if the method yields or gets its block
+func takesBlock(method Object) bool {
+	m, ok := method.(*MethodObject)
+
+	if !ok {
+		return false
+	}
+
+	for _, i := range m.instructionSet.instructions {
+		if i.Opcode == bytecode.InvokeBlock || i.Opcode == bytecode.GetBlock {
+			return true
+		}
+	}
+
+	return false
+}
+
+func generateMemoizedMethod(owner *RClass, name string, v visibility) *BuiltinMethodObject {
+	return &BuiltinMethodObject{
+		Name:       name,
+		owner:      owner,
+		visibility: v,
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if blockFrame != nil {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, cantCallMemoized, name)
+			}
+
+			call := func() Object {
+				return t.callMethod(receiver, unmemoizedPrefix+name, sourceLine, args...)
+			}
+
+			// Only the instances of the classes defined in Goby have a cache
+			obj, ok := receiver.(*RObject)
+
+			if !ok {
+				return call()
+			}
+
+			// Arguments that are equal have the same key, even if they're different objects
+			key := Canonicalize(t.vm.InitArrayObject(args))
+
+			return obj.memo.fetch(t, name, key, call)
+		},
+	}
+}
+
+// clearMemoizedMethod is defined on the classes that memoize methods
+var clearMemoizedMethod = &BuiltinMethodObject{
+	// Forgets the cached results of the given memoized methods, or all of them if none is given,
+	// so they're computed again on the next calls.
+	//
+	// ```ruby
+	// class Report
+	//   def total
+	//     # expensive computation
+	//   end
+	//   memoize(:total)
+	// end
+	//
+	// r = Report.new
+	// r.total
+	// r.clear_memoized(:total)
+	// r.total # computed again
+	// ```
+	//
+	// @param *names [String] The method names
+	// @return [Object] self
+	Name: clearMemoizedName,
+	Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+		obj, ok := receiver.(*RObject)
+
+		if !ok {
+			return receiver
+		}
+
+		var names []string
+
+		for i, arg := range args {
+			name, ok := arg.(*StringObject)
+
+			if !ok {
+				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormatNum, i+1, classes.StringClass, arg.Class().Name)
+			}
+
+			names = append(names, name.value)
+		}
+
+		obj.memo.clear(names...)
+		return receiver
+	},
+}
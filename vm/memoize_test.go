@@ -0,0 +1,244 @@
+package vm
+
+import (
+	"testing"
+)
+
+func TestMemoize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		// concurrent first calls compute once for each set of arguments
+		{`
+		require 'concurrent/array'
+
+		class Foo
+		  def initialize
+		    @log = Concurrent::Array.new
+		  end
+
+		  def log
+		    @log
+		  end
+
+		  def square(n)
+		    @log.push(n)
+		    sleep(0.05)
+		    n * n
+		  end
+
+		  memoize(:square)
+		end
+
+		f = Foo.new
+		c = Channel.new
+
+		20.times do |i|
+		  thread do
+		    c.deliver(f.square(i % 3))
+		  end
+		end
+
+		r = 0
+		20.times do
+		  r = r + c.receive
+		end
+
+		[r, f.log.length]
+		`, []interface{}{31, 3}},
+		// nil and false are cached too
+		{`
+		class Foo
+		  def initialize
+		    @calls = 0
+		  end
+
+		  def calls
+		    @calls
+		  end
+
+		  def none
+		    @calls += 1
+		    nil
+		  end
+
+		  def no?
+		    @calls += 1
+		    false
+		  end
+
+		  memoize(:none, "no?")
+		end
+
+		f = Foo.new
+		[f.none, f.none, f.no?, f.no?, f.calls]
+		`, []interface{}{nil, nil, false, false, 2}},
+		// equal arguments share the result, even if they're different objects
+		{`
+		class Foo
+		  def initialize
+		    @calls = 0
+		  end
+
+		  def calls
+		    @calls
+		  end
+
+		  def total(items, opts)
+		    @calls += 1
+		    items.length
+		  end
+
+		  memoize(:total)
+		end
+
+		f = Foo.new
+		[f.total([1, 2], { a: 1 }), f.total([1, 2], { a: 1 }), f.total([1, 2, 3], { a: 1 }), f.total([1, 2], { a: 2 }), f.calls]
+		`, []interface{}{2, 2, 3, 2, 3}},
+		// each instance has its own results
+		{`
+		class Foo
+		  def initialize(n)
+		    @n = n
+		  end
+
+		  def n
+		    @n
+		  end
+
+		  memoize(:n)
+		end
+
+		[Foo.new(1).n, Foo.new(2).n]
+		`, []interface{}{1, 2}},
+		{`
+		class Foo
+		  def initialize
+		    @calls = 0
+		  end
+
+		  def calls
+		    @calls
+		  end
+
+		  def a
+		    @calls += 1
+		  end
+
+		  def b
+		    @calls += 1
+		  end
+
+		  memoize(:a, :b)
+		end
+
+		f = Foo.new
+		r = [f.a, f.b]
+		f.clear_memoized(:a)
+		r.push(f.a, f.b)
+		f.clear_memoized
+		r.push(f.a, f.b, f.calls)
+		r
+		`, []interface{}{1, 2, 3, 2, 4, 5, 5}},
+		// memoizing a method again doesn't change it
+		{`
+		class Foo
+		  def initialize
+		    @calls = 0
+		  end
+
+		  def a
+		    @calls += 1
+		  end
+
+		  memoize(:a)
+		  memoize(:a)
+		end
+
+		f = Foo.new
+		f.a
+		f.clear_memoized(:a)
+		f.a
+		`, 2},
+		{`
+		class Foo
+		  def a
+		    1
+		  end
+		end
+
+		Foo.memoize(:a).name
+		`, "Foo"},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestMemoizeFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`
+		class Foo
+		  memoize(:a)
+		end`, "NameError: undefined method 'a' for class 'Foo'", 2},
+		{`
+		class Foo
+		  memoize(1)
+		end`, "TypeError: Expect argument #1 to be String. got: Integer", 2},
+		{`
+		class Foo
+		  memoize
+		end`, "ArgumentError: Expect 1 or more argument(s). got: 0", 2},
+		{`
+		class Foo
+		  def each
+		    yield(1)
+		  end
+
+		  memoize(:each)
+		end`, "ArgumentError: Can't memoize method each, which takes a block", 2},
+		{`
+		class Foo
+		  def each
+		    get_block.call(1)
+		  end
+
+		  memoize(:each)
+		end`, "ArgumentError: Can't memoize method each, which takes a block", 2},
+		{`
+		class Foo
+		  def a
+		    1
+		  end
+
+		  memoize(:a)
+		end
+
+		Foo.new.a do
+		end`, "ArgumentError: Can't pass a block to memoized method a", 1},
+		{`
+		class Foo
+		  def a
+		    1
+		  end
+
+		  memoize(:a)
+		end
+
+		Foo.new.clear_memoized(1)`, "TypeError: Expect argument #1 to be String. got: Integer", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
@@ -0,0 +1,151 @@
+package vm
+
+import (
+	"math"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/goby-lang/goby/vm/errors"
+)
+
+// A vm running untrusted scripts can be given a memory limit with SetMemoryLimit.
+// The memory is accounted in two ways, which are both approximate:
+//
+// - The methods that can allocate a lot at once, like `String#*`, `Array.new` and `JSON.parse`, reserve the size
+//   they're going to allocate before they do, so a single huge allocation is refused instead of crashing the host.
+// - Every few thousand instructions, the heap is measured against the baseline taken when the execution starts,
+//   which catches a script that grows the memory bit by bit, like pushing to an Array in a loop.
+//
+// Either way, a MemoryLimitError is raised when the limit is exceeded.
+
+const (
+	memoryLimitExceeded = "Memory limit of %d bytes exceeded by %s"
+
+	// memoryCheckInterval is how many instructions run between the measurements of the heap
+	memoryCheckInterval = 10000
+
+	// elementSize is the size of an element of an Array, which is an interface value
+	elementSize = int(unsafe.Sizeof(Object(nil)))
+
+	// jsonExpansion estimates how many times larger the objects decoded from JSON are than the JSON text
+	jsonExpansion = 8
+
+	// yamlExpansion estimates the same for YAML, whose text is usually more compact than JSON's
+	yamlExpansion = 10
+)
+
+// memoryBudget keeps track of the memory used since the baseline, see SetMemoryLimit
+type memoryBudget struct {
+	limit int64
+
+	// measureLock makes one thread measure the heap at a time, and guards the baseline
+	measureLock sync.Mutex
+	baseline    uint64
+
+	// heapGrowth is the heap size over the baseline at the last measurement
+	heapGrowth int64
+	// reserved is the memory reserved by the methods since the last measurement
+	reserved int64
+	// instructions counts the instructions executed since the last measurement
+	instructions int64
+}
+
+// SetMemoryLimit limits the memory the scripts running on the vm can use, in bytes.
+// The memory used before the next execution starts isn't counted, and a limit of 0 removes the limit.
+func (vm *VM) SetMemoryLimit(bytes int) {
+	if bytes <= 0 {
+		vm.memoryBudget = nil
+		return
+	}
+
+	vm.memoryBudget = &memoryBudget{limit: int64(bytes)}
+	vm.memoryBudget.resetBaseline()
+}
+
+// resetBaseline makes the current heap size the baseline, so only the memory allocated after it is counted
+func (b *memoryBudget) resetBaseline() {
+	b.measureLock.Lock()
+	defer b.measureLock.Unlock()
+
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	b.baseline = stats.HeapAlloc
+	atomic.StoreInt64(&b.heapGrowth, 0)
+	atomic.StoreInt64(&b.reserved, 0)
+}
+
+// measure reads the heap size, collecting the garbage first if collect is true, and reports whether it's within the limit.
+// The memory reserved so far is counted in the heap from now on.
+func (b *memoryBudget) measure(collect bool) bool {
+	b.measureLock.Lock()
+	defer b.measureLock.Unlock()
+
+	if collect {
+		runtime.GC()
+	}
+
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	growth := int64(stats.HeapAlloc) - int64(b.baseline)
+	atomic.StoreInt64(&b.heapGrowth, growth)
+	atomic.StoreInt64(&b.reserved, 0)
+	return growth <= b.limit
+}
+
+// used estimates the memory used since the baseline
+func (b *memoryBudget) used() int64 {
+	return atomic.LoadInt64(&b.heapGrowth) + atomic.LoadInt64(&b.reserved)
+}
+
+// reserve counts the size the caller is going to allocate, and reports whether it fits in the limit.
+// Before refusing, the garbage is collected in case it's what takes the memory.
+func (b *memoryBudget) reserve(size int64) bool {
+	if size > b.limit || (b.used()+size > b.limit && !b.measure(true)) || b.used()+size > b.limit {
+		return false
+	}
+
+	atomic.AddInt64(&b.reserved, size)
+	return true
+}
+
+// tick counts an executed instruction, and measures the heap at the intervals.
+// It reports whether the memory is within the limit.
+func (b *memoryBudget) tick() bool {
+	if atomic.AddInt64(&b.instructions, 1)%memoryCheckInterval != 0 {
+		return true
+	}
+
+	return b.measure(false) || b.measure(true)
+}
+
+// reserveMemory reserves the memory a method is going to allocate, which is described by what in the error.
+// It returns a MemoryLimitError if it doesn't fit in the vm's limit, and nil if it does or there's no limit.
+func (t *Thread) reserveMemory(size int, what string, sourceLine int) *Error {
+	budget := t.vm.memoryBudget
+
+	if budget == nil || budget.reserve(int64(size)) {
+		return nil
+	}
+
+	return t.vm.InitErrorObject(errors.MemoryLimitError, sourceLine, memoryLimitExceeded, budget.limit, what)
+}
+
+// checkMemory counts the instruction against the vm's limit, and raises a MemoryLimitError if the heap has outgrown it
+func (t *Thread) checkMemory(sourceLine int) {
+	budget := t.vm.memoryBudget
+
+	if budget != nil && !budget.tick() {
+		t.pushErrorObject(errors.MemoryLimitError, sourceLine, memoryLimitExceeded, budget.limit, "the heap")
+	}
+}
+
+// multipliedSize returns size * n, or the largest int if it overflows, so the reservation fails
+func multipliedSize(size, n int) int {
+	if size != 0 && n > math.MaxInt64/size {
+		return math.MaxInt64
+	}
+
+	return size * n
+}
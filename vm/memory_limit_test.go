@@ -0,0 +1,100 @@
+package vm
+
+import (
+	"testing"
+)
+
+const testMemoryLimit = 32 * 1024 * 1024
+
+func TestMemoryLimit(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`("abc" * 1000).length`, 3000},
+		{`
+		s = "abc"
+		s = s + s
+		s.concat(s, "d").size`, 13},
+		{`Array.new(1000, 1).length`, 1000},
+		{`([1, 2] * 1000).length`, 2000},
+		{`[].fill(0, 0, 1000).length`, 1000},
+		{`
+		require 'json'
+		JSON.parse('{"a": [1, 2, 3]}')["a"].length`, 3},
+		{`
+		a = []
+		i = 0
+		while i < 100000 do
+		  a.push(i)
+		  i += 1
+		end
+		a.length`, 100000},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		v.SetMemoryLimit(testMemoryLimit)
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestMemoryLimitFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`"x" * 10000000000`, "MemoryLimitError: Memory limit of 33554432 bytes exceeded by String#*", 1},
+		{`
+		s = "x" * 1024
+		while true do
+		  s = s + s
+		end`, "MemoryLimitError: Memory limit of 33554432 bytes exceeded by String#+", 1},
+		{`
+		s = "x" * 1024
+		while true do
+		  s.concat(s)
+		end`, "MemoryLimitError: Memory limit of 33554432 bytes exceeded by String#concat", 1},
+		{`Array.new(100000000)`, "MemoryLimitError: Memory limit of 33554432 bytes exceeded by Array.new", 1},
+		{`[1] * 1000000000`, "MemoryLimitError: Memory limit of 33554432 bytes exceeded by Array#*", 1},
+		{`[].fill(0, 0, 100000000)`, "MemoryLimitError: Memory limit of 33554432 bytes exceeded by Array#fill", 1},
+		{`
+		require 'json'
+		JSON.parse("[" + "1," * 5000000 + "1]")`, "MemoryLimitError: Memory limit of 33554432 bytes exceeded by JSON.parse", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		v.SetMemoryLimit(testMemoryLimit)
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+// The heap is measured at intervals of instructions, so the stack pointer depends on where the error happens
+func TestMemoryLimitHeapFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`
+		a = []
+		while true do
+		  a.push((1..200).to_a)
+		end`, "MemoryLimitError: Memory limit of 8388608 bytes exceeded by the heap", 1},
+		{`
+		h = {}
+		i = 0
+		while true do
+		  h[i.to_s] = (1..200).to_a
+		  i += 1
+		end`, "MemoryLimitError: Memory limit of 8388608 bytes exceeded by the heap", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		v.SetMemoryLimit(8 * 1024 * 1024)
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+	}
+}
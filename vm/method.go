@@ -1,8 +1,8 @@
 package vm
 
 import (
-	"bytes"
 	"fmt"
+	"strings"
 
 	"github.com/goby-lang/goby/compiler/bytecode"
 	"github.com/goby-lang/goby/vm/classes"
@@ -14,6 +14,11 @@ type MethodObject struct {
 	Name           string
 	instructionSet *instructionSet
 	argc           int
+	// sourceLine is where the method is defined
+	sourceLine int
+	// owner is the class the method is defined on
+	owner      *RClass
+	visibility visibility
 }
 
 // Internal functions ===================================================
@@ -26,14 +31,10 @@ func (vm *VM) initMethodClass() *RClass {
 
 // Polymorphic helper functions -----------------------------------------
 
-// ToString returns the object's name as the string format
+// ToString returns the method's owner, name, parameters and where it's defined,
+// like `#<Method: Foo#bar(a, b=1, *c) at foo.gb:12>`
 func (m *MethodObject) ToString() string {
-	var out bytes.Buffer
-
-	out.WriteString(fmt.Sprintf("<Method: %s (%d params)\n>", m.Name, m.argc))
-	out.WriteString(m.instructionSet.inspect())
-
-	return out.String()
+	return fmt.Sprintf("#<Method: %s(%s) at %s:%d>", qualifiedMethodName(m.owner, m.Name), formatParams(m.instructionSet.paramTypes, m.instructionSet.paramDefaults), m.instructionSet.filename, m.sourceLine)
 }
 
 // Inspect delegates to ToString
@@ -43,7 +44,7 @@ func (m *MethodObject) Inspect() string {
 
 // ToJSON just delegates to ToString
 func (m *MethodObject) ToJSON(t *Thread) string {
-	return m.ToString()
+	return unsupportedJSON(m)
 }
 
 // Value returns method object's string format
@@ -65,6 +66,16 @@ func (m *MethodObject) isSplatArgIncluded() bool {
 	return false
 }
 
+func (m *MethodObject) isDoubleSplatArgIncluded() bool {
+	for _, argType := range m.paramTypes() {
+		if argType == bytecode.DoubleSplatArg {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (m *MethodObject) isKeywordArgIncluded() bool {
 	for _, argType := range m.paramTypes() {
 		if argType == bytecode.OptionalKeywordArg || argType == bytecode.RequiredKeywordArg {
@@ -82,6 +93,11 @@ type BuiltinMethodObject struct {
 	*BaseObj
 	Name string
 	Fn   builtinMethodBody
+	// owner is the class the method is set on
+	owner      *RClass
+	visibility visibility
+	// returnsError is set on methods which return an Error as a value, so it isn't raised
+	returnsError bool
 }
 
 // Method is a callable function
@@ -101,9 +117,9 @@ type builtinMethodBody func(Object, int, *Thread, []Object, *normalCallFrame) Ob
 
 // Polymorphic helper functions -----------------------------------------
 
-// ToString returns the object's name as the string format
+// ToString returns the method's owner and name, like `#<BuiltinMethod: Array#push>`
 func (bim *BuiltinMethodObject) ToString() string {
-	return "<BuiltinMethod: " + bim.Name + ">"
+	return "#<BuiltinMethod: " + qualifiedMethodName(bim.owner, bim.Name) + ">"
 }
 
 // Inspect delegates to ToString
@@ -113,10 +129,34 @@ func (bim *BuiltinMethodObject) Inspect() string {
 
 // ToJSON just delegates to `ToString`
 func (bim *BuiltinMethodObject) ToJSON(t *Thread) string {
-	return bim.ToString()
+	return unsupportedJSON(bim)
 }
 
 // Value returns builtin method object's function
 func (bim *BuiltinMethodObject) Value() interface{} {
 	return bim.Fn
 }
+
+// ownedBy returns a copy of the method that's owned by the class.
+// The builtin method lists are shared by all VMs, so their methods are never modified.
+func (bim *BuiltinMethodObject) ownedBy(c *RClass) *BuiltinMethodObject {
+	owned := *bim
+	owned.owner = c
+	return &owned
+}
+
+// Other helper functions -----------------------------------------------
+
+// qualifiedMethodName prefixes the method's name with its owner like Ruby does:
+// `Foo#bar` for an instance method and `Foo.bar` for a singleton method.
+func qualifiedMethodName(owner *RClass, name string) string {
+	if owner == nil {
+		return name
+	}
+
+	if owner.isSingleton {
+		return strings.TrimSuffix(strings.TrimPrefix(owner.Name, "#<Class:"), ">") + "." + name
+	}
+
+	return owner.Name + "#" + name
+}
@@ -0,0 +1,182 @@
+package vm
+
+import (
+	"strings"
+
+	"github.com/goby-lang/goby/compiler/bytecode"
+)
+
+// methodTable holds a class's methods in the order they're first defined, along with each method's metadata.
+// It's the only way methods are stored on a class, so every definition goes through `set`.
+type methodTable struct {
+	entries map[string]*methodEntry
+	order   []string
+	// serial is bumped whenever a method is defined or redefined, so anything caching lookups can tell it's stale
+	serial uint64
+}
+
+// methodEntry is a method along with the metadata recorded when it's defined
+type methodEntry struct {
+	method  Object
+	builtin bool
+	// arity follows Ruby's convention: the number of required arguments, or -(required + 1) if the method takes optional ones
+	arity int
+	// fileName and sourceLine are where the method is defined; they're empty for builtin methods
+	fileName   string
+	sourceLine int
+	// undefined marks a method undefined with `undef_method`, which hides the methods with the same name in the ancestors
+	undefined bool
+}
+
+func newMethodTable() *methodTable {
+	return &methodTable{entries: make(map[string]*methodEntry)}
+}
+
+// get returns the method, or nil and true if it's undefined with `undef_method`
+func (mt *methodTable) get(name string) (Object, bool) {
+	entry, ok := mt.entries[name]
+
+	if !ok {
+		return nil, false
+	}
+
+	return entry.method, true
+}
+
+// entry returns the method's metadata
+func (mt *methodTable) entry(name string) (*methodEntry, bool) {
+	entry, ok := mt.entries[name]
+	return entry, ok
+}
+
+// set defines or redefines a method. A redefined method keeps its original position.
+func (mt *methodTable) set(name string, method Object) Object {
+	if _, ok := mt.entries[name]; !ok {
+		mt.order = append(mt.order, name)
+	}
+
+	entry := &methodEntry{method: method, builtin: true, arity: -1}
+
+	if m, ok := method.(*MethodObject); ok {
+		entry.builtin = false
+		entry.arity = m.arity()
+		entry.fileName = m.instructionSet.filename
+		entry.sourceLine = m.sourceLine
+	}
+
+	mt.entries[name] = entry
+	mt.serial++
+
+	return method
+}
+
+// remove deletes the method, so looking it up goes on to the ancestors. It returns false if there's no such method.
+func (mt *methodTable) remove(name string) bool {
+	entry, ok := mt.entries[name]
+
+	if !ok || entry.undefined {
+		return false
+	}
+
+	delete(mt.entries, name)
+
+	for i, n := range mt.order {
+		if n == name {
+			mt.order = append(mt.order[:i:i], mt.order[i+1:]...)
+			break
+		}
+	}
+
+	mt.serial++
+	return true
+}
+
+// undefine replaces the method with a tombstone, so looking it up stops here without finding a method
+func (mt *methodTable) undefine(name string) {
+	if _, ok := mt.entries[name]; !ok {
+		mt.order = append(mt.order, name)
+	}
+
+	mt.entries[name] = &methodEntry{undefined: true}
+	mt.serial++
+}
+
+// names returns the methods' names in definition order, including the undefined ones
+func (mt *methodTable) names() []string {
+	return append([]string{}, mt.order...)
+}
+
+// arity counts the method's parameters following Ruby's convention. See `methodEntry.arity`.
+func (m *MethodObject) arity() int {
+	if m.instructionSet.paramTypes == nil {
+		return m.argc
+	}
+
+	return paramsArity(m.instructionSet.paramTypes)
+}
+
+// paramsArity counts the parameters of a method or a block following Ruby's convention
+func paramsArity(params *bytecode.ArgSet) int {
+	required := 0
+	optional := false
+	requiredKeyword := false
+
+	for _, argType := range params.Types() {
+		switch argType {
+		case bytecode.NormalArg:
+			required++
+		case bytecode.RequiredKeywordArg:
+			requiredKeyword = true
+		default:
+			optional = true
+		}
+	}
+
+	// all the required keyword arguments count as one required argument, like a Hash does
+	if requiredKeyword {
+		required++
+	}
+
+	if optional {
+		return -(required + 1)
+	}
+
+	return required
+}
+
+// formatParams writes the parameters of a method or a block the way they're declared, like `a, b=1, *c, d:, e: 2, **f`.
+// A default value is written as `...` if its source is unknown.
+func formatParams(params *bytecode.ArgSet, defaults map[int]string) string {
+	if params == nil {
+		return ""
+	}
+
+	var out []string
+
+	for i, name := range params.Names() {
+		switch params.Types()[i] {
+		case bytecode.OptionedArg:
+			out = append(out, name+"="+defaultSource(defaults, i))
+		case bytecode.SplatArg:
+			out = append(out, "*"+name)
+		case bytecode.RequiredKeywordArg:
+			out = append(out, name+":")
+		case bytecode.OptionalKeywordArg:
+			out = append(out, name+": "+defaultSource(defaults, i))
+		case bytecode.DoubleSplatArg:
+			out = append(out, "**"+name)
+		default:
+			out = append(out, name)
+		}
+	}
+
+	return strings.Join(out, ", ")
+}
+
+func defaultSource(defaults map[int]string, index int) string {
+	if source, ok := defaults[index]; ok {
+		return source
+	}
+
+	return "..."
+}
@@ -0,0 +1,66 @@
+package vm
+
+import "testing"
+
+func TestMethodTableSerial(t *testing.T) {
+	input := `
+	class C
+	  def foo
+	    1
+	  end
+	end
+	C
+	`
+
+	v := initTestVM()
+	c := v.testEval(t, input, getFilename()).(*RClass)
+	serial := c.Methods.serial
+
+	v.testEval(t, `
+	class C
+	  def foo
+	    2
+	  end
+	end
+	`, getFilename())
+
+	if c.Methods.serial <= serial {
+		t.Fatalf("Expect redefinition to bump the serial. got: %d, was: %d", c.Methods.serial, serial)
+	}
+
+	serial = c.Methods.serial
+	v.testEval(t, `C.define_method(:bar) do; end`, getFilename())
+
+	if c.Methods.serial <= serial {
+		t.Fatalf("Expect define_method to bump the serial. got: %d, was: %d", c.Methods.serial, serial)
+	}
+
+	serial = c.Methods.serial
+	c.setBuiltinMethods([]*BuiltinMethodObject{{Name: "baz"}}, false)
+
+	if c.Methods.serial <= serial {
+		t.Fatalf("Expect builtin methods to bump the serial. got: %d, was: %d", c.Methods.serial, serial)
+	}
+
+	if names := c.Methods.names(); len(names) != 3 || names[0] != "foo" || names[1] != "bar" || names[2] != "baz" {
+		t.Fatalf("Expect methods to be listed in definition order. got: %v", names)
+	}
+
+	serial = c.Methods.serial
+	v.testEval(t, `C.remove_method(:foo)`, getFilename())
+
+	if c.Methods.serial <= serial {
+		t.Fatalf("Expect remove_method to bump the serial. got: %d, was: %d", c.Methods.serial, serial)
+	}
+
+	serial = c.Methods.serial
+	v.testEval(t, `C.undef_method(:bar)`, getFilename())
+
+	if c.Methods.serial <= serial {
+		t.Fatalf("Expect undef_method to bump the serial. got: %d, was: %d", c.Methods.serial, serial)
+	}
+
+	if names := c.Methods.names(); len(names) != 2 || names[0] != "bar" || names[1] != "baz" {
+		t.Fatalf("Expect removed methods to be unlisted. got: %v", names)
+	}
+}
@@ -0,0 +1,53 @@
+package vm
+
+import "testing"
+
+func TestMethodInspect(t *testing.T) {
+	input := `
+class Foo
+  attr_reader :x
+
+  def bar(a, b = 10, d:, e: "x", *c, **f)
+  end
+
+  def baz
+  end
+
+  def self.qux(a)
+  end
+end
+
+def top(a, b = a)
+end
+
+Foo
+`
+
+	v := initTestVM()
+	foo := v.testEval(t, input, "foo.gb").(*RClass)
+	object := v.TopLevelClass("Object")
+	array := v.TopLevelClass("Array")
+
+	tests := []struct {
+		method   Object
+		expected string
+	}{
+		{foo.lookupMethod("bar"), `#<Method: Foo#bar(a, b=10, d:, e: "x", *c, **f) at foo.gb:5>`},
+		{foo.lookupMethod("baz"), `#<Method: Foo#baz() at foo.gb:8>`},
+		{foo.SingletonClass().lookupMethod("qux"), `#<Method: Foo.qux(a) at foo.gb:11>`},
+		{object.lookupMethod("top"), `#<Method: Object#top(a, b=a) at foo.gb:15>`},
+		{foo.lookupMethod("x"), `#<BuiltinMethod: Foo#x>`},
+		{array.lookupMethod("push"), `#<BuiltinMethod: Array#push>`},
+		{array.SingletonClass().lookupMethod("new"), `#<BuiltinMethod: Array.new>`},
+	}
+
+	for i, tt := range tests {
+		if tt.method == nil {
+			t.Fatalf("At test case %d: method not found", i)
+		}
+
+		if got := tt.method.Inspect(); got != tt.expected {
+			t.Errorf("At test case %d: expect inspect to be %s. got: %s", i, tt.expected, got)
+		}
+	}
+}
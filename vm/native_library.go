@@ -0,0 +1,104 @@
+package vm
+
+import (
+	"sort"
+)
+
+// nativeLibrary is a library implemented in Go, which `require` loads by its name
+type nativeLibrary struct {
+	// dependencies are the names of the native libraries which need to be loaded before this one
+	dependencies []string
+	// init defines the library's classes and modules; it shouldn't define anything other libraries provide
+	init func(*VM)
+}
+
+var nativeLibraries = map[string]nativeLibrary{
+	"net/http":           {init: initHTTPClass},
+	"net/dns":            {init: initDNSModule},
+	"net/simple_server":  {dependencies: []string{"net/http"}, init: initSimpleServerClass},
+	"uri":                {init: initURIClass},
+	"json":               {init: initJSONClass},
+	"crypto":             {init: initCryptoClass},
+	"yaml":               {init: initYAMLModule},
+	"concurrent/array":   {init: initConcurrentArrayClass},
+	"concurrent/future":  {init: initConcurrentFutureClass},
+	"concurrent/hash":    {init: initConcurrentHashClass},
+	"concurrent/rw_lock": {init: initConcurrentRWLockClass},
+	"concurrent/timer":   {init: initConcurrentTimerClass},
+	"spec":               {init: initSpecClass},
+}
+
+// AvailableNativeLibraries returns the sorted names of the libraries implemented in Go that `require` can load,
+// including the ones registered with `RegisterExternalClass`
+func (vm *VM) AvailableNativeLibraries() []string {
+	names := make([]string, 0, len(nativeLibraries))
+
+	for name := range nativeLibraries {
+		names = append(names, name)
+	}
+
+	externalClassLock.Lock()
+	for name := range externalClasses {
+		if _, ok := nativeLibraries[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	externalClassLock.Unlock()
+
+	sort.Strings(names)
+
+	return names
+}
+
+// lookupNativeLibrary returns the native library with the given name, which is either builtin or registered
+// with `RegisterExternalClass`
+func lookupNativeLibrary(name string) (nativeLibrary, bool) {
+	if lib, ok := nativeLibraries[name]; ok {
+		return lib, true
+	}
+
+	externalClassLock.Lock()
+	loaders, ok := externalClasses[name]
+	externalClassLock.Unlock()
+
+	if !ok {
+		return nativeLibrary{}, false
+	}
+
+	return nativeLibrary{init: func(vm *VM) {
+		for _, l := range loaders {
+			l(vm)
+		}
+	}}, true
+}
+
+// loadNativeLibrary loads the native library with the given name after its dependencies.
+// `found` is false if there's no such library, and `loaded` is false if the library has already been loaded.
+// If another thread is loading a library, it waits for it to finish first.
+func (vm *VM) loadNativeLibrary(name string) (loaded bool, found bool) {
+	vm.nativeLibraryLock.Lock()
+	defer vm.nativeLibraryLock.Unlock()
+
+	return vm.loadNativeLibraryLocked(name)
+}
+
+func (vm *VM) loadNativeLibraryLocked(name string) (loaded bool, found bool) {
+	lib, found := lookupNativeLibrary(name)
+
+	if !found {
+		return false, false
+	}
+
+	// Libraries are marked before they're initialized, so a library requiring itself doesn't loop forever
+	if _, ok := vm.loadedNativeLibraries.LoadOrStore(name, true); ok {
+		return false, true
+	}
+
+	for _, dependency := range lib.dependencies {
+		vm.loadNativeLibraryLocked(dependency)
+	}
+
+	lib.init(vm)
+
+	return true, true
+}
@@ -0,0 +1,185 @@
+package vm
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+// constantDefined checks if the given constant, like "Concurrent::Array", is defined without defining anything
+func (v *VM) constantDefined(path string) bool {
+	namespace := v.objectClass
+
+	for _, name := range strings.Split(path, "::") {
+		ptr, ok := namespace.constants[name]
+
+		if !ok {
+			return false
+		}
+
+		namespace, ok = ptr.Target.(*RClass)
+
+		if !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestRequireNativeLibraryInIsolation(t *testing.T) {
+	libraryConstants := map[string][]string{
+		"net/http":           {"Net::HTTP", "Net::HTTP::Client", "Net::HTTP::Request", "Net::HTTP::Response"},
+		"net/dns":            {"Net::DNS", "Net::IP"},
+		"net/simple_server":  {"Net::SimpleServer"},
+		"uri":                {"URI", "URI::HTTP", "URI::HTTPS"},
+		"json":               {"JSON"},
+		"crypto":             {"Crypto"},
+		"yaml":               {"YAML"},
+		"concurrent/array":   {"Concurrent::Array"},
+		"concurrent/future":  {"Concurrent::Future"},
+		"concurrent/hash":    {"Concurrent::Hash"},
+		"concurrent/rw_lock": {"Concurrent::RWLock"},
+		"concurrent/timer":   {"Concurrent::Timer"},
+		"spec":               {"Spec"},
+	}
+
+	for lib, constants := range libraryConstants {
+		v := initTestVM()
+		evaluated := v.testEval(t, `require "`+lib+`"`, getFilename())
+		VerifyExpected(t, 0, evaluated, true)
+
+		defined := map[string]bool{}
+
+		// the constants of the dependencies are defined as well
+		for _, dependency := range nativeLibraries[lib].dependencies {
+			constants = append(constants, libraryConstants[dependency]...)
+		}
+
+		for _, constant := range constants {
+			defined[constant] = true
+
+			if !v.constantDefined(constant) {
+				t.Errorf("Expect %s to be defined after requiring %s", constant, lib)
+			}
+		}
+
+		for otherLib, otherConstants := range libraryConstants {
+			for _, constant := range otherConstants {
+				if !defined[constant] && v.constantDefined(constant) {
+					t.Errorf("Expect %s of %s to stay undefined after requiring %s", constant, otherLib, lib)
+				}
+			}
+		}
+	}
+}
+
+func TestRequireNativeLibraryTwice(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		r1 = require "concurrent/hash"
+		r2 = require "concurrent/hash"
+		[r1, r2]
+		`, []interface{}{true, false}},
+		{`
+		require "concurrent/hash"
+		h = Concurrent::Hash.new({ a: 1 })
+		require "concurrent/hash"
+		h.class == Concurrent::Hash
+		`, true},
+		// dependencies are loaded only once too
+		{`
+		r1 = require "net/simple_server"
+		r2 = require "net/http"
+		[r1, r2]
+		`, []interface{}{true, false}},
+		{`
+		r1 = require "net/http"
+		r2 = require "net/simple_server"
+		[r1, r2]
+		`, []interface{}{true, true}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+// Run it with -race to check threads loading a library at the same time don't race
+func TestRequireNativeLibraryFromThreads(t *testing.T) {
+	input := `
+	c = Channel.new
+
+	20.times do
+	  thread do
+	    require 'concurrent/hash'
+	    require 'net/http'
+	    c.deliver([Concurrent::Hash.new.class, Net::HTTP::Client.new.class])
+	  end
+	end
+
+	classes = []
+
+	20.times do
+	  classes.push(c.receive)
+	end
+
+	classes
+	`
+
+	v := initTestVM()
+	evaluated := v.testEval(t, input, getFilename())
+
+	if isError(evaluated) {
+		t.Fatal(evaluated.ToString())
+	}
+
+	hashClass := v.objectClass.getClassConstant("Concurrent").getClassConstant("Hash")
+	clientClass := v.objectClass.getClassConstant("Net").getClassConstant("HTTP").getClassConstant("Client")
+
+	for i, pair := range evaluated.(*ArrayObject).Elements {
+		classes := pair.(*ArrayObject).Elements
+
+		if classes[0] != hashClass || classes[1] != clientClass {
+			t.Errorf("At thread %d: expect the classes to be the constants' classes. got: %s", i, pair.Inspect())
+		}
+	}
+
+	v.checkCFP(t, 0, 0)
+	v.checkSP(t, 0, 1)
+}
+
+func TestAvailableNativeLibraries(t *testing.T) {
+	v := initTestVM()
+	RegisterExternalClass("test/native_library")
+	defer func() {
+		externalClassLock.Lock()
+		delete(externalClasses, "test/native_library")
+		externalClassLock.Unlock()
+	}()
+
+	libs := v.AvailableNativeLibraries()
+
+	for _, lib := range []string{"concurrent/array", "json", "net/http", "test/native_library"} {
+		found := false
+
+		for _, available := range libs {
+			found = found || available == lib
+		}
+
+		if !found {
+			t.Errorf("Expect %s to be available. got: %v", lib, libs)
+		}
+	}
+
+	if !sort.StringsAreSorted(libs) {
+		t.Errorf("Expect the libraries to be sorted. got: %v", libs)
+	}
+}
@@ -1,5 +1,13 @@
 package vm
 
+import (
+	"math"
+	"math/big"
+
+	"github.com/goby-lang/goby/vm/classes"
+	"github.com/goby-lang/goby/vm/errors"
+)
+
 // Numeric currently represents a class that support some numeric conversions.
 // At this stage, it's not meant to be a Goby class in a strict sense, but only
 // a convenient interface.
@@ -7,3 +15,194 @@ type Numeric interface {
 	floatValue() float64
 	lessThan(object Object) bool
 }
+
+const (
+	invalidCoercion = "Expect %s#coerce to return an Array of 2 elements. got: %s"
+	cantDivmod      = "Can't divmod %s by %s"
+)
+
+var bigOne = big.NewInt(1)
+
+// coerceOperation lets an object that isn't a built-in number be the right operand of an arithmetic operator, like Ruby does:
+// if it responds to `coerce`, `right.coerce(left)` returns the pair of operands to apply the operator to instead.
+// The pair's left operand can't be a built-in number if the right one isn't, or they'd be coerced forever.
+func (t *Thread) coerceOperation(left, right Object, operator string, sourceLine int) Object {
+	if right.findMethod("coerce") == nil {
+		return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, "Numeric", right.Class().Name)
+	}
+
+	result := t.callMethod(right, "coerce", sourceLine, left)
+
+	if err, ok := result.(*Error); ok {
+		return err
+	}
+
+	pair, ok := result.(*ArrayObject)
+
+	if !ok || len(pair.Elements) != 2 {
+		return t.vm.InitErrorObject(errors.TypeError, sourceLine, invalidCoercion, right.Class().Name, result.Inspect())
+	}
+
+	coercedLeft, coercedRight := pair.Elements[0], pair.Elements[1]
+
+	if _, ok := coercedRight.(Numeric); !ok && isBuiltinNumber(coercedLeft) {
+		return t.vm.InitErrorObject(errors.TypeError, sourceLine, invalidCoercion, right.Class().Name, result.Inspect())
+	}
+
+	return t.callMethod(coercedLeft, operator, sourceLine, coercedRight)
+}
+
+func isBuiltinNumber(obj Object) bool {
+	switch obj.(type) {
+	case *IntegerObject, *BigIntegerObject, *FloatObject:
+		return true
+	default:
+		return false
+	}
+}
+
+// floorDiv returns the quotient of the division rounded toward negative infinity, like Ruby's Integer division
+func floorDiv(x, y int) int {
+	q := x / y
+
+	if x%y != 0 && (x < 0) != (y < 0) {
+		q--
+	}
+
+	return q
+}
+
+// floorMod returns the modulo matching floorDiv, which has the sign of the divisor
+func floorMod(x, y int) int {
+	m := x % y
+
+	if m != 0 && (m < 0) != (y < 0) {
+		m += y
+	}
+
+	return m
+}
+
+// floorDivBig is floorDiv for big integers, with the signature of big.Int's operations
+func floorDivBig(z, x, y *Int) *Int {
+	m := new(Int)
+	z.QuoRem(x, y, m)
+
+	if m.Sign() != 0 && (m.Sign() < 0) != (y.Sign() < 0) {
+		z.Sub(z, bigOne)
+	}
+
+	return z
+}
+
+// floorModBig is floorMod for big integers, with the signature of big.Int's operations
+func floorModBig(z, x, y *Int) *Int {
+	// y is copied in case z is y
+	y = new(Int).Set(y)
+	z.Rem(x, y)
+
+	if z.Sign() != 0 && (z.Sign() < 0) != (y.Sign() < 0) {
+		z.Add(z, y)
+	}
+
+	return z
+}
+
+// floorModFloat is floorMod for floats. It's NaN if y is 0, as IEEE 754 specifies.
+func floorModFloat(x, y float64) float64 {
+	m := math.Mod(x, y)
+
+	if m != 0 && (m < 0) != (y < 0) {
+		m += y
+	}
+
+	return m
+}
+
+// floorModRat is floorMod for rationals, with the signature of big.Rat's operations
+func floorModRat(z, x, y *big.Rat) *big.Rat {
+	q := new(big.Rat).Quo(x, y)
+	floor := floorDivBig(new(Int), q.Num(), q.Denom())
+	product := new(big.Rat).Mul(y, new(big.Rat).SetInt(floor))
+
+	return z.Sub(x, product)
+}
+
+// floatDivmod returns `[q, r]` where q is the floored quotient as an Integer and r is floorModFloat(x, y)
+func (t *Thread) floatDivmod(x, y float64, sourceLine int) Object {
+	if y == 0 {
+		return t.vm.InitErrorObject(errors.ZeroDivisionError, sourceLine, errors.DividedByZero)
+	}
+
+	q := math.Floor(x / y)
+
+	if math.IsInf(q, 0) || math.IsNaN(q) {
+		return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, cantDivmod, t.vm.initFloatObject(x).ToString(), t.vm.initFloatObject(y).ToString())
+	}
+
+	quotient, _ := big.NewFloat(q).Int(nil)
+
+	return t.vm.InitArrayObject([]Object{t.vm.initIntegerObjectFromBig(quotient), t.vm.initFloatObject(floorModFloat(x, y))})
+}
+
+// ratDivmod returns `[q, r]` where q is the floored quotient as an Integer and r is floorModRat(x, y)
+func (t *Thread) ratDivmod(x, y *big.Rat, sourceLine int) Object {
+	if y.Sign() == 0 {
+		return t.vm.InitErrorObject(errors.ZeroDivisionError, sourceLine, errors.DividedByZero)
+	}
+
+	q := new(big.Rat).Quo(x, y)
+	quotient := floorDivBig(new(Int), q.Num(), q.Denom())
+
+	return t.vm.InitArrayObject([]Object{t.vm.initIntegerObjectFromBig(quotient), t.vm.initRationalObject(floorModRat(new(big.Rat), x, y))})
+}
+
+// roundIntegerToDigits rounds the value to a multiple of 10 ** -digits, with roundQuotient rounding value / 10 ** -digits
+// to an integer. It's the value itself if digits isn't negative.
+func (t *Thread) roundIntegerToDigits(value int, args []Object, roundQuotient func(x, y *Int) *Int, sourceLine int) Object {
+	if len(args) > 1 {
+		return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgumentLess, 1, len(args))
+	}
+
+	if len(args) == 0 {
+		return t.vm.InitIntegerObject(value)
+	}
+
+	digits, ok := args[0].(*IntegerObject)
+
+	if !ok {
+		return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.IntegerClass, args[0].Class().Name)
+	}
+
+	if digits.value >= 0 {
+		return t.vm.InitIntegerObject(value)
+	}
+
+	unit := new(Int).Exp(big.NewInt(10), big.NewInt(int64(-digits.value)), nil)
+	quotient := roundQuotient(big.NewInt(int64(value)), unit)
+
+	return t.vm.initIntegerObjectFromBig(quotient.Mul(quotient, unit))
+}
+
+// roundHalfAwayFromZero returns x / y rounded to the nearest integer, and away from zero in case of a tie
+func roundHalfAwayFromZero(x, y *Int) *Int {
+	q, r := new(Int).QuoRem(x, y, new(Int))
+
+	twice := new(Int).Lsh(new(Int).Abs(r), 1)
+
+	if twice.Cmp(new(Int).Abs(y)) >= 0 {
+		if x.Sign()*y.Sign() < 0 {
+			q.Sub(q, bigOne)
+		} else {
+			q.Add(q, bigOne)
+		}
+	}
+
+	return q
+}
+
+// ceilDivBig returns x / y rounded toward positive infinity
+func ceilDivBig(x, y *Int) *Int {
+	q := floorDivBig(new(Int), new(Int).Neg(x), y)
+	return q.Neg(q)
+}
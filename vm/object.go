@@ -168,6 +168,8 @@ func (p *Pointer) returnClass() *RClass {
 type RObject struct {
 	*BaseObj
 	InitializeMethod *MethodObject
+	// memo caches the results of the methods made with `memoize`
+	memo memoTable
 }
 
 // Polymorphic helper functions -----------------------------------------
@@ -177,28 +179,34 @@ func (ro *RObject) ToString() string {
 	return "#<" + ro.class.Name + ":" + fmt.Sprint(ro.ID()) + " >"
 }
 
-// Inspect delegates to ToString
+// Inspect returns the object's name with its instance variables.
+// The names are sorted, so the output doesn't depend on the order the instance variables were set.
 func (ro *RObject) Inspect() string {
-	var iv string
-	for _, n := range ro.InstanceVariables.names() {
-		v, _ := ro.InstanceVariableGet(n)
-		iv = iv + n + "=" + v.ToString() + " "
-	}
-	return "#<" + ro.class.Name + ":" + fmt.Sprint(ro.ID()) + " " + iv + ">"
+	return inspectWithDepth(ro, 0)
 }
 
-// ToJSON just delegates to ToString
+// ToJSON calls the object's `to_json` method if it's defined.
+// Otherwise it returns a JSON object of the instance variables, without the `@` prefix.
+// Only the instance variables listed with `json_attributes` are included if the class declares them.
 func (ro *RObject) ToJSON(t *Thread) string {
-	customToJSONMethod := ro.findMethod("to_json").(*MethodObject)
+	customToJSONMethod, ok := ro.findMethod("to_json").(*MethodObject)
 
-	if customToJSONMethod != nil {
+	if ok {
 		t.Stack.Push(&Pointer{Target: ro})
 		callObj := newCallObject(ro, customToJSONMethod, t.Stack.pointer, 0, &bytecode.ArgSet{}, nil, customToJSONMethod.instructionSet.instructions[0].SourceLine())
 		t.evalMethodObject(callObj)
 		result := t.Stack.Pop().Target
 		return result.ToString()
 	}
-	return ro.ToString()
+
+	e := &jsonEncoder{t: t}
+	return e.encodeInstanceVariables(ro, jsonRootPath)
+}
+
+// hasCustomToJSON returns true if the object's class defines `to_json` in Goby
+func (ro *RObject) hasCustomToJSON() bool {
+	_, ok := ro.findMethod("to_json").(*MethodObject)
+	return ok
 }
 
 // Value returns object's string format
@@ -0,0 +1,87 @@
+package vm
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// prettyDoc is a piece of the pretty-printed output, which is laid out by prettyLayout.
+// A leaf is a text. A group holds children, which are written in a line if they fit in the width,
+// or one per line and indented by two spaces otherwise.
+type prettyDoc struct {
+	// prefix is written before the doc, like a hash key
+	prefix string
+
+	// text and color are a leaf's text and the ANSI color code it's written in, if any
+	text  string
+	color string
+
+	group    bool
+	children []*prettyDoc
+	// empty is written for a group without children
+	empty string
+	// open, sep and close surround and separate the children when they're written in a line
+	open, sep, close string
+	// breakOpen, breakSep and breakClose do the same when the children are broken into lines
+	breakOpen, breakSep, breakClose string
+}
+
+func leafDoc(text, color string) *prettyDoc {
+	return &prettyDoc{text: text, color: color}
+}
+
+// prettyLayout lays docs out in the width, coloring the leaves if color is true
+type prettyLayout struct {
+	width int
+	color bool
+}
+
+func (l *prettyLayout) render(d *prettyDoc) string {
+	return l.layout(d, 0, len(d.prefix))
+}
+
+// layout writes the doc on a line indented by the given depth, after a prefix like a hash key.
+// A group is broken into lines if it doesn't fit in the rest of the line.
+func (l *prettyLayout) layout(d *prettyDoc, depth, prefixLen int) string {
+	if !d.group || len(d.children) == 0 || depth+prefixLen+flatWidth(d) <= l.width {
+		return l.flat(d)
+	}
+
+	indent := strings.Repeat(" ", depth+2)
+	lines := make([]string, len(d.children))
+
+	for i, child := range d.children {
+		lines[i] = indent + child.prefix + l.layout(child, depth+2, utf8.RuneCountInString(child.prefix))
+	}
+
+	return d.breakOpen + "\n" + strings.Join(lines, d.breakSep+"\n") + "\n" + strings.Repeat(" ", depth) + d.breakClose
+}
+
+// flat writes the doc in a single line
+func (l *prettyLayout) flat(d *prettyDoc) string {
+	if !d.group {
+		if l.color && d.color != "" {
+			return "\x1b[" + d.color + "m" + d.text + "\x1b[0m"
+		}
+
+		return d.text
+	}
+
+	if len(d.children) == 0 {
+		return d.empty
+	}
+
+	children := make([]string, len(d.children))
+
+	for i, child := range d.children {
+		children[i] = child.prefix + l.flat(child)
+	}
+
+	return d.open + strings.Join(children, d.sep) + d.close
+}
+
+// flatWidth returns how many characters the doc takes in a single line, without the color codes
+func flatWidth(d *prettyDoc) int {
+	plain := &prettyLayout{}
+	return utf8.RuneCountInString(plain.flat(d))
+}
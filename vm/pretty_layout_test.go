@@ -0,0 +1,44 @@
+package vm
+
+import (
+	"testing"
+)
+
+func TestPrettyLayout(t *testing.T) {
+	numbers := func() *prettyDoc {
+		return &prettyDoc{group: true, empty: "[]", open: "[", sep: ", ", close: "]", breakOpen: "[", breakSep: ",", breakClose: "]",
+			children: []*prettyDoc{leafDoc("100", numberColor), leafDoc("200", numberColor), leafDoc("300", numberColor)}}
+	}
+	nested := func() *prettyDoc {
+		inner := numbers()
+		inner.prefix = "key: "
+		return &prettyDoc{group: true, empty: "{  }", open: "{ ", sep: ", ", close: " }", breakOpen: "{", breakSep: ",", breakClose: "}",
+			children: []*prettyDoc{inner}}
+	}
+
+	tests := []struct {
+		doc      *prettyDoc
+		width    int
+		color    bool
+		expected string
+	}{
+		{leafDoc("a long leaf is never broken", ""), 5, false, "a long leaf is never broken"},
+		{&prettyDoc{group: true, empty: "[]"}, 1, false, "[]"},
+		{numbers(), 15, false, "[100, 200, 300]"},
+		{numbers(), 14, false, "[\n  100,\n  200,\n  300\n]"},
+		// the children's prefixes count in the width
+		{nested(), 24, false, "{ key: [100, 200, 300] }"},
+		{nested(), 22, false, "{\n  key: [100, 200, 300]\n}"},
+		{nested(), 21, false, "{\n  key: [\n    100,\n    200,\n    300\n  ]\n}"},
+		// the color codes don't count in the width
+		{numbers(), 15, true, "[\x1b[36m100\x1b[0m, \x1b[36m200\x1b[0m, \x1b[36m300\x1b[0m]"},
+	}
+
+	for i, tt := range tests {
+		l := &prettyLayout{width: tt.width, color: tt.color}
+
+		if got := l.render(tt.doc); got != tt.expected {
+			t.Errorf("At test case %d: expect the layout to be:\n%q\ngot:\n%q", i, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package vm
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// defaultPrettyPrintWidth is the line width `pp` and `pretty_inspect` fit the output in by default
+const defaultPrettyPrintWidth = 80
+
+// PrettyPrintColor decides when `pp` and the REPL color the values they print by their types
+type PrettyPrintColor int
+
+const (
+	// ColorAuto colors the output only when it's written to a terminal
+	ColorAuto PrettyPrintColor = iota
+	// ColorAlways always colors the output
+	ColorAlways
+	// ColorNever never colors the output
+	ColorNever
+)
+
+// PrettyPrintOptions configures how `pp` and the REPL print objects
+type PrettyPrintOptions struct {
+	// Width is the line width the output is fitted in. It's 80 if it's 0.
+	Width int
+	// Color decides when the values are colored with ANSI escape codes
+	Color PrettyPrintColor
+	// MaxItems is how many elements of an array, or pairs of a hash, are printed.
+	// The rest are elided like `... 990 more`, and all of them are printed if it's 0.
+	MaxItems int
+	// MaxStringLength is how many characters of a string are printed, and the rest are elided likewise
+	MaxStringLength int
+}
+
+// defaultPrettyPrintOptions are the options a vm starts with
+var defaultPrettyPrintOptions = PrettyPrintOptions{
+	Width:           defaultPrettyPrintWidth,
+	MaxItems:        100,
+	MaxStringLength: 1000,
+}
+
+// The ANSI color codes of the values
+const (
+	numberColor   = "36"
+	stringColor   = "32"
+	booleanColor  = "33"
+	nilColor      = "35"
+	elisionColor  = "90"
+	classObjColor = "1"
+)
+
+// isTerminal reports whether the writer is a terminal. It's a variable so tests can pretend to print to one.
+var isTerminal = func(w io.Writer) bool {
+	f, ok := w.(*os.File)
+
+	if !ok {
+		return false
+	}
+
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// SetPrettyPrintOptions changes how `pp` and the REPL print objects
+func (vm *VM) SetPrettyPrintOptions(opts PrettyPrintOptions) {
+	vm.prettyPrintOptions = opts
+}
+
+// prettyPrint returns the object's pretty-printed form for the vm's standard output, with the vm's options.
+// It's colored if the options say so, or the output is a terminal by default.
+func (vm *VM) prettyPrint(obj Object) string {
+	opts := vm.prettyPrintOptions
+	color := opts.Color == ColorAlways || opts.Color == ColorAuto && isTerminal(vm.stdout)
+	return prettyFormat(obj, opts, color)
+}
+
+// prettyInspect returns the object's inspect form. Arrays, hashes and objects that don't fit in the width
+// are broken into lines, with their elements indented by two spaces.
+// A collection or object that contains itself is written as `[...]`, `{...}` or `#<ClassName:id ...>` where it recurs.
+func prettyInspect(obj Object, width int) string {
+	return prettyFormat(obj, PrettyPrintOptions{Width: width}, false)
+}
+
+func prettyFormat(obj Object, opts PrettyPrintOptions, color bool) string {
+	if opts.Width <= 0 {
+		opts.Width = defaultPrettyPrintWidth
+	}
+
+	b := &prettyDocBuilder{opts: opts, visiting: make(map[Object]bool)}
+	l := &prettyLayout{width: opts.Width, color: color}
+	return l.render(b.build(obj))
+}
+
+// prettyDocBuilder turns objects into docs, eliding long collections and strings
+type prettyDocBuilder struct {
+	opts PrettyPrintOptions
+	// visiting holds the containers on the path from the root to the current object, for detecting cycles
+	visiting map[Object]bool
+}
+
+func (b *prettyDocBuilder) build(obj Object) *prettyDoc {
+	switch o := obj.(type) {
+	case *ArrayObject:
+		return b.elements(o, o.Elements)
+	case *ConcurrentArrayObject:
+		return b.elements(o, o.snapshot())
+	case *HashObject:
+		return b.pairs(o, o.Pairs)
+	case *ConcurrentHashObject:
+		return b.pairs(o, o.pairs())
+	case *RObject:
+		return b.object(o)
+	case *StringObject:
+		return b.string(o)
+	case *IntegerObject, *FloatObject, *DecimalObject, *BigIntegerObject, *RationalObject:
+		return leafDoc(o.Inspect(), numberColor)
+	case *BooleanObject:
+		return leafDoc(o.Inspect(), booleanColor)
+	case *NullObject:
+		return leafDoc(o.Inspect(), nilColor)
+	case *RClass:
+		return leafDoc(o.Inspect(), classObjColor)
+	default:
+		return leafDoc(obj.Inspect(), "")
+	}
+}
+
+func (b *prettyDocBuilder) elements(container Object, elems []Object) *prettyDoc {
+	if b.visiting[container] {
+		return leafDoc("[...]", "")
+	}
+
+	b.visiting[container] = true
+	shown, elided := b.limit(len(elems))
+	d := &prettyDoc{group: true, empty: "[]", open: "[", sep: ", ", close: "]", breakOpen: "[", breakSep: ",", breakClose: "]"}
+
+	for _, elem := range elems[:shown] {
+		d.children = append(d.children, b.build(elem))
+	}
+
+	d.children = appendElision(d.children, elided)
+	delete(b.visiting, container)
+	return d
+}
+
+func (b *prettyDocBuilder) pairs(container Object, pairs map[string]Object) *prettyDoc {
+	if b.visiting[container] {
+		return leafDoc("{...}", "")
+	}
+
+	keys := make([]string, 0, len(pairs))
+
+	for key := range pairs {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	b.visiting[container] = true
+	shown, elided := b.limit(len(keys))
+	d := &prettyDoc{group: true, empty: "{  }", open: "{ ", sep: ", ", close: " }", breakOpen: "{", breakSep: ",", breakClose: "}"}
+
+	for _, key := range keys[:shown] {
+		child := b.build(pairs[key])
+		child.prefix = key + ": "
+		d.children = append(d.children, child)
+	}
+
+	d.children = appendElision(d.children, elided)
+	delete(b.visiting, container)
+	return d
+}
+
+// object writes the instance variables in their inspect forms, so strings in them are quoted
+func (b *prettyDocBuilder) object(o *RObject) *prettyDoc {
+	head := fmt.Sprintf("#<%s:%d", o.class.Name, o.ID())
+
+	if b.visiting[o] {
+		return leafDoc(head+" ...>", "")
+	}
+
+	b.visiting[o] = true
+	d := &prettyDoc{group: true, empty: head + " >", open: head + " ", sep: " ", close: " >", breakOpen: head, breakClose: ">"}
+
+	for _, name := range o.InstanceVariables.names() {
+		v, _ := o.InstanceVariableGet(name)
+		child := b.build(v)
+		child.prefix = name + "="
+		d.children = append(d.children, child)
+	}
+
+	delete(b.visiting, o)
+	return d
+}
+
+func (b *prettyDocBuilder) string(s *StringObject) *prettyDoc {
+	chars := []rune(s.value)
+	max := b.opts.MaxStringLength
+
+	if max <= 0 || len(chars) <= max {
+		return leafDoc(s.Inspect(), stringColor)
+	}
+
+	shown := `"` + escapeSpecialChars(escapeBackslash(string(chars[:max]))) + `"`
+	return leafDoc(shown+"... "+strconv.Itoa(len(chars)-max)+" more", stringColor)
+}
+
+// limit returns how many of the items are shown, and how many are elided
+func (b *prettyDocBuilder) limit(count int) (shown, elided int) {
+	if b.opts.MaxItems <= 0 || count <= b.opts.MaxItems {
+		return count, 0
+	}
+
+	return b.opts.MaxItems, count - b.opts.MaxItems
+}
+
+func appendElision(children []*prettyDoc, elided int) []*prettyDoc {
+	if elided == 0 {
+		return children
+	}
+
+	return append(children, leafDoc("... "+strconv.Itoa(elided)+" more", elisionColor))
+}
@@ -0,0 +1,285 @@
+package vm
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestPrettyInspectMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`1.pretty_inspect`, `1`},
+		{`"foo".pretty_inspect`, `"foo"`},
+		{`[].pretty_inspect(1)`, `[]`},
+		{`[1, [2, "3"], { a: nil }].pretty_inspect`, `[1, [2, "3"], { a: nil }]`},
+		{`
+		{
+		  name: "goby",
+		  contributors: [{ name: "st0012", roles: ["maintainer", "reviewer"] }, { name: "hachi8833", roles: ["documentation", "reviewer"] }],
+		  version: "0.1.13"
+		}.pretty_inspect
+		`, `{
+  contributors: [
+    { name: "st0012", roles: ["maintainer", "reviewer"] },
+    { name: "hachi8833", roles: ["documentation", "reviewer"] }
+  ],
+  name: "goby",
+  version: "0.1.13"
+}`},
+		{`[[1, 2], { a: [3, 4] }].pretty_inspect(14)`, `[
+  [1, 2],
+  {
+    a: [3, 4]
+  }
+]`},
+		{`
+		class Foo
+		  def initialize
+		    @bar = [1, 2, 3]
+		    @baz = "baz"
+		  end
+		end
+
+		Foo.new.pretty_inspect(10).split("\n")[1..-1]
+		`, []interface{}{`  @bar=[`, `    1,`, `    2,`, `    3`, `  ]`, `  @baz="baz"`, `>`}},
+		{`
+		a = [1]
+		a.push(a)
+		a.pretty_inspect
+		`, `[1, [...]]`},
+		{`
+		h = { a: [1, 2, 3] }
+		h[:b] = h
+		h.pretty_inspect(10)
+		`, `{
+  a: [
+    1,
+    2,
+    3
+  ],
+  b: {...}
+}`},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestPrettyInspectMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`1.pretty_inspect("80")`, "TypeError: Expect argument to be Integer. got: String", 1},
+		{`1.pretty_inspect(0)`, "ArgumentError: Expect argument to be positive value. got: 0", 1},
+		{`1.pretty_inspect(80, 1)`, "ArgumentError: Expect 1 or less argument(s). got: 2", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestPPMethod(t *testing.T) {
+	tests := []struct {
+		input          string
+		expected       interface{}
+		expectedOutput string
+	}{
+		{`pp`, nil, ``},
+		{`pp([1, { a: "b" }])`, canonical(`[1, {"a": "b"}]`), "[1, { a: \"b\" }]\n"},
+		{`pp(1, "a")`, []interface{}{1, "a"}, "1\n\"a\"\n"},
+		{`
+		pp([
+		  { id: 1, name: "alpha", children: [{ id: 2, name: "beta", children: [{ id: 3, name: "gamma", children: [] }] }] }
+		])
+		nil
+		`, nil, `[
+  {
+    children: [
+      {
+        children: [{ children: [], id: 3, name: "gamma" }],
+        id: 2,
+        name: "beta"
+      }
+    ],
+    id: 1,
+    name: "alpha"
+  }
+]
+`},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		var out bytes.Buffer
+		v.SetStdout(&out)
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+
+		if out.String() != tt.expectedOutput {
+			t.Errorf("At test case %d: expect output to be:\n%s\ngot:\n%s", i, tt.expectedOutput, out.String())
+		}
+
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestPrettyInspectWidths(t *testing.T) {
+	data := `
+	data = {
+	  users: [{ name: "alice", tags: ["admin", "dev"], active: true }, { name: "bob", tags: [], active: false }],
+	  total: 2,
+	  ratio: 1.5,
+	  cursor: nil
+	}
+	`
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{data + `data.pretty_inspect(40)`, `{
+  cursor: nil,
+  ratio: 1.5,
+  total: 2,
+  users: [
+    {
+      active: true,
+      name: "alice",
+      tags: ["admin", "dev"]
+    },
+    {
+      active: false,
+      name: "bob",
+      tags: []
+    }
+  ]
+}`},
+		{data + `data.pretty_inspect(80)`, `{
+  cursor: nil,
+  ratio: 1.5,
+  total: 2,
+  users: [
+    { active: true, name: "alice", tags: ["admin", "dev"] },
+    { active: false, name: "bob", tags: [] }
+  ]
+}`},
+		{`[(1..10).to_a, { a: "x" * 30, b: "y" * 30 }].pretty_inspect(40)`, `[
+  [1, 2, 3, 4, 5, 6, 7, 8, 9, 10],
+  {
+    a: "xxxxxxxxxxxxxxxxxxxxxxxxxxxxxx",
+    b: "yyyyyyyyyyyyyyyyyyyyyyyyyyyyyy"
+  }
+]`},
+		{`[(1..10).to_a, { a: "x" * 30, b: "y" * 30 }].pretty_inspect(80)`, `[
+  [1, 2, 3, 4, 5, 6, 7, 8, 9, 10],
+  { a: "xxxxxxxxxxxxxxxxxxxxxxxxxxxxxx", b: "yyyyyyyyyyyyyyyyyyyyyyyyyyyyyy" }
+]`},
+		// pretty_inspect never elides
+		{`(1..1000).to_a.pretty_inspect.split("\n").length`, 1002},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestPPMethodElision(t *testing.T) {
+	tests := []struct {
+		input          string
+		opts           PrettyPrintOptions
+		expectedOutput string
+	}{
+		{`pp((1..1000).to_a)`, PrettyPrintOptions{MaxItems: 10}, "[1, 2, 3, 4, 5, 6, 7, 8, 9, 10, ... 990 more]\n"},
+		{`pp({ a: 1, b: 2, c: 3 })`, PrettyPrintOptions{MaxItems: 2}, "{ a: 1, b: 2, ... 1 more }\n"},
+		{`pp("abcdefgh")`, PrettyPrintOptions{MaxStringLength: 3}, "\"abc\"... 5 more\n"},
+		{`pp((1..5).to_a)`, PrettyPrintOptions{Width: 5, MaxItems: 2}, "[\n  1,\n  2,\n  ... 3 more\n]\n"},
+		{`pp((1..150).to_a.length)`, defaultPrettyPrintOptions, "150\n"},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		var out bytes.Buffer
+		v.SetStdout(&out)
+		v.SetPrettyPrintOptions(tt.opts)
+		v.testEval(t, tt.input, getFilename())
+
+		if out.String() != tt.expectedOutput {
+			t.Errorf("At test case %d: expect output to be:\n%q\ngot:\n%q", i, tt.expectedOutput, out.String())
+		}
+
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+
+	v := initTestVM()
+	var out bytes.Buffer
+	v.SetStdout(&out)
+	v.testEval(t, `pp((1..150).to_a)`, getFilename())
+
+	if !strings.HasSuffix(out.String(), "  100,\n  ... 50 more\n]\n") {
+		t.Errorf("Expect pp to elide collections after 100 items by default. got:\n%s", out.String())
+	}
+}
+
+func TestPPMethodColor(t *testing.T) {
+	defer func(original func(w io.Writer) bool) { isTerminal = original }(isTerminal)
+
+	input := `pp([1, "a", nil, true])`
+	colored := "[\x1b[36m1\x1b[0m, \x1b[32m\"a\"\x1b[0m, \x1b[35mnil\x1b[0m, \x1b[33mtrue\x1b[0m]\n"
+	plain := "[1, \"a\", nil, true]\n"
+
+	tests := []struct {
+		terminal       bool
+		color          PrettyPrintColor
+		expectedOutput string
+	}{
+		{false, ColorAuto, plain},
+		{true, ColorAuto, colored},
+		{true, ColorNever, plain},
+		{false, ColorAlways, colored},
+	}
+
+	for i, tt := range tests {
+		terminal := tt.terminal
+		isTerminal = func(w io.Writer) bool { return terminal }
+
+		v := initTestVM()
+		var out bytes.Buffer
+		v.SetStdout(&out)
+		opts := defaultPrettyPrintOptions
+		opts.Color = tt.color
+		v.SetPrettyPrintOptions(opts)
+		v.testEval(t, input, getFilename())
+
+		if out.String() != tt.expectedOutput {
+			t.Errorf("At test case %d: expect output to be:\n%q\ngot:\n%q", i, tt.expectedOutput, out.String())
+		}
+
+		// pretty_inspect returns a String, which is never colored
+		evaluated := v.testEval(t, `[1].pretty_inspect`, getFilename())
+		VerifyExpected(t, i, evaluated, "[1]")
+	}
+
+	var out bytes.Buffer
+
+	if isTerminal(&out) {
+		t.Errorf("Expect a buffer not to be a terminal")
+	}
+}
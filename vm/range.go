@@ -9,6 +9,7 @@ import (
 
 // RangeObject is the built in range class
 // Range represents an interval: a set of values from the beginning to the end specified.
+// A range written with `..` includes its end, and a range written with `...` doesn't.
 // Currently, only Integer objects or integer literal are supported.
 //
 // ```ruby
@@ -27,10 +28,18 @@ import (
 // end
 // ```
 //
+// ```ruby
+// (1..5).to_a  # => [1, 2, 3, 4, 5]
+// (1...5).to_a # => [1, 2, 3, 4]
+// (5...1).to_a # => [5, 4, 3, 2]
+// ```
+//
 type RangeObject struct {
 	*BaseObj
 	Start int
 	End   int
+	// Exclusive is true for a range written with `...`, whose End isn't one of its values
+	Exclusive bool
 }
 
 // Class methods --------------------------------------------------------
@@ -89,19 +98,21 @@ var builtinRangeInstanceMethods = []*BuiltinMethodObject{
 		// @return [Integer]
 		Name: "bsearch",
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
-			ro := receiver.(*RangeObject)
+			if blockFrame == nil {
+				return t.vm.InitErrorObject(errors.InternalError, sourceLine, errors.CantYieldWithoutBlockFormat)
+			}
+
+			first, last, ok := receiver.(*RangeObject).bounds()
 
-			if ro.Start < 0 || ro.End < 0 {
-				// if block is not used, it should be popped
-				t.callFrameStack.pop()
+			if !ok || first < 0 || last < 0 {
 				return NULL
 			}
 
 			var start, end int
-			if ro.Start < ro.End {
-				start, end = ro.Start, ro.End
+			if first < last {
+				start, end = first, last
 			} else {
-				start, end = ro.End, ro.Start
+				start, end = last, first
 			}
 
 			// the element of the range
@@ -117,7 +128,11 @@ var builtinRangeInstanceMethods = []*BuiltinMethodObject{
 					mid++
 				}
 
-				result := t.builtinMethodYield(blockFrame, t.vm.InitIntegerObject(mid))
+				result, erred := t.builtinMethodYield(blockFrame, t.vm.InitIntegerObject(mid))
+
+				if erred {
+					return result
+				}
 
 				switch r := result.(type) {
 				case *BooleanObject:
@@ -160,6 +175,46 @@ var builtinRangeInstanceMethods = []*BuiltinMethodObject{
 
 		},
 	},
+	{
+		// Returns true if the given number is between the beginning and the end of the range, or if all the values
+		// of the given range are. Unlike `include?`, it also accepts a Float, which is compared with both ends,
+		// so the number doesn't have to be one of the values of the range.
+		//
+		// ```ruby
+		// (1..5).cover?(5)     # => true
+		// (1...5).cover?(5)    # => false
+		// (1...5).cover?(4.5)  # => true
+		// (5..1).cover?(2.5)   # => true
+		// (1..5).cover?(2..4)  # => true
+		// (1..5).cover?(2...6) # => true
+		// (1..5).cover?(4..6)  # => false
+		// (1..5).cover?(3...3) # => false
+		// ```
+		//
+		// @param value [Integer, Float, Range]
+		// @return [Boolean]
+		Name: "cover?",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			ro := receiver.(*RangeObject)
+
+			switch v := args[0].(type) {
+			case *IntegerObject:
+				return toBooleanObject(ro.covers(float64(v.value)))
+			case *FloatObject:
+				return toBooleanObject(ro.covers(v.value))
+			case *RangeObject:
+				first, last, ok := v.bounds()
+				return toBooleanObject(ok && ro.covers(float64(first)) && ro.covers(float64(last)))
+			default:
+				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, "Integer, Float or Range", v.Class().Name)
+			}
+
+		},
+	},
 	{
 		// Iterates over the elements of range, passing each in turn to the block.
 		// Returns `nil`.
@@ -176,28 +231,50 @@ var builtinRangeInstanceMethods = []*BuiltinMethodObject{
 		//   sum = sum + i
 		// end
 		// sum # => -15
+		//
+		// e = (1..3).each
+		// e.next # => 1
+		// e.to_a # => [1, 2, 3]
+		//
+		// sum = 0
+		// (1...5).each do |i|
+		//   sum = sum + i
+		// end
+		// sum # => 10
 		// ```
 		//
+		// Without a block, returns a RangeEnumerator of the values instead.
+		//
 		// **Note:**
 		// - Only `do`-`end` block is supported: `{ }` block is unavailable.
-		// - Three-dot range `...` is not supported yet.
 		//
 		// @return [Range]
 		Name: "each",
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
 			ro := receiver.(*RangeObject)
 
 			if blockFrame == nil {
-				return t.vm.InitErrorObject(errors.InternalError, sourceLine, errors.CantYieldWithoutBlockFormat)
+				return t.callMethod(receiver, "to_enum", sourceLine)
 			}
 
-			ro.each(func(i int) error {
+			err := ro.each(func(i int) *Error {
 				obj := t.vm.InitIntegerObject(i)
-				t.builtinMethodYield(blockFrame, obj)
+
+				if result, erred := t.builtinMethodYield(blockFrame, obj); erred {
+					return result.(*Error)
+				}
 
 				return nil
 			})
 
+			if err != nil {
+				return err
+			}
+
 			return ro
 
 		},
@@ -234,6 +311,8 @@ var builtinRangeInstanceMethods = []*BuiltinMethodObject{
 		// (1..-5).include?(-2)  # => true
 		// (-2..-5).include?(-2) # => true
 		// (-3..-5).include?(-2) # => false
+		// (5...10).include?(10) # => false
+		// (5...10).include?(9)  # => true
 		// ```
 		//
 		// @param number [Integer]
@@ -246,14 +325,13 @@ var builtinRangeInstanceMethods = []*BuiltinMethodObject{
 
 			ro := receiver.(*RangeObject)
 
-			value := args[0].(*IntegerObject).value
-			ascendRangeBool := ro.Start <= ro.End && value >= ro.Start && value <= ro.End
-			descendRangeBool := ro.End <= ro.Start && value <= ro.Start && value >= ro.End
+			i, ok := args[0].(*IntegerObject)
 
-			if ascendRangeBool || descendRangeBool {
-				return TRUE
+			if !ok {
+				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.IntegerClass, args[0].Class().Name)
 			}
-			return FALSE
+
+			return toBooleanObject(ro.covers(float64(i.value)))
 
 		},
 	},
@@ -298,21 +376,93 @@ var builtinRangeInstanceMethods = []*BuiltinMethodObject{
 			ro := receiver.(*RangeObject)
 			var el []Object
 
-			ro.each(func(i int) error {
+			err := ro.each(func(i int) *Error {
 				if blockIsEmpty(blockFrame) {
 					el = append(el, NULL)
 				} else {
 					obj := t.vm.InitIntegerObject(i)
-					el = append(el, t.builtinMethodYield(blockFrame, obj))
+					result, erred := t.builtinMethodYield(blockFrame, obj)
+
+					if erred {
+						return result.(*Error)
+					}
+
+					el = append(el, result)
 				}
 
 				return nil
 			})
 
+			if err != nil {
+				return err
+			}
+
 			return t.vm.InitArrayObject(el)
 
 		},
 	},
+	{
+		// Returns the largest value of the range, or nil if the range has no value.
+		//
+		// ```ruby
+		// (1..5).max   # => 5
+		// (1...5).max  # => 4
+		// (5...1).max  # => 5
+		// (-1..-5).max # => -1
+		// (3...3).max  # => nil
+		// ```
+		//
+		// @return [Integer]
+		Name: "max",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			first, last, ok := receiver.(*RangeObject).bounds()
+
+			if !ok {
+				return NULL
+			}
+
+			if first > last {
+				return t.vm.InitIntegerObject(first)
+			}
+			return t.vm.InitIntegerObject(last)
+
+		},
+	},
+	{
+		// Returns the smallest value of the range, or nil if the range has no value.
+		//
+		// ```ruby
+		// (1..5).min   # => 1
+		// (1...5).min  # => 1
+		// (5...1).min  # => 2
+		// (-1..-5).min # => -5
+		// (3...3).min  # => nil
+		// ```
+		//
+		// @return [Integer]
+		Name: "min",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			first, last, ok := receiver.(*RangeObject).bounds()
+
+			if !ok {
+				return NULL
+			}
+
+			if first < last {
+				return t.vm.InitIntegerObject(first)
+			}
+			return t.vm.InitIntegerObject(last)
+
+		},
+	},
 	{
 		// Returns the size of the range
 		//
@@ -321,17 +471,22 @@ var builtinRangeInstanceMethods = []*BuiltinMethodObject{
 		// (3..9).size   # => 7
 		// (-1..-5).size # => 5
 		// (-1..7).size  # => 9
+		// (1...5).size  # => 4
+		// (5...5).size  # => 0
 		// ```
 		//
 		// @return [Integer]
 		Name: "size",
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
-			ro := receiver.(*RangeObject)
+			first, last, ok := receiver.(*RangeObject).bounds()
 
-			if ro.Start <= ro.End {
-				return t.vm.InitIntegerObject(ro.End - ro.Start + 1)
+			switch {
+			case !ok:
+				return t.vm.InitIntegerObject(0)
+			case first <= last:
+				return t.vm.InitIntegerObject(last - first + 1)
 			}
-			return t.vm.InitIntegerObject(ro.Start - ro.End + 1)
+			return t.vm.InitIntegerObject(first - last + 1)
 
 		},
 	},
@@ -363,6 +518,12 @@ var builtinRangeInstanceMethods = []*BuiltinMethodObject{
 		//   sum = sum + 1
 		// end
 		// sum # => 0
+		//
+		// a = []
+		// (1...10).step(3) do |i|
+		//   a.push(i)
+		// end
+		// a # => [1, 4, 7]
 		// ```
 		//
 		// @param positive number [Integer]
@@ -377,29 +538,35 @@ var builtinRangeInstanceMethods = []*BuiltinMethodObject{
 				return t.vm.InitErrorObject(errors.InternalError, sourceLine, errors.CantYieldWithoutBlockFormat)
 			}
 
+			s, ok := args[0].(*IntegerObject)
+
+			if !ok {
+				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.IntegerClass, args[0].Class().Name)
+			}
+
 			ro := receiver.(*RangeObject)
-			step := args[0].(*IntegerObject).value
+			step := s.value
 			if step <= 0 {
 				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.NegativeValue, step)
 			}
 
-			blockFrameUsed := false
 
-			ro.each(func(i int) error {
+			err := ro.each(func(i int) *Error {
 				if (i-ro.Start)%step != 0 {
 					return nil
 				}
 
 				obj := t.vm.InitIntegerObject(i)
-				t.builtinMethodYield(blockFrame, obj)
-				blockFrameUsed = true
+
+				if result, erred := t.builtinMethodYield(blockFrame, obj); erred {
+					return result.(*Error)
+				}
 
 				return nil
 			})
 
-			// if block is not used, it should be popped
-			if !blockFrameUsed {
-				t.callFrameStack.pop()
+			if err != nil {
+				return err
 			}
 
 			return ro
@@ -414,24 +581,19 @@ var builtinRangeInstanceMethods = []*BuiltinMethodObject{
 		// (1..5).to_a[2]  # => 3
 		// (-1..-5).to_a   # => [-1, -2, -3, -4, -5]
 		// (-1..3).to_a    # => [-1, 0, 1, 2, 3]
+		// (1...5).to_a    # => [1, 2, 3, 4]
+		// (1...1).to_a    # => []
 		// ```
 		//
 		// @return [Array]
 		Name: "to_a",
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
-			var offset int
-
-			ro := receiver.(*RangeObject)
-			if ro.Start <= ro.End {
-				offset = 1
-			} else {
-				offset = -1
-			}
-
 			el := []Object{}
-			for i := ro.Start; i != (ro.End + offset); i += offset {
+
+			receiver.(*RangeObject).each(func(i int) *Error {
 				el = append(el, t.vm.InitIntegerObject(i))
-			}
+				return nil
+			})
 
 			return t.vm.InitArrayObject(el)
 
@@ -443,6 +605,7 @@ var builtinRangeInstanceMethods = []*BuiltinMethodObject{
 		// ```ruby
 		// (1..5).to_s   # "(1..5)"
 		// (-1..-3).to_s # "(-1..-3)"
+		// (1...5).to_s  # "(1...5)"
 		// ```
 		//
 		// @return [String]
@@ -460,11 +623,12 @@ var builtinRangeInstanceMethods = []*BuiltinMethodObject{
 
 // Functions for initialization -----------------------------------------
 
-func (vm *VM) initRangeObject(start, end int) *RangeObject {
+func (vm *VM) initRangeObject(start, end int, exclusive bool) *RangeObject {
 	return &RangeObject{
-		BaseObj: NewBaseObject(vm.TopLevelClass(classes.RangeClass)),
-		Start:   start,
-		End:     end,
+		BaseObj:   NewBaseObject(vm.TopLevelClass(classes.RangeClass)),
+		Start:     start,
+		End:       end,
+		Exclusive: exclusive,
 	}
 }
 
@@ -481,6 +645,10 @@ func (vm *VM) initRangeClass() *RClass {
 
 // ToString returns the object's name as the string format
 func (ro *RangeObject) ToString() string {
+	if ro.Exclusive {
+		return fmt.Sprintf("(%d...%d)", ro.Start, ro.End)
+	}
+
 	return fmt.Sprintf("(%d..%d)", ro.Start, ro.End)
 }
 
@@ -491,7 +659,7 @@ func (ro *RangeObject) Inspect() string {
 
 // ToJSON just delegates to ToString
 func (ro *RangeObject) ToJSON(t *Thread) string {
-	return ro.ToString()
+	return quoteJSON(ro.ToString())
 }
 
 // Value returns range object's string format
@@ -499,15 +667,47 @@ func (ro *RangeObject) Value() interface{} {
 	return ro.ToString()
 }
 
-func (ro *RangeObject) each(f func(int) error) (err error) {
-	var inc int
-	if ro.End-ro.Start >= 0 {
-		inc = 1
-	} else {
+// bounds returns the first and the last values of the range,
+// and false if the range has no value, which happens to an exclusive range starting at its end
+func (ro *RangeObject) bounds() (first, last int, ok bool) {
+	switch {
+	case !ro.Exclusive:
+		return ro.Start, ro.End, true
+	case ro.Start < ro.End:
+		return ro.Start, ro.End - 1, true
+	case ro.Start > ro.End:
+		return ro.Start, ro.End + 1, true
+	}
+
+	return 0, 0, false
+}
+
+// covers reports whether the number is between the start and the end of the range, in either order.
+// The end is left out for an exclusive range.
+func (ro *RangeObject) covers(v float64) bool {
+	start, end := float64(ro.Start), float64(ro.End)
+
+	if ro.Exclusive && v == end {
+		return false
+	}
+
+	return start <= v && v <= end || end <= v && v <= start
+}
+
+// each calls f with each integer in the range, and stops at the first error f returns
+func (ro *RangeObject) each(f func(int) *Error) (err *Error) {
+	first, last, ok := ro.bounds()
+
+	if !ok {
+		return
+	}
+
+	inc := 1
+	if last < first {
 		inc = -1
 	}
 
-	for i := ro.Start; i != ro.End+inc; i += inc {
+	for i := first; i != last+inc; i += inc {
 		if err = f(i); err != nil {
 			return err
 		}
@@ -523,7 +723,7 @@ func (ro *RangeObject) equalTo(with Object) bool {
 		return false
 	}
 
-	if ro.Start == right.Start && ro.End == right.End {
+	if ro.Start == right.Start && ro.End == right.End && ro.Exclusive == right.Exclusive {
 		return true
 	}
 
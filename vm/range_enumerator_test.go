@@ -35,6 +35,32 @@ func TestRangeEnumeratorEnumerationWithElements(t *testing.T) {
 		`,
 			[]interface{}{3, 2, 1},
 		},
+		{`
+		iterated_values = []
+
+		enumerator = RangeEnumerator.new((3...0))
+
+		while enumerator.has_next? do
+			iterated_values.push(enumerator.next)
+		end
+
+		iterated_values
+		`,
+			[]interface{}{3, 2, 1},
+		},
+		{`
+		iterated_values = []
+
+		enumerator = RangeEnumerator.new((1...1))
+
+		while enumerator.has_next? do
+			iterated_values.push(enumerator.next)
+		end
+
+		iterated_values
+		`,
+			[]interface{}{},
+		},
 	}
 
 	for i, tt := range tests {
@@ -189,6 +189,24 @@ func TestRangeBsearchMethod(t *testing.T) {
 			0 - ary[i]
 		end
 		`, nil},
+		// the end of an exclusive range isn't searched
+		{`
+		ary = [0, 4, 7, 10, 12]
+		(0...4).bsearch do |i|
+			ary[i] >= 10
+		end
+		`, 3},
+		{`
+		ary = [0, 4, 7, 10, 12]
+		(0...4).bsearch do |i|
+			ary[i] >= 12
+		end
+		`, nil},
+		{`
+		(2...2).bsearch do |i|
+			true
+		end
+		`, nil},
 	}
 
 	for i, tt := range tests {
@@ -201,16 +219,17 @@ func TestRangeBsearchMethod(t *testing.T) {
 }
 
 func TestRangeBsearchMethodFail(t *testing.T) {
-	v := initTestVM()
 	testsFail := []errorTestCase{
 		{`ary = [0, 4, 7, 10, 12]
 		(0..4).bsearch do |i|
 			"Binary Search"
 		end
 		`, "TypeError: Expect argument to be Integer or Boolean. got: String", 1},
+		{`(0..4).bsearch`, "InternalError: Can't yield without a block", 1},
 	}
 
 	for i, tt := range testsFail {
+		v := initTestVM()
 		evaluated := v.testEval(t, tt.input, getFilename())
 		checkErrorMsg(t, i, evaluated, tt.expected)
 		v.checkCFP(t, i, tt.expectedCFP)
@@ -266,6 +285,38 @@ func TestRangeEachMethod(t *testing.T) {
 		end
 		r
 		`, -15},
+		// returns an enumerator without a block
+		{`(0..4).each.class.name`, "RangeEnumerator"},
+		{`(0..4).each.to_a`, []interface{}{0, 1, 2, 3, 4}},
+		{`(3..1).each.map do |i| i * 2 end`, []interface{}{6, 4, 2}},
+		{`
+		e = (1..3).each
+		[e.next, e.next, e.to_a.length, e.next, e.has_next?]
+		`, []interface{}{1, 2, 3, 3, false}},
+		// an exclusive range leaves its end out
+		{`
+		r = []
+		(1...5).each do |i|
+		  r.push(i)
+		end
+		r
+		`, []interface{}{1, 2, 3, 4}},
+		{`
+		r = []
+		(5...1).each do |i|
+		  r.push(i)
+		end
+		r
+		`, []interface{}{5, 4, 3, 2}},
+		{`
+		r = []
+		(3...3).each do |i|
+		  r.push(i)
+		end
+		r
+		`, []interface{}{}},
+		{`(1...4).each.to_a`, []interface{}{1, 2, 3}},
+		{`(2...2).each.has_next?`, false},
 	}
 
 	for i, tt := range tests {
@@ -281,8 +332,9 @@ func TestRangeEachMethodFail(t *testing.T) {
 	v := initTestVM()
 	testsFail := []errorTestCase{
 		{`
-		(0..4).each
-		`, "InternalError: Can't yield without a block", 1},
+		(0..4).each(1) do |i|
+		end
+		`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
 	}
 
 	for i, tt := range testsFail {
@@ -359,6 +411,11 @@ func TestRangeIncludeMethod(t *testing.T) {
 		{`
 		(-3..-5).include?(-2)
 		`, false},
+		{`(5...10).include?(10)`, false},
+		{`(5...10).include?(9)`, true},
+		{`(10...5).include?(5)`, false},
+		{`(10...5).include?(10)`, true},
+		{`(5...5).include?(5)`, false},
 	}
 
 	for i, tt := range tests {
@@ -374,6 +431,8 @@ func TestRangeIncludeMethodFail(t *testing.T) {
 	testsFail := []errorTestCase{
 		{`(1..4).include?`, "ArgumentError: Expect 1 argument(s). got: 0", 1},
 		{`(1..4).include?(1, 2)`, "ArgumentError: Expect 1 argument(s). got: 2", 1},
+		{`(1..4).include?(nil)`, "TypeError: Expect argument to be Integer. got: Null", 1},
+		{`(1..4).include?("2")`, "TypeError: Expect argument to be Integer. got: String", 1},
 	}
 
 	for i, tt := range testsFail {
@@ -475,6 +534,9 @@ func TestRangeSizeMethod(t *testing.T) {
 		{`
 		(-1..7).size
 		`, 9},
+		{`(1...5).size`, 4},
+		{`(-1...-5).size`, 4},
+		{`(5...5).size`, 0},
 	}
 
 	for i, tt := range tests {
@@ -535,6 +597,27 @@ func TestRangeStepMethod(t *testing.T) {
 		 end
 		 sum
 		`, -9},
+		{`
+		r = []
+		(1...10).step(3) do |i|
+		  r.push(i)
+		end
+		r
+		`, []interface{}{1, 4, 7}},
+		{`
+		r = []
+		(1...7).step(3) do |i|
+		  r.push(i)
+		end
+		r
+		`, []interface{}{1, 4}},
+		{`
+		r = []
+		(10...0).step(5) do |i|
+		  r.push(i)
+		end
+		r
+		`, []interface{}{10, 5}},
 	}
 
 	for i, tt := range tests {
@@ -563,6 +646,11 @@ func TestRangeStepMethodFail(t *testing.T) {
 								i
 							end
 `, "ArgumentError: Expect argument to be positive value. got: -1", 4},
+		{
+			` (1..10).step("2") do |i|
+								i
+							end
+`, "TypeError: Expect argument to be Integer. got: String", 5},
 	}
 
 	for i, tt := range testsFail {
@@ -589,6 +677,8 @@ func TestRangeToStringMethod(t *testing.T) {
 		{`
 		(1..-5).to_s
 		`, "(1..-5)"},
+		{`(1...5).to_s`, "(1...5)"},
+		{`(5...-1).inspect`, "(5...-1)"},
 	}
 
 	for i, tt := range tests {
@@ -623,6 +713,10 @@ func TestRangeToArrayMethod(t *testing.T) {
 		{`
 		(-1..3).to_a[2]
 		`, 1},
+		{`(1...5).to_a`, []interface{}{1, 2, 3, 4}},
+		{`(-1...-4).to_a`, []interface{}{-1, -2, -3}},
+		{`(1...1).to_a`, []interface{}{}},
+		{`(1...2).to_a`, []interface{}{1}},
 	}
 
 	for i, tt := range tests {
@@ -662,6 +756,8 @@ func TestRangeDupMethod(t *testing.T) {
 		expected interface{}
 	}{
 		{`(1..2).dup == (1..2)`, true},
+		{`(1...2).dup == (1...2)`, true},
+		{`(1...2) == (1..2)`, false},
 	}
 
 	for i, tt := range tests {
@@ -672,3 +768,94 @@ func TestRangeDupMethod(t *testing.T) {
 		v.checkSP(t, i, 1)
 	}
 }
+
+func TestRangeCoverMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`(1..5).cover?(5)`, true},
+		{`(1..5).cover?(1)`, true},
+		{`(1..5).cover?(0)`, false},
+		{`(1...5).cover?(5)`, false},
+		{`(1...5).cover?(4)`, true},
+		{`(1...5).cover?(4.5)`, true},
+		{`(1..5).cover?(5.5)`, false},
+		{`(5..1).cover?(2.5)`, true},
+		{`(5...1).cover?(1)`, false},
+		{`(5...1).cover?(1.5)`, true},
+		{`(3...3).cover?(3)`, false},
+		{`(1..5).cover?(2..4)`, true},
+		{`(1..5).cover?(2...6)`, true},
+		{`(1..5).cover?(4..6)`, false},
+		{`(1..5).cover?(4..2)`, true},
+		{`(1...5).cover?(1..5)`, false},
+		{`(1..5).cover?(3...3)`, false},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestRangeCoverMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`(1..4).cover?`, "ArgumentError: Expect 1 argument(s). got: 0", 1},
+		{`(1..4).cover?(1, 2)`, "ArgumentError: Expect 1 argument(s). got: 2", 1},
+		{`(1..4).cover?("2")`, "TypeError: Expect argument to be Integer, Float or Range. got: String", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestRangeMinMaxMethods(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`(1..5).min`, 1},
+		{`(1..5).max`, 5},
+		{`(1...5).max`, 4},
+		{`(5..1).min`, 1},
+		{`(5...1).min`, 2},
+		{`(5...1).max`, 5},
+		{`(-1..-5).min`, -5},
+		{`(-1..-5).max`, -1},
+		{`(3..3).min`, 3},
+		{`(3...3).min`, nil},
+		{`(3...3).max`, nil},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestRangeMinMaxMethodsFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`(1..4).min(1)`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+		{`(1..4).max(1)`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
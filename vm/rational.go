@@ -0,0 +1,532 @@
+package vm
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/goby-lang/goby/vm/classes"
+	"github.com/goby-lang/goby/vm/errors"
+)
+
+// RationalObject represents an exact fraction, backed by Go's `big.Rat` from math/big package.
+// Rationals are always kept in lowest terms, with the sign in the numerator.
+//
+// Arithmetic between Rationals, Integers and BigIntegers keeps the result exact; Floats make it a Float.
+//
+// ```ruby
+// a = Rational(1, 3)
+// a + Rational(1, 6) # => (1/2)
+// a * 3              # => (1/1)
+// a.to_f             # => 0.3333333333333333
+// ```
+//
+// - `Rational.new` is not supported; use `Rational(numerator, denominator)` instead.
+type RationalObject struct {
+	*BaseObj
+	value *big.Rat
+}
+
+// Class methods --------------------------------------------------------
+var builtinRationalClassMethods = []*BuiltinMethodObject{
+	{
+		Name: "new",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return t.vm.InitNoMethodError(sourceLine, "new", receiver)
+
+		},
+	},
+}
+
+// Instance methods -----------------------------------------------------
+var builtinRationalInstanceMethods = []*BuiltinMethodObject{
+	{
+		// Returns the sum of self and another Numeric.
+		//
+		// ```ruby
+		// Rational(1, 3) + Rational(1, 6) # => (1/2)
+		// Rational(1, 3) + 1              # => (4/3)
+		// Rational(1, 2) + 0.25           # => 0.75
+		// ```
+		// @return [Numeric]
+		Name: "+",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			floatOperation := func(leftValue float64, rightValue float64) float64 {
+				return leftValue + rightValue
+			}
+
+			return receiver.(*RationalObject).arithmeticOperation(t, args[0], (*big.Rat).Add, floatOperation, sourceLine, false)
+
+		},
+	},
+	{
+		// Returns the subtraction of another Numeric from self.
+		//
+		// ```ruby
+		// Rational(1, 2) - Rational(1, 3) # => (1/6)
+		// ```
+		// @return [Numeric]
+		Name: "-",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			floatOperation := func(leftValue float64, rightValue float64) float64 {
+				return leftValue - rightValue
+			}
+
+			return receiver.(*RationalObject).arithmeticOperation(t, args[0], (*big.Rat).Sub, floatOperation, sourceLine, false)
+
+		},
+	},
+	{
+		// Returns self multiplying another Numeric.
+		//
+		// ```ruby
+		// Rational(2, 3) * Rational(3, 4) # => (1/2)
+		// ```
+		// @return [Numeric]
+		Name: "*",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			floatOperation := func(leftValue float64, rightValue float64) float64 {
+				return leftValue * rightValue
+			}
+
+			return receiver.(*RationalObject).arithmeticOperation(t, args[0], (*big.Rat).Mul, floatOperation, sourceLine, false)
+
+		},
+	},
+	{
+		// Returns self to the power of another Numeric.
+		// Integer exponents keep the result exact, while other exponents make it a Float.
+		//
+		// ```ruby
+		// Rational(2, 3) ** 2  # => (4/9)
+		// Rational(2, 3) ** -1 # => (3/2)
+		// Rational(1, 4) ** 0.5 # => 0.5
+		// ```
+		// @return [Numeric]
+		Name: "**",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			r := receiver.(*RationalObject)
+
+			switch exponent := args[0].(type) {
+			case *IntegerObject:
+				if exponent.value < 0 && r.value.Sign() == 0 {
+					return t.vm.InitErrorObject(errors.ZeroDivisionError, sourceLine, errors.DividedByZero)
+				}
+
+				return t.vm.initRationalObject(r.pow(exponent.value))
+			case *BigIntegerObject:
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.ExponentTooLarge, exponent.ToString())
+			case Numeric:
+				return t.vm.initFloatObject(math.Pow(r.floatValue(), exponent.floatValue()))
+			default:
+				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, "Numeric", args[0].Class().Name)
+			}
+
+		},
+	},
+	{
+		// Returns self divided by another Numeric.
+		//
+		// ```ruby
+		// Rational(1, 2) / Rational(1, 4) # => (2/1)
+		// Rational(1, 2) / 3              # => (1/6)
+		// Rational(1, 2) / 0.0            # => Infinity
+		// ```
+		// @return [Numeric]
+		Name: "/",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			floatOperation := func(leftValue float64, rightValue float64) float64 {
+				return leftValue / rightValue
+			}
+
+			return receiver.(*RationalObject).arithmeticOperation(t, args[0], (*big.Rat).Quo, floatOperation, sourceLine, true)
+
+		},
+	},
+	{
+		// Returns the modulo of self divided by another Numeric, which has the sign of the divisor like Integer's `%`.
+		//
+		// ```ruby
+		// Rational(7, 2) % 2               # => (3/2)
+		// Rational(-7, 2) % 2              # => (1/2)
+		// Rational(7, 2) % Rational(-2, 3) # => (-1/2)
+		// Rational(7, 2) % 1.5             # => 0.5
+		// ```
+		// @return [Numeric]
+		Name: "%",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return receiver.(*RationalObject).arithmeticOperation(t, args[0], floorModRat, floorModFloat, sourceLine, true)
+
+		},
+	},
+	{
+		// Returns if self is larger than another Numeric.
+		//
+		// ```ruby
+		// Rational(1, 2) > Rational(1, 3) # => true
+		// ```
+		// @return [Boolean]
+		Name: ">",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return receiver.(*RationalObject).numericComparison(t, args[0], sourceLine, func(result int) bool {
+				return result > 0
+			})
+
+		},
+	},
+	{
+		// Returns if self is larger than or equals to another Numeric.
+		//
+		// ```ruby
+		// Rational(1, 2) >= Rational(2, 4) # => true
+		// ```
+		// @return [Boolean]
+		Name: ">=",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return receiver.(*RationalObject).numericComparison(t, args[0], sourceLine, func(result int) bool {
+				return result >= 0
+			})
+
+		},
+	},
+	{
+		// Returns if self is smaller than another Numeric.
+		//
+		// ```ruby
+		// Rational(1, 3) < 0.5 # => true
+		// ```
+		// @return [Boolean]
+		Name: "<",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return receiver.(*RationalObject).numericComparison(t, args[0], sourceLine, func(result int) bool {
+				return result < 0
+			})
+
+		},
+	},
+	{
+		// Returns if self is smaller than or equals to another Numeric.
+		//
+		// ```ruby
+		// Rational(2, 2) <= 1 # => true
+		// ```
+		// @return [Boolean]
+		Name: "<=",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return receiver.(*RationalObject).numericComparison(t, args[0], sourceLine, func(result int) bool {
+				return result <= 0
+			})
+
+		},
+	},
+	{
+		// Returns 1 if self is larger than the incoming Numeric, -1 if smaller. Otherwise 0.
+		//
+		// ```ruby
+		// Rational(1, 2) <=> Rational(1, 3) # => 1
+		// Rational(1, 2) <=> 0.5            # => 0
+		// ```
+		// @return [Integer]
+		Name: "<=>",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if _, ok := args[0].(Numeric); !ok {
+				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, "Numeric", args[0].Class().Name)
+			}
+
+			result, ok := receiver.(*RationalObject).compare(args[0])
+
+			if !ok {
+				return NULL
+			}
+
+			return t.vm.InitIntegerObject(result)
+
+		},
+	},
+	{
+		// Returns the denominator, which is always positive.
+		//
+		// ```ruby
+		// Rational(2, -6).denominator # => 3
+		// ```
+		//
+		// @return [Integer]
+		Name: "denominator",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			return t.vm.initIntegerObjectFromBig(new(Int).Set(receiver.(*RationalObject).value.Denom()))
+
+		},
+	},
+	{
+		// Returns `[q, r]`, where q is `(self / divisor).floor` as an Integer and r is `self % divisor`.
+		// Raises a ZeroDivisionError if the divisor is 0.
+		//
+		// ```ruby
+		// Rational(7, 2).divmod(2)   # => [1, (3/2)]
+		// Rational(-7, 2).divmod(2)  # => [-2, (1/2)]
+		// Rational(7, 2).divmod(1.5) # => [2, 0.5]
+		// ```
+		//
+		// @param divisor [Numeric]
+		// @return [Array]
+		Name: "divmod",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			r := receiver.(*RationalObject)
+
+			if f, ok := args[0].(*FloatObject); ok {
+				return t.floatDivmod(r.floatValue(), f.value, sourceLine)
+			}
+
+			divisor, ok := ratOf(args[0])
+
+			if !ok {
+				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, "Numeric", args[0].Class().Name)
+			}
+
+			return t.ratDivmod(r.value, divisor, sourceLine)
+
+		},
+	},
+	{
+		// Returns the numerator, which carries the sign.
+		//
+		// ```ruby
+		// Rational(2, -6).numerator # => -1
+		// ```
+		//
+		// @return [Integer]
+		Name: "numerator",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			return t.vm.initIntegerObjectFromBig(new(Int).Set(receiver.(*RationalObject).value.Num()))
+
+		},
+	},
+	{
+		// Returns the nearest Float.
+		//
+		// ```ruby
+		// Rational(1, 4).to_f # => 0.25
+		// Rational(1, 3).to_f # => 0.3333333333333333
+		// ```
+		//
+		// @return [Float]
+		Name: "to_f",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			return t.vm.initFloatObject(receiver.(*RationalObject).floatValue())
+
+		},
+	},
+	{
+		// Returns the integer part, truncated toward zero.
+		//
+		// ```ruby
+		// Rational(7, 2).to_i  # => 3
+		// Rational(-7, 2).to_i # => -3
+		// ```
+		//
+		// @return [Integer]
+		Name: "to_i",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			r := receiver.(*RationalObject)
+
+			return t.vm.initIntegerObjectFromBig(new(Int).Quo(r.value.Num(), r.value.Denom()))
+
+		},
+	},
+	{
+		// Returns the Rational as a string of the form "numerator/denominator".
+		//
+		// ```ruby
+		// Rational(2, 4).to_s # => "1/2"
+		// ```
+		//
+		// @return [String]
+		Name: "to_s",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			return t.vm.InitStringObject(receiver.(*RationalObject).ToString())
+
+		},
+	},
+}
+
+// Internal functions ===================================================
+
+// Functions for initialization -----------------------------------------
+
+func (vm *VM) initRationalObject(value *big.Rat) *RationalObject {
+	return &RationalObject{
+		BaseObj: NewBaseObject(vm.TopLevelClass(classes.RationalClass)),
+		value:   value,
+	}
+}
+
+func (vm *VM) initRationalClass() *RClass {
+	rc := vm.initializeClass(classes.RationalClass)
+	rc.setBuiltinMethods(builtinRationalInstanceMethods, false)
+	rc.setBuiltinMethods(builtinRationalClassMethods, true)
+	return rc
+}
+
+// Polymorphic helper functions -----------------------------------------
+
+// Value returns the object
+func (r *RationalObject) Value() interface{} {
+	return r.value
+}
+
+// Numeric interface
+func (r *RationalObject) floatValue() float64 {
+	f, _ := r.value.Float64()
+	return f
+}
+
+// Apply the passed arithmetic operation, while performing type conversion.
+// Integers and BigIntegers keep the result exact, while Floats make it a Float by the rules of Float,
+// so dividing by 0.0 gives Infinity or NaN instead of raising a ZeroDivisionError.
+func (r *RationalObject) arithmeticOperation(
+	t *Thread,
+	rightObject Object,
+	ratOperation func(z *big.Rat, x *big.Rat, y *big.Rat) *big.Rat,
+	floatOperation func(leftValue float64, rightValue float64) float64,
+	sourceLine int,
+	division bool,
+) Object {
+	if f, ok := rightObject.(*FloatObject); ok {
+		return t.vm.initFloatObject(floatOperation(r.floatValue(), f.value))
+	}
+
+	rightValue, ok := ratOf(rightObject)
+
+	if !ok {
+		return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, "Numeric", rightObject.Class().Name)
+	}
+
+	if division && rightValue.Sign() == 0 {
+		return t.vm.InitErrorObject(errors.ZeroDivisionError, sourceLine, errors.DividedByZero)
+	}
+
+	return t.vm.initRationalObject(ratOperation(new(big.Rat), r.value, rightValue))
+}
+
+// compare returns -1, 0 or 1 like big.Rat's Cmp does, and false if the object isn't a Numeric or is NaN
+func (r *RationalObject) compare(rightObject Object) (int, bool) {
+	if f, ok := rightObject.(*FloatObject); ok {
+		switch {
+		case math.IsNaN(f.value):
+			return 0, false
+		case math.IsInf(f.value, 0):
+			return -int(math.Copysign(1, f.value)), true
+		default:
+			return r.value.Cmp(new(big.Rat).SetFloat64(f.value)), true
+		}
+	}
+
+	rightValue, ok := ratOf(rightObject)
+
+	if !ok {
+		return 0, false
+	}
+
+	return r.value.Cmp(rightValue), true
+}
+
+// Apply the passed comparison to the result of compare, or raise a TypeError if the object isn't a Numeric.
+// Comparisons with NaN are always false.
+func (r *RationalObject) numericComparison(t *Thread, rightObject Object, sourceLine int, comparison func(result int) bool) Object {
+	if _, ok := rightObject.(Numeric); !ok {
+		return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, "Numeric", rightObject.Class().Name)
+	}
+
+	result, ok := r.compare(rightObject)
+
+	return toBooleanObject(ok && comparison(result))
+}
+
+func (r *RationalObject) lessThan(arg Object) bool {
+	result, ok := r.compare(arg)
+	return ok && result < 0
+}
+
+// pow returns the exact power of the Rational by an Integer; a negative exponent inverts the result
+func (r *RationalObject) pow(exponent int) *big.Rat {
+	e := big.NewInt(int64(exponent))
+	e.Abs(e)
+
+	num := new(Int).Exp(r.value.Num(), e, nil)
+	denom := new(Int).Exp(r.value.Denom(), e, nil)
+
+	if exponent < 0 {
+		num, denom = denom, num
+	}
+
+	return new(big.Rat).SetFrac(num, denom)
+}
+
+// ToString returns the object's value in the form of "numerator/denominator"
+func (r *RationalObject) ToString() string {
+	return r.value.String()
+}
+
+// Inspect returns the object's value in the form of "(numerator/denominator)"
+func (r *RationalObject) Inspect() string {
+	return "(" + r.value.String() + ")"
+}
+
+// ToJSON returns the object's value as a JSON string, since JSON has no fractions
+func (r *RationalObject) ToJSON(t *Thread) string {
+	return quoteJSON(r.ToString())
+}
+
+func (r *RationalObject) equalTo(with Object) bool {
+	w, ok := with.(*RationalObject)
+	return ok && r.value.Cmp(w.value) == 0
+}
+
+// Other helper functions -----------------------------------------------
+
+// ratOperations are the exact operations of the arithmetic operators Integers apply to Rationals
+var ratOperations = map[string]func(z *big.Rat, x *big.Rat, y *big.Rat) *big.Rat{
+	"+": (*big.Rat).Add,
+	"-": (*big.Rat).Sub,
+	"*": (*big.Rat).Mul,
+	"/": (*big.Rat).Quo,
+	"%": floorModRat,
+}
+
+// ratOf returns the exact value of a Rational, an Integer or a BigInteger as a big.Rat
+func ratOf(obj Object) (*big.Rat, bool) {
+	if r, ok := obj.(*RationalObject); ok {
+		return r.value, true
+	}
+
+	i, ok := bigIntOf(obj)
+
+	if !ok {
+		return nil, false
+	}
+
+	return new(big.Rat).SetInt(i), true
+}
@@ -0,0 +1,188 @@
+package vm
+
+import (
+	"testing"
+)
+
+func TestRationalObject(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`Rational(1, 3).to_s`, "1/3"},
+		{`Rational(1, 3).inspect`, "(1/3)"},
+		{`Rational(2, 4).to_s`, "1/2"},
+		{`Rational(2, -6).to_s`, "-1/3"},
+		{`Rational(3).to_s`, "3/1"},
+		{`Rational(0, 5).to_s`, "0/1"},
+		{`Rational(2, -6).numerator`, -1},
+		{`Rational(2, -6).denominator`, 3},
+		{`Rational(1, 3).class.name`, "Rational"},
+		{`a = Rational(1, 3)
+		a.to_s`, "1/3"},
+		{`[Rational(1, 3)].first.to_s`, "1/3"},
+		{`def third
+		  Rational(1, 3)
+		end
+		third.to_s`, "1/3"},
+		{`Rational(1, 2) == Rational(2, 4)`, true},
+		{`Rational(1, 2) == Rational(1, 3)`, false},
+		{`Rational(1, 2).to_json`, `"1/2"`},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestRationalArithmetic(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`(Rational(1, 3) + Rational(1, 6)).to_s`, "1/2"},
+		{`Rational(1, 3) + Rational(1, 6) == Rational(1, 2)`, true},
+		{`(Rational(1, 3) + 1).to_s`, "4/3"},
+		{`(Rational(1, 2) - Rational(1, 3)).to_s`, "1/6"},
+		{`(Rational(1, 3) - 1).to_s`, "-2/3"},
+		{`(Rational(2, 3) * Rational(3, 4)).to_s`, "1/2"},
+		{`(Rational(1, 3) * 3).to_s`, "1/1"},
+		{`(Rational(1, 2) / Rational(1, 4)).to_s`, "2/1"},
+		{`(Rational(1, 2) / 3).to_s`, "1/6"},
+		{`(Rational(2, 3) ** 2).to_s`, "4/9"},
+		{`(Rational(2, 3) ** -2).to_s`, "9/4"},
+		{`(Rational(2, 3) ** 0).to_s`, "1/1"},
+		{`Rational(1, 4) ** 0.5`, 0.5},
+		{`Rational(1, 2) + 0.25`, 0.75},
+		{`Rational(1, 2) * 0.5`, 0.25},
+		// `%` and divmod are floored like Integer's
+		{`(Rational(7, 2) % Rational(1, 3)).to_s`, "1/6"},
+		{`Rational(7, 2).divmod(2).map do |n| n.to_s end`, []interface{}{"1", "3/2"}},
+		{`Rational(-7, 2).divmod(2).map do |n| n.to_s end`, []interface{}{"-2", "1/2"}},
+		{`Rational(7, 2).divmod(-2).map do |n| n.to_s end`, []interface{}{"-2", "-1/2"}},
+		{`Rational(-7, 2).divmod(-2).map do |n| n.to_s end`, []interface{}{"1", "-3/2"}},
+		{`Rational(7, 2).divmod(Rational(2, 3)).map do |n| n.to_s end`, []interface{}{"5", "1/6"}},
+		{`Rational(7, 2).divmod(1.5)`, []interface{}{2, 0.5}},
+		{`7.divmod(Rational(2, 3)).map do |n| n.to_s end`, []interface{}{"10", "1/3"}},
+		// exactness is kept where Floats lose it
+		{`a = Rational(1, 10)
+		(a + a + a) == Rational(3, 10)`, true},
+		{`(Rational(1, 3) + (9223372036854775807 + 1)).to_s`, "27670116110564327425/3"},
+		// Integers on the left keep the result exact too
+		{`(1 + Rational(1, 2)).to_s`, "3/2"},
+		{`(1 - Rational(1, 2)).to_s`, "1/2"},
+		{`(3 * Rational(1, 3)).to_s`, "1/1"},
+		{`(1 / Rational(1, 4)).to_s`, "4/1"},
+		{`(-1 / Rational(2, 3)).to_s`, "-3/2"},
+		{`(1 % Rational(1, 3)).to_s`, "0/1"},
+		{`(1 % Rational(2, 3)).to_s`, "1/3"},
+		{`(-1 % Rational(2, 3)).to_s`, "1/3"},
+		{`(1 % Rational(-2, 3)).to_s`, "-1/3"},
+		{`4 ** Rational(1, 2)`, 2.0},
+		{`(1 + Rational(1, 2)).class.name`, "Rational"},
+		{`(9223372036854775807 + Rational(1, 2)).to_s`, "18446744073709551615/2"},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		v.SetIntegerOverflowPolicy(OverflowPromote)
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestRationalComparison(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`Rational(1, 2) > Rational(1, 3)`, true},
+		{`Rational(1, 2) >= Rational(2, 4)`, true},
+		{`Rational(1, 3) < 0.5`, true},
+		{`Rational(2, 2) <= 1`, true},
+		{`Rational(3, 2) < 1`, false},
+		{`Rational(1, 2) <=> Rational(1, 3)`, 1},
+		{`Rational(1, 2) <=> 0.5`, 0},
+		{`Rational(1, 2) <=> 1`, -1},
+		// Integers on the left compare exactly
+		{`1 > Rational(1, 2)`, true},
+		{`1 >= Rational(2, 2)`, true},
+		{`1 < Rational(1, 2)`, false},
+		{`1 <= Rational(3, 2)`, true},
+		{`1 <=> Rational(1, 2)`, 1},
+		{`1 <=> Rational(2, 2)`, 0},
+		{`1 <=> Rational(3, 2)`, -1},
+		{`9223372036854775807 < Rational(9223372036854775807, 2) * 2 + Rational(1, 2)`, true},
+		{`[Rational(3, 2), 1, Rational(1, 2)].sort.map do |n| n.to_s end`, []interface{}{"1/2", "1", "3/2"}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestRationalConversion(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`Rational(1, 4).to_f`, 0.25},
+		{`Rational(1, 3).to_f`, 0.3333333333333333},
+		{`Rational(-1, 3).to_f`, -0.3333333333333333},
+		{`Rational(7, 2).to_i`, 3},
+		{`Rational(-7, 2).to_i`, -3},
+		{`Rational(6, 3).to_i`, 2},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestRationalMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`Rational()`, "ArgumentError: Expect 1 to 2 argument(s). got: 0", 1},
+		{`Rational(1, 2, 3)`, "ArgumentError: Expect 1 to 2 argument(s). got: 3", 1},
+		{`Rational(1, 0)`, "ZeroDivisionError: Divided by 0", 1},
+		{`Rational("1", 2)`, "TypeError: Expect argument #1 to be Integer. got: String", 1},
+		{`Rational(1, 2.0)`, "TypeError: Expect argument #2 to be Integer. got: Float", 1},
+		{`Rational.new`, "NoMethodError: Undefined Method 'new' for Rational", 1},
+		{`Rational(1, 2) / 0`, "ZeroDivisionError: Divided by 0", 1},
+		{`1 / Rational(0, 1)`, "ZeroDivisionError: Divided by 0", 1},
+		{`1 % Rational(0, 1)`, "ZeroDivisionError: Divided by 0", 1},
+		{`Rational(1, 2) / Rational(0, 1)`, "ZeroDivisionError: Divided by 0", 1},
+		{`Rational(1, 2) % 0`, "ZeroDivisionError: Divided by 0", 1},
+		{`Rational(1, 2).divmod(0)`, "ZeroDivisionError: Divided by 0", 1},
+		{`Rational(1, 2).divmod(0.0)`, "ZeroDivisionError: Divided by 0", 1},
+		{`7.divmod(Rational(0, 1))`, "ZeroDivisionError: Divided by 0", 1},
+		{`Rational(1, 2).divmod`, "ArgumentError: Expect 1 argument(s). got: 0", 1},
+		{`Rational(1, 2).divmod("1")`, "TypeError: Expect argument to be Numeric. got: String", 1},
+		{`Rational(1, 2) % "1"`, "TypeError: Expect argument to be Numeric. got: String", 1},
+		{`Rational(0, 1) ** -1`, "ZeroDivisionError: Divided by 0", 1},
+		{`Rational(1, 2) + "1"`, "TypeError: Expect argument to be Numeric. got: String", 1},
+		{`Rational(1, 2) > "1"`, "TypeError: Expect argument to be Numeric. got: String", 1},
+		{`Rational(1, 2).to_f(1)`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
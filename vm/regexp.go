@@ -1,6 +1,8 @@
 package vm
 
 import (
+	"strconv"
+
 	"github.com/dlclark/regexp2"
 	"github.com/goby-lang/goby/vm/classes"
 	"github.com/goby-lang/goby/vm/errors"
@@ -69,6 +71,33 @@ var builtInRegexpClassMethods = []*BuiltinMethodObject{
 
 // Instance methods -----------------------------------------------------
 var builtinRegexpInstanceMethods = []*BuiltinMethodObject{
+	{
+		// Matches the string given with the receiver, and returns the index of the first match or nil.
+		// The match is stored as the last match, like `String#=~` does.
+		//
+		// ```ruby
+		// Regexp.new("l+") =~ "Hello" # => 2
+		// $~ # => #<MatchData 0:"ll">
+		// ```
+		//
+		// @param string [String]
+		// @return [Integer]
+		Name: "=~",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			input, ok := args[0].(*StringObject)
+
+			if !ok {
+				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.StringClass, args[0].Class().Name)
+			}
+
+			return t.matchIndex(receiver.(*RegexpObject).regexp, input.value)
+
+		},
+	},
 	{
 		// Returns boolean value to indicate the result of regexp match with the string given. The methods evaluates a String object.
 		//
@@ -142,7 +171,7 @@ func (r *RegexpObject) Inspect() string {
 
 // ToJSON just delegates to ToString
 func (r *RegexpObject) ToJSON(t *Thread) string {
-	return "\"" + r.ToString() + "\""
+	return quoteJSON(r.ToString())
 }
 
 // equal checks if the string values between receiver and argument are equal
@@ -162,3 +191,56 @@ func (r *RegexpObject) equalTo(with Object) bool {
 
 	return false
 }
+
+// Other helper functions -----------------------------------------------
+
+// matchString matches the text with the regexp and stores the result as the thread's last match,
+// which is nil if it doesn't match.
+func (t *Thread) matchString(re *Regexp, text string) *MatchDataObject {
+	match, _ := re.FindStringMatch(text)
+
+	if match == nil {
+		t.lastMatch = nil
+		return nil
+	}
+
+	t.lastMatch = t.vm.initMatchDataObject(match, re.String(), text)
+	return t.lastMatch
+}
+
+// matchIndex is like matchString, but returns the index of the match, or nil if it doesn't match
+func (t *Thread) matchIndex(re *Regexp, text string) Object {
+	match := t.matchString(re, text)
+
+	if match == nil {
+		return NULL
+	}
+
+	return t.vm.InitIntegerObject(match.match.Index)
+}
+
+// getGlobalVariable reads the last match `$~` or one of its groups like `$1`.
+// They're nil if the last match failed or there's no such group.
+func (t *Thread) getGlobalVariable(name string) Object {
+	if t.lastMatch == nil {
+		return NULL
+	}
+
+	if name == "$~" {
+		return t.lastMatch
+	}
+
+	n, err := strconv.Atoi(name[1:])
+
+	if err != nil {
+		return NULL
+	}
+
+	group := t.lastMatch.match.GroupByNumber(n)
+
+	if group == nil || len(group.Captures) == 0 {
+		return NULL
+	}
+
+	return t.vm.InitStringObject(group.String())
+}
@@ -26,6 +26,7 @@ func (vm *VM) REPLExec(sets []*bytecode.InstructionSet) {
 	p.vm = vm
 	p.transferInstructionSets(sets)
 
+	vm.instructionTableLock.Lock()
 	for setType, table := range p.setTable {
 		for name, is := range table {
 			vm.isTables[setType][name] = is
@@ -33,6 +34,7 @@ func (vm *VM) REPLExec(sets []*bytecode.InstructionSet) {
 	}
 
 	vm.blockTables[p.filename] = p.blockTable
+	vm.instructionTableLock.Unlock()
 
 	oldFrame := vm.mainThread.callFrameStack.pop()
 	cf := newNormalCallFrame(p.program, p.filename, oldFrame.SourceLine())
@@ -76,3 +78,19 @@ func (vm *VM) GetREPLResult() string {
 
 	return ""
 }
+
+// GetPrettyREPLResult works like GetREPLResult, but returns the result in the form `pp` prints,
+// so strings are quoted and nested structures that don't fit in a line are broken into lines.
+func (vm *VM) GetPrettyREPLResult() string {
+	top := vm.mainThread.Stack.Pop()
+
+	if top == nil {
+		return ""
+	}
+
+	if err, ok := top.Target.(*Error); ok {
+		return err.ToString()
+	}
+
+	return vm.prettyPrint(top.Target)
+}
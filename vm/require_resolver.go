@@ -0,0 +1,76 @@
+package vm
+
+import (
+	"io/ioutil"
+	"path/filepath"
+)
+
+// RequireResolver resolves the name given to `require` into Goby source code.
+// It returns the source along with the path the code should be known by,
+// which is shown in backtraces and used to make sure the same code is loaded only once.
+// Hosts can use resolvers to load Goby code bundled in their binaries instead of from the disk.
+type RequireResolver func(name string) (src string, path string, found bool)
+
+// AddRequireResolver registers a resolver to be consulted after the ones registered before it.
+// Resolvers are always consulted before the lib directory on the disk, but after the standard libraries.
+func (vm *VM) AddRequireResolver(resolver RequireResolver) {
+	vm.requireResolverLock.Lock()
+	defer vm.requireResolverLock.Unlock()
+
+	vm.requireResolvers = append(vm.requireResolvers, resolver)
+}
+
+// PrependRequireResolver registers a resolver to be consulted before any resolver registered so far.
+func (vm *VM) PrependRequireResolver(resolver RequireResolver) {
+	vm.requireResolverLock.Lock()
+	defer vm.requireResolverLock.Unlock()
+
+	vm.requireResolvers = append([]RequireResolver{resolver}, vm.requireResolvers...)
+}
+
+// resolveRequire returns the result of the first resolver that finds the given name
+func (vm *VM) resolveRequire(name string) (src string, path string, found bool) {
+	vm.requireResolverLock.RLock()
+	resolvers := vm.requireResolvers
+	vm.requireResolverLock.RUnlock()
+
+	for _, resolve := range resolvers {
+		if src, path, found = resolve(name); found {
+			return
+		}
+	}
+
+	return
+}
+
+// requireFile loads the Goby file with the given name from the registered resolvers or the lib directory.
+// It returns false without loading anything if the file has already been loaded.
+// A file that fails to compile or raises an error while it's loaded isn't recorded, so it can be required again.
+func (t *Thread) requireFile(libName string) (loaded bool, err error) {
+	src, fpath, found := t.vm.resolveRequire(libName)
+
+	if !found {
+		fpath = filepath.Join(t.vm.libPath, libName+".gb")
+
+		file, err := ioutil.ReadFile(fpath)
+
+		if err != nil {
+			return false, err
+		}
+
+		src = string(file)
+	}
+
+	// The file is recorded before it runs, so it isn't loaded again while it's still loading
+	if _, ok := t.vm.loadedFiles.LoadOrStore(fpath, true); ok {
+		return false, nil
+	}
+
+	raised, err := t.execSource(src, fpath)
+
+	if raised != nil || err != nil {
+		t.vm.loadedFiles.Delete(fpath)
+	}
+
+	return true, err
+}
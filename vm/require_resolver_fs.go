@@ -0,0 +1,33 @@
+//go:build go1.16
+// +build go1.16
+
+package vm
+
+import (
+	"io/fs"
+	"path"
+)
+
+// FSResolver returns a RequireResolver which looks up `name.gb` under the prefix directory of the given file system,
+// such as an `embed.FS`. The resolved files are known by their paths in the file system.
+//
+// ```go
+// //go:embed lib
+// var lib embed.FS
+//
+// v.AddRequireResolver(vm.FSResolver(lib, "lib"))
+// ```
+//
+func FSResolver(fsys fs.FS, prefix string) RequireResolver {
+	return func(name string) (string, string, bool) {
+		p := path.Join(prefix, name+".gb")
+
+		src, err := fs.ReadFile(fsys, p)
+
+		if err != nil {
+			return "", "", false
+		}
+
+		return string(src), p, true
+	}
+}
@@ -0,0 +1,52 @@
+//go:build go1.16
+// +build go1.16
+
+package vm
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestFSResolver(t *testing.T) {
+	fsys := fstest.MapFS{
+		"lib/myapp/util.gb": &fstest.MapFile{Data: []byte(`
+		class Util
+		  def self.triple(n)
+		    n * 3
+		  end
+		end
+		`)},
+	}
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require "myapp/util"
+		Util.triple(3)
+		`, 9},
+		{`
+		[require("myapp/util"), require("myapp/util")]
+		`, []interface{}{true, false}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		v.AddRequireResolver(FSResolver(fsys, "lib"))
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestFSResolverNotFound(t *testing.T) {
+	v := initTestVM()
+	v.AddRequireResolver(FSResolver(fstest.MapFS{}, "lib"))
+	evaluated := v.testEval(t, `require "myapp/missing"`, getFilename())
+	checkErrorMsg(t, 0, evaluated, `IOError: Can't load "myapp/missing"`)
+	v.checkCFP(t, 0, 1)
+	v.checkSP(t, 0, 1)
+}
@@ -0,0 +1,178 @@
+package vm
+
+import (
+	"strings"
+	"testing"
+)
+
+func mapResolver(files map[string]string) RequireResolver {
+	return func(name string) (string, string, bool) {
+		src, ok := files[name]
+		return src, "embedded/" + name + ".gb", ok
+	}
+}
+
+func TestRequireResolver(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require "myapp/util"
+		Util.double(21)
+		`, 42},
+		// a resolved file is loaded only once
+		{`
+		r1 = require "myapp/util"
+		r2 = require "myapp/util"
+		[r1, r2, Util.loaded_times]
+		`, []interface{}{true, false, 1}},
+		// standard libraries take precedence over resolvers
+		{`
+		require "uri"
+		URI.parse("http://example.com").scheme
+		`, "http"},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		v.AddRequireResolver(mapResolver(map[string]string{
+			"myapp/util": `
+			class Util
+			  def self.double(n)
+			    n * 2
+			  end
+
+			  def self.loaded_times
+			    @loaded_times
+			  end
+
+			  def self.loaded
+			    if @loaded_times.nil?
+			      @loaded_times = 0
+			    end
+			    @loaded_times += 1
+			  end
+			end
+
+			Util.loaded
+			`,
+			"uri": `raise "shouldn't be loaded"`,
+		}))
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestRequireResolverRetryAfterFailure(t *testing.T) {
+	tests := []struct {
+		broken   string
+		input    string
+		expected interface{}
+	}{
+		// in test mode, an error raised by the file stops only the file
+		{`raise ArgumentError, "not yet"`, `
+		require 'concurrent/future'
+		f = Concurrent::Future.execute do
+		  require "myapp/flaky"
+		end
+		f.value(5)
+		[f.rejected?, require("myapp/flaky"), Flaky.ok, require("myapp/flaky")]
+		`, []interface{}{false, true, true, false}},
+		// a file that doesn't compile raises an IOError from require
+		{`class Flaky`, `
+		require 'concurrent/future'
+		f = Concurrent::Future.execute do
+		  require "myapp/flaky"
+		end
+		f.value(5)
+		[f.reason.class.name, require("myapp/flaky"), Flaky.ok, require("myapp/flaky")]
+		`, []interface{}{"IOError", true, true, false}},
+	}
+
+	for i, tt := range tests {
+		attempts := 0
+		v := initTestVM()
+		v.AddRequireResolver(func(name string) (string, string, bool) {
+			if name != "myapp/flaky" {
+				return "", "", false
+			}
+
+			attempts++
+
+			if attempts == 1 {
+				return tt.broken, "embedded/myapp/flaky.gb", true
+			}
+
+			return `
+			class Flaky
+			  def self.ok
+			    true
+			  end
+			end
+			`, "embedded/myapp/flaky.gb", true
+		})
+
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestRequireResolverOrder(t *testing.T) {
+	input := `
+	require "config"
+	Config.name
+	`
+
+	v := initTestVM()
+	v.AddRequireResolver(mapResolver(map[string]string{"config": `
+	class Config
+	  def self.name
+	    "added"
+	  end
+	end
+	`}))
+	v.PrependRequireResolver(mapResolver(map[string]string{"config": `
+	class Config
+	  def self.name
+	    "prepended"
+	  end
+	end
+	`}))
+
+	evaluated := v.testEval(t, input, getFilename())
+	VerifyExpected(t, 0, evaluated, "prepended")
+	v.checkCFP(t, 0, 0)
+	v.checkSP(t, 0, 1)
+}
+
+func TestRequireResolverBacktrace(t *testing.T) {
+	input := `
+	require "myapp/broken"
+	Broken.call
+	`
+
+	v := initTestVM()
+	v.AddRequireResolver(mapResolver(map[string]string{"myapp/broken": `
+	class Broken
+	  def self.call
+	    1 + "a"
+	  end
+	end
+	`}))
+
+	evaluated := v.testEval(t, input, getFilename())
+	err, ok := evaluated.(*Error)
+
+	if !ok {
+		t.Fatalf("Expect an error. got: %s", evaluated.ToString())
+	}
+
+	if !strings.HasPrefix(err.stackTraces[0], "from embedded/myapp/broken.gb:") {
+		t.Fatalf("Expect the backtrace to show the resolved path. got: %v", err.stackTraces)
+	}
+}
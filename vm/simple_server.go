@@ -132,13 +132,12 @@ func builtinSimpleServerInstanceMethods() []*BuiltinMethodObject {
 // Functions for initialization -----------------------------------------
 
 func initSimpleServerClass(vm *VM) {
-	initHTTPClass(vm)
 	net := vm.loadConstant("Net", true)
 	simpleServer := vm.initializeClass("SimpleServer")
 	simpleServer.setBuiltinMethods(builtinSimpleServerInstanceMethods(), false)
 	net.setClassConstant(simpleServer)
 
-	vm.mainThread.execGobyLib("net/simple_server.gb")
+	vm.execGobyLibInNewThread("net/simple_server.gb")
 }
 
 // Other helper functions -----------------------------------------------
@@ -147,13 +146,13 @@ func newHandler(t *Thread, blockFrame *normalCallFrame) func(http.ResponseWriter
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Go creates one goroutine per request, so we also need to create a new Goby thread for every request.
 		thread := t.vm.newThread()
-		res := httpResponseClass.initializeInstance()
+		res := t.vm.httpResponseClass.initializeInstance()
 
 		req := initRequest(t, w, r)
-		result := thread.builtinMethodYield(blockFrame, req, res)
+		result, erred := thread.builtinMethodYield(blockFrame, req, res)
 
-		if err, ok := result.(*Error); ok {
-			log.Printf("Error: %s", err.message)
+		if erred {
+			log.Printf("Error: %s", result.(*Error).message)
 			res.InstanceVariableSet("@status", t.vm.InitIntegerObject(500))
 		}
 
@@ -163,7 +162,7 @@ func newHandler(t *Thread, blockFrame *normalCallFrame) func(http.ResponseWriter
 
 func initRequest(t *Thread, w http.ResponseWriter, req *http.Request) *RObject {
 	r := request{}
-	reqObj := httpRequestClass.initializeInstance()
+	reqObj := t.vm.httpRequestClass.initializeInstance()
 
 	body, err := ioutil.ReadAll(req.Body)
 	if err != nil {
@@ -96,7 +96,9 @@ func TestSetupResponseDefaultValue(t *testing.T) {
 	recorder := httptest.NewRecorder()
 	req := httptest.NewRequest("GET", "https://google.com/path", reader)
 
-	res := httpResponseClass.initializeInstance()
+	v := initTestVM()
+	v.loadNativeLibrary("net/http")
+	res := v.httpResponseClass.initializeInstance()
 
 	setupResponse(recorder, req, res)
 
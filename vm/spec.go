@@ -1,5 +1,5 @@
 package vm
 
 func initSpecClass(vm *VM) {
-	vm.mainThread.execGobyLib("spec.gb")
+	vm.execGobyLibInNewThread("spec.gb")
 }
@@ -284,6 +284,21 @@ func TestDefStatementWithSplatArgument(t *testing.T) {
 
 		foo(10, 20, 30)
 		`, 60},
+		// keyword arguments aren't collected by the splat argument
+		{`
+		def foo(a, k: 1, *b)
+		  [a, b, k]
+		end
+
+		foo(1, 2, 3, k: 4)
+		`, []interface{}{1, []interface{}{2, 3}, 4}},
+		{`
+		def foo(a, *b)
+		  [a, b]
+		end
+
+		send(:foo, 1, 2, 3)
+		`, []interface{}{1, []interface{}{2, 3}}},
 	}
 
 	for i, tt := range tests {
@@ -419,6 +434,67 @@ func TestDefStatementWithKeywordArgument(t *testing.T) {
 	}
 }
 
+func TestDefStatementWithDoubleSplatArgument(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		def foo(**opts)
+		  opts
+		end
+
+		foo
+		`, canonical(`{}`)},
+		{`
+		def foo(**opts)
+		  opts
+		end
+
+		foo(a: 1, b: "2")
+		`, canonical(`{"a": 1, "b": "2"}`)},
+		{`
+		def foo(a, b:, c: 2, **opts)
+		  [a, b, c, opts]
+		end
+
+		foo(1, b: 3)
+		`, canonical(`[1, 3, 2, {}]`)},
+		{`
+		def foo(a, b:, c: 2, **opts)
+		  [a, b, c, opts]
+		end
+
+		foo(1, d: 4, c: 5, b: 3, e: 6)
+		`, canonical(`[1, 3, 5, {"d": 4, "e": 6}]`)},
+		{`
+		def foo(a, k: 1, *rest, **opts)
+		  [a, k, rest, opts]
+		end
+
+		foo(x: 0, 1, 2, 3)
+		`, canonical(`[1, 1, [2, 3], {"x": 0}]`)},
+		// a fresh hash is given on every call
+		{`
+		def foo(**opts)
+		  opts[:count] = opts.length
+		  opts
+		end
+
+		foo(a: 1)
+		foo(a: 1)
+		`, canonical(`{"a": 1, "count": 1}`)},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestModuleStatement(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -2,6 +2,7 @@ package vm
 
 import (
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 	"unicode"
@@ -30,9 +31,28 @@ import (
 // - Currently, manipulations are based upon Golang's Unicode manipulations.
 // - Currently, UTF-8 encoding is assumed based upon Golang's string manipulation, but the encoding is not actually specified(TBD).
 // - `String.new` is not supported.
+//
+// **Mutation:**
+//
+// Methods with a trailing `!`, and `[]=`, `clear`, `concat`, `insert`, `prepend` and `replace` (with one argument)
+// modify the receiver in place, so every variable referencing the same string sees the change.
+// All the other methods return a new String and leave the receiver untouched.
+// Destructive methods raise a `FrozenError` on a frozen string.
+//
+// ```ruby
+// a = "Goby"
+// b = a
+// b.concat(" Lang")
+// a # => "Goby Lang"
+//
+// a.freeze
+// a.concat("!") # => FrozenError
+// ```
+//
 type StringObject struct {
 	*BaseObj
-	value string
+	value  string
+	frozen bool
 }
 
 // Class methods --------------------------------------------------------
@@ -106,7 +126,13 @@ var builtinStringInstanceMethods = []*BuiltinMethodObject{
 				return typeErr
 			}
 
-			return t.vm.InitStringObject(receiver.Value().(string) + args[0].Value().(string))
+			left, right := receiver.Value().(string), args[0].Value().(string)
+
+			if err := t.reserveMemory(len(left)+len(right), "String#+", sourceLine); err != nil {
+				return err
+			}
+
+			return t.vm.InitStringObject(left + right)
 		},
 	},
 	{
@@ -132,14 +158,40 @@ var builtinStringInstanceMethods = []*BuiltinMethodObject{
 				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.NegativeSecondValue, right)
 			}
 
-			var result string
-
 			left := receiver.(*StringObject)
-			for i := 0; i < right; i++ {
-				result += left.value
+
+			if err := t.reserveMemory(multipliedSize(len(left.value), right), "String#*", sourceLine); err != nil {
+				return err
 			}
 
-			return t.vm.InitStringObject(result)
+			return t.vm.InitStringObject(strings.Repeat(left.value, right))
+
+		},
+	},
+	{
+		// Formats the argument with self as the format string, like `format`.
+		// An Array gives the arguments for multiple directives.
+		//
+		// ```ruby
+		// "%05.2f" % 3.14159       # => "03.14"
+		// "%s: %x" % ["hex", 255] # => "hex: ff"
+		// ```
+		//
+		// @param argument [Object]
+		// @return [String]
+		Name: "%",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			formatArgs := args
+
+			if arr, ok := args[0].(*ArrayObject); ok {
+				formatArgs = arr.Elements
+			}
+
+			return t.formatObjects(receiver.(*StringObject).value, formatArgs, sourceLine)
 
 		},
 	},
@@ -195,6 +247,47 @@ var builtinStringInstanceMethods = []*BuiltinMethodObject{
 
 		},
 	},
+	{
+		// Appends the given string to the receiver in place and returns the receiver, so appends can be chained.
+		// A destructive method.
+		//
+		// ```ruby
+		// s = "Goby"
+		// s << " is" << " fun" # => "Goby is fun"
+		// s                    # => "Goby is fun"
+		// ```
+		//
+		// @param string [String]
+		// @return [String]
+		Name: "<<",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			typeErr := t.vm.checkArgTypes(args, sourceLine, classes.StringClass)
+
+			if typeErr != nil {
+				return typeErr
+			}
+
+			s := receiver.(*StringObject)
+
+			if err := s.checkFrozen(t, sourceLine); err != nil {
+				return err
+			}
+
+			appended := args[0].Value().(string)
+
+			if err := t.reserveMemory(len(s.value)+len(appended), "String#<<", sourceLine); err != nil {
+				return err
+			}
+
+			s.value += appended
+			return s
+
+		},
+	},
 	{
 		// Returns a Integer.
 		// Returns -1 if the first string is less than the second string returns -1, returns 0 if equal to, or returns 1 if greater than.
@@ -257,8 +350,39 @@ var builtinStringInstanceMethods = []*BuiltinMethodObject{
 		},
 	},
 	{
-		// Returns the character of the string with specified index.
-		// Raises an error if the input is not an Integer type.
+		// Matches the receiver with a Regexp, and returns the index of the first match or nil.
+		// The match is stored as the last match, so `$~` and the groups like `$1` can be read after it.
+		//
+		// ```ruby
+		// "Hello World" =~ Regexp.new("(o)r(l)") # => 7
+		// $~ # => #<MatchData 0:"orl" 1:"o" 2:"l">
+		// $2 # => "l"
+		// "Hello" =~ Regexp.new("x")              # => nil
+		// $~ # => nil
+		// ```
+		//
+		// @param regexp [Regexp]
+		// @return [Integer]
+		Name: "=~",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			re, ok := args[0].(*RegexpObject)
+
+			if !ok {
+				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.RegexpClass, args[0].Class().Name)
+			}
+
+			return t.matchIndex(re.regexp, receiver.(*StringObject).value)
+
+		},
+	},
+	{
+		// Returns the character or the substring of the string with specified index,
+		// following the same indexing rules as `Array#[]`.
+		// Returns `nil` if the index is out of the string.
 		//
 		// ```ruby
 		// "Hello"[1]        # => "e"
@@ -268,72 +392,26 @@ var builtinStringInstanceMethods = []*BuiltinMethodObject{
 		// "Hello"[-6]       # => nil
 		// "Hello😊"[5]      # => "😊"
 		// "Hello😊"[-1]     # => "😊"
+		// "Hello"[1, 3]     # => "ell"
+		// "Hello"[5, 1]     # => ""
+		// "Hello"[6, 1]     # => nil
+		// "Hello"[1..3]     # => "ell"
+		// "Hello"[-3..-1]   # => "llo"
 		// ```
 		//
-		// @param index [Integer]
+		// @param index [Integer], (length [Integer])
+		// @param range [Range]
 		// @return [String]
 		Name: "[]",
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
-			if len(args) != 1 {
-				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
-			}
-
-			str := receiver.(*StringObject).value
-			i := args[0]
-
-			switch index := i.(type) {
-			case *IntegerObject:
-				indexValue := index.value
-
-				if indexValue < 0 {
-					strLength := utf8.RuneCountInString(str)
-					if -indexValue > strLength {
-						return NULL
-					}
-					return t.vm.InitStringObject(string([]rune(str)[strLength+indexValue]))
-				}
-
-				if len(str) > indexValue {
-					return t.vm.InitStringObject(string([]rune(str)[indexValue]))
-				}
-
-				return NULL
-			case *RangeObject:
-				strLength := utf8.RuneCountInString(str)
-				start := index.Start
-				end := index.End
-
-				if start < 0 {
-					start = strLength + start
-
-					if start < 0 {
-						return NULL
-					}
-				}
-
-				if end < 0 {
-					end = strLength + end
-				}
-
-				if start > strLength {
-					return NULL
-				}
-
-				if end >= strLength {
-					end = strLength - 1
-				}
-
-				return t.vm.InitStringObject(string([]rune(str)[start : end+1]))
-			default:
-				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.IntegerClass, i.Class().Name)
-			}
+			return receiver.(*StringObject).index(t, args, sourceLine)
 
 		},
 	},
 	{
-		// Replaces the receiver's string with input string. A destructive method.
-		// Raises an error if the index is not Integer type or the index value is out of
-		// range of the string length
+		// Replaces the character or the substring at the given index with the input string in place. A destructive method.
+		// The index follows the same rules as `Array#[]=`, but an index beyond the end of the string
+		// raises an IndexError instead of padding the string.
 		//
 		// Currently only support assign string type value.
 		// TODO: Support to assign type which have to_s method
@@ -344,46 +422,49 @@ var builtinStringInstanceMethods = []*BuiltinMethodObject{
 		// "Hello\nWorld"[5] = " " # => "Hello World"
 		// "Ruby"[-3] = "oo" # => "Rooby"
 		// "Hello😊"[5] = "🐟" # => "Hello🐟"
+		// "Hello"[1, 3] = "a" # => "Hao"
+		// "Hello"[1..-1] = "i" # => "Hi"
+		// "Go"[3] = "by"   # => IndexError: Index value out of range. got: 3
 		// ```
 		//
-		// @param index [Integer]
+		// @param index [Integer], string [String]
+		// @param index [Integer], length [Integer], string [String]
+		// @param range [Range], string [String]
 		// @return [String]
 		Name: "[]=",
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
-			if len(args) != 2 {
-				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 2, len(args))
+			aLen := len(args)
+			if aLen < 2 || aLen > 3 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgumentRange, 2, 3, aLen)
 			}
-			typeErr := t.vm.checkArgTypes(args, sourceLine, classes.IntegerClass, classes.StringClass)
 
-			if typeErr != nil {
-				return typeErr
-			}
+			s := receiver.(*StringObject)
+			runes := []rune(s.value)
+			indices := args[:aLen-1]
 
-			indexValue := args[0].Value().(int)
-			replaceStrValue := args[1].Value().(string)
+			span, tooSmall, err := resolveAssignmentIndex(t, indices, len(runes), sourceLine)
 
-			str := receiver.(*StringObject).value
-			strLength := utf8.RuneCountInString(str)
+			if err != nil {
+				return err
+			}
 
-			if strLength < indexValue {
-				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.IndexOutOfRange, strconv.Itoa(indexValue))
+			if tooSmall || span.start > len(runes) {
+				return t.vm.InitErrorObject(errors.IndexError, sourceLine, errors.IndexOutOfRange, indices[0].ToString())
 			}
 
-			// Negative Index Case
-			if indexValue < 0 {
-				if -indexValue > strLength {
-					return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.IndexOutOfRange, strconv.Itoa(indexValue))
-				}
-				// Change to positive index to replace the string
-				indexValue += strLength
+			replaceStr, ok := args[aLen-1].(*StringObject)
+
+			if !ok {
+				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.StringClass, args[aLen-1].Class().Name)
 			}
 
-			if strLength == indexValue {
-				return t.vm.InitStringObject(str + replaceStrValue)
+			if err := s.checkFrozen(t, sourceLine); err != nil {
+				return err
 			}
+
 			// Using rune type to support UTF-8 encoding to replace character
-			result := string([]rune(str)[:indexValue]) + replaceStrValue + string([]rune(str)[indexValue+1:])
-			return t.vm.InitStringObject(result)
+			s.value = string(runes[:span.start]) + replaceStr.value + string(runes[span.end:])
+			return s
 
 		},
 	},
@@ -411,6 +492,58 @@ var builtinStringInstanceMethods = []*BuiltinMethodObject{
 
 		},
 	},
+	{
+		// Returns a new String with the given suffix removed from the end.
+		// Without an argument, a trailing line separator ("\n", "\r" or "\r\n") is removed.
+		//
+		// ```ruby
+		// "Hello\n".chomp       # => "Hello"
+		// "Hello\r\n".chomp     # => "Hello"
+		// "Hello".chomp         # => "Hello"
+		// "Hello😊".chomp("😊") # => "Hello"
+		// ```
+		//
+		// @param suffix [String]
+		// @return [String]
+		Name: "chomp",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			str := receiver.(*StringObject).value
+
+			result, err := chompString(t, sourceLine, str, args)
+			if err != nil {
+				return err
+			}
+
+			return t.vm.InitStringObject(result)
+
+		},
+	},
+	{
+		// Same as `#chomp`, but modifies the receiver in place. A destructive method.
+		// Returns nil if nothing was removed.
+		//
+		// ```ruby
+		// s = "Hello\n"
+		// s.chomp! # => "Hello"
+		// s.chomp! # => nil
+		// s        # => "Hello"
+		// ```
+		//
+		// @param suffix [String]
+		// @return [String]
+		Name: "chomp!",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			s := receiver.(*StringObject)
+
+			result, err := chompString(t, sourceLine, s.value, args)
+			if err != nil {
+				return err
+			}
+
+			return s.mutate(t, sourceLine, result)
+
+		},
+	},
 	{
 		// Returns a string with the last character chopped.
 		//
@@ -433,29 +566,69 @@ var builtinStringInstanceMethods = []*BuiltinMethodObject{
 		},
 	},
 	{
-		// Returns a string which is concatenate with the input string or character.
+		// Makes the receiver an empty string and returns it. A destructive method.
 		//
 		// ```ruby
-		// "Hello ".concat("World")   # => "Hello World"
-		// "Hello World".concat("😊") # => "Hello World😊"
+		// s = "Goby"
+		// s.clear # => ""
+		// s       # => ""
 		// ```
 		//
-		// @param string [String]
+		// @return [String]
+		Name: "clear",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			s := receiver.(*StringObject)
+
+			if err := s.checkFrozen(t, sourceLine); err != nil {
+				return err
+			}
+
+			s.value = ""
+			return s
+
+		},
+	},
+	{
+		// Appends the given strings to the receiver in place and returns the receiver. A destructive method.
+		//
+		// ```ruby
+		// "Hello ".concat("World")        # => "Hello World"
+		// "Hello World".concat("😊")      # => "Hello World😊"
+		// "Hello".concat(" ", "World")    # => "Hello World"
+		// ```
+		//
+		// @param strings [String]...
 		// @return [String]
 		Name: "concat",
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
-			if len(args) != 1 {
-				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			s := receiver.(*StringObject)
+
+			if err := s.checkFrozen(t, sourceLine); err != nil {
+				return err
 			}
 
-			typeErr := t.vm.checkArgTypes(args, sourceLine, classes.StringClass)
+			var appended string
 
-			if typeErr != nil {
-				return typeErr
+			for _, arg := range args {
+				str, ok := arg.(*StringObject)
+
+				if !ok {
+					return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.StringClass, arg.Class().Name)
+				}
+
+				appended += str.value
 			}
 
-			str := receiver.(*StringObject).value
-			return t.vm.InitStringObject(str + args[0].Value().(string))
+			if err := t.reserveMemory(len(s.value)+len(appended), "String#concat", sourceLine); err != nil {
+				return err
+			}
+
+			s.value += appended
+			return s
 
 		},
 	},
@@ -586,7 +759,9 @@ var builtinStringInstanceMethods = []*BuiltinMethodObject{
 			}
 
 			for _, byte := range []byte(str) {
-				t.builtinMethodYield(blockFrame, t.vm.InitIntegerObject(int(byte)))
+				if result, erred := t.builtinMethodYield(blockFrame, t.vm.InitIntegerObject(int(byte))); erred {
+					return result
+				}
 			}
 
 			return t.vm.InitStringObject(str)
@@ -626,7 +801,9 @@ var builtinStringInstanceMethods = []*BuiltinMethodObject{
 			}
 
 			for _, char := range []rune(str) {
-				t.builtinMethodYield(blockFrame, t.vm.InitStringObject(string(char)))
+				if result, erred := t.builtinMethodYield(blockFrame, t.vm.InitStringObject(string(char))); erred {
+					return result
+				}
 			}
 
 			return t.vm.InitStringObject(str)
@@ -663,7 +840,9 @@ var builtinStringInstanceMethods = []*BuiltinMethodObject{
 			lineArray := strings.Split(str, "\n")
 
 			for _, line := range lineArray {
-				t.builtinMethodYield(blockFrame, t.vm.InitStringObject(line))
+				if result, erred := t.builtinMethodYield(blockFrame, t.vm.InitStringObject(line)); erred {
+					return result
+				}
 			}
 
 			return t.vm.InitStringObject(str)
@@ -761,6 +940,49 @@ var builtinStringInstanceMethods = []*BuiltinMethodObject{
 
 		},
 	},
+	{
+		// Freezes the receiver so that it can't be modified anymore, and returns it.
+		// Destructive methods called on a frozen string raise a `FrozenError`.
+		//
+		// ```ruby
+		// s = "Goby".freeze
+		// s.frozen?      # => true
+		// s.concat("!") # => FrozenError
+		// ```
+		//
+		// @return [String]
+		Name: "freeze",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			s := receiver.(*StringObject)
+			s.frozen = true
+			return s
+
+		},
+	},
+	{
+		// Returns true if the receiver is frozen.
+		//
+		// ```ruby
+		// "Goby".frozen?        # => false
+		// "Goby".freeze.frozen? # => true
+		// "Goby".freeze.dup.frozen? # => false
+		// ```
+		//
+		// @return [Boolean]
+		Name: "frozen?",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			return toBooleanObject(receiver.(*StringObject).frozen)
+
+		},
+	},
 	{
 		// Checks if the specified string is included in the receiver.
 		//
@@ -793,7 +1015,7 @@ var builtinStringInstanceMethods = []*BuiltinMethodObject{
 		},
 	},
 	{
-		// Insert a string input in specified index value of the receiver string.
+		// Inserts a string input in specified index value of the receiver string in place. A destructive method.
 		//
 		// It will raise error if index value is not an integer or index value is out
 		// of receiver string's range.
@@ -826,15 +1048,22 @@ var builtinStringInstanceMethods = []*BuiltinMethodObject{
 				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormatNum, 2, classes.StringClass, args[1].Class().Name)
 			}
 
+			s := receiver.(*StringObject)
+
+			if err := s.checkFrozen(t, sourceLine); err != nil {
+				return err
+			}
+
 			indexValue := index.value
-			str := receiver.(*StringObject).value
+			str := s.value
 			strLength := utf8.RuneCountInString(str)
 
 			if indexValue < 0 {
 				if -indexValue > strLength+1 {
 					return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.IndexOutOfRange, indexValue)
 				} else if -indexValue == strLength+1 {
-					return t.vm.InitStringObject(insertStr.value + str)
+					s.value = insertStr.value + str
+					return s
 				}
 				// Change it to positive index value to replace the string via index
 				indexValue += strLength
@@ -845,7 +1074,8 @@ var builtinStringInstanceMethods = []*BuiltinMethodObject{
 			}
 
 			// Support UTF-8 Encoding
-			return t.vm.InitStringObject(string([]rune(str)[:indexValue]) + insertStr.value + string([]rune(str)[indexValue:]))
+			s.value = string([]rune(str)[:indexValue]) + insertStr.value + string([]rune(str)[indexValue:])
+			return s
 
 		},
 	},
@@ -933,6 +1163,7 @@ var builtinStringInstanceMethods = []*BuiltinMethodObject{
 	},
 	{
 		// Returns the matched data of the regex with the receiver's string.
+		// The match is stored as the last match, like `=~` does.
 		//
 		// ```ruby
 		// 'pow'.match(Regexp.new("o")) # => #<MatchData "o">
@@ -954,16 +1185,13 @@ var builtinStringInstanceMethods = []*BuiltinMethodObject{
 				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.RegexpClass, args[0].Class().Name)
 			}
 
-			re := regexpObj.regexp
-			text := receiver.(*StringObject).value
-
-			match, _ := re.FindStringMatch(text)
+			match := t.matchString(regexpObj.regexp, receiver.(*StringObject).value)
 
 			if match == nil {
 				return NULL
 			}
 
-			return t.vm.initMatchDataObject(match, re.String(), text)
+			return match
 
 		},
 	},
@@ -1001,6 +1229,40 @@ var builtinStringInstanceMethods = []*BuiltinMethodObject{
 
 		},
 	},
+	{
+		// Prepends the given string to the receiver in place and returns the receiver. A destructive method.
+		//
+		// ```ruby
+		// s = "Lang"
+		// s.prepend("Goby ") # => "Goby Lang"
+		// s                  # => "Goby Lang"
+		// ```
+		//
+		// @param string [String]
+		// @return [String]
+		Name: "prepend",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			typeErr := t.vm.checkArgTypes(args, sourceLine, classes.StringClass)
+
+			if typeErr != nil {
+				return typeErr
+			}
+
+			s := receiver.(*StringObject)
+
+			if err := s.checkFrozen(t, sourceLine); err != nil {
+				return err
+			}
+
+			s.value = args[0].Value().(string) + s.value
+			return s
+
+		},
+	},
 	{
 		// Returns a copy of str with the all occurrences of pattern substituted for the second argument.
 		// The pattern is typically a String or Regexp; if given as a String, any
@@ -1008,20 +1270,44 @@ var builtinStringInstanceMethods = []*BuiltinMethodObject{
 		// match a backslash followed by ‘d’, instead of a digit.
 		//
 		// `#replace` is equivalent to Ruby's `gsub`.
+		//
+		// When only one String is given, the receiver's content is replaced with it in place,
+		// like Ruby's `String#replace`. This form is destructive.
+		//
 		// ```ruby
 		// "Ruby Lang".replace("Ru", "Go")                # => "Goby Lang"
 		// "Hello 😊 Hello 😊 Hello".replace("😊", "🐟") # => "Hello 🐟 Hello 🐟 Hello"
 		//
 		// re = Regexp.new("(Ru|ru)")
 		// "Ruby Lang".replace(re, "Go")                # => "Goby Lang"
+		//
+		// s = "Ruby"
+		// s.replace("Goby")
+		// s                                            # => "Goby"
 		// ```
 		//
 		// @param pattern [Regexp/String], [String] the new string
 		// @return [String]
 		Name: "replace",
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) == 1 {
+				s := receiver.(*StringObject)
+				other, ok := args[0].(*StringObject)
+
+				if !ok {
+					return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.StringClass, args[0].Class().Name)
+				}
+
+				if err := s.checkFrozen(t, sourceLine); err != nil {
+					return err
+				}
+
+				s.value = other.value
+				return s
+			}
+
 			if len(args) != 2 {
-				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 2, len(args))
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgumentRange, 1, 2, len(args))
 			}
 
 			r := args[1]
@@ -1208,7 +1494,7 @@ var builtinStringInstanceMethods = []*BuiltinMethodObject{
 		},
 	},
 	{
-		// Returns a string sliced according to the input range.
+		// Returns a string sliced according to the input index, index with length, or range. It is the same as `String#[]`.
 		//
 		// ```ruby
 		// "Hello World".slice(1..6)    # => "ello W"
@@ -1244,74 +1530,57 @@ var builtinStringInstanceMethods = []*BuiltinMethodObject{
 		// "Hello 😊🐟 World".slice(14)      # => nil
 		// ```
 		//
-		// @param slicing point or range [Integer/Range]
+		// @param slicing point or range [Integer/Range], (length [Integer])
 		// @return [String]
 		Name: "slice",
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
-			if len(args) != 1 {
-				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
-			}
+			return receiver.(*StringObject).index(t, args, sourceLine)
 
-			str := receiver.(*StringObject).value
-			strLength := utf8.RuneCountInString(str)
+		},
+	},
+	{
+		// Removes the specified portion from the receiver in place and returns the removed part. A destructive method.
+		// The portion can be specified by an index, an index with a length, or a range.
+		// Returns nil and leaves the receiver untouched if the portion is out of range.
+		//
+		// ```ruby
+		// s = "Hello 😊🐟 World"
+		// s.slice!(6)    # => "😊"
+		// s              # => "Hello 🐟 World"
+		// s.slice!(-5, 5) # => "World"
+		// s              # => "Hello 🐟 "
+		// s.slice!(0..5) # => "Hello "
+		// s              # => "🐟 "
+		// s.slice!(10)   # => nil
+		// ```
+		//
+		// @param slicing point or range [Integer/Range], length [Integer]
+		// @return [String]
+		Name: "slice!",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			s := receiver.(*StringObject)
+			runes := []rune(s.value)
 
-			// All Case Support UTF-8 Encoding
-			slice := args[0]
-			switch slice.(type) {
-			case *RangeObject:
-				ro := slice.(*RangeObject)
-				switch {
-				case ro.Start >= 0 && ro.End >= 0:
-					if ro.Start > strLength {
-						return NULL
-					} else if ro.Start > ro.End {
-						return t.vm.InitStringObject("")
-					}
-					return t.vm.InitStringObject(string([]rune(str)[ro.Start : ro.End+1]))
-				case ro.Start < 0 && ro.End >= 0:
-					positiveStart := strLength + ro.Start
-					if -ro.Start > strLength {
-						return NULL
-					} else if positiveStart > ro.End {
-						return t.vm.InitStringObject("")
-					}
-					return t.vm.InitStringObject(string([]rune(str)[positiveStart : ro.End+1]))
-				case ro.Start >= 0 && ro.End < 0:
-					positiveEnd := strLength + ro.End
-					if ro.Start > strLength {
-						return NULL
-					} else if positiveEnd < 0 || ro.Start > positiveEnd {
-						return t.vm.InitStringObject("")
-					}
-					return t.vm.InitStringObject(string([]rune(str)[ro.Start : positiveEnd+1]))
-				default:
-					positiveStart := strLength + ro.Start
-					positiveEnd := strLength + ro.End
-					if positiveStart < 0 {
-						return NULL
-					} else if positiveStart > positiveEnd {
-						return t.vm.InitStringObject("")
-					}
-					return t.vm.InitStringObject(string([]rune(str)[positiveStart : positiveEnd+1]))
-				}
+			span, ok, err := resolveIndex(t, args, len(runes), sourceLine)
 
-			case *IntegerObject:
-				iv := slice.(*IntegerObject).value
-				if iv < 0 {
-					if -iv > strLength {
-						return NULL
-					}
-					return t.vm.InitStringObject(string([]rune(str)[strLength+iv]))
-				}
-				if iv > strLength-1 {
-					return NULL
-				}
-				return t.vm.InitStringObject(string([]rune(str)[iv]))
+			if err != nil {
+				return err
+			}
 
-			default:
-				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, "Range or Integer", slice.Class().Name)
+			if !ok {
+				return NULL
+			}
+
+			if err := s.checkFrozen(t, sourceLine); err != nil {
+				return err
 			}
 
+			start, end := span.start, span.end
+			removed := string(runes[start:end])
+			s.value = string(runes[:start]) + string(runes[end:])
+
+			return t.vm.InitStringObject(removed)
+
 		},
 	},
 	{
@@ -1352,75 +1621,128 @@ var builtinStringInstanceMethods = []*BuiltinMethodObject{
 	},
 	{
 		// Returns true if receiver string start with the argument string.
+		// `start_with` is its deprecated alias.
 		//
 		// ```ruby
-		// "Hello".start_with("Hel")     # => true
-		// "Hello".start_with("hel")     # => false
-		// "😊Hello🐟".start_with("😊") # => true
-		// "😊Hello🐟".start_with("🐟") # => false
+		// "Hello".start_with?("Hel")     # => true
+		// "Hello".start_with?("hel")     # => false
+		// "😊Hello🐟".start_with?("😊") # => true
+		// "😊Hello🐟".start_with?("🐟") # => false
 		// ```
 		//
 		// @param string [String]
 		// @return [Boolean]
-		Name: "start_with",
+		Name: "start_with?",
+		Fn:   stringStartWith,
+	},
+	Deprecated(&BuiltinMethodObject{Name: "start_with", Fn: stringStartWith}, "0.1.14", "start_with?"),
+	{
+		// Returns a copy of str with leading and trailing whitespace removed.
+		// Whitespace is defined as any of the following characters: null, horizontal tab,
+		// line feed, vertical tab, form feed, carriage return, space.
+		//
+		// ```ruby
+		// "  Goby Lang  ".strip   # => "Goby Lang"
+		// "\nGoby Lang\r\t".strip # => "Goby Lang"
+		// ```
+		//
+		// @return [String]
+		Name: "strip",
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
-			if len(args) != 1 {
-				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
-			}
-
-			typeErr := t.vm.checkArgTypes(args, sourceLine, classes.StringClass)
-
-			if typeErr != nil {
-				return typeErr
-			}
-
-			compareStrValue := args[0].Value().(string)
-			compareStrLength := utf8.RuneCountInString(compareStrValue)
 
 			str := receiver.(*StringObject).value
-			strLength := utf8.RuneCountInString(str)
 
-			if compareStrLength > strLength {
-				return FALSE
-			}
+			return t.vm.InitStringObject(strings.Trim(str, stripCutset))
 
-			if compareStrValue == string([]rune(str)[:compareStrLength]) {
-				return TRUE
+		},
+	},
+	{
+		// Same as `#strip`, but modifies the receiver in place. A destructive method.
+		// Returns nil if no whitespace was removed.
+		//
+		// ```ruby
+		// s = "  Goby Lang  "
+		// s.strip! # => "Goby Lang"
+		// s.strip! # => nil
+		// s        # => "Goby Lang"
+		// ```
+		//
+		// @return [String]
+		Name: "strip!",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
 			}
-			return FALSE
+
+			s := receiver.(*StringObject)
+
+			return s.mutate(t, sourceLine, strings.Trim(s.value, stripCutset))
 
 		},
 	},
 	{
-		// Returns a copy of str with leading and trailing whitespace removed.
-		// Whitespace is defined as any of the following characters: null, horizontal tab,
-		// line feed, vertical tab, form feed, carriage return, space.
+		// Returns a copy of the string with each `{{key}}` placeholder replaced by the value of the key in the given hash,
+		// converted like `to_s`. Spaces are allowed inside the braces, like `{{ key }}`, and keys are made of letters,
+		// digits and underscores. Anything else, including the values, is copied as is: nothing in the template is evaluated.
+		//
+		// A placeholder whose key isn't in the hash raises an `ArgumentError`.
+		// With the `strict: false` option, it's replaced with an empty string instead.
 		//
 		// ```ruby
-		// "  Goby Lang  ".strip   # => "Goby Lang"
-		// "\nGoby Lang\r\t".strip # => "Goby Lang"
+		// "Hello, {{name}}! You have {{ count }} messages.".template({ name: "Goby", count: 3 })
+		// # => "Hello, Goby! You have 3 messages."
+		// "{{greeting}}, {{name}}".template({ name: "Goby" })                      # => ArgumentError
+		// "{{greeting}}, {{name}}".template({ name: "Goby" }, { strict: false })  # => ", Goby"
 		// ```
 		//
+		// @param values [Hash]
+		// @param options [Hash]
 		// @return [String]
-		Name: "strip",
+		Name: "template",
 		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			aLen := len(args)
 
-			str := receiver.(*StringObject).value
+			if aLen < 1 || aLen > 2 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgumentRange, 1, 2, aLen)
+			}
+
+			values, ok := args[0].(*HashObject)
+
+			if !ok {
+				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormatNum, 1, classes.HashClass, args[0].Class().Name)
+			}
 
-			for {
-				str = strings.Trim(str, " ")
+			strict := true
 
-				if strings.HasPrefix(str, "\n") || strings.HasPrefix(str, "\t") || strings.HasPrefix(str, "\r") || strings.HasPrefix(str, "\v") {
-					str = string([]rune(str)[1:])
-					continue
+			if aLen == 2 {
+				options, ok := args[1].(*HashObject)
+
+				if !ok {
+					return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormatNum, 2, classes.HashClass, args[1].Class().Name)
 				}
-				if strings.HasSuffix(str, "\n") || strings.HasSuffix(str, "\t") || strings.HasSuffix(str, "\r") || strings.HasSuffix(str, "\v") {
-					str = string([]rune(str)[:utf8.RuneCountInString(str)-2])
-					continue
+
+				for _, key := range options.sortedKeys() {
+					if key != "strict" {
+						return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, unknownTemplateOption, key)
+					}
+
+					value, ok := options.Pairs[key].(*BooleanObject)
+
+					if !ok {
+						return t.vm.InitErrorObject(errors.TypeError, sourceLine, invalidTemplateOption, key, classes.BooleanClass, options.Pairs[key].Class().Name)
+					}
+
+					strict = value == TRUE
 				}
-				break
 			}
-			return t.vm.InitStringObject(str)
+
+			result, missing := renderTemplate(receiver.(*StringObject).value, values.Pairs, strict)
+
+			if missing != "" {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, missingTemplateKey, missing)
+			}
+
+			return t.vm.InitStringObject(result)
 
 		},
 	},
@@ -1634,6 +1956,30 @@ var builtinStringInstanceMethods = []*BuiltinMethodObject{
 
 		},
 	},
+	{
+		// Same as `#upcase`, but modifies the receiver in place. A destructive method.
+		// Returns nil if no character was changed.
+		//
+		// ```ruby
+		// s = "very big"
+		// s.upcase! # => "VERY BIG"
+		// s.upcase! # => nil
+		// s         # => "VERY BIG"
+		// ```
+		//
+		// @return [String]
+		Name: "upcase!",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			s := receiver.(*StringObject)
+
+			return s.mutate(t, sourceLine, strings.ToUpper(s.value))
+
+		},
+	},
 }
 
 // Internal functions ===================================================
@@ -1694,10 +2040,143 @@ func escapeBackslash(s string) string {
 
 // ToJSON just delegates to ToString
 func (s *StringObject) ToJSON(t *Thread) string {
-	return strconv.Quote(s.value)
+	return quoteJSON(s.value)
 }
 
 // equal returns true if the String values between receiver and parameter are equal
 func (s *StringObject) equal(e *StringObject) bool {
 	return s.value == e.value
 }
+
+// Other helper functions -----------------------------------------------
+
+// stripCutset is the whitespace removed by `strip`: null, horizontal tab, line feed, vertical tab, form feed, carriage return and space
+const stripCutset = "\x00\t\n\v\f\r "
+
+const (
+	missingTemplateKey    = "Missing template key: %s"
+	unknownTemplateOption = "Unknown option %s for String#template"
+	invalidTemplateOption = "Expect option %s to be %s. got: %s"
+)
+
+// templatePlaceholder matches the `{{key}}` placeholders of `String#template`
+var templatePlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// checkFrozen returns a FrozenError if the string is frozen, nil otherwise
+// index returns the character or the substring specified by the given arguments; common to `[]` and `slice()`.
+func (s *StringObject) index(t *Thread, args []Object, sourceLine int) Object {
+	runes := []rune(s.value)
+	span, ok, err := resolveIndex(t, args, len(runes), sourceLine)
+
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return NULL
+	}
+
+	return t.vm.InitStringObject(string(runes[span.start:span.end]))
+}
+
+func (s *StringObject) checkFrozen(t *Thread, sourceLine int) *Error {
+	if !s.frozen {
+		return nil
+	}
+
+	return t.vm.InitErrorObject(errors.FrozenError, sourceLine, errors.CantModifyFrozenObject, s.class.Name, s.Inspect())
+}
+
+// mutate sets the string's value in place for the bang methods.
+// It returns nil if the value doesn't change, and the receiver otherwise.
+func (s *StringObject) mutate(t *Thread, sourceLine int, value string) Object {
+	if err := s.checkFrozen(t, sourceLine); err != nil {
+		return err
+	}
+
+	if s.value == value {
+		return NULL
+	}
+
+	s.value = value
+	return s
+}
+
+// chompString removes the given suffix, or a trailing line separator if no suffix is given
+func chompString(t *Thread, sourceLine int, str string, args []Object) (string, *Error) {
+	switch len(args) {
+	case 0:
+		if strings.HasSuffix(str, "\r\n") {
+			return str[:len(str)-2], nil
+		}
+
+		return strings.TrimSuffix(strings.TrimSuffix(str, "\n"), "\r"), nil
+	case 1:
+		suffix, ok := args[0].(*StringObject)
+
+		if !ok {
+			return "", t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.StringClass, args[0].Class().Name)
+		}
+
+		return strings.TrimSuffix(str, suffix.value), nil
+	default:
+		return "", t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgumentLess, 1, len(args))
+	}
+}
+
+// renderTemplate replaces the placeholders of the template with the values of their keys.
+// In strict mode, it stops at the first missing key and returns it; otherwise missing keys are rendered as empty strings.
+func renderTemplate(template string, values map[string]Object, strict bool) (string, string) {
+	var out strings.Builder
+	last := 0
+
+	for _, match := range templatePlaceholder.FindAllStringSubmatchIndex(template, -1) {
+		out.WriteString(template[last:match[0]])
+		last = match[1]
+
+		key := template[match[2]:match[3]]
+		value, ok := values[key]
+
+		if !ok {
+			if strict {
+				return "", key
+			}
+
+			continue
+		}
+
+		out.WriteString(value.ToString())
+	}
+
+	out.WriteString(template[last:])
+
+	return out.String(), ""
+}
+
+// stringStartWith implements `start_with?`, and its deprecated alias `start_with`
+func stringStartWith(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+	if len(args) != 1 {
+		return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+	}
+
+	typeErr := t.vm.checkArgTypes(args, sourceLine, classes.StringClass)
+
+	if typeErr != nil {
+		return typeErr
+	}
+
+	compareStrValue := args[0].Value().(string)
+	compareStrLength := utf8.RuneCountInString(compareStrValue)
+
+	str := receiver.(*StringObject).value
+	strLength := utf8.RuneCountInString(str)
+
+	if compareStrLength > strLength {
+		return FALSE
+	}
+
+	if compareStrValue == string([]rune(str)[:compareStrLength]) {
+		return TRUE
+	}
+	return FALSE
+}
@@ -170,6 +170,57 @@ func TestMatchMethod(t *testing.T) {
 	}
 }
 
+func TestStringMatchOperator(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"Hello World" =~ Regexp.new("(o)r(l)")`, 7},
+		{`"Hello 😊 World" =~ Regexp.new("W")`, 8},
+		{`"abc" =~ Regexp.new("d")`, nil},
+		{`Regexp.new("l+") =~ "Hello"`, 2},
+		{`
+		"Hello World" =~ Regexp.new("(o)r(l)")
+		[$~.to_s, $1, $2, $3]
+		`, []interface{}{`#<MatchData 0:"orl" 1:"o" 2:"l">`, "o", "l", nil}},
+		{`
+		"2018-01" =~ Regexp.new("(?<year>\d+)-(?<month>\d+)")
+		$~.to_h["year"] + $2
+		`, "201801"},
+		// a failed match clears the last match
+		{`
+		"abc" =~ Regexp.new("(b)")
+		"abc" =~ Regexp.new("(d)")
+		[$~, $1]
+		`, []interface{}{nil, nil}},
+		{`"abc".match(Regexp.new("(c)")); $1`, "c"},
+		{`$~`, nil},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestStringMatchOperatorFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`"abc" =~ "a"`, "TypeError: Expect argument to be Regexp. got: String", 1},
+		{`Regexp.new("a") =~ 1`, "TypeError: Expect argument to be String. got: Integer", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestMatchMethodFail(t *testing.T) {
 	testsFail := []errorTestCase{
 		{`"abc".match?(*[1, 2])`, "ArgumentError: Expect 1 argument(s). got: 2", 1},
@@ -225,6 +276,12 @@ func TestStringOperation(t *testing.T) {
 		{`"Hello🍣"[5] = "🍺"`, "Hello🍺"},
 		{`"Hello🍣"[1] = "🍺"`, "H🍺llo🍣"},
 		{`"Hello🍣"[-1] = "🍺"`, "Hello🍺"},
+		{`
+		a = "Ruby"
+		b = a
+		b[0] = "G"
+		a
+		`, "Guby"},
 	}
 
 	for i, tt := range tests {
@@ -243,10 +300,10 @@ func TestStringOperationFail(t *testing.T) {
 		{`"Taipei" * (-101)`, "ArgumentError: Expect second argument to be positive value. got: -101", 1},
 		{`"Taipei"[1] = 1`, "TypeError: Expect argument to be String. got: Integer", 1},
 		{`"Taipei"[1] = true`, "TypeError: Expect argument to be String. got: Boolean", 1},
-		{`"Taipei"[]`, "ArgumentError: Expect 1 argument(s). got: 0", 1},
-		{`"Taipei"[true] = 101`, "TypeError: Expect argument to be Integer. got: Boolean", 1},
-		{`"Taipei"[20] = "10"`, "ArgumentError: Index value out of range. got: 20", 1},
-		{`"Taipei"[-20] = "a"`, "ArgumentError: Index value out of range. got: -20", 1},
+		{`"Taipei"[]`, "ArgumentError: Expect 1 to 2 argument(s). got: 0", 1},
+		{`"Taipei"[true] = 101`, "TypeError: Expect argument to be Range or Integer. got: Boolean", 1},
+		{`"Taipei"[20] = "10"`, "IndexError: Index value out of range. got: 20", 1},
+		{`"Taipei"[-20] = "a"`, "IndexError: Index value out of range. got: -20", 1},
 	}
 
 	for i, tt := range testsFail {
@@ -287,6 +344,91 @@ func TestStringCapitalizeMethod(t *testing.T) {
 	}
 }
 
+func TestStringChompMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"Hello\n".chomp`, "Hello"},
+		{`"Hello\r\n".chomp`, "Hello"},
+		{`"Hello\r".chomp`, "Hello"},
+		{`"Hello\n\n".chomp`, "Hello\n"},
+		{`"Hello".chomp`, "Hello"},
+		{`"Hello🍣".chomp("🍣")`, "Hello"},
+		{`"Hello".chomp("🍣")`, "Hello"},
+		{`
+		s = "Hello\n"
+		s.chomp
+		s
+		`, "Hello\n"},
+		{`
+		s = "Hello\n"
+		s.chomp!
+		`, "Hello"},
+		{`
+		s = "Hello\n"
+		t = s
+		s.chomp!
+		t
+		`, "Hello"},
+		{`
+		s = "Hello"
+		s.chomp!
+		`, nil},
+		{`
+		s = "Hello🍣"
+		s.chomp!("🍣")
+		s
+		`, "Hello"},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestStringChompMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`"Hello".chomp(1)`, "TypeError: Expect argument to be String. got: Integer", 1},
+		{`"Hello".chomp!("a", "b")`, "ArgumentError: Expect 1 or less argument(s). got: 2", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestStringClearMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"Hello".clear`, ""},
+		{`
+		s = "Hello"
+		t = s
+		s.clear
+		t
+		`, ""},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestStringChopMethod(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -313,6 +455,14 @@ func TestStringConcatenateMethod(t *testing.T) {
 	}{
 		{`"Hello ".concat("World")`, "Hello World"},
 		{`"Hello World".concat("🍣")`, "Hello World🍣"},
+		{`"Hello".concat(" ", "World", "🍣")`, "Hello World🍣"},
+		{`"Hello".concat`, "Hello"},
+		{`
+		a = "Hello"
+		b = a
+		b.concat(" World")
+		a
+		`, "Hello World"},
 	}
 
 	for i, tt := range tests {
@@ -326,8 +476,7 @@ func TestStringConcatenateMethod(t *testing.T) {
 
 func TestStringConcatenateMethodFail(t *testing.T) {
 	testsFail := []errorTestCase{
-		{`"a".concat`, "ArgumentError: Expect 1 argument(s). got: 0", 1},
-		{`"a".concat("Hello", "World")`, "ArgumentError: Expect 1 argument(s). got: 2", 1},
+		{`"a".concat("Hello", 1)`, "TypeError: Expect argument to be String. got: Integer", 1},
 		{`"a".concat(1)`, "TypeError: Expect argument to be String. got: Integer", 1},
 		{`"a".concat(true)`, "TypeError: Expect argument to be String. got: Boolean", 1},
 		{`"a".concat(nil)`, "TypeError: Expect argument to be String. got: Null", 1},
@@ -342,6 +491,61 @@ func TestStringConcatenateMethodFail(t *testing.T) {
 	}
 }
 
+func TestStringAppendMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"Hello " << "World"`, "Hello World"},
+		{`"Hello" << " " << "World" << "🍣"`, "Hello World🍣"},
+		{`
+		a = "Hello"
+		b = a
+		b << " World"
+		a
+		`, "Hello World"},
+		{`
+		s = "Goby"
+		s << " is" << " fun"
+		s.replace("Ruby").insert(0, "Not ") << "!"
+		s
+		`, "Not Ruby!"},
+		{`
+		s = ""
+		i = 0
+		while i < 3 do
+		  s << i.to_s
+		  i += 1
+		end
+		s
+		`, "012"},
+		{`"a" << "b" * 2`, "abb"},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestStringAppendMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`"a" << 1`, "TypeError: Expect argument to be String. got: Integer", 1},
+		{`"a" << nil`, "TypeError: Expect argument to be String. got: Null", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestStringCountMethod(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -701,6 +905,33 @@ func TestStringEqualMethodFail(t *testing.T) {
 	}
 }
 
+func TestStringFreezeMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"Goby".frozen?`, false},
+		{`"Goby".freeze.frozen?`, true},
+		{`"Goby".freeze`, "Goby"},
+		{`"Goby".freeze.dup.frozen?`, false},
+		{`"Goby".freeze.upcase`, "GOBY"},
+		{`
+		s = "Goby"
+		t = s
+		s.freeze
+		t.frozen?
+		`, true},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestStringIncludeMethod(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -757,6 +988,12 @@ func TestStringInsertMethod(t *testing.T) {
 		{`"Hello".insert(5, "🍣")`, "Hello🍣"},
 		{`"Hello".insert(-2, "🍣")`, "Hel🍣lo"},
 		{`"Hello".insert(-6, "🍣")`, "🍣Hello"},
+		{`
+		a = "Hello"
+		b = a
+		b.insert(5, " World")
+		a
+		`, "Hello World"},
 	}
 
 	for i, tt := range tests {
@@ -880,6 +1117,45 @@ func TestStringMatchFail(t *testing.T) {
 	}
 }
 
+func TestStringPrependMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"Lang".prepend("Goby ")`, "Goby Lang"},
+		{`"Lang".prepend("🍣")`, "🍣Lang"},
+		{`
+		a = "Lang"
+		b = a
+		b.prepend("Goby ")
+		a
+		`, "Goby Lang"},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestStringPrependMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`"Lang".prepend`, "ArgumentError: Expect 1 argument(s). got: 0", 1},
+		{`"Lang".prepend(1)`, "TypeError: Expect argument to be String. got: Integer", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestStringReplaceMethod(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -888,6 +1164,18 @@ func TestStringReplaceMethod(t *testing.T) {
 		{`"Ruby Lang Ruby Ruby".replace("Ru", "Go")`, "Goby Lang Goby Goby"},
 		{`"🍣Ruby🍣Lang".replace("Ru", "Go")`, "🍣Goby🍣Lang"},
 		{`re = Regexp.new("(Ru|ru)");"Ruby Lang ruby lang".replace(re, "Go")`, "Goby Lang Goby lang"},
+		{`"Ruby".replace("Goby")`, "Goby"},
+		{`
+		a = "Ruby"
+		b = a
+		b.replace("Goby")
+		a
+		`, "Goby"},
+		{`
+		a = "Ruby"
+		b = a.replace("Ru", "Go")
+		a
+		`, "Ruby"},
 	}
 
 	for i, tt := range tests {
@@ -901,9 +1189,9 @@ func TestStringReplaceMethod(t *testing.T) {
 
 func TestStringReplaceMethodFail(t *testing.T) {
 	testsFail := []errorTestCase{
-		{`"Invalid".replace`, "ArgumentError: Expect 2 argument(s). got: 0", 1},
-		{`"Invalid".replace("string")`, "ArgumentError: Expect 2 argument(s). got: 1", 1},
-		{`"Invalid".replace("string", "replace", true)`, "ArgumentError: Expect 2 argument(s). got: 3", 1},
+		{`"Invalid".replace`, "ArgumentError: Expect 1 to 2 argument(s). got: 0", 1},
+		{`"Invalid".replace(1)`, "TypeError: Expect argument to be String. got: Integer", 1},
+		{`"Invalid".replace("string", "replace", true)`, "ArgumentError: Expect 1 to 2 argument(s). got: 3", 1},
 		{`"Invalid".replace(true, "replacement")`, "TypeError: Expect argument #1 to be String or Regexp. got: Boolean", 1},
 		{`"Invalid".replace("pattern", true)`, "TypeError: Expect argument #2 to be String. got: Boolean", 1},
 	}
@@ -1084,7 +1372,7 @@ func TestStringSliceMethod(t *testing.T) {
 
 func TestStringSliceMethodFail(t *testing.T) {
 	testsFail := []errorTestCase{
-		{`"Goby Lang".slice`, "ArgumentError: Expect 1 argument(s). got: 0", 1},
+		{`"Goby Lang".slice`, "ArgumentError: Expect 1 to 2 argument(s). got: 0", 1},
 		{`"Goby Lang".slice("Hello")`, "TypeError: Expect argument to be Range or Integer. got: String", 1},
 		{`"Goby Lang".slice(true)`, "TypeError: Expect argument to be Range or Integer. got: Boolean", 1},
 	}
@@ -1098,6 +1386,74 @@ func TestStringSliceMethodFail(t *testing.T) {
 	}
 }
 
+func TestStringSliceBangMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"Hello 🍣🍺 World".slice!(6)`, "🍣"},
+		{`"Hello 🍣🍺 World".slice!(-1)`, "d"},
+		{`"Hello".slice!(5)`, nil},
+		{`"Hello".slice!(-6)`, nil},
+		{`"Hello".slice!(1, 3)`, "ell"},
+		{`"Hello".slice!(-3, 10)`, "llo"},
+		{`"Hello".slice!(5, 1)`, ""},
+		{`"Hello".slice!(6, 1)`, nil},
+		{`"Hello".slice!(1, -1)`, nil},
+		{`"Hello 🍣🍺 World".slice!(6..7)`, "🍣🍺"},
+		{`"Hello".slice!(-3..-1)`, "llo"},
+		{`"Hello".slice!(3..1)`, ""},
+		{`"Hello".slice!(6..7)`, nil},
+		{`
+		s = "Hello 🍣🍺 World"
+		s.slice!(6)
+		s
+		`, "Hello 🍺 World"},
+		{`
+		s = "Hello World"
+		s.slice!(5, 6)
+		s
+		`, "Hello"},
+		{`
+		s = "Hello World"
+		t = s
+		s.slice!(0..5)
+		t
+		`, "World"},
+		{`
+		s = "Hello"
+		s.slice!(10)
+		s
+		`, "Hello"},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestStringSliceBangMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`"Hello".slice!`, "ArgumentError: Expect 1 to 2 argument(s). got: 0", 1},
+		{`"Hello".slice!(1, 2, 3)`, "ArgumentError: Expect 1 to 2 argument(s). got: 3", 1},
+		{`"Hello".slice!("1")`, "TypeError: Expect argument to be Range or Integer. got: String", 1},
+		{`"Hello".slice!(1, "2")`, "TypeError: Expect argument #2 to be Integer. got: String", 1},
+		{`"Hello".slice!(1..2, 1)`, "ArgumentError: Expect 1 argument(s). got: 2", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestStringSplitMethod(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -1200,14 +1556,14 @@ func TestStringStartWithMethod(t *testing.T) {
 		input    string
 		expected interface{}
 	}{
-		{`"Hello".start_with("Hel")`, true},
-		{`"Hello".start_with("Hello")`, true},
-		{`"Hello".start_with("Hello ")`, false},
-		{`"哈囉！世界！".start_with("哈囉！")`, true},
-		{`"Hello".start_with("hel")`, false},
-		{`"哈囉！世界".start_with("世界！")`, false},
-		{`"🍣Hello🍺".start_with("🍣")`, true},
-		{`"🍣Hello🍺".start_with("🍺")`, false},
+		{`"Hello".start_with?("Hel")`, true},
+		{`"Hello".start_with?("Hello")`, true},
+		{`"Hello".start_with?("Hello ")`, false},
+		{`"哈囉！世界！".start_with?("哈囉！")`, true},
+		{`"Hello".start_with?("hel")`, false},
+		{`"哈囉！世界".start_with?("世界！")`, false},
+		{`"🍣Hello🍺".start_with?("🍣")`, true},
+		{`"🍣Hello🍺".start_with?("🍺")`, false},
 	}
 
 	for i, tt := range tests {
@@ -1221,10 +1577,10 @@ func TestStringStartWithMethod(t *testing.T) {
 
 func TestStringStartWithMethodFail(t *testing.T) {
 	testsFail := []errorTestCase{
-		{`"Taipei".start_with("1", "0", "1")`, "ArgumentError: Expect 1 argument(s). got: 3", 1},
-		{`"Taipei".start_with(101)`, "TypeError: Expect argument to be String. got: Integer", 1},
-		{`"Hello".start_with(true)`, "TypeError: Expect argument to be String. got: Boolean", 1},
-		{`"Hello".start_with(1..5)`, "TypeError: Expect argument to be String. got: Range", 1},
+		{`"Taipei".start_with?("1", "0", "1")`, "ArgumentError: Expect 1 argument(s). got: 3", 1},
+		{`"Taipei".start_with?(101)`, "TypeError: Expect argument to be String. got: Integer", 1},
+		{`"Hello".start_with?(true)`, "TypeError: Expect argument to be String. got: Boolean", 1},
+		{`"Hello".start_with?(1..5)`, "TypeError: Expect argument to be String. got: Range", 1},
 	}
 
 	for i, tt := range testsFail {
@@ -1255,6 +1611,88 @@ func TestStringStripMethod(t *testing.T) {
 	}
 }
 
+func TestStringStripBangMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"  Goby Lang   ".strip!`, "Goby Lang"},
+		{`"Goby Lang".strip!`, nil},
+		{`
+		s = " \t 🍣 Goby Lang 🍺 \r\n "
+		t = s
+		s.strip!
+		t
+		`, "🍣 Goby Lang 🍺"},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestStringTemplateMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"Hello, {{name}}!".template({ name: "Goby" })`, "Hello, Goby!"},
+		{`"{{ a }}+{{b}}={{  sum  }}".template({ a: 1, b: 2.5, sum: 3.5 })`, "1+2.5=3.5"},
+		{`"{{a}}{{a}}{{b}}".template({ a: "x", b: [1, "y"] })`, `xx[1, "y"]`},
+		{`"{{v}}|".template({ v: nil })`, "|"},
+		{`"no placeholders".template({ a: 1 })`, "no placeholders"},
+		{`"".template({})`, ""},
+		{`"🍣 {{item}} 🍺".template({ item: "寿司" })`, "🍣 寿司 🍺"},
+		// values aren't rendered again, and nothing else is evaluated
+		{`"{{a}}".template({ a: "{{b}}", b: "no" })`, "{{b}}"},
+		{`"{{a.b}} {{ }} {{1a}} { {a} } #{a} {{{a}}}".template({ a: 1 })`, "{{a.b}} {{ }} {{1a}} { {a} } #{a} {1}"},
+		{`"{{first_name}}{{_x9}}".template({ first_name: "Go", _x9: "by" })`, "Goby"},
+		{`"{{Name}}".template({ Name: "upper", name: "lower" })`, "upper"},
+		// missing keys are rendered as empty strings when not strict
+		{`"{{greeting}}, {{name}}".template({ name: "Goby" }, { strict: false })`, ", Goby"},
+		{`"{{name}}".template({ name: "Goby" }, { strict: true })`, "Goby"},
+		{`"{{name}}".template({ name: "Goby" }, {})`, "Goby"},
+		{`
+		s = "{{a}}"
+		s.template({ a: 1 })
+		s
+		`, "{{a}}"},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestStringTemplateMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`"{{greeting}}, {{name}}".template({ name: "Goby" })`, "ArgumentError: Missing template key: greeting", 1},
+		{`"{{a}} {{b}} {{c}}".template({ b: 1 })`, "ArgumentError: Missing template key: a", 1},
+		{`"{{a}}".template`, "ArgumentError: Expect 1 to 2 argument(s). got: 0", 1},
+		{`"{{a}}".template({}, {}, {})`, "ArgumentError: Expect 1 to 2 argument(s). got: 3", 1},
+		{`"{{a}}".template([1])`, "TypeError: Expect argument #1 to be Hash. got: Array", 1},
+		{`"{{a}}".template({ a: 1 }, true)`, "TypeError: Expect argument #2 to be Hash. got: Boolean", 1},
+		{`"{{a}}".template({ a: 1 }, { lenient: true })`, "ArgumentError: Unknown option lenient for String#template", 1},
+		{`"{{a}}".template({ a: 1 }, { strict: "no" })`, "TypeError: Expect option strict to be Boolean. got: String", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestStringConversion(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -1408,6 +1846,61 @@ func TestStringUpcaseMethod(t *testing.T) {
 
 // Other test
 
+func TestStringUpcaseBangMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"hEllO".upcase!`, "HELLO"},
+		{`"HELLO".upcase!`, nil},
+		{`"🍣".upcase!`, nil},
+		{`
+		s = "hello"
+		t = s
+		s.upcase!
+		t
+		`, "HELLO"},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestStringFrozenMethodFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`"Goby".freeze[0] = "R"`, "FrozenError: Can't modify frozen String: \"Goby\"", 1},
+		{`"Goby".freeze.chomp!`, "FrozenError: Can't modify frozen String: \"Goby\"", 1},
+		{`"Goby".freeze.clear`, "FrozenError: Can't modify frozen String: \"Goby\"", 1},
+		{`"Goby".freeze << "!"`, "FrozenError: Can't modify frozen String: \"Goby\"", 1},
+		{`"Goby".freeze.concat("!")`, "FrozenError: Can't modify frozen String: \"Goby\"", 1},
+		{`"Goby".freeze.insert(0, "!")`, "FrozenError: Can't modify frozen String: \"Goby\"", 1},
+		{`"Goby".freeze.prepend("!")`, "FrozenError: Can't modify frozen String: \"Goby\"", 1},
+		{`"Goby".freeze.replace("Ruby")`, "FrozenError: Can't modify frozen String: \"Goby\"", 1},
+		{`"Goby".freeze.slice!(0)`, "FrozenError: Can't modify frozen String: \"Goby\"", 1},
+		{`"Goby".freeze.strip!`, "FrozenError: Can't modify frozen String: \"Goby\"", 1},
+		{`"Goby".freeze.upcase!`, "FrozenError: Can't modify frozen String: \"Goby\"", 1},
+		{`
+		s = "Goby"
+		t = s
+		s.freeze
+		t.concat("!")
+		`, "FrozenError: Can't modify frozen String: \"Goby\"", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
 func TestStringMethodChaining(t *testing.T) {
 	tests := []struct {
 		input    string
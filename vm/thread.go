@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"path/filepath"
+	"runtime/debug"
 	"strings"
 
 	"github.com/goby-lang/goby/compiler"
@@ -28,9 +29,23 @@ type Thread struct {
 	// theads have an id so they can be looked up in the vm. The main thread is always 0
 	id int64
 
+	// catchTags holds the tags of the running `catch` calls, the innermost last
+	catchTags []Object
+
+	// lastMatch is the result of the thread's last regexp match, which `$~` and `$1`, `$2`... read
+	lastMatch *MatchDataObject
+
 	vm *VM
 }
 
+// throwSignal is the panic value `throw` unwinds the thread with, up to the `catch` of its tag.
+// It's not an Error, so nothing else rescues it.
+type throwSignal struct {
+	// tag is the tag the `catch` registered
+	tag   Object
+	value Object
+}
+
 // VM returns the vm of the thread
 func (t *Thread) VM() *VM {
 	return t.vm
@@ -43,7 +58,9 @@ func (t *Thread) isMainThread() bool {
 func (t *Thread) getBlock(name string, filename filename) *instructionSet {
 	// The "name" here is actually an index of block
 	// for example <Block:1>'s name is "1"
+	t.vm.instructionTableLock.Lock()
 	is, ok := t.vm.blockTables[filename][name]
+	t.vm.instructionTableLock.Unlock()
 
 	if !ok {
 		panic(fmt.Sprintf("Can't find block %s", name))
@@ -53,6 +70,9 @@ func (t *Thread) getBlock(name string, filename filename) *instructionSet {
 }
 
 func (t *Thread) getMethodIS(name string, filename filename) (*instructionSet, bool) {
+	t.vm.instructionTableLock.Lock()
+	defer t.vm.instructionTableLock.Unlock()
+
 	iss, ok := t.vm.isTables[bytecode.MethodDef][name]
 
 	if !ok {
@@ -67,6 +87,9 @@ func (t *Thread) getMethodIS(name string, filename filename) (*instructionSet, b
 }
 
 func (t *Thread) getClassIS(name string, filename filename) *instructionSet {
+	t.vm.instructionTableLock.Lock()
+	defer t.vm.instructionTableLock.Unlock()
+
 	iss, ok := t.vm.isTables[bytecode.ClassDef][name]
 
 	if !ok {
@@ -80,6 +103,13 @@ func (t *Thread) getClassIS(name string, filename filename) *instructionSet {
 	return is
 }
 
+// execGobyLibInNewThread runs the Goby library on a thread of its own.
+// Native libraries load their Goby part with it, since any thread can require them.
+func (vm *VM) execGobyLibInNewThread(libName string) error {
+	t := vm.newThread()
+	return t.execGobyLib(libName)
+}
+
 func (t *Thread) execGobyLib(libName string) (err error) {
 	libPath := filepath.Join(t.vm.libPath, libName)
 	err = t.execFile(libPath)
@@ -93,7 +123,14 @@ func (t *Thread) execFile(fpath string) (err error) {
 		return
 	}
 
-	instructionSets, err := compiler.CompileToInstructions(string(file), parser.NormalMode)
+	_, err = t.execSource(string(file), fpath)
+	return
+}
+
+// execSource runs the given source as if it's the content of the file at fpath.
+// It returns the Goby error the source raises, unless the VM's mode exits on it.
+func (t *Thread) execSource(src string, fpath string) (raised *Error, err error) {
+	instructionSets, err := compiler.CompileToInstructions(src, parser.NormalMode)
 
 	if err != nil {
 		return
@@ -103,6 +140,7 @@ func (t *Thread) execFile(fpath string) (err error) {
 	oldClassTable := isTable{}
 
 	// Copy current file's instruction sets.
+	t.vm.instructionTableLock.Lock()
 	for name, is := range t.vm.isTables[bytecode.MethodDef] {
 		oldMethodTable[name] = is
 	}
@@ -110,14 +148,17 @@ func (t *Thread) execFile(fpath string) (err error) {
 	for name, is := range t.vm.isTables[bytecode.ClassDef] {
 		oldClassTable[name] = is
 	}
+	t.vm.instructionTableLock.Unlock()
 
 	// This creates new execution environments for required file, including new instruction set table.
 	// So we need to copy old instruction sets and restore them later, otherwise current program's instruction set would be overwrite.
-	t.vm.ExecInstructions(instructionSets, fpath)
+	raised = t.execInstructions(instructionSets, fpath)
 
 	// Restore instruction sets.
+	t.vm.instructionTableLock.Lock()
 	t.vm.isTables[bytecode.MethodDef] = oldMethodTable
 	t.vm.isTables[bytecode.ClassDef] = oldClassTable
+	t.vm.instructionTableLock.Unlock()
 	return
 }
 
@@ -136,8 +177,17 @@ func (t *Thread) evalCallFrame(cf callFrame) {
 
 	switch cf := cf.(type) {
 	case *normalCallFrame:
+		tracedLine := -1
+
 		for cf.pc < cf.instructionsCount() {
 			i := cf.instructionSet.instructions[cf.pc]
+
+			// `leave` is placed on the line the frame starts at, so it's not a new line
+			if t.vm.tracer() != nil && i.SourceLine() != tracedLine && i.Opcode != bytecode.Leave {
+				tracedLine = i.SourceLine()
+				t.traceLine(cf, tracedLine)
+			}
+
 			t.execInstruction(cf, i)
 		}
 	case *goMethodCallFrame:
@@ -148,7 +198,12 @@ func (t *Thread) evalCallFrame(cf callFrame) {
 		}
 		//fmt.Println("-----------------------")
 		//fmt.Println(t.callFrameStack.inspect())
-		result := cf.method(cf.receiver, cf.sourceLine, t, args, cf.blockFrame)
+		result := t.callBuiltinMethod(cf, args)
+
+		if err, ok := result.(*Error); ok {
+			err.raisedFrom(cf.FileName())
+		}
+
 		t.Stack.Push(&Pointer{Target: result})
 		//fmt.Println(t.callFrameStack.inspect())
 		//fmt.Println("-----------------------")
@@ -169,6 +224,54 @@ func (t *Thread) evalCallFrame(cf callFrame) {
 	t.removeUselessBlockFrame(cf)
 }
 
+// callBuiltinMethod calls the method of the frame, and returns an InternalError in place of its result if it panics in Go,
+// so a bug in a builtin method fails the method call instead of killing the program. Panics with values that aren't errors,
+// like the strings of `panic(fmt.Sprintf(...))`, are converted too.
+// Goby errors and throws raised by the Goby code the method runs are passed on as they are.
+func (t *Thread) callBuiltinMethod(cf *goMethodCallFrame, args []Object) (result Object) {
+	cfp := t.callFrameStack.pointer
+	sp := t.Stack.pointer
+
+	defer func() {
+		r := recover()
+
+		if r == nil {
+			return
+		}
+
+		var goErr error
+
+		switch r := r.(type) {
+		case *Error, *throwSignal:
+			panic(r)
+		case string:
+			// A Goby error raised with pushErrorObject or setErrorObject panics with its message, and is left on the stack top
+			if top := t.Stack.top(); top != nil {
+				if err, ok := top.Target.(*Error); ok && err.Message() == r {
+					panic(r)
+				}
+			}
+
+			goErr = fmt.Errorf("%v", r)
+		case error:
+			goErr = r
+		default:
+			goErr = fmt.Errorf("%v", r)
+		}
+
+		// The method may have left frames and values of the Goby code it was running
+		t.callFrameStack.pointer = cfp
+		t.Stack.pointer = sp
+		t.currentFrame = cf
+
+		err := t.vm.InitErrorObject(errors.InternalError, cf.sourceLine, errors.BuiltinMethodPanicked, cf.name, goErr)
+		err.GoStack = string(debug.Stack())
+		result = err
+	}()
+
+	return cf.method(cf.receiver, cf.sourceLine, t, args, cf.blockFrame)
+}
+
 /*
 	Remove top frame if it's a block frame
 
@@ -196,6 +299,11 @@ func (t *Thread) reportErrorAndStop(e interface{}) {
 		cf.stopExecution()
 	}
 
+	// A throw isn't an error, it's passed on to its catch as it is
+	if _, ok := e.(*throwSignal); ok {
+		panic(e)
+	}
+
 	top := t.Stack.top().Target
 	switch err := top.(type) {
 	// If we can get an Error object, the panic was raised intentionally from
@@ -230,6 +338,7 @@ func (t *Thread) reportErrorAndStop(e interface{}) {
 
 func (t *Thread) execInstruction(cf *normalCallFrame, i *bytecode.Instruction) {
 	cf.pc++
+	t.checkMemory(i.SourceLine())
 
 	//fmt.Println(t.callFrameStack.inspect())
 	//fmt.Println(i.inspect())
@@ -239,9 +348,10 @@ func (t *Thread) execInstruction(cf *normalCallFrame, i *bytecode.Instruction) {
 	//fmt.Println(t.callFrameStack.inspect())
 }
 
-// Yield to a call frame
+// Yield to a call frame. An error raised in the block is returned as the result.
 func (t *Thread) Yield(args ...Object) Object {
-	return t.builtinMethodYield(t.currentFrame.BlockFrame(), args...)
+	result, _ := t.builtinMethodYield(t.currentFrame.BlockFrame(), args...)
+	return result
 }
 
 // BlockGiven returns whethe or not we have a block frame below us in the stack
@@ -249,11 +359,35 @@ func (t *Thread) BlockGiven() bool {
 	return t.currentFrame.BlockFrame() != nil
 }
 
-func (t *Thread) builtinMethodYield(blockFrame *normalCallFrame, args ...Object) Object {
+// builtinMethodYield calls the block with the arguments and returns its result.
+// If the block raises an error, the error is returned as the result with `erred` set, and the thread's call frames and stack
+// are restored to the state before the call. The builtin method should return the error right away,
+// so it's raised from the method call with the line in the block where it happened.
+//
+// The call frames are restored when the call returns, however the block ends, so a builtin method never pops them itself.
+// The block's source frame, which is below the method's frame, is removed after the method returns by `evalCallFrame`,
+// even if the method doesn't call the block at all.
+func (t *Thread) builtinMethodYield(blockFrame *normalCallFrame, args ...Object) (result Object, erred bool) {
 	if blockFrame.IsRemoved() {
-		return NULL
+		return NULL, false
 	}
 
+	cfp := t.callFrameStack.pointer
+	currentFrame := t.currentFrame
+
+	defer func() {
+		// `break` removes the method's frames too, which must stay removed
+		if t.callFrameStack.pointer < cfp {
+			return
+		}
+
+		for t.callFrameStack.pointer > cfp {
+			t.callFrameStack.pop()
+		}
+
+		t.currentFrame = currentFrame
+	}()
+
 	c := newNormalCallFrame(blockFrame.instructionSet, blockFrame.FileName(), blockFrame.sourceLine)
 	c.blockFrame = blockFrame
 	c.ep = blockFrame.ep
@@ -261,18 +395,110 @@ func (t *Thread) builtinMethodYield(blockFrame *normalCallFrame, args ...Object)
 	c.sourceLine = blockFrame.SourceLine()
 	c.isBlock = true
 
-	for i := 0; i < len(args); i++ {
-		c.insertLCL(i, 0, args[i])
+	for i, arg := range t.vm.blockArguments(blockFrame.instructionSet, args) {
+		c.insertLCL(i, 0, arg)
 	}
 
-	t.callFrameStack.push(c)
-	t.startFromTopFrame()
+	err := t.rescue(func() {
+		t.callFrameStack.push(c)
+		t.startFromTopFrame()
+	}, nil, nil)
+
+	if err != nil {
+		return err, true
+	}
 
 	if blockFrame.IsRemoved() {
-		return NULL
+		return NULL, false
+	}
+
+	return t.Stack.top().Target, false
+}
+
+// rescue calls fn and rescues the Goby error it raises if `rescuable` accepts it (a nil `rescuable` accepts any error).
+// The rescued error is returned with its stack traces, and the thread's call frames and stack are restored
+// to the state before the call, so the thread can go on as if fn has returned.
+//
+// `ensure`, if given, always runs after fn: when fn returns, when an error is rescued, and before an error
+// that isn't rescued (or a Go panic) is raised again.
+func (t *Thread) rescue(fn func(), rescuable func(err *Error) bool, ensure func()) (rescued *Error) {
+	cfp := t.callFrameStack.pointer
+	sp := t.Stack.pointer
+	currentFrame := t.currentFrame
+
+	defer func() {
+		r := recover()
+
+		if err, ok := r.(*Error); ok && (rescuable == nil || rescuable(err)) {
+			rescued, r = err, nil
+		}
+
+		// ensure may run Goby code, so the thread's state is restored before it runs, even if the error is raised again.
+		// Otherwise an error that isn't rescued leaves the state as it was when raised, like any other error.
+		if rescued != nil || (r != nil && ensure != nil) {
+			t.callFrameStack.pointer = cfp
+			t.Stack.pointer = sp
+			t.currentFrame = currentFrame
+		}
+
+		if ensure != nil {
+			ensure()
+		}
+
+		if r != nil {
+			panic(r)
+		}
+	}()
+
+	fn()
+
+	return nil
+}
+
+// catch calls fn, and returns the value given to `throw` with the tag while fn runs.
+// If something is thrown, the thread's call frames and stack are restored to the state before the call, like `rescue` does.
+func (t *Thread) catch(tag Object, fn func()) (value Object, thrown bool) {
+	cfp := t.callFrameStack.pointer
+	sp := t.Stack.pointer
+	currentFrame := t.currentFrame
+	depth := len(t.catchTags)
+	t.catchTags = append(t.catchTags, tag)
+
+	defer func() {
+		t.catchTags = t.catchTags[:depth]
+		r := recover()
+
+		if r == nil {
+			return
+		}
+
+		signal, ok := r.(*throwSignal)
+
+		if !ok || signal.tag != tag {
+			panic(r)
+		}
+
+		t.callFrameStack.pointer = cfp
+		t.Stack.pointer = sp
+		t.currentFrame = currentFrame
+		value, thrown = signal.value, true
+	}()
+
+	fn()
+
+	return NULL, false
+}
+
+// throw unwinds the thread to the innermost running `catch` whose tag equals the given one, which then returns the value.
+// It returns an UncaughtThrowError if there's no such `catch`.
+func (t *Thread) throw(tag, value Object, sourceLine int) *Error {
+	for i := len(t.catchTags) - 1; i >= 0; i-- {
+		if t.catchTags[i] == tag || t.catchTags[i].equalTo(tag) {
+			panic(&throwSignal{tag: t.catchTags[i], value: value})
+		}
 	}
 
-	return t.Stack.top().Target
+	return t.vm.InitErrorObject(errors.UncaughtThrowError, sourceLine, "uncaught throw %s", tag.Inspect())
 }
 
 func (t *Thread) retrieveBlock(fileName, blockFlag string, sourceLine int) (blockFrame *normalCallFrame) {
@@ -296,9 +522,21 @@ func (t *Thread) retrieveBlock(fileName, blockFlag string, sourceLine int) (bloc
 	return
 }
 
-func (t *Thread) findMethod(receiver Object, methodName string, receiverPr int, argCount int, argPr int, sourceLine int) (method Object, argC int) {
+// findMethod finds the receiver's method, or its method_missing if there's no such method.
+// A private or protected method that can't be called on the receiver written like receiverKind from the caller raises a NoMethodError.
+func (t *Thread) findMethod(receiver Object, methodName string, receiverPr int, argCount int, argPr int, sourceLine int, receiverKind uint8, caller Object) (method Object, argC int) {
 	method = receiver.findMethod(methodName)
 
+	if method != nil && !canCallMethod(method, methodName, receiverKind, caller) {
+		format := errors.PrivateMethodCalled
+
+		if methodVisibility(method) == protectedMethod {
+			format = errors.ProtectedMethodCalled
+		}
+
+		t.setErrorObject(receiverPr, argPr, errors.NoMethodError, sourceLine, format, methodName, receiver.Inspect())
+	}
+
 	if method == nil {
 		mm := receiver.findMethodMissing(receiver.Class().inheritsMethodMissing)
 
@@ -327,9 +565,9 @@ func (t *Thread) findMethod(receiver Object, methodName string, receiverPr int,
 	return method, argCount
 }
 
-func (t *Thread) findAndCallMethod(receiver Object, methodName string, receiverPr int, argSet *bytecode.ArgSet, argCount int, argPr int, sourceLine int, blockFrame *normalCallFrame, fileName string) {
+func (t *Thread) findAndCallMethod(receiver Object, methodName string, receiverPr int, argSet *bytecode.ArgSet, argCount int, argPr int, sourceLine int, blockFrame *normalCallFrame, fileName string, receiverKind uint8, caller Object) {
 	// argCount change if we ended up calling method_missing
-	method, argCount := t.findMethod(receiver, methodName, receiverPr, argCount, argPr, sourceLine)
+	method, argCount := t.findMethod(receiver, methodName, receiverPr, argCount, argPr, sourceLine, receiverKind, caller)
 
 	switch m := method.(type) {
 	case *MethodObject:
@@ -340,17 +578,59 @@ func (t *Thread) findAndCallMethod(receiver Object, methodName string, receiverP
 	}
 }
 
-func (t *Thread) sendMethod(methodName string, argCount int, blockFrame *normalCallFrame, sourceLine int) {
-	if arr, ok := t.Stack.top().Target.(*ArrayObject); ok && arr.splat {
-		// Pop array
-		t.Stack.Pop()
-		// Can't count array self, only the number of array elements
-		argCount += len(arr.Elements)
-		for _, elem := range arr.Elements {
-			t.Stack.Push(&Pointer{Target: elem})
+// callMethod calls the receiver's method with the given arguments, like a method call in Goby code, and returns its result.
+// An error raised by the method is returned as the result, and the thread's state is restored.
+func (t *Thread) callMethod(receiver Object, methodName string, sourceLine int, args ...Object) (result Object) {
+	cfp := t.callFrameStack.pointer
+	receiverPr := t.Stack.pointer
+	currentFrame := t.currentFrame
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+
+		err, ok := r.(*Error)
+		if !ok {
+			// An error returned by a builtin method is left in place of the receiver
+			err, ok = t.Stack.data[receiverPr].Target.(*Error)
 		}
+
+		if !ok {
+			panic(r)
+		}
+
+		t.callFrameStack.pointer = cfp
+		t.Stack.pointer = receiverPr
+		t.currentFrame = currentFrame
+		result = err
+	}()
+
+	var fileName string
+	if currentFrame != nil {
+		fileName = currentFrame.FileName()
 	}
 
+	t.Stack.Push(&Pointer{Target: receiver})
+
+	for _, arg := range args {
+		t.Stack.Push(&Pointer{Target: arg})
+	}
+
+	// Methods called from Go ignore the visibility, like the ones called without a receiver
+	t.findAndCallMethod(receiver, methodName, receiverPr, &bytecode.ArgSet{}, len(args), receiverPr+1, sourceLine, nil, fileName, bytecode.ImplicitReceiver, nil)
+
+	result = t.Stack.data[receiverPr].Target
+	t.Stack.pointer = receiverPr
+	return result
+}
+
+// sendMethod calls the method named by `send` or `public_send` with the rest of the arguments.
+// With ImplicitReceiver as receiverKind, any method can be called regardless of its visibility.
+func (t *Thread) sendMethod(methodName string, argCount int, blockFrame *normalCallFrame, sourceLine int, receiverKind uint8) {
+	argCount, _ = t.expandSplatArguments(argCount, nil)
+
 	argPr := t.Stack.pointer - argCount - 1
 	receiverPr := argPr - 1
 	receiver := t.Stack.data[receiverPr].Target
@@ -381,8 +661,65 @@ func (t *Thread) sendMethod(methodName string, argCount int, blockFrame *normalC
 	t.Stack.pointer--
 
 	sendCallFrame := t.callFrameStack.top()
+	// The frame under `send`'s own frame is where it's called
+	caller := t.callFrameStack.callFrames[t.callFrameStack.pointer-2].Self()
+
+	t.findAndCallMethod(receiver, methodName, receiverPr, &bytecode.ArgSet{}, argCount, argPr, sourceLine, blockFrame, sendCallFrame.FileName(), receiverKind, caller)
+}
+
+// expandSplatArguments replaces the splatted arrays among the arguments on the top of the stack with their elements,
+// so `foo(1, *arr, 2)` passes the array's elements between the other arguments.
+// It returns the new argument count, and the argument set with the elements marked as normal arguments.
+func (t *Thread) expandSplatArguments(argCount int, argSet *bytecode.ArgSet) (int, *bytecode.ArgSet) {
+	argPr := t.Stack.pointer - argCount
+	given := make([]Object, argCount)
+	splatted := false
+
+	for i := range given {
+		given[i] = t.Stack.data[argPr+i].Target
+
+		if arr, ok := given[i].(*ArrayObject); ok && arr.splat {
+			splatted = true
+		}
+	}
 
-	t.findAndCallMethod(receiver, methodName, receiverPr, &bytecode.ArgSet{}, argCount, argPr, sourceLine, blockFrame, sendCallFrame.FileName())
+	if !splatted {
+		return argCount, argSet
+	}
+
+	var args []Object
+	var names []string
+	var types []uint8
+
+	for i, arg := range given {
+		if arr, ok := arg.(*ArrayObject); ok && arr.splat {
+			for _, elem := range arr.Elements {
+				args = append(args, elem)
+				names = append(names, "")
+				types = append(types, bytecode.NormalArg)
+			}
+
+			continue
+		}
+
+		args = append(args, arg)
+
+		if argSet != nil && i < len(argSet.Types()) {
+			names = append(names, argSet.Names()[i])
+			types = append(types, argSet.Types()[i])
+		} else {
+			names = append(names, "")
+			types = append(types, bytecode.NormalArg)
+		}
+	}
+
+	t.Stack.pointer = argPr
+
+	for _, arg := range args {
+		t.Stack.Push(&Pointer{Target: arg})
+	}
+
+	return len(args), bytecode.NewArgSet(names, types)
 }
 
 func (t *Thread) evalBuiltinMethod(receiver Object, method *BuiltinMethodObject, receiverPtr, argCount int, argSet *bytecode.ArgSet, blockFrame *normalCallFrame, sourceLine int, fileName string) {
@@ -400,9 +737,21 @@ func (t *Thread) evalBuiltinMethod(receiver Object, method *BuiltinMethodObject,
 	)
 
 	t.callFrameStack.push(cf)
+
+	if t.vm.tracer() != nil {
+		event := t.methodTraceEvent(method.Name, receiver, fileName, sourceLine, true)
+		t.vm.trace(event, TraceCall)
+		defer t.vm.trace(event, TraceReturn)
+	}
+
 	t.startFromTopFrame()
 	evaluated := t.Stack.top()
 
+	// `break` with a value inside the block makes the method call return that value
+	if blockFrame != nil && blockFrame.breakValue != nil {
+		evaluated = &Pointer{Target: blockFrame.breakValue}
+	}
+
 	_, ok := receiver.(*RClass)
 	if method.Name == "new" && ok {
 		instance, ok := evaluated.Target.(*RObject)
@@ -415,7 +764,7 @@ func (t *Thread) evalBuiltinMethod(receiver Object, method *BuiltinMethodObject,
 	t.Stack.Set(receiverPtr, evaluated)
 	t.Stack.pointer = cf.argPtr
 
-	if err, ok := evaluated.Target.(*Error); ok {
+	if err, ok := evaluated.Target.(*Error); ok && !method.returnsError {
 		panic(err.Message())
 	}
 }
@@ -428,8 +777,17 @@ func (t *Thread) evalMethodObject(call *callObject) {
 	stack := t.Stack.data
 	sourceLine := call.sourceLine
 
-	if call.argCount > paramsCount && !call.method.isSplatArgIncluded() {
-		t.reportArgumentError(sourceLine, paramsCount, call.methodName(), call.argCount, call.receiverPtr)
+	if !call.method.isSplatArgIncluded() {
+		if call.method.isDoubleSplatArgIncluded() {
+			// The double splat parameter takes any keyword arguments, so only the positional ones are limited
+			positionalArgsCount := call.argCount - call.keywordArgsCount()
+
+			if positionalArgsCount > call.positionalParamsCount() {
+				t.reportArgumentError(sourceLine, call.positionalParamsCount(), call.methodName(), positionalArgsCount, call.receiverPtr)
+			}
+		} else if call.argCount > paramsCount {
+			t.reportArgumentError(sourceLine, paramsCount, call.methodName(), call.argCount, call.receiverPtr)
+		}
 	}
 
 	if normalParamsCount > call.argCount {
@@ -447,7 +805,13 @@ func (t *Thread) evalMethodObject(call *callObject) {
 		}
 	}
 
-	err := call.assignKeywordArguments(stack)
+	var extraKeywordArgs *HashObject
+
+	if call.method.isDoubleSplatArgIncluded() {
+		extraKeywordArgs = t.vm.InitHashObject(map[string]Object{})
+	}
+
+	err := call.assignKeywordArguments(stack, extraKeywordArgs)
 
 	if err != nil {
 		t.setErrorObject(call.receiverPtr, call.argPtr(), errors.ArgumentError, sourceLine, err.Error())
@@ -462,8 +826,7 @@ func (t *Thread) evalMethodObject(call *callObject) {
 			case bytecode.NormalArg, bytecode.OptionedArg:
 				call.assignNormalAndOptionedArguments(paramIndex, stack)
 			case bytecode.SplatArg:
-				call.argIndex = paramIndex
-				call.assignSplatArgument(stack, t.vm.InitArrayObject([]Object{}))
+				call.assignSplatArgument(paramIndex, stack, t.vm.InitArrayObject([]Object{}))
 			}
 		}
 	} else {
@@ -471,6 +834,13 @@ func (t *Thread) evalMethodObject(call *callObject) {
 	}
 
 	t.callFrameStack.push(call.callFrame)
+
+	if t.vm.tracer() != nil {
+		event := t.methodTraceEvent(call.methodName(), call.callFrame.Self(), call.callFrame.FileName(), sourceLine, false)
+		t.vm.trace(event, TraceCall)
+		defer t.vm.trace(event, TraceReturn)
+	}
+
 	t.startFromTopFrame()
 
 	t.Stack.Set(call.receiverPtr, t.Stack.top())
@@ -491,14 +861,14 @@ func (t *Thread) reportArgumentError(sourceLine, idealArgNumber int, methodName
 
 // pushErrorObject pushes the Error object to the stack
 func (t *Thread) pushErrorObject(errorType string, sourceLine int, format string, args ...interface{}) {
-	err := t.vm.InitErrorObject(errorType, sourceLine, format, args...)
+	err := t.initErrorObject(errorType, sourceLine, format, args...)
 	t.Stack.Push(&Pointer{Target: err})
 	panic(err.Message())
 }
 
 // setErrorObject replaces a certain stack element with the Error object
 func (t *Thread) setErrorObject(receiverPtr, sp int, errorType string, sourceLine int, format string, args ...interface{}) {
-	err := t.vm.InitErrorObject(errorType, sourceLine, format, args...)
+	err := t.initErrorObject(errorType, sourceLine, format, args...)
 	t.Stack.Set(receiverPtr, &Pointer{Target: err})
 	t.Stack.pointer = sp
 	panic(err.Message())
@@ -513,3 +883,25 @@ func blockIsEmpty(blockFrame *normalCallFrame) bool {
 	}
 	return false
 }
+
+// blockArguments adapts the yielded arguments to the block's parameters:
+// an Array yielded alone to a block with several parameters is spread into them,
+// and several arguments yielded to a block whose only parameter destructures them, like `|(k, v)|`, are packed into an Array.
+func (vm *VM) blockArguments(is *instructionSet, args []Object) []Object {
+	if is.paramTypes == nil {
+		return args
+	}
+
+	params := is.paramTypes.Names()
+
+	switch {
+	case len(params) > 1 && len(args) == 1:
+		if arr, ok := args[0].(*ArrayObject); ok {
+			return arr.Elements
+		}
+	case len(params) == 1 && len(args) > 1 && strings.HasPrefix(params[0], "("):
+		return []Object{vm.InitArrayObject(args)}
+	}
+
+	return args
+}
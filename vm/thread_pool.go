@@ -0,0 +1,91 @@
+package vm
+
+import (
+	"sync/atomic"
+)
+
+// threadPool keeps the threads that finished running a block, so `thread` and `Concurrent::Future` can reuse
+// them instead of allocating new stacks for every block. See SetThreadPool.
+type threadPool struct {
+	idle chan *Thread
+}
+
+// SetThreadPool makes `thread` and `Concurrent::Future` run their blocks on threads reused from a pool
+// that keeps up to size idle threads. The pool is filled with size threads in advance.
+// A size of 0 turns the pool off, so every block gets a new thread.
+func (vm *VM) SetThreadPool(size int) {
+	if size <= 0 {
+		vm.threadPool.Store((*threadPool)(nil))
+		return
+	}
+
+	p := &threadPool{idle: make(chan *Thread, size)}
+
+	for i := 0; i < size; i++ {
+		t := vm.newThread()
+		p.idle <- &t
+	}
+
+	vm.threadPool.Store(p)
+}
+
+// pool returns the thread pool, or nil if it's off
+func (vm *VM) pool() *threadPool {
+	p, _ := vm.threadPool.Load().(*threadPool)
+	return p
+}
+
+// acquireThread returns an idle thread from the pool, or a new thread if there's none
+func (vm *VM) acquireThread() *Thread {
+	if p := vm.pool(); p != nil {
+		select {
+		case t := <-p.idle:
+			// A reused thread gets a new id, so it isn't mistaken for the thread it was
+			t.id = atomic.AddInt64(&vm.threadCount, 1)
+			return t
+		default:
+		}
+	}
+
+	t := vm.newThread()
+	return &t
+}
+
+// releaseThread resets the thread and puts it back to the pool.
+// It's dropped if the pool is off or full. The thread must not be used after it's released.
+func (vm *VM) releaseThread(t *Thread) {
+	p := vm.pool()
+
+	if p == nil {
+		return
+	}
+
+	t.reset()
+
+	select {
+	case p.idle <- t:
+	default:
+	}
+}
+
+// reset empties the thread's stacks and forgets its state, so nothing leaks to the next block it runs.
+// The stacks keep their capacity, which is what makes reusing a thread cheaper than creating one.
+func (t *Thread) reset() {
+	for i := range t.callFrameStack.callFrames {
+		t.callFrameStack.callFrames[i] = nil
+	}
+
+	t.callFrameStack.pointer = 0
+
+	t.Stack.Lock()
+	for i := range t.Stack.data {
+		t.Stack.data[i] = nil
+	}
+
+	t.Stack.pointer = 0
+	t.Stack.Unlock()
+
+	t.currentFrame = nil
+	t.catchTags = nil
+	t.lastMatch = nil
+}
@@ -0,0 +1,149 @@
+package vm
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestThreadPoolReusesThreads(t *testing.T) {
+	v := initTestVM()
+	v.SetThreadPool(1)
+
+	first := v.acquireThread()
+	first.Stack.Push(&Pointer{Target: v.InitIntegerObject(1)})
+	first.callFrameStack.push(newNormalCallFrame(nil, "", 1))
+	first.catchTags = append(first.catchTags, v.InitStringObject("tag"))
+	first.lastMatch = &MatchDataObject{}
+	id := first.id
+	v.releaseThread(first)
+
+	second := v.acquireThread()
+
+	if second != first {
+		t.Fatal("Expect the released thread to be reused")
+	}
+
+	if second.id == id {
+		t.Fatalf("Expect the reused thread to get a new id. got: %d", second.id)
+	}
+
+	if second.Stack.pointer != 0 || second.Stack.data[0] != nil {
+		t.Fatalf("Expect the stack to be empty. got: %s", second.Stack.inspect())
+	}
+
+	if second.callFrameStack.pointer != 0 || second.callFrameStack.callFrames[0] != nil {
+		t.Fatalf("Expect the call frame stack to be empty. got: %s", second.callFrameStack.inspect())
+	}
+
+	if second.catchTags != nil || second.lastMatch != nil {
+		t.Fatal("Expect the catch tags and the last match to be cleared")
+	}
+
+	// the pool is empty, so a new thread is created
+	if third := v.acquireThread(); third == second {
+		t.Fatal("Expect a new thread when the pool is empty")
+	}
+}
+
+func TestThreadPoolOff(t *testing.T) {
+	v := initTestVM()
+	v.SetThreadPool(2)
+	v.SetThreadPool(0)
+
+	first := v.acquireThread()
+	v.releaseThread(first)
+
+	if second := v.acquireThread(); second == first {
+		t.Fatal("Expect threads not to be reused when the pool is off")
+	}
+}
+
+func TestThreadPoolSetWhileInUse(t *testing.T) {
+	v := initTestVM()
+	v.SetThreadPool(2)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				v.releaseThread(v.acquireThread())
+			}
+		}()
+	}
+
+	// The pool can be resized or turned off while other threads use it
+	for i := 0; i < 20; i++ {
+		v.SetThreadPool(i % 3)
+	}
+
+	wg.Wait()
+}
+
+func TestThreadPoolBlocks(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		c = Channel.new
+
+		100.times do |i|
+		  thread do
+		    c.deliver(i)
+		  end
+		end
+
+		r = 0
+		100.times do
+		  r = r + c.receive
+		end
+
+		r
+		`, 4950},
+		{`
+		require 'concurrent/future'
+
+		fs = []
+		20.times do |i|
+		  fs.push(Concurrent::Future.execute do
+		    i * 2
+		  end)
+		end
+
+		fs.map do |f|
+		  f.value(5)
+		end.reduce(0) do |sum, n|
+		  sum + n
+		end
+		`, 380},
+		// the last match of a block doesn't leak into the next block run on the same thread
+		{`
+		require 'concurrent/future'
+
+		f = Concurrent::Future.execute do
+		  "goby" =~ Regexp.new("(o)")
+		  $1
+		end
+		r = f.value(5)
+
+		g = Concurrent::Future.execute do
+		  $1
+		end
+		[r, g.value(5)]
+		`, []interface{}{"o", nil}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		v.SetThreadPool(1)
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
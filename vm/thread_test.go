@@ -0,0 +1,449 @@
+package vm
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/goby-lang/goby/vm/errors"
+)
+
+func blockFrameOf(block *BlockObject) *normalCallFrame {
+	c := newNormalCallFrame(block.instructionSet, block.instructionSet.filename, 0)
+	c.ep = block.ep
+	c.self = block.self
+	c.isBlock = true
+	return c
+}
+
+// runBlock runs the block on the thread without rescuing the error it raises, unlike builtinMethodYield
+func runBlock(thread *Thread, block *BlockObject) Object {
+	thread.callFrameStack.push(blockFrameOf(block))
+	thread.startFromTopFrame()
+	return thread.Stack.top().Target
+}
+
+func TestThreadRescue(t *testing.T) {
+	input := `
+	def foo
+	  bar
+	end
+
+	def bar
+	  raise ArgumentError, "boom"
+	end
+
+	Block.new do
+	  foo
+	end
+	`
+
+	v := initTestVM()
+	block := v.testEval(t, input, getFilename()).(*BlockObject)
+	thread := &v.mainThread
+	cfp, sp := thread.callFrameStack.pointer, thread.Stack.pointer
+	ensured := false
+
+	err := thread.rescue(func() {
+		runBlock(thread, block)
+	}, nil, func() {
+		ensured = true
+	})
+
+	if err == nil {
+		t.Fatal("Expect the error to be rescued")
+	}
+
+	if err.Type != errors.ArgumentError || err.message != `ArgumentError: "boom"` {
+		t.Errorf("Expect the rescued error to be the raised one. got: %s", err.message)
+	}
+
+	// the lines of `raise`, `bar` and `foo`
+	expectedLines := []string{":7", ":3", ":11"}
+
+	if len(err.stackTraces) != len(expectedLines) {
+		t.Fatalf("Expect the rescued error to carry %d stack traces. got: %v", len(expectedLines), err.stackTraces)
+	}
+
+	for i, line := range expectedLines {
+		if !strings.HasSuffix(err.stackTraces[i], line) {
+			t.Errorf("Expect stack trace #%d to end with %s. got: %s", i, line, err.stackTraces[i])
+		}
+	}
+
+	if !ensured {
+		t.Error("Expect ensure to run after the error is rescued")
+	}
+
+	if thread.callFrameStack.pointer != cfp || thread.Stack.pointer != sp {
+		t.Errorf("Expect the thread's state to be restored. got cfp: %d, sp: %d", thread.callFrameStack.pointer, thread.Stack.pointer)
+	}
+
+	// the thread is still usable after the error is rescued
+	result := runBlock(thread, v.testEval(t, `Block.new do 1 + 1 end`, getFilename()).(*BlockObject))
+	VerifyExpected(t, 0, result, 2)
+}
+
+func TestThreadRescueEnsure(t *testing.T) {
+	v := initTestVM()
+	thread := &v.mainThread
+	block := v.testEval(t, `Block.new do 10 end`, getFilename()).(*BlockObject)
+	var result Object
+	ensured := false
+
+	err := thread.rescue(func() {
+		result = runBlock(thread, block)
+	}, nil, func() {
+		ensured = true
+	})
+
+	if err != nil {
+		t.Errorf("Expect no error to be rescued. got: %s", err.message)
+	}
+
+	if !ensured {
+		t.Error("Expect ensure to run when no error is raised")
+	}
+
+	VerifyExpected(t, 0, result, 10)
+}
+
+func TestThreadRescueReraise(t *testing.T) {
+	v := initTestVM()
+	thread := &v.mainThread
+	block := v.testEval(t, `Block.new do raise TypeError, "nope" end`, getFilename()).(*BlockObject)
+	ensured := false
+
+	defer func() {
+		err, ok := recover().(*Error)
+
+		if !ok || err.Type != errors.TypeError {
+			t.Errorf("Expect the TypeError to be raised again. got: %v", err)
+		}
+
+		if !ensured {
+			t.Error("Expect ensure to run before the error is raised again")
+		}
+	}()
+
+	isArgumentError := func(err *Error) bool {
+		return err.Type == errors.ArgumentError
+	}
+
+	thread.rescue(func() {
+		runBlock(thread, block)
+	}, isArgumentError, func() {
+		ensured = true
+	})
+
+	t.Error("Expect the error not to be rescued")
+}
+
+func TestBuiltinMethodYieldError(t *testing.T) {
+	tests := []struct {
+		input    string
+		lines    []string
+		expected interface{}
+	}{
+		{`
+		A = [1, 2]
+		failing = Block.new do
+		  A.each do |i|
+		    i.foo
+		  end
+		end
+		healthy = Block.new do
+		  A.map do |i| i * 2 end
+		end
+		[failing, healthy]
+		`, []string{":5", ":4"}, []interface{}{2, 4}},
+		{`
+		H = { a: 1, b: 2 }
+		failing = Block.new do
+		  H.map_values do |v|
+		    v.foo
+		  end
+		end
+		healthy = Block.new do
+		  H.each do |k, v| H[k] = v + 1 end
+		  H.values.sort
+		end
+		[failing, healthy]
+		`, []string{":5", ":4"}, []interface{}{2, 3}},
+		{`
+		require 'concurrent/hash'
+		H = Concurrent::Hash.new({ a: 1 })
+		failing = Block.new do
+		  H.each do |k, v|
+		    v.foo
+		  end
+		end
+		healthy = Block.new do
+		  H[:b] = 2
+		  H[:a] + H[:b]
+		end
+		[failing, healthy]
+		`, []string{":6", ":5"}, 3},
+		{`
+		require 'concurrent/array'
+		A = Concurrent::Array.new([1, 2])
+		failing = Block.new do
+		  A.map do |i|
+		    i.foo
+		  end
+		end
+		healthy = Block.new do
+		  A.push(3)
+		  A.length
+		end
+		[failing, healthy]
+		`, []string{":6", ":5"}, 3},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		blocks := v.testEval(t, tt.input, getFilename()).(*ArrayObject).Elements
+		thread := &v.mainThread
+		cfp, sp := thread.callFrameStack.pointer, thread.Stack.pointer
+
+		result, erred := thread.builtinMethodYield(blockFrameOf(blocks[0].(*BlockObject)))
+
+		if !erred {
+			t.Fatalf("At test case %d: Expect the block to raise an error. got: %s", i, result.ToString())
+		}
+
+		err := result.(*Error)
+
+		if err.Type != errors.NoMethodError {
+			t.Errorf("At test case %d: Expect a NoMethodError. got: %s", i, err.message)
+		}
+
+		// the lines of `i.foo` or `v.foo` in the inner block, and the method call with the block
+		if len(err.stackTraces) != len(tt.lines) {
+			t.Fatalf("At test case %d: Expect the error to carry %d stack traces. got: %v", i, len(tt.lines), err.stackTraces)
+		}
+
+		for j, line := range tt.lines {
+			if !strings.HasSuffix(err.stackTraces[j], line) {
+				t.Errorf("At test case %d: Expect stack trace #%d to end with %s. got: %s", i, j, line, err.stackTraces[j])
+			}
+		}
+
+		if thread.callFrameStack.pointer != cfp || thread.Stack.pointer != sp {
+			t.Errorf("At test case %d: Expect the thread's state to be restored. got cfp: %d, sp: %d", i, thread.callFrameStack.pointer, thread.Stack.pointer)
+		}
+
+		result, erred = thread.builtinMethodYield(blockFrameOf(blocks[1].(*BlockObject)))
+
+		if erred {
+			t.Fatalf("At test case %d: Expect the VM to keep working after the error. got: %s", i, result.ToString())
+		}
+
+		VerifyExpected(t, i, result, tt.expected)
+	}
+}
+
+func TestBuiltinMethodYieldFrameBalance(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		Block.new do
+		  [].each do |i| i end
+		  [].map do |i| i end
+		  [].any? do |i| i end
+		  1
+		end
+		`, 1},
+		{`
+		Block.new do
+		  {}.each do |k, v| k end
+		  {}.any? do |k, v| k end
+		  {}.each_key do |k| k end
+		  2
+		end
+		`, 2},
+		{`
+		require 'concurrent/hash'
+		Block.new do
+		  h = Concurrent::Hash.new
+		  h.each do |k, v| k end
+		  h.merge({}) do |k, a, b| a end
+		  3
+		end
+		`, 3},
+		{`
+		Block.new do
+		  [1, 2].each do |i| i end
+		  { a: 1 }.each do |k, v| k end
+		  4
+		end
+		`, 4},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		block := v.testEval(t, tt.input, getFilename()).(*BlockObject)
+		thread := &v.mainThread
+		cfp := thread.callFrameStack.pointer
+
+		result := runBlock(thread, block)
+
+		VerifyExpected(t, i, result, tt.expected)
+
+		if thread.callFrameStack.pointer != cfp {
+			t.Errorf("At test case %d: Expect the call frames to be balanced. got cfp: %d, expect: %d", i, thread.callFrameStack.pointer, cfp)
+		}
+	}
+}
+
+func TestBuiltinMethodPanic(t *testing.T) {
+	tests := []struct {
+		input    string
+		message  string
+		expected interface{}
+	}{
+		{`
+		failing = Block.new do
+		  1.send("+")
+		end
+		healthy = Block.new do
+		  1 + 2
+		end
+		[failing, healthy]
+		`, "InternalError: Builtin method '+' panicked: runtime error: index out of range", 3},
+		{`
+		failing = Block.new do
+		  "goby".send("!=")
+		end
+		healthy = Block.new do
+		  "goby" != "ruby"
+		end
+		[failing, healthy]
+		`, "InternalError: Builtin method '!=' panicked: runtime error: index out of range", true},
+		// the panic happens in a block run by another builtin method
+		{`
+		A = [1, 2]
+		failing = Block.new do
+		  A.map do |i|
+		    i.send("*")
+		  end
+		end
+		healthy = Block.new do
+		  A.map do |i| i * 2 end
+		end
+		[failing, healthy]
+		`, "InternalError: Builtin method '*' panicked: runtime error: index out of range", []interface{}{2, 4}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		blocks := v.testEval(t, tt.input, getFilename()).(*ArrayObject).Elements
+		thread := &v.mainThread
+		cfp, sp := thread.callFrameStack.pointer, thread.Stack.pointer
+
+		result, erred := thread.builtinMethodYield(blockFrameOf(blocks[0].(*BlockObject)))
+
+		if !erred {
+			t.Fatalf("At test case %d: Expect the block to raise an error. got: %s", i, result.ToString())
+		}
+
+		err := result.(*Error)
+
+		if err.Type != errors.InternalError || !strings.HasPrefix(err.message, tt.message) {
+			t.Errorf("At test case %d: Expect the error to start with %q. got: %s", i, tt.message, err.message)
+		}
+
+		if !strings.Contains(err.GoStack, "goroutine") {
+			t.Errorf("At test case %d: Expect the error to carry the Go stack. got: %q", i, err.GoStack)
+		}
+
+		if thread.callFrameStack.pointer != cfp || thread.Stack.pointer != sp {
+			t.Errorf("At test case %d: Expect the thread's state to be restored. got cfp: %d, sp: %d", i, thread.callFrameStack.pointer, thread.Stack.pointer)
+		}
+
+		result, erred = thread.builtinMethodYield(blockFrameOf(blocks[1].(*BlockObject)))
+
+		if erred {
+			t.Fatalf("At test case %d: Expect the VM to keep working after the error. got: %s", i, result.ToString())
+		}
+
+		VerifyExpected(t, i, result, tt.expected)
+	}
+}
+
+func TestBuiltinMethodNonErrorPanic(t *testing.T) {
+	panicking := []*BuiltinMethodObject{
+		{
+			Name: "panic_with_string",
+			Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+				panic(fmt.Sprintf("Can't find block %s", "1"))
+			},
+		},
+		{
+			Name: "panic_with_value",
+			Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+				panic(42)
+			},
+		},
+	}
+
+	testsFail := []errorTestCase{
+		{`panic_with_string`, "InternalError: Builtin method 'panic_with_string' panicked: Can't find block 1", 1},
+		{`panic_with_value`, "InternalError: Builtin method 'panic_with_value' panicked: 42", 1},
+		{`[1].map do |i| panic_with_string end`, "InternalError: Builtin method 'panic_with_string' panicked: Can't find block 1", 1},
+		// Goby errors raised while the method runs are still raised as they are
+		{`[1].map do |i| i.foo end`, "NoMethodError: Undefined Method 'foo' for 1", 1},
+		{`1.send("foo")`, "NoMethodError: Undefined Method 'foo' for 1", 2},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		v.objectClass.setBuiltinMethods(panicking, false)
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestBuiltinMethodPanicInFuture(t *testing.T) {
+	input := `
+	require 'concurrent/future'
+
+	f = Concurrent::Future.execute do
+	  1.send("-")
+	end
+	f.value(5)
+
+	n = 0
+	[1, 2, 3].each do |i|
+	  n += i
+	end
+
+	[f.rejected?, f.reason.class.name, f.reason.to_s.include?("InternalError: Builtin method '-' panicked"), n]
+	`
+
+	v := initTestVM()
+	evaluated := v.testEval(t, input, getFilename())
+	VerifyExpected(t, 0, evaluated, []interface{}{true, "InternalError", true, 6})
+	v.checkCFP(t, 0, 0)
+	v.checkSP(t, 0, 1)
+}
+
+func TestBuiltinMethodPanicFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`1.send("+")`, "InternalError: Builtin method '+' panicked: runtime error: index out of range [0] with length 0", 2},
+		{`1.5.send("/")`, "InternalError: Builtin method '/' panicked: runtime error: index out of range [0] with length 0", 2},
+		{`1.send("")`, "InternalError: Builtin method '' panicked: runtime error: invalid memory address or nil pointer dereference", 2},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
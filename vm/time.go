@@ -0,0 +1,841 @@
+package vm
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goby-lang/goby/vm/classes"
+	"github.com/goby-lang/goby/vm/errors"
+)
+
+// TimeObject represents a point in time with nanosecond precision, backed by Go's `time.Time`.
+// A Time is either in the local timezone or in UTC; `utc` and `localtime` return the same point in time in the other one.
+// Times are immutable: arithmetic returns a new Time, in the same timezone as the receiver.
+//
+// ```ruby
+// t = Time.utc(2020, 1, 2, 3, 4, 5)
+// t + 60                     # => 2020-01-02 03:05:05 UTC
+// t.strftime("%Y/%m/%d %H:%M") # => "2020/01/02 03:04"
+// Time.now - t               # => the seconds since then, as a Float
+// ```
+//
+// - `Time.new` is not supported; use `Time.now`, `Time.at`, `Time.utc` or `Time.local` instead.
+type TimeObject struct {
+	*BaseObj
+	value time.Time
+}
+
+const timeArgumentOutOfRange = "Expect %s to be between %d and %d. got: %d"
+
+// timeFields are the arguments of `Time.utc` and `Time.local` after the year, with their ranges
+var timeFields = []struct {
+	name     string
+	min, max int
+}{
+	{"month", 1, 12},
+	{"day", 1, 31},
+	{"hour", 0, 23},
+	{"minute", 0, 59},
+	{"second", 0, 59},
+}
+
+// Class methods --------------------------------------------------------
+var builtinTimeClassMethods = []*BuiltinMethodObject{
+	{
+		Name: "new",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return t.vm.InitNoMethodError(sourceLine, "new", receiver)
+
+		},
+	},
+	{
+		// Returns the current time in the local timezone.
+		//
+		// ```ruby
+		// Time.now # => 2020-01-02 12:04:05 +0900
+		// ```
+		//
+		// @return [Time]
+		Name: "now",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			return t.vm.initTimeObject(time.Now())
+
+		},
+	},
+	{
+		// Returns the time the given number of seconds after the Unix epoch, in the local timezone.
+		// The seconds can be a Float for a fraction of a second.
+		//
+		// ```ruby
+		// Time.at(0).utc         # => 1970-01-01 00:00:00 UTC
+		// Time.at(1.5).utc.nsec  # => 500000000
+		// ```
+		//
+		// @param seconds [Numeric]
+		// @return [Time]
+		Name: "at",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			d, err := t.secondsOf(args[0], sourceLine)
+
+			if err != nil {
+				return err
+			}
+
+			return t.vm.initTimeObject(time.Unix(0, 0).Add(d))
+
+		},
+	},
+	{
+		// Returns the time of the given date and time in UTC. Everything but the year can be left out,
+		// and defaults to the start of the year.
+		//
+		// ```ruby
+		// Time.utc(2020)                 # => 2020-01-01 00:00:00 UTC
+		// Time.utc(2020, 1, 2, 3, 4, 5)  # => 2020-01-02 03:04:05 UTC
+		// ```
+		//
+		// @param year [Integer], month [Integer], day [Integer], hour [Integer], minute [Integer], second [Integer]
+		// @return [Time]
+		Name: "utc",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return t.timeFromFields(args, time.UTC, sourceLine)
+
+		},
+	},
+	{
+		// Returns the time of the given date and time in the local timezone, like `Time.utc` does in UTC.
+		//
+		// ```ruby
+		// Time.local(2020, 1, 2) # => 2020-01-02 00:00:00 +0900
+		// ```
+		//
+		// @param year [Integer], month [Integer], day [Integer], hour [Integer], minute [Integer], second [Integer]
+		// @return [Time]
+		Name: "local",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return t.timeFromFields(args, time.Local, sourceLine)
+
+		},
+	},
+}
+
+// Instance methods -----------------------------------------------------
+var builtinTimeInstanceMethods = []*BuiltinMethodObject{
+	{
+		// Returns the time the given number of seconds later. The seconds can be a Float.
+		//
+		// ```ruby
+		// Time.utc(2020) + 90    # => 2020-01-01 00:01:30 UTC
+		// Time.utc(2020) + 0.5   # => 2020-01-01 00:00:00.5 UTC
+		// ```
+		//
+		// @param seconds [Numeric]
+		// @return [Time]
+		Name: "+",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			d, err := t.secondsOf(args[0], sourceLine)
+
+			if err != nil {
+				return err
+			}
+
+			return t.vm.initTimeObject(receiver.(*TimeObject).value.Add(d))
+
+		},
+	},
+	{
+		// Returns the time the given number of seconds earlier, or the seconds between the times as a Float if given a Time.
+		//
+		// ```ruby
+		// Time.utc(2020) - 60                  # => 2019-12-31 23:59:00 UTC
+		// Time.utc(2020, 1, 2) - Time.utc(2020) # => 86400.0
+		// ```
+		//
+		// @param seconds [Numeric] or time [Time]
+		// @return [Time] or [Float]
+		Name: "-",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			tm := receiver.(*TimeObject).value
+
+			if other, ok := args[0].(*TimeObject); ok {
+				return t.vm.initFloatObject(tm.Sub(other.value).Seconds())
+			}
+
+			d, err := t.secondsOf(args[0], sourceLine)
+
+			if err != nil {
+				return err
+			}
+
+			return t.vm.initTimeObject(tm.Add(-d))
+
+		},
+	},
+	{
+		// Returns 1 if self is later than the other Time, -1 if earlier, or 0 if they're the same point in time,
+		// even in different timezones. Returns nil if the other object isn't a Time.
+		//
+		// ```ruby
+		// Time.utc(2020) <=> Time.utc(2021) # => -1
+		// Time.utc(2020) <=> 1              # => nil
+		// ```
+		//
+		// @param other [Time]
+		// @return [Integer]
+		Name: "<=>",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			other, ok := args[0].(*TimeObject)
+
+			if !ok {
+				return NULL
+			}
+
+			return t.vm.InitIntegerObject(receiver.(*TimeObject).compare(other))
+
+		},
+	},
+	{
+		// Returns true if self is earlier than the other Time.
+		//
+		// ```ruby
+		// Time.utc(2020) < Time.utc(2021) # => true
+		// ```
+		//
+		// @param other [Time]
+		// @return [Boolean]
+		Name: "<",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return receiver.(*TimeObject).timeComparison(t, args, sourceLine, func(result int) bool {
+				return result < 0
+			})
+
+		},
+	},
+	{
+		// Returns true if self is earlier than or the same as the other Time.
+		//
+		// ```ruby
+		// Time.utc(2020) <= Time.utc(2020) # => true
+		// ```
+		//
+		// @param other [Time]
+		// @return [Boolean]
+		Name: "<=",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return receiver.(*TimeObject).timeComparison(t, args, sourceLine, func(result int) bool {
+				return result <= 0
+			})
+
+		},
+	},
+	{
+		// Returns true if self is later than the other Time.
+		//
+		// ```ruby
+		// Time.utc(2021) > Time.utc(2020) # => true
+		// ```
+		//
+		// @param other [Time]
+		// @return [Boolean]
+		Name: ">",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return receiver.(*TimeObject).timeComparison(t, args, sourceLine, func(result int) bool {
+				return result > 0
+			})
+
+		},
+	},
+	{
+		// Returns true if self is later than or the same as the other Time.
+		//
+		// ```ruby
+		// Time.utc(2020) >= Time.utc(2021) # => false
+		// ```
+		//
+		// @param other [Time]
+		// @return [Boolean]
+		Name: ">=",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return receiver.(*TimeObject).timeComparison(t, args, sourceLine, func(result int) bool {
+				return result >= 0
+			})
+
+		},
+	},
+	{
+		// Returns the day of the month, from 1 to 31.
+		//
+		// @return [Integer]
+		Name: "day",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return receiver.(*TimeObject).field(t, args, sourceLine, time.Time.Day)
+
+		},
+	},
+	{
+		// Returns the hour of the day, from 0 to 23.
+		//
+		// @return [Integer]
+		Name: "hour",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return receiver.(*TimeObject).field(t, args, sourceLine, time.Time.Hour)
+
+		},
+	},
+	{
+		// Returns the same point in time in the local timezone.
+		//
+		// ```ruby
+		// Time.utc(2020).localtime # => 2020-01-01 09:00:00 +0900
+		// ```
+		//
+		// @return [Time]
+		Name: "localtime",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			return t.vm.initTimeObject(receiver.(*TimeObject).value.Local())
+
+		},
+	},
+	{
+		// Returns the minute of the hour, from 0 to 59.
+		//
+		// @return [Integer]
+		Name: "min",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return receiver.(*TimeObject).field(t, args, sourceLine, time.Time.Minute)
+
+		},
+	},
+	{
+		// Returns the month of the year, from 1 to 12.
+		//
+		// @return [Integer]
+		Name: "month",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return receiver.(*TimeObject).field(t, args, sourceLine, func(tm time.Time) int {
+				return int(tm.Month())
+			})
+
+		},
+	},
+	{
+		// Returns the nanoseconds of the second, from 0 to 999999999.
+		//
+		// @return [Integer]
+		Name: "nsec",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return receiver.(*TimeObject).field(t, args, sourceLine, time.Time.Nanosecond)
+
+		},
+	},
+	{
+		// Returns the second of the minute, from 0 to 59.
+		//
+		// @return [Integer]
+		Name: "sec",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return receiver.(*TimeObject).field(t, args, sourceLine, time.Time.Second)
+
+		},
+	},
+	{
+		// Formats the time with Ruby's directives:
+		//
+		// - `%Y` year, `%C` century, `%y` year without century, `%m` month, `%B` month name, `%b` or `%h` abbreviated month name
+		// - `%d` day, `%e` day padded with a space, `%j` day of the year
+		// - `%H` hour, `%k` hour padded with a space, `%I` hour on a 12-hour clock, `%l` the same padded with a space,
+		//   `%P` "am" or "pm", `%p` "AM" or "PM"
+		// - `%M` minute, `%S` second, `%L` milliseconds, `%N` nanoseconds
+		// - `%A` weekday name, `%a` abbreviated weekday name, `%u` weekday from 1 (Monday) to 7, `%w` weekday from 0 (Sunday) to 6
+		// - `%Z` timezone abbreviation, `%z` UTC offset like "+0900", `%:z` UTC offset like "+09:00"
+		// - `%s` seconds since the Unix epoch
+		// - `%F` is `%Y-%m-%d`, `%T` is `%H:%M:%S`, `%D` is `%m/%d/%y`, `%R` is `%H:%M`, `%r` is `%I:%M:%S %p`,
+		//   `%c` is `%a %b %e %H:%M:%S %Y`
+		// - `%n` newline, `%t` tab, `%%` percent sign
+		//
+		// A `-` flag leaves out the padding of numbers, like `%-d`. Unknown directives are kept as they are.
+		//
+		// ```ruby
+		// t = Time.utc(2020, 1, 2, 15, 4, 5)
+		// t.strftime("%Y-%m-%d %H:%M:%S %Z") # => "2020-01-02 15:04:05 UTC"
+		// t.strftime("%a %b %-d, %l:%M %p")   # => "Thu Jan 2,  3:04 PM"
+		// ```
+		//
+		// @param format [String]
+		// @return [String]
+		Name: "strftime",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			format, ok := args[0].(*StringObject)
+
+			if !ok {
+				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.StringClass, args[0].Class().Name)
+			}
+
+			return t.vm.InitStringObject(strftime(receiver.(*TimeObject).value, format.value))
+
+		},
+	},
+	{
+		// Returns the seconds since the Unix epoch as a Float, with the fraction of the second.
+		//
+		// ```ruby
+		// Time.at(1.5).to_f # => 1.5
+		// ```
+		//
+		// @return [Float]
+		Name: "to_f",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			tm := receiver.(*TimeObject).value
+
+			return t.vm.initFloatObject(float64(tm.Unix()) + float64(tm.Nanosecond())/float64(time.Second))
+
+		},
+	},
+	{
+		// Returns the whole seconds since the Unix epoch.
+		//
+		// ```ruby
+		// Time.utc(2020).to_i # => 1577836800
+		// ```
+		//
+		// @return [Integer]
+		Name: "to_i",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			return t.vm.InitIntegerObject(int(receiver.(*TimeObject).value.Unix()))
+
+		},
+	},
+	{
+		// Returns the time like "2020-01-02 03:04:05 +0900", or with "UTC" for a time in UTC.
+		//
+		// @return [String]
+		Name: "to_s",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			return t.vm.InitStringObject(receiver.(*TimeObject).ToString())
+
+		},
+	},
+	{
+		// Returns the same point in time in UTC.
+		//
+		// ```ruby
+		// Time.at(0).utc # => 1970-01-01 00:00:00 UTC
+		// ```
+		//
+		// @return [Time]
+		Name: "utc",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			return t.vm.initTimeObject(receiver.(*TimeObject).value.UTC())
+
+		},
+	},
+	{
+		// Returns true if the time is in UTC.
+		//
+		// ```ruby
+		// Time.utc(2020).utc?   # => true
+		// Time.local(2020).utc? # => false
+		// ```
+		//
+		// @return [Boolean]
+		Name: "utc?",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			return toBooleanObject(receiver.(*TimeObject).isUTC())
+
+		},
+	},
+	{
+		// Returns the offset of the timezone from UTC in seconds.
+		//
+		// ```ruby
+		// Time.utc(2020).utc_offset # => 0
+		// ```
+		//
+		// @return [Integer]
+		Name: "utc_offset",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return receiver.(*TimeObject).field(t, args, sourceLine, func(tm time.Time) int {
+				_, offset := tm.Zone()
+				return offset
+			})
+
+		},
+	},
+	{
+		// Returns the day of the week, from 0 (Sunday) to 6.
+		//
+		// @return [Integer]
+		Name: "wday",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return receiver.(*TimeObject).field(t, args, sourceLine, func(tm time.Time) int {
+				return int(tm.Weekday())
+			})
+
+		},
+	},
+	{
+		// Returns the day of the year, from 1 to 366.
+		//
+		// @return [Integer]
+		Name: "yday",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return receiver.(*TimeObject).field(t, args, sourceLine, time.Time.YearDay)
+
+		},
+	},
+	{
+		// Returns the year.
+		//
+		// @return [Integer]
+		Name: "year",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return receiver.(*TimeObject).field(t, args, sourceLine, time.Time.Year)
+
+		},
+	},
+	{
+		// Returns the abbreviation of the timezone, like "UTC" or "JST".
+		//
+		// @return [String]
+		Name: "zone",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			name, _ := receiver.(*TimeObject).value.Zone()
+
+			return t.vm.InitStringObject(name)
+
+		},
+	},
+}
+
+// Internal functions ===================================================
+
+// Functions for initialization -----------------------------------------
+
+func (vm *VM) initTimeObject(value time.Time) *TimeObject {
+	return &TimeObject{
+		BaseObj: NewBaseObject(vm.TopLevelClass(classes.TimeClass)),
+		value:   value,
+	}
+}
+
+func (vm *VM) initTimeClass() *RClass {
+	tc := vm.initializeClass(classes.TimeClass)
+	// The class methods are set first, so the instance methods with the same names, like `utc`, take their place on instances
+	tc.setBuiltinMethods(builtinTimeClassMethods, true)
+	tc.setBuiltinMethods(builtinTimeInstanceMethods, false)
+	return tc
+}
+
+// Polymorphic helper functions -----------------------------------------
+
+// Value returns the object
+func (tm *TimeObject) Value() interface{} {
+	return tm.value
+}
+
+// ToString returns the time like "2020-01-02 03:04:05 +0900", or with "UTC" for a time in UTC
+func (tm *TimeObject) ToString() string {
+	if tm.isUTC() {
+		return tm.value.Format("2006-01-02 15:04:05 UTC")
+	}
+
+	return tm.value.Format("2006-01-02 15:04:05 -0700")
+}
+
+// Inspect returns the time like ToString does, with the fraction of the second if there's one
+func (tm *TimeObject) Inspect() string {
+	if tm.isUTC() {
+		return tm.value.Format("2006-01-02 15:04:05.999999999 UTC")
+	}
+
+	return tm.value.Format("2006-01-02 15:04:05.999999999 -0700")
+}
+
+// ToJSON returns the time as a JSON string in the RFC 3339 format
+func (tm *TimeObject) ToJSON(t *Thread) string {
+	return quoteJSON(tm.value.Format(time.RFC3339Nano))
+}
+
+// Times are equal if they're the same point in time, even in different timezones
+func (tm *TimeObject) equalTo(with Object) bool {
+	w, ok := with.(*TimeObject)
+	return ok && tm.value.Equal(w.value)
+}
+
+// compare returns -1, 0 or 1 when self is earlier than, the same as or later than the other time
+func (tm *TimeObject) compare(other *TimeObject) int {
+	switch {
+	case tm.value.Before(other.value):
+		return -1
+	case tm.value.After(other.value):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Apply the passed comparison to the result of compare, or raise an ArgumentError if the object isn't a Time
+func (tm *TimeObject) timeComparison(t *Thread, args []Object, sourceLine int, comparison func(result int) bool) Object {
+	if len(args) != 1 {
+		return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+	}
+
+	other, ok := args[0].(*TimeObject)
+
+	if !ok {
+		return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, comparisonFailed, classes.TimeClass, args[0].Class().Name)
+	}
+
+	return toBooleanObject(comparison(tm.compare(other)))
+}
+
+// field returns a part of the time as an Integer; common to the getters like `year` and `hour`
+func (tm *TimeObject) field(t *Thread, args []Object, sourceLine int, get func(time.Time) int) Object {
+	if len(args) != 0 {
+		return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+	}
+
+	return t.vm.InitIntegerObject(get(tm.value))
+}
+
+func (tm *TimeObject) isUTC() bool {
+	return tm.value.Location() == time.UTC
+}
+
+// Other helper functions -----------------------------------------------
+
+// secondsOf converts an Integer or a Float number of seconds to a duration
+func (t *Thread) secondsOf(obj Object, sourceLine int) (time.Duration, *Error) {
+	switch s := obj.(type) {
+	case *IntegerObject:
+		return time.Duration(s.value) * time.Second, nil
+	case *FloatObject:
+		return time.Duration(math.Round(s.value * float64(time.Second))), nil
+	default:
+		return 0, t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, "Numeric", obj.Class().Name)
+	}
+}
+
+// timeFromFields builds the time for `Time.utc` and `Time.local` from the year and the optional month, day, hour, minute and second
+func (t *Thread) timeFromFields(args []Object, loc *time.Location, sourceLine int) Object {
+	if len(args) < 1 || len(args) > 6 {
+		return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgumentRange, 1, 6, len(args))
+	}
+
+	values := []int{0, 1, 1, 0, 0, 0}
+
+	for i, arg := range args {
+		v, ok := arg.(*IntegerObject)
+
+		if !ok {
+			return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormatNum, i+1, classes.IntegerClass, arg.Class().Name)
+		}
+
+		if i > 0 {
+			f := timeFields[i-1]
+
+			if v.value < f.min || v.value > f.max {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, timeArgumentOutOfRange, f.name, f.min, f.max, v.value)
+			}
+		}
+
+		values[i] = v.value
+	}
+
+	tm := time.Date(values[0], time.Month(values[1]), values[2], values[3], values[4], values[5], 0, loc)
+
+	// time.Date normalizes dates like February 30 to the next month
+	if tm.Day() != values[2] {
+		return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, timeArgumentOutOfRange, "day", 1, daysIn(time.Month(values[1]), values[0]), values[2])
+	}
+
+	return t.vm.initTimeObject(tm)
+}
+
+// daysIn returns the number of days in the month of the year
+func daysIn(month time.Month, year int) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// strftime formats the time with Ruby's directives, see `Time#strftime`
+func strftime(tm time.Time, format string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i == len(format)-1 {
+			b.WriteByte(format[i])
+			continue
+		}
+
+		start := i
+		i++
+		pad := true
+
+		if format[i] == '-' && i < len(format)-1 {
+			pad = false
+			i++
+		}
+
+		num := func(value, width int, padding byte) {
+			s := strconv.Itoa(value)
+
+			if pad {
+				for n := len(s); n < width; n++ {
+					b.WriteByte(padding)
+				}
+			}
+
+			b.WriteString(s)
+		}
+
+		hour12 := tm.Hour() % 12
+
+		if hour12 == 0 {
+			hour12 = 12
+		}
+
+		switch format[i] {
+		case 'Y':
+			num(tm.Year(), 4, '0')
+		case 'C':
+			num(tm.Year()/100, 2, '0')
+		case 'y':
+			num(tm.Year()%100, 2, '0')
+		case 'm':
+			num(int(tm.Month()), 2, '0')
+		case 'B':
+			b.WriteString(tm.Format("January"))
+		case 'b', 'h':
+			b.WriteString(tm.Format("Jan"))
+		case 'd':
+			num(tm.Day(), 2, '0')
+		case 'e':
+			num(tm.Day(), 2, ' ')
+		case 'j':
+			num(tm.YearDay(), 3, '0')
+		case 'H':
+			num(tm.Hour(), 2, '0')
+		case 'k':
+			num(tm.Hour(), 2, ' ')
+		case 'I':
+			num(hour12, 2, '0')
+		case 'l':
+			num(hour12, 2, ' ')
+		case 'P':
+			b.WriteString(tm.Format("pm"))
+		case 'p':
+			b.WriteString(tm.Format("PM"))
+		case 'M':
+			num(tm.Minute(), 2, '0')
+		case 'S':
+			num(tm.Second(), 2, '0')
+		case 'L':
+			num(tm.Nanosecond()/int(time.Millisecond), 3, '0')
+		case 'N':
+			num(tm.Nanosecond(), 9, '0')
+		case 'A':
+			b.WriteString(tm.Format("Monday"))
+		case 'a':
+			b.WriteString(tm.Format("Mon"))
+		case 'u':
+			wday := int(tm.Weekday())
+
+			if wday == 0 {
+				wday = 7
+			}
+
+			num(wday, 1, '0')
+		case 'w':
+			num(int(tm.Weekday()), 1, '0')
+		case 'Z':
+			b.WriteString(tm.Format("MST"))
+		case 'z':
+			b.WriteString(tm.Format("-0700"))
+		case ':':
+			if i < len(format)-1 && format[i+1] == 'z' {
+				i++
+				b.WriteString(tm.Format("-07:00"))
+			} else {
+				b.WriteString(format[start : i+1])
+			}
+		case 's':
+			b.WriteString(strconv.FormatInt(tm.Unix(), 10))
+		case 'F':
+			b.WriteString(tm.Format("2006-01-02"))
+		case 'T':
+			b.WriteString(tm.Format("15:04:05"))
+		case 'D':
+			b.WriteString(tm.Format("01/02/06"))
+		case 'R':
+			b.WriteString(tm.Format("15:04"))
+		case 'r':
+			b.WriteString(tm.Format("03:04:05 PM"))
+		case 'c':
+			b.WriteString(tm.Format("Mon Jan _2 15:04:05 2006"))
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case '%':
+			b.WriteByte('%')
+		default:
+			b.WriteString(format[start : i+1])
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,174 @@
+package vm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeConstruction(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`Time.now.class.name`, "Time"},
+		{`Time.now <= Time.now`, true},
+		{`Time.utc(2020).to_i`, 1577836800},
+		{`Time.utc(2020, 1, 2, 3, 4, 5).to_s`, "2020-01-02 03:04:05 UTC"},
+		{`Time.at(1577836800).utc.to_s`, "2020-01-01 00:00:00 UTC"},
+		{`Time.at(1577836800) == Time.utc(2020)`, true},
+		{`Time.at(1.5).to_f`, 1.5},
+		{`Time.at(1.5).nsec`, 500000000},
+		{`Time.at(-1).utc.to_s`, "1969-12-31 23:59:59 UTC"},
+		{`
+		t = Time.utc(2020, 2, 29, 13, 14, 15)
+		[t.year, t.month, t.day, t.hour, t.min, t.sec, t.wday, t.yday]
+		`, []interface{}{2020, 2, 29, 13, 14, 15, 6, 60}},
+		{`Time.utc(2020).utc?`, true},
+		{`Time.utc(2020).zone`, "UTC"},
+		{`Time.utc(2020).utc_offset`, 0},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestTimeConstructionFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`Time.new`, "NoMethodError: Undefined Method 'new' for Time", 1},
+		{`Time.now(1)`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+		{`Time.at("0")`, "TypeError: Expect argument to be Numeric. got: String", 1},
+		{`Time.at`, "ArgumentError: Expect 1 argument(s). got: 0", 1},
+		{`Time.utc`, "ArgumentError: Expect 1 to 6 argument(s). got: 0", 1},
+		{`Time.utc(2020, 1, 1, 0, 0, 0, 0)`, "ArgumentError: Expect 1 to 6 argument(s). got: 7", 1},
+		{`Time.utc("2020")`, "TypeError: Expect argument #1 to be Integer. got: String", 1},
+		{`Time.utc(2020, 13)`, "ArgumentError: Expect month to be between 1 and 12. got: 13", 1},
+		{`Time.utc(2020, 1, 1, 24)`, "ArgumentError: Expect hour to be between 0 and 23. got: 24", 1},
+		{`Time.utc(2019, 2, 29)`, "ArgumentError: Expect day to be between 1 and 28. got: 29", 1},
+		{`Time.local(2020, 4, 31)`, "ArgumentError: Expect day to be between 1 and 30. got: 31", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestTimeFormatting(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`Time.utc(2020, 1, 2, 3, 4, 5).inspect`, "2020-01-02 03:04:05 UTC"},
+		{`(Time.utc(2020) + 0.25).inspect`, "2020-01-01 00:00:00.25 UTC"},
+		{`Time.utc(2020, 1, 2, 15, 4, 5).strftime("%Y-%m-%d %H:%M:%S %Z")`, "2020-01-02 15:04:05 UTC"},
+		{`Time.utc(2020, 1, 2, 15, 4, 5).strftime("%a %b %-d, %l:%M %p")`, "Thu Jan 2,  3:04 PM"},
+		{`Time.utc(2020, 1, 2, 15, 4, 5).strftime("%A %B %e %I%P %k %j %u %w")`, "Thursday January  2 03pm 15 002 4 4"},
+		{`Time.utc(2020, 1, 2, 15, 4, 5).strftime("%F %T|%D|%R|%r|%c")`, "2020-01-02 15:04:05|01/02/20|15:04|03:04:05 PM|Thu Jan  2 15:04:05 2020"},
+		{`Time.utc(2020, 1, 5).strftime("%C %y %-m %h %u %w %s")`, "20 20 1 Jan 7 0 1578182400"},
+		{`(Time.utc(2020) + 1.5).strftime("%S.%L %N")`, "01.500 500000000"},
+		{`Time.utc(2020).strftime("%z %:z %%Y %Q %")`, "+0000 +00:00 %Y %Q %"},
+		{`Time.utc(2020).strftime("a%nb%tc")`, "a\nb\tc"},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestTimeArithmeticAndComparison(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`(Time.utc(2020) + 90).to_s`, "2020-01-01 00:01:30 UTC"},
+		{`(Time.utc(2020) - 60).to_s`, "2019-12-31 23:59:00 UTC"},
+		{`(Time.utc(2020) + 0.5).to_f`, 1577836800.5},
+		{`Time.utc(2020, 1, 2) - Time.utc(2020)`, 86400.0},
+		{`Time.utc(2020) - Time.utc(2020, 1, 1, 0, 0, 30)`, -30.0},
+		{`(Time.utc(2020) + 1).utc?`, true},
+		{`Time.utc(2020) < Time.utc(2021)`, true},
+		{`Time.utc(2020) <= Time.utc(2020)`, true},
+		{`Time.utc(2020) > Time.utc(2021)`, false},
+		{`Time.utc(2020) >= Time.utc(2021)`, false},
+		{`Time.utc(2020) <=> Time.utc(2021)`, -1},
+		{`Time.utc(2021) <=> Time.utc(2020)`, 1},
+		{`Time.utc(2020) <=> Time.utc(2020)`, 0},
+		{`Time.utc(2020) <=> 1`, nil},
+		{`Time.utc(2020) == Time.utc(2020)`, true},
+		{`Time.utc(2020) == Time.utc(2020) + 0.001`, false},
+		{`Time.utc(2020) == 1577836800`, false},
+		{`Time.utc(2020) == Time.utc(2020).localtime`, true},
+		{`[Time.utc(2021), Time.utc(2019), Time.utc(2020)].sort.map do |t| t.year end`, []interface{}{2019, 2020, 2021}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestTimeArithmeticAndComparisonFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`Time.utc(2020) + "1"`, "TypeError: Expect argument to be Numeric. got: String", 1},
+		{`Time.utc(2020) + Time.utc(2020)`, "TypeError: Expect argument to be Numeric. got: Time", 1},
+		{`Time.utc(2020) - nil`, "TypeError: Expect argument to be Numeric. got: Null", 1},
+		{`Time.utc(2020) < 1`, "ArgumentError: comparison of Time with Integer failed", 1},
+		{`Time.utc(2020) >= "2020"`, "ArgumentError: comparison of Time with String failed", 1},
+		{`Time.utc(2020).strftime(1)`, "TypeError: Expect argument to be String. got: Integer", 1},
+		{`Time.utc(2020).year(1)`, "ArgumentError: Expect 0 argument(s). got: 1", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestTimeZones(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("JST", 9*60*60)
+	defer func() { time.Local = local }()
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`Time.local(2020, 1, 2).to_s`, "2020-01-02 00:00:00 +0900"},
+		{`Time.local(2020, 1, 2).utc.to_s`, "2020-01-01 15:00:00 UTC"},
+		{`Time.local(2020, 1, 2).utc?`, false},
+		{`Time.local(2020).to_i - Time.utc(2020).to_i`, -32400},
+		{`Time.utc(2020).localtime.hour`, 9},
+		{`Time.at(0).to_s`, "1970-01-01 09:00:00 +0900"},
+		{`Time.now.zone`, "JST"},
+		{`Time.now.utc_offset`, 32400},
+		{`(Time.local(2020) + 60).to_s`, "2020-01-01 00:01:00 +0900"},
+		{`Time.local(2020).strftime("%H %Z %z %:z")`, "00 JST +0900 +09:00"},
+		{`Time.local(2020, 1, 1, 9) == Time.utc(2020)`, true},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
@@ -0,0 +1,97 @@
+package vm
+
+// TraceEventKind tells what a TraceEvent reports
+type TraceEventKind int
+
+const (
+	// TraceCall is reported when a method is called, before its body runs
+	TraceCall TraceEventKind = iota
+	// TraceReturn is reported when a method returns, or when an error is raised through it
+	TraceReturn
+	// TraceLine is reported when a method, block or class body starts running a new source line
+	TraceLine
+)
+
+var traceEventKindNames = map[TraceEventKind]string{
+	TraceCall:   "call",
+	TraceReturn: "return",
+	TraceLine:   "line",
+}
+
+func (k TraceEventKind) String() string {
+	return traceEventKindNames[k]
+}
+
+// TraceEvent describes what the vm is running when the trace function is called
+type TraceEvent struct {
+	Kind TraceEventKind
+	// MethodName is the called method for call and return events.
+	// For line events it's the method the line is in, the class name in a class body, or `ProgramStart` at the top level.
+	MethodName string
+	FileName   string
+	// SourceLine is the line of the method call for call and return events
+	SourceLine int
+	// Self is the receiver of the method, or self of the running frame for line events
+	Self Object
+	// Depth is the number of call frames on the thread's stack, including the method's own frame for call and return events
+	Depth int
+	// Builtin is true when the called method is implemented in Go
+	Builtin bool
+}
+
+// SetTraceFunc makes the vm call the function on every method call and return, and every new source line it runs.
+// This is a hook for building step debuggers and tracers. The function is called on the thread running the code,
+// so it's called from several goroutines if the program uses threads. Pass nil to stop tracing.
+// It's safe to call while the program is running.
+func (vm *VM) SetTraceFunc(fn func(event TraceEvent)) {
+	vm.traceFunc.Store(fn)
+}
+
+// tracer returns the trace function, or nil if tracing is off
+func (vm *VM) tracer() func(event TraceEvent) {
+	fn, _ := vm.traceFunc.Load().(func(event TraceEvent))
+	return fn
+}
+
+// methodTraceEvent returns the event of the method running in the top call frame, to be reported with trace
+// when it's called and when it returns
+func (t *Thread) methodTraceEvent(methodName string, self Object, fileName string, sourceLine int, builtin bool) TraceEvent {
+	return TraceEvent{
+		MethodName: methodName,
+		FileName:   fileName,
+		SourceLine: sourceLine,
+		Self:       self,
+		Depth:      t.callFrameStack.pointer,
+		Builtin:    builtin,
+	}
+}
+
+// trace reports the event as the kind, unless tracing has been turned off since the event was made
+func (vm *VM) trace(event TraceEvent, kind TraceEventKind) {
+	fn := vm.tracer()
+
+	if fn == nil {
+		return
+	}
+
+	event.Kind = kind
+	fn(event)
+}
+
+// traceLine reports a line event of the frame
+func (t *Thread) traceLine(cf *normalCallFrame, sourceLine int) {
+	// A block runs as part of the method that defines it
+	scope := cf
+
+	for scope.IsBlock() && scope.EP() != nil {
+		scope = scope.EP()
+	}
+
+	t.vm.trace(TraceEvent{
+		MethodName: scope.instructionSet.name,
+		FileName:   cf.FileName(),
+		SourceLine: sourceLine,
+		Self:       cf.Self(),
+		Depth:      t.callFrameStack.pointer,
+	}, TraceLine)
+}
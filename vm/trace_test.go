@@ -0,0 +1,136 @@
+package vm
+
+import (
+	"fmt"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+func TestTraceFunc(t *testing.T) {
+	input := `
+def add(a, b)
+  a + b
+end
+
+x = add(1, 2)
+`
+	var events []string
+
+	v := initTestVM()
+	v.SetTraceFunc(func(event TraceEvent) {
+		events = append(events, fmt.Sprintf("%s %s %d %d %t", event.Kind, event.MethodName, event.SourceLine, event.Depth, event.Builtin))
+	})
+	evaluated := v.testEval(t, input, getFilename())
+	VerifyExpected(t, 0, evaluated, 3)
+
+	expected := []string{
+		"line ProgramStart 2 1 false",
+		"line ProgramStart 6 1 false",
+		"call add 6 2 false",
+		"line add 3 2 false",
+		"call + 3 3 true",
+		"return + 3 3 true",
+		"return add 6 2 false",
+	}
+
+	if !reflect.DeepEqual(events, expected) {
+		t.Fatalf("Expect trace events:\n%v\ngot:\n%v", expected, events)
+	}
+}
+
+func TestTraceFuncWithBlockAndError(t *testing.T) {
+	input := `
+def run
+  [1].each do |i|
+    i.foo
+  end
+end
+
+run
+`
+	var events []string
+
+	v := initTestVM()
+	v.SetTraceFunc(func(event TraceEvent) {
+		if event.Kind != TraceLine || event.MethodName == "run" {
+			events = append(events, fmt.Sprintf("%s %s %d", event.Kind, event.MethodName, event.SourceLine))
+		}
+	})
+	evaluated := v.testEval(t, input, getFilename())
+	checkErrorMsg(t, 0, evaluated, "NoMethodError: Undefined Method 'foo' for 1")
+
+	// The block's lines are reported as `run`'s, and the methods the error is raised through still return
+	expected := []string{
+		"call run 8",
+		"line run 3",
+		"call each 3",
+		"line run 4",
+		"return each 3",
+		"return run 8",
+	}
+
+	if !reflect.DeepEqual(events, expected) {
+		t.Fatalf("Expect trace events:\n%v\ngot:\n%v", expected, events)
+	}
+}
+
+func TestTraceFuncSetWhileRunning(t *testing.T) {
+	input := `
+c = Channel.new
+
+20.times do |i|
+  thread do
+    c.deliver(i)
+  end
+end
+
+r = 0
+20.times do
+  r = r + c.receive
+end
+
+r
+`
+	var count int64
+	trace := func(event TraceEvent) { atomic.AddInt64(&count, 1) }
+
+	v := initTestVM()
+	v.SetTraceFunc(trace)
+	done := make(chan bool)
+
+	// Tracing can be turned on and off while the threads are running
+	go func() {
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			if i%2 == 0 {
+				v.SetTraceFunc(nil)
+			} else {
+				v.SetTraceFunc(trace)
+			}
+		}
+	}()
+
+	evaluated := v.testEval(t, input, getFilename())
+	close(done)
+	VerifyExpected(t, 0, evaluated, 190)
+}
+
+func TestTraceFuncUnset(t *testing.T) {
+	called := false
+
+	v := initTestVM()
+	v.SetTraceFunc(func(event TraceEvent) { called = true })
+	v.SetTraceFunc(nil)
+	evaluated := v.testEval(t, `1 + 1`, getFilename())
+	VerifyExpected(t, 0, evaluated, 2)
+
+	if called {
+		t.Fatal("Expect the trace function not to be called after it's unset")
+	}
+}
@@ -25,6 +25,8 @@ func VerifyExpected(t *testing.T, i int, evaluated Object, expected interface{})
 		verifyBooleanObject(t, i, evaluated, expected)
 	case []interface{}:
 		verifyArrayObject(t, i, evaluated, expected)
+	case canonical:
+		verifyCanonicalObject(t, i, evaluated, expected)
 	case nil:
 		verifyNullObject(t, i, evaluated)
 	default:
@@ -32,6 +34,21 @@ func VerifyExpected(t *testing.T, i int, evaluated Object, expected interface{})
 	}
 }
 
+// canonical is the expected canonical form of an object graph, see Canonicalize
+type canonical string
+
+func verifyCanonicalObject(t *testing.T, i int, obj Object, expected canonical) bool {
+	t.Helper()
+	result := Canonicalize(obj)
+
+	if result != string(expected) {
+		t.Errorf("At test case %d: object has wrong canonical form. expect=%s, got=%s", i, expected, result)
+		return false
+	}
+
+	return true
+}
+
 func verifyIntegerObject(t *testing.T, i int, obj Object, expected int) bool {
 	t.Helper()
 	switch result := obj.(type) {
@@ -89,7 +106,7 @@ func verifyStringObject(t *testing.T, i int, obj Object, expected string) bool {
 	var fuzStr string
 	switch result := obj.(type) {
 	case *StringObject:
-		re, _ := regexp2.Compile("(?<=#<[a-zA-Z0-9_]+:)[0-9]{12}(?=[ ]>?)", 0)
+		re, _ := regexp2.Compile("(?<=#<[a-zA-Z0-9_]+:)[0-9]+(?=[ ]>?)", 0)
 		fuzStr, _ = re.Replace(result.value, "##OBJECTID##", 0, -1)
 		if fuzStr != expected {
 			t.Errorf("At test case %d: object has wrong value. expect=%q, got=%q", i, expected, result.value)
@@ -174,16 +191,7 @@ func verifyConcurrentHashObject(t *testing.T, index int, objectResult Object, ex
 		return false
 	}
 
-	pairs := make(map[string]Object)
-
-	iterator := func(key, value interface{}) bool {
-		pairs[key.(string)] = value.(Object)
-		return true
-	}
-
-	result.internalMap.Range(iterator)
-
-	return _checkHashPairs(t, pairs, expected)
+	return _checkHashPairs(t, result.pairs(), expected)
 }
 
 // Tests a Hash Object, with a few limitations:
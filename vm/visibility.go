@@ -0,0 +1,167 @@
+package vm
+
+import (
+	"strings"
+
+	"github.com/goby-lang/goby/compiler/bytecode"
+	"github.com/goby-lang/goby/vm/classes"
+	"github.com/goby-lang/goby/vm/errors"
+)
+
+// visibility decides who can call a method. Methods are public unless they're made private or protected.
+type visibility uint8
+
+const (
+	publicMethod visibility = iota
+	privateMethod
+	protectedMethod
+)
+
+var visibilityNames = map[visibility]string{
+	publicMethod:    "public",
+	privateMethod:   "private",
+	protectedMethod: "protected",
+}
+
+// methodVisibility returns the visibility of a method found on a class
+func methodVisibility(method Object) visibility {
+	switch m := method.(type) {
+	case *MethodObject:
+		return m.visibility
+	case *BuiltinMethodObject:
+		return m.visibility
+	default:
+		return publicMethod
+	}
+}
+
+// methodOwner returns the class a method is defined on
+func methodOwner(method Object) *RClass {
+	switch m := method.(type) {
+	case *MethodObject:
+		return m.owner
+	case *BuiltinMethodObject:
+		return m.owner
+	default:
+		return nil
+	}
+}
+
+// canCallMethod reports whether the method can be called on a receiver written like the kind, from the caller's self.
+//
+// - A private method can only be called without a receiver, or on `self` if it's a writer like `self.foo = 1`.
+// - A protected method can also be called on another receiver if the caller's self is an instance of the method's owner.
+func canCallMethod(method Object, methodName string, receiverKind uint8, caller Object) bool {
+	switch methodVisibility(method) {
+	case privateMethod:
+		return receiverKind == bytecode.ImplicitReceiver ||
+			receiverKind == bytecode.SelfReceiver && strings.HasSuffix(methodName, "=")
+	case protectedMethod:
+		if receiverKind != bytecode.ExplicitReceiver {
+			return true
+		}
+
+		return caller != nil && isInstanceOf(caller, methodOwner(method))
+	default:
+		return true
+	}
+}
+
+// isInstanceOf reports whether the object's class or any of its ancestors is the class, like `is_a?` does
+func isInstanceOf(obj Object, c *RClass) bool {
+	if c == nil {
+		return false
+	}
+
+	if obj.SingletonClass() == c {
+		return true
+	}
+
+	for _, ancestor := range obj.Class().ancestors() {
+		if ancestor == c {
+			return true
+		}
+	}
+
+	return false
+}
+
+// setMethodVisibility changes the visibility of the class's method.
+// An inherited method is copied to the class with the new visibility, so the superclass isn't affected.
+func (t *Thread) setMethodVisibility(c *RClass, name string, v visibility, sourceLine int) *Error {
+	switch m := c.lookupMethod(name).(type) {
+	case *MethodObject:
+		changed := *m
+		changed.visibility = v
+		c.Methods.set(name, &changed)
+	case *BuiltinMethodObject:
+		changed := *m
+		changed.visibility = v
+		c.Methods.set(name, &changed)
+	default:
+		return t.vm.InitErrorObject(errors.NameError, sourceLine, errors.UndefinedMethodForClass, name, c.Name)
+	}
+
+	return nil
+}
+
+// visibilityMethod builds `private`, `protected` and `public`. Without arguments, the methods defined after it
+// in the class body get the visibility. Otherwise the named methods get it.
+func visibilityMethod(v visibility) builtinMethodBody {
+	return func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+		class, ok := receiver.(*RClass)
+
+		if !ok {
+			return t.vm.InitNoMethodError(sourceLine, visibilityNames[v], receiver)
+		}
+
+		if len(args) == 0 {
+			// The frame under the method's own frame is the class body that calls it
+			if cf, ok := t.callFrameStack.callFrames[t.callFrameStack.pointer-2].(*normalCallFrame); ok {
+				cf.defaultVisibility = v
+			}
+
+			return NULL
+		}
+
+		for i, arg := range args {
+			name, ok := arg.(*StringObject)
+
+			if !ok {
+				return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormatNum, i+1, classes.StringClass, arg.Class().Name)
+			}
+
+			if err := t.setMethodVisibility(class, name.value, v, sourceLine); err != nil {
+				return err
+			}
+		}
+
+		if len(args) == 1 {
+			return args[0]
+		}
+
+		return t.vm.InitArrayObject(args)
+	}
+}
+
+// respondTo implements `respond_to?`. Private and protected methods are only counted if the second argument is truthy.
+func (t *Thread) respondTo(receiver Object, args []Object, sourceLine int) Object {
+	if len(args) < 1 || len(args) > 2 {
+		return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgumentRange, 1, 2, len(args))
+	}
+
+	name, ok := args[0].(*StringObject)
+
+	if !ok {
+		return t.vm.InitErrorObject(errors.TypeError, sourceLine, errors.WrongArgumentTypeFormat, classes.StringClass, args[0].Class().Name)
+	}
+
+	method := receiver.findMethod(name.value)
+
+	if method == nil {
+		return FALSE
+	}
+
+	includePrivate := len(args) == 2 && args[1].isTruthy()
+	return toBooleanObject(includePrivate || methodVisibility(method) == publicMethod)
+}
@@ -0,0 +1,304 @@
+package vm
+
+import (
+	"testing"
+)
+
+func TestMethodVisibility(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		// a private method can be called without a receiver
+		{`
+		class Foo
+		  def bar
+		    baz
+		  end
+
+		  private
+
+		  def baz
+		    10
+		  end
+		end
+
+		Foo.new.bar
+		`, 10},
+		// a private writer can be called on self
+		{`
+		class Foo
+		  def bar
+		    self.baz = 5
+		    @baz
+		  end
+
+		  private
+
+		  def baz=(v)
+		    @baz = v
+		  end
+		end
+
+		Foo.new.bar
+		`, 5},
+		// `public` switches the default back
+		{`
+		class Foo
+		  private
+
+		  def bar; end
+
+		  public
+
+		  def baz
+		    1
+		  end
+		end
+
+		Foo.new.baz
+		`, 1},
+		// the default only lasts until the end of the class body
+		{`
+		class Foo
+		  private
+		end
+
+		class Foo
+		  def bar
+		    1
+		  end
+		end
+
+		Foo.new.bar
+		`, 1},
+		{`
+		class Foo
+		  def bar
+		    2
+		  end
+
+		  private :bar
+		end
+
+		Foo.new.send(:bar)
+		`, 2},
+		{`
+		class Foo
+		  private
+
+		  def bar
+		    3
+		  end
+
+		  public :bar
+		end
+
+		Foo.new.public_send(:bar)
+		`, 3},
+		// making an inherited method private doesn't affect the superclass
+		{`
+		class Foo
+		  def bar
+		    4
+		  end
+		end
+
+		class Bar < Foo
+		  private :bar
+		end
+
+		Foo.new.bar
+		`, 4},
+		// a protected method can be called on another instance of the class
+		{`
+		class Account
+		  def initialize(balance)
+		    @balance = balance
+		  end
+
+		  def >(other)
+		    balance > other.balance
+		  end
+
+		  protected
+
+		  def balance
+		    @balance
+		  end
+		end
+
+		Account.new(2) > Account.new(1)
+		`, true},
+		// and from an instance of a subclass
+		{`
+		class Foo
+		  def initialize(v)
+		    @v = v
+		  end
+
+		  protected
+
+		  def v
+		    @v
+		  end
+		end
+
+		class Bar < Foo
+		  def peek(other)
+		    other.v
+		  end
+		end
+
+		Bar.new(1).peek(Foo.new(5))
+		`, 5},
+		{`
+		class Foo
+		  def bar
+		    self.baz
+		  end
+
+		  protected
+
+		  def baz
+		    6
+		  end
+		end
+
+		Foo.new.bar
+		`, 6},
+		{`
+		class Foo
+		  private
+
+		  def bar; end
+
+		  protected
+
+		  def baz; end
+		end
+
+		f = Foo.new
+		[f.respond_to?(:bar), f.respond_to?(:baz), f.respond_to?(:bar, true), f.respond_to?(:baz, true)]
+		`, []interface{}{false, false, true, true}},
+		{`
+		class Foo
+		  private
+
+		  def bar; end
+		end
+
+		Foo.new.methods.include?("bar")
+		`, false},
+		{`
+		class Foo
+		  private :to_s
+		end
+
+		Foo.new.respond_to?(:to_s)
+		`, false},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestMethodVisibilityFail(t *testing.T) {
+	testsFail := []struct {
+		input       string
+		expected    string
+		expectedCFP int
+		expectedSP  int
+	}{
+		{`
+		class Foo
+		  private
+
+		  def bar; end
+		end
+
+		Foo.new.bar
+		`, "NoMethodError: private method 'bar' called for #<Foo:##OBJECTID## >", 1, 1},
+		// a private method that isn't a writer can't be called on self
+		{`
+		class Foo
+		  def bar
+		    self.baz
+		  end
+
+		  private
+
+		  def baz; end
+		end
+
+		Foo.new.bar
+		`, "NoMethodError: private method 'baz' called for #<Foo:##OBJECTID## >", 2, 2},
+		{`
+		class Foo
+		  def bar; end
+
+		  private :bar
+		end
+
+		Foo.new.public_send(:bar)
+		`, "NoMethodError: private method 'bar' called for #<Foo:##OBJECTID## >", 2, 1},
+		{`
+		class Foo
+		  protected
+
+		  def bar; end
+		end
+
+		Foo.new.bar
+		`, "NoMethodError: protected method 'bar' called for #<Foo:##OBJECTID## >", 1, 1},
+		// a protected method can't be called from an instance of another class
+		{`
+		class Foo
+		  protected
+
+		  def bar; end
+		end
+
+		class Baz
+		  def call(foo)
+		    foo.bar
+		  end
+		end
+
+		Baz.new.call(Foo.new)
+		`, "NoMethodError: protected method 'bar' called for #<Foo:##OBJECTID## >", 2, 3},
+		{`
+		class Foo
+		  protected
+
+		  def bar; end
+		end
+
+		Foo.new.public_send(:bar)
+		`, "NoMethodError: protected method 'bar' called for #<Foo:##OBJECTID## >", 2, 1},
+		{`
+		class Foo
+		  private :bar
+		end
+		`, "NameError: undefined method 'bar' for class 'Foo'", 2, 1},
+		{`
+		class Foo
+		  private 1
+		end
+		`, "TypeError: Expect argument #1 to be String. got: Integer", 2, 1},
+		{`1.respond_to?(:to_s, true, 1)`, "ArgumentError: Expect 1 to 2 argument(s). got: 3", 1, 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkFuzzifiedErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, tt.expectedSP)
+	}
+}
@@ -2,6 +2,8 @@ package vm
 
 import (
 	"fmt"
+	"hash/maphash"
+	"io"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -35,17 +37,6 @@ type isTable map[string][]*instructionSet
 
 type filename = string
 
-var standardLibraries = map[string]func(*VM){
-	"net/http":           initHTTPClass,
-	"net/simple_server":  initSimpleServerClass,
-	"uri":                initURIClass,
-	"json":               initJSONClass,
-	"concurrent/array":   initConcurrentArrayClass,
-	"concurrent/hash":    initConcurrentHashClass,
-	"concurrent/rw_lock": initConcurrentRWLockClass,
-	"spec":               initSpecClass,
-}
-
 // VM represents a stack based virtual machine.
 type VM struct {
 	mainObj     *RObject
@@ -59,6 +50,9 @@ type VM struct {
 	classISIndexTables map[filename]*isIndexTable
 	// block instruction set table
 	blockTables map[filename]map[string]*instructionSet
+	// instructionTableLock guards the instruction set tables above, which a thread loading a file updates
+	// while other threads may look instruction sets up
+	instructionTableLock sync.Mutex
 	// fileDir indicates executed file's directory
 	fileDir string
 	// args are command line arguments
@@ -78,11 +72,77 @@ type VM struct {
 	libFiles []string
 
 	threadCount int64
+
+	// threadPool holds the *threadPool that keeps threads for reusing when it's set, see SetThreadPool.
+	// It's an atomic.Value because any thread can acquire and release threads while the pool is set.
+	threadPool atomic.Value
+
+	// jsonSerializers maps class names to the JSONSerializers registered by the host
+	jsonSerializers sync.Map
+
+	// strictJSON makes `to_json` raise on values JSON can't represent
+	strictJSON bool
+
+	// strictDeprecations makes deprecated builtin methods raise instead of warning, see Deprecated
+	strictDeprecations bool
+
+	// deprecations keeps the call sites of the deprecated builtin methods that have been called
+	deprecations deprecationRegistry
+
+	// requireResolvers are consulted in order when a required file isn't a standard library
+	requireResolvers    []RequireResolver
+	requireResolverLock sync.RWMutex
+
+	// loadedFiles holds the paths of the files loaded by `require`
+	loadedFiles sync.Map
+
+	// loadedNativeLibraries holds the names of the native libraries loaded by `require`
+	loadedNativeLibraries sync.Map
+	// nativeLibraryLock makes threads requiring native libraries at the same time load them one at a time,
+	// so a library's classes are defined once, and only used after they're fully initialized
+	nativeLibraryLock sync.Mutex
+
+	// The classes of the `net/http` library, which are set when it's loaded
+	httpRequestClass  *RClass
+	httpResponseClass *RClass
+	httpClientClass   *RClass
+
+	// dnsTimeout is how long a `Net::DNS` lookup can take in nanoseconds, or 0 for defaultDNSTimeout.
+	// It's accessed atomically, since any thread can set it.
+	dnsTimeout int64
+
+	// concurrentHashClass is the Concurrent::Hash class, which is looked up whenever a Concurrent::Hash is created
+	concurrentHashClass *RClass
+
+	// timerRegistry keeps the running Concurrent::Timers, see Shutdown
+	timerRegistry timerRegistry
+
+	// hashSeed is the random seed hashKey hashes keys with, see hashKey
+	hashSeed maphash.Seed
+
+	// overflowPolicy decides what Integer arithmetic does on overflow
+	overflowPolicy IntegerOverflowPolicy
+
+	// stdout is where `puts`, `print` and `p` write to
+	stdout io.Writer
+
+	// stderr is where the vm writes warnings to
+	stderr io.Writer
+
+	// prettyPrintOptions configures how `pp` and the REPL print objects
+	prettyPrintOptions PrettyPrintOptions
+
+	// memoryBudget limits the memory scripts can use when it's set, see SetMemoryLimit
+	memoryBudget *memoryBudget
+
+	// traceFunc holds the func(TraceEvent) called on method calls, returns and new lines when it's set, see SetTraceFunc.
+	// It's an atomic.Value because tracing can be turned on and off while other threads are running.
+	traceFunc atomic.Value
 }
 
 // New initializes a vm to initialize state and returns it.
 func New(fileDir string, args []string) (vm *VM, e error) {
-	vm = &VM{args: args}
+	vm = &VM{args: args, stdout: os.Stdout, stderr: os.Stderr, hashSeed: maphash.MakeSeed(), prettyPrintOptions: defaultPrettyPrintOptions}
 	vm.mainThread.vm = vm
 	vm.threadCount++
 	vm.mode = parser.NormalMode
@@ -122,6 +182,16 @@ func New(fileDir string, args []string) (vm *VM, e error) {
 	return
 }
 
+// SetStdout makes `puts`, `print` and `p` write to the given writer instead of the standard output
+func (vm *VM) SetStdout(w io.Writer) {
+	vm.stdout = w
+}
+
+// SetStderr makes the vm write its warnings to the given writer instead of the standard error
+func (vm *VM) SetStderr(w io.Writer) {
+	vm.stderr = w
+}
+
 func (vm *VM) newThread() (t Thread) {
 	t.vm = vm
 	t.id = atomic.AddInt64(&vm.threadCount, 1)
@@ -161,12 +231,25 @@ func (vm *VM) assignLibPath() (err error) {
 
 // ExecInstructions accepts a sequence of bytecodes and use vm to evaluate them.
 func (vm *VM) ExecInstructions(sets []*bytecode.InstructionSet, fn string) {
+	if vm.memoryBudget != nil {
+		vm.memoryBudget.resetBaseline()
+	}
+
+	vm.mainThread.execInstructions(sets, fn)
+}
+
+// execInstructions evaluates the sequence of bytecodes on the thread, so a file required by a thread other than
+// the main one doesn't run on the main thread's stacks.
+// It returns the Goby error the bytecodes raise, unless the VM's mode exits on it.
+func (t *Thread) execInstructions(sets []*bytecode.InstructionSet, fn string) (raised *Error) {
+	vm := t.vm
 	translator := newInstructionTranslator(fn)
 	translator.vm = vm
 	translator.transferInstructionSets(sets)
 
 	// Keep instruction set table updated after parsed new files.
 	// TODO: Find more efficient way to do this.
+	vm.instructionTableLock.Lock()
 	for setType, table := range translator.setTable {
 		for name, is := range table {
 			vm.isTables[setType][name] = is
@@ -174,12 +257,14 @@ func (vm *VM) ExecInstructions(sets []*bytecode.InstructionSet, fn string) {
 	}
 
 	vm.blockTables[translator.filename] = translator.blockTable
+	vm.instructionTableLock.Unlock()
+
 	vm.SetClassISIndexTable(translator.filename)
 	vm.SetMethodISIndexTable(translator.filename)
 
 	cf := newNormalCallFrame(translator.program, translator.filename, 1)
 	cf.self = vm.mainObj
-	vm.mainThread.callFrameStack.push(cf)
+	t.callFrameStack.push(cf)
 
 	// here is the final destination of Goby errors at the VM level, and we don't deal with them at this point.
 	// we only decide how the user program should react to them.
@@ -195,6 +280,7 @@ func (vm *VM) ExecInstructions(sets []*bytecode.InstructionSet, fn string) {
 			// if the error is one of the Goby's errors, such as argument error, we need to handle it depending on the mode of execution.
 			// we need to handle it depends on the type of program execution
 		case *Error:
+			raised = err
 
 			// REPLMode: We handle the error inside the igb package, so don't need to do anything here
 			// TestMode: We should preserve the vm as it is and inspect its state via test helpers, so don't need to do anything here either
@@ -206,17 +292,23 @@ func (vm *VM) ExecInstructions(sets []*bytecode.InstructionSet, fn string) {
 		}
 	}()
 
-	vm.mainThread.startFromTopFrame()
+	t.startFromTopFrame()
+
+	return
 }
 
 // SetClassISIndexTable adds new instruction set's index table to vm.classISIndexTables
 func (vm *VM) SetClassISIndexTable(fn filename) {
+	vm.instructionTableLock.Lock()
 	vm.classISIndexTables[fn] = newISIndexTable()
+	vm.instructionTableLock.Unlock()
 }
 
 // SetMethodISIndexTable adds new instruction set's index table to vm.methodISIndexTables
 func (vm *VM) SetMethodISIndexTable(fn filename) {
+	vm.instructionTableLock.Lock()
 	vm.methodISIndexTables[fn] = newISIndexTable()
+	vm.instructionTableLock.Unlock()
 }
 
 // main object singleton methods -----------------------------------------------------
@@ -252,6 +344,8 @@ func (vm *VM) initConstants() {
 
 	// Init builtin classes
 	builtinClasses := []*RClass{
+		// Enumerable's library is loaded first, so the other libraries can include it
+		vm.initEnumerableModule(),
 		vm.initIntegerClass(),
 		vm.initFloatClass(),
 		vm.initStringClass(),
@@ -269,6 +363,12 @@ func (vm *VM) initConstants() {
 		vm.initMatchDataClass(),
 		vm.initGoMapClass(),
 		vm.initDecimalClass(),
+		vm.initBigIntegerClass(),
+		vm.initRationalClass(),
+		vm.initTimeClass(),
+		vm.initComparableModule(),
+		vm.initMarshalModule(),
+		vm.initVMModule(),
 	}
 
 	// Init error classes
@@ -285,7 +385,7 @@ func (vm *VM) initConstants() {
 		args = append(args, vm.InitStringObject(arg))
 	}
 
-	vm.objectClass.constants["ARGV"] = &Pointer{Target: vm.InitArrayObject(args)}
+	vm.objectClass.setConstant("ARGV", &Pointer{Target: vm.InitArrayObject(args)})
 
 	// Init ENV
 	envs := map[string]Object{}
@@ -295,10 +395,10 @@ func (vm *VM) initConstants() {
 		envs[pair[0]] = vm.InitStringObject(pair[1])
 	}
 
-	vm.objectClass.constants["ENV"] = &Pointer{Target: vm.InitHashObject(envs)}
-	vm.objectClass.constants["STDOUT"] = &Pointer{Target: vm.initFileObject(os.Stdout)}
-	vm.objectClass.constants["STDERR"] = &Pointer{Target: vm.initFileObject(os.Stderr)}
-	vm.objectClass.constants["STDIN"] = &Pointer{Target: vm.initFileObject(os.Stdin)}
+	vm.objectClass.setConstant("ENV", &Pointer{Target: vm.InitHashObject(envs)})
+	vm.objectClass.setConstant("STDOUT", &Pointer{Target: vm.initFileObject(os.Stdout)})
+	vm.objectClass.setConstant("STDERR", &Pointer{Target: vm.initFileObject(os.Stderr)})
+	vm.objectClass.setConstant("STDIN", &Pointer{Target: vm.initFileObject(os.Stdin)})
 }
 
 // TopLevelClass returns a specified top-level class (stored under the Object constant)
@@ -309,7 +409,7 @@ func (vm *VM) TopLevelClass(cn string) *RClass {
 		return objClass
 	}
 
-	return objClass.constants[cn].Target.(*RClass)
+	return objClass.lookupConstantInCurrentScope(cn).Target.(*RClass)
 }
 
 func (vm *VM) currentFilePath() string {
@@ -317,33 +417,31 @@ func (vm *VM) currentFilePath() string {
 	return frame.FileName()
 }
 
-// loadConstant makes sure we don't create a class twice.
+// loadConstant makes sure we don't create a class twice, even if threads load it at the same time.
 func (vm *VM) loadConstant(name string, isModule bool) *RClass {
-	var c *RClass
-	var ptr *Pointer
-
-	ptr = vm.objectClass.constants[name]
+	if ptr := vm.objectClass.lookupConstantInCurrentScope(name); ptr != nil {
+		return ptr.Target.(*RClass)
+	}
 
-	if ptr == nil {
-		if isModule {
-			c = vm.initializeClass(name)
-		} else {
-			c = vm.initializeModule(name)
-		}
+	var c *RClass
 
-		vm.objectClass.setClassConstant(c)
+	if isModule {
+		c = vm.initializeClass(name)
 	} else {
-		c = ptr.Target.(*RClass)
+		c = vm.initializeModule(name)
 	}
 
-	return c
+	// Another thread may have defined it since it was looked up
+	return vm.objectClass.loadOrStoreClassConstant(c)
 }
 
-func (vm *VM) lookupConstant(cf callFrame, constName string) (constant *Pointer) {
+// lookupConstant looks up the constant in the namespace on top of the thread's stack, then in the frame's scope
+func (t *Thread) lookupConstant(cf callFrame, constName string) (constant *Pointer) {
 	var namespace *RClass
 	var hasNamespace bool
 
-	top := vm.mainThread.Stack.top()
+	vm := t.vm
+	top := t.Stack.top()
 
 	if top == nil {
 		hasNamespace = false
@@ -362,7 +460,7 @@ func (vm *VM) lookupConstant(cf callFrame, constName string) (constant *Pointer)
 	constant = cf.lookupConstantUnderAllScope(constName)
 
 	if constant == nil {
-		constant = vm.objectClass.constants[constName]
+		constant = vm.objectClass.lookupConstantInCurrentScope(constName)
 	}
 
 	if constName == classes.ObjectClass {
@@ -415,6 +513,10 @@ func (vm *VM) testEval(t *testing.T, input, filepath string) Object {
 }
 
 func (vm *VM) checkArgTypes(args []Object, sourceLine int, types ...string) *Error {
+	if len(args) < len(types) {
+		return vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, len(types), len(args))
+	}
+
 	for i, expectedType := range types {
 		className := args[i].Class().Name
 
@@ -0,0 +1,54 @@
+package vm
+
+import (
+	"github.com/goby-lang/goby/vm/classes"
+	"github.com/goby-lang/goby/vm/errors"
+)
+
+// VM is a module for inspecting the vm that runs the script.
+var builtinVMModuleClassMethods = []*BuiltinMethodObject{
+	{
+		// Returns the call sites of deprecated builtin methods that have been called so far, in the order they were first called.
+		// Each of them is a hash with the method's name, the version it's deprecated since, its replacement,
+		// and the file and line it was called at. A test suite can check it's empty to make sure no deprecated method is used.
+		//
+		// ```ruby
+		// "Goby".start_with("Go")
+		// VM.deprecations
+		// # => [{ method: "String#start_with", since: "0.1.14", replacement: "start_with?", file: "script.gb", line: 1 }]
+		// ```
+		//
+		// @return [Array]
+		Name: "deprecations",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 0 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 0, len(args))
+			}
+
+			var elements []Object
+
+			for _, d := range t.vm.Deprecations() {
+				elements = append(elements, t.vm.InitHashObject(map[string]Object{
+					"method":      t.vm.InitStringObject(d.Method),
+					"since":       t.vm.InitStringObject(d.Since),
+					"replacement": t.vm.InitStringObject(d.Replacement),
+					"file":        t.vm.InitStringObject(d.File),
+					"line":        t.vm.InitIntegerObject(d.Line),
+				}))
+			}
+
+			return t.vm.InitArrayObject(elements)
+
+		},
+	},
+}
+
+// Internal functions ===================================================
+
+// Functions for initialization -----------------------------------------
+
+func (vm *VM) initVMModule() *RClass {
+	module := vm.initializeModule(classes.VMModule)
+	module.setBuiltinMethods(builtinVMModuleClassMethods, true)
+	return module
+}
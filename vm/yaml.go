@@ -0,0 +1,477 @@
+package vm
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/goby-lang/goby/vm/classes"
+	"github.com/goby-lang/goby/vm/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	invalidYAML  = "Invalid YAML: %s"
+	yamlMergeKey = "!!merge"
+)
+
+// YAML converts YAML documents to Goby objects and back, which is required by `require "yaml"`.
+// Mappings are loaded as Hashes, sequences as Arrays, and scalars as `nil`, booleans, Integers, Floats,
+// Strings or Times by their tags. The keys of a mapping are converted to Strings, like the ones of a Hash.
+//
+// ```ruby
+// require "yaml"
+//
+// config = YAML.load("name: goby\nports: [80, 443]\ndebug: false\n")
+// config["ports"] # => [80, 443]
+//
+// YAML.dump({ name: "goby", ports: [80, 443] })
+// # => "name: goby\nports:\n    - 80\n    - 443\n"
+// ```
+//
+// An anchored node is loaded once, and its aliases refer to the same object. The merge key `<<` is supported.
+//
+// ```ruby
+// config = YAML.load("
+// base: &base
+//   adapter: postgres
+// development:
+//   <<: *base
+//   database: dev
+// ")
+// config["development"]["adapter"] # => "postgres"
+// ```
+
+// Class methods --------------------------------------------------------
+var builtinYAMLClassMethods = []*BuiltinMethodObject{
+	{
+		// Returns the object dumped as a YAML document.
+		// An Array or Hash appearing more than once is dumped once with an anchor and referred to by aliases afterwards,
+		// so shared and cyclic structures can be dumped. The keys of a Hash are dumped in sorted order.
+		// It's a TypeError if the object, or an object it contains, isn't `nil`, a boolean, an Integer, a Float,
+		// a String, a Time, an Array or a Hash.
+		//
+		// ```ruby
+		// require "yaml"
+		//
+		// YAML.dump([1, "two", nil]) # => "- 1\n- two\n- null\n"
+		// YAML.dump("true")          # => "\"true\"\n"
+		// YAML.dump(1..2)            # => TypeError: Can't dump Range
+		// ```
+		//
+		// @param object [Object]
+		// @return [String]
+		Name: "dump",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			if len(args) != 1 {
+				return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+			}
+
+			e := &yamlEncoder{nodes: map[Object]*yaml.Node{}}
+			node, unsupported := e.encode(args[0])
+
+			if unsupported != nil {
+				return t.vm.InitErrorObject(errors.TypeError, sourceLine, cantDump, unsupported.Class().Name)
+			}
+
+			out, err := yaml.Marshal(node)
+
+			if err != nil {
+				return t.vm.InitErrorObject(errors.InternalError, sourceLine, err.Error())
+			}
+
+			return t.vm.InitStringObject(string(out))
+
+		},
+	},
+	{
+		// Returns the first document of the YAML String as an object, or `nil` if there's no document.
+		// It's an ArgumentError if the String isn't valid YAML.
+		//
+		// ```ruby
+		// require "yaml"
+		//
+		// YAML.load("- 1\n- 2.5\n- ~\n")   # => [1, 2.5, nil]
+		// YAML.load("a: {b: [true, no]}") # => { a: { b: [true, "no"] } }
+		// YAML.load("")                   # => nil
+		// ```
+		//
+		// @param yaml [String]
+		// @return [Object]
+		Name: "load",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			docs := t.loadYAML(args, sourceLine, 1)
+
+			if err, ok := docs.(*Error); ok {
+				return err
+			}
+
+			elems := docs.(*ArrayObject).Elements
+
+			if len(elems) == 0 {
+				return NULL
+			}
+
+			return elems[0]
+
+		},
+	},
+	{
+		// Returns every document of the YAML String, which are separated by `---`, as an Array.
+		// It's an ArgumentError if the String isn't valid YAML.
+		//
+		// ```ruby
+		// require "yaml"
+		//
+		// YAML.load_all("--- 1\n--- [a, b]\n") # => [1, ["a", "b"]]
+		// ```
+		//
+		// @param yaml [String]
+		// @return [Array]
+		Name: "load_all",
+		Fn: func(receiver Object, sourceLine int, t *Thread, args []Object, blockFrame *normalCallFrame) Object {
+			return t.loadYAML(args, sourceLine, 0)
+
+		},
+	},
+}
+
+// Internal functions ===================================================
+
+// Functions for initialization -----------------------------------------
+
+func initYAMLModule(vm *VM) {
+	module := vm.initializeModule("YAML")
+	module.setBuiltinMethods(builtinYAMLClassMethods, true)
+	vm.objectClass.setClassConstant(module)
+}
+
+// Other helper functions -----------------------------------------------
+
+// loadYAML decodes up to limit documents of the only argument, or all of them if limit is 0, and returns them as an Array
+func (t *Thread) loadYAML(args []Object, sourceLine int, limit int) Object {
+	if len(args) != 1 {
+		return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, errors.WrongNumberOfArgument, 1, len(args))
+	}
+
+	typeErr := t.vm.checkArgTypes(args, sourceLine, classes.StringClass)
+
+	if typeErr != nil {
+		return typeErr
+	}
+
+	source := args[0].(*StringObject).value
+
+	if err := t.reserveMemory(multipliedSize(len(source), yamlExpansion), "YAML.load", sourceLine); err != nil {
+		return err
+	}
+
+	decoder := yaml.NewDecoder(strings.NewReader(source))
+	docs := []Object{}
+
+	for limit == 0 || len(docs) < limit {
+		var node yaml.Node
+		err := decoder.Decode(&node)
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, invalidYAML, strings.TrimPrefix(err.Error(), "yaml: "))
+		}
+
+		d := &yamlDecoder{t: t, anchors: map[*yaml.Node]Object{}}
+		doc, decodeErr := d.decode(&node)
+
+		if decodeErr != "" {
+			return t.vm.InitErrorObject(errors.ArgumentError, sourceLine, invalidYAML, decodeErr)
+		}
+
+		docs = append(docs, doc)
+	}
+
+	return t.vm.InitArrayObject(docs)
+}
+
+// yamlDecoder converts the nodes of a YAML document to objects
+type yamlDecoder struct {
+	t *Thread
+	// anchors holds the objects the anchored nodes are converted to, which their aliases refer to
+	anchors map[*yaml.Node]Object
+}
+
+// decode returns the object the node is converted to, or the reason it can't be
+func (d *yamlDecoder) decode(n *yaml.Node) (Object, string) {
+	if obj, ok := d.anchors[n]; ok {
+		return obj, ""
+	}
+
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) == 0 {
+			return NULL, ""
+		}
+
+		return d.decode(n.Content[0])
+	case yaml.AliasNode:
+		return d.decode(n.Alias)
+	case yaml.SequenceNode:
+		arr := d.t.vm.InitArrayObject([]Object{})
+		d.anchors[n] = arr
+
+		for _, child := range n.Content {
+			elem, err := d.decode(child)
+
+			if err != "" {
+				return nil, err
+			}
+
+			arr.Elements = append(arr.Elements, elem)
+		}
+
+		return arr, ""
+	case yaml.MappingNode:
+		return d.decodeMapping(n)
+	case yaml.ScalarNode:
+		return d.decodeScalar(n)
+	}
+
+	return nil, fmt.Sprintf("unknown node at line %d", n.Line)
+}
+
+// decodeMapping returns the Hash of the mapping. The pairs of the mappings merged by `<<` are added first,
+// so the mapping's own pairs take their place.
+func (d *yamlDecoder) decodeMapping(n *yaml.Node) (Object, string) {
+	h := d.t.vm.InitHashObject(map[string]Object{})
+	d.anchors[n] = h
+
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+
+		if key.Kind != yaml.ScalarNode || key.ShortTag() != yamlMergeKey {
+			continue
+		}
+
+		sources := []*yaml.Node{value}
+
+		if value.Kind == yaml.SequenceNode {
+			sources = value.Content
+		}
+
+		for _, source := range sources {
+			merged, err := d.decode(source)
+
+			if err != "" {
+				return nil, err
+			}
+
+			mh, ok := merged.(*HashObject)
+
+			if !ok {
+				return nil, fmt.Sprintf("can't merge %s at line %d", merged.Class().Name, source.Line)
+			}
+
+			for k, v := range mh.Pairs {
+				if _, exists := h.Pairs[k]; !exists {
+					h.Pairs[k] = v
+				}
+			}
+		}
+	}
+
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+
+		if key.Kind == yaml.ScalarNode && key.ShortTag() == yamlMergeKey {
+			continue
+		}
+
+		k, err := d.decode(key)
+
+		if err != "" {
+			return nil, err
+		}
+
+		v, err := d.decode(value)
+
+		if err != "" {
+			return nil, err
+		}
+
+		if s, ok := k.(*StringObject); ok {
+			h.Pairs[s.value] = v
+		} else {
+			h.Pairs[k.ToString()] = v
+		}
+	}
+
+	return h, ""
+}
+
+// decodeScalar returns the object of the scalar's type, which is told by its tag
+func (d *yamlDecoder) decodeScalar(n *yaml.Node) (Object, string) {
+	vm := d.t.vm
+
+	switch n.ShortTag() {
+	case "!!null":
+		return NULL, ""
+	case "!!bool":
+		var b bool
+
+		if err := n.Decode(&b); err != nil {
+			return nil, yamlDecodeError(err)
+		}
+
+		return toBooleanObject(b), ""
+	case "!!int":
+		var i int
+
+		if err := n.Decode(&i); err != nil {
+			return nil, yamlDecodeError(err)
+		}
+
+		return vm.InitIntegerObject(i), ""
+	case "!!float":
+		var f float64
+
+		if err := n.Decode(&f); err != nil {
+			return nil, yamlDecodeError(err)
+		}
+
+		return vm.initFloatObject(f), ""
+	case "!!timestamp":
+		var tm time.Time
+
+		if err := n.Decode(&tm); err != nil {
+			return nil, yamlDecodeError(err)
+		}
+
+		return vm.initTimeObject(tm), ""
+	case "!!str", "!!binary":
+		var s string
+
+		if err := n.Decode(&s); err != nil {
+			return nil, yamlDecodeError(err)
+		}
+
+		return vm.InitStringObject(s), ""
+	}
+
+	// Scalars with other tags are loaded as they're written
+	return vm.InitStringObject(n.Value), ""
+}
+
+// yamlDecodeError returns the reason of the error yaml.v3 returns for a node it can't decode
+func yamlDecodeError(err error) string {
+	if typeErr, ok := err.(*yaml.TypeError); ok && len(typeErr.Errors) > 0 {
+		return strings.TrimPrefix(typeErr.Errors[0], "yaml: ")
+	}
+
+	return strings.TrimPrefix(err.Error(), "yaml: ")
+}
+
+// yamlEncoder converts objects to the nodes of a YAML document
+type yamlEncoder struct {
+	// nodes holds the nodes of the Arrays and Hashes encoded so far, so the ones appearing again are aliased
+	nodes   map[Object]*yaml.Node
+	anchors int
+}
+
+// encode returns the node of the object, or the first object that can't be encoded
+func (e *yamlEncoder) encode(obj Object) (*yaml.Node, Object) {
+	switch obj := obj.(type) {
+	case *NullObject:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	case *BooleanObject:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(obj.value)}, nil
+	case *IntegerObject:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(obj.value)}, nil
+	case *FloatObject:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: formatYAMLFloat(obj.value)}, nil
+	case *StringObject:
+		node := &yaml.Node{Kind: yaml.ScalarNode, Value: obj.value}
+
+		// Invalid UTF-8 is left untagged, so it's dumped as base64 with the binary tag
+		if utf8.ValidString(obj.value) {
+			node.Tag = "!!str"
+		}
+
+		return node, nil
+	case *TimeObject:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!timestamp", Value: obj.value.Format(time.RFC3339Nano)}, nil
+	case *ArrayObject, *HashObject:
+		if node, ok := e.nodes[obj]; ok {
+			return e.alias(node), nil
+		}
+	}
+
+	switch obj := obj.(type) {
+	case *ArrayObject:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		e.nodes[obj] = node
+
+		for _, elem := range obj.Elements {
+			child, unsupported := e.encode(elem)
+
+			if unsupported != nil {
+				return nil, unsupported
+			}
+
+			node.Content = append(node.Content, child)
+		}
+
+		return node, nil
+	case *HashObject:
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		e.nodes[obj] = node
+
+		for _, key := range obj.sortedKeys() {
+			value, unsupported := e.encode(obj.Pairs[key])
+
+			if unsupported != nil {
+				return nil, unsupported
+			}
+
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+		}
+
+		return node, nil
+	}
+
+	return nil, obj
+}
+
+// alias returns an alias of the node, which is anchored the first time it's aliased
+func (e *yamlEncoder) alias(node *yaml.Node) *yaml.Node {
+	if node.Anchor == "" {
+		e.anchors++
+		node.Anchor = fmt.Sprintf("id%03d", e.anchors)
+	}
+
+	return &yaml.Node{Kind: yaml.AliasNode, Value: node.Anchor, Alias: node}
+}
+
+// formatYAMLFloat formats the float so it's loaded as a Float, not as an Integer
+func formatYAMLFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return ".inf"
+	case math.IsInf(f, -1):
+		return "-.inf"
+	case math.IsNaN(f):
+		return ".nan"
+	}
+
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+
+	return s
+}
@@ -0,0 +1,283 @@
+package vm
+
+import (
+	"testing"
+)
+
+const yamlConfig = `
+require "yaml"
+
+config = YAML.load("
+defaults: &defaults
+  adapter: postgres
+  pool: 5
+  timeout: 2.5
+
+development:
+  <<: *defaults
+  database: goby_dev
+  pool: 10
+
+servers:
+  - host: web1.example.com
+    ports: [80, 443]
+    enabled: true
+  - host: web2.example.com
+    ports: []
+    enabled: no
+    note: ~
+")
+`
+
+func TestYAMLLoadMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{yamlConfig + `config["defaults"]["adapter"]`, "postgres"},
+		{yamlConfig + `config["defaults"]["timeout"]`, 2.5},
+		{yamlConfig + `config["development"]["adapter"]`, "postgres"},
+		{yamlConfig + `config["development"]["pool"]`, 10},
+		{yamlConfig + `config["defaults"]["pool"]`, 5},
+		{yamlConfig + `config["development"].keys.sort`, []interface{}{"adapter", "database", "pool", "timeout"}},
+		{yamlConfig + `config["servers"][0]["ports"]`, []interface{}{80, 443}},
+		{yamlConfig + `config["servers"][0]["enabled"]`, true},
+		// YAML 1.2 doesn't take `no` as a boolean
+		{yamlConfig + `config["servers"][1]["enabled"]`, "no"},
+		{yamlConfig + `config["servers"][1]["note"]`, nil},
+		{yamlConfig + `config["servers"][1].has_key?("note")`, true},
+		{`
+		require "yaml"
+		YAML.load("- 1\n- -2.5\n- ~\n- null\n- true\n- '3'\n- \"4\"\n- 0x1F\n- 1e3")
+		`, []interface{}{1, -2.5, nil, nil, true, "3", "4", 31, 1000.0}},
+		{`
+		require "yaml"
+		YAML.load("[.inf, -.Inf]").map do |f|
+		  f.to_s
+		end
+		`, []interface{}{"Infinity", "-Infinity"}},
+		{`
+		require "yaml"
+		YAML.load("- 2001-12-14\n- 2001-12-14t21:59:43.10-05:00").map do |t|
+		  t.class.name
+		end
+		`, []interface{}{"Time", "Time"}},
+		{`
+		require "yaml"
+		h = YAML.load("1: one\nnull: none\ntrue: yes")
+		[h["1"], h[""], h["true"]]
+		`, []interface{}{"one", "none", "yes"}},
+		{`
+		require "yaml"
+		h = YAML.load("text: |\n  line 1\n  line 2\nfolded: >\n  a\n  b\n")
+		[h["text"], h["folded"]]
+		`, []interface{}{"line 1\nline 2\n", "a b\n"}},
+		// scalars with unknown tags are loaded as Strings
+		{`
+		require "yaml"
+		YAML.load("!custom 42")
+		`, "42"},
+		{`
+		require "yaml"
+		YAML.load("")
+		`, nil},
+		{`
+		require "yaml"
+		YAML.load("# only a comment")
+		`, nil},
+		// the first document is loaded
+		{`
+		require "yaml"
+		YAML.load("--- 1\n--- 2\n")
+		`, 1},
+		// aliases refer to the same object
+		{`
+		require "yaml"
+		h = YAML.load("a: &list [1, 2]\nb: *list")
+		h["a"].object_id == h["b"].object_id
+		`, true},
+		{`
+		require "yaml"
+		a = YAML.load("&a [1, *a]")
+		a[1].object_id == a.object_id
+		`, true},
+		// the mapping's own pairs take the place of the merged ones
+		{`
+		require "yaml"
+		h = YAML.load("x: &x {a: 1, b: 1}\ny: &y {b: 2, c: 2}\nz:\n  c: 3\n  <<: [*x, *y]\n")
+		[h["z"]["a"], h["z"]["b"], h["z"]["c"]]
+		`, []interface{}{1, 1, 3}},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestYAMLLoadAllMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require "yaml"
+		d = YAML.load_all("--- 1\n--- [a, b]\n---\nc: d\n")
+		[d[0], d[1], d[2]["c"]]
+		`, []interface{}{1, []interface{}{"a", "b"}, "d"}},
+		{`
+		require "yaml"
+		YAML.load_all("")
+		`, []interface{}{}},
+		{`
+		require "yaml"
+		YAML.load_all("---\n...\n--- 2\n")
+		`, []interface{}{nil, 2}},
+		// anchors are scoped to their documents
+		{`
+		require "yaml"
+		d = YAML.load_all("--- &a [1]\n--- &a [1]\n")
+		d[0].object_id == d[1].object_id
+		`, false},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestYAMLDumpMethod(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require "yaml"
+		YAML.dump({ name: "goby", ports: [80, 443], debug: false })
+		`, "debug: false\nname: goby\nports:\n    - 80\n    - 443\n"},
+		{`
+		require "yaml"
+		YAML.dump([1, "two", nil, 1.0, -0.5])
+		`, "- 1\n- two\n- null\n- 1.0\n- -0.5\n"},
+		{`
+		require "yaml"
+		YAML.dump(["true", "1", "", "a: b", "x\ny"])
+		`, "- \"true\"\n- \"1\"\n- \"\"\n- 'a: b'\n- |-\n  x\n  y\n"},
+		{`
+		require "yaml"
+		YAML.dump({})
+		`, "{}\n"},
+		{`
+		require "yaml"
+		a = [1]
+		YAML.dump([a, a])
+		`, "- &id001\n  - 1\n- *id001\n"},
+		{`
+		require "yaml"
+		YAML.dump(Time.at(0).utc)
+		`, "1970-01-01T00:00:00Z\n"},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestYAMLRoundTrip(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`
+		require "yaml"
+		h = { name: "goby", version: 1, ratio: 0.25, tags: ["lang", "vm"], meta: { stars: 3, ok: true, none: nil } }
+		YAML.load(YAML.dump(h)) == h
+		`, true},
+		{`
+		require "yaml"
+		s = "Hello, 世界\n\tnext line"
+		YAML.load(YAML.dump(s)) == s
+		`, true},
+		{`
+		require "yaml"
+		YAML.load(YAML.dump(["1", "null", "yes", "1.5", "~"]))
+		`, []interface{}{"1", "null", "yes", "1.5", "~"}},
+		{`
+		require "yaml"
+		YAML.load(YAML.dump([2.0, 0.1, -3.0])).map do |f|
+		  f.class.name
+		end
+		`, []interface{}{"Float", "Float", "Float"}},
+		{`
+		require "yaml"
+		t = Time.at(1500000000).utc
+		YAML.load(YAML.dump(t)) == t
+		`, true},
+		// shared and cyclic structures are kept
+		{`
+		require "yaml"
+		h = { name: "parent" }
+		h[:self] = h
+		r = YAML.load(YAML.dump(h))
+		r["self"].object_id == r.object_id
+		`, true},
+		{`
+		require "yaml"
+		s = [1]
+		r = YAML.load(YAML.dump({ a: s, b: s }))
+		r["a"].object_id == r["b"].object_id
+		`, true},
+	}
+
+	for i, tt := range tests {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		VerifyExpected(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, 0)
+		v.checkSP(t, i, 1)
+	}
+}
+
+func TestYAMLMethodsFail(t *testing.T) {
+	testsFail := []errorTestCase{
+		{`require "yaml"
+		YAML.load`, "ArgumentError: Expect 1 argument(s). got: 0", 1},
+		{`require "yaml"
+		YAML.load(1)`, "TypeError: Expect argument to be String. got: Integer", 1},
+		{`require "yaml"
+		YAML.load("a: [1")`, "ArgumentError: Invalid YAML: line 1: did not find expected ',' or ']'", 1},
+		{`require "yaml"
+		YAML.load("a: *missing")`, "ArgumentError: Invalid YAML: unknown anchor 'missing' referenced", 1},
+		{`require "yaml"
+		YAML.load("!!int abc")`, "ArgumentError: Invalid YAML: cannot decode !!str `abc` as a !!int", 1},
+		{`require "yaml"
+		YAML.load("a: 1\n<<: [1]")`, "ArgumentError: Invalid YAML: can't merge Integer at line 2", 1},
+		{`require "yaml"
+		YAML.load_all("--- 1\n--- [")`, "ArgumentError: Invalid YAML: line 2: did not find expected node content", 1},
+		{`require "yaml"
+		YAML.dump`, "ArgumentError: Expect 1 argument(s). got: 0", 1},
+		{`require "yaml"
+		YAML.dump([1, { a: 1..2 }])`, "TypeError: Can't dump Range", 1},
+		{`require "yaml"
+		YAML.dump(Object.new)`, "TypeError: Can't dump Object", 1},
+	}
+
+	for i, tt := range testsFail {
+		v := initTestVM()
+		evaluated := v.testEval(t, tt.input, getFilename())
+		checkErrorMsg(t, i, evaluated, tt.expected)
+		v.checkCFP(t, i, tt.expectedCFP)
+		v.checkSP(t, i, 1)
+	}
+}